@@ -0,0 +1,279 @@
+// Package archive reads and writes the backup archive of a ledger.  An archive is NDJSON, one JSON object per line:
+// a header naming the format and its version, then a record per row of each table, then an end record with the
+// number of records of each type, so a truncated archive is detected.
+//
+//	{"format":"mimirledger-archive","version":1,"exportedAt":"...","recordTypes":["setting","account",...]}
+//	{"type":"account","data":{"accountID":1,...}}
+//	{"type":"end","data":{"counts":{"account":12,...}}}
+package archive
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Format names the archive format in the header
+const Format = "mimirledger-archive"
+
+// Version is the version of the archive format that is written, and the newest that can be read
+const Version = 1
+
+// RecordType is the type of a record, one per table
+type RecordType string
+
+const (
+	RecordTypeSetting        = RecordType("setting")
+	RecordTypeAccount        = RecordType("account")
+	RecordTypeTransaction    = RecordType("transaction")
+	RecordTypeDebitCredit    = RecordType("debitCredit")
+	RecordTypeReport         = RecordType("report")
+	RecordTypeReportTemplate = RecordType("reportTemplate")
+	recordTypeEnd            = RecordType("end")
+)
+
+// RecordTypes are the record types in the order they are written, a record only refers to records of earlier types
+var RecordTypes = []RecordType{RecordTypeSetting, RecordTypeAccount, RecordTypeTransaction, //nolint:gochecknoglobals
+	RecordTypeDebitCredit, RecordTypeReport, RecordTypeReportTemplate}
+
+// Header is the first line of an archive
+type Header struct {
+	Format      string       `json:"format"`
+	Version     int          `json:"version"`
+	ExportedAt  time.Time    `json:"exportedAt"`
+	RecordTypes []RecordType `json:"recordTypes"`
+}
+
+// Record is a line of an archive, Data is one of the record structs
+type Record struct {
+	Type RecordType      `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// end is the data of the last record
+type end struct {
+	Counts map[RecordType]int `json:"counts"`
+}
+
+// Setting is a row of settings
+type Setting struct {
+	SettingName  string `json:"settingName"`
+	SettingValue string `json:"settingValue"`
+}
+
+// Account is a row of transaction_accounts, balances and subtotals are not archived as they are recalculated
+type Account struct {
+	AccountID            uint64     `json:"accountID"`
+	AccountParent        uint64     `json:"accountParent"`
+	AccountName          string     `json:"accountName"`
+	AccountFullName      string     `json:"accountFullName"`
+	AccountMemo          string     `json:"accountMemo"`
+	AccountCurrent       bool       `json:"accountCurrent"`
+	AccountLeft          uint64     `json:"accountLeft"`
+	AccountRight         uint64     `json:"accountRight"`
+	AccountDecimals      uint64     `json:"accountDecimals"`
+	AccountReconcileDate *time.Time `json:"accountReconcileDate"`
+	AccountFlagged       bool       `json:"accountFlagged"`
+	AccountLocked        bool       `json:"accountLocked"`
+	AccountOpenDate      time.Time  `json:"accountOpenDate"`
+	AccountCloseDate     *time.Time `json:"accountCloseDate"`
+	AccountCode          *string    `json:"accountCode"`
+	AccountSign          string     `json:"accountSign"`
+	AccountType          string     `json:"accountType"`
+}
+
+// Transaction is a row of transaction_main
+type Transaction struct {
+	TransactionID            uint64     `json:"transactionID"`
+	TransactionDate          time.Time  `json:"transactionDate"`
+	TransactionReconcileDate *time.Time `json:"transactionReconcileDate"`
+	TransactionComment       string     `json:"transactionComment"`
+	TransactionAmount        uint64     `json:"transactionAmount"`
+	TransactionReference     string     `json:"transactionReference"`
+	IsReconciled             bool       `json:"isReconciled"`
+	IsSplit                  bool       `json:"isSplit"`
+}
+
+// DebitCredit is a row of transaction_debit_credit.  Archives written before lines were reconciled on their own have
+// no isReconciled, and their lines take the reconciled state of their transaction.
+type DebitCredit struct {
+	TransactionDCID          uint64     `json:"transactionDCID"`
+	TransactionID            uint64     `json:"transactionID"`
+	AccountID                uint64     `json:"accountID"`
+	TransactionDCAmount      uint64     `json:"transactionDCAmount"`
+	DebitOrCredit            string     `json:"debitOrCredit"`
+	IsReconciled             *bool      `json:"isReconciled,omitempty"`
+	TransactionReconcileDate *time.Time `json:"transactionReconcileDate,omitempty"`
+}
+
+// Report is a row of reports, the body is kept as it is stored
+type Report struct {
+	ReportID   uint64          `json:"reportID"`
+	ReportName string          `json:"reportName"`
+	ReportBody json.RawMessage `json:"reportBody"`
+}
+
+// ReportTemplate is a row of report_templates
+type ReportTemplate struct {
+	TemplateID   uint64 `json:"templateID"`
+	TemplateName string `json:"templateName"`
+	TemplateType string `json:"templateType"`
+	TemplateBody string `json:"templateBody"`
+}
+
+var ErrArchiveInvalid = errors.New("archive is invalid")
+var ErrArchiveVersionUnsupported = errors.New("archive version is not supported")
+
+// Writer writes an archive
+type Writer struct {
+	writer  *bufio.Writer
+	encoder *json.Encoder
+	counts  map[RecordType]int
+}
+
+// NewWriter writes the header of an archive
+func NewWriter(writer io.Writer, exportedAt time.Time) (*Writer, error) {
+	buffered := bufio.NewWriter(writer)
+
+	myWriter := Writer{writer: buffered, encoder: json.NewEncoder(buffered), counts: make(map[RecordType]int)}
+
+	header := Header{Format: Format, Version: Version, ExportedAt: exportedAt, RecordTypes: RecordTypes}
+	if err := myWriter.encoder.Encode(header); err != nil {
+		return nil, fmt.Errorf("encoder.Encode:%w", err)
+	}
+
+	return &myWriter, nil
+}
+
+// Write writes a record, data is one of the record structs
+func (c *Writer) Write(recordType RecordType, data any) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("json.Marshal:%w", err)
+	}
+
+	if err = c.encoder.Encode(Record{Type: recordType, Data: body}); err != nil {
+		return fmt.Errorf("encoder.Encode:%w", err)
+	}
+
+	c.counts[recordType]++
+
+	return nil
+}
+
+// Close writes the end record and flushes the archive
+func (c *Writer) Close() error {
+	body, err := json.Marshal(end{Counts: c.counts})
+	if err != nil {
+		return fmt.Errorf("json.Marshal:%w", err)
+	}
+
+	if err = c.encoder.Encode(Record{Type: recordTypeEnd, Data: body}); err != nil {
+		return fmt.Errorf("encoder.Encode:%w", err)
+	}
+
+	if err = c.writer.Flush(); err != nil {
+		return fmt.Errorf("writer.Flush:%w", err)
+	}
+
+	return nil
+}
+
+// Reader reads an archive
+type Reader struct {
+	Header  Header
+	decoder *json.Decoder
+	counts  map[RecordType]int
+	known   map[RecordType]bool
+	done    bool
+}
+
+// NewReader reads and checks the header of an archive
+func NewReader(reader io.Reader) (*Reader, error) {
+	myReader := Reader{
+		Header:  Header{Format: "", Version: 0, ExportedAt: time.Time{}, RecordTypes: nil},
+		decoder: json.NewDecoder(reader),
+		counts:  make(map[RecordType]int),
+		known:   make(map[RecordType]bool, len(RecordTypes)),
+		done:    false,
+	}
+
+	if err := myReader.decoder.Decode(&myReader.Header); err != nil {
+		return nil, fmt.Errorf("%w: header: %w", ErrArchiveInvalid, err)
+	}
+
+	if myReader.Header.Format != Format {
+		return nil, fmt.Errorf("%w: format %q", ErrArchiveInvalid, myReader.Header.Format)
+	}
+
+	if myReader.Header.Version < 1 || myReader.Header.Version > Version {
+		return nil, fmt.Errorf("%w: version %d", ErrArchiveVersionUnsupported, myReader.Header.Version)
+	}
+
+	for _, recordType := range RecordTypes {
+		myReader.known[recordType] = true
+	}
+
+	return &myReader, nil
+}
+
+// Next reads the next record, it returns io.EOF after the end record.  The counts of the end record are checked
+// against the records read.
+func (c *Reader) Next() (*Record, error) {
+	if c.done {
+		return nil, io.EOF
+	}
+
+	var record Record
+
+	if err := c.decoder.Decode(&record); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: archive is truncated", ErrArchiveInvalid)
+		}
+
+		return nil, fmt.Errorf("%w: %w", ErrArchiveInvalid, err)
+	}
+
+	if record.Type == recordTypeEnd {
+		return nil, c.end(record.Data)
+	}
+
+	if !c.known[record.Type] {
+		return nil, fmt.Errorf("%w: unknown record type %q", ErrArchiveInvalid, record.Type)
+	}
+
+	c.counts[record.Type]++
+
+	return &record, nil
+}
+
+func (c *Reader) end(data json.RawMessage) error {
+	var myEnd end
+
+	if err := json.Unmarshal(data, &myEnd); err != nil {
+		return fmt.Errorf("%w: end: %w", ErrArchiveInvalid, err)
+	}
+
+	for _, recordType := range RecordTypes {
+		if myEnd.Counts[recordType] != c.counts[recordType] {
+			return fmt.Errorf("%w: %d %s records, the archive has %d", ErrArchiveInvalid, c.counts[recordType],
+				recordType, myEnd.Counts[recordType])
+		}
+	}
+
+	c.done = true
+
+	return io.EOF
+}
+
+// Decode decodes the data of a record into one of the record structs
+func (c *Record) Decode(data any) error {
+	if err := json.Unmarshal(c.Data, data); err != nil {
+		return fmt.Errorf("%w: %s record: %w", ErrArchiveInvalid, c.Type, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func writeTestArchive(g *gomega.WithT) *bytes.Buffer {
+	var buf bytes.Buffer
+
+	myWriter, err := NewWriter(&buf, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	g.Expect(myWriter.Write(RecordTypeSetting, Setting{SettingName: "ledgerName",
+		SettingValue: "Household"})).To(gomega.Succeed())
+	g.Expect(myWriter.Write(RecordTypeAccount, Account{AccountID: 7, AccountName: "Checking",
+		AccountFullName: "Checking", AccountLeft: 1, AccountRight: 2, AccountDecimals: 2,
+		AccountOpenDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), AccountSign: "DEBIT",
+		AccountType: "ASSET"})).To(gomega.Succeed())
+	g.Expect(myWriter.Write(RecordTypeReport, Report{ReportID: 3, ReportName: "Balances",
+		ReportBody: []byte(`{"sourcePredefinedAccounts":[7]}`)})).To(gomega.Succeed())
+	g.Expect(myWriter.Close()).To(gomega.Succeed())
+
+	return &buf
+}
+
+func TestArchive_RoundTrip(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	buf := writeTestArchive(g)
+	g.Expect(strings.Count(buf.String(), "\n")).To(gomega.Equal(5))
+
+	myReader, err := NewReader(buf)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myReader.Header.Format).To(gomega.Equal(Format))
+	g.Expect(myReader.Header.Version).To(gomega.Equal(Version))
+	g.Expect(myReader.Header.ExportedAt).To(gomega.Equal(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)))
+	g.Expect(myReader.Header.RecordTypes).To(gomega.Equal(RecordTypes))
+
+	record, err := myReader.Next()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(record.Type).To(gomega.Equal(RecordTypeSetting))
+
+	var mySetting Setting
+	g.Expect(record.Decode(&mySetting)).To(gomega.Succeed())
+	g.Expect(mySetting).To(gomega.Equal(Setting{SettingName: "ledgerName", SettingValue: "Household"}))
+
+	record, err = myReader.Next()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	var account Account
+	g.Expect(record.Decode(&account)).To(gomega.Succeed())
+	g.Expect(account.AccountID).To(gomega.Equal(uint64(7)))
+	g.Expect(account.AccountReconcileDate).To(gomega.BeNil())
+	g.Expect(account.AccountOpenDate).To(gomega.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	record, err = myReader.Next()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	var myReport Report
+	g.Expect(record.Decode(&myReport)).To(gomega.Succeed())
+	g.Expect(string(myReport.ReportBody)).To(gomega.Equal(`{"sourcePredefinedAccounts":[7]}`))
+
+	_, err = myReader.Next()
+	g.Expect(err).To(gomega.Equal(io.EOF))
+
+	_, err = myReader.Next()
+	g.Expect(err).To(gomega.Equal(io.EOF))
+}
+
+func readTestArchive(archive string) error {
+	myReader, err := NewReader(strings.NewReader(archive))
+	if err != nil {
+		return err
+	}
+
+	for {
+		if _, err = myReader.Next(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+	}
+}
+
+func TestArchive_Invalid(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	lines := strings.SplitAfter(writeTestArchive(g).String(), "\n")
+	header, end := lines[0], lines[4]
+
+	tests := []struct {
+		name    string
+		archive string
+		err     error
+	}{
+		{"empty", "", ErrArchiveInvalid},
+		{"not an archive", `{"format":"something-else","version":1}` + "\n", ErrArchiveInvalid},
+		{"newer version", `{"format":"mimirledger-archive","version":2}` + "\n", ErrArchiveVersionUnsupported},
+		{"truncated", strings.Join(lines[:3], ""), ErrArchiveInvalid},
+		{"missing record", header + lines[1] + lines[3] + end, ErrArchiveInvalid},
+		{"unknown record", header + `{"type":"budget","data":{}}` + "\n" + end, ErrArchiveInvalid},
+		{"not json", header + "Checking 1.00\n", ErrArchiveInvalid},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := gomega.NewWithT(t)
+			g.Expect(readTestArchive(test.archive)).To(gomega.MatchError(test.err))
+		})
+	}
+
+	g.Expect(readTestArchive(strings.Join(lines, ""))).To(gomega.Succeed())
+}
@@ -0,0 +1,130 @@
+// Package blobstore keeps the contents of files, such as the receipts attached to transactions, outside of postgres.
+// A Store is either a directory on the local filesystem or a bucket of an S3-compatible object store.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Store keeps blobs under slash separated keys such as "transactions/12/3f9a"
+type Store interface {
+	// Put stores the size bytes read from body under key, replacing the blob already there
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens the blob under key, ErrNotFound when there is none
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete deletes the blob under key, a key without a blob is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// Backend is an enum for the kind of Store the attachments are kept in
+type Backend string
+
+const (
+	BackendLocal = Backend("local")
+	BackendS3    = Backend("s3")
+)
+
+// defaultDir is where a local Store keeps its blobs when ATTACHMENT_DIR is not set
+const defaultDir = "attachments"
+
+// defaultRegion is the region an S3 Store signs its requests for when S3_REGION is not set
+const defaultRegion = "us-east-1"
+
+var ErrNotFound = errors.New("blob not found")
+var ErrInvalidKey = errors.New("invalid blob key")
+var ErrInvalidBackend = errors.New("invalid attachment store")
+
+// Config selects and configures the Store, Dir is for BackendLocal and S3 for BackendS3
+type Config struct {
+	Backend Backend
+	Dir     string
+	S3      S3Config
+}
+
+// S3Config is the bucket of an S3-compatible object store, Endpoint is the base URL such as
+// "https://s3.us-east-1.amazonaws.com" or "http://localhost:9000" and the bucket is addressed in the path
+type S3Config struct {
+	Endpoint        string
+	Bucket          string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// LoadConfigFromEnv reads ATTACHMENT_STORE as "local" or "s3", ATTACHMENT_DIR for a local store and S3_ENDPOINT,
+// S3_BUCKET, S3_REGION, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY for an S3 store
+func LoadConfigFromEnv() Config {
+	blobConfig := Config{
+		Backend: BackendLocal,
+		Dir:     defaultDir,
+		S3: S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			Region:          defaultRegion,
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		},
+	}
+
+	if backend := os.Getenv("ATTACHMENT_STORE"); backend != "" {
+		blobConfig.Backend = Backend(strings.ToLower(backend))
+	}
+
+	if dir := os.Getenv("ATTACHMENT_DIR"); dir != "" {
+		blobConfig.Dir = dir
+	}
+
+	if region := os.Getenv("S3_REGION"); region != "" {
+		blobConfig.S3.Region = region
+	}
+
+	return blobConfig
+}
+
+// New creates the Store selected by blobConfig
+func New(blobConfig *Config) (Store, error) {
+	switch blobConfig.Backend {
+	case BackendLocal:
+		return NewFileStore(blobConfig.Dir), nil
+	case BackendS3:
+		if blobConfig.S3.Endpoint == "" || blobConfig.S3.Bucket == "" {
+			return nil, fmt.Errorf("%w: s3 needs an endpoint and a bucket", ErrInvalidBackend)
+		}
+
+		return NewS3Store(&blobConfig.S3), nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrInvalidBackend, blobConfig.Backend)
+}
+
+// validateKey checks a key is a relative path of segments of letters, digits, '.', '_' and '-', so it is the same
+// key on every Store and cannot escape the directory of a local one
+func validateKey(key string) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+
+		for _, r := range segment {
+			if !isKeyRune(r) {
+				return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+			}
+		}
+	}
+
+	return nil
+}
+
+func isKeyRune(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') ||
+		r == '.' || r == '_' || r == '-'
+}
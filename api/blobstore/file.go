@@ -0,0 +1,103 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FileStore keeps each blob in a file under Dir, at the path of its key
+type FileStore struct {
+	Dir string
+}
+
+var ErrSizeMismatch = errors.New("blob size does not match")
+
+// NewFileStore instantiates a new FileStore struct, dir is created with the first blob
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{
+		Dir: dir,
+	}
+}
+
+func (store *FileStore) path(key string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(store.Dir, filepath.FromSlash(key)), nil
+}
+
+// Put writes the blob to a temporary file that is renamed over the key, so a blob is never read half written
+func (store *FileStore) Put(_ context.Context, key string, body io.Reader, size int64, _ string) error {
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0o750); err != nil { //nolint:mnd
+		return fmt.Errorf("os.MkdirAll:%w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".put-*")
+	if err != nil {
+		return fmt.Errorf("os.CreateTemp:%w", err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+
+	written, err := io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("io.Copy:%w [key:%s]", err, key)
+	}
+
+	if written != size {
+		return fmt.Errorf("%w: wrote %d bytes of %d [key:%s]", ErrSizeMismatch, written, size, key)
+	}
+
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("os.Rename:%w", err)
+	}
+
+	return nil
+}
+
+// Get opens the file of the blob
+func (store *FileStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := store.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("os.Open:%w", err)
+	}
+
+	return file, nil
+}
+
+// Delete removes the file of the blob
+func (store *FileStore) Delete(_ context.Context, key string) error {
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("os.Remove:%w", err)
+	}
+
+	return nil
+}
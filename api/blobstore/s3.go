@@ -0,0 +1,252 @@
+package blobstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Store keeps blobs as the objects of a bucket of an S3-compatible object store such as AWS S3 or MinIO.  Requests
+// are signed with AWS Signature Version 4, the bodies of uploads are not signed so they are streamed.
+type S3Store struct {
+	Config S3Config
+	Client *http.Client
+	// now is the clock requests are signed with
+	now func() time.Time
+}
+
+// s3Timeout limits each request, uploads of large attachments over a slow link included
+const s3Timeout = time.Minute * 5
+
+// s3ErrorBodyLimit is how much of an error response is kept in the error
+const s3ErrorBodyLimit = 512
+
+// unsignedPayload is the payload hash of a request whose body is not signed
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// emptyPayloadHash is the sha256 of an empty body
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+var ErrS3Request = errors.New("s3 request failed")
+
+// NewS3Store instantiates a new S3Store struct
+func NewS3Store(s3Config *S3Config) *S3Store {
+	return &S3Store{
+		Config: *s3Config,
+		Client: &http.Client{Timeout: s3Timeout}, //nolint:exhaustruct
+		now:    time.Now,
+	}
+}
+
+// Put uploads the blob as an object, size is sent as its Content-Length
+func (store *S3Store) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	req, err := store.newRequest(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := store.do(req, unsignedPayload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s3ResponseError(resp, key)
+	}
+
+	return nil
+}
+
+// Get downloads the object of the blob, the body is streamed from the response
+func (store *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := store.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := store.do(req, emptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+
+		return nil, ErrNotFound
+	}
+
+	defer resp.Body.Close()
+
+	return nil, s3ResponseError(resp, key)
+}
+
+// Delete deletes the object of the blob, deleting an object that does not exist succeeds on S3
+func (store *S3Store) Delete(ctx context.Context, key string) error {
+	req, err := store.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := store.do(req, emptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+
+	return s3ResponseError(resp, key)
+}
+
+// newRequest makes a request for the object of key, the bucket is the first segment of the path
+func (store *S3Store) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	objectURL, err := url.Parse(strings.TrimRight(store.Config.Endpoint, "/") + "/" +
+		s3URIEncode(store.Config.Bucket, false) + "/" + s3URIEncode(key, true))
+	if err != nil {
+		return nil, fmt.Errorf("url.Parse:%w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, objectURL.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("http.NewRequestWithContext:%w", err)
+	}
+
+	return req, nil
+}
+
+func (store *S3Store) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	signV4(req, payloadHash, &store.Config, store.now())
+
+	resp, err := store.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Do:%w", err)
+	}
+
+	return resp, nil
+}
+
+func s3ResponseError(resp *http.Response, key string) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, s3ErrorBodyLimit))
+
+	return fmt.Errorf("%w: %s %s [key:%s]", ErrS3Request, resp.Status, strings.TrimSpace(string(body)), key)
+}
+
+// signV4 signs req with AWS Signature Version 4 for the s3 service.  The host and every header already set on req are
+// signed, payloadHash is the hex sha256 of the body or UNSIGNED-PAYLOAD.
+func signV4(req *http.Request, payloadHash string, s3Config *S3Config, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	scope := amzDate[:8] + "/" + s3Config.Region + "/s3/aws4_request"
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3URIEncode(req.URL.Path, true),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex(canonicalRequest)}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s3Config.SecretAccessKey), amzDate[:8])
+	for _, part := range []string{s3Config.Region, "s3", "aws4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Config.AccessKeyID, scope, signedHeaders, hex.EncodeToString(hmacSHA256(signingKey, stringToSign))))
+}
+
+// s3URIEncode percent-encodes everything but the unreserved characters, and the slashes of a path when keepSlash
+func s3URIEncode(value string, keepSlash bool) string {
+	var encoded strings.Builder
+
+	for _, b := range []byte(value) {
+		if isKeyRune(rune(b)) || b == '~' || (keepSlash && b == '/') {
+			encoded.WriteByte(b)
+
+			continue
+		}
+
+		fmt.Fprintf(&encoded, "%%%02X", b)
+	}
+
+	return encoded.String()
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	params := make([]string, 0, len(query))
+
+	for name, values := range query {
+		for _, value := range values {
+			params = append(params, s3URIEncode(name, false)+"="+s3URIEncode(value, false))
+		}
+	}
+
+	sort.Strings(params)
+
+	return strings.Join(params, "&")
+}
+
+func sha256Hex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+
+	return mac.Sum(nil)
+}
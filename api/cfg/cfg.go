@@ -4,17 +4,25 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/mimirsoft/mimirledger/api/blobstore"
 	"github.com/mimirsoft/mimirledger/api/datastore"
 )
 
 const (
 	pathSeparator = "/"
+	// defaultSchedulerInterval is how often scheduled transactions are posted when SCHEDULER_INTERVAL is not set
+	defaultSchedulerInterval = time.Minute * 5
 )
 
 type Config struct {
 	Postgres datastore.PostgresConfig
+	// SchedulerInterval is how often the due scheduled transactions are posted, 0 when this server does not post them
+	SchedulerInterval time.Duration
+	// Attachments is the blob store the files attached to transactions are kept in
+	Attachments blobstore.Config
 }
 
 func LoadEnv() error {
@@ -34,3 +42,18 @@ func LoadEnv() error {
 func envFile(appRoot string, file string) string {
 	return strings.Join([]string{appRoot, file}, pathSeparator)
 }
+
+// LoadSchedulerIntervalFromEnv reads SCHEDULER_INTERVAL as a duration such as "5m", "0" turns the scheduler off
+func LoadSchedulerIntervalFromEnv() (time.Duration, error) {
+	intervalStr := os.Getenv("SCHEDULER_INTERVAL")
+	if intervalStr == "" {
+		return defaultSchedulerInterval, nil
+	}
+
+	interval, err := time.ParseDuration(intervalStr)
+	if err != nil || interval < 0 {
+		return defaultSchedulerInterval, fmt.Errorf("SCHEDULER_INTERVAL %q is not a duration: %w", intervalStr, err)
+	}
+
+	return interval, nil
+}
@@ -4,12 +4,10 @@ import (
 	"database/sql"
 	"fmt"
 	"time"
-
-	"github.com/jmoiron/sqlx"
 )
 
 type AccountStore struct {
-	Client *sqlx.DB
+	Client DBClient
 }
 
 const (
@@ -425,7 +423,8 @@ func (store AccountStore) GetAccountWithChildrenByLevel(acctID uint64) ([]Accoun
                             ORDER BY A2.account_left)
             AS accounts
          WHERE accounts.account_left
-       BETWEEN parent.account_left AND parent.account_right`
+       BETWEEN parent.account_left AND parent.account_right
+      ORDER BY accounts.account_left`
 
 	rows, err := store.Client.Queryx(query, acctID)
 	if err != nil {
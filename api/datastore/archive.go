@@ -0,0 +1,294 @@
+package datastore
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ArchiveStore reads the whole ledger for an archive, and restores an archive in a single database transaction
+type ArchiveStore struct {
+	Client *sqlx.DB
+}
+
+// IsEmpty is true when there are no accounts, transactions, reports or report templates, settings may exist
+func (store ArchiveStore) IsEmpty() (bool, error) {
+	query := `SELECT NOT (EXISTS (SELECT 1 FROM transaction_accounts)
+		               OR EXISTS (SELECT 1 FROM transaction_main)
+		               OR EXISTS (SELECT 1 FROM reports)
+		               OR EXISTS (SELECT 1 FROM report_templates))`
+
+	var isEmpty bool
+
+	if err := store.Client.QueryRowx(query).Scan(&isEmpty); err != nil {
+		return false, fmt.Errorf("row.Scan(&isEmpty):%w", err)
+	}
+
+	return isEmpty, nil
+}
+
+// EachTransaction calls myFunc with every transaction in transaction_id order, without holding them all in memory
+func (store ArchiveStore) EachTransaction(myFunc func(*Transaction) error) error {
+	query := `SELECT ` + transactionColumns + ` FROM transaction_main ORDER BY transaction_id`
+
+	rows, err := store.Client.Queryx(query)
+	if err != nil {
+		return fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var trn Transaction
+		if err = rows.StructScan(&trn); err != nil {
+			return fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		if err = myFunc(&trn); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("rows.Err:%w", err)
+	}
+
+	return nil
+}
+
+// EachDebitCredit calls myFunc with every debit and credit in transaction_dc_id order
+func (store ArchiveStore) EachDebitCredit(myFunc func(*TransactionDebitCredit) error) error {
+	query := `SELECT * FROM transaction_debit_credit ORDER BY transaction_dc_id`
+
+	rows, err := store.Client.Queryx(query)
+	if err != nil {
+		return fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var trnDC TransactionDebitCredit
+		if err = rows.StructScan(&trnDC); err != nil {
+			return fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		if err = myFunc(&trnDC); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("rows.Err:%w", err)
+	}
+
+	return nil
+}
+
+// ArchiveRestore is a restore in progress, nothing is visible until Commit
+type ArchiveRestore struct {
+	tx *sqlx.Tx
+}
+
+// BeginRestore starts the database transaction of a restore
+func (store ArchiveStore) BeginRestore() (*ArchiveRestore, error) {
+	tx, err := store.Client.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Beginx:%w", err)
+	}
+
+	return &ArchiveRestore{tx: tx}, nil
+}
+
+// namedExec runs an insert with named parameters inside the restore
+func (restore *ArchiveRestore) namedExec(query string, arg any) error {
+	if _, err := restore.tx.NamedExec(query, arg); err != nil {
+		return fmt.Errorf("tx.NamedExec:%w", err)
+	}
+
+	return nil
+}
+
+// StoreSetting inserts a Setting, or updates the value of the existing record
+func (restore *ArchiveRestore) StoreSetting(mySetting *Setting) error {
+	return restore.namedExec(`INSERT INTO settings
+		           (setting_name,
+		            setting_value)
+		    VALUES (:setting_name,
+		            :setting_value)
+		ON CONFLICT (setting_name)
+		 DO UPDATE SET setting_value = :setting_value`, mySetting)
+}
+
+// StoreAccount inserts an Account with its account_id
+func (restore *ArchiveRestore) StoreAccount(acct *Account) error {
+	return restore.namedExec(`INSERT INTO transaction_accounts
+		           (account_id,
+		            account_parent,
+		            account_name,
+		            account_full_name,
+		            account_memo,
+		            account_current,
+		            account_left,
+		            account_right,
+		            account_decimals,
+		            account_reconcile_date,
+		            account_flagged,
+		            account_locked,
+		            account_open_date,
+		            account_close_date,
+		            account_code,
+		            account_sign,
+		            account_type)
+		  OVERRIDING SYSTEM VALUE
+		    VALUES (:account_id,
+		            :account_parent,
+		            :account_name,
+		            :account_full_name,
+		            :account_memo,
+		            :account_current,
+		            :account_left,
+		            :account_right,
+		            :account_decimals,
+		            :account_reconcile_date,
+		            :account_flagged,
+		            :account_locked,
+		            :account_open_date,
+		            :account_close_date,
+		            :account_code,
+		            :account_sign,
+		            :account_type)`, acct)
+}
+
+// StoreTransaction inserts a Transaction with its transaction_id
+func (restore *ArchiveRestore) StoreTransaction(trn *Transaction) error {
+	return restore.namedExec(`INSERT INTO transaction_main
+		           (transaction_id,
+		            transaction_date,
+		            transaction_reconcile_date,
+		            transaction_comment,
+		            transaction_amount,
+		            transaction_reference,
+		            is_reconciled,
+		            is_split)
+		  OVERRIDING SYSTEM VALUE
+		    VALUES (:transaction_id,
+		            :transaction_date,
+		            :transaction_reconcile_date,
+		            :transaction_comment,
+		            :transaction_amount,
+		            :transaction_reference,
+		            :is_reconciled,
+		            :is_split)`, trn)
+}
+
+// StoreDebitCredit inserts a TransactionDebitCredit with its transaction_dc_id
+func (restore *ArchiveRestore) StoreDebitCredit(trnDC *TransactionDebitCredit) error {
+	return restore.namedExec(`INSERT INTO transaction_debit_credit
+		           (transaction_dc_id,
+		            transaction_id,
+		            account_id,
+		            transaction_dc_amount,
+		            debit_or_credit,
+		            is_reconciled,
+		            transaction_reconcile_date)
+		  OVERRIDING SYSTEM VALUE
+		    VALUES (:transaction_dc_id,
+		            :transaction_id,
+		            :account_id,
+		            :transaction_dc_amount,
+		            :debit_or_credit,
+		            :is_reconciled,
+		            :transaction_reconcile_date)`, trnDC)
+}
+
+// StoreReport inserts a Report with its report_id
+func (restore *ArchiveRestore) StoreReport(myReport *Report) error {
+	return restore.namedExec(`INSERT INTO reports
+		           (report_id,
+		            report_name,
+		            report_body)
+		  OVERRIDING SYSTEM VALUE
+		    VALUES (:report_id,
+		            :report_name,
+		            :report_body)`, myReport)
+}
+
+// StoreReportTemplate inserts a ReportTemplate with its template_id
+func (restore *ArchiveRestore) StoreReportTemplate(myTemplate *ReportTemplate) error {
+	return restore.namedExec(`INSERT INTO report_templates
+		           (template_id,
+		            template_name,
+		            template_type,
+		            template_body)
+		  OVERRIDING SYSTEM VALUE
+		    VALUES (:template_id,
+		            :template_name,
+		            :template_type,
+		            :template_body)`, myTemplate)
+}
+
+// UpdateSubtotalsAndBalances recalculates account_subtotal from the debits and credits posted to each account, then
+// account_balance as the sum of the subtotals of the account and its subaccounts
+func (restore *ArchiveRestore) UpdateSubtotalsAndBalances() error {
+	subtotals := `UPDATE transaction_accounts AS account
+		   SET account_subtotal = COALESCE((
+		       SELECT SUM(CASE WHEN dc.debit_or_credit = account.account_sign
+		                       THEN dc.transaction_dc_amount
+		                       ELSE -dc.transaction_dc_amount END)
+		         FROM transaction_debit_credit AS dc
+		        WHERE dc.account_id = account.account_id), 0)`
+
+	if _, err := restore.tx.Exec(subtotals); err != nil {
+		return fmt.Errorf("tx.Exec(subtotals):%w", err)
+	}
+
+	balances := `UPDATE transaction_accounts AS account
+		   SET account_balance = (
+		       SELECT SUM(subaccount.account_subtotal)
+		         FROM transaction_accounts AS subaccount
+		        WHERE subaccount.account_left BETWEEN account.account_left AND account.account_right)`
+
+	if _, err := restore.tx.Exec(balances); err != nil {
+		return fmt.Errorf("tx.Exec(balances):%w", err)
+	}
+
+	return nil
+}
+
+// archiveSequences are the identity columns whose sequences follow the restored IDs
+var archiveSequences = []struct{ table, column string }{ //nolint:gochecknoglobals
+	{"transaction_accounts", "account_id"},
+	{"transaction_main", "transaction_id"},
+	{"transaction_debit_credit", "transaction_dc_id"},
+	{"reports", "report_id"},
+	{"report_templates", "template_id"},
+}
+
+// Commit moves the identity sequences past the restored IDs and commits the restore
+func (restore *ArchiveRestore) Commit() error {
+	for _, sequence := range archiveSequences {
+		query := fmt.Sprintf(`SELECT setval(pg_get_serial_sequence('%[1]s', '%[2]s'),
+		                                    COALESCE((SELECT MAX(%[2]s) FROM %[1]s), 0) + 1, false)`,
+			sequence.table, sequence.column)
+
+		if _, err := restore.tx.Exec(query); err != nil {
+			_ = restore.tx.Rollback()
+
+			return fmt.Errorf("tx.Exec(setval):%w [table:%s]", err, sequence.table)
+		}
+	}
+
+	if err := restore.tx.Commit(); err != nil {
+		return fmt.Errorf("tx.Commit:%w", err)
+	}
+
+	return nil
+}
+
+// Rollback abandons the restore
+func (restore *ArchiveRestore) Rollback() error {
+	if err := restore.tx.Rollback(); err != nil {
+		return fmt.Errorf("tx.Rollback:%w", err)
+	}
+
+	return nil
+}
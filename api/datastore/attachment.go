@@ -0,0 +1,129 @@
+package datastore
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type AttachmentStore struct {
+	Client DBClient
+}
+
+// Attachment is the metadata of a file attached to a transaction, the file is kept in the blob store under StorageKey
+type Attachment struct {
+	AttachmentID  uint64    `db:"attachment_id,omitempty"`
+	TransactionID uint64    `db:"transaction_id"`
+	FileName      string    `db:"file_name"`
+	ContentType   string    `db:"content_type"`
+	FileSize      int64     `db:"file_size"`
+	FileSHA256    string    `db:"file_sha256"`
+	StorageKey    string    `db:"storage_key"`
+	CreatedDate   time.Time `db:"created_date,omitempty"`
+}
+
+// Store inserts an Attachment, we do not include :attachment_id or :created_date in our insert
+func (store AttachmentStore) Store(attachment *Attachment) error {
+	query := `INSERT INTO transaction_attachments
+		           (transaction_id,
+		            file_name,
+		            content_type,
+		            file_size,
+		            file_sha256,
+		            storage_key)
+		    VALUES (:transaction_id,
+		            :file_name,
+		            :content_type,
+		            :file_size,
+		            :file_sha256,
+		            :storage_key)
+		 RETURNING *`
+
+	stmt, err := store.Client.PrepareNamed(query)
+	if err != nil {
+		return fmt.Errorf("store.Client.PrepareNamed(query):%w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(attachment).StructScan(attachment)
+	if err != nil {
+		return fmt.Errorf("stmt.QueryRow().StructScan():%w", err)
+	}
+
+	return nil
+}
+
+// RetrieveByID retrieves an Attachment
+func (store AttachmentStore) RetrieveByID(attachmentID uint64) (*Attachment, error) {
+	query := `SELECT * FROM transaction_attachments WHERE attachment_id = $1`
+
+	var attachment Attachment
+
+	if err := store.Client.QueryRowx(query, attachmentID).StructScan(&attachment); err != nil {
+		return nil, fmt.Errorf("row.StructScan:%w", err)
+	}
+
+	return &attachment, nil
+}
+
+// RetrieveByTransactionID gets the Attachments of a transaction in the order they were attached
+func (store AttachmentStore) RetrieveByTransactionID(transactionID uint64) ([]*Attachment, error) {
+	query := `SELECT *
+		    FROM transaction_attachments
+		   WHERE transaction_id = $1
+		ORDER BY attachment_id`
+
+	rows, err := store.Client.Queryx(query, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	var attachments []*Attachment
+
+	for rows.Next() {
+		var attachment Attachment
+		if err = rows.StructScan(&attachment); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		attachments = append(attachments, &attachment)
+	}
+
+	return attachments, nil
+}
+
+// Delete an Attachment, the file is deleted from the blob store by the caller
+func (store AttachmentStore) Delete(attachment *Attachment) error {
+	query := `DELETE FROM transaction_attachments WHERE attachment_id = $1`
+
+	res, err := store.Client.Exec(query, attachment.AttachmentID)
+	if err != nil {
+		return fmt.Errorf("store.Client.Exec:%w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("res.RowsAffected:%w", err)
+	}
+
+	if count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ReplaceTransaction moves the attachments of one transaction to another, when the first is merged into the second
+func (store AttachmentStore) ReplaceTransaction(transactionID, replacementID uint64) error {
+	query := `UPDATE transaction_attachments
+		         SET transaction_id = $2
+		       WHERE transaction_id = $1`
+
+	_, err := store.Client.Exec(query, transactionID, replacementID)
+	if err != nil {
+		return fmt.Errorf("store.Client.Exec:%w", err)
+	}
+
+	return nil
+}
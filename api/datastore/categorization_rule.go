@@ -0,0 +1,192 @@
+package datastore
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+type CategorizationRuleStore struct {
+	Client DBClient
+}
+
+// RuleActionType is an enum for what a CategorizationRule does to the transactions it matches
+type RuleActionType string
+
+const (
+	RuleActionSetAccount = RuleActionType("SET_ACCOUNT")
+	RuleActionSplit      = RuleActionType("SPLIT")
+	RuleActionSetComment = RuleActionType("SET_COMMENT")
+)
+
+// CategorizationRule is a rule of the rules engine, rules are applied in RuleOrder then RuleID order
+type CategorizationRule struct {
+	RuleID    uint64                 `db:"rule_id,omitempty"`
+	RuleName  string                 `db:"rule_name"`
+	RuleOrder int                    `db:"rule_order"`
+	IsEnabled bool                   `db:"is_enabled"`
+	RuleBody  CategorizationRuleBody `db:"rule_body"`
+}
+
+// CategorizationRuleBody is what a rule matches and what it does
+type CategorizationRuleBody struct {
+	Match  RuleMatch  `json:"match"`
+	Action RuleAction `json:"action"`
+}
+
+// RuleMatch is the conditions of a rule, all of the conditions that are set must match.  Amounts are in the sign of
+// the source account, positive for money into it.
+type RuleMatch struct {
+	DescriptionRegex string `json:"descriptionRegex"`
+	MinAmount        *int64 `json:"minAmount"`
+	MaxAmount        *int64 `json:"maxAmount"`
+	SourceAccountID  uint64 `json:"sourceAccountID"`
+	Reference        string `json:"reference"`
+}
+
+// RuleAction is what a rule does, OffsetAccountID is for SET_ACCOUNT, Splits for SPLIT and Comment for SET_COMMENT
+type RuleAction struct {
+	ActionType      RuleActionType `json:"actionType"`
+	OffsetAccountID uint64         `json:"offsetAccountID"`
+	Splits          []*RuleSplit   `json:"splits"`
+	Comment         string         `json:"comment"`
+}
+
+// RuleSplit is a share of a split, either a fixed Amount or a Percent of what is left after the fixed amounts
+type RuleSplit struct {
+	AccountID uint64  `json:"accountID"`
+	Percent   float64 `json:"percent"`
+	Amount    uint64  `json:"amount"`
+}
+
+// Value implements driver.Valuer, the body is stored as JSON
+func (rb CategorizationRuleBody) Value() (driver.Value, error) {
+	return json.Marshal(rb) //nolint:wrapcheck
+}
+
+var errCategorizationRuleBodyScanFailed = errors.New("failed to scan categorization rule body:type assertion failed")
+
+// Scan implements sql.Scanner, decoding the JSON body
+func (rb *CategorizationRuleBody) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errCategorizationRuleBodyScanFailed
+	}
+
+	return json.Unmarshal(b, rb) //nolint:wrapcheck
+}
+
+// Store inserts a CategorizationRule, we do not include :rule_id in our insert
+func (store CategorizationRuleStore) Store(myRule *CategorizationRule) error {
+	query := `INSERT INTO categorization_rules
+		           (rule_name,
+		            rule_order,
+		            is_enabled,
+		            rule_body)
+		    VALUES (:rule_name,
+		            :rule_order,
+		            :is_enabled,
+		            :rule_body)
+		 RETURNING *`
+
+	stmt, err := store.Client.PrepareNamed(query)
+	if err != nil {
+		return fmt.Errorf("store.Client.PrepareNamed(query):%w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(myRule).StructScan(myRule)
+	if err != nil {
+		return fmt.Errorf("stmt.QueryRow().StructScan():%w", err)
+	}
+
+	return nil
+}
+
+// Update updates the name, order, enabled flag and body of a CategorizationRule
+func (store CategorizationRuleStore) Update(myRule *CategorizationRule) error {
+	query := `UPDATE categorization_rules
+		   SET (rule_name,
+		        rule_order,
+		        is_enabled,
+		        rule_body)
+		     = (:rule_name,
+		        :rule_order,
+		        :is_enabled,
+		        :rule_body)
+		 WHERE rule_id = :rule_id
+		 RETURNING *`
+
+	stmt, err := store.Client.PrepareNamed(query)
+	if err != nil {
+		return fmt.Errorf("store.Client.PrepareNamed(query):%w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(myRule).StructScan(myRule)
+	if err != nil {
+		return fmt.Errorf("stmt.QueryRow().StructScan():%w", err)
+	}
+
+	return nil
+}
+
+// RetrieveByID retrieves a CategorizationRule
+func (store CategorizationRuleStore) RetrieveByID(ruleID uint64) (*CategorizationRule, error) {
+	query := `SELECT * FROM categorization_rules WHERE rule_id = $1`
+
+	var myRule CategorizationRule
+
+	if err := store.Client.QueryRowx(query, ruleID).StructScan(&myRule); err != nil {
+		return nil, fmt.Errorf("row.StructScan:%w", err)
+	}
+
+	return &myRule, nil
+}
+
+// Retrieve gets all CategorizationRules in the order they are applied
+func (store CategorizationRuleStore) Retrieve() ([]*CategorizationRule, error) {
+	query := `SELECT * FROM categorization_rules ORDER BY rule_order, rule_id`
+
+	rows, err := store.Client.Queryx(query)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	var ruleSet []*CategorizationRule
+
+	for rows.Next() {
+		var myRule CategorizationRule
+		if err = rows.StructScan(&myRule); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		ruleSet = append(ruleSet, &myRule)
+	}
+
+	return ruleSet, nil
+}
+
+// Delete a CategorizationRule
+func (store CategorizationRuleStore) Delete(myRule *CategorizationRule) error {
+	query := `DELETE FROM categorization_rules WHERE rule_id = $1`
+
+	res, err := store.Client.Exec(query, myRule.RuleID)
+	if err != nil {
+		return fmt.Errorf("store.Client.Exec:%w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("res.RowsAffected:%w", err)
+	}
+
+	if count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
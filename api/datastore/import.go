@@ -0,0 +1,408 @@
+package datastore
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type ImportStore struct {
+	Client DBClient
+}
+
+// ImportStatus is an enum for the stage of an Import
+type ImportStatus string
+
+const (
+	ImportStatusPreview   = ImportStatus("PREVIEW")
+	ImportStatusPosted    = ImportStatus("POSTED")
+	ImportStatusCancelled = ImportStatus("CANCELLED")
+)
+
+// Import is an uploaded statement, its lines are posted against AccountID and OffsetAccountID
+type Import struct {
+	ImportID        uint64       `db:"import_id,omitempty"`
+	AccountID       uint64       `db:"account_id"`
+	OffsetAccountID uint64       `db:"offset_account_id"`
+	ImportFormat    string       `db:"import_format"`
+	ImportFilename  string       `db:"import_filename"`
+	ImportStatus    ImportStatus `db:"import_status"`
+	ImportDate      time.Time    `db:"import_date,omitempty"`
+	// CategoryParentID is the account that categories are mapped to accounts under
+	CategoryParentID sql.NullInt64    `db:"category_parent_id"`
+	ImportCategories ImportCategories `db:"import_categories"`
+	// OpeningBalance and ClosingBalance are the balances the statement reports, positive when in credit with the bank
+	OpeningBalance     sql.NullInt64 `db:"opening_balance"`
+	OpeningBalanceDate sql.NullTime  `db:"opening_balance_date"`
+	ClosingBalance     sql.NullInt64 `db:"closing_balance"`
+	ClosingBalanceDate sql.NullTime  `db:"closing_balance_date"`
+}
+
+// ImportLine is a line of an Import, TransactionID is set once it is posted
+type ImportLine struct {
+	ImportLineID  uint64        `db:"import_line_id,omitempty"`
+	ImportID      uint64        `db:"import_id"`
+	ExternalID    string        `db:"external_id"`
+	LineDate      time.Time     `db:"line_date"`
+	LineAmount    int64         `db:"line_amount"`
+	LineComment   string        `db:"line_comment"`
+	LineReference string        `db:"line_reference"`
+	IsDuplicate   bool          `db:"is_duplicate"`
+	TransactionID sql.NullInt64 `db:"transaction_id"`
+	// LineCategory is offset instead of the import's offset account, unless the line has LineSplits
+	LineCategory string           `db:"line_category"`
+	LineSplits   ImportLineSplits `db:"line_splits"`
+	// LineValueDate is the value date the statement gives for the line
+	LineValueDate sql.NullTime `db:"line_value_date"`
+}
+
+// ImportCategory is a category defined by the statement file
+type ImportCategory struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	IsIncome    bool   `json:"isIncome"`
+}
+
+// ImportCategories are stored as JSON
+type ImportCategories []*ImportCategory
+
+// ImportLineSplit divides an ImportLine between categories
+type ImportLineSplit struct {
+	Category string `json:"category"`
+	Memo     string `json:"memo"`
+	Amount   int64  `json:"amount"`
+}
+
+// ImportLineSplits are stored as JSON
+type ImportLineSplits []*ImportLineSplit
+
+var errImportJSONScanFailed = errors.New("failed to scan import json:type assertion failed")
+
+// Value implements driver.Valuer, no categories is an empty list
+func (ic ImportCategories) Value() (driver.Value, error) {
+	if ic == nil {
+		return []byte("[]"), nil
+	}
+
+	return json.Marshal([]*ImportCategory(ic)) //nolint:wrapcheck
+}
+
+// Scan implements sql.Scanner
+func (ic *ImportCategories) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errImportJSONScanFailed
+	}
+
+	return json.Unmarshal(b, (*[]*ImportCategory)(ic)) //nolint:wrapcheck
+}
+
+// Value implements driver.Valuer, no splits is an empty list
+func (ls ImportLineSplits) Value() (driver.Value, error) {
+	if ls == nil {
+		return []byte("[]"), nil
+	}
+
+	return json.Marshal([]*ImportLineSplit(ls)) //nolint:wrapcheck
+}
+
+// Scan implements sql.Scanner
+func (ls *ImportLineSplits) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errImportJSONScanFailed
+	}
+
+	return json.Unmarshal(b, (*[]*ImportLineSplit)(ls)) //nolint:wrapcheck
+}
+
+// ImportError is a row of the statement file that could not be read
+type ImportError struct {
+	ImportErrorID uint64 `db:"import_error_id,omitempty"`
+	ImportID      uint64 `db:"import_id"`
+	RowNumber     int    `db:"row_number"`
+	ErrorMessage  string `db:"error_message"`
+}
+
+// Store inserts an Import, we do not include :import_id or :import_date in our insert
+func (store ImportStore) Store(myImport *Import) error {
+	query := `INSERT INTO imports
+		           (account_id,
+		            offset_account_id,
+		            import_format,
+		            import_filename,
+		            import_status,
+		            category_parent_id,
+		            import_categories,
+		            opening_balance,
+		            opening_balance_date,
+		            closing_balance,
+		            closing_balance_date)
+		    VALUES (:account_id,
+		            :offset_account_id,
+		            :import_format,
+		            :import_filename,
+		            :import_status,
+		            :category_parent_id,
+		            :import_categories,
+		            :opening_balance,
+		            :opening_balance_date,
+		            :closing_balance,
+		            :closing_balance_date)
+		 RETURNING *`
+
+	stmt, err := store.Client.PrepareNamed(query)
+	if err != nil {
+		return fmt.Errorf("store.Client.PrepareNamed(query):%w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(myImport).StructScan(myImport)
+	if err != nil {
+		return fmt.Errorf("stmt.QueryRow().StructScan():%w", err)
+	}
+
+	return nil
+}
+
+// StoreLine inserts an ImportLine
+func (store ImportStore) StoreLine(line *ImportLine) error {
+	query := `INSERT INTO import_lines
+		           (import_id,
+		            external_id,
+		            line_date,
+		            line_amount,
+		            line_comment,
+		            line_reference,
+		            is_duplicate,
+		            line_category,
+		            line_splits,
+		            line_value_date)
+		    VALUES (:import_id,
+		            :external_id,
+		            :line_date,
+		            :line_amount,
+		            :line_comment,
+		            :line_reference,
+		            :is_duplicate,
+		            :line_category,
+		            :line_splits,
+		            :line_value_date)
+		 RETURNING *`
+
+	stmt, err := store.Client.PrepareNamed(query)
+	if err != nil {
+		return fmt.Errorf("store.Client.PrepareNamed(query):%w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(line).StructScan(line)
+	if err != nil {
+		return fmt.Errorf("stmt.QueryRow().StructScan():%w", err)
+	}
+
+	return nil
+}
+
+// StoreError inserts an ImportError
+func (store ImportStore) StoreError(importError *ImportError) error {
+	query := `INSERT INTO import_errors
+		           (import_id,
+		            row_number,
+		            error_message)
+		    VALUES (:import_id,
+		            :row_number,
+		            :error_message)
+		 RETURNING *`
+
+	stmt, err := store.Client.PrepareNamed(query)
+	if err != nil {
+		return fmt.Errorf("store.Client.PrepareNamed(query):%w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(importError).StructScan(importError)
+	if err != nil {
+		return fmt.Errorf("stmt.QueryRow().StructScan():%w", err)
+	}
+
+	return nil
+}
+
+// SetStatusFrom sets the ImportStatus of an Import that is still in fromStatus, so of two requests moving an Import
+// on from the same status only the first does.  sql.ErrNoRows when the Import is not in fromStatus.
+func (store ImportStore) SetStatusFrom(myImport *Import, fromStatus ImportStatus) error {
+	query := `UPDATE imports
+		         SET import_status = $2
+		       WHERE import_id = $1
+		         AND import_status = $3`
+
+	res, err := store.Client.Exec(query, myImport.ImportID, myImport.ImportStatus, fromStatus)
+	if err != nil {
+		return fmt.Errorf("store.Client.Exec:%w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("res.RowsAffected:%w", err)
+	}
+
+	if count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// SetLineTransaction records the transaction an ImportLine was posted as
+func (store ImportStore) SetLineTransaction(line *ImportLine) error {
+	query := `UPDATE import_lines
+		         SET transaction_id = $2,
+		             is_duplicate = $3
+		       WHERE import_line_id = $1`
+
+	_, err := store.Client.Exec(query, line.ImportLineID, line.TransactionID, line.IsDuplicate)
+	if err != nil {
+		return fmt.Errorf("store.Client.Exec:%w", err)
+	}
+
+	return nil
+}
+
+// ReplaceLineTransaction points the import lines posted as one transaction at another, when the first is merged
+// into the second
+func (store ImportStore) ReplaceLineTransaction(transactionID, replacementID uint64) error {
+	query := `UPDATE import_lines
+		         SET transaction_id = $2
+		       WHERE transaction_id = $1`
+
+	_, err := store.Client.Exec(query, transactionID, replacementID)
+	if err != nil {
+		return fmt.Errorf("store.Client.Exec:%w", err)
+	}
+
+	return nil
+}
+
+// RetrieveByID retrieves an Import
+func (store ImportStore) RetrieveByID(importID uint64) (*Import, error) {
+	query := `SELECT * FROM imports WHERE import_id = $1`
+
+	var myImport Import
+
+	if err := store.Client.QueryRowx(query, importID).StructScan(&myImport); err != nil {
+		return nil, fmt.Errorf("row.StructScan:%w", err)
+	}
+
+	return &myImport, nil
+}
+
+// Retrieve retrieves all imports, the newest first
+func (store ImportStore) Retrieve() ([]*Import, error) {
+	query := `SELECT * FROM imports ORDER BY import_date DESC, import_id DESC`
+
+	rows, err := store.Client.Queryx(query)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	var importSet []*Import
+
+	for rows.Next() {
+		var myImport Import
+		if err = rows.StructScan(&myImport); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		importSet = append(importSet, &myImport)
+	}
+
+	if len(importSet) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return importSet, nil
+}
+
+// GetLines gets the lines of an Import in statement order
+func (store ImportStore) GetLines(importID uint64) ([]*ImportLine, error) {
+	query := `SELECT * FROM import_lines WHERE import_id = $1 ORDER BY import_line_id`
+
+	rows, err := store.Client.Queryx(query, importID)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	var lineSet []*ImportLine
+
+	for rows.Next() {
+		var line ImportLine
+		if err = rows.StructScan(&line); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		lineSet = append(lineSet, &line)
+	}
+
+	return lineSet, nil
+}
+
+// GetErrors gets the errors of an Import in file order
+func (store ImportStore) GetErrors(importID uint64) ([]*ImportError, error) {
+	query := `SELECT * FROM import_errors WHERE import_id = $1 ORDER BY row_number, import_error_id`
+
+	rows, err := store.Client.Queryx(query, importID)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	var errorSet []*ImportError
+
+	for rows.Next() {
+		var importError ImportError
+		if err = rows.StructScan(&importError); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		errorSet = append(errorSet, &importError)
+	}
+
+	return errorSet, nil
+}
+
+// GetPostedExternalIDs gets which of externalIDs have already been posted to accountID by an earlier import.
+// A line whose transaction was since deleted no longer counts as posted.
+func (store ImportStore) GetPostedExternalIDs(accountID uint64, externalIDs []string) ([]string, error) {
+	query := `SELECT DISTINCT il.external_id
+		        FROM import_lines AS il
+		  INNER JOIN imports AS im
+		          ON im.import_id=il.import_id
+		       WHERE im.account_id = $1
+		         AND il.transaction_id IS NOT NULL
+		         AND il.external_id = ANY($2::text[])`
+
+	rows, err := store.Client.Queryx(query, accountID, externalIDs)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	var posted []string
+
+	for rows.Next() {
+		var externalID string
+		if err = rows.Scan(&externalID); err != nil {
+			return nil, fmt.Errorf("rows.Scan:%w", err)
+		}
+
+		posted = append(posted, externalID)
+	}
+
+	return posted, nil
+}
@@ -0,0 +1,161 @@
+package datastore
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+type ImportProfileStore struct {
+	Client DBClient
+}
+
+// ImportProfile is a saved mapping of a bank's CSV export, OffsetAccountID is the default account its lines are
+// posted against
+type ImportProfile struct {
+	ProfileID       uint64            `db:"profile_id,omitempty"`
+	ProfileName     string            `db:"profile_name"`
+	OffsetAccountID sql.NullInt64     `db:"offset_account_id"`
+	ProfileBody     ImportProfileBody `db:"profile_body"`
+}
+
+// ImportProfileBody is the column mapping, columns are numbered from 1 and 0 is not mapped
+type ImportProfileBody struct {
+	Delimiter         string `json:"delimiter"`
+	SkipRows          int    `json:"skipRows"`
+	DateColumn        int    `json:"dateColumn"`
+	AmountColumn      int    `json:"amountColumn"`
+	DebitColumn       int    `json:"debitColumn"`
+	CreditColumn      int    `json:"creditColumn"`
+	DescriptionColumn int    `json:"descriptionColumn"`
+	ReferenceColumn   int    `json:"referenceColumn"`
+	ExternalIDColumn  int    `json:"externalIDColumn"`
+	DateFormat        string `json:"dateFormat"`
+	DecimalSeparator  string `json:"decimalSeparator"`
+	SignConvention    string `json:"signConvention"`
+}
+
+// Value implements driver.Valuer, the body is stored as JSON
+func (pb *ImportProfileBody) Value() (driver.Value, error) {
+	return json.Marshal(pb) //nolint:wrapcheck
+}
+
+var errImportProfileBodyScanFailed = errors.New("failed to scan import profile body:type assertion failed")
+
+// Scan implements sql.Scanner, decoding the JSON body
+func (pb *ImportProfileBody) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errImportProfileBodyScanFailed
+	}
+
+	return json.Unmarshal(b, &pb) //nolint:wrapcheck
+}
+
+// Store inserts an ImportProfile, we do not include :profile_id in our insert
+func (store ImportProfileStore) Store(myProfile *ImportProfile) error {
+	query := `INSERT INTO import_profiles
+		           (profile_name,
+		            offset_account_id,
+		            profile_body)
+		    VALUES (:profile_name,
+		            :offset_account_id,
+		            :profile_body)
+		 RETURNING *`
+
+	stmt, err := store.Client.PrepareNamed(query)
+	if err != nil {
+		return fmt.Errorf("error preparing import profile insert: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(myProfile).StructScan(myProfile) //nolint:musttag
+	if err != nil {
+		return fmt.Errorf("stmt.QueryRow().StructScan():%w", err)
+	}
+
+	return nil
+}
+
+// Update updates the name, offset account and body of an ImportProfile
+func (store ImportProfileStore) Update(myProfile *ImportProfile) error {
+	query := `UPDATE import_profiles
+		   SET (profile_name,
+		        offset_account_id,
+		        profile_body)
+		     = (:profile_name,
+		        :offset_account_id,
+		        :profile_body)
+		 WHERE profile_id = :profile_id
+		 RETURNING *`
+
+	stmt, err := store.Client.PrepareNamed(query)
+	if err != nil {
+		return fmt.Errorf("error preparing import profile update: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(myProfile).StructScan(myProfile) //nolint:musttag
+	if err != nil {
+		return fmt.Errorf("stmt.QueryRow().StructScan():%w", err)
+	}
+
+	return nil
+}
+
+func (store ImportProfileStore) RetrieveByID(id uint64) (*ImportProfile, error) {
+	query := `select * from import_profiles where profile_id = $1`
+
+	row := store.Client.QueryRowx(query, id)
+
+	var myProfile ImportProfile
+
+	if err := row.StructScan(&myProfile); err != nil { //nolint:musttag
+		return nil, fmt.Errorf("row.StructScan(&tn):%w", err)
+	}
+
+	return &myProfile, nil
+}
+
+// Gets All ImportProfiles.
+func (store ImportProfileStore) Retrieve() ([]*ImportProfile, error) {
+	query := `select * from import_profiles order by profile_name`
+
+	rows, err := store.Client.Queryx(query)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	var set []*ImportProfile
+
+	for rows.Next() {
+		var myProfile ImportProfile
+		if err = rows.StructScan(&myProfile); err != nil { //nolint:musttag
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		set = append(set, &myProfile)
+	}
+
+	if len(set) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return set, nil
+}
+
+// Delete an ImportProfile
+func (store ImportProfileStore) Delete(myProfile *ImportProfile) error {
+	query := `Delete FROM import_profiles 
+		         where profile_id = $1`
+
+	_, err := store.Client.Exec(query, myProfile.ProfileID)
+	if err != nil {
+		return fmt.Errorf("store.Client.Exec:%w", err)
+	}
+
+	return nil
+}
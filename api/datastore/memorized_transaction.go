@@ -0,0 +1,170 @@
+package datastore
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+type MemorizedTransactionStore struct {
+	Client DBClient
+}
+
+// MemorizedLineType is an enum for how the amount of a line of a MemorizedTransaction is worked out
+type MemorizedLineType string
+
+const (
+	MemorizedLineFixed   = MemorizedLineType("FIXED")
+	MemorizedLineAmount  = MemorizedLineType("AMOUNT")
+	MemorizedLinePercent = MemorizedLineType("PERCENT")
+	MemorizedLineBalance = MemorizedLineType("BALANCE")
+)
+
+// MemorizedTransaction is a named template of a multi-line transaction
+type MemorizedTransaction struct {
+	MemorizedID   uint64        `db:"memorized_id,omitempty"`
+	MemorizedName string        `db:"memorized_name"`
+	MemorizedBody MemorizedBody `db:"memorized_body"`
+}
+
+// MemorizedBody is the comment, reference and lines of a MemorizedTransaction
+type MemorizedBody struct {
+	TransactionComment   string           `json:"transactionComment"`
+	TransactionReference string           `json:"transactionReference"`
+	Lines                []*MemorizedLine `json:"lines"`
+}
+
+// MemorizedLine is a line of a MemorizedTransaction.  A FIXED line is Amount, an AMOUNT line is the amount named
+// ParameterName that is filled in, a PERCENT line is Percent of it, and a BALANCE line is what balances the others.
+type MemorizedLine struct {
+	AccountID     uint64            `json:"accountID"`
+	DebitOrCredit AccountSign       `json:"debitOrCredit"`
+	LineType      MemorizedLineType `json:"lineType"`
+	Amount        uint64            `json:"amount"`
+	Percent       float64           `json:"percent"`
+	ParameterName string            `json:"parameterName"`
+}
+
+// Value implements driver.Valuer, the body is stored as JSON
+func (mb MemorizedBody) Value() (driver.Value, error) {
+	return json.Marshal(mb) //nolint:wrapcheck
+}
+
+var errMemorizedBodyScanFailed = errors.New("failed to scan memorized transaction body:type assertion failed")
+
+// Scan implements sql.Scanner, decoding the JSON body
+func (mb *MemorizedBody) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errMemorizedBodyScanFailed
+	}
+
+	return json.Unmarshal(b, mb) //nolint:wrapcheck
+}
+
+// Store inserts a MemorizedTransaction, we do not include :memorized_id in our insert
+func (store MemorizedTransactionStore) Store(memorized *MemorizedTransaction) error {
+	query := `INSERT INTO memorized_transactions
+		           (memorized_name,
+		            memorized_body)
+		    VALUES (:memorized_name,
+		            :memorized_body)
+		 RETURNING *`
+
+	stmt, err := store.Client.PrepareNamed(query)
+	if err != nil {
+		return fmt.Errorf("store.Client.PrepareNamed(query):%w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(memorized).StructScan(memorized)
+	if err != nil {
+		return fmt.Errorf("stmt.QueryRow().StructScan():%w", err)
+	}
+
+	return nil
+}
+
+// Update updates the name and body of a MemorizedTransaction
+func (store MemorizedTransactionStore) Update(memorized *MemorizedTransaction) error {
+	query := `UPDATE memorized_transactions
+		   SET (memorized_name,
+		        memorized_body)
+		     = (:memorized_name,
+		        :memorized_body)
+		 WHERE memorized_id = :memorized_id
+		 RETURNING *`
+
+	stmt, err := store.Client.PrepareNamed(query)
+	if err != nil {
+		return fmt.Errorf("store.Client.PrepareNamed(query):%w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(memorized).StructScan(memorized)
+	if err != nil {
+		return fmt.Errorf("stmt.QueryRow().StructScan():%w", err)
+	}
+
+	return nil
+}
+
+// RetrieveByID retrieves a MemorizedTransaction
+func (store MemorizedTransactionStore) RetrieveByID(memorizedID uint64) (*MemorizedTransaction, error) {
+	query := `SELECT * FROM memorized_transactions WHERE memorized_id = $1`
+
+	var memorized MemorizedTransaction
+
+	if err := store.Client.QueryRowx(query, memorizedID).StructScan(&memorized); err != nil {
+		return nil, fmt.Errorf("row.StructScan:%w", err)
+	}
+
+	return &memorized, nil
+}
+
+// Retrieve gets all MemorizedTransactions in name order
+func (store MemorizedTransactionStore) Retrieve() ([]*MemorizedTransaction, error) {
+	query := `SELECT * FROM memorized_transactions ORDER BY memorized_name, memorized_id`
+
+	rows, err := store.Client.Queryx(query)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	var memorizedSet []*MemorizedTransaction
+
+	for rows.Next() {
+		var memorized MemorizedTransaction
+		if err = rows.StructScan(&memorized); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		memorizedSet = append(memorizedSet, &memorized)
+	}
+
+	return memorizedSet, nil
+}
+
+// Delete a MemorizedTransaction, the transactions made from it are kept
+func (store MemorizedTransactionStore) Delete(memorized *MemorizedTransaction) error {
+	query := `DELETE FROM memorized_transactions WHERE memorized_id = $1`
+
+	res, err := store.Client.Exec(query, memorized.MemorizedID)
+	if err != nil {
+		return fmt.Errorf("store.Client.Exec:%w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("res.RowsAffected:%w", err)
+	}
+
+	if count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -1,6 +1,8 @@
 package datastore
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -8,6 +10,7 @@ import (
 
 	_ "github.com/jackc/pgx/v5/stdlib" // Standard library bindings for pgx.
 	"github.com/jmoiron/sqlx"
+	"github.com/mimirsoft/mimirledger/api/blobstore"
 )
 
 const (
@@ -15,11 +18,25 @@ const (
 )
 
 type Datastores struct {
-	postgresClient     *sqlx.DB
-	accountStore       AccountStore
-	transactionStore   TransactionStore
-	transactionDCStore TransactionDebitCreditStore
-	reportStore        ReportStore
+	postgresClient *sqlx.DB
+	// client is postgresClient, or a transaction of it for the Datastores of InTransaction
+	client              DBClient
+	accountStore        AccountStore
+	archiveStore        ArchiveStore
+	attachmentStore     AttachmentStore
+	blobStore           blobstore.Store
+	memorizedStore      MemorizedTransactionStore
+	reconciliationStore ReconciliationStore
+	ruleStore           CategorizationRuleStore
+	scheduledStore      ScheduledTransactionStore
+	transactionStore    TransactionStore
+	transactionDCStore  TransactionDebitCreditStore
+	duplicateStore      TransactionDuplicateStore
+	reportStore         ReportStore
+	reportTemplateStore ReportTemplateStore
+	settingStore        SettingStore
+	importStore         ImportStore
+	importProfileStore  ImportProfileStore
 }
 
 // AccountStore is the way to access the AccountStore.
@@ -27,6 +44,41 @@ func (ds *Datastores) AccountStore() AccountStore {
 	return ds.accountStore
 }
 
+// ArchiveStore is the way to access the ArchiveStore.
+func (ds *Datastores) ArchiveStore() ArchiveStore {
+	return ds.archiveStore
+}
+
+// AttachmentStore is the way to access the AttachmentStore.
+func (ds *Datastores) AttachmentStore() AttachmentStore {
+	return ds.attachmentStore
+}
+
+// BlobStore is the way to access the blob store the files of attachments are kept in.
+func (ds *Datastores) BlobStore() blobstore.Store {
+	return ds.blobStore
+}
+
+// CategorizationRuleStore is the way to access the CategorizationRuleStore.
+func (ds *Datastores) CategorizationRuleStore() CategorizationRuleStore {
+	return ds.ruleStore
+}
+
+// MemorizedTransactionStore is the way to access the MemorizedTransactionStore.
+func (ds *Datastores) MemorizedTransactionStore() MemorizedTransactionStore {
+	return ds.memorizedStore
+}
+
+// ReconciliationStore is the way to access the ReconciliationStore.
+func (ds *Datastores) ReconciliationStore() ReconciliationStore {
+	return ds.reconciliationStore
+}
+
+// ScheduledTransactionStore is the way to access the ScheduledTransactionStore.
+func (ds *Datastores) ScheduledTransactionStore() ScheduledTransactionStore {
+	return ds.scheduledStore
+}
+
 // TransactionStore is the way to access the TransactionStore.
 func (ds *Datastores) TransactionStore() TransactionStore {
 	return ds.transactionStore
@@ -37,33 +89,169 @@ func (ds *Datastores) TransactionDebitCreditStore() TransactionDebitCreditStore
 	return ds.transactionDCStore
 }
 
+// TransactionDuplicateStore is the way to access the TransactionDuplicateStore.
+func (ds *Datastores) TransactionDuplicateStore() TransactionDuplicateStore {
+	return ds.duplicateStore
+}
+
 // ReportStore is the way to access the ReportStore.
 func (ds *Datastores) ReportStore() ReportStore {
 	return ds.reportStore
 }
 
+// ReportTemplateStore is the way to access the ReportTemplateStore.
+func (ds *Datastores) ReportTemplateStore() ReportTemplateStore {
+	return ds.reportTemplateStore
+}
+
+// SettingStore is the way to access the SettingStore.
+func (ds *Datastores) SettingStore() SettingStore {
+	return ds.settingStore
+}
+
+// ImportStore is the way to access the ImportStore.
+func (ds *Datastores) ImportStore() ImportStore {
+	return ds.importStore
+}
+
+// ImportProfileStore is the way to access the ImportProfileStore.
+func (ds *Datastores) ImportProfileStore() ImportProfileStore {
+	return ds.importProfileStore
+}
+
 // PGClient is the way to access the Postgres Client
 func (ds *Datastores) PGClient() *sqlx.DB {
 	return ds.postgresClient
 }
 
-func NewDatastores(conn *sqlx.DB) *Datastores {
+func NewDatastores(conn *sqlx.DB, blobs blobstore.Store) *Datastores {
+	return newDatastores(conn, conn, blobs)
+}
+
+// newDatastores builds the stores on client, which is conn or a transaction of it.  A restore always runs in a
+// transaction of its own, so the ArchiveStore is on conn.
+func newDatastores(conn *sqlx.DB, client DBClient, blobs blobstore.Store) *Datastores {
 	return &Datastores{postgresClient: conn,
+		client:    client,
+		blobStore: blobs,
 		accountStore: AccountStore{
+			Client: client,
+		},
+		archiveStore: ArchiveStore{
 			Client: conn,
 		},
+		attachmentStore: AttachmentStore{
+			Client: client,
+		},
+		memorizedStore: MemorizedTransactionStore{
+			Client: client,
+		},
+		reconciliationStore: ReconciliationStore{
+			Client: client,
+		},
+		ruleStore: CategorizationRuleStore{
+			Client: client,
+		},
+		scheduledStore: ScheduledTransactionStore{
+			Client: client,
+		},
+		importStore: ImportStore{
+			Client: client,
+		},
+		importProfileStore: ImportProfileStore{
+			Client: client,
+		},
 		reportStore: ReportStore{
+			Client: client,
+		},
+		reportTemplateStore: ReportTemplateStore{
+			Client: client,
+		},
+		settingStore: SettingStore{
 			Client: conn,
 		},
 		transactionStore: TransactionStore{
-			Client: conn,
+			Client: client,
 		},
 		transactionDCStore: TransactionDebitCreditStore{
-			Client: conn,
+			Client: client,
+		},
+		duplicateStore: TransactionDuplicateStore{
+			Client: client,
 		},
 	}
 }
 
+// InTransaction runs myFunc with Datastores whose stores all run in one database transaction, which is committed
+// when myFunc returns nil and rolled back when it returns an error.  Calling it on the Datastores of a transaction
+// runs myFunc in a savepoint of that transaction.
+func (ds *Datastores) InTransaction(myFunc func(txStores *Datastores) error) error {
+	return inTransaction(ds.client, func(tx *sqlx.Tx) error {
+		return myFunc(newDatastores(ds.postgresClient, tx, ds.blobStore))
+	})
+}
+
+// DBClient is what the stores run their queries on, the database or a transaction of it
+type DBClient interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Queryx(query string, args ...any) (*sqlx.Rows, error)
+	QueryRowx(query string, args ...any) *sqlx.Row
+	PrepareNamed(query string) (*sqlx.NamedStmt, error)
+	Select(dest any, query string, args ...any) error
+}
+
+// inTransaction runs myFunc in a transaction of client, committed when myFunc returns nil and rolled back when it
+// returns an error.  When client is already a transaction myFunc runs in a savepoint of it, so a failure only undoes
+// the work of myFunc and the outer transaction decides whether the rest is kept.
+func inTransaction(client DBClient, myFunc func(tx *sqlx.Tx) error) error {
+	if tx, ok := client.(*sqlx.Tx); ok {
+		return inSavepoint(tx, myFunc)
+	}
+
+	conn, ok := client.(*sqlx.DB)
+	if !ok {
+		return fmt.Errorf("%w: %T", ErrUnknownDBClient, client)
+	}
+
+	tx, err := conn.Beginx()
+	if err != nil {
+		return fmt.Errorf("conn.Beginx:%w", err)
+	}
+
+	if err = myFunc(tx); err != nil {
+		_ = tx.Rollback()
+
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("tx.Commit:%w", err)
+	}
+
+	return nil
+}
+
+// inSavepoint runs myFunc in a savepoint of tx, rolling back to the savepoint when it returns an error
+func inSavepoint(tx *sqlx.Tx, myFunc func(tx *sqlx.Tx) error) error {
+	if _, err := tx.Exec(`SAVEPOINT nested_transaction`); err != nil {
+		return fmt.Errorf("tx.Exec(SAVEPOINT):%w", err)
+	}
+
+	if err := myFunc(tx); err != nil {
+		_, _ = tx.Exec(`ROLLBACK TO SAVEPOINT nested_transaction`)
+
+		return err
+	}
+
+	if _, err := tx.Exec(`RELEASE SAVEPOINT nested_transaction`); err != nil {
+		return fmt.Errorf("tx.Exec(RELEASE SAVEPOINT):%w", err)
+	}
+
+	return nil
+}
+
+var ErrUnknownDBClient = errors.New("unknown database client")
+
 type PostgresConfig struct {
 	Host, Username, Password, DBName string
 	Port, MaxConnLifetime            int
@@ -0,0 +1,421 @@
+package datastore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ReconciliationStore struct {
+	Client DBClient
+}
+
+// ReconciliationStatus is an enum for the state of a ReconciliationSession
+type ReconciliationStatus string
+
+const (
+	ReconciliationStatusOpen     = ReconciliationStatus("OPEN")
+	ReconciliationStatusFinished = ReconciliationStatus("FINISHED")
+)
+
+// ReconciliationSession is a statement being reconciled against an account.  OpeningBalance is the reconciled
+// subtotal of the account when the session was started.  ClearedCount, ClearedBalance and FinishedBy are set when
+// the session is finished.
+type ReconciliationSession struct {
+	SessionID        uint64               `db:"session_id,omitempty"`
+	AccountID        uint64               `db:"account_id"`
+	StatementDate    time.Time            `db:"statement_date"`
+	StatementBalance int64                `db:"statement_balance"`
+	OpeningBalance   int64                `db:"opening_balance"`
+	SessionStatus    ReconciliationStatus `db:"session_status"`
+	CreatedDate      time.Time            `db:"created_date,omitempty"`
+	FinishedDate     sql.NullTime         `db:"finished_date"`
+	ClearedCount     int                  `db:"cleared_count"`
+	ClearedBalance   int64                `db:"cleared_balance"`
+	FinishedBy       string               `db:"finished_by"`
+}
+
+// ClearedTransaction is a transaction as it was when a session that cleared it was finished, next to how it is now.
+// ClearedAmount is what it posted to the account, in the sign of the account, and the current reconciled state is of
+// its lines on the account.  The current fields are zero when the transaction has been deleted.
+type ClearedTransaction struct {
+	SessionID                       uint64       `db:"session_id"`
+	TransactionID                   uint64       `db:"transaction_id"`
+	TransactionDate                 time.Time    `db:"transaction_date"`
+	TransactionComment              string       `db:"transaction_comment"`
+	TransactionReference            string       `db:"transaction_reference"`
+	ClearedAmount                   int64        `db:"cleared_amount"`
+	TransactionModifiedDate         time.Time    `db:"transaction_modified_date"`
+	IsDeleted                       bool         `db:"is_deleted"`
+	CurrentTransactionDate          sql.NullTime `db:"current_transaction_date"`
+	CurrentTransactionComment       string       `db:"current_transaction_comment"`
+	CurrentTransactionReference     string       `db:"current_transaction_reference"`
+	CurrentAmount                   int64        `db:"current_amount"`
+	CurrentTransactionModifiedDate  sql.NullTime `db:"current_transaction_modified_date"`
+	CurrentIsReconciled             bool         `db:"current_is_reconciled"`
+	CurrentTransactionReconcileDate sql.NullTime `db:"current_transaction_reconcile_date"`
+}
+
+// Store inserts a ReconciliationSession, we do not include :session_id in our insert
+func (store ReconciliationStore) Store(session *ReconciliationSession) error {
+	query := `INSERT INTO reconciliation_sessions
+		           (account_id,
+		            statement_date,
+		            statement_balance,
+		            opening_balance,
+		            session_status)
+		    VALUES (:account_id,
+		            :statement_date,
+		            :statement_balance,
+		            :opening_balance,
+		            :session_status)
+		 RETURNING *`
+
+	stmt, err := store.Client.PrepareNamed(query)
+	if err != nil {
+		return fmt.Errorf("store.Client.PrepareNamed(query):%w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(session).StructScan(session)
+	if err != nil {
+		return fmt.Errorf("stmt.QueryRow().StructScan():%w", err)
+	}
+
+	return nil
+}
+
+// RetrieveByID retrieves a ReconciliationSession
+func (store ReconciliationStore) RetrieveByID(sessionID uint64) (*ReconciliationSession, error) {
+	query := `SELECT * FROM reconciliation_sessions WHERE session_id = $1`
+
+	var session ReconciliationSession
+
+	if err := store.Client.QueryRowx(query, sessionID).StructScan(&session); err != nil {
+		return nil, fmt.Errorf("row.StructScan:%w", err)
+	}
+
+	return &session, nil
+}
+
+// RetrieveByAccount gets the ReconciliationSessions of an account, the latest statement first
+func (store ReconciliationStore) RetrieveByAccount(accountID uint64) ([]*ReconciliationSession, error) {
+	query := `SELECT * FROM reconciliation_sessions
+		       WHERE account_id = $1
+		    ORDER BY statement_date DESC, session_id DESC`
+
+	rows, err := store.Client.Queryx(query, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	var sessionSet []*ReconciliationSession
+
+	for rows.Next() {
+		var session ReconciliationSession
+		if err = rows.StructScan(&session); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		sessionSet = append(sessionSet, &session)
+	}
+
+	return sessionSet, nil
+}
+
+// Delete a ReconciliationSession that is still open, sql.ErrNoRows when there is no open session
+func (store ReconciliationStore) Delete(session *ReconciliationSession) error {
+	query := `DELETE FROM reconciliation_sessions WHERE session_id = $1 AND session_status = $2`
+
+	res, err := store.Client.Exec(query, session.SessionID, ReconciliationStatusOpen)
+	if err != nil {
+		return fmt.Errorf("store.Client.Exec:%w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("res.RowsAffected:%w", err)
+	}
+
+	if count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetTransactionIDs gets the IDs of the transactions ticked in a session
+func (store ReconciliationStore) GetTransactionIDs(sessionID uint64) ([]uint64, error) {
+	query := `SELECT transaction_id FROM reconciliation_session_transactions
+		       WHERE session_id = $1
+		    ORDER BY transaction_id`
+
+	var transactionIDs []uint64
+
+	if err := store.Client.Select(&transactionIDs, query, sessionID); err != nil {
+		return nil, fmt.Errorf("store.Client.Select:%w", err)
+	}
+
+	return transactionIDs, nil
+}
+
+// StoreTransaction ticks a transaction in a session, ticking it again does nothing
+func (store ReconciliationStore) StoreTransaction(sessionID, transactionID uint64) error {
+	query := `INSERT INTO reconciliation_session_transactions
+		           (session_id,
+		            transaction_id)
+		    VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`
+
+	if _, err := store.Client.Exec(query, sessionID, transactionID); err != nil {
+		return fmt.Errorf("store.Client.Exec:%w", err)
+	}
+
+	return nil
+}
+
+// DeleteTransaction ticks a transaction out of a session, sql.ErrNoRows when it was not in the session
+func (store ReconciliationStore) DeleteTransaction(sessionID, transactionID uint64) error {
+	query := `DELETE FROM reconciliation_session_transactions WHERE session_id = $1 AND transaction_id = $2`
+
+	res, err := store.Client.Exec(query, sessionID, transactionID)
+	if err != nil {
+		return fmt.Errorf("store.Client.Exec:%w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("res.RowsAffected:%w", err)
+	}
+
+	if count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetClearedSubtotals gets the debit and credit subtotals the ticked transactions of a session post to the accounts
+// between accountLeft and accountRight
+func (store ReconciliationStore) GetClearedSubtotals(sessionID, accountLeft,
+	accountRight uint64) ([]*AccountSubtotal, error) {
+	query := `SELECT SUM(dc.transaction_dc_amount) AS subtotal, dc.debit_or_credit
+		        FROM reconciliation_session_transactions AS rst
+		  INNER JOIN transaction_debit_credit AS dc
+		          ON dc.transaction_id = rst.transaction_id
+		       WHERE rst.session_id = $1
+		         AND dc.account_id
+		             IN (SELECT account_id FROM transaction_accounts WHERE account_left BETWEEN $2 AND $3)
+		    GROUP BY dc.debit_or_credit`
+
+	rows, err := store.Client.Queryx(query, sessionID, accountLeft, accountRight)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	var subtotalSet []*AccountSubtotal
+
+	for rows.Next() {
+		var subtotal AccountSubtotal
+		if err = rows.StructScan(&subtotal); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		subtotalSet = append(subtotalSet, &subtotal)
+	}
+
+	return subtotalSet, nil
+}
+
+// ErrReconciliationChanged is when the ticked transactions of a session changed after its cleared balance was worked
+// out
+var ErrReconciliationChanged = errors.New("reconciliation session transactions changed")
+
+// Finish closes an open session in a single database transaction, marking its ticked transactions reconciled on the
+// statement date and advancing the reconcile date of the account to the statement date.  The ticked transactions are
+// kept as they are now, posting to the accounts between accountLeft and accountRight.  sql.ErrNoRows when the
+// session is not open, ErrReconciliationChanged when the ticked transactions no longer clear session.ClearedBalance
+// or one of them was reconciled since, and then nothing is changed.
+func (store ReconciliationStore) Finish(session *ReconciliationSession, accountLeft, accountRight uint64,
+	accountSign AccountSign) error {
+	return inTransaction(store.Client, func(tx *sqlx.Tx) error {
+		return finishSession(tx, session, accountLeft, accountRight, accountSign)
+	})
+}
+
+func finishSession(tx *sqlx.Tx, session *ReconciliationSession, accountLeft, accountRight uint64,
+	accountSign AccountSign) error {
+	query := `UPDATE reconciliation_sessions
+		         SET session_status = $2,
+		             finished_date = NOW(),
+		             cleared_count = $4,
+		             cleared_balance = $5,
+		             finished_by = $6
+		       WHERE session_id = $1
+		         AND session_status = $3
+		   RETURNING *`
+
+	if err := tx.QueryRowx(query, session.SessionID, ReconciliationStatusFinished, ReconciliationStatusOpen,
+		session.ClearedCount, session.ClearedBalance, session.FinishedBy).StructScan(session); err != nil {
+		return fmt.Errorf("tx.QueryRowx.StructScan:%w", err)
+	}
+
+	// the session is locked now, so the cleared balance is worked out again in case a ticked transaction was edited
+	query = `SELECT COALESCE(SUM(CASE WHEN dc.debit_or_credit = $4 THEN dc.transaction_dc_amount
+		                              ELSE -dc.transaction_dc_amount END), 0)
+		       FROM reconciliation_session_transactions AS rst
+		 INNER JOIN transaction_debit_credit AS dc
+		         ON dc.transaction_id = rst.transaction_id
+		      WHERE rst.session_id = $1
+		        AND dc.account_id
+		            IN (SELECT account_id FROM transaction_accounts WHERE account_left BETWEEN $2 AND $3)`
+
+	var clearedNet int64
+
+	if err := tx.QueryRowx(query, session.SessionID, accountLeft, accountRight, accountSign).
+		Scan(&clearedNet); err != nil {
+		return fmt.Errorf("tx.QueryRowx.Scan:%w", err)
+	}
+
+	if session.OpeningBalance+clearedNet != session.ClearedBalance {
+		return fmt.Errorf("%w: [clearedBalance:%d]", ErrReconciliationChanged, session.OpeningBalance+clearedNet)
+	}
+
+	transactionIDs := []uint64{}
+
+	query = `SELECT transaction_id FROM reconciliation_session_transactions WHERE session_id = $1`
+
+	if err := tx.Select(&transactionIDs, query, session.SessionID); err != nil {
+		return fmt.Errorf("tx.Select:%w", err)
+	}
+
+	if len(transactionIDs) != session.ClearedCount {
+		return fmt.Errorf("%w: [ticked:%d]", ErrReconciliationChanged, len(transactionIDs))
+	}
+
+	query = `INSERT INTO reconciliation_cleared_transactions
+		           (session_id,
+		            transaction_id,
+		            transaction_date,
+		            transaction_comment,
+		            transaction_reference,
+		            cleared_amount,
+		            transaction_modified_date)
+		     SELECT rst.session_id, tm.transaction_id, tm.transaction_date, tm.transaction_comment,
+		            COALESCE(tm.transaction_reference, ''),
+		            SUM(CASE WHEN dc.debit_or_credit = $4 THEN dc.transaction_dc_amount
+		                     ELSE -dc.transaction_dc_amount END),
+		            tm.transaction_modified_date
+		       FROM reconciliation_session_transactions AS rst
+		 INNER JOIN transaction_main AS tm
+		         ON tm.transaction_id = rst.transaction_id
+		 INNER JOIN transaction_debit_credit AS dc
+		         ON dc.transaction_id = tm.transaction_id
+		      WHERE rst.session_id = $1
+		        AND dc.account_id
+		            IN (SELECT account_id FROM transaction_accounts WHERE account_left BETWEEN $2 AND $3)
+		   GROUP BY rst.session_id, tm.transaction_id`
+
+	if _, err := tx.Exec(query, session.SessionID, accountLeft, accountRight, accountSign); err != nil {
+		return fmt.Errorf("tx.Exec:%w", err)
+	}
+
+	// only lines that are still unreconciled are reconciled, each ticked transaction must have one
+	query = `WITH reconciled AS (
+		             UPDATE transaction_debit_credit
+		                SET is_reconciled = TRUE,
+		                    transaction_reconcile_date = $2
+		              WHERE transaction_id
+		                    IN (SELECT transaction_id FROM reconciliation_session_transactions WHERE session_id = $1)
+		                AND account_id
+		                    IN (SELECT account_id FROM transaction_accounts WHERE account_left BETWEEN $3 AND $4)
+		                AND is_reconciled = FALSE
+		          RETURNING transaction_id)
+		     SELECT COUNT(DISTINCT transaction_id) FROM reconciled`
+
+	var reconciledCount int
+
+	if err := tx.QueryRowx(query, session.SessionID, session.StatementDate, accountLeft, accountRight).
+		Scan(&reconciledCount); err != nil {
+		return fmt.Errorf("tx.QueryRowx.Scan:%w", err)
+	}
+
+	if reconciledCount != len(transactionIDs) {
+		return fmt.Errorf("%w: [reconciled:%d, ticked:%d]", ErrReconciliationChanged, reconciledCount,
+			len(transactionIDs))
+	}
+
+	if _, err := refreshTransactionReconciled(tx, transactionIDs); err != nil {
+		return err
+	}
+
+	query = `UPDATE transaction_accounts
+		        SET account_reconcile_date = $2
+		      WHERE account_id = $1`
+
+	if _, err := tx.Exec(query, session.AccountID, session.StatementDate); err != nil {
+		return fmt.Errorf("tx.Exec:%w", err)
+	}
+
+	return nil
+}
+
+// GetClearedTransactions gets the transactions a finished session cleared, as they were and as they are now
+func (store ReconciliationStore) GetClearedTransactions(sessionID, accountLeft, accountRight uint64,
+	accountSign AccountSign) ([]*ClearedTransaction, error) {
+	query := `SELECT rct.session_id, rct.transaction_id, rct.transaction_date, rct.transaction_comment,
+		             rct.transaction_reference, rct.cleared_amount, rct.transaction_modified_date,
+		             tm.transaction_id IS NULL AS is_deleted,
+		             tm.transaction_date AS current_transaction_date,
+		             COALESCE(tm.transaction_comment, '') AS current_transaction_comment,
+		             COALESCE(tm.transaction_reference, '') AS current_transaction_reference,
+		             COALESCE((SELECT SUM(CASE WHEN dc.debit_or_credit = $4 THEN dc.transaction_dc_amount
+		                                       ELSE -dc.transaction_dc_amount END)
+		                         FROM transaction_debit_credit AS dc
+		                        WHERE dc.transaction_id = rct.transaction_id
+		                          AND dc.account_id
+		                              IN (SELECT account_id FROM transaction_accounts
+		                                   WHERE account_left BETWEEN $2 AND $3)), 0) AS current_amount,
+		             tm.transaction_modified_date AS current_transaction_modified_date,
+		             COALESCE((SELECT bool_and(dc.is_reconciled)
+		                         FROM transaction_debit_credit AS dc
+		                        WHERE dc.transaction_id = rct.transaction_id
+		                          AND dc.account_id
+		                              IN (SELECT account_id FROM transaction_accounts
+		                                   WHERE account_left BETWEEN $2 AND $3)), FALSE) AS current_is_reconciled,
+		             (SELECT MAX(dc.transaction_reconcile_date)
+		                FROM transaction_debit_credit AS dc
+		               WHERE dc.transaction_id = rct.transaction_id
+		                 AND dc.account_id
+		                     IN (SELECT account_id FROM transaction_accounts
+		                          WHERE account_left BETWEEN $2 AND $3)) AS current_transaction_reconcile_date
+		        FROM reconciliation_cleared_transactions AS rct
+		   LEFT JOIN transaction_main AS tm
+		          ON tm.transaction_id = rct.transaction_id
+		       WHERE rct.session_id = $1
+		    ORDER BY rct.transaction_date, rct.transaction_id`
+
+	rows, err := store.Client.Queryx(query, sessionID, accountLeft, accountRight, accountSign)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	var clearedSet []*ClearedTransaction
+
+	for rows.Next() {
+		var cleared ClearedTransaction
+		if err = rows.StructScan(&cleared); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		clearedSet = append(clearedSet, &cleared)
+	}
+
+	return clearedSet, nil
+}
@@ -6,12 +6,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-
-	"github.com/jmoiron/sqlx"
 )
 
 type ReportStore struct {
-	Client *sqlx.DB
+	Client DBClient
 }
 
 type Report struct {
@@ -0,0 +1,146 @@
+package datastore
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+type ReportTemplateStore struct {
+	Client DBClient
+}
+
+// ReportTemplateType is an enum for the template engine a ReportTemplate is parsed with
+type ReportTemplateType string
+
+const (
+	ReportTemplateTypeText = ReportTemplateType("TEXT")
+	ReportTemplateTypeHTML = ReportTemplateType("HTML")
+)
+
+// ReportTemplate is a user defined layout for report output and account statements
+type ReportTemplate struct {
+	TemplateID   uint64             `db:"template_id,omitempty"`
+	TemplateName string             `db:"template_name"`
+	TemplateType ReportTemplateType `db:"template_type"`
+	TemplateBody string             `db:"template_body"`
+}
+
+// Store inserts a ReportTemplate into postgres, we do not include :template_id in our insert
+func (store ReportTemplateStore) Store(myTemplate *ReportTemplate) error {
+	query := `INSERT INTO report_templates
+		           (template_name,
+		            template_type,
+		            template_body)
+		    VALUES (:template_name,
+		            :template_type,
+		            :template_body)
+		 RETURNING *`
+
+	stmt, err := store.Client.PrepareNamed(query)
+	if err != nil {
+		return fmt.Errorf("error preparing report template insert: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(myTemplate).StructScan(myTemplate)
+	if err != nil {
+		return fmt.Errorf("stmt.QueryRow().StructScan():%w", err)
+	}
+
+	return nil
+}
+
+// Update updates the name, type and body of a ReportTemplate
+func (store ReportTemplateStore) Update(myTemplate *ReportTemplate) error {
+	query := `UPDATE report_templates
+		   SET (template_name,
+		        template_type,
+		        template_body)
+		     = (:template_name,
+		        :template_type,
+		        :template_body)
+		 WHERE template_id = :template_id
+		 RETURNING *`
+
+	stmt, err := store.Client.PrepareNamed(query)
+	if err != nil {
+		return fmt.Errorf("error preparing report template update: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(myTemplate).StructScan(myTemplate)
+	if err != nil {
+		return fmt.Errorf("stmt.QueryRow().StructScan():%w", err)
+	}
+
+	return nil
+}
+
+func (store ReportTemplateStore) RetrieveByID(id uint64) (*ReportTemplate, error) {
+	query := `select * from report_templates where template_id = $1`
+
+	row := store.Client.QueryRowx(query, id)
+
+	var myTemplate ReportTemplate
+
+	if err := row.StructScan(&myTemplate); err != nil {
+		return nil, fmt.Errorf("row.StructScan(&tn):%w", err)
+	}
+
+	return &myTemplate, nil
+}
+
+func (store ReportTemplateStore) RetrieveByName(name string) (*ReportTemplate, error) {
+	query := `select * from report_templates where template_name = $1`
+
+	row := store.Client.QueryRowx(query, name)
+
+	var myTemplate ReportTemplate
+
+	if err := row.StructScan(&myTemplate); err != nil {
+		return nil, fmt.Errorf("row.StructScan(&tn):%w", err)
+	}
+
+	return &myTemplate, nil
+}
+
+// Gets All ReportTemplates.
+func (store ReportTemplateStore) Retrieve() ([]*ReportTemplate, error) {
+	query := `select * from report_templates order by template_name`
+
+	rows, err := store.Client.Queryx(query)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	var set []*ReportTemplate
+
+	for rows.Next() {
+		var myTemplate ReportTemplate
+		if err = rows.StructScan(&myTemplate); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		set = append(set, &myTemplate)
+	}
+
+	if len(set) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return set, nil
+}
+
+// Delete a ReportTemplate
+func (store ReportTemplateStore) Delete(myTemplate *ReportTemplate) error {
+	query := `Delete FROM report_templates 
+		         where template_id = $1`
+
+	_, err := store.Client.Exec(query, myTemplate.TemplateID)
+	if err != nil {
+		return fmt.Errorf("store.Client.Exec:%w", err)
+	}
+
+	return nil
+}
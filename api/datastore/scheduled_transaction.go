@@ -0,0 +1,322 @@
+package datastore
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type ScheduledTransactionStore struct {
+	Client DBClient
+}
+
+// OccurrenceStatus is an enum for what happened to an occurrence of a ScheduledTransaction
+type OccurrenceStatus string
+
+const (
+	OccurrenceStatusPosting = OccurrenceStatus("POSTING")
+	OccurrenceStatusPosted  = OccurrenceStatus("POSTED")
+	OccurrenceStatusSkipped = OccurrenceStatus("SKIPPED")
+)
+
+// ScheduledTransaction is a transaction posted on each day of its RecurrenceRule from StartDate through EndDate.
+// NextDue is the day the scheduler posts it from, the occurrences before it are only posted by hand.
+type ScheduledTransaction struct {
+	ScheduledID         uint64              `db:"scheduled_id,omitempty"`
+	ScheduledName       string              `db:"scheduled_name"`
+	RecurrenceRule      string              `db:"recurrence_rule"`
+	StartDate           time.Time           `db:"start_date"`
+	EndDate             sql.NullTime        `db:"end_date"`
+	IsEnabled           bool                `db:"is_enabled"`
+	TransactionTemplate TransactionTemplate `db:"transaction_template"`
+	NextDue             time.Time           `db:"next_due"`
+}
+
+// TransactionTemplate is the transaction a ScheduledTransaction posts, without its date
+type TransactionTemplate struct {
+	TransactionComment   string                 `json:"transactionComment"`
+	TransactionReference string                 `json:"transactionReference"`
+	DebitCreditSet       []*TemplateDebitCredit `json:"debitCreditSet"`
+}
+
+// TemplateDebitCredit is a line of a TransactionTemplate
+type TemplateDebitCredit struct {
+	AccountID           uint64      `json:"accountID"`
+	DebitOrCredit       AccountSign `json:"debitOrCredit"`
+	TransactionDCAmount uint64      `json:"transactionDCAmount"`
+}
+
+// ScheduledOccurrence is an occurrence of a ScheduledTransaction that was claimed to be posted, posted or skipped.
+// An occurrence without a row is still to be posted.
+type ScheduledOccurrence struct {
+	ScheduledID      uint64           `db:"scheduled_id"`
+	OccurrenceDate   time.Time        `db:"occurrence_date"`
+	OccurrenceStatus OccurrenceStatus `db:"occurrence_status"`
+	TransactionID    sql.NullInt64    `db:"transaction_id"`
+	ClaimedDate      time.Time        `db:"claimed_date,omitempty"`
+}
+
+// Value implements driver.Valuer, the template is stored as JSON
+func (tt TransactionTemplate) Value() (driver.Value, error) {
+	return json.Marshal(tt) //nolint:wrapcheck
+}
+
+var errTransactionTemplateScanFailed = errors.New("failed to scan transaction template:type assertion failed")
+
+// Scan implements sql.Scanner, decoding the JSON template
+func (tt *TransactionTemplate) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errTransactionTemplateScanFailed
+	}
+
+	return json.Unmarshal(b, tt) //nolint:wrapcheck
+}
+
+// Store inserts a ScheduledTransaction, we do not include :scheduled_id in our insert
+func (store ScheduledTransactionStore) Store(scheduled *ScheduledTransaction) error {
+	query := `INSERT INTO scheduled_transactions
+		           (scheduled_name,
+		            recurrence_rule,
+		            start_date,
+		            end_date,
+		            is_enabled,
+		            transaction_template,
+		            next_due)
+		    VALUES (:scheduled_name,
+		            :recurrence_rule,
+		            :start_date,
+		            :end_date,
+		            :is_enabled,
+		            :transaction_template,
+		            :next_due)
+		 RETURNING *`
+
+	stmt, err := store.Client.PrepareNamed(query)
+	if err != nil {
+		return fmt.Errorf("store.Client.PrepareNamed(query):%w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(scheduled).StructScan(scheduled)
+	if err != nil {
+		return fmt.Errorf("stmt.QueryRow().StructScan():%w", err)
+	}
+
+	return nil
+}
+
+// Update updates a ScheduledTransaction, the occurrences already posted or skipped and NextDue are kept
+func (store ScheduledTransactionStore) Update(scheduled *ScheduledTransaction) error {
+	query := `UPDATE scheduled_transactions
+		   SET (scheduled_name,
+		        recurrence_rule,
+		        start_date,
+		        end_date,
+		        is_enabled,
+		        transaction_template)
+		     = (:scheduled_name,
+		        :recurrence_rule,
+		        :start_date,
+		        :end_date,
+		        :is_enabled,
+		        :transaction_template)
+		 WHERE scheduled_id = :scheduled_id
+		 RETURNING *`
+
+	stmt, err := store.Client.PrepareNamed(query)
+	if err != nil {
+		return fmt.Errorf("store.Client.PrepareNamed(query):%w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(scheduled).StructScan(scheduled)
+	if err != nil {
+		return fmt.Errorf("stmt.QueryRow().StructScan():%w", err)
+	}
+
+	return nil
+}
+
+// RetrieveByID retrieves a ScheduledTransaction
+func (store ScheduledTransactionStore) RetrieveByID(scheduledID uint64) (*ScheduledTransaction, error) {
+	query := `SELECT * FROM scheduled_transactions WHERE scheduled_id = $1`
+
+	var scheduled ScheduledTransaction
+
+	if err := store.Client.QueryRowx(query, scheduledID).StructScan(&scheduled); err != nil {
+		return nil, fmt.Errorf("row.StructScan:%w", err)
+	}
+
+	return &scheduled, nil
+}
+
+// Retrieve gets the ScheduledTransactions, only the enabled ones when onlyEnabled is set
+func (store ScheduledTransactionStore) Retrieve(onlyEnabled bool) ([]*ScheduledTransaction, error) {
+	query := `SELECT *
+		    FROM scheduled_transactions
+		   WHERE is_enabled OR NOT $1
+		ORDER BY scheduled_name, scheduled_id`
+
+	rows, err := store.Client.Queryx(query, onlyEnabled)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	var scheduledSet []*ScheduledTransaction
+
+	for rows.Next() {
+		var scheduled ScheduledTransaction
+		if err = rows.StructScan(&scheduled); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		scheduledSet = append(scheduledSet, &scheduled)
+	}
+
+	return scheduledSet, nil
+}
+
+// Delete a ScheduledTransaction and its occurrences, the transactions it posted are kept
+func (store ScheduledTransactionStore) Delete(scheduled *ScheduledTransaction) error {
+	query := `DELETE FROM scheduled_transactions WHERE scheduled_id = $1`
+
+	res, err := store.Client.Exec(query, scheduled.ScheduledID)
+	if err != nil {
+		return fmt.Errorf("store.Client.Exec:%w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("res.RowsAffected:%w", err)
+	}
+
+	if count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// SetNextDue sets the day a ScheduledTransaction is posted from, which may be earlier to post past occurrences
+func (store ScheduledTransactionStore) SetNextDue(scheduled *ScheduledTransaction) error {
+	query := `UPDATE scheduled_transactions
+		     SET next_due = :next_due
+		   WHERE scheduled_id = :scheduled_id
+		RETURNING *`
+
+	stmt, err := store.Client.PrepareNamed(query)
+	if err != nil {
+		return fmt.Errorf("store.Client.PrepareNamed(query):%w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(scheduled).StructScan(scheduled)
+	if err != nil {
+		return fmt.Errorf("stmt.QueryRow().StructScan():%w", err)
+	}
+
+	return nil
+}
+
+// AdvanceNextDue moves the day a ScheduledTransaction is posted from to nextDue.  It is never moved back, so the
+// schedulers running side by side cannot undo each other.
+func (store ScheduledTransactionStore) AdvanceNextDue(scheduledID uint64, nextDue time.Time) error {
+	query := `UPDATE scheduled_transactions
+		     SET next_due = $2
+		   WHERE scheduled_id = $1
+		     AND next_due < $2`
+
+	if _, err := store.Client.Exec(query, scheduledID, nextDue); err != nil {
+		return fmt.Errorf("store.Client.Exec:%w", err)
+	}
+
+	return nil
+}
+
+// GetOccurrences gets the occurrences of a ScheduledTransaction from startDate on, in date order
+func (store ScheduledTransactionStore) GetOccurrences(scheduledID uint64,
+	startDate time.Time) ([]*ScheduledOccurrence, error) {
+	query := `SELECT *
+		    FROM scheduled_occurrences
+		   WHERE scheduled_id = $1
+		     AND occurrence_date >= $2
+		ORDER BY occurrence_date`
+
+	rows, err := store.Client.Queryx(query, scheduledID, startDate)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	var occurrences []*ScheduledOccurrence
+
+	for rows.Next() {
+		var occurrence ScheduledOccurrence
+		if err = rows.StructScan(&occurrence); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		occurrences = append(occurrences, &occurrence)
+	}
+
+	return occurrences, nil
+}
+
+// ClaimOccurrence inserts the row of an occurrence with a status of POSTING or SKIPPED.  The insert is what makes an
+// occurrence posted at most once when several schedulers run: sql.ErrNoRows when the occurrence already has a row.
+// An occurrence is claimed as POSTING in the database transaction that posts it, so the status is never committed.
+func (store ScheduledTransactionStore) ClaimOccurrence(occurrence *ScheduledOccurrence) error {
+	query := `INSERT INTO scheduled_occurrences
+		           (scheduled_id,
+		            occurrence_date,
+		            occurrence_status,
+		            transaction_id)
+		    VALUES (:scheduled_id,
+		            :occurrence_date,
+		            :occurrence_status,
+		            :transaction_id)
+		ON CONFLICT (scheduled_id, occurrence_date) DO NOTHING
+		 RETURNING *`
+
+	stmt, err := store.Client.PrepareNamed(query)
+	if err != nil {
+		return fmt.Errorf("store.Client.PrepareNamed(query):%w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(occurrence).StructScan(occurrence)
+	if err != nil {
+		return fmt.Errorf("stmt.QueryRow().StructScan():%w", err)
+	}
+
+	return nil
+}
+
+// SetOccurrencePosted records the transaction a claimed occurrence posted
+func (store ScheduledTransactionStore) SetOccurrencePosted(occurrence *ScheduledOccurrence) error {
+	query := `UPDATE scheduled_occurrences
+		     SET occurrence_status = 'POSTED',
+		         transaction_id = :transaction_id
+		   WHERE scheduled_id = :scheduled_id
+		     AND occurrence_date = :occurrence_date
+		     AND occurrence_status = 'POSTING'
+		RETURNING *`
+
+	stmt, err := store.Client.PrepareNamed(query)
+	if err != nil {
+		return fmt.Errorf("store.Client.PrepareNamed(query):%w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(occurrence).StructScan(occurrence)
+	if err != nil {
+		return fmt.Errorf("stmt.QueryRow().StructScan():%w", err)
+	}
+
+	return nil
+}
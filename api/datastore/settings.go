@@ -0,0 +1,85 @@
+package datastore
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type SettingStore struct {
+	Client *sqlx.DB
+}
+
+// Setting is a single named ledger wide setting
+type Setting struct {
+	SettingName  string `db:"setting_name"`
+	SettingValue string `db:"setting_value"`
+}
+
+// StoreOrUpdate inserts a Setting into postgres, or updates the value of the existing record
+func (store SettingStore) StoreOrUpdate(mySetting *Setting) error {
+	query := `INSERT INTO settings
+		           (setting_name,
+		            setting_value)
+		    VALUES (:setting_name,
+		            :setting_value)
+		ON CONFLICT (setting_name)
+		 DO UPDATE SET setting_value = :setting_value
+		 RETURNING *`
+
+	stmt, err := store.Client.PrepareNamed(query)
+	if err != nil {
+		return fmt.Errorf("error preparing setting insert: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(mySetting).StructScan(mySetting)
+	if err != nil {
+		return fmt.Errorf("stmt.QueryRow().StructScan():%w", err)
+	}
+
+	return nil
+}
+
+func (store SettingStore) RetrieveByName(name string) (*Setting, error) {
+	query := `select * from settings where setting_name = $1`
+
+	row := store.Client.QueryRowx(query, name)
+
+	var mySetting Setting
+
+	if err := row.StructScan(&mySetting); err != nil {
+		return nil, fmt.Errorf("row.StructScan(&tn):%w", err)
+	}
+
+	return &mySetting, nil
+}
+
+// Gets All Settings.
+func (store SettingStore) Retrieve() ([]*Setting, error) {
+	query := `select * from settings order by setting_name`
+
+	rows, err := store.Client.Queryx(query)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	var set []*Setting
+
+	for rows.Next() {
+		var setting Setting
+		if err = rows.StructScan(&setting); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		set = append(set, &setting)
+	}
+
+	if len(set) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return set, nil
+}
@@ -9,8 +9,13 @@ import (
 )
 
 type TransactionStore struct {
-	Client *sqlx.DB
+	Client DBClient
 }
+
+// transactionColumns are the columns of transaction_main that map onto Transaction
+const transactionColumns = `transaction_id, transaction_date, transaction_reconcile_date, transaction_comment,
+                            transaction_amount, transaction_reference, is_reconciled, is_split`
+
 type Transaction struct {
 	TransactionID            uint64       `db:"transaction_id,omitempty"`
 	TransactionDate          time.Time    `db:"transaction_date,omitempty"`
@@ -43,7 +48,7 @@ func (store TransactionStore) Store(trn *Transaction) error {
 	:transaction_reference,
 	:is_reconciled,
 	:is_split)
-		 RETURNING *`
+		 RETURNING ` + transactionColumns
 
 	stmt, err := store.Client.PrepareNamed(query)
 	if err != nil {
@@ -74,9 +79,10 @@ func (store TransactionStore) Update(trn *Transaction) error {
 	:transaction_amount,
 	:transaction_reference,
 	:is_reconciled,
-	:is_split)
+	:is_split),
+		             transaction_modified_date = NOW()
 		     WHERE transaction_id = :transaction_id
-		 RETURNING *`
+		 RETURNING ` + transactionColumns
 
 	stmt, err := store.Client.PrepareNamed(query)
 	if err != nil {
@@ -93,7 +99,7 @@ func (store TransactionStore) Update(trn *Transaction) error {
 }
 
 func (store TransactionStore) GetByID(id uint64) (*Transaction, error) {
-	query := `select * from transaction_main where transaction_id = $1`
+	query := `select ` + transactionColumns + ` from transaction_main where transaction_id = $1`
 	row := store.Client.QueryRowx(query, id)
 
 	var myTransaction Transaction
@@ -105,6 +111,37 @@ func (store TransactionStore) GetByID(id uint64) (*Transaction, error) {
 	return &myTransaction, nil
 }
 
+// GetByAccountForDates gets the transactions with a debit or credit on an account in date order, unset dates do not
+// filter
+func (store TransactionStore) GetByAccountForDates(accountID uint64, startDate, endDate sql.NullTime) ([]*Transaction,
+	error) {
+	query := `SELECT ` + transactionColumns + `
+		        FROM transaction_main
+		       WHERE transaction_id IN (SELECT transaction_id FROM transaction_debit_credit WHERE account_id = $1)
+		         AND ($2::timestamptz IS NULL OR transaction_date >= $2::timestamptz)
+		         AND ($3::timestamptz IS NULL OR transaction_date <= $3::timestamptz)
+		    ORDER BY transaction_date, transaction_id`
+
+	rows, err := store.Client.Queryx(query, accountID, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	var txnSet []*Transaction
+
+	for rows.Next() {
+		var trn Transaction
+		if err = rows.StructScan(&trn); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		txnSet = append(txnSet, &trn)
+	}
+
+	return txnSet, nil
+}
+
 // Delete a transaction
 func (store TransactionStore) Delete(trn *Transaction) error {
 	query := `Delete FROM transaction_main 
@@ -118,32 +155,168 @@ func (store TransactionStore) Delete(trn *Transaction) error {
 	return nil
 }
 
-// Set Transaction Reconsciled
-func (store TransactionStore) SetIsReconciled(trn *Transaction) error {
-	query := `UPDATE  transaction_main 
-		    SET is_reconciled = $2
-		    where transaction_id = $1`
+// SetReconciled sets is_reconciled on every line of a transaction and then on the transaction from its lines,
+// reconcileDate is only set when it is valid.  sql.ErrNoRows when the transaction does not exist.
+func (store TransactionStore) SetReconciled(transactionID uint64, isReconciled bool,
+	reconcileDate sql.NullTime) error {
+	return inTransaction(store.Client, func(tx *sqlx.Tx) error {
+		query := `UPDATE transaction_debit_credit
+		             SET is_reconciled = $2,
+		                 transaction_reconcile_date = CASE WHEN $3::timestamptz IS NULL
+		                                                   THEN transaction_reconcile_date
+		                                                   ELSE $3::timestamptz END
+		           WHERE transaction_id = $1`
+
+		if _, err := tx.Exec(query, transactionID, isReconciled, reconcileDate); err != nil {
+			return fmt.Errorf("tx.Exec:%w", err)
+		}
+
+		count, err := refreshTransactionReconciled(tx, []uint64{transactionID})
+		if err != nil {
+			return err
+		}
+
+		if count == 0 {
+			return sql.ErrNoRows
+		}
+
+		return nil
+	})
+}
+
+// refreshTransactionReconciled sets is_reconciled on transactionIDs when all of their lines are reconciled, and
+// transaction_reconcile_date to the latest date a line was reconciled on.  It returns how many transactions there are.
+func refreshTransactionReconciled(tx *sqlx.Tx, transactionIDs []uint64) (int64, error) {
+	query := `UPDATE transaction_main AS tm
+		         SET is_reconciled = lines.is_reconciled,
+		             transaction_reconcile_date = COALESCE(lines.transaction_reconcile_date,
+		                                                   tm.transaction_reconcile_date)
+		        FROM (SELECT transaction_id,
+		                     bool_and(is_reconciled) AS is_reconciled,
+		                     MAX(transaction_reconcile_date) FILTER (WHERE is_reconciled) AS transaction_reconcile_date
+		                FROM transaction_debit_credit
+		               WHERE transaction_id = ANY($1::int[])
+		            GROUP BY transaction_id) AS lines
+		       WHERE tm.transaction_id = lines.transaction_id`
+
+	res, err := tx.Exec(query, transactionIDs)
+	if err != nil {
+		return 0, fmt.Errorf("tx.Exec:%w", err)
+	}
 
-	_, err := store.Client.Exec(query, trn.TransactionID, trn.IsReconciled)
+	count, err := res.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("store.Client.Exec:%w", err)
+		return 0, fmt.Errorf("res.RowsAffected:%w", err)
 	}
 
-	return nil
+	return count, nil
 }
 
-// Set TransactionReconcileDate
-func (store TransactionStore) SetTransactionReconcileDate(trn *Transaction) error {
-	query := `UPDATE  transaction_main 
-		    SET transaction_reconcile_date = $2
-		    where transaction_id = $1`
-
-	_, err := store.Client.Exec(query, trn.TransactionID, trn.TransactionReconcileDate)
+// GetOutstandingOnAccountForDate gets the transactions on the accounts between accountLeft and accountRight dated on
+// or before asOfDate that were not reconciled as of asOfDate, either still unreconciled or reconciled later
+func (store TransactionStore) GetOutstandingOnAccountForDate(accountLeft, accountRight uint64,
+	asOfDate time.Time) ([]*TransactionReconciliation, error) {
+	query := `SELECT workingtdc.debit_or_credit,
+		             workingtdc.transaction_id,
+		             workingtdc.transaction_dc_amount,
+		             workingtdc.account_id,
+		             tm.transaction_date,
+		             tm.transaction_reference,
+		             tm.transaction_comment,
+		             workingtdc.is_reconciled,
+		             workingtdc.transaction_reconcile_date,
+		             string_agg(odc.account_id::text, ',') AS split
+		        FROM transaction_debit_credit AS workingtdc
+		   LEFT JOIN transaction_debit_credit AS odc
+		          ON workingtdc.transaction_id=odc.transaction_id
+		  INNER JOIN transaction_main AS tm
+		          ON tm.transaction_id=workingtdc.transaction_id
+		       WHERE workingtdc.account_id
+		             IN (SELECT account_id FROM transaction_accounts WHERE account_left BETWEEN $2 AND $3)
+		         AND odc.account_id
+		             NOT IN (SELECT account_id FROM transaction_accounts WHERE account_left BETWEEN $2 AND $3)
+		         AND tm.transaction_date <= $1::timestamptz
+		         AND (workingtdc.is_reconciled IS FALSE OR workingtdc.transaction_reconcile_date > $1::timestamptz)
+		    GROUP BY workingtdc.transaction_dc_amount,
+		             workingtdc.debit_or_credit,
+		             workingtdc.transaction_id,
+		             workingtdc.account_id,
+		             workingtdc.transaction_reconcile_date,
+		             workingtdc.is_reconciled,
+		             tm.transaction_date,
+		             tm.transaction_reference,
+		             tm.transaction_comment
+		    ORDER BY transaction_date ASC, transaction_id ASC`
+
+	rows, err := store.Client.Queryx(query, asOfDate, accountLeft, accountRight)
 	if err != nil {
-		return fmt.Errorf("store.Client.Exec:%w", err)
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
 	}
+	defer rows.Close()
 
-	return nil
+	var txnSet []*TransactionReconciliation
+
+	for rows.Next() {
+		var txn TransactionReconciliation
+		if err = rows.StructScan(&txn); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		txnSet = append(txnSet, &txn)
+	}
+
+	return txnSet, nil
+}
+
+// GetIDsOnAccounts gets which of transactionIDs post to any of the accounts between accountLeft and accountRight
+func (store TransactionStore) GetIDsOnAccounts(transactionIDs []uint64, accountLeft,
+	accountRight uint64) ([]uint64, error) {
+	query := `SELECT DISTINCT transaction_id FROM transaction_debit_credit
+		       WHERE transaction_id = ANY($1::int[])
+		         AND account_id
+		             IN (SELECT account_id FROM transaction_accounts WHERE account_left BETWEEN $2 AND $3)
+		    ORDER BY transaction_id`
+
+	var onAccounts []uint64
+
+	if err := store.Client.Select(&onAccounts, query, transactionIDs, accountLeft, accountRight); err != nil {
+		return nil, fmt.Errorf("store.Client.Select:%w", err)
+	}
+
+	return onAccounts, nil
+}
+
+// SetReconciledBulk sets is_reconciled on the lines of all of transactionIDs that post to the accounts between
+// accountLeft and accountRight in a single database transaction, reconcileDate is only set when it is valid.
+// sql.ErrNoRows when any of the transactions does not exist, and then nothing is changed.
+func (store TransactionStore) SetReconciledBulk(transactionIDs []uint64, accountLeft, accountRight uint64,
+	isReconciled bool, reconcileDate sql.NullTime) error {
+	return inTransaction(store.Client, func(tx *sqlx.Tx) error {
+		query := `UPDATE transaction_debit_credit
+		             SET is_reconciled = $2,
+		                 transaction_reconcile_date = CASE WHEN $3::timestamptz IS NULL
+		                                                   THEN transaction_reconcile_date
+		                                                   ELSE $3::timestamptz END
+		           WHERE transaction_id = ANY($1::int[])
+		             AND account_id
+		                 IN (SELECT account_id FROM transaction_accounts WHERE account_left BETWEEN $4 AND $5)`
+
+		if _, err := tx.Exec(query, transactionIDs, isReconciled, reconcileDate, accountLeft,
+			accountRight); err != nil {
+			return fmt.Errorf("tx.Exec:%w", err)
+		}
+
+		count, err := refreshTransactionReconciled(tx, transactionIDs)
+		if err != nil {
+			return err
+		}
+
+		if count != int64(len(transactionIDs)) {
+			return sql.ErrNoRows
+		}
+
+		return nil
+	})
 }
 
 type TransactionReconciliation struct {
@@ -170,8 +343,8 @@ func (store TransactionStore) GetUnreconciledTransactionsOnAccountForDate(accoun
                tm.transaction_date, 
                tm.transaction_reference, 
                tm.transaction_comment, 
-               tm.is_reconciled, 
-               tm.transaction_reconcile_date, 
+               workingtdc.is_reconciled, 
+               workingtdc.transaction_reconcile_date, 
                string_agg(odc.account_id::text, ',') AS split
           FROM transaction_debit_credit AS workingtdc
      LEFT JOIN transaction_debit_credit AS odc
@@ -182,22 +355,22 @@ func (store TransactionStore) GetUnreconciledTransactionsOnAccountForDate(accoun
 			IN (SELECT account_id FROM transaction_accounts WHERE account_left BETWEEN $3 AND $4)  
 		   AND odc.account_id 
 		NOT IN (SELECT account_id FROM transaction_accounts WHERE account_left BETWEEN $3 AND $4) )
-           AND ((tm.is_reconciled IS FALSE 
+           AND ((workingtdc.is_reconciled IS FALSE 
                  AND  EXTRACT(EPOCH FROM tm.transaction_date) <= EXTRACT(EPOCH FROM $1::timestamp) )
                OR
-               (tm.is_reconciled IS TRUE 
-                 AND EXTRACT(EPOCH FROM tm.transaction_reconcile_date) > EXTRACT(EPOCH FROM  $2::timestamp)
-                 AND EXTRACT(EPOCH FROM tm.transaction_reconcile_date) <= EXTRACT(EPOCH FROM  $1::timestamp))
+               (workingtdc.is_reconciled IS TRUE 
+                 AND EXTRACT(EPOCH FROM workingtdc.transaction_reconcile_date) > EXTRACT(EPOCH FROM  $2::timestamp)
+                 AND EXTRACT(EPOCH FROM workingtdc.transaction_reconcile_date) <= EXTRACT(EPOCH FROM  $1::timestamp))
                )
 			 GROUP BY  workingtdc.transaction_dc_amount, 
 					  workingtdc.debit_or_credit, 
 					  workingtdc.transaction_id, 
 					  workingtdc.account_id, 
 					  tm.transaction_date, 
-					  tm.transaction_reconcile_date, 
+					  workingtdc.transaction_reconcile_date, 
 					  tm.transaction_reference, 
 					  tm.transaction_comment, 
-					  tm.is_reconciled
+					  workingtdc.is_reconciled
       ORDER BY is_reconciled DESC, transaction_reconcile_date ASC, transaction_date ASC, transaction_reference ASC`
 
 	rows, err := store.Client.Queryx(query, searchLimitDate, reconciledCutoffDate, accountLeft, accountRight)
@@ -226,6 +399,8 @@ func (store TransactionStore) GetUnreconciledTransactionsOnAccountForDate(accoun
 
 type TransactionLedger struct {
 	TransactionID            uint64       `db:"transaction_id"`
+	TransactionDCID          uint64       `db:"transaction_dc_id"`
+	AccountID                uint64       `db:"account_id"`
 	TransactionDate          time.Time    `db:"transaction_date"`
 	TransactionReconcileDate sql.NullTime `db:"transaction_reconcile_date"`
 	TransactionComment       string       `db:"transaction_comment"`
@@ -236,35 +411,148 @@ type TransactionLedger struct {
 	DebitOrCredit            AccountSign  `db:"debit_or_credit"`
 	// split is a generated field, a comma separated list of the other d/c
 	Split string `db:"split"`
+	// RunningBalance is a generated field, the balance of the account range after this line
+	RunningBalance int64 `db:"running_balance"`
+	// AttachmentCount is a generated field, the number of files attached to the transaction
+	AttachmentCount uint64 `db:"attachment_count"`
 }
 
-func (store TransactionStore) GetTransactionsForAccount(accountID uint64) ([]*TransactionLedger, error) {
-	query := `SELECT workingDC.transaction_dc_amount, 
-    							  workingDC.debit_or_credit, 
-    							  tm.transaction_id, 
-    							  tm.transaction_reference, 
-    							  tm.transaction_date, 
-    							  tm.transaction_reconcile_date, 
-    							  tm.transaction_comment, 
-    							  tm.is_reconciled, 
-    							  string_agg(odc.account_id::text, ',') AS split
-                             FROM transaction_debit_credit AS workingDC
-                        LEFT JOIN transaction_debit_credit AS odc
-                               ON workingDC.transaction_id=odc.transaction_id AND odc.account_id != $1
-    				   INNER JOIN transaction_main AS tm
-                               ON tm.transaction_id=workingDC.transaction_id
-                            WHERE workingDC.account_id = $1
-                         GROUP BY  workingDC.transaction_dc_amount, 
-    							  workingDC.debit_or_credit, 
-    							  tm.transaction_id, 
-    							  tm.transaction_reference, 
-    							  tm.transaction_date, 
-    							  tm.transaction_reconcile_date, 
-    							  tm.transaction_comment, 
-    							  tm.is_reconciled
-                         ORDER BY tm.transaction_date, tm.transaction_id`
+// TransactionLedgerFilter selects the lines of an account ledger.  Lines are taken from every account with an
+// account_left between AccountLeft and AccountRight, so AccountLeft for both is the account without its sub-accounts.
+// EndDate includes the whole of its day.  Unset fields do not filter.
+type TransactionLedgerFilter struct {
+	AccountLeft  uint64
+	AccountRight uint64
+	// AccountSign is the sign of the lines that increase the running balance
+	AccountSign  AccountSign
+	StartDate    sql.NullTime
+	EndDate      sql.NullTime
+	MinAmount    sql.NullInt64
+	MaxAmount    sql.NullInt64
+	IsReconciled sql.NullBool
+	Reference    string
+	Comment      string
+	// After starts the page after this line, the ledger is ordered by (transaction_date, transaction_id)
+	After *TransactionLedgerCursor
+	// Limit is the page size, 0 for no limit
+	Limit uint64
+}
+
+// TransactionLedgerCursor is the position of a line in the ledger order
+type TransactionLedgerCursor struct {
+	TransactionDate time.Time
+	TransactionID   uint64
+	TransactionDCID uint64
+}
+
+// ledgerAccountRange selects the accounts of the ledger, $1 and $2 are the account_left range
+const ledgerAccountRange = `(SELECT account_id FROM transaction_accounts WHERE account_left BETWEEN $1 AND $2)`
+
+// ledgerFilterClause is shared by the ledger and its count, $3 to $9 are the filters
+const ledgerFilterClause = `($3::timestamptz IS NULL OR ledger.transaction_date >= $3::timestamptz)
+                        AND ($4::timestamptz IS NULL OR ledger.transaction_date < $4::timestamptz + interval '1 day')
+                        AND ($5::bigint IS NULL OR ledger.transaction_dc_amount >= $5::bigint)
+                        AND ($6::bigint IS NULL OR ledger.transaction_dc_amount <= $6::bigint)
+                        AND ($7::bool IS NULL OR ledger.is_reconciled = $7::bool)
+                        AND ($8::text = '' OR strpos(lower(ledger.transaction_reference), lower($8::text)) > 0)
+                        AND ($9::text = '' OR strpos(lower(ledger.transaction_comment), lower($9::text)) > 0)`
+
+func (filter *TransactionLedgerFilter) args() []any {
+	return []any{filter.AccountLeft, filter.AccountRight, filter.StartDate, filter.EndDate,
+		filter.MinAmount, filter.MaxAmount, filter.IsReconciled, filter.Reference, filter.Comment}
+}
+
+// ledgerLines are the lines of the accounts of the ledger with their transactions, for use as a subquery
+const ledgerLines = `SELECT workingDC.transaction_dc_id,
+                            workingDC.transaction_dc_amount, 
+                            workingDC.debit_or_credit, 
+                            workingDC.account_id, 
+                            tm.transaction_id, 
+                            tm.transaction_reference, 
+                            tm.transaction_date, 
+                            workingDC.transaction_reconcile_date, 
+                            tm.transaction_comment, 
+                            workingDC.is_reconciled, 
+                            tm.is_split
+                       FROM transaction_debit_credit AS workingDC
+                 INNER JOIN transaction_main AS tm
+                         ON tm.transaction_id=workingDC.transaction_id
+                      WHERE workingDC.account_id IN ` + ledgerAccountRange
+
+// ledgerSignedAmount is how a line of the history moves the running balance, $13 is the sign
+const ledgerSignedAmount = `CASE WHEN history.debit_or_credit = $13::transaction_account_sign_type 
+                                 THEN history.transaction_dc_amount 
+                                 ELSE -history.transaction_dc_amount END`
+
+// GetTransactionsForAccount gets the ledger lines of an account, the split lists the accounts outside the range.
+// The running balance is over the whole history of the range, so it is unaffected by the filters.  It is one sum
+// of the lines before the page, carried through the lines between the first and the last line of the page.
+func (store TransactionStore) GetTransactionsForAccount(filter *TransactionLedgerFilter) ([]*TransactionLedger,
+	error) {
+	query := `WITH page AS (
+                     SELECT ledger.*
+                       FROM (` + ledgerLines + `) AS ledger
+                      WHERE ` + ledgerFilterClause + `
+                        AND ($10::timestamptz IS NULL 
+                             OR (ledger.transaction_date, ledger.transaction_id, ledger.transaction_dc_id) 
+                              > ($10::timestamptz, $11::bigint, $12::bigint))
+                   ORDER BY ledger.transaction_date, ledger.transaction_id, ledger.transaction_dc_id
+                      LIMIT $14::bigint),
+                   first_line AS (
+                     SELECT transaction_date, transaction_id, transaction_dc_id 
+                       FROM page 
+                   ORDER BY transaction_date, transaction_id, transaction_dc_id
+                      LIMIT 1),
+                   last_line AS (
+                     SELECT transaction_date, transaction_id, transaction_dc_id 
+                       FROM page 
+                   ORDER BY transaction_date DESC, transaction_id DESC, transaction_dc_id DESC
+                      LIMIT 1),
+                   opening AS (
+                     SELECT COALESCE(SUM(` + ledgerSignedAmount + `), 0) AS balance
+                       FROM (` + ledgerLines + `) AS history, first_line
+                      WHERE (history.transaction_date, history.transaction_id, history.transaction_dc_id) 
+                          < (first_line.transaction_date, first_line.transaction_id, first_line.transaction_dc_id)),
+                   running AS (
+                     SELECT history.transaction_dc_id,
+                            opening.balance + SUM(` + ledgerSignedAmount + `) 
+                                OVER (ORDER BY history.transaction_date, history.transaction_id, history.transaction_dc_id) 
+                                AS running_balance
+                       FROM (` + ledgerLines + `) AS history, first_line, last_line, opening
+                      WHERE (history.transaction_date, history.transaction_id, history.transaction_dc_id) 
+                         >= (first_line.transaction_date, first_line.transaction_id, first_line.transaction_dc_id)
+                        AND (history.transaction_date, history.transaction_id, history.transaction_dc_id) 
+                         <= (last_line.transaction_date, last_line.transaction_id, last_line.transaction_dc_id))
+                     SELECT page.*,
+                            running.running_balance,
+                            COALESCE((SELECT string_agg(odc.account_id::text, ',') 
+                                        FROM transaction_debit_credit AS odc 
+                                       WHERE odc.transaction_id=page.transaction_id 
+                                         AND odc.account_id NOT IN ` + ledgerAccountRange + `), '') AS split,
+                            (SELECT COUNT(*) 
+                               FROM transaction_attachments AS ta 
+                              WHERE ta.transaction_id=page.transaction_id) AS attachment_count
+                       FROM page
+                 INNER JOIN running
+                         ON running.transaction_dc_id=page.transaction_dc_id
+                   ORDER BY page.transaction_date, page.transaction_id, page.transaction_dc_id`
+
+	var afterDate sql.NullTime
+
+	var afterTransactionID, afterDCID uint64
+
+	if filter.After != nil {
+		afterDate = sql.NullTime{Time: filter.After.TransactionDate, Valid: true}
+		afterTransactionID = filter.After.TransactionID
+		afterDCID = filter.After.TransactionDCID
+	}
 
-	rows, err := store.Client.Queryx(query, accountID)
+	// a NULL limit is no limit
+	limit := sql.NullInt64{Int64: int64(filter.Limit), Valid: filter.Limit > 0} //nolint:gosec
+
+	args := append(filter.args(), afterDate, afterTransactionID, afterDCID, filter.AccountSign, limit)
+
+	rows, err := store.Client.Queryx(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
 	}
@@ -289,6 +577,22 @@ func (store TransactionStore) GetTransactionsForAccount(accountID uint64) ([]*Tr
 	return txnSet, nil
 }
 
+// CountTransactionsForAccount counts the ledger lines of an account that match the filter, ignoring the page
+func (store TransactionStore) CountTransactionsForAccount(filter *TransactionLedgerFilter) (uint64, error) {
+	query := `SELECT COUNT(*) 
+                FROM (` + ledgerLines + `) AS ledger
+               WHERE ` + ledgerFilterClause
+
+	var count uint64
+
+	err := store.Client.QueryRowx(query, filter.args()...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("store.Client.QueryRowx:%w", err)
+	}
+
+	return count, nil
+}
+
 func (store TransactionStore) RetrieveTransactionsNetForDates(accountIDSet []uint64,
 	startDate time.Time, endDate time.Time) ([]*TransactionLedger, error) {
 	query := `SELECT workingDC.transaction_dc_amount, 
@@ -296,9 +600,9 @@ func (store TransactionStore) RetrieveTransactionsNetForDates(accountIDSet []uin
     							  tm.transaction_id, 
     							  tm.transaction_reference, 
     							  tm.transaction_date, 
-    							  tm.transaction_reconcile_date, 
+    							  workingDC.transaction_reconcile_date, 
     							  tm.transaction_comment, 
-    							  tm.is_reconciled, 
+    							  workingDC.is_reconciled, 
     							  string_agg(odc.account_id::text, ',') AS split
                              FROM transaction_debit_credit AS workingDC
                         LEFT JOIN transaction_debit_credit AS odc
@@ -314,9 +618,9 @@ func (store TransactionStore) RetrieveTransactionsNetForDates(accountIDSet []uin
     							  tm.transaction_id, 
     							  tm.transaction_reference, 
     							  tm.transaction_date, 
-    							  tm.transaction_reconcile_date, 
+    							  workingDC.transaction_reconcile_date, 
     							  tm.transaction_comment, 
-    							  tm.is_reconciled
+    							  workingDC.is_reconciled
                          ORDER BY tm.transaction_date, tm.transaction_id`
 
 	rows, err := store.Client.Queryx(query, accountIDSet, startDate, endDate)
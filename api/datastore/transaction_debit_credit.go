@@ -4,20 +4,22 @@ import (
 	"database/sql"
 	"fmt"
 	"time"
-
-	"github.com/jmoiron/sqlx"
 )
 
 type TransactionDebitCreditStore struct {
-	Client *sqlx.DB
+	Client DBClient
 }
 
+// TransactionDebitCredit is a line of a transaction.  Each line is reconciled on its own, so a transfer can be
+// reconciled on one account and not the other.
 type TransactionDebitCredit struct {
-	TransactionDCID     uint64      `db:"transaction_dc_id,omitempty"`
-	TransactionID       uint64      `db:"transaction_id"`
-	AccountID           uint64      `db:"account_id"`
-	TransactionDCAmount uint64      `db:"transaction_dc_amount"`
-	DebitOrCredit       AccountSign `db:"debit_or_credit"`
+	TransactionDCID          uint64       `db:"transaction_dc_id,omitempty"`
+	TransactionID            uint64       `db:"transaction_id"`
+	AccountID                uint64       `db:"account_id"`
+	TransactionDCAmount      uint64       `db:"transaction_dc_amount"`
+	DebitOrCredit            AccountSign  `db:"debit_or_credit"`
+	IsReconciled             bool         `db:"is_reconciled"`
+	TransactionReconcileDate sql.NullTime `db:"transaction_reconcile_date"`
 }
 
 // Store inserts a UserNotification into postgres
@@ -26,11 +28,15 @@ func (store TransactionDebitCreditStore) Store(trn *TransactionDebitCredit) erro
 		           (transaction_id,
 	account_id,
 	transaction_dc_amount,
-	debit_or_credit)
+	debit_or_credit,
+	is_reconciled,
+	transaction_reconcile_date)
 		    VALUES (:transaction_id,
 	:account_id,
 	:transaction_dc_amount,
-	:debit_or_credit)
+	:debit_or_credit,
+	:is_reconciled,
+	:transaction_reconcile_date)
 		 RETURNING *`
 
 	stmt, err := store.Client.PrepareNamed(query)
@@ -103,6 +109,7 @@ func (store TransactionDebitCreditStore) DeleteForTransactionID(id uint64) ([]*T
 }
 
 type AccountSubtotal struct {
+	AccountID     uint64      `db:"account_id"`
 	Subtotal      uint64      `db:"subtotal"`
 	DebitOrCredit AccountSign `db:"debit_or_credit"`
 }
@@ -149,8 +156,8 @@ func (store TransactionDebitCreditStore) GetReconciledSubtotals(accountLeft, acc
 						AND odc.account_id 
 						NOT IN (SELECT account_id FROM transaction_accounts WHERE account_left BETWEEN $2 AND $3) )
 					  
-					AND tm.is_reconciled IS TRUE 
-					AND EXTRACT(EPOCH FROM tm.transaction_reconcile_date) <= EXTRACT(EPOCH FROM  $1::timestamp)
+					AND workingtdc.is_reconciled IS TRUE 
+					AND EXTRACT(EPOCH FROM workingtdc.transaction_reconcile_date) <= EXTRACT(EPOCH FROM  $1::timestamp)
 					  )
 				 AS z    
 	  GROUP BY z.debit_or_credit`
@@ -174,3 +181,70 @@ func (store TransactionDebitCreditStore) GetReconciledSubtotals(accountLeft, acc
 
 	return txnSet, nil
 }
+
+// GetSubtotalsBeforeDate gets the debit and credit subtotals of all the accounts between accountLeft and
+// accountRight, for transactions dated before beforeDate
+func (store TransactionDebitCreditStore) GetSubtotalsBeforeDate(accountLeft, accountRight uint64,
+	beforeDate time.Time) ([]*AccountSubtotal, error) {
+	query := `SELECT SUM(tdc.transaction_dc_amount) AS subtotal, tdc.debit_or_credit
+					FROM transaction_debit_credit AS tdc
+			  INNER JOIN transaction_main AS tm
+					  ON tm.transaction_id=tdc.transaction_id
+				   WHERE tdc.account_id
+						 IN (SELECT account_id FROM transaction_accounts WHERE account_left BETWEEN $1 AND $2)
+					 AND tm.transaction_date < $3::timestamptz
+				GROUP BY tdc.debit_or_credit`
+
+	rows, err := store.Client.Queryx(query, accountLeft, accountRight, beforeDate)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	var txnSet []*AccountSubtotal
+
+	for rows.Next() {
+		var txn AccountSubtotal
+		if err = rows.StructScan(&txn); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		txnSet = append(txnSet, &txn)
+	}
+
+	return txnSet, nil
+}
+
+// GetSubtotalsForDates gets the debit and credit subtotals of every account in the subtree between accountLeft
+// and accountRight, for transactions dated between startDate and endDate
+func (store TransactionDebitCreditStore) GetSubtotalsForDates(accountLeft, accountRight uint64,
+	startDate time.Time, endDate time.Time) ([]*AccountSubtotal, error) {
+	query := `SELECT tdc.account_id, SUM(tdc.transaction_dc_amount) AS subtotal, tdc.debit_or_credit
+					FROM transaction_debit_credit AS tdc
+			  INNER JOIN transaction_main AS tm
+					  ON tm.transaction_id=tdc.transaction_id
+				   WHERE tdc.account_id
+						 IN (SELECT account_id FROM transaction_accounts WHERE account_left BETWEEN $1 AND $2)
+					 AND EXTRACT(EPOCH FROM tm.transaction_date) >= EXTRACT(EPOCH FROM $3::timestamp)
+					 AND EXTRACT(EPOCH FROM tm.transaction_date) <= EXTRACT(EPOCH FROM $4::timestamp)
+				GROUP BY tdc.account_id, tdc.debit_or_credit`
+
+	rows, err := store.Client.Queryx(query, accountLeft, accountRight, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	var txnSet []*AccountSubtotal
+
+	for rows.Next() {
+		var txn AccountSubtotal
+		if err = rows.StructScan(&txn); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		txnSet = append(txnSet, &txn)
+	}
+
+	return txnSet, nil
+}
@@ -411,13 +411,9 @@ func TestTransactionDebitCreditStore_GetReconciledSubtotal(t *testing.T) {
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 
 	// set is_reconciled and the reconciled_date on one of the transactions
-	myTrans.IsReconciled = true
-	err = transStore.SetIsReconciled(&myTrans)
-	g.Expect(err).NotTo(gomega.HaveOccurred())
 	reconciledDate2, err := time.Parse("2006-01-02", "2023-09-08")
 	g.Expect(err).NotTo(gomega.HaveOccurred())
-	myTrans.TransactionReconcileDate = sql.NullTime{Time: reconciledDate2, Valid: true}
-	err = transStore.SetTransactionReconcileDate(&myTrans)
+	err = transStore.SetReconciled(myTrans.TransactionID, true, sql.NullTime{Time: reconciledDate2, Valid: true})
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 
 	reconciledDateCutoff0, err := time.Parse("2006-01-02", "2023-08-30")
@@ -459,11 +455,7 @@ func TestTransactionDebitCreditStore_GetReconciledSubtotal(t *testing.T) {
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 
 	// set is_reconciled and the reconciled_date on myTrans2
-	myTrans2.IsReconciled = true
-	err = transStore.SetIsReconciled(&myTrans2)
-	g.Expect(err).NotTo(gomega.HaveOccurred())
-	myTrans2.TransactionReconcileDate = sql.NullTime{Time: reconciledDate2, Valid: true}
-	err = transStore.SetTransactionReconcileDate(&myTrans2)
+	err = transStore.SetReconciled(myTrans2.TransactionID, true, sql.NullTime{Time: reconciledDate2, Valid: true})
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 
 	// cut off date is far in past, zero transactions in sum
@@ -494,11 +486,7 @@ func TestTransactionDebitCreditStore_GetReconciledSubtotal(t *testing.T) {
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 
 	// set is_reconciled and the reconciled_date on myTrans2
-	myTrans3.IsReconciled = true
-	err = transStore.SetIsReconciled(&myTrans3)
-	g.Expect(err).NotTo(gomega.HaveOccurred())
-	myTrans3.TransactionReconcileDate = sql.NullTime{Time: reconciledDate2, Valid: true}
-	err = transStore.SetTransactionReconcileDate(&myTrans3)
+	err = transStore.SetReconciled(myTrans3.TransactionID, true, sql.NullTime{Time: reconciledDate2, Valid: true})
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 
 	// should now be 3 transaction, 13000 in credit and 65000 in debit on account1
@@ -0,0 +1,169 @@
+package datastore
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type TransactionDuplicateStore struct {
+	Client DBClient
+}
+
+// DuplicateStatus is an enum for the review of a TransactionDuplicate
+type DuplicateStatus string
+
+const (
+	DuplicateStatusSuspected = DuplicateStatus("SUSPECTED")
+	DuplicateStatusDismissed = DuplicateStatus("DISMISSED")
+)
+
+// TransactionDuplicate is a pair of transactions that are probably the same, TransactionID is always the lower ID.
+// AccountID is the account on which they matched.
+type TransactionDuplicate struct {
+	DuplicateID            uint64          `db:"duplicate_id,omitempty"`
+	TransactionID          uint64          `db:"transaction_id"`
+	DuplicateTransactionID uint64          `db:"duplicate_transaction_id"`
+	AccountID              uint64          `db:"account_id"`
+	DuplicateStatus        DuplicateStatus `db:"duplicate_status"`
+	DetectedDate           time.Time       `db:"detected_date,omitempty"`
+}
+
+// DuplicateCandidateFilter finds transactions posting the same amount to the same side of an account within a
+// date range
+type DuplicateCandidateFilter struct {
+	AccountID     uint64
+	DebitOrCredit AccountSign
+	Amount        uint64
+	StartDate     time.Time
+	EndDate       time.Time
+	// ExcludeTransactionID is the transaction the candidates are for
+	ExcludeTransactionID uint64
+}
+
+// GetCandidates gets the transactions matching a DuplicateCandidateFilter in transaction_id order
+func (store TransactionDuplicateStore) GetCandidates(filter *DuplicateCandidateFilter) ([]*Transaction, error) {
+	query := `SELECT DISTINCT tm.transaction_id, tm.transaction_date, tm.transaction_reconcile_date,
+		             tm.transaction_comment, tm.transaction_amount, tm.transaction_reference,
+		             tm.is_reconciled, tm.is_split
+		        FROM transaction_main AS tm
+		  INNER JOIN transaction_debit_credit AS dc
+		          ON dc.transaction_id = tm.transaction_id
+		       WHERE dc.account_id = $1
+		         AND dc.debit_or_credit = $2
+		         AND dc.transaction_dc_amount = $3
+		         AND tm.transaction_date BETWEEN $4 AND $5
+		         AND tm.transaction_id <> $6
+		    ORDER BY tm.transaction_id`
+
+	rows, err := store.Client.Queryx(query, filter.AccountID, filter.DebitOrCredit, filter.Amount,
+		filter.StartDate, filter.EndDate, filter.ExcludeTransactionID)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	var txnSet []*Transaction
+
+	for rows.Next() {
+		var trn Transaction
+		if err = rows.StructScan(&trn); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		txnSet = append(txnSet, &trn)
+	}
+
+	return txnSet, nil
+}
+
+// Store inserts a TransactionDuplicate.  A pair that is already recorded, suspected or dismissed, is left as it
+// is and sql.ErrNoRows is returned.
+func (store TransactionDuplicateStore) Store(myDuplicate *TransactionDuplicate) error {
+	query := `INSERT INTO transaction_duplicates
+		           (transaction_id,
+		            duplicate_transaction_id,
+		            account_id,
+		            duplicate_status)
+		    VALUES (:transaction_id,
+		            :duplicate_transaction_id,
+		            :account_id,
+		            :duplicate_status)
+		ON CONFLICT (transaction_id, duplicate_transaction_id) DO NOTHING
+		 RETURNING *`
+
+	stmt, err := store.Client.PrepareNamed(query)
+	if err != nil {
+		return fmt.Errorf("store.Client.PrepareNamed(query):%w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(myDuplicate).StructScan(myDuplicate)
+	if err != nil {
+		return fmt.Errorf("stmt.QueryRow().StructScan():%w", err)
+	}
+
+	return nil
+}
+
+// RetrieveByID retrieves a TransactionDuplicate
+func (store TransactionDuplicateStore) RetrieveByID(duplicateID uint64) (*TransactionDuplicate, error) {
+	query := `SELECT * FROM transaction_duplicates WHERE duplicate_id = $1`
+
+	var myDuplicate TransactionDuplicate
+
+	if err := store.Client.QueryRowx(query, duplicateID).StructScan(&myDuplicate); err != nil {
+		return nil, fmt.Errorf("row.StructScan:%w", err)
+	}
+
+	return &myDuplicate, nil
+}
+
+// RetrieveByStatus retrieves the TransactionDuplicates with a status, the most recently detected first
+func (store TransactionDuplicateStore) RetrieveByStatus(status DuplicateStatus) ([]*TransactionDuplicate, error) {
+	query := `SELECT * FROM transaction_duplicates
+		       WHERE duplicate_status = $1
+		    ORDER BY detected_date DESC, duplicate_id DESC`
+
+	rows, err := store.Client.Queryx(query, status)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	var duplicateSet []*TransactionDuplicate
+
+	for rows.Next() {
+		var myDuplicate TransactionDuplicate
+		if err = rows.StructScan(&myDuplicate); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		duplicateSet = append(duplicateSet, &myDuplicate)
+	}
+
+	return duplicateSet, nil
+}
+
+// SetStatus sets the DuplicateStatus of a TransactionDuplicate
+func (store TransactionDuplicateStore) SetStatus(myDuplicate *TransactionDuplicate) error {
+	query := `UPDATE transaction_duplicates
+		         SET duplicate_status = $2
+		       WHERE duplicate_id = $1`
+
+	res, err := store.Client.Exec(query, myDuplicate.DuplicateID, myDuplicate.DuplicateStatus)
+	if err != nil {
+		return fmt.Errorf("store.Client.Exec:%w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("res.RowsAffected:%w", err)
+	}
+
+	if count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
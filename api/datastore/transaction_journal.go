@@ -0,0 +1,174 @@
+package datastore
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// TransactionJournalFilter selects the transactions of the general journal.  A transaction is in an account subtree
+// when any of its lines is on an account with an account_left between AccountLeft and AccountRight.  Amounts are
+// compared to the transaction_amount.  IsReconciled true selects the transactions with all of their lines reconciled
+// and false those with any line unreconciled.  Unset fields do not filter.
+type TransactionJournalFilter struct {
+	AccountLeft  sql.NullInt64
+	AccountRight sql.NullInt64
+	StartDate    sql.NullTime
+	EndDate      sql.NullTime
+	MinAmount    sql.NullInt64
+	MaxAmount    sql.NullInt64
+	IsReconciled sql.NullBool
+	Reference    string
+	Comment      string
+	// After starts the page after this transaction, the journal is ordered by (transaction_date, transaction_id)
+	After *TransactionJournalCursor
+	// Limit is the page size, 0 for no limit
+	Limit uint64
+}
+
+// TransactionJournalCursor is the position of a transaction in the journal order
+type TransactionJournalCursor struct {
+	TransactionDate time.Time
+	TransactionID   uint64
+}
+
+// journalFilterClause is shared by the journal and its count, $1 to $9 are the filters
+const journalFilterClause = `($1::bigint IS NULL OR EXISTS (
+                                  SELECT 1 
+                                    FROM transaction_debit_credit AS tdc
+                              INNER JOIN transaction_accounts AS ta
+                                      ON ta.account_id=tdc.account_id
+                                   WHERE tdc.transaction_id=tm.transaction_id
+                                     AND ta.account_left BETWEEN $1::bigint AND $2::bigint))
+                        AND ($3::timestamptz IS NULL OR tm.transaction_date >= $3::timestamptz)
+                        AND ($4::timestamptz IS NULL OR tm.transaction_date < $4::timestamptz + interval '1 day')
+                        AND ($5::bigint IS NULL OR tm.transaction_amount >= $5::bigint)
+                        AND ($6::bigint IS NULL OR tm.transaction_amount <= $6::bigint)
+                        AND ($7::bool IS NULL OR $7::bool = NOT EXISTS (
+                                  SELECT 1
+                                    FROM transaction_debit_credit AS tdc
+                                   WHERE tdc.transaction_id=tm.transaction_id
+                                     AND tdc.is_reconciled = FALSE))
+                        AND ($8::text = '' OR strpos(lower(tm.transaction_reference), lower($8::text)) > 0)
+                        AND ($9::text = '' OR strpos(lower(tm.transaction_comment), lower($9::text)) > 0)`
+
+func (filter *TransactionJournalFilter) args() []any {
+	return []any{filter.AccountLeft, filter.AccountRight, filter.StartDate, filter.EndDate,
+		filter.MinAmount, filter.MaxAmount, filter.IsReconciled, filter.Reference, filter.Comment}
+}
+
+// GetJournal gets a page of transactions across all accounts, in chronological order
+func (store TransactionStore) GetJournal(filter *TransactionJournalFilter) ([]*Transaction, error) {
+	query := `SELECT ` + transactionColumns + `
+                FROM transaction_main AS tm
+               WHERE ` + journalFilterClause + `
+                 AND ($10::timestamptz IS NULL 
+                      OR (tm.transaction_date, tm.transaction_id) > ($10::timestamptz, $11::bigint))
+            ORDER BY tm.transaction_date, tm.transaction_id
+               LIMIT $12::bigint`
+
+	var afterDate sql.NullTime
+
+	var afterTransactionID uint64
+
+	if filter.After != nil {
+		afterDate = sql.NullTime{Time: filter.After.TransactionDate, Valid: true}
+		afterTransactionID = filter.After.TransactionID
+	}
+
+	// a NULL limit is no limit
+	limit := sql.NullInt64{Int64: int64(filter.Limit), Valid: filter.Limit > 0} //nolint:gosec
+
+	args := append(filter.args(), afterDate, afterTransactionID, limit)
+
+	rows, err := store.Client.Queryx(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+
+	defer rows.Close()
+
+	var txnSet []*Transaction
+
+	for rows.Next() {
+		var txn Transaction
+		if err = rows.StructScan(&txn); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		txnSet = append(txnSet, &txn)
+	}
+
+	if len(txnSet) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return txnSet, nil
+}
+
+// CountJournal counts the transactions that match the filter, ignoring the page
+func (store TransactionStore) CountJournal(filter *TransactionJournalFilter) (uint64, error) {
+	query := `SELECT COUNT(*) 
+                FROM transaction_main AS tm
+               WHERE ` + journalFilterClause
+
+	var count uint64
+
+	err := store.Client.QueryRowx(query, filter.args()...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("store.Client.QueryRowx:%w", err)
+	}
+
+	return count, nil
+}
+
+// JournalDebitCredit is a TransactionDebitCredit with the names of its account
+type JournalDebitCredit struct {
+	TransactionDCID          uint64       `db:"transaction_dc_id"`
+	TransactionID            uint64       `db:"transaction_id"`
+	AccountID                uint64       `db:"account_id"`
+	TransactionDCAmount      uint64       `db:"transaction_dc_amount"`
+	DebitOrCredit            AccountSign  `db:"debit_or_credit"`
+	AccountName              string       `db:"account_name"`
+	AccountFullName          string       `db:"account_full_name"`
+	IsReconciled             bool         `db:"is_reconciled"`
+	TransactionReconcileDate sql.NullTime `db:"transaction_reconcile_date"`
+}
+
+// GetJournalDCForTransactionIDs gets the lines of a set of transactions, with their account names
+func (store TransactionDebitCreditStore) GetJournalDCForTransactionIDs(ids []uint64) ([]*JournalDebitCredit,
+	error) {
+	query := `SELECT tdc.transaction_dc_id,
+                     tdc.transaction_id,
+                     tdc.account_id,
+                     tdc.transaction_dc_amount,
+                     tdc.debit_or_credit,
+                     ta.account_name,
+                     ta.account_full_name,
+                     tdc.is_reconciled,
+                     tdc.transaction_reconcile_date
+                FROM transaction_debit_credit AS tdc
+          INNER JOIN transaction_accounts AS ta
+                  ON ta.account_id=tdc.account_id
+               WHERE tdc.transaction_id = ANY($1::int[])
+            ORDER BY tdc.transaction_id, tdc.transaction_dc_id`
+
+	rows, err := store.Client.Queryx(query, ids)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	var dcSet []*JournalDebitCredit
+
+	for rows.Next() {
+		var tdc JournalDebitCredit
+		if err = rows.StructScan(&tdc); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		dcSet = append(dcSet, &tdc)
+	}
+
+	return dcSet, nil
+}
@@ -0,0 +1,128 @@
+package datastore
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// TransactionSearchFilter is a full-text search of the comment and reference of transactions.  Query is in web
+// search syntax, ie `home depot -refund`.  A transaction is in an account subtree when any of its lines is on an
+// account with an account_left between AccountLeft and AccountRight.  Unset fields do not filter.
+type TransactionSearchFilter struct {
+	Query        string
+	AccountLeft  sql.NullInt64
+	AccountRight sql.NullInt64
+	StartDate    sql.NullTime
+	EndDate      sql.NullTime
+	MinAmount    sql.NullInt64
+	MaxAmount    sql.NullInt64
+	Offset       uint64
+	Limit        uint64
+}
+
+// TransactionSearchResult is a Transaction matching a search, with the matches of the query highlighted
+type TransactionSearchResult struct {
+	TransactionID            uint64       `db:"transaction_id"`
+	TransactionDate          time.Time    `db:"transaction_date"`
+	TransactionReconcileDate sql.NullTime `db:"transaction_reconcile_date"`
+	TransactionComment       string       `db:"transaction_comment"`
+	TransactionAmount        uint64       `db:"transaction_amount"`
+	TransactionReference     string       `db:"transaction_reference"`
+	IsReconciled             bool         `db:"is_reconciled"`
+	IsSplit                  bool         `db:"is_split"`
+	Rank                     float64      `db:"rank"`
+	CommentHighlight         string       `db:"comment_highlight"`
+	ReferenceHighlight       string       `db:"reference_highlight"`
+}
+
+// searchHighlightOptions marks every match of the query in the highlights
+const searchHighlightOptions = `'StartSel=<mark>, StopSel=</mark>, HighlightAll=TRUE'`
+
+// searchHighlightText escapes the text of a highlight as html.EscapeString does, before ts_headline marks the
+// matches, so the <mark> tags are the only markup in a highlight
+func searchHighlightText(column string) string {
+	return `replace(replace(replace(replace(replace(` + column + `, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+                                         '"', '&#34;'), '''', '&#39;')`
+}
+
+// searchFilterClause is shared by the search and its count, $1 is the query and $2 to $7 are the filters
+const searchFilterClause = `tm.transaction_search @@ websearch_to_tsquery('english', $1::text)
+                        AND ($2::bigint IS NULL OR EXISTS (
+                                  SELECT 1
+                                    FROM transaction_debit_credit AS tdc
+                              INNER JOIN transaction_accounts AS ta
+                                      ON ta.account_id=tdc.account_id
+                                   WHERE tdc.transaction_id=tm.transaction_id
+                                     AND ta.account_left BETWEEN $2::bigint AND $3::bigint))
+                        AND ($4::timestamptz IS NULL OR tm.transaction_date >= $4::timestamptz)
+                        AND ($5::timestamptz IS NULL OR tm.transaction_date < $5::timestamptz + interval '1 day')
+                        AND ($6::bigint IS NULL OR tm.transaction_amount >= $6::bigint)
+                        AND ($7::bigint IS NULL OR tm.transaction_amount <= $7::bigint)`
+
+func (filter *TransactionSearchFilter) args() []any {
+	return []any{filter.Query, filter.AccountLeft, filter.AccountRight, filter.StartDate, filter.EndDate,
+		filter.MinAmount, filter.MaxAmount}
+}
+
+// Search gets the transactions matching a full-text search, the best matches first
+func (store TransactionStore) Search(filter *TransactionSearchFilter) ([]*TransactionSearchResult, error) {
+	query := `SELECT ` + transactionColumns + `,
+                     ts_rank(tm.transaction_search, websearch_to_tsquery('english', $1::text)) AS rank,
+                     ts_headline('english', ` + searchHighlightText("tm.transaction_comment") + `,
+                                 websearch_to_tsquery('english', $1::text),
+                                 ` + searchHighlightOptions + `) AS comment_highlight,
+                     ts_headline('english', ` + searchHighlightText("COALESCE(tm.transaction_reference, '')") + `,
+                                 websearch_to_tsquery('english', $1::text),
+                                 ` + searchHighlightOptions + `) AS reference_highlight
+                FROM transaction_main AS tm
+               WHERE ` + searchFilterClause + `
+            ORDER BY rank DESC, tm.transaction_date DESC, tm.transaction_id DESC
+              OFFSET $8::bigint
+               LIMIT $9::bigint`
+
+	// a NULL limit is no limit
+	limit := sql.NullInt64{Int64: int64(filter.Limit), Valid: filter.Limit > 0} //nolint:gosec
+
+	args := append(filter.args(), filter.Offset, limit)
+
+	rows, err := store.Client.Queryx(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+
+	defer rows.Close()
+
+	var resultSet []*TransactionSearchResult
+
+	for rows.Next() {
+		var result TransactionSearchResult
+		if err = rows.StructScan(&result); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		resultSet = append(resultSet, &result)
+	}
+
+	if len(resultSet) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return resultSet, nil
+}
+
+// CountSearch counts the transactions matching a full-text search, ignoring the page
+func (store TransactionStore) CountSearch(filter *TransactionSearchFilter) (uint64, error) {
+	query := `SELECT COUNT(*)
+                FROM transaction_main AS tm
+               WHERE ` + searchFilterClause
+
+	var count uint64
+
+	err := store.Client.QueryRowx(query, filter.args()...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("store.Client.QueryRowx:%w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,75 @@
+package datastore
+
+import (
+	"fmt"
+	"time"
+)
+
+// TransactionModified is when a transaction was last changed
+type TransactionModified struct {
+	TransactionID           uint64    `db:"transaction_id"`
+	TransactionModifiedDate time.Time `db:"transaction_modified_date"`
+}
+
+// TransactionPosting is a debit or credit with the comment of its transaction
+type TransactionPosting struct {
+	TransactionID       uint64      `db:"transaction_id"`
+	TransactionComment  string      `db:"transaction_comment"`
+	AccountID           uint64      `db:"account_id"`
+	TransactionDCAmount uint64      `db:"transaction_dc_amount"`
+	DebitOrCredit       AccountSign `db:"debit_or_credit"`
+}
+
+// GetModifiedDates gets when each transaction was last changed
+func (store TransactionStore) GetModifiedDates() ([]*TransactionModified, error) {
+	query := `SELECT transaction_id, transaction_modified_date FROM transaction_main`
+
+	rows, err := store.Client.Queryx(query)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	var modifiedSet []*TransactionModified
+
+	for rows.Next() {
+		var modified TransactionModified
+		if err = rows.StructScan(&modified); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		modifiedSet = append(modifiedSet, &modified)
+	}
+
+	return modifiedSet, nil
+}
+
+// GetPostings gets the debits and credits of transactions in transaction_id order
+func (store TransactionStore) GetPostings(transactionIDs []uint64) ([]*TransactionPosting, error) {
+	query := `SELECT tm.transaction_id, tm.transaction_comment, dc.account_id, dc.transaction_dc_amount,
+		             dc.debit_or_credit
+		        FROM transaction_main AS tm
+		  INNER JOIN transaction_debit_credit AS dc
+		          ON dc.transaction_id = tm.transaction_id
+		       WHERE tm.transaction_id = ANY($1::int[])
+		    ORDER BY tm.transaction_id, dc.transaction_dc_id`
+
+	rows, err := store.Client.Queryx(query, transactionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("store.Client.Queryx:%w", err)
+	}
+	defer rows.Close()
+
+	var postingSet []*TransactionPosting
+
+	for rows.Next() {
+		var posting TransactionPosting
+		if err = rows.StructScan(&posting); err != nil {
+			return nil, fmt.Errorf("rows.StructScan:%w", err)
+		}
+
+		postingSet = append(postingSet, &posting)
+	}
+
+	return postingSet, nil
+}
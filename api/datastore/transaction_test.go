@@ -166,9 +166,10 @@ func TestTransactionStore_StoreWithDCAndDelete(t *testing.T) {
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 }
 
-func TestTransactionStore_StoreAndToggleReconciled(t *testing.T) {
+func TestTransactionStore_StoreAndToggleReconciled(t *testing.T) { //nolint:funlen
 	g := gomega.NewWithT(t)
 	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
 
 	store := createTransactionStore()
 
@@ -176,48 +177,83 @@ func TestTransactionStore_StoreAndToggleReconciled(t *testing.T) {
 	err := store.Store(&a1)
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 
+	aStore := createAccountStore()
+	myAcct := Account{AccountName: "myBank", AccountFullName: "BankAccounts:myBank",
+		AccountSign: AccountSignDebit, AccountType: AccountTypeAsset,
+		AccountBalance: 0, AccountDecimals: 2, AccountSubtotal: 0,
+		AccountLeft: 1, AccountRight: 2}
+	err = aStore.Store(&myAcct)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	mySavings := Account{AccountName: "mySavings", AccountFullName: "BankAccounts:mySavings",
+		AccountSign: AccountSignDebit, AccountType: AccountTypeAsset,
+		AccountBalance: 0, AccountDecimals: 2, AccountSubtotal: 0,
+		AccountLeft: 3, AccountRight: 4}
+	err = aStore.Store(&mySavings)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// a transfer from savings to the bank
+	dcStore := createTransactionDCStore()
+	myDC := TransactionDebitCredit{DebitOrCredit: AccountSignDebit, TransactionDCAmount: 1000,
+		TransactionID: a1.TransactionID, AccountID: myAcct.AccountID}
+	err = dcStore.Store(&myDC)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	myDCb := TransactionDebitCredit{DebitOrCredit: AccountSignCredit, TransactionDCAmount: 1000,
+		TransactionID: a1.TransactionID, AccountID: mySavings.AccountID}
+	err = dcStore.Store(&myDCb)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
 	myTrans, err := store.GetByID(a1.TransactionID)
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 	g.Expect(myTrans).NotTo(gomega.BeNil())
 	g.Expect(myTrans.IsReconciled).To(gomega.BeFalse())
 	g.Expect(myTrans.TransactionReconcileDate).To(gomega.Equal(sql.NullTime{Time: time.Time{}, Valid: false}))
 
-	a1.IsReconciled = true
-	err = store.SetIsReconciled(&a1)
+	// reconcile only the bank side
+	reconcileDate := time.Now()
+	err = store.SetReconciledBulk([]uint64{a1.TransactionID}, myAcct.AccountLeft, myAcct.AccountRight, true,
+		sql.NullTime{Time: reconcileDate, Valid: true})
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 
+	// the transaction is only reconciled once all of its lines are
 	myTrans, err = store.GetByID(a1.TransactionID)
 	g.Expect(err).NotTo(gomega.HaveOccurred())
-	g.Expect(myTrans).NotTo(gomega.BeNil())
-	g.Expect(myTrans).NotTo(gomega.BeNil())
-	g.Expect(myTrans.IsReconciled).To(gomega.BeTrue())
-	g.Expect(myTrans.TransactionReconcileDate).To(gomega.Equal(sql.NullTime{Time: time.Time{}, Valid: false}))
+	g.Expect(myTrans.IsReconciled).To(gomega.BeFalse())
+	g.Expect(myTrans.TransactionReconcileDate.Time).To(gomega.BeTemporally("~", reconcileDate, time.Second))
+
+	myDCSet, err := dcStore.GetDCForTransactionID(a1.TransactionID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myDCSet).To(gomega.HaveLen(2))
 
-	a1.TransactionReconcileDate = sql.NullTime{Time: time.Now(), Valid: true}
-	err = store.SetTransactionReconcileDate(&a1)
+	for _, dc := range myDCSet {
+		g.Expect(dc.IsReconciled).To(gomega.Equal(dc.AccountID == myAcct.AccountID))
+	}
+
+	// reconcile every line, then unreconcile every line, which keeps the reconcile date
+	err = store.SetReconciled(a1.TransactionID, true, sql.NullTime{Time: reconcileDate, Valid: true})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	myDCSet, err = dcStore.GetDCForTransactionID(a1.TransactionID)
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 
+	for _, dc := range myDCSet {
+		g.Expect(dc.IsReconciled).To(gomega.BeTrue())
+	}
+
 	myTrans, err = store.GetByID(a1.TransactionID)
 	g.Expect(err).NotTo(gomega.HaveOccurred())
-	g.Expect(myTrans).NotTo(gomega.BeNil())
-	g.Expect(myTrans).NotTo(gomega.BeNil())
 	g.Expect(myTrans.IsReconciled).To(gomega.BeTrue())
-	g.Expect(myTrans.TransactionReconcileDate.Time).To(gomega.BeTemporally("~", time.Now(), time.Second))
-	g.Expect(myTrans.TransactionReconcileDate.Valid).To(gomega.BeTrue())
 
-	// unset both
-	a1.TransactionReconcileDate.Valid = false
-	a1.IsReconciled = false
-	err = store.SetIsReconciled(&a1)
-	g.Expect(err).NotTo(gomega.HaveOccurred())
-	err = store.SetTransactionReconcileDate(&a1)
+	err = store.SetReconciled(a1.TransactionID, false, sql.NullTime{Time: time.Time{}, Valid: false})
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 
 	myTrans, err = store.GetByID(a1.TransactionID)
 	g.Expect(err).NotTo(gomega.HaveOccurred())
-	g.Expect(myTrans).NotTo(gomega.BeNil())
 	g.Expect(myTrans.IsReconciled).To(gomega.BeFalse())
-	g.Expect(myTrans.TransactionReconcileDate).To(gomega.Equal(sql.NullTime{Time: time.Time{}, Valid: false}))
+	g.Expect(myTrans.TransactionReconcileDate.Valid).To(gomega.BeTrue())
+
+	err = store.SetReconciled(999999, true, sql.NullTime{Time: reconcileDate, Valid: true})
+	g.Expect(errors.Is(err, sql.ErrNoRows)).To(gomega.BeTrue())
 }
 
 func TestTransactionStore_GetUnreconciledTransactionsOnAccountForDate(t *testing.T) {
@@ -280,13 +316,9 @@ func TestTransactionStore_GetUnreconciledTransactionsOnAccountForDate(t *testing
 	g.Expect(unreconciledTransaction[0].TransactionID).To(gomega.Equal(myTrans.TransactionID))
 
 	// set is_reconciled and the reconciled_date on myTrans1
-	myTrans.IsReconciled = true
-	err = transStore.SetIsReconciled(&myTrans)
-	g.Expect(err).NotTo(gomega.HaveOccurred())
 	reconciledDate, err := time.Parse("2006-01-02", "2016-07-11")
 	g.Expect(err).NotTo(gomega.HaveOccurred())
-	myTrans.TransactionReconcileDate = sql.NullTime{Time: reconciledDate, Valid: true}
-	err = transStore.SetTransactionReconcileDate(&myTrans)
+	err = transStore.SetReconciled(myTrans.TransactionID, true, sql.NullTime{Time: reconciledDate, Valid: true})
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 
 	// should still return nothing, as the reconciled date greater than the search limit date
@@ -342,13 +374,9 @@ func TestTransactionStore_GetUnreconciledTransactionsOnAccountForDate(t *testing
 	g.Expect(unreconciledTransaction2[1].TransactionID).To(gomega.Equal(myTrans2.TransactionID))
 
 	// set is_reconciled and the reconciled_date on one of the transactions
-	myTrans2.IsReconciled = true
-	err = transStore.SetIsReconciled(&myTrans2)
-	g.Expect(err).NotTo(gomega.HaveOccurred())
 	reconciledDate2, err := time.Parse("2006-01-02", "2023-09-08")
 	g.Expect(err).NotTo(gomega.HaveOccurred())
-	myTrans2.TransactionReconcileDate = sql.NullTime{Time: reconciledDate2, Valid: true}
-	err = transStore.SetTransactionReconcileDate(&myTrans2)
+	err = transStore.SetReconciled(myTrans2.TransactionID, true, sql.NullTime{Time: reconciledDate2, Valid: true})
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 
 	// get transactions , there are still two of them, one of them unreconciled, and the other reconciled, but after the
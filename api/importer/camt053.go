@@ -0,0 +1,354 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/money"
+)
+
+// camtAmount is an amount with its currency attribute, the amount itself is never negative
+type camtAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+// camtDate is a date, or a date and time
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+// camtParty is a debtor or creditor, the name is directly under the party up to version 07 and under Pty after
+type camtParty struct {
+	Name      string `xml:"Nm"`
+	PartyName string `xml:"Pty>Nm"`
+}
+
+// camtStatus is the status of an entry, a code up to version 08 and a Cd element after
+type camtStatus struct {
+	Text string `xml:",chardata"`
+	Code string `xml:"Cd"`
+}
+
+// camtTransaction is an entry detail, an entry of a batch has one for each transaction in the batch
+type camtTransaction struct {
+	EndToEndID       string     `xml:"Refs>EndToEndId"`
+	AccountServicer  string     `xml:"Refs>AcctSvcrRef"`
+	Amount           camtAmount `xml:"Amt"`
+	TransactionAmt   camtAmount `xml:"AmtDtls>TxAmt>Amt"`
+	CreditDebit      string     `xml:"CdtDbtInd"`
+	Debtor           camtParty  `xml:"RltdPties>Dbtr"`
+	Creditor         camtParty  `xml:"RltdPties>Cdtr"`
+	Unstructured     []string   `xml:"RmtInf>Ustrd"`
+	CreditorRefs     []string   `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+	AdditionalTxInfo string     `xml:"AddtlTxInf"`
+}
+
+// camt053XML is the layout of a camt.053 document, element names are matched without their namespace so every
+// version of the message is read
+type camt053XML struct {
+	Statements []struct {
+		ID      string `xml:"Id"`
+		Account struct {
+			IBAN     string `xml:"Id>IBAN"`
+			Other    string `xml:"Id>Othr>Id"`
+			Currency string `xml:"Ccy"`
+		} `xml:"Acct"`
+		Balances []struct {
+			Code        string     `xml:"Tp>CdOrPrtry>Cd"`
+			Amount      camtAmount `xml:"Amt"`
+			CreditDebit string     `xml:"CdtDbtInd"`
+			Date        camtDate   `xml:"Dt"`
+		} `xml:"Bal"`
+		Entries []struct {
+			EntryRef        string            `xml:"NtryRef"`
+			Amount          camtAmount        `xml:"Amt"`
+			CreditDebit     string            `xml:"CdtDbtInd"`
+			Status          camtStatus        `xml:"Sts"`
+			BookingDate     camtDate          `xml:"BookgDt"`
+			ValueDate       camtDate          `xml:"ValDt"`
+			AccountServicer string            `xml:"AcctSvcrRef"`
+			Transactions    []camtTransaction `xml:"NtryDtls>TxDtls"`
+			AdditionalInfo  string            `xml:"AddtlNtryInf"`
+		} `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+// camt053 balance type codes, a statement reports its opening booked balance or the previous closing balance
+const (
+	camtBalanceOpening         = "OPBD"
+	camtBalancePreviousClosing = "PRCD"
+	camtBalanceClosing         = "CLBD"
+	camtCredit                 = "CRDT"
+	camtDebit                  = "DBIT"
+	camtStatusBooked           = "BOOK"
+	// camtNotProvided is the end to end id of a payment that had none
+	camtNotProvided = "NOTPROVIDED"
+)
+
+// ParseCAMT053 parses an ISO 20022 camt.053 bank to customer statement.  Booked entries become lines, pending
+// entries are not read, and a reversal is a line on the side the bank booked it.  An entry that is a batch of
+// several transactions with their own amounts becomes a line for each transaction.  The counterparty is the debtor
+// of a credit and the creditor of a debit.  A file with several statements of the account is read as one, from the
+// opening balance of the first to the closing balance of the last.
+func ParseCAMT053(reader io.Reader, decimals uint64) (*Statement, error) { //nolint:cyclop,funlen
+	var doc camt053XML
+
+	if err := xml.NewDecoder(reader).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrStatementInvalid, err)
+	}
+
+	if len(doc.Statements) == 0 {
+		return nil, fmt.Errorf("%w: no camt.053 statement", ErrStatementInvalid)
+	}
+
+	myStatement := Statement{
+		AccountNumber:  strings.TrimSpace(doc.Statements[0].Account.IBAN),
+		Currency:       strings.TrimSpace(doc.Statements[0].Account.Currency),
+		Lines:          nil,
+		RowErrors:      nil,
+		Categories:     nil,
+		OpeningBalance: nil,
+		ClosingBalance: nil,
+	}
+
+	if myStatement.AccountNumber == "" {
+		myStatement.AccountNumber = strings.TrimSpace(doc.Statements[0].Account.Other)
+	}
+
+	for _, stmt := range doc.Statements {
+		for _, balance := range stmt.Balances {
+			code := strings.TrimSpace(balance.Code)
+			if code != camtBalanceOpening && code != camtBalancePreviousClosing && code != camtBalanceClosing {
+				continue
+			}
+
+			myBalance, err := camtBalance(balance.Amount, balance.CreditDebit, balance.Date, decimals)
+			if err != nil {
+				return nil, fmt.Errorf("%w [statement:%s balance:%s]", err, stmt.ID, code)
+			}
+
+			if code == camtBalanceClosing {
+				myStatement.ClosingBalance = myBalance
+			} else if myStatement.OpeningBalance == nil {
+				myStatement.OpeningBalance = myBalance
+			}
+		}
+
+		for _, entry := range stmt.Entries {
+			status := strings.TrimSpace(entry.Status.Code)
+			if status == "" {
+				status = strings.TrimSpace(entry.Status.Text)
+			}
+
+			if status != "" && status != camtStatusBooked {
+				continue
+			}
+
+			bookingDate, err := camtParseDate(entry.BookingDate)
+			if err != nil {
+				return nil, fmt.Errorf("%w [statement:%s entry:%s]", err, stmt.ID, entry.EntryRef)
+			}
+
+			valueDate, err := camtParseDate(entry.ValueDate)
+			if err != nil {
+				return nil, fmt.Errorf("%w [statement:%s entry:%s]", err, stmt.ID, entry.EntryRef)
+			}
+
+			if bookingDate.IsZero() {
+				bookingDate = valueDate
+			}
+
+			if bookingDate.IsZero() {
+				return nil, fmt.Errorf("%w: entry %s has no booking date", ErrStatementInvalid, entry.EntryRef)
+			}
+
+			transactions := entry.Transactions
+
+			// a batch is split into its transactions only when each says how much it is
+			batch := len(transactions) > 1
+			for _, txn := range transactions {
+				if txn.amount().Value == "" {
+					batch = false
+				}
+			}
+
+			if !batch {
+				var details camtTransaction
+				if len(transactions) > 0 {
+					details = transactions[0]
+				}
+
+				details.Amount, details.CreditDebit = entry.Amount, entry.CreditDebit
+				details.TransactionAmt = camtAmount{Value: "", Currency: ""}
+				transactions = []camtTransaction{details}
+			}
+
+			for idx, txn := range transactions {
+				creditDebit := txn.CreditDebit
+				if creditDebit == "" {
+					creditDebit = entry.CreditDebit
+				}
+
+				amount, err := camtSignedAmount(txn.amount(), creditDebit, decimals)
+				if err != nil {
+					return nil, fmt.Errorf("%w [statement:%s entry:%s]", err, stmt.ID, entry.EntryRef)
+				}
+
+				if amount == 0 {
+					continue
+				}
+
+				line := StatementLine{
+					ExternalID: camtExternalID(entry.AccountServicer, txn.AccountServicer, entry.EntryRef),
+					Date:       bookingDate,
+					ValueDate:  valueDate,
+					Amount:     amount,
+					Payee:      txn.counterparty(amount),
+					Memo:       txn.remittance(entry.AdditionalInfo),
+					Reference:  strings.TrimSpace(txn.EndToEndID),
+					Category:   "",
+					Splits:     nil,
+				}
+
+				// the transactions of a batch have their own references, or are numbered within the entry
+				if batch {
+					line.ExternalID = strings.TrimSpace(txn.AccountServicer)
+					if entryID := camtExternalID(entry.AccountServicer, entry.EntryRef); line.ExternalID == "" &&
+						entryID != "" {
+						line.ExternalID = fmt.Sprintf("%s/%d", entryID, idx+1)
+					}
+				}
+
+				if line.Reference == "" || line.Reference == camtNotProvided {
+					line.Reference = camtExternalID(txn.AccountServicer, entry.AccountServicer, entry.EntryRef)
+				}
+
+				myStatement.Lines = append(myStatement.Lines, &line)
+			}
+		}
+	}
+
+	if len(myStatement.Lines) == 0 {
+		return nil, ErrStatementEmpty
+	}
+
+	return &myStatement, nil
+}
+
+// amount is the amount of the transaction in the currency of the account
+func (c *camtTransaction) amount() camtAmount {
+	if strings.TrimSpace(c.Amount.Value) != "" {
+		return c.Amount
+	}
+
+	return c.TransactionAmt
+}
+
+// counterparty is who paid a credit, or who was paid by a debit
+func (c *camtTransaction) counterparty(amount int64) string {
+	party := c.Creditor
+	if amount > 0 {
+		party = c.Debtor
+	}
+
+	name := strings.TrimSpace(party.Name)
+	if name == "" {
+		name = strings.TrimSpace(party.PartyName)
+	}
+
+	return name
+}
+
+// remittance is the unstructured remittance information, or else the structured creditor references, or else the
+// additional information of the transaction or of the entry
+func (c *camtTransaction) remittance(entryInfo string) string {
+	for _, parts := range [][]string{c.Unstructured, c.CreditorRefs, {c.AdditionalTxInfo}, {entryInfo}} {
+		var remittance []string
+
+		for _, part := range parts {
+			if part = strings.Join(strings.Fields(part), " "); part != "" {
+				remittance = append(remittance, part)
+			}
+		}
+
+		if len(remittance) > 0 {
+			return strings.Join(remittance, " ")
+		}
+	}
+
+	return ""
+}
+
+// camtExternalID is the first reference that is set
+func camtExternalID(refs ...string) string {
+	for _, ref := range refs {
+		if ref = strings.TrimSpace(ref); ref != "" {
+			return ref
+		}
+	}
+
+	return ""
+}
+
+// camtSignedAmount is the amount in minor units, negative when it is a debit
+func camtSignedAmount(amount camtAmount, creditDebit string, decimals uint64) (int64, error) {
+	value, err := money.Parse(amount.Value, decimals, ".")
+	if err != nil {
+		return 0, fmt.Errorf("%w: amount %w", ErrStatementInvalid, err)
+	}
+
+	switch strings.TrimSpace(creditDebit) {
+	case camtCredit:
+		return value, nil
+	case camtDebit:
+		return -value, nil
+	}
+
+	return 0, fmt.Errorf("%w: credit debit indicator %q", ErrStatementInvalid, creditDebit)
+}
+
+func camtBalance(amount camtAmount, creditDebit string, date camtDate,
+	decimals uint64) (*StatementBalance, error) {
+	value, err := camtSignedAmount(amount, creditDebit, decimals)
+	if err != nil {
+		return nil, err
+	}
+
+	balanceDate, err := camtParseDate(date)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StatementBalance{Date: balanceDate, Amount: value}, nil
+}
+
+// camtParseDate parses an ISODate or an ISODateTime, the date of a date time is kept as the bank wrote it
+func camtParseDate(date camtDate) (time.Time, error) {
+	if dateStr := strings.TrimSpace(date.Date); dateStr != "" {
+		parsed, err := time.Parse(time.DateOnly, dateStr)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: date %q", ErrStatementInvalid, dateStr)
+		}
+
+		return parsed, nil
+	}
+
+	dateTimeStr := strings.TrimSpace(date.DateTime)
+	if dateTimeStr == "" {
+		return time.Time{}, nil
+	}
+
+	// the date part is the booking day at the bank, whatever the time zone
+	if len(dateTimeStr) >= len(time.DateOnly) {
+		if parsed, err := time.Parse(time.DateOnly, dateTimeStr[:len(time.DateOnly)]); err == nil {
+			return parsed, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: date time %q", ErrStatementInvalid, dateTimeStr)
+}
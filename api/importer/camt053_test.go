@@ -0,0 +1,83 @@
+package importer
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestParseCAMT053(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	file, err := os.Open("testdata/statement.camt053.xml")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	defer file.Close()
+
+	myStatement, err := ParseCAMT053(file, 2)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myStatement.AccountNumber).To(gomega.Equal("DE89370400440532013000"))
+	g.Expect(myStatement.Currency).To(gomega.Equal("EUR"))
+	g.Expect(*myStatement.OpeningBalance).To(gomega.Equal(StatementBalance{
+		Date: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), Amount: 100000}))
+	g.Expect(*myStatement.ClosingBalance).To(gomega.Equal(StatementBalance{
+		Date: time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC), Amount: 311250}))
+	// the batch is split in two and the pending entry is not read
+	g.Expect(myStatement.Lines).To(gomega.HaveLen(4))
+
+	salary := myStatement.Lines[0]
+	g.Expect(salary.ExternalID).To(gomega.Equal("BANK-0001"))
+	g.Expect(salary.Amount).To(gomega.Equal(int64(250000)))
+	g.Expect(salary.Date).To(gomega.Equal(time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)))
+	g.Expect(salary.ValueDate).To(gomega.Equal(time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)))
+	// the counterparty of a credit is the debtor
+	g.Expect(salary.Payee).To(gomega.Equal("ACME GmbH"))
+	g.Expect(salary.Memo).To(gomega.Equal("Salary May 2024"))
+	g.Expect(salary.Reference).To(gomega.Equal("PAYROLL-05"))
+
+	power := myStatement.Lines[1]
+	g.Expect(power.ExternalID).To(gomega.Equal("BANK-0002-1"))
+	g.Expect(power.Amount).To(gomega.Equal(int64(-10000)))
+	g.Expect(power.Date).To(gomega.Equal(time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)))
+	g.Expect(power.Payee).To(gomega.Equal("Power Co"))
+	g.Expect(power.Memo).To(gomega.Equal("RF18539007547034"))
+	g.Expect(power.Reference).To(gomega.Equal("BANK-0002-1"))
+
+	water := myStatement.Lines[2]
+	g.Expect(water.ExternalID).To(gomega.Equal("BANK-0002/2"))
+	g.Expect(water.Amount).To(gomega.Equal(int64(-3750)))
+	g.Expect(water.Payee).To(gomega.Equal("Water Co"))
+	g.Expect(water.Memo).To(gomega.Equal("Direct debit batch"))
+
+	// a reversal is read on the side the bank booked it
+	reversal := myStatement.Lines[3]
+	g.Expect(reversal.ExternalID).To(gomega.Equal("3"))
+	g.Expect(reversal.Amount).To(gomega.Equal(int64(-25000)))
+	g.Expect(reversal.Memo).To(gomega.Equal("Return of incoming payment"))
+}
+
+func TestParseCAMT053_Invalid(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	_, err := ParseCAMT053(strings.NewReader("not xml"), 2)
+	g.Expect(errors.Is(err, ErrStatementInvalid)).To(gomega.BeTrue())
+
+	_, err = ParseCAMT053(strings.NewReader("<Document><BkToCstmrStmt></BkToCstmrStmt></Document>"), 2)
+	g.Expect(errors.Is(err, ErrStatementInvalid)).To(gomega.BeTrue())
+
+	_, err = ParseCAMT053(strings.NewReader("<Document><BkToCstmrStmt><Stmt><Id>1</Id></Stmt></BkToCstmrStmt>"+
+		"</Document>"), 2)
+	g.Expect(errors.Is(err, ErrStatementEmpty)).To(gomega.BeTrue())
+
+	_, err = ParseCAMT053(strings.NewReader("<Document><BkToCstmrStmt><Stmt><Ntry><Amt>1.00</Amt>"+
+		"<CdtDbtInd>UP</CdtDbtInd><BookgDt><Dt>2024-05-01</Dt></BookgDt></Ntry></Stmt></BkToCstmrStmt>"+
+		"</Document>"), 2)
+	g.Expect(errors.Is(err, ErrStatementInvalid)).To(gomega.BeTrue())
+}
@@ -0,0 +1,286 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mimirsoft/mimirledger/api/money"
+)
+
+// SignConvention is how the sign of a CSV amount relates to the account
+const (
+	// SignNormal is a positive amount moving money into the account, ie a deposit
+	SignNormal = "NORMAL"
+	// SignInverted is a positive amount moving money out of the account, as card exports often list purchases
+	SignInverted = "INVERTED"
+)
+
+// CSVProfile maps the columns of a bank's CSV export onto statement lines.  Columns are numbered from 1 and 0 is
+// not mapped.  A file has either an AmountColumn, or a DebitColumn for money out and a CreditColumn for money in.
+type CSVProfile struct {
+	// Delimiter is a single character, a comma when empty
+	Delimiter string
+	// SkipRows are skipped before the first line, including any header row
+	SkipRows          int
+	DateColumn        int
+	AmountColumn      int
+	DebitColumn       int
+	CreditColumn      int
+	DescriptionColumn int
+	ReferenceColumn   int
+	// ExternalIDColumn is a unique id of the row at the bank, used to find lines imported before
+	ExternalIDColumn int
+	// DateFormat uses YYYY, YY, MMM, MM, M, DD and D, ie DD/MM/YYYY.  YYYY-MM-DD when empty.
+	DateFormat string
+	// DecimalSeparator is "." or ",", "." when empty
+	DecimalSeparator string
+	// SignConvention is SignNormal or SignInverted, SignNormal when empty
+	SignConvention string
+}
+
+var ErrCSVProfileInvalid = errors.New("csv profile is invalid")
+
+const defaultCSVDateFormat = "YYYY-MM-DD"
+
+// dateFormatReplacer converts the tokens of a DateFormat into a time layout, longer tokens are listed first
+var dateFormatReplacer = strings.NewReplacer("YYYY", "2006", "YY", "06", "MMM", "Jan", "MM", "01", "M", "1",
+	"DD", "02", "D", "2")
+
+// Validate checks the profile maps a date and an amount
+func (c *CSVProfile) Validate() error {
+	if utf8.RuneCountInString(c.Delimiter) > 1 {
+		return fmt.Errorf("%w: delimiter must be a single character", ErrCSVProfileInvalid)
+	}
+
+	if c.SkipRows < 0 {
+		return fmt.Errorf("%w: skipRows cannot be negative", ErrCSVProfileInvalid)
+	}
+
+	for _, column := range []int{c.DateColumn, c.AmountColumn, c.DebitColumn, c.CreditColumn,
+		c.DescriptionColumn, c.ReferenceColumn, c.ExternalIDColumn} {
+		if column < 0 {
+			return fmt.Errorf("%w: columns cannot be negative", ErrCSVProfileInvalid)
+		}
+	}
+
+	if c.DateColumn == 0 {
+		return fmt.Errorf("%w: dateColumn is required", ErrCSVProfileInvalid)
+	}
+
+	switch {
+	case c.AmountColumn > 0 && (c.DebitColumn > 0 || c.CreditColumn > 0):
+		return fmt.Errorf("%w: use amountColumn or debitColumn and creditColumn, not both", ErrCSVProfileInvalid)
+	case c.AmountColumn == 0 && (c.DebitColumn == 0 || c.CreditColumn == 0):
+		return fmt.Errorf("%w: amountColumn, or debitColumn and creditColumn, are required", ErrCSVProfileInvalid)
+	}
+
+	if c.DecimalSeparator != "" && c.DecimalSeparator != "." && c.DecimalSeparator != "," {
+		return fmt.Errorf("%w: decimalSeparator must be . or ,", ErrCSVProfileInvalid)
+	}
+
+	if c.SignConvention != "" && c.SignConvention != SignNormal && c.SignConvention != SignInverted {
+		return fmt.Errorf("%w: signConvention must be %s or %s", ErrCSVProfileInvalid, SignNormal, SignInverted)
+	}
+
+	return nil
+}
+
+func (c *CSVProfile) dateLayout() string {
+	if c.DateFormat == "" {
+		return dateFormatReplacer.Replace(defaultCSVDateFormat)
+	}
+
+	return dateFormatReplacer.Replace(c.DateFormat)
+}
+
+func (c *CSVProfile) decimalSeparator() string {
+	if c.DecimalSeparator == "" {
+		return "."
+	}
+
+	return c.DecimalSeparator
+}
+
+// ParseCSV parses a CSV file with a profile, amounts are converted to minor units with decimals.  A row that cannot
+// be read is recorded in RowErrors and the rest of the file is still parsed.
+func ParseCSV(reader io.Reader, profile *CSVProfile, decimals uint64) (*Statement, error) {
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("io.ReadAll:%w", err)
+	}
+
+	csvReader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(body, []byte("\ufeff"))))
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+
+	if profile.Delimiter != "" {
+		csvReader.Comma, _ = utf8.DecodeRuneInString(profile.Delimiter)
+	}
+
+	myStatement := Statement{AccountNumber: "", Currency: "", Lines: nil, RowErrors: nil}
+
+	for rowIdx := 0; ; rowIdx++ {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		row, _ := csvReader.FieldPos(0)
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("csvReader.Read:%w", err)
+			}
+
+			myStatement.RowErrors = append(myStatement.RowErrors, &RowError{Row: parseErr.StartLine,
+				Message: parseErr.Err.Error()})
+
+			continue
+		}
+
+		if rowIdx < profile.SkipRows {
+			continue
+		}
+
+		line, err := csvStatementLine(record, profile, decimals)
+		if err != nil {
+			myStatement.RowErrors = append(myStatement.RowErrors, &RowError{Row: row, Message: err.Error()})
+
+			continue
+		}
+
+		// a zero amount cannot be posted
+		if line.Amount == 0 {
+			continue
+		}
+
+		myStatement.Lines = append(myStatement.Lines, line)
+	}
+
+	if len(myStatement.Lines) == 0 && len(myStatement.RowErrors) == 0 {
+		return nil, ErrStatementEmpty
+	}
+
+	return &myStatement, nil
+}
+
+var errCSVColumnMissing = errors.New("row has no column")
+var errCSVDateInvalid = errors.New("date does not match the date format")
+var errCSVAmountEmpty = errors.New("debit and credit are both empty")
+
+// csvField is the trimmed value of a column numbered from 1, an unmapped column is empty
+func csvField(record []string, column int) (string, error) {
+	if column == 0 {
+		return "", nil
+	}
+
+	if column > len(record) {
+		return "", fmt.Errorf("%w %d", errCSVColumnMissing, column)
+	}
+
+	return strings.TrimSpace(record[column-1]), nil
+}
+
+func csvStatementLine(record []string, profile *CSVProfile, decimals uint64) (*StatementLine, error) {
+	dateStr, err := csvField(record, profile.DateColumn)
+	if err != nil {
+		return nil, err
+	}
+
+	date, err := time.Parse(profile.dateLayout(), dateStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", errCSVDateInvalid, dateStr)
+	}
+
+	amount, err := csvAmount(record, profile, decimals)
+	if err != nil {
+		return nil, err
+	}
+
+	if profile.SignConvention == SignInverted {
+		amount = -amount
+	}
+
+	line := StatementLine{ExternalID: "", Date: date, Amount: amount, Payee: "", Memo: "", Reference: ""}
+
+	if line.Payee, err = csvField(record, profile.DescriptionColumn); err != nil {
+		return nil, err
+	}
+
+	if line.Reference, err = csvField(record, profile.ReferenceColumn); err != nil {
+		return nil, err
+	}
+
+	if line.ExternalID, err = csvField(record, profile.ExternalIDColumn); err != nil {
+		return nil, err
+	}
+
+	return &line, nil
+}
+
+// csvAmount is the amount column, or the credit column less the debit column where an empty column is zero
+func csvAmount(record []string, profile *CSVProfile, decimals uint64) (int64, error) {
+	if profile.AmountColumn > 0 {
+		amountStr, err := csvField(record, profile.AmountColumn)
+		if err != nil {
+			return 0, err
+		}
+
+		amount, err := money.Parse(amountStr, decimals, profile.decimalSeparator())
+		if err != nil {
+			return 0, fmt.Errorf("amount:%w", err)
+		}
+
+		return amount, nil
+	}
+
+	debitStr, err := csvField(record, profile.DebitColumn)
+	if err != nil {
+		return 0, err
+	}
+
+	creditStr, err := csvField(record, profile.CreditColumn)
+	if err != nil {
+		return 0, err
+	}
+
+	if debitStr == "" && creditStr == "" {
+		return 0, errCSVAmountEmpty
+	}
+
+	var debit, credit int64
+
+	if debitStr != "" {
+		if debit, err = money.Parse(debitStr, decimals, profile.decimalSeparator()); err != nil {
+			return 0, fmt.Errorf("debit:%w", err)
+		}
+	}
+
+	if creditStr != "" {
+		if credit, err = money.Parse(creditStr, decimals, profile.decimalSeparator()); err != nil {
+			return 0, fmt.Errorf("credit:%w", err)
+		}
+	}
+
+	// banks list debits as positive or negative, either way they are money out
+	if debit > 0 {
+		debit = -debit
+	}
+
+	if credit < 0 {
+		credit = -credit
+	}
+
+	return credit + debit, nil
+}
@@ -0,0 +1,92 @@
+package importer
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestParseCSV_DebitCreditColumns(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	file, err := os.Open("testdata/bank_debit_credit.csv")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	defer file.Close()
+
+	profile := CSVProfile{Delimiter: ";", SkipRows: 2, DateColumn: 1, DescriptionColumn: 2, ReferenceColumn: 3,
+		DebitColumn: 4, CreditColumn: 5, DateFormat: "DD/MM/YYYY", DecimalSeparator: ","}
+
+	myStatement, err := ParseCSV(file, &profile, 2)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myStatement.Lines).To(gomega.HaveLen(3))
+
+	g.Expect(myStatement.Lines[0].Amount).To(gomega.Equal(int64(-4599)))
+	g.Expect(myStatement.Lines[0].Date).To(gomega.Equal(time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)))
+	g.Expect(myStatement.Lines[0].Comment()).To(gomega.Equal("Corner Books"))
+
+	g.Expect(myStatement.Lines[1].Amount).To(gomega.Equal(int64(250000)))
+	g.Expect(myStatement.Lines[1].Payee).To(gomega.Equal("Salary; May"))
+	g.Expect(myStatement.Lines[1].Reference).To(gomega.Equal("PAY-5"))
+
+	g.Expect(myStatement.Lines[2].Amount).To(gomega.Equal(int64(1250)))
+
+	// the bad rows are reported with their line numbers, the rest of the file is imported
+	g.Expect(myStatement.RowErrors).To(gomega.HaveLen(2))
+	g.Expect(myStatement.RowErrors[0].Row).To(gomega.Equal(5))
+	g.Expect(myStatement.RowErrors[0].Message).To(gomega.ContainSubstring("31/04/2024"))
+	g.Expect(myStatement.RowErrors[1].Row).To(gomega.Equal(6))
+}
+
+func TestParseCSV_AmountColumnInverted(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	file, err := os.Open("testdata/card_amount.csv")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	defer file.Close()
+
+	profile := CSVProfile{SkipRows: 1, ExternalIDColumn: 1, DateColumn: 2, DescriptionColumn: 3, AmountColumn: 4,
+		SignConvention: SignInverted}
+
+	myStatement, err := ParseCSV(file, &profile, 2)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myStatement.Lines).To(gomega.HaveLen(2))
+
+	// purchases are positive in the file and move money out of the account
+	g.Expect(myStatement.Lines[0].ExternalID).To(gomega.Equal("T-1"))
+	g.Expect(myStatement.Lines[0].Amount).To(gomega.Equal(int64(-450)))
+	g.Expect(myStatement.Lines[1].Amount).To(gomega.Equal(int64(30000)))
+
+	g.Expect(myStatement.RowErrors).To(gomega.HaveLen(1))
+	g.Expect(myStatement.RowErrors[0].Row).To(gomega.Equal(4))
+}
+
+func TestCSVProfile_Validate(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	invalid := []CSVProfile{
+		{AmountColumn: 2},
+		{DateColumn: 1},
+		{DateColumn: 1, DebitColumn: 2},
+		{DateColumn: 1, AmountColumn: 2, CreditColumn: 3},
+		{DateColumn: 1, AmountColumn: 2, Delimiter: "||"},
+		{DateColumn: 1, AmountColumn: 2, DecimalSeparator: "'"},
+		{DateColumn: 1, AmountColumn: 2, SignConvention: "BACKWARDS"},
+		{DateColumn: 1, AmountColumn: 2, SkipRows: -1},
+	}
+
+	for idx := range invalid {
+		g.Expect(errors.Is(invalid[idx].Validate(), ErrCSVProfileInvalid)).To(gomega.BeTrue())
+	}
+
+	valid := CSVProfile{DateColumn: 1, DebitColumn: 2, CreditColumn: 3, Delimiter: "\t"}
+	g.Expect(valid.Validate()).To(gomega.Succeed())
+}
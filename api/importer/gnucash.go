@@ -0,0 +1,217 @@
+package importer
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// GnuCashBook is the accounts and transactions of a GnuCash XML book
+type GnuCashBook struct {
+	Accounts     []*GnuCashAccount
+	Transactions []*GnuCashTransaction
+}
+
+// GnuCashAccount is an account of a book, Type is the GnuCash type such as BANK or EXPENSE.  The ROOT account is
+// the parent of the top level accounts.
+type GnuCashAccount struct {
+	ID          string
+	Name        string
+	Type        string
+	ParentID    string
+	Description string
+}
+
+// GnuCashTransaction is a transaction of a book with its splits
+type GnuCashTransaction struct {
+	ID          string
+	Num         string
+	DatePosted  time.Time
+	Description string
+	Splits      []*GnuCashSplit
+}
+
+// Comment is the transaction comment, the description or else the number
+func (c *GnuCashTransaction) Comment() string {
+	comment := strings.TrimSpace(c.Description)
+	if comment == "" {
+		comment = strings.TrimSpace("GnuCash transaction " + c.Num)
+	}
+
+	return truncate(comment, maxCommentLength)
+}
+
+// Reference is the number of the transaction, truncated to fit a transaction reference
+func (c *GnuCashTransaction) Reference() string {
+	return truncate(c.Num, maxReferenceLength)
+}
+
+// GnuCashSplit is a split of a transaction, a positive Value debits the account
+type GnuCashSplit struct {
+	ID        string
+	AccountID string
+	Memo      string
+	// ReconciledState is n (new), c (cleared), y (reconciled), f (frozen) or v (voided)
+	ReconciledState string
+	// ReconcileDate is zero when the split is not reconciled
+	ReconcileDate time.Time
+	// Value is in the currency of the transaction
+	Value *big.Rat
+}
+
+// GnuCash account types
+const (
+	GnuCashAccountTypeRoot = "ROOT"
+)
+
+// IsReconciled is whether the split was reconciled in GnuCash
+func (c *GnuCashSplit) IsReconciled() bool {
+	return c.ReconciledState == "y" || c.ReconciledState == "f"
+}
+
+// Amount is the Value in minor units with decimals, rounded half away from zero
+func (c *GnuCashSplit) Amount(decimals uint64) int64 {
+	return RoundRat(c.Value, decimals)
+}
+
+// RoundRat converts a rational amount to minor units with decimals, rounded half away from zero
+func RoundRat(value *big.Rat, decimals uint64) int64 {
+	scale := new(big.Int).Exp(big.NewInt(10), new(big.Int).SetUint64(decimals), nil) //nolint:mnd
+	scaled := new(big.Rat).Mul(value, new(big.Rat).SetInt(scale))
+
+	quotient, remainder := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+
+	// |remainder| * 2 >= denominator rounds away from zero
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(scaled.Denom()) >= 0 { //nolint:mnd
+		quotient.Add(quotient, big.NewInt(int64(scaled.Num().Sign())))
+	}
+
+	return quotient.Int64()
+}
+
+// gnuCashXML is the layout of the file, element names are matched without their namespace.  The accounts and
+// transactions of scheduled transaction templates are in template-transactions and are not read.
+type gnuCashXML struct {
+	Books []struct {
+		Accounts []struct {
+			ID          string `xml:"id"`
+			Name        string `xml:"name"`
+			Type        string `xml:"type"`
+			Parent      string `xml:"parent"`
+			Description string `xml:"description"`
+		} `xml:"account"`
+		Transactions []struct {
+			ID          string `xml:"id"`
+			Num         string `xml:"num"`
+			DatePosted  string `xml:"date-posted>date"`
+			Description string `xml:"description"`
+			Splits      []struct {
+				ID              string `xml:"id"`
+				Memo            string `xml:"memo"`
+				ReconciledState string `xml:"reconciled-state"`
+				ReconcileDate   string `xml:"reconcile-date>date"`
+				Value           string `xml:"value"`
+				Account         string `xml:"account"`
+			} `xml:"splits>split"`
+		} `xml:"transaction"`
+	} `xml:"book"`
+}
+
+// gnuCashDateLayout is the layout of ts:date, ie 2024-01-15 10:59:00 +0000
+const gnuCashDateLayout = "2006-01-02 15:04:05 -0700"
+
+// ParseGnuCash parses a GnuCash XML book, either uncompressed or gzipped as GnuCash saves it by default
+func ParseGnuCash(reader io.Reader) (*GnuCashBook, error) {
+	buffered := bufio.NewReader(reader)
+
+	var source io.Reader = buffered
+
+	// gzip files start with 1f 8b
+	if magic, err := buffered.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b { //nolint:mnd
+		gzipReader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrStatementInvalid, err)
+		}
+		defer gzipReader.Close()
+
+		source = gzipReader
+	}
+
+	var doc gnuCashXML
+
+	if err := xml.NewDecoder(source).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrStatementInvalid, err)
+	}
+
+	if len(doc.Books) == 0 {
+		return nil, fmt.Errorf("%w: no GnuCash book", ErrStatementInvalid)
+	}
+
+	var book GnuCashBook
+
+	for _, docBook := range doc.Books {
+		for _, account := range docBook.Accounts {
+			book.Accounts = append(book.Accounts, &GnuCashAccount{
+				ID:          strings.TrimSpace(account.ID),
+				Name:        strings.TrimSpace(account.Name),
+				Type:        strings.TrimSpace(account.Type),
+				ParentID:    strings.TrimSpace(account.Parent),
+				Description: strings.TrimSpace(account.Description),
+			})
+		}
+
+		for _, docTxn := range docBook.Transactions {
+			datePosted, err := time.Parse(gnuCashDateLayout, strings.TrimSpace(docTxn.DatePosted))
+			if err != nil {
+				return nil, fmt.Errorf("%w: transaction %s date %q", ErrStatementInvalid, docTxn.ID, docTxn.DatePosted)
+			}
+
+			txn := GnuCashTransaction{
+				ID:          strings.TrimSpace(docTxn.ID),
+				Num:         strings.TrimSpace(docTxn.Num),
+				DatePosted:  datePosted,
+				Description: strings.TrimSpace(docTxn.Description),
+				Splits:      make([]*GnuCashSplit, 0, len(docTxn.Splits)),
+			}
+
+			for _, docSplit := range docTxn.Splits {
+				value, ok := new(big.Rat).SetString(strings.TrimSpace(docSplit.Value))
+				if !ok {
+					return nil, fmt.Errorf("%w: transaction %s value %q", ErrStatementInvalid, txn.ID,
+						docSplit.Value)
+				}
+
+				var reconcileDate time.Time
+
+				if dateStr := strings.TrimSpace(docSplit.ReconcileDate); dateStr != "" {
+					if reconcileDate, err = time.Parse(gnuCashDateLayout, dateStr); err != nil {
+						return nil, fmt.Errorf("%w: transaction %s reconcile date %q", ErrStatementInvalid,
+							txn.ID, dateStr)
+					}
+				}
+
+				txn.Splits = append(txn.Splits, &GnuCashSplit{
+					ID:              strings.TrimSpace(docSplit.ID),
+					AccountID:       strings.TrimSpace(docSplit.Account),
+					Memo:            strings.TrimSpace(docSplit.Memo),
+					ReconciledState: strings.TrimSpace(docSplit.ReconciledState),
+					ReconcileDate:   reconcileDate,
+					Value:           value,
+				})
+			}
+
+			book.Transactions = append(book.Transactions, &txn)
+		}
+	}
+
+	if len(book.Accounts) == 0 {
+		return nil, fmt.Errorf("%w: book has no accounts", ErrStatementInvalid)
+	}
+
+	return &book, nil
+}
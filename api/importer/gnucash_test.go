@@ -0,0 +1,72 @@
+package importer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestParseGnuCash(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	body, err := os.ReadFile("testdata/book.gnucash")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	var compressed bytes.Buffer
+
+	gzipWriter := gzip.NewWriter(&compressed)
+	_, err = gzipWriter.Write(body)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(gzipWriter.Close()).To(gomega.Succeed())
+
+	// GnuCash saves gzipped by default, both read the same
+	for _, file := range [][]byte{body, compressed.Bytes()} {
+		book, err := ParseGnuCash(bytes.NewReader(file))
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		// the template root is not a book account
+		g.Expect(book.Accounts).To(gomega.HaveLen(6))
+		g.Expect(book.Accounts[0].Type).To(gomega.Equal(GnuCashAccountTypeRoot))
+		g.Expect(book.Accounts[2].Name).To(gomega.Equal("Checking Account"))
+		g.Expect(book.Accounts[2].Type).To(gomega.Equal("BANK"))
+		g.Expect(book.Accounts[2].Description).To(gomega.Equal("Everyday checking"))
+		g.Expect(book.Accounts[2].ParentID).To(gomega.Equal(book.Accounts[1].ID))
+
+		g.Expect(book.Transactions).To(gomega.HaveLen(4))
+
+		salary := book.Transactions[0]
+		g.Expect(salary.Num).To(gomega.Equal("101"))
+		g.Expect(salary.Description).To(gomega.Equal("January salary"))
+		g.Expect(salary.DatePosted.Equal(time.Date(2015, 1, 31, 10, 59, 0, 0, time.UTC))).To(gomega.BeTrue())
+		g.Expect(salary.Splits).To(gomega.HaveLen(2))
+		g.Expect(salary.Splits[0].IsReconciled()).To(gomega.BeTrue())
+		g.Expect(salary.Splits[0].ReconcileDate.Equal(time.Date(2015, 2, 5, 0, 0, 0, 0, time.UTC))).To(gomega.BeTrue())
+		g.Expect(salary.Splits[0].Amount(2)).To(gomega.Equal(int64(300000)))
+		g.Expect(salary.Splits[1].Amount(2)).To(gomega.Equal(int64(-300000)))
+		g.Expect(salary.Splits[1].IsReconciled()).To(gomega.BeFalse())
+		g.Expect(salary.Splits[1].ReconcileDate.IsZero()).To(gomega.BeTrue())
+
+		g.Expect(book.Transactions[1].Splits[0].Memo).To(gomega.Equal("food"))
+	}
+
+	_, err = ParseGnuCash(bytes.NewReader([]byte("<gnc-v2></gnc-v2>")))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(ErrStatementInvalid.Error())))
+}
+
+func TestRoundRat(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	g.Expect(RoundRat(big.NewRat(12345, 100), 2)).To(gomega.Equal(int64(12345)))
+	g.Expect(RoundRat(big.NewRat(12345, 1000), 2)).To(gomega.Equal(int64(1235)))
+	g.Expect(RoundRat(big.NewRat(-12345, 1000), 2)).To(gomega.Equal(int64(-1235)))
+	g.Expect(RoundRat(big.NewRat(-12344, 1000), 2)).To(gomega.Equal(int64(-1234)))
+	g.Expect(RoundRat(big.NewRat(1234, 1), 2)).To(gomega.Equal(int64(123400)))
+}
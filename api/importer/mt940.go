@@ -0,0 +1,325 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/money"
+)
+
+// mt940Field is a tag of the message with its value, continuation lines are joined to the value with newlines
+type mt940Field struct {
+	row   int
+	tag   string
+	value string
+}
+
+var (
+	mt940TagRegex = regexp.MustCompile(`^:(\d{2}[A-Z]?):(.*)$`)
+	// mt940BalanceRegex is a balance, ie C240531EUR1234,56
+	mt940BalanceRegex = regexp.MustCompile(`^([CD])(\d{6})([A-Z]{3})(\d[\d,]*)$`)
+	// mt940LineRegex is the first line of a statement line: value date, entry date, mark, funds code, amount,
+	// transaction type, then the customer reference and the bank reference after //
+	mt940LineRegex = regexp.MustCompile(`^(\d{6})(\d{4})?(RC|RD|C|D)([A-Z])?(\d[\d,]*)([NFS][A-Z0-9]{3})(.*)$`)
+	// mt940GVCRegex is the start of the structured information of German banks, a transaction code then ?00
+	mt940GVCRegex = regexp.MustCompile(`^\d{3}\?`)
+	// mt940SubfieldRegex is a subfield of the structured information, ie ?20
+	mt940SubfieldRegex = regexp.MustCompile(`\?(\d{2})`)
+	// mt940CodeRegex is a code of the slash separated information, ie /REMI/
+	mt940CodeRegex = regexp.MustCompile(`/([A-Z]{2,4})/`)
+)
+
+const (
+	mt940DateLayout = "060102"
+	// mt940NoReference is the customer reference of a line that has none
+	mt940NoReference = "NONREF"
+	// mt940SEPARemittance starts the remittance information of a SEPA payment
+	mt940SEPARemittance = "SVWZ+"
+)
+
+// ParseMT940 parses a SWIFT MT940 customer statement, as exported by most European banks.  Each :61: is a line and
+// the :86: after it is read for the counterparty and the remittance information, either as the ?-subfields of
+// German banks or as /NAME/ and /REMI/ codes, or else taken whole as the remittance.  The opening balance is the
+// first :60F: or :60M: and the closing balance the last :62F: or :62M:, so a file of several statements is read as
+// one.  A statement line that cannot be read is recorded in RowErrors and the rest of the file is still parsed.
+func ParseMT940(reader io.Reader, decimals uint64) (*Statement, error) { //nolint:cyclop
+	fields, err := mt940Fields(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	myStatement := Statement{AccountNumber: "", Currency: "", Lines: nil, RowErrors: nil, Categories: nil,
+		OpeningBalance: nil, ClosingBalance: nil}
+
+	// line is the statement line a :86: belongs to, it only follows its :61:
+	var line *StatementLine
+
+	for _, field := range fields {
+		switch field.tag {
+		case "25":
+			if myStatement.AccountNumber == "" {
+				myStatement.AccountNumber = strings.TrimSpace(field.value)
+			}
+		case "60F", "60M", "62F", "62M":
+			balance, currency, err := mt940Balance(field.value, decimals)
+			if err != nil {
+				return nil, fmt.Errorf("%w [row:%d tag:%s]", err, field.row, field.tag)
+			}
+
+			if myStatement.Currency == "" {
+				myStatement.Currency = currency
+			}
+
+			if strings.HasPrefix(field.tag, "62") {
+				myStatement.ClosingBalance = balance
+			} else if myStatement.OpeningBalance == nil {
+				myStatement.OpeningBalance = balance
+			}
+		case "61":
+			line, err = mt940StatementLine(field.value, decimals)
+			if err != nil {
+				myStatement.RowErrors = append(myStatement.RowErrors, &RowError{Row: field.row, Message: err.Error()})
+
+				continue
+			}
+
+			if line.Amount != 0 {
+				myStatement.Lines = append(myStatement.Lines, line)
+			}
+
+			continue
+		case "86":
+			if line != nil {
+				payee, remittance := mt940Information(field.value)
+
+				// the supplementary details of the :61: are kept when there is no remittance information
+				line.Payee = payee
+				if remittance != "" {
+					line.Memo = remittance
+				}
+			}
+		}
+
+		line = nil
+	}
+
+	if len(myStatement.Lines) == 0 && len(myStatement.RowErrors) == 0 {
+		return nil, ErrStatementEmpty
+	}
+
+	return &myStatement, nil
+}
+
+// mt940Fields reads the tags of the messages in the file.  The {1:}, {2:} and {4: blocks that wrap a message
+// sent over SWIFT are skipped, as is the -} or - that ends a message.
+func mt940Fields(reader io.Reader) ([]*mt940Field, error) {
+	var (
+		fields []*mt940Field
+		field  *mt940Field
+	)
+
+	scanner := bufio.NewScanner(reader)
+
+	for row := 1; scanner.Scan(); row++ {
+		text := strings.TrimRight(scanner.Text(), "\r ")
+		if row == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+
+		if idx := strings.Index(text, "{4:"); idx >= 0 {
+			text = text[idx+len("{4:"):]
+		}
+
+		switch {
+		case text == "" || text == "-" || text == "-}" || strings.HasPrefix(text, "{"):
+			field = nil
+		case mt940TagRegex.MatchString(text):
+			match := mt940TagRegex.FindStringSubmatch(text)
+			field = &mt940Field{row: row, tag: match[1], value: match[2]}
+			fields = append(fields, field)
+		case field != nil:
+			field.value += "\n" + text
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanner.Err:%w", err)
+	}
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w: no MT940 tags", ErrStatementInvalid)
+	}
+
+	return fields, nil
+}
+
+// mt940Balance parses a balance, ie C240531EUR1234,56, into the balance and its currency
+func mt940Balance(value string, decimals uint64) (*StatementBalance, string, error) {
+	match := mt940BalanceRegex.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return nil, "", fmt.Errorf("%w: balance %q", ErrStatementInvalid, value)
+	}
+
+	date, err := time.Parse(mt940DateLayout, match[2])
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: date %q", ErrStatementInvalid, match[2])
+	}
+
+	amount, err := money.Parse(match[4], decimals, ",")
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: amount %w", ErrStatementInvalid, err)
+	}
+
+	if match[1] == "D" {
+		amount = -amount
+	}
+
+	return &StatementBalance{Date: date, Amount: amount}, match[3], nil
+}
+
+// mt940StatementLine parses a :61:.  The entry date is the booking date, it has no year so it is taken from the
+// value date, allowing for a booking on the other side of the new year.  A reversal of a credit (RC) is a debit
+// and a reversal of a debit (RD) is a credit.
+func mt940StatementLine(value string, decimals uint64) (*StatementLine, error) {
+	first, supplementary, _ := strings.Cut(value, "\n")
+
+	match := mt940LineRegex.FindStringSubmatch(strings.TrimSpace(first))
+	if match == nil {
+		return nil, fmt.Errorf("%w: statement line %q", ErrStatementInvalid, first)
+	}
+
+	valueDate, err := time.Parse(mt940DateLayout, match[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: value date %q", ErrStatementInvalid, match[1])
+	}
+
+	bookingDate := valueDate
+
+	if match[2] != "" {
+		entryDate, err := time.Parse("0102", match[2])
+		if err != nil {
+			return nil, fmt.Errorf("%w: entry date %q", ErrStatementInvalid, match[2])
+		}
+
+		year := valueDate.Year()
+
+		switch {
+		case entryDate.Month() == time.December && valueDate.Month() == time.January:
+			year--
+		case entryDate.Month() == time.January && valueDate.Month() == time.December:
+			year++
+		}
+
+		bookingDate = time.Date(year, entryDate.Month(), entryDate.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	amount, err := money.Parse(match[5], decimals, ",")
+	if err != nil {
+		return nil, fmt.Errorf("%w: amount %w", ErrStatementInvalid, err)
+	}
+
+	if match[3] == "D" || match[3] == "RC" {
+		amount = -amount
+	}
+
+	customerRef, bankRef, _ := strings.Cut(match[7], "//")
+	customerRef, bankRef = strings.TrimSpace(customerRef), strings.TrimSpace(bankRef)
+
+	line := StatementLine{
+		ExternalID: bankRef,
+		Date:       bookingDate,
+		ValueDate:  valueDate,
+		Amount:     amount,
+		Payee:      "",
+		Memo:       strings.Join(strings.Fields(supplementary), " "),
+		Reference:  customerRef,
+		Category:   "",
+		Splits:     nil,
+	}
+
+	if line.Reference == "" || line.Reference == mt940NoReference {
+		line.Reference = bankRef
+	}
+
+	return &line, nil
+}
+
+// mt940Information parses a :86: into the counterparty and the remittance information
+func mt940Information(value string) (string, string) {
+	// the lines of a :86: are cut at a fixed width, not between words
+	joined := strings.ReplaceAll(value, "\n", "")
+
+	switch {
+	case mt940GVCRegex.MatchString(joined):
+		return mt940Subfields(joined)
+	case strings.HasPrefix(joined, "/") && mt940CodeRegex.MatchString(joined):
+		return mt940Codes(joined)
+	}
+
+	return "", strings.Join(strings.Fields(strings.ReplaceAll(value, "\n", " ")), " ")
+}
+
+// mt940Subfields reads the ?-subfields of German banks, ?20 to ?29 and ?60 to ?63 are the remittance information
+// and ?32 and ?33 the name of the counterparty.  A SEPA remittance is the text after SVWZ+, the other SEPA
+// references before it are dropped.
+func mt940Subfields(value string) (string, string) {
+	var payee, remittance strings.Builder
+
+	matches := mt940SubfieldRegex.FindAllStringSubmatchIndex(value, -1)
+	for idx, match := range matches {
+		end := len(value)
+		if idx+1 < len(matches) {
+			end = matches[idx+1][0]
+		}
+
+		code, text := value[match[2]:match[3]], value[match[1]:end]
+
+		switch {
+		case code >= "20" && code <= "29", code >= "60" && code <= "63":
+			remittance.WriteString(text)
+		case code == "32" || code == "33":
+			payee.WriteString(text)
+		}
+	}
+
+	remittanceStr := remittance.String()
+	if _, sepa, ok := strings.Cut(remittanceStr, mt940SEPARemittance); ok {
+		remittanceStr = sepa
+	}
+
+	return strings.TrimSpace(payee.String()), strings.Join(strings.Fields(remittanceStr), " ")
+}
+
+// mt940Codes reads the slash separated codes, ie /NAME/ACME GMBH/REMI/USTD//INVOICE 123/
+func mt940Codes(value string) (string, string) {
+	var payee, remittance string
+
+	matches := mt940CodeRegex.FindAllStringSubmatchIndex(value, -1)
+	for idx, match := range matches {
+		end := len(value)
+		if idx+1 < len(matches) {
+			end = matches[idx+1][0]
+		}
+
+		text := strings.Trim(value[match[1]:end], "/ ")
+
+		switch value[match[2]:match[3]] {
+		case "NAME":
+			if payee == "" {
+				payee = text
+			}
+		case "REMI":
+			for _, prefix := range []string{"USTD//", "STRD/"} {
+				text = strings.TrimPrefix(text, prefix)
+			}
+
+			remittance = strings.Trim(text, "/ ")
+		}
+	}
+
+	return payee, strings.Join(strings.Fields(remittance), " ")
+}
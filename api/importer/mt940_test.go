@@ -0,0 +1,85 @@
+package importer
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestParseMT940(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	file, err := os.Open("testdata/statement.mt940")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	defer file.Close()
+
+	myStatement, err := ParseMT940(file, 2)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myStatement.AccountNumber).To(gomega.Equal("37040044/0532013000"))
+	g.Expect(myStatement.Currency).To(gomega.Equal("EUR"))
+	g.Expect(*myStatement.OpeningBalance).To(gomega.Equal(StatementBalance{
+		Date: time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC), Amount: 100000}))
+	g.Expect(*myStatement.ClosingBalance).To(gomega.Equal(StatementBalance{
+		Date: time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC), Amount: 361250}))
+	g.Expect(myStatement.Lines).To(gomega.HaveLen(3))
+	g.Expect(myStatement.RowErrors).To(gomega.HaveLen(1))
+	g.Expect(myStatement.RowErrors[0].Row).To(gomega.Equal(16))
+
+	salary := myStatement.Lines[0]
+	g.Expect(salary.ExternalID).To(gomega.Equal("BANK-0001"))
+	// the R after the mark is the funds code
+	g.Expect(salary.Amount).To(gomega.Equal(int64(250000)))
+	g.Expect(salary.ValueDate).To(gomega.Equal(time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)))
+	g.Expect(salary.Date).To(gomega.Equal(time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)))
+	g.Expect(salary.Payee).To(gomega.Equal("ACME GmbH"))
+	g.Expect(salary.Memo).To(gomega.Equal("Salary May 2024"))
+	g.Expect(salary.Reference).To(gomega.Equal("PAYROLL-05"))
+
+	power := myStatement.Lines[1]
+	g.Expect(power.Amount).To(gomega.Equal(int64(-13750)))
+	g.Expect(power.Date).To(gomega.Equal(time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)))
+	g.Expect(power.Payee).To(gomega.Equal("Power Co"))
+	g.Expect(power.Memo).To(gomega.Equal("Electricity May"))
+	g.Expect(power.Reference).To(gomega.Equal("BANK-0002"))
+
+	// a reversal of a debit is a credit
+	refund := myStatement.Lines[2]
+	g.Expect(refund.Amount).To(gomega.Equal(int64(25000)))
+	g.Expect(refund.Payee).To(gomega.Equal(""))
+	g.Expect(refund.Memo).To(gomega.Equal("Return of outgoing payment"))
+	g.Expect(refund.Reference).To(gomega.Equal("BANK-0003"))
+}
+
+func TestParseMT940_EntryDateAcrossYear(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	myStatement, err := ParseMT940(strings.NewReader(":25:1234\n:61:2401021231D10,00NMSCREF\n"), 2)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myStatement.Lines).To(gomega.HaveLen(1))
+	g.Expect(myStatement.Lines[0].Date).To(gomega.Equal(time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)))
+	g.Expect(myStatement.Lines[0].ValueDate).To(gomega.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)))
+	g.Expect(myStatement.Lines[0].Reference).To(gomega.Equal("REF"))
+	g.Expect(myStatement.OpeningBalance).To(gomega.BeNil())
+}
+
+func TestParseMT940_Invalid(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	_, err := ParseMT940(strings.NewReader("not a statement"), 2)
+	g.Expect(errors.Is(err, ErrStatementInvalid)).To(gomega.BeTrue())
+
+	_, err = ParseMT940(strings.NewReader(":25:1234\n:60F:X240101EUR1,00\n"), 2)
+	g.Expect(errors.Is(err, ErrStatementInvalid)).To(gomega.BeTrue())
+
+	_, err = ParseMT940(strings.NewReader(":25:1234\n:60F:C240101EUR1,00\n:62F:C240101EUR1,00\n"), 2)
+	g.Expect(errors.Is(err, ErrStatementEmpty)).To(gomega.BeTrue())
+}
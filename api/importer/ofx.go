@@ -0,0 +1,256 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/money"
+)
+
+// ofxNode is an element of an OFX document, leaves have a value and aggregates have children
+type ofxNode struct {
+	name     string
+	value    string
+	children []*ofxNode
+}
+
+// first finds the first descendant with name, depth first
+func (n *ofxNode) first(name string) *ofxNode {
+	for _, child := range n.children {
+		if child.name == name {
+			return child
+		}
+
+		if found := child.first(name); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// all finds every descendant with name, it does not look inside a match
+func (n *ofxNode) all(name string) []*ofxNode {
+	var found []*ofxNode
+
+	for _, child := range n.children {
+		if child.name == name {
+			found = append(found, child)
+
+			continue
+		}
+
+		found = append(found, child.all(name)...)
+	}
+
+	return found
+}
+
+// valueOf is the value of the first descendant with name, or empty
+func (n *ofxNode) valueOf(name string) string {
+	if found := n.first(name); found != nil {
+		return found.value
+	}
+
+	return ""
+}
+
+// ParseOFX parses an OFX or QFX file, version 1.x (SGML, leaf elements are not closed) or 2.x (XML).  Bank and
+// credit card statements are supported, amounts are converted to minor units with decimals.
+func ParseOFX(reader io.Reader, decimals uint64) (*Statement, error) {
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("io.ReadAll:%w", err)
+	}
+
+	root, err := parseOFXTree(body)
+	if err != nil {
+		return nil, err
+	}
+
+	ofx := root.first("OFX")
+	if ofx == nil {
+		return nil, fmt.Errorf("%w: no OFX element", ErrStatementInvalid)
+	}
+
+	myStatement := Statement{
+		AccountNumber: ofx.valueOf("ACCTID"),
+		Currency:      ofx.valueOf("CURDEF"),
+		Lines:         nil,
+	}
+
+	for _, trn := range ofx.all("STMTTRN") {
+		line, err := ofxStatementLine(trn, decimals)
+		if err != nil {
+			return nil, err
+		}
+
+		// a zero amount cannot be posted, banks use them for informational lines
+		if line.Amount == 0 {
+			continue
+		}
+
+		myStatement.Lines = append(myStatement.Lines, line)
+	}
+
+	if len(myStatement.Lines) == 0 {
+		return nil, ErrStatementEmpty
+	}
+
+	return &myStatement, nil
+}
+
+func ofxStatementLine(trn *ofxNode, decimals uint64) (*StatementLine, error) {
+	dateStr := trn.valueOf("DTPOSTED")
+	if dateStr == "" {
+		dateStr = trn.valueOf("DTUSER")
+	}
+
+	date, err := parseOFXDate(dateStr)
+	if err != nil {
+		return nil, err
+	}
+
+	amountStr := trn.valueOf("TRNAMT")
+
+	decimalSeparator := "."
+	if strings.Contains(amountStr, ",") && !strings.Contains(amountStr, ".") {
+		decimalSeparator = ","
+	}
+
+	amount, err := money.Parse(amountStr, decimals, decimalSeparator)
+	if err != nil {
+		return nil, fmt.Errorf("%w: TRNAMT %w", ErrStatementInvalid, err)
+	}
+
+	reference := trn.valueOf("CHECKNUM")
+	if reference == "" {
+		reference = trn.valueOf("REFNUM")
+	}
+
+	return &StatementLine{
+		ExternalID: trn.valueOf("FITID"),
+		Date:       date,
+		Amount:     amount,
+		Payee:      trn.valueOf("NAME"),
+		Memo:       trn.valueOf("MEMO"),
+		Reference:  reference,
+	}, nil
+}
+
+// parseOFXTree builds the element tree.  A tag followed by text is a leaf and is closed by the text, which handles
+// the unclosed leaves of OFX 1.x.  A closing tag closes the nearest open element of that name and is ignored when
+// there is none, which is the case for the closed leaves of OFX 2.x.
+func parseOFXTree(body []byte) (*ofxNode, error) {
+	start := bytes.Index(bytes.ToUpper(body), []byte("<OFX>"))
+	if start < 0 {
+		return nil, fmt.Errorf("%w: no OFX element", ErrStatementInvalid)
+	}
+
+	root := &ofxNode{name: "", value: "", children: nil}
+	stack := []*ofxNode{root}
+	rest := string(body[start:])
+
+	for rest != "" {
+		if rest[0] != '<' {
+			end := strings.IndexByte(rest, '<')
+			if end < 0 {
+				end = len(rest)
+			}
+
+			text := strings.TrimSpace(html.UnescapeString(rest[:end]))
+			rest = rest[end:]
+
+			if text != "" && len(stack) > 1 {
+				stack[len(stack)-1].value = text
+				stack = stack[:len(stack)-1]
+			}
+
+			continue
+		}
+
+		end := strings.IndexByte(rest, '>')
+		if end < 0 {
+			return nil, fmt.Errorf("%w: unterminated tag", ErrStatementInvalid)
+		}
+
+		tag := strings.TrimSpace(rest[1:end])
+		rest = rest[end+1:]
+
+		switch {
+		case tag == "", strings.HasPrefix(tag, "?"), strings.HasPrefix(tag, "!"):
+			// processing instructions and comments
+		case strings.HasPrefix(tag, "/"):
+			stack = closeOFXElement(stack, strings.ToUpper(strings.TrimSpace(tag[1:])))
+		case strings.HasSuffix(tag, "/"):
+			name := strings.ToUpper(strings.TrimSpace(strings.TrimSuffix(tag, "/")))
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, &ofxNode{name: name, value: "", children: nil})
+		default:
+			// OFX elements have no attributes, but drop any to be safe
+			name, _, _ := strings.Cut(tag, " ")
+			node := &ofxNode{name: strings.ToUpper(name), value: "", children: nil}
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, node)
+			stack = append(stack, node)
+		}
+	}
+
+	return root, nil
+}
+
+func closeOFXElement(stack []*ofxNode, name string) []*ofxNode {
+	for idx := len(stack) - 1; idx > 0; idx-- {
+		if stack[idx].name == name {
+			return stack[:idx]
+		}
+	}
+
+	return stack
+}
+
+// parseOFXDate parses the OFX datetime, YYYYMMDD[HHMMSS[.XXX]][[offset:TZ]], ie 20240115120000.000[-5:EST].
+// Without an offset the time is UTC.
+func parseOFXDate(str string) (time.Time, error) {
+	dateStr, zoneStr, _ := strings.Cut(strings.TrimSpace(str), "[")
+
+	digits, _, _ := strings.Cut(dateStr, ".")
+
+	var layout string
+
+	switch len(digits) {
+	case len("20060102"):
+		layout = "20060102"
+	case len("200601021504"):
+		layout = "200601021504"
+	case len("20060102150405"):
+		layout = "20060102150405"
+	default:
+		return time.Time{}, fmt.Errorf("%w: date %q", ErrStatementInvalid, str)
+	}
+
+	location := time.UTC
+
+	if zoneStr != "" {
+		offsetStr, zoneName, _ := strings.Cut(strings.TrimSuffix(zoneStr, "]"), ":")
+
+		offsetHours, err := strconv.ParseFloat(offsetStr, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: date %q", ErrStatementInvalid, str)
+		}
+
+		location = time.FixedZone(zoneName, int(offsetHours*float64(time.Hour/time.Second)))
+	}
+
+	date, err := time.ParseInLocation(layout, digits, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: date %q", ErrStatementInvalid, str)
+	}
+
+	return date, nil
+}
@@ -0,0 +1,96 @@
+package importer
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestParseOFX_SGML(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	file, err := os.Open("testdata/bank_v1.ofx")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	defer file.Close()
+
+	myStatement, err := ParseOFX(file, 2)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myStatement.AccountNumber).To(gomega.Equal("1234567890"))
+	g.Expect(myStatement.Currency).To(gomega.Equal("USD"))
+	// the zero amount line is skipped
+	g.Expect(myStatement.Lines).To(gomega.HaveLen(3))
+
+	g.Expect(myStatement.Lines[0].ExternalID).To(gomega.Equal("2024051201"))
+	g.Expect(myStatement.Lines[0].Amount).To(gomega.Equal(int64(-12750)))
+	g.Expect(myStatement.Lines[0].Date.Equal(time.Date(2024, 5, 12, 17, 0, 0, 0, time.UTC))).To(gomega.BeTrue())
+	g.Expect(myStatement.Lines[0].Comment()).To(gomega.Equal("HOME DEPOT #1234 - POS PURCHASE"))
+
+	g.Expect(myStatement.Lines[1].Amount).To(gomega.Equal(int64(-120000)))
+	g.Expect(myStatement.Lines[1].Reference).To(gomega.Equal("1042"))
+	g.Expect(myStatement.Lines[1].Comment()).To(gomega.Equal("Rent & Utilities"))
+	g.Expect(myStatement.Lines[1].Date).To(gomega.Equal(time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)))
+
+	g.Expect(myStatement.Lines[2].Amount).To(gomega.Equal(int64(250000)))
+	// a memo that repeats the payee is not doubled
+	g.Expect(myStatement.Lines[2].Comment()).To(gomega.Equal("ACME PAYROLL"))
+}
+
+func TestParseOFX_XML(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	file, err := os.Open("testdata/card_v2.ofx")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	defer file.Close()
+
+	myStatement, err := ParseOFX(file, 2)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myStatement.AccountNumber).To(gomega.Equal("4111XXXXXXXX1111"))
+	g.Expect(myStatement.Currency).To(gomega.Equal("EUR"))
+	g.Expect(myStatement.Lines).To(gomega.HaveLen(2))
+
+	g.Expect(myStatement.Lines[0].ExternalID).To(gomega.Equal("CC-0001"))
+	g.Expect(myStatement.Lines[0].Amount).To(gomega.Equal(int64(-4599)))
+	g.Expect(myStatement.Lines[0].Payee).To(gomega.Equal("Corner Books"))
+	g.Expect(myStatement.Lines[0].Memo).To(gomega.Equal(""))
+	g.Expect(myStatement.Lines[0].Date).To(gomega.Equal(time.Date(2024, 5, 3, 9, 30, 0, 0, time.UTC)))
+
+	g.Expect(myStatement.Lines[1].Amount).To(gomega.Equal(int64(30000)))
+	g.Expect(myStatement.Lines[1].Reference).To(gomega.Equal("PMT-88"))
+	g.Expect(myStatement.Lines[1].Date).To(gomega.Equal(time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestParseOFX_Invalid(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	_, err := ParseOFX(strings.NewReader("Date,Amount\n2024-01-01,5.00\n"), 2)
+	g.Expect(errors.Is(err, ErrStatementInvalid)).To(gomega.BeTrue())
+
+	_, err = ParseOFX(strings.NewReader("<OFX><BANKTRANLIST></BANKTRANLIST></OFX>"), 2)
+	g.Expect(errors.Is(err, ErrStatementEmpty)).To(gomega.BeTrue())
+
+	_, err = ParseOFX(strings.NewReader("<OFX><STMTTRN><DTPOSTED>2024<TRNAMT>1.00</STMTTRN></OFX>"), 2)
+	g.Expect(errors.Is(err, ErrStatementInvalid)).To(gomega.BeTrue())
+
+	_, err = ParseOFX(strings.NewReader("<OFX><STMTTRN><DTPOSTED>20240101<TRNAMT>abc</STMTTRN></OFX>"), 2)
+	g.Expect(errors.Is(err, ErrStatementInvalid)).To(gomega.BeTrue())
+}
+
+func TestStatementLine_Comment(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	g.Expect((&StatementLine{Memo: "memo only"}).Comment()).To(gomega.Equal("memo only"))
+	g.Expect((&StatementLine{}).Comment()).To(gomega.Equal("Imported transaction"))
+	g.Expect((&StatementLine{Payee: strings.Repeat("x", 300)}).Comment()).To(gomega.HaveLen(250))
+	g.Expect((&StatementLine{Reference: strings.Repeat("9", 40)}).TransactionReference()).To(gomega.HaveLen(32))
+}
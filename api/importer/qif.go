@@ -0,0 +1,282 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/money"
+)
+
+// qifSection is the kind of records that follow a !Type header
+type qifSection int
+
+const (
+	qifSectionSkip qifSection = iota
+	qifSectionTransactions
+	qifSectionCategories
+	qifSectionAccount
+)
+
+// qifTransactionTypes are the !Type sections of bank and card transactions, ie !Type:Bank
+var qifTransactionTypes = map[string]bool{"bank": true, "ccard": true, "cash": true, "oth a": true, "oth l": true}
+
+// qifRecord is the fields of a record, by their code, with the line the record starts on
+type qifRecord struct {
+	row    int
+	fields []qifField
+}
+
+type qifField struct {
+	code  byte
+	value string
+}
+
+// ParseQIF parses a QIF file as exported by Quicken and MS Money.  Bank, cash and credit card transactions are
+// read with their category (L) and splits (S, E and $), and the category list (!Type:Cat) is read into
+// Categories.  Investment and other sections are skipped.  QIF dates are month first unless dayFirst is set.
+// A record that cannot be read is recorded in RowErrors and the rest of the file is still parsed.
+func ParseQIF(reader io.Reader, decimals uint64, dayFirst bool) (*Statement, error) {
+	myStatement := Statement{AccountNumber: "", Currency: "", Lines: nil, RowErrors: nil, Categories: nil}
+	section := qifSectionSkip
+	record := qifRecord{row: 0, fields: nil}
+
+	scanner := bufio.NewScanner(reader)
+
+	for row := 1; scanner.Scan(); row++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if row == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		switch {
+		case text[0] == '!':
+			section = qifSectionOf(text, section)
+			record = qifRecord{row: 0, fields: nil}
+		case text[0] == '^':
+			myStatement.addQIFRecord(section, &record, decimals, dayFirst)
+			record = qifRecord{row: 0, fields: nil}
+		default:
+			if record.row == 0 {
+				record.row = row
+			}
+
+			record.fields = append(record.fields, qifField{code: text[0], value: strings.TrimSpace(text[1:])})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanner.Err:%w", err)
+	}
+
+	if len(myStatement.Lines) == 0 && len(myStatement.RowErrors) == 0 {
+		return nil, ErrStatementEmpty
+	}
+
+	return &myStatement, nil
+}
+
+// qifSectionOf is the section a header starts, options such as !Option:AutoSwitch do not change it
+func qifSectionOf(header string, current qifSection) qifSection {
+	lower := strings.ToLower(strings.TrimSpace(header))
+
+	switch {
+	case lower == "!account":
+		return qifSectionAccount
+	case lower == "!type:cat":
+		return qifSectionCategories
+	case strings.HasPrefix(lower, "!type:"):
+		if qifTransactionTypes[strings.TrimSpace(strings.TrimPrefix(lower, "!type:"))] {
+			return qifSectionTransactions
+		}
+
+		return qifSectionSkip
+	}
+
+	return current
+}
+
+func (c *Statement) addQIFRecord(section qifSection, record *qifRecord, decimals uint64, dayFirst bool) {
+	if len(record.fields) == 0 {
+		return
+	}
+
+	switch section {
+	case qifSectionAccount:
+		for _, field := range record.fields {
+			if field.code == 'N' && c.AccountNumber == "" {
+				c.AccountNumber = field.value
+			}
+		}
+	case qifSectionCategories:
+		category := StatementCategory{Name: "", Description: "", IsIncome: false}
+
+		for _, field := range record.fields {
+			switch field.code {
+			case 'N':
+				category.Name = field.value
+			case 'D':
+				category.Description = field.value
+			case 'I':
+				category.IsIncome = true
+			}
+		}
+
+		if category.Name != "" {
+			c.Categories = append(c.Categories, &category)
+		}
+	case qifSectionTransactions:
+		line, err := qifStatementLine(record, decimals, dayFirst)
+		if err != nil {
+			c.RowErrors = append(c.RowErrors, &RowError{Row: record.row, Message: err.Error()})
+
+			return
+		}
+
+		// a zero amount cannot be posted
+		if line.Amount != 0 {
+			c.Lines = append(c.Lines, line)
+		}
+	case qifSectionSkip:
+	}
+}
+
+func qifStatementLine(record *qifRecord, decimals uint64, dayFirst bool) (*StatementLine, error) { //nolint:cyclop
+	line := StatementLine{ExternalID: "", Date: time.Time{}, Amount: 0, Payee: "", Memo: "", Reference: "",
+		Category: "", Splits: nil}
+
+	var (
+		dateSet, amountSet bool
+		split              *StatementSplit
+		err                error
+	)
+
+	for _, field := range record.fields {
+		switch field.code {
+		case 'D':
+			if line.Date, err = parseQIFDate(field.value, dayFirst); err != nil {
+				return nil, err
+			}
+
+			dateSet = true
+		case 'T', 'U':
+			if line.Amount, err = money.Parse(field.value, decimals, "."); err != nil {
+				return nil, fmt.Errorf("amount:%w", err)
+			}
+
+			amountSet = true
+		case 'P':
+			line.Payee = field.value
+		case 'M':
+			line.Memo = field.value
+		case 'N':
+			line.Reference = field.value
+		case 'L':
+			line.Category = qifCategory(field.value)
+		case 'S':
+			split = &StatementSplit{Category: qifCategory(field.value), Memo: "", Amount: 0}
+			line.Splits = append(line.Splits, split)
+		case 'E':
+			if split != nil {
+				split.Memo = field.value
+			}
+		case '$':
+			if split == nil {
+				return nil, fmt.Errorf("%w: split amount without a split category", ErrStatementInvalid)
+			}
+
+			if split.Amount, err = money.Parse(field.value, decimals, "."); err != nil {
+				return nil, fmt.Errorf("split amount:%w", err)
+			}
+		}
+	}
+
+	if !dateSet || !amountSet {
+		return nil, fmt.Errorf("%w: transaction needs a date and an amount", ErrStatementInvalid)
+	}
+
+	if len(line.Splits) > 0 {
+		var total int64
+		for _, split := range line.Splits {
+			total += split.Amount
+		}
+
+		if total != line.Amount {
+			return nil, fmt.Errorf("%w: splits total %s, not %s", ErrStatementInvalid,
+				money.FormatPlain(total, decimals), money.FormatPlain(line.Amount, decimals))
+		}
+	}
+
+	return &line, nil
+}
+
+// qifCategory drops the class of a category, which follows a slash, ie Auto:Fuel/Business
+func qifCategory(value string) string {
+	category, _, _ := strings.Cut(value, "/")
+
+	return strings.TrimSpace(category)
+}
+
+// IsTransferCategory is whether a category names another account, which QIF writes in brackets, ie [Savings]
+func IsTransferCategory(category string) bool {
+	return strings.HasPrefix(category, "[") && strings.HasSuffix(category, "]")
+}
+
+// parseQIFDate parses the dates QIF exporters write, ie 1/15/2024, 01/15/24, 1/15'24 and 2024-01-15.  A two digit
+// year after an apostrophe is in the 2000s, other two digit years are in the 1900s from 70.
+func parseQIFDate(str string, dayFirst bool) (time.Time, error) {
+	clean := strings.ReplaceAll(strings.TrimSpace(str), " ", "")
+	apostrophe := strings.Contains(clean, "'")
+
+	parts := strings.FieldsFunc(clean, func(r rune) bool {
+		return r == '/' || r == '-' || r == '.' || r == '\''
+	})
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("%w: date %q", ErrStatementInvalid, str)
+	}
+
+	numbers := make([]int, len(parts))
+
+	for idx, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: date %q", ErrStatementInvalid, str)
+		}
+
+		numbers[idx] = number
+	}
+
+	var year, month, day int
+
+	switch {
+	case len(parts[0]) == len("2006"):
+		year, month, day = numbers[0], numbers[1], numbers[2]
+	case dayFirst:
+		day, month, year = numbers[0], numbers[1], numbers[2]
+	default:
+		month, day, year = numbers[0], numbers[1], numbers[2]
+	}
+
+	if len(parts[2]) <= len("06") && len(parts[0]) != len("2006") {
+		switch {
+		case apostrophe, year < 70: //nolint:mnd
+			year += 2000
+		default:
+			year += 1900
+		}
+	}
+
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Month() != time.Month(month) || date.Day() != day {
+		return time.Time{}, fmt.Errorf("%w: date %q", ErrStatementInvalid, str)
+	}
+
+	return date, nil
+}
@@ -0,0 +1,79 @@
+package importer
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestParseQIF(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	file, err := os.Open("testdata/quicken.qif")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	defer file.Close()
+
+	myStatement, err := ParseQIF(file, 2, false)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myStatement.AccountNumber).To(gomega.Equal("Everyday Checking"))
+
+	g.Expect(myStatement.Categories).To(gomega.HaveLen(3))
+	g.Expect(myStatement.Categories[0].Name).To(gomega.Equal("Auto"))
+	g.Expect(myStatement.Categories[0].Description).To(gomega.Equal("Vehicle costs"))
+	g.Expect(myStatement.Categories[1].Name).To(gomega.Equal("Auto:Fuel"))
+	g.Expect(myStatement.Categories[2].IsIncome).To(gomega.BeTrue())
+
+	// the investment section is skipped
+	g.Expect(myStatement.Lines).To(gomega.HaveLen(4))
+
+	g.Expect(myStatement.Lines[0].Date).To(gomega.Equal(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)))
+	g.Expect(myStatement.Lines[0].Amount).To(gomega.Equal(int64(-125000)))
+	g.Expect(myStatement.Lines[0].Reference).To(gomega.Equal("1042"))
+	g.Expect(myStatement.Lines[0].Category).To(gomega.Equal("Housing:Rent"))
+	g.Expect(myStatement.Lines[0].Comment()).To(gomega.Equal("Landlord - January rent"))
+
+	g.Expect(myStatement.Lines[1].Splits).To(gomega.HaveLen(2))
+	g.Expect(myStatement.Lines[1].Splits[0].Category).To(gomega.Equal("Groceries"))
+	g.Expect(myStatement.Lines[1].Splits[0].Memo).To(gomega.Equal("Food"))
+	g.Expect(myStatement.Lines[1].Splits[0].Amount).To(gomega.Equal(int64(-6012)))
+	// the class is dropped
+	g.Expect(myStatement.Lines[1].Splits[1].Category).To(gomega.Equal("Auto:Fuel"))
+
+	g.Expect(myStatement.Lines[2].Amount).To(gomega.Equal(int64(250000)))
+	g.Expect(IsTransferCategory(myStatement.Lines[3].Category)).To(gomega.BeTrue())
+
+	g.Expect(myStatement.RowErrors).To(gomega.HaveLen(2))
+	g.Expect(myStatement.RowErrors[0].Row).To(gomega.Equal(43))
+	g.Expect(myStatement.RowErrors[1].Message).To(gomega.ContainSubstring("splits total"))
+}
+
+func TestParseQIFDate(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	tests := []struct {
+		str      string
+		dayFirst bool
+		expected time.Time
+	}{
+		{"1/15'24", false, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{" 1/ 5'04", false, time.Date(2004, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"12/31/99", false, time.Date(1999, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{"15/01/2024", true, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"2024-01-15", true, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		date, err := parseQIFDate(test.str, test.dayFirst)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(date).To(gomega.Equal(test.expected))
+	}
+
+	_, err := parseQIFDate("15/01/2024", false)
+	g.Expect(err).To(gomega.HaveOccurred())
+}
@@ -0,0 +1,130 @@
+// Package importer parses bank and card statement files into statement lines that can be previewed and then
+// posted to the ledger.  Amounts are in minor units of the account being imported into.
+package importer
+
+import (
+	"errors"
+	"time"
+)
+
+// Format is the file format a statement was parsed from
+type Format string
+
+const (
+	FormatOFX = Format("OFX")
+	FormatCSV = Format("CSV")
+	FormatQIF = Format("QIF")
+	// FormatCAMT053 is the ISO 20022 bank to customer statement, camt.053
+	FormatCAMT053 = Format("CAMT053")
+	// FormatMT940 is the SWIFT customer statement message
+	FormatMT940 = Format("MT940")
+)
+
+// Statement is the content of a statement file
+type Statement struct {
+	// AccountNumber is the account at the bank, it is informational only
+	AccountNumber string
+	Currency      string
+	Lines         []*StatementLine
+	// RowErrors are the rows that could not be read, the other rows are still imported
+	RowErrors []*RowError
+	// Categories are the categories the file defines, such as the category list of a QIF file
+	Categories []*StatementCategory
+	// OpeningBalance and ClosingBalance are the booked balances the statement reports, nil when it does not
+	OpeningBalance *StatementBalance
+	ClosingBalance *StatementBalance
+}
+
+// StatementBalance is the balance of the account at the bank on a date, positive when it is in credit with the
+// bank, ie money in a bank account
+type StatementBalance struct {
+	Date   time.Time
+	Amount int64
+}
+
+// StatementCategory is a category of income or expense, subcategories are separated by CategorySeparator
+type StatementCategory struct {
+	Name        string
+	Description string
+	IsIncome    bool
+}
+
+// CategorySeparator separates a category from its subcategory, ie Auto:Fuel
+const CategorySeparator = ":"
+
+// RowError is a row of a statement file that could not be read
+type RowError struct {
+	// Row is the line number in the file, starting at 1
+	Row     int
+	Message string
+}
+
+// StatementLine is one line of a statement.  A positive Amount moves money into the account, ie a deposit to a
+// bank account or a payment to a card, so it debits the account in the ledger.
+type StatementLine struct {
+	// ExternalID is the bank's unique id for the line, such as the OFX FITID, it may be empty
+	ExternalID string
+	// Date is the booking date
+	Date time.Time
+	// ValueDate is when the amount starts or stops earning interest, zero when the statement does not say
+	ValueDate time.Time
+	Amount    int64
+	// Payee is the counterparty
+	Payee string
+	// Memo is the remittance information
+	Memo string
+	// Reference is a check or reference number
+	Reference string
+	// Category is the category the line is offset against, empty when it is not categorized
+	Category string
+	// Splits divide the line between categories, their amounts add up to Amount
+	Splits []*StatementSplit
+}
+
+// StatementSplit is a part of a split line
+type StatementSplit struct {
+	Category string
+	Memo     string
+	Amount   int64
+}
+
+var ErrStatementInvalid = errors.New("statement file is invalid")
+var ErrStatementEmpty = errors.New("statement has no lines")
+
+// maxCommentLength and maxReferenceLength are the column sizes of transaction_main
+const (
+	maxCommentLength   = 250
+	maxReferenceLength = 32
+)
+
+// Comment is the transaction comment of the line, the payee and the memo when they differ
+func (c *StatementLine) Comment() string {
+	comment := c.Payee
+
+	switch {
+	case comment == "":
+		comment = c.Memo
+	case c.Memo != "" && c.Memo != c.Payee:
+		comment += " - " + c.Memo
+	}
+
+	if comment == "" {
+		comment = "Imported transaction"
+	}
+
+	return truncate(comment, maxCommentLength)
+}
+
+// TransactionReference is the reference of the line, truncated to fit a transaction reference
+func (c *StatementLine) TransactionReference() string {
+	return truncate(c.Reference, maxReferenceLength)
+}
+
+func truncate(str string, length int) string {
+	runes := []rune(str)
+	if len(runes) <= length {
+		return str
+	}
+
+	return string(runes[:length])
+}
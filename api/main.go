@@ -1,12 +1,18 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/mimirsoft/mimirledger/api/blobstore"
 	"github.com/mimirsoft/mimirledger/api/cfg"
 	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/scheduler"
 	"github.com/mimirsoft/mimirledger/api/web"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -14,6 +20,9 @@ import (
 
 const readHeaderTimeout = time.Second * 3
 
+// shutdownTimeout is how long the requests in progress are given to finish on SIGINT or SIGTERM
+const shutdownTimeout = time.Second * 30
+
 func main() {
 	loggerOutput := zerolog.ConsoleWriter{Out: os.Stderr} //nolint:exhaustruct
 	logger := zerolog.New(loggerOutput)
@@ -21,6 +30,9 @@ func main() {
 
 	logger.Info().Msg("####Starting MimirLedger API Server###")
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	myClient, err := datastore.NewClient(&appConfig.Postgres)
 	if err != nil {
 		log.Error().Err(err).Msg("godotenv.Load")
@@ -31,19 +43,55 @@ func main() {
 		log.Error().Err(err).Msg("myCrlient.Ping()")
 	}
 
-	ds := datastore.NewDatastores(myClient)
+	blobs, err := blobstore.New(&appConfig.Attachments)
+	if err != nil {
+		log.Fatal().Err(err).Msg("blobstore.New")
+	}
+
+	ds := datastore.NewDatastores(myClient, blobs)
 	r := web.NewRouter(ds, &logger)
 
+	var schedulerDone <-chan struct{}
+	if appConfig.SchedulerInterval > 0 {
+		schedulerDone = scheduler.NewScheduler(ds, &logger, appConfig.SchedulerInterval).Start(ctx)
+	}
+
 	server := &http.Server{ //nolint:exhaustruct
 		Addr:              ":3010",
 		ReadHeaderTimeout: readHeaderTimeout,
 		Handler:           r,
 	}
 
+	serverDone := make(chan struct{})
+
+	go func() {
+		defer close(serverDone)
+
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Error().Err(err).Msg("server.Shutdown")
+		}
+	}()
+
 	err = server.ListenAndServe()
-	if err != nil {
+	if errors.Is(err, http.ErrServerClosed) {
+		<-serverDone
+	} else if err != nil {
 		log.Error().Err(err).Msg("server.ListenAndServe")
 	}
+
+	// stop the scheduler and let the run in progress finish
+	stop()
+
+	if schedulerDone != nil {
+		<-schedulerDone
+	}
+
+	logger.Info().Msg("####Stopped MimirLedger API Server###")
 }
 
 func LoadConfig() cfg.Config {
@@ -54,7 +102,13 @@ func LoadConfig() cfg.Config {
 
 	postgresCfg := datastore.LoadPostgresConfigFromEnv()
 
-	myCfg := cfg.Config{Postgres: postgresCfg}
+	schedulerInterval, err := cfg.LoadSchedulerIntervalFromEnv()
+	if err != nil {
+		log.Error().Err(err).Msg("cfg.LoadSchedulerIntervalFromEnv()")
+	}
+
+	myCfg := cfg.Config{Postgres: postgresCfg, SchedulerInterval: schedulerInterval,
+		Attachments: blobstore.LoadConfigFromEnv()}
 
 	return myCfg
 }
@@ -477,6 +477,33 @@ func findDirectChildren(dStores *datastore.Datastores, accountID uint64) ([]*Acc
 
 	return accounts, nil
 }
+
+// findAccountByName is the account named name directly under parentID, 0 for the top level, or nil
+func findAccountByName(dStores *datastore.Datastores, parentID uint64, name string) (*Account, error) {
+	var (
+		siblings []*Account
+		err      error
+	)
+
+	if parentID == 0 {
+		siblings, err = RetrieveAccounts(dStores)
+	} else {
+		siblings, err = findDirectChildren(dStores, parentID)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sibling := range siblings {
+		if sibling.AccountParent == parentID && sibling.AccountName == name {
+			return sibling, nil
+		}
+	}
+
+	return nil, nil //nolint:nilnil
+}
+
 func findAllChildren(dStores *datastore.Datastores, parentID uint64) ([]*Account, error) {
 	as := dStores.AccountStore()
 
@@ -581,3 +608,18 @@ func GetReconciledSubtotal(dStores *datastore.Datastores, accountLeft, accountRi
 
 	return reconciledSubtotal, nil
 }
+
+// netSubtotals nets debit and credit subtotals into a balance, positive in the direction of accountSign
+func netSubtotals(subtotals []*datastore.AccountSubtotal, accountSign datastore.AccountSign) int64 {
+	var balance int64
+
+	for idx := range subtotals {
+		if subtotals[idx].DebitOrCredit == accountSign {
+			balance += int64(subtotals[idx].Subtotal) //nolint:gosec
+		} else {
+			balance -= int64(subtotals[idx].Subtotal) //nolint:gosec
+		}
+	}
+
+	return balance
+}
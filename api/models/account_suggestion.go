@@ -0,0 +1,415 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+)
+
+// MaxAccountSuggestions is how many accounts are suggested for a transaction
+const MaxAccountSuggestions = 5
+
+// AccountSuggestion is an account a transaction is likely offset against, Confidence is between 0 and 1 and the
+// confidences of every account that could be suggested add up to 1
+type AccountSuggestion struct {
+	AccountID   uint64
+	AccountName string
+	Confidence  float64
+}
+
+var ErrSuggestionAccountInvalid = errors.New("account is not one of the transaction's debits or credits")
+
+// Categorizer is a naive Bayes classifier of the accounts transactions are offset against, learned from the ledger.
+// Each debit and credit pair of a transaction is an example from each side: the words of the comment, the account
+// on that side, whether money went into or out of it and the size of the amount are the features, and the account
+// on the other side is the class.  Transactions on the suspense account are not categorized yet, so they are not
+// learned from.  The Categorizer runs in the process and learns again only from the transactions that changed since
+// it last looked at the ledger.
+type Categorizer struct {
+	mu sync.Mutex
+	// modified is when each transaction that was learned was last changed
+	modified map[uint64]time.Time
+	// examples are the examples learned from each transaction, so they can be forgotten when it changes
+	examples      map[uint64][]*categorizerExample
+	classCounts   map[uint64]int
+	featureCounts map[uint64]map[string]int
+	featureTotals map[uint64]int
+	// vocabulary counts the examples of each feature, a feature no example has is ignored
+	vocabulary    map[string]int
+	examplesTotal int
+}
+
+type categorizerExample struct {
+	class    uint64
+	features []string
+}
+
+// categorizerSubject is what is categorized, amount is in the sign of the source account, positive for money into
+// it
+type categorizerSubject struct {
+	comment         string
+	amount          int64
+	sourceAccountID uint64
+}
+
+// categorizers are the Categorizers of each ledger, so they are kept between requests
+var categorizers sync.Map //nolint:gochecknoglobals
+
+// NewCategorizer creates a Categorizer that has learned nothing
+func NewCategorizer() *Categorizer {
+	return &Categorizer{
+		mu:            sync.Mutex{},
+		modified:      make(map[uint64]time.Time),
+		examples:      make(map[uint64][]*categorizerExample),
+		classCounts:   make(map[uint64]int),
+		featureCounts: make(map[uint64]map[string]int),
+		featureTotals: make(map[uint64]int),
+		vocabulary:    make(map[string]int),
+		examplesTotal: 0,
+	}
+}
+
+// SharedCategorizer is the Categorizer of a ledger, the Datastores of a database transaction share the Categorizer
+// of their database
+func SharedCategorizer(dStores *datastore.Datastores) *Categorizer {
+	categorizer, _ := categorizers.LoadOrStore(dStores.PGClient(), NewCategorizer())
+
+	return categorizer.(*Categorizer) //nolint:forcetypeassert
+}
+
+// SuggestAccounts suggests the accounts a transaction is offset against, ranked by confidence.  sourceAccountID is
+// the side of the transaction the suggestions are for, when it is 0 it is the first side that is not on the suspense
+// account.  The transaction itself is left out of what was learned, so an account it is already offset against is
+// only suggested when other transactions suggest it.
+func (c *Categorizer) SuggestAccounts(dStores *datastore.Datastores, txn *Transaction,
+	sourceAccountID uint64) ([]*AccountSuggestion, error) {
+	suspense, err := RetrieveSuspenseAccount(dStores)
+	if err != nil {
+		return nil, fmt.Errorf("RetrieveSuspenseAccount:%w", err)
+	}
+
+	var source *TransactionDebitCredit
+
+	for _, myDC := range txn.DebitCreditSet {
+		if (sourceAccountID == 0 && myDC.AccountID != suspense.AccountID) || myDC.AccountID == sourceAccountID {
+			source = myDC
+
+			break
+		}
+	}
+
+	if source == nil {
+		return nil, ErrSuggestionAccountInvalid
+	}
+
+	amount := int64(source.TransactionDCAmount) //nolint:gosec
+	if source.DebitOrCredit == datastore.AccountSignCredit {
+		amount = -amount
+	}
+
+	excluded := map[uint64]bool{suspense.AccountID: true, source.AccountID: true}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err = c.refresh(dStores, suspense.AccountID); err != nil {
+		return nil, err
+	}
+
+	learned := c.examples[txn.TransactionID]
+	c.forget(txn.TransactionID)
+
+	scores := c.score(&categorizerSubject{comment: txn.TransactionComment, amount: amount,
+		sourceAccountID: source.AccountID}, excluded)
+
+	c.learn(txn.TransactionID, learned)
+
+	return namedSuggestions(dStores, scores)
+}
+
+// SuggestImportAccounts suggests the accounts for each line of a preview that has no category, by ImportLineID
+func (c *Categorizer) SuggestImportAccounts(dStores *datastore.Datastores,
+	myImport *Import) (map[uint64][]*AccountSuggestion, error) {
+	suspense, err := RetrieveSuspenseAccount(dStores)
+	if err != nil {
+		return nil, fmt.Errorf("RetrieveSuspenseAccount:%w", err)
+	}
+
+	excluded := map[uint64]bool{suspense.AccountID: true, myImport.AccountID: true}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err = c.refresh(dStores, suspense.AccountID); err != nil {
+		return nil, err
+	}
+
+	suggestions := make(map[uint64][]*AccountSuggestion)
+
+	for _, line := range myImport.Lines {
+		if line.IsDuplicate || line.TransactionID.Valid || line.LineCategory != "" || len(line.LineSplits) > 0 {
+			continue
+		}
+
+		scores := c.score(&categorizerSubject{comment: line.LineComment, amount: line.LineAmount,
+			sourceAccountID: myImport.AccountID}, excluded)
+
+		if suggestions[line.ImportLineID], err = namedSuggestions(dStores, scores); err != nil {
+			return nil, err
+		}
+	}
+
+	return suggestions, nil
+}
+
+// refresh learns from the transactions that were added or changed, and forgets the ones that were deleted, since
+// the ledger was last looked at
+func (c *Categorizer) refresh(dStores *datastore.Datastores, suspenseAccountID uint64) error {
+	modifiedSet, err := dStores.TransactionStore().GetModifiedDates()
+	if err != nil {
+		return fmt.Errorf("TransactionStore().GetModifiedDates:%w", err)
+	}
+
+	current := make(map[uint64]time.Time, len(modifiedSet))
+
+	var changed []uint64
+
+	for _, modified := range modifiedSet {
+		current[modified.TransactionID] = modified.TransactionModifiedDate
+
+		if learned, ok := c.modified[modified.TransactionID]; !ok || !learned.Equal(modified.TransactionModifiedDate) {
+			changed = append(changed, modified.TransactionID)
+		}
+	}
+
+	for transactionID := range c.modified {
+		if _, ok := current[transactionID]; !ok {
+			c.forget(transactionID)
+			delete(c.modified, transactionID)
+		}
+	}
+
+	if len(changed) == 0 {
+		return nil
+	}
+
+	postings, err := dStores.TransactionStore().GetPostings(changed)
+	if err != nil {
+		return fmt.Errorf("TransactionStore().GetPostings:%w", err)
+	}
+
+	byTransaction := make(map[uint64][]*datastore.TransactionPosting)
+	for _, posting := range postings {
+		byTransaction[posting.TransactionID] = append(byTransaction[posting.TransactionID], posting)
+	}
+
+	for _, transactionID := range changed {
+		c.forget(transactionID)
+		delete(c.modified, transactionID)
+
+		// a transaction is stored before its debits and credits, without them it is looked at again next time
+		if len(byTransaction[transactionID]) == 0 {
+			continue
+		}
+
+		c.learn(transactionID, categorizerExamples(byTransaction[transactionID], suspenseAccountID))
+		c.modified[transactionID] = current[transactionID]
+	}
+
+	return nil
+}
+
+// categorizerExamples are the examples of a transaction, none when it is on the suspense account
+func categorizerExamples(postings []*datastore.TransactionPosting, suspenseAccountID uint64) []*categorizerExample {
+	for _, posting := range postings {
+		if posting.AccountID == suspenseAccountID {
+			return nil
+		}
+	}
+
+	var examples []*categorizerExample
+
+	for _, source := range postings {
+		amount := int64(source.TransactionDCAmount) //nolint:gosec
+		if source.DebitOrCredit == datastore.AccountSignCredit {
+			amount = -amount
+		}
+
+		features := categorizerFeatures(&categorizerSubject{comment: source.TransactionComment, amount: amount,
+			sourceAccountID: source.AccountID})
+
+		for _, offset := range postings {
+			if offset.DebitOrCredit == source.DebitOrCredit || offset.AccountID == source.AccountID {
+				continue
+			}
+
+			examples = append(examples, &categorizerExample{class: offset.AccountID, features: features})
+		}
+	}
+
+	return examples
+}
+
+// categorizerFeatures are the words of the comment, the source account, whether money went in or out of it and the
+// number of digits of the amount
+func categorizerFeatures(subject *categorizerSubject) []string {
+	words := descriptionWords(subject.comment)
+	features := make([]string, 0, len(words)+3) //nolint:mnd
+
+	for word := range words {
+		features = append(features, "word:"+word)
+	}
+
+	direction, amount := "in", subject.amount
+	if amount < 0 {
+		direction, amount = "out", -amount
+	}
+
+	sort.Strings(features)
+
+	return append(features, "source:"+strconv.FormatUint(subject.sourceAccountID, 10), "direction:"+direction,
+		"digits:"+strconv.Itoa(len(strconv.FormatInt(amount, 10))))
+}
+
+func (c *Categorizer) learn(transactionID uint64, examples []*categorizerExample) {
+	if len(examples) == 0 {
+		return
+	}
+
+	c.examples[transactionID] = examples
+
+	for _, example := range examples {
+		c.examplesTotal++
+		c.classCounts[example.class]++
+
+		if c.featureCounts[example.class] == nil {
+			c.featureCounts[example.class] = make(map[string]int)
+		}
+
+		for _, feature := range example.features {
+			c.featureCounts[example.class][feature]++
+			c.featureTotals[example.class]++
+			c.vocabulary[feature]++
+		}
+	}
+}
+
+func (c *Categorizer) forget(transactionID uint64) {
+	for _, example := range c.examples[transactionID] {
+		c.examplesTotal--
+
+		if c.classCounts[example.class]--; c.classCounts[example.class] == 0 {
+			delete(c.classCounts, example.class)
+			delete(c.featureCounts, example.class)
+			delete(c.featureTotals, example.class)
+
+			for _, feature := range example.features {
+				c.forgetFeature(feature)
+			}
+
+			continue
+		}
+
+		for _, feature := range example.features {
+			if c.featureCounts[example.class][feature]--; c.featureCounts[example.class][feature] == 0 {
+				delete(c.featureCounts[example.class], feature)
+			}
+
+			c.featureTotals[example.class]--
+			c.forgetFeature(feature)
+		}
+	}
+
+	delete(c.examples, transactionID)
+}
+
+func (c *Categorizer) forgetFeature(feature string) {
+	if c.vocabulary[feature]--; c.vocabulary[feature] == 0 {
+		delete(c.vocabulary, feature)
+	}
+}
+
+// categorizerScore is the confidence in a class
+type categorizerScore struct {
+	accountID  uint64
+	confidence float64
+}
+
+// score is the confidence in each class that is not excluded, highest first.  Each class scores the log of its
+// share of the examples and of the Laplace smoothed likelihood of each known feature, and the scores are normalized
+// into confidences that add up to 1.
+func (c *Categorizer) score(subject *categorizerSubject, excluded map[uint64]bool) []*categorizerScore {
+	var known []string
+
+	for _, feature := range categorizerFeatures(subject) {
+		if c.vocabulary[feature] > 0 {
+			known = append(known, feature)
+		}
+	}
+
+	vocabularySize := float64(len(c.vocabulary))
+	logScores := make(map[uint64]float64)
+	maxScore := math.Inf(-1)
+
+	for class, count := range c.classCounts {
+		if excluded[class] {
+			continue
+		}
+
+		logScore := math.Log(float64(count) / float64(c.examplesTotal))
+		for _, feature := range known {
+			logScore += math.Log(float64(c.featureCounts[class][feature]+1) /
+				(float64(c.featureTotals[class]) + vocabularySize))
+		}
+
+		logScores[class] = logScore
+		maxScore = math.Max(maxScore, logScore)
+	}
+
+	var total float64
+
+	scores := make([]*categorizerScore, 0, len(logScores))
+
+	for class, logScore := range logScores {
+		confidence := math.Exp(logScore - maxScore)
+		total += confidence
+		scores = append(scores, &categorizerScore{accountID: class, confidence: confidence})
+	}
+
+	for _, myScore := range scores {
+		myScore.confidence /= total
+	}
+
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].confidence != scores[j].confidence {
+			return scores[i].confidence > scores[j].confidence
+		}
+
+		return scores[i].accountID < scores[j].accountID
+	})
+
+	return scores
+}
+
+// namedSuggestions are the first MaxAccountSuggestions scores with the names of their accounts
+func namedSuggestions(dStores *datastore.Datastores, scores []*categorizerScore) ([]*AccountSuggestion, error) {
+	suggestions := make([]*AccountSuggestion, 0, min(len(scores), MaxAccountSuggestions))
+
+	for _, myScore := range scores[:min(len(scores), MaxAccountSuggestions)] {
+		account, err := RetrieveAccountByID(dStores, myScore.accountID)
+		if err != nil {
+			return nil, fmt.Errorf("RetrieveAccountByID:%w", err)
+		}
+
+		suggestions = append(suggestions, &AccountSuggestion{AccountID: account.AccountID,
+			AccountName: account.AccountName, Confidence: myScore.confidence})
+	}
+
+	return suggestions, nil
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/importer"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestCategorizer_LearnScoreForget(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	const checking, home, salary, suspense = 1, 2, 3, 9
+
+	postings := func(transactionID uint64, comment string, debitID, creditID, amount uint64) []*datastore.TransactionPosting {
+		return []*datastore.TransactionPosting{
+			{TransactionID: transactionID, TransactionComment: comment, AccountID: debitID,
+				TransactionDCAmount: amount, DebitOrCredit: datastore.AccountSignDebit},
+			{TransactionID: transactionID, TransactionComment: comment, AccountID: creditID,
+				TransactionDCAmount: amount, DebitOrCredit: datastore.AccountSignCredit},
+		}
+	}
+
+	categorizer := NewCategorizer()
+	categorizer.learn(1, categorizerExamples(postings(1, "HOME DEPOT #1234", home, checking, 12750), suspense))
+	categorizer.learn(2, categorizerExamples(postings(2, "Home Depot garden", home, checking, 5000), suspense))
+	categorizer.learn(3, categorizerExamples(postings(3, "ACME PAYROLL", checking, salary, 250000), suspense))
+	// an uncategorized transaction teaches nothing
+	g.Expect(categorizerExamples(postings(4, "HOME DEPOT", suspense, checking, 100), suspense)).To(gomega.BeEmpty())
+
+	// each transaction is an example from each side
+	g.Expect(categorizer.examplesTotal).To(gomega.Equal(6))
+	g.Expect(categorizer.classCounts[home]).To(gomega.Equal(2))
+	g.Expect(categorizer.classCounts[checking]).To(gomega.Equal(3))
+
+	excluded := map[uint64]bool{checking: true, suspense: true}
+
+	scores := categorizer.score(&categorizerSubject{comment: "HOME DEPOT #99", amount: -3000,
+		sourceAccountID: checking}, excluded)
+	g.Expect(scores).To(gomega.HaveLen(2))
+	g.Expect(scores[0].accountID).To(gomega.Equal(uint64(home)))
+	g.Expect(scores[0].confidence).To(gomega.BeNumerically(">", 0.9))
+	g.Expect(scores[0].confidence + scores[1].confidence).To(gomega.BeNumerically("~", 1.0, 1e-9))
+
+	scores = categorizer.score(&categorizerSubject{comment: "Acme payroll", amount: 260000,
+		sourceAccountID: checking}, excluded)
+	g.Expect(scores[0].accountID).To(gomega.Equal(uint64(salary)))
+
+	// forgetting the transactions forgets the class and its words
+	categorizer.forget(1)
+	categorizer.forget(2)
+	g.Expect(categorizer.classCounts).NotTo(gomega.HaveKey(uint64(home)))
+	g.Expect(categorizer.vocabulary).NotTo(gomega.HaveKey("word:depot"))
+	g.Expect(categorizer.examplesTotal).To(gomega.Equal(2))
+
+	scores = categorizer.score(&categorizerSubject{comment: "HOME DEPOT #99", amount: -3000,
+		sourceAccountID: checking}, excluded)
+	g.Expect(scores).To(gomega.HaveLen(1))
+	g.Expect(scores[0].accountID).To(gomega.Equal(uint64(salary)))
+}
+
+func TestCategorizer_SuggestAccounts(t *testing.T) { //nolint:funlen
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	storeAccount := func(name string, accountType datastore.AccountType) *Account {
+		account := Account{AccountName: name, AccountSign: datastore.AccountSignDebit, AccountType: accountType}
+		err := account.Store(testDS)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		return &account
+	}
+
+	checking := storeAccount("Checking", datastore.AccountTypeAsset)
+	home := storeAccount("Home", datastore.AccountTypeExpense)
+	garden := storeAccount("Garden", datastore.AccountTypeExpense)
+
+	suspense, err := RetrieveSuspenseAccount(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	storeTxn := func(comment string, offsetAccountID uint64) *Transaction {
+		txn := Transaction{TransactionCore: TransactionCore{TransactionComment: comment,
+			TransactionDate: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+			DebitCreditSet: []*TransactionDebitCredit{
+				{AccountID: checking.AccountID, DebitOrCredit: datastore.AccountSignCredit, TransactionDCAmount: 4200},
+				{AccountID: offsetAccountID, DebitOrCredit: datastore.AccountSignDebit, TransactionDCAmount: 4200},
+			},
+		}
+		err := txn.Store(testDS)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		return &txn
+	}
+
+	storeTxn("HOME DEPOT #1234", home.AccountID)
+	storeTxn("Home Depot lumber", home.AccountID)
+	storeTxn("Garden centre plants", garden.AccountID)
+	uncategorized := storeTxn("HOME DEPOT #5678", suspense.AccountID)
+
+	suggestions, err := SharedCategorizer(testDS).SuggestAccounts(testDS, uncategorized, 0)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(suggestions).To(gomega.HaveLen(2))
+	g.Expect(suggestions[0].AccountID).To(gomega.Equal(home.AccountID))
+	g.Expect(suggestions[0].AccountName).To(gomega.Equal("Home"))
+	g.Expect(suggestions[0].Confidence).To(gomega.BeNumerically(">", suggestions[1].Confidence))
+
+	// an account that is not in the transaction cannot be the side suggestions are for
+	_, err = SharedCategorizer(testDS).SuggestAccounts(testDS, uncategorized, garden.AccountID)
+	g.Expect(err).To(gomega.MatchError(ErrSuggestionAccountInvalid))
+
+	// recategorizing a transaction is learned again
+	plants := storeTxn("Home Depot plants", home.AccountID)
+	plants.DebitCreditSet[1].AccountID = garden.AccountID
+	err = plants.Update(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	categorizer := SharedCategorizer(testDS)
+	_, err = categorizer.SuggestAccounts(testDS, uncategorized, 0)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(categorizer.classCounts[garden.AccountID]).To(gomega.Equal(2))
+	g.Expect(categorizer.classCounts[home.AccountID]).To(gomega.Equal(2))
+
+	// the lines of an import preview are suggested accounts too
+	myImport, err := NewImport(testDS, parseTestOFX(g), importer.FormatOFX, "bank_v1.ofx",
+		&ImportAccounts{AccountID: checking.AccountID})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myImport.Suggestions).To(gomega.HaveKey(myImport.Lines[0].ImportLineID))
+	g.Expect(myImport.Suggestions[myImport.Lines[0].ImportLineID][0].AccountID).To(gomega.Equal(home.AccountID))
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"github.com/jmoiron/sqlx"
+	"github.com/mimirsoft/mimirledger/api/blobstore"
 	"github.com/mimirsoft/mimirledger/api/cfg"
 	"github.com/mimirsoft/mimirledger/api/datastore"
 	"github.com/onsi/ginkgo"
@@ -23,10 +24,15 @@ func TestMain(m *testing.M) {
 	if err != nil {
 		panic(err)
 	}
-	testDS = datastore.NewDatastores(myClient)
+	blobDir, err := os.MkdirTemp("", "attachments")
+	if err != nil {
+		panic(err)
+	}
+	testDS = datastore.NewDatastores(myClient, blobstore.NewFileStore(blobDir))
 	dbClient = myClient
 
 	result := m.Run()
+	os.RemoveAll(blobDir)
 	os.Exit(result)
 }
 
@@ -632,9 +638,36 @@ func TestAccount_RetrieveAccountFullName(t *testing.T) {
 
 // test make account and children and grand children and move them around
 func setupDB(g *gomega.WithT) {
-	query := `delete from transaction_debit_credit `
+	query := `delete from imports `
 	_, err := dbClient.Exec(query)
 	g.Expect(err).NotTo(gomega.HaveOccurred())
+	query = `delete from scheduled_transactions `
+	_, err = dbClient.Exec(query)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	query = `delete from memorized_transactions `
+	_, err = dbClient.Exec(query)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	query = `delete from categorization_rules `
+	_, err = dbClient.Exec(query)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	query = `delete from import_profiles `
+	_, err = dbClient.Exec(query)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	query = `delete from transaction_debit_credit `
+	_, err = dbClient.Exec(query)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	query = `delete from transaction_main `
+	_, err = dbClient.Exec(query)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	query = `delete from settings `
+	_, err = dbClient.Exec(query)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	query = `delete from reports `
+	_, err = dbClient.Exec(query)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	query = `delete from report_templates `
+	_, err = dbClient.Exec(query)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
 	query = `delete from transaction_accounts `
 	_, err = dbClient.Exec(query)
 	g.Expect(err).NotTo(gomega.HaveOccurred())
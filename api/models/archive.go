@@ -0,0 +1,524 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/archive"
+	"github.com/mimirsoft/mimirledger/api/datastore"
+)
+
+// ArchiveRestoreSummary is the number of records restored of each type, and the integrity checks of the restored
+// ledger
+type ArchiveRestoreSummary struct {
+	Settings        int
+	Accounts        int
+	Transactions    int
+	DebitCredits    int
+	Reports         int
+	ReportTemplates int
+	Integrity       *IntegrityReport
+}
+
+var ErrArchiveDatabaseNotEmpty = errors.New("an archive can only be restored into an empty ledger")
+
+// ExportArchive writes the settings, accounts, transactions, debits and credits, reports and report templates as
+// an archive.  Transactions and their debits and credits are streamed from the database.
+func ExportArchive(dStores *datastore.Datastores, writer io.Writer) error { //nolint:cyclop
+	myWriter, err := archive.NewWriter(writer, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("archive.NewWriter:%w", err)
+	}
+
+	settings, err := RetrieveSettings(dStores)
+	if err != nil {
+		return fmt.Errorf("RetrieveSettings:%w", err)
+	}
+
+	for _, mySetting := range settings {
+		err = myWriter.Write(archive.RecordTypeSetting, archive.Setting{SettingName: mySetting.SettingName,
+			SettingValue: mySetting.SettingValue})
+		if err != nil {
+			return fmt.Errorf("myWriter.Write:%w", err)
+		}
+	}
+
+	accounts, err := RetrieveAccounts(dStores)
+	if err != nil {
+		return fmt.Errorf("RetrieveAccounts:%w", err)
+	}
+
+	for _, account := range accounts {
+		if err = myWriter.Write(archive.RecordTypeAccount, accountToArchiveAccount(account)); err != nil {
+			return fmt.Errorf("myWriter.Write:%w", err)
+		}
+	}
+
+	err = dStores.ArchiveStore().EachTransaction(func(eTxn *datastore.Transaction) error {
+		return myWriter.Write(archive.RecordTypeTransaction, entTransactionToArchiveTransaction(eTxn))
+	})
+	if err != nil {
+		return fmt.Errorf("ArchiveStore().EachTransaction:%w", err)
+	}
+
+	err = dStores.ArchiveStore().EachDebitCredit(func(eDC *datastore.TransactionDebitCredit) error {
+		return myWriter.Write(archive.RecordTypeDebitCredit, archive.DebitCredit{
+			TransactionDCID:          eDC.TransactionDCID,
+			TransactionID:            eDC.TransactionID,
+			AccountID:                eDC.AccountID,
+			TransactionDCAmount:      eDC.TransactionDCAmount,
+			DebitOrCredit:            string(eDC.DebitOrCredit),
+			IsReconciled:             &eDC.IsReconciled,
+			TransactionReconcileDate: archiveTime(eDC.TransactionReconcileDate),
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("ArchiveStore().EachDebitCredit:%w", err)
+	}
+
+	reports, err := RetrieveReports(dStores)
+	if err != nil && !errors.Is(err, ErrNoReports) {
+		return fmt.Errorf("RetrieveReports:%w", err)
+	}
+
+	for _, myReport := range reports {
+		body, err := json.Marshal(reportToEntReport(myReport).ReportBody)
+		if err != nil {
+			return fmt.Errorf("json.Marshal:%w", err)
+		}
+
+		err = myWriter.Write(archive.RecordTypeReport, archive.Report{ReportID: myReport.ReportID,
+			ReportName: myReport.ReportName, ReportBody: body})
+		if err != nil {
+			return fmt.Errorf("myWriter.Write:%w", err)
+		}
+	}
+
+	templates, err := RetrieveReportTemplates(dStores)
+	if err != nil {
+		return fmt.Errorf("RetrieveReportTemplates:%w", err)
+	}
+
+	for _, myTemplate := range templates {
+		err = myWriter.Write(archive.RecordTypeReportTemplate, archive.ReportTemplate{
+			TemplateID:   myTemplate.TemplateID,
+			TemplateName: myTemplate.TemplateName,
+			TemplateType: string(myTemplate.TemplateType),
+			TemplateBody: myTemplate.TemplateBody,
+		})
+		if err != nil {
+			return fmt.Errorf("myWriter.Write:%w", err)
+		}
+	}
+
+	if err = myWriter.Close(); err != nil {
+		return fmt.Errorf("myWriter.Close:%w", err)
+	}
+
+	return nil
+}
+
+// archiveRestore is the state of a restore, the IDs restored so far of each type
+type archiveRestore struct {
+	restore        *datastore.ArchiveRestore
+	recordType     int
+	accounts       map[uint64]*archive.Account
+	transactions   map[uint64]*archive.Transaction
+	debitCredits   map[uint64]bool
+	reports        map[uint64]bool
+	templates      map[uint64]bool
+	summary        ArchiveRestoreSummary
+	recordTypeRank map[archive.RecordType]int
+}
+
+// RestoreArchive restores an archive into a ledger with no accounts, transactions, reports or report templates.
+// Records keep their IDs, so reports still refer to the same accounts, and the ID sequences continue after them.
+// Subtotals and balances are recalculated from the debits and credits.  The whole archive is restored in one
+// database transaction, an archive with a record referring to a missing record restores nothing.  The integrity
+// checks are run on the restored ledger.
+func RestoreArchive(dStores *datastore.Datastores, reader io.Reader) (*ArchiveRestoreSummary, error) {
+	isEmpty, err := dStores.ArchiveStore().IsEmpty()
+	if err != nil {
+		return nil, fmt.Errorf("ArchiveStore().IsEmpty:%w", err)
+	}
+
+	if !isEmpty {
+		return nil, ErrArchiveDatabaseNotEmpty
+	}
+
+	myReader, err := archive.NewReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("archive.NewReader:%w", err)
+	}
+
+	restore, err := dStores.ArchiveStore().BeginRestore()
+	if err != nil {
+		return nil, fmt.Errorf("ArchiveStore().BeginRestore:%w", err)
+	}
+
+	myRestore := archiveRestore{
+		restore:        restore,
+		recordType:     0,
+		accounts:       make(map[uint64]*archive.Account),
+		transactions:   make(map[uint64]*archive.Transaction),
+		debitCredits:   make(map[uint64]bool),
+		reports:        make(map[uint64]bool),
+		templates:      make(map[uint64]bool),
+		summary:        ArchiveRestoreSummary{}, //nolint:exhaustruct
+		recordTypeRank: make(map[archive.RecordType]int, len(archive.RecordTypes)),
+	}
+
+	for idx, recordType := range archive.RecordTypes {
+		myRestore.recordTypeRank[recordType] = idx
+	}
+
+	if err = myRestore.run(myReader); err != nil {
+		_ = restore.Rollback()
+
+		return nil, err
+	}
+
+	if err = restore.Commit(); err != nil {
+		return nil, fmt.Errorf("restore.Commit:%w", err)
+	}
+
+	if myRestore.summary.Integrity, err = CheckIntegrity(dStores); err != nil {
+		return nil, fmt.Errorf("CheckIntegrity:%w", err)
+	}
+
+	return &myRestore.summary, nil
+}
+
+// run restores every record, then checks the references that may point forward and recalculates the totals
+func (c *archiveRestore) run(myReader *archive.Reader) error {
+	for {
+		record, err := myReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("myReader.Next:%w", err)
+		}
+
+		// a record may only refer to records of earlier types, so they must come in order
+		rank := c.recordTypeRank[record.Type]
+		if rank < c.recordType {
+			return fmt.Errorf("%w: %s record after the %s records", archive.ErrArchiveInvalid, record.Type,
+				archive.RecordTypes[c.recordType])
+		}
+
+		c.recordType = rank
+
+		if err = c.record(record); err != nil {
+			return err
+		}
+	}
+
+	if err := c.checkReferences(); err != nil {
+		return err
+	}
+
+	if err := c.restore.UpdateSubtotalsAndBalances(); err != nil {
+		return fmt.Errorf("restore.UpdateSubtotalsAndBalances:%w", err)
+	}
+
+	return nil
+}
+
+func (c *archiveRestore) record(record *archive.Record) error {
+	switch record.Type {
+	case archive.RecordTypeSetting:
+		return c.setting(record)
+	case archive.RecordTypeAccount:
+		return c.account(record)
+	case archive.RecordTypeTransaction:
+		return c.transaction(record)
+	case archive.RecordTypeDebitCredit:
+		return c.debitCredit(record)
+	case archive.RecordTypeReport:
+		return c.report(record)
+	case archive.RecordTypeReportTemplate:
+		return c.reportTemplate(record)
+	}
+
+	return nil
+}
+
+func (c *archiveRestore) setting(record *archive.Record) error {
+	var mySetting archive.Setting
+	if err := record.Decode(&mySetting); err != nil {
+		return fmt.Errorf("record.Decode:%w", err)
+	}
+
+	eSetting := datastore.Setting{SettingName: mySetting.SettingName, SettingValue: mySetting.SettingValue}
+	if err := c.restore.StoreSetting(&eSetting); err != nil {
+		return fmt.Errorf("restore.StoreSetting:%w [setting:%s]", err, mySetting.SettingName)
+	}
+
+	c.summary.Settings++
+
+	return nil
+}
+
+func (c *archiveRestore) account(record *archive.Record) error {
+	var account archive.Account
+	if err := record.Decode(&account); err != nil {
+		return fmt.Errorf("record.Decode:%w", err)
+	}
+
+	if account.AccountID == 0 || c.accounts[account.AccountID] != nil {
+		return fmt.Errorf("%w: account ID %d is missing or repeated", archive.ErrArchiveInvalid, account.AccountID)
+	}
+
+	eAcct := archiveAccountToEntAccount(&account)
+	if err := c.restore.StoreAccount(&eAcct); err != nil {
+		return fmt.Errorf("restore.StoreAccount:%w [accountID:%d]", err, account.AccountID)
+	}
+
+	c.accounts[account.AccountID] = &account
+	c.summary.Accounts++
+
+	return nil
+}
+
+func (c *archiveRestore) transaction(record *archive.Record) error {
+	var txn archive.Transaction
+	if err := record.Decode(&txn); err != nil {
+		return fmt.Errorf("record.Decode:%w", err)
+	}
+
+	if txn.TransactionID == 0 || c.transactions[txn.TransactionID] != nil {
+		return fmt.Errorf("%w: transaction ID %d is missing or repeated", archive.ErrArchiveInvalid,
+			txn.TransactionID)
+	}
+
+	eTxn := datastore.Transaction{
+		TransactionID:            txn.TransactionID,
+		TransactionDate:          txn.TransactionDate,
+		TransactionReconcileDate: archiveNullTime(txn.TransactionReconcileDate),
+		TransactionComment:       txn.TransactionComment,
+		TransactionAmount:        txn.TransactionAmount,
+		TransactionReference:     txn.TransactionReference,
+		IsReconciled:             txn.IsReconciled,
+		IsSplit:                  txn.IsSplit,
+	}
+	if err := c.restore.StoreTransaction(&eTxn); err != nil {
+		return fmt.Errorf("restore.StoreTransaction:%w [transactionID:%d]", err, txn.TransactionID)
+	}
+
+	c.transactions[txn.TransactionID] = &txn
+	c.summary.Transactions++
+
+	return nil
+}
+
+func (c *archiveRestore) debitCredit(record *archive.Record) error {
+	var myDC archive.DebitCredit
+	if err := record.Decode(&myDC); err != nil {
+		return fmt.Errorf("record.Decode:%w", err)
+	}
+
+	switch {
+	case myDC.TransactionDCID == 0 || c.debitCredits[myDC.TransactionDCID]:
+		return fmt.Errorf("%w: debit/credit ID %d is missing or repeated", archive.ErrArchiveInvalid,
+			myDC.TransactionDCID)
+	case c.transactions[myDC.TransactionID] == nil:
+		return fmt.Errorf("%w: debit/credit %d is on transaction %d, which is not in the archive",
+			archive.ErrArchiveInvalid, myDC.TransactionDCID, myDC.TransactionID)
+	case c.accounts[myDC.AccountID] == nil:
+		return fmt.Errorf("%w: debit/credit %d is posted to account %d, which is not in the archive",
+			archive.ErrArchiveInvalid, myDC.TransactionDCID, myDC.AccountID)
+	}
+
+	eDC := datastore.TransactionDebitCredit{
+		TransactionDCID:          myDC.TransactionDCID,
+		TransactionID:            myDC.TransactionID,
+		AccountID:                myDC.AccountID,
+		TransactionDCAmount:      myDC.TransactionDCAmount,
+		DebitOrCredit:            datastore.AccountSign(myDC.DebitOrCredit),
+		IsReconciled:             c.transactions[myDC.TransactionID].IsReconciled,
+		TransactionReconcileDate: archiveNullTime(c.transactions[myDC.TransactionID].TransactionReconcileDate),
+	}
+
+	if myDC.IsReconciled != nil {
+		eDC.IsReconciled = *myDC.IsReconciled
+		eDC.TransactionReconcileDate = archiveNullTime(myDC.TransactionReconcileDate)
+	}
+
+	if err := c.restore.StoreDebitCredit(&eDC); err != nil {
+		return fmt.Errorf("restore.StoreDebitCredit:%w [transactionDCID:%d]", err, myDC.TransactionDCID)
+	}
+
+	c.debitCredits[myDC.TransactionDCID] = true
+	c.summary.DebitCredits++
+
+	return nil
+}
+
+func (c *archiveRestore) report(record *archive.Record) error {
+	var myReport archive.Report
+	if err := record.Decode(&myReport); err != nil {
+		return fmt.Errorf("record.Decode:%w", err)
+	}
+
+	if myReport.ReportID == 0 || c.reports[myReport.ReportID] {
+		return fmt.Errorf("%w: report ID %d is missing or repeated", archive.ErrArchiveInvalid, myReport.ReportID)
+	}
+
+	eReport := datastore.Report{ReportID: myReport.ReportID, ReportName: myReport.ReportName,
+		ReportBody: datastore.ReportBody{}} //nolint:exhaustruct
+	if err := json.Unmarshal(myReport.ReportBody, &eReport.ReportBody); err != nil {
+		return fmt.Errorf("%w: report %d body: %w", archive.ErrArchiveInvalid, myReport.ReportID, err)
+	}
+
+	for _, accountIDs := range [][]uint64{eReport.ReportBody.SourcePredefinedAccounts,
+		eReport.ReportBody.FilterPredefinedAccounts} {
+		for _, accountID := range accountIDs {
+			if c.accounts[accountID] == nil {
+				return fmt.Errorf("%w: report %d refers to account %d, which is not in the archive",
+					archive.ErrArchiveInvalid, myReport.ReportID, accountID)
+			}
+		}
+	}
+
+	if err := c.restore.StoreReport(&eReport); err != nil {
+		return fmt.Errorf("restore.StoreReport:%w [reportID:%d]", err, myReport.ReportID)
+	}
+
+	c.reports[myReport.ReportID] = true
+	c.summary.Reports++
+
+	return nil
+}
+
+func (c *archiveRestore) reportTemplate(record *archive.Record) error {
+	var myTemplate archive.ReportTemplate
+	if err := record.Decode(&myTemplate); err != nil {
+		return fmt.Errorf("record.Decode:%w", err)
+	}
+
+	if myTemplate.TemplateID == 0 || c.templates[myTemplate.TemplateID] {
+		return fmt.Errorf("%w: report template ID %d is missing or repeated", archive.ErrArchiveInvalid,
+			myTemplate.TemplateID)
+	}
+
+	eTemplate := datastore.ReportTemplate{
+		TemplateID:   myTemplate.TemplateID,
+		TemplateName: myTemplate.TemplateName,
+		TemplateType: datastore.ReportTemplateType(myTemplate.TemplateType),
+		TemplateBody: myTemplate.TemplateBody,
+	}
+	if err := c.restore.StoreReportTemplate(&eTemplate); err != nil {
+		return fmt.Errorf("restore.StoreReportTemplate:%w [templateID:%d]", err, myTemplate.TemplateID)
+	}
+
+	c.templates[myTemplate.TemplateID] = true
+	c.summary.ReportTemplates++
+
+	return nil
+}
+
+// checkReferences checks every parent account is in the archive, a parent may come after its subaccounts
+func (c *archiveRestore) checkReferences() error {
+	for _, account := range c.accounts {
+		if account.AccountParent != 0 && c.accounts[account.AccountParent] == nil {
+			return fmt.Errorf("%w: account %d has parent %d, which is not in the archive", archive.ErrArchiveInvalid,
+				account.AccountID, account.AccountParent)
+		}
+	}
+
+	return nil
+}
+
+func accountToArchiveAccount(account *Account) archive.Account {
+	myAccount := archive.Account{
+		AccountID:            account.AccountID,
+		AccountParent:        account.AccountParent,
+		AccountName:          account.AccountName,
+		AccountFullName:      account.AccountFullName,
+		AccountMemo:          account.AccountMemo,
+		AccountCurrent:       account.AccountCurrent,
+		AccountLeft:          account.AccountLeft,
+		AccountRight:         account.AccountRight,
+		AccountDecimals:      account.AccountDecimals,
+		AccountReconcileDate: archiveTime(account.AccountReconcileDate),
+		AccountFlagged:       account.AccountFlagged,
+		AccountLocked:        account.AccountLocked,
+		AccountOpenDate:      account.AccountOpenDate,
+		AccountCloseDate:     archiveTime(account.AccountCloseDate),
+		AccountCode:          nil,
+		AccountSign:          string(account.AccountSign),
+		AccountType:          string(account.AccountType),
+	}
+
+	if account.AccountCode.Valid {
+		myAccount.AccountCode = &account.AccountCode.String
+	}
+
+	return myAccount
+}
+
+func archiveAccountToEntAccount(account *archive.Account) datastore.Account {
+	eAcct := datastore.Account{
+		AccountID:            account.AccountID,
+		AccountParent:        account.AccountParent,
+		AccountName:          account.AccountName,
+		AccountFullName:      account.AccountFullName,
+		AccountMemo:          account.AccountMemo,
+		AccountCurrent:       account.AccountCurrent,
+		AccountLeft:          account.AccountLeft,
+		AccountRight:         account.AccountRight,
+		AccountBalance:       0,
+		AccountSubtotal:      0,
+		AccountDecimals:      account.AccountDecimals,
+		AccountReconcileDate: archiveNullTime(account.AccountReconcileDate),
+		AccountFlagged:       account.AccountFlagged,
+		AccountLocked:        account.AccountLocked,
+		AccountOpenDate:      account.AccountOpenDate,
+		AccountCloseDate:     archiveNullTime(account.AccountCloseDate),
+		AccountCode:          sql.NullString{},
+		AccountSign:          datastore.AccountSign(account.AccountSign),
+		AccountType:          datastore.AccountType(account.AccountType),
+	}
+
+	if account.AccountCode != nil {
+		eAcct.AccountCode = sql.NullString{String: *account.AccountCode, Valid: true}
+	}
+
+	return eAcct
+}
+
+func entTransactionToArchiveTransaction(eTxn *datastore.Transaction) archive.Transaction {
+	return archive.Transaction{
+		TransactionID:            eTxn.TransactionID,
+		TransactionDate:          eTxn.TransactionDate,
+		TransactionReconcileDate: archiveTime(eTxn.TransactionReconcileDate),
+		TransactionComment:       eTxn.TransactionComment,
+		TransactionAmount:        eTxn.TransactionAmount,
+		TransactionReference:     eTxn.TransactionReference,
+		IsReconciled:             eTxn.IsReconciled,
+		IsSplit:                  eTxn.IsSplit,
+	}
+}
+
+// archiveTime is a nullable time as it is archived, nil when it is null
+func archiveTime(nullTime sql.NullTime) *time.Time {
+	if !nullTime.Valid {
+		return nil
+	}
+
+	return &nullTime.Time
+}
+
+func archiveNullTime(myTime *time.Time) sql.NullTime {
+	if myTime == nil {
+		return sql.NullTime{}
+	}
+
+	return sql.NullTime{Time: *myTime, Valid: true}
+}
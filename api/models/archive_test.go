@@ -0,0 +1,192 @@
+package models
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/archive"
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+// archiveTestLedger stores a small ledger with a report on the checking account, and returns the checking account
+func archiveTestLedger(g *gomega.WithT) *Account {
+	banks := Account{AccountName: "Banks", AccountType: datastore.AccountTypeAsset}
+	g.Expect(banks.Store(testDS)).To(gomega.Succeed())
+
+	checking := Account{AccountName: "Checking", AccountParent: banks.AccountID, AccountMemo: "joint account"}
+	g.Expect(checking.Store(testDS)).To(gomega.Succeed())
+
+	salary := Account{AccountName: "Salary", AccountType: datastore.AccountTypeIncome}
+	g.Expect(salary.Store(testDS)).To(gomega.Succeed())
+
+	food := Account{AccountName: "Food", AccountType: datastore.AccountTypeExpense}
+	g.Expect(food.Store(testDS)).To(gomega.Succeed())
+
+	paycheck := Transaction{TransactionCore: TransactionCore{TransactionComment: "paycheck",
+		TransactionDate: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), TransactionReference: "PAY1"},
+		DebitCreditSet: []*TransactionDebitCredit{
+			{AccountID: salary.AccountID, DebitOrCredit: datastore.AccountSignCredit, TransactionDCAmount: 250000},
+			{AccountID: checking.AccountID, DebitOrCredit: datastore.AccountSignDebit, TransactionDCAmount: 250000},
+		},
+	}
+	g.Expect(paycheck.Store(testDS)).To(gomega.Succeed())
+
+	groceries := Transaction{TransactionCore: TransactionCore{TransactionComment: "groceries",
+		TransactionDate: time.Date(2024, 1, 12, 0, 0, 0, 0, time.UTC), IsReconciled: true,
+		TransactionReconcileDate: sql.NullTime{Time: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), Valid: true}},
+		DebitCreditSet: []*TransactionDebitCredit{
+			{AccountID: checking.AccountID, DebitOrCredit: datastore.AccountSignCredit, TransactionDCAmount: 4525},
+			{AccountID: food.AccountID, DebitOrCredit: datastore.AccountSignDebit, TransactionDCAmount: 4525},
+		},
+	}
+	g.Expect(groceries.Store(testDS)).To(gomega.Succeed())
+
+	myReport := Report{ReportName: "Checking balance", ReportBody: ReportBody{
+		SourceAccountSetType:     datastore.ReportAccountSetPredefined,
+		SourcePredefinedAccounts: []uint64{checking.AccountID},
+		DataSetType:              datastore.ReportDataSetTypeBalance,
+	}}
+	g.Expect(myReport.Store(testDS)).To(gomega.Succeed())
+
+	myTemplate := ReportTemplate{TemplateName: "Plain", TemplateType: datastore.ReportTemplateTypeText,
+		TemplateBody: "{{.Title}}"}
+	g.Expect(myTemplate.Store(testDS)).To(gomega.Succeed())
+
+	mySetting := Setting{SettingName: "ledgerName", SettingValue: "Household"}
+	g.Expect(mySetting.StoreOrUpdate(testDS)).To(gomega.Succeed())
+
+	return &checking
+}
+
+func TestArchive_ExportAndRestore(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	checking := archiveTestLedger(g)
+
+	accounts, err := RetrieveAccounts(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	var exported bytes.Buffer
+	g.Expect(ExportArchive(testDS, &exported)).To(gomega.Succeed())
+
+	// an archive is only restored into an empty ledger
+	_, err = RestoreArchive(testDS, bytes.NewReader(exported.Bytes()))
+	g.Expect(err).To(gomega.MatchError(ErrArchiveDatabaseNotEmpty))
+
+	setupDB(g)
+
+	summary, err := RestoreArchive(testDS, bytes.NewReader(exported.Bytes()))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(summary.Settings).To(gomega.Equal(1))
+	g.Expect(summary.Accounts).To(gomega.Equal(4))
+	g.Expect(summary.Transactions).To(gomega.Equal(2))
+	g.Expect(summary.DebitCredits).To(gomega.Equal(4))
+	g.Expect(summary.Reports).To(gomega.Equal(1))
+	g.Expect(summary.ReportTemplates).To(gomega.Equal(1))
+	g.Expect(summary.Integrity.AccountsChecked).To(gomega.Equal(4))
+	g.Expect(summary.Integrity.TransactionsChecked).To(gomega.Equal(2))
+	g.Expect(summary.Integrity.Problems).To(gomega.BeEmpty())
+
+	// IDs, subtotals and balances are the same as before
+	restored, err := RetrieveAccounts(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(restored).To(gomega.Equal(accounts))
+
+	reports, err := RetrieveReports(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(reports).To(gomega.HaveLen(1))
+	g.Expect(reports[0].ReportBody.SourcePredefinedAccounts).To(gomega.Equal([]uint64{checking.AccountID}))
+
+	mySetting, err := RetrieveSettingByName(testDS, "ledgerName")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(mySetting.SettingValue).To(gomega.Equal("Household"))
+
+	// the restored ledger exports the same records
+	var reexported bytes.Buffer
+	g.Expect(ExportArchive(testDS, &reexported)).To(gomega.Succeed())
+	g.Expect(strings.SplitN(reexported.String(), "\n", 2)[1]).To(
+		gomega.Equal(strings.SplitN(exported.String(), "\n", 2)[1]))
+
+	// new records are numbered after the restored ones
+	savings := Account{AccountName: "Savings", AccountParent: checking.AccountParent}
+	g.Expect(savings.Store(testDS)).To(gomega.Succeed())
+
+	for _, account := range accounts {
+		g.Expect(savings.AccountID).To(gomega.BeNumerically(">", account.AccountID))
+	}
+}
+
+func TestArchive_RestoreInvalid(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	archiveTestLedger(g)
+
+	var exported bytes.Buffer
+	g.Expect(ExportArchive(testDS, &exported)).To(gomega.Succeed())
+
+	lines := strings.SplitAfter(exported.String(), "\n")
+
+	setupDB(g)
+
+	// a truncated archive restores nothing
+	_, err := RestoreArchive(testDS, strings.NewReader(strings.Join(lines[:len(lines)-3], "")))
+	g.Expect(err).To(gomega.MatchError(archive.ErrArchiveInvalid))
+
+	accounts, err := RetrieveAccounts(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(accounts).To(gomega.BeEmpty())
+
+	// a debit or credit on an account that is not in the archive
+	withoutAccounts := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if !strings.HasPrefix(line, `{"type":"account"`) {
+			withoutAccounts = append(withoutAccounts, line)
+		}
+	}
+
+	_, err = RestoreArchive(testDS, strings.NewReader(strings.Join(withoutAccounts, "")))
+	g.Expect(err).To(gomega.MatchError(archive.ErrArchiveInvalid))
+
+	isEmpty, err := testDS.ArchiveStore().IsEmpty()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(isEmpty).To(gomega.BeTrue())
+}
+
+func TestIntegrity_CheckIntegrity(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	checking := archiveTestLedger(g)
+
+	report, err := CheckIntegrity(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(report.Problems).To(gomega.BeEmpty())
+
+	_, err = dbClient.Exec(`UPDATE transaction_accounts SET account_subtotal = account_subtotal + 1,
+		account_full_name = 'Checkings' WHERE account_id = $1`, checking.AccountID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	report, err = CheckIntegrity(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	problems := make(map[IntegrityCheck][]uint64)
+	for _, problem := range report.Problems {
+		problems[problem.Check] = append(problems[problem.Check], problem.AccountID)
+	}
+
+	// the subtotal and balance of checking, and the balance of banks which adds up the subtotal of checking
+	g.Expect(problems).To(gomega.HaveLen(2))
+	g.Expect(problems[IntegrityCheckAccountTotals]).To(gomega.ConsistOf(checking.AccountID, checking.AccountID,
+		checking.AccountParent))
+	g.Expect(problems[IntegrityCheckAccountFullName]).To(gomega.Equal([]uint64{checking.AccountID}))
+}
@@ -0,0 +1,220 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/blobstore"
+	"github.com/mimirsoft/mimirledger/api/datastore"
+)
+
+// Attachment is a receipt, invoice or other document attached to a transaction.  The file is kept in the blob store
+// under StorageKey, FileSHA256 is the hex sha256 of its contents.
+type Attachment struct {
+	AttachmentID  uint64
+	TransactionID uint64
+	FileName      string
+	ContentType   string
+	FileSize      int64
+	FileSHA256    string
+	StorageKey    string
+	CreatedDate   time.Time
+}
+
+// attachmentDefaultContentType is the content type of a file uploaded without one
+const attachmentDefaultContentType = "application/octet-stream"
+
+// attachmentKeyBytes is the random part of a storage key, so keys are never reused
+const attachmentKeyBytes = 16
+
+var ErrAttachmentNotFound = errors.New("attachment not found")
+var ErrAttachmentInvalid = errors.New("attachment is invalid")
+
+// Store saves the FileSize bytes read from body to the blob store, then inserts the metadata of the Attachment.  The
+// blob is deleted again when the metadata cannot be stored.
+func (c *Attachment) Store(ctx context.Context, dStores *datastore.Datastores, body io.Reader) error {
+	if err := c.validate(dStores); err != nil {
+		return fmt.Errorf("c.validate:%w", err)
+	}
+
+	storageKey, err := newAttachmentKey(c.TransactionID)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+
+	err = dStores.BlobStore().Put(ctx, storageKey, io.TeeReader(body, hash), c.FileSize, c.ContentType)
+	if err != nil {
+		return fmt.Errorf("ds.BlobStore().Put:%w [transaction:%d]", err, c.TransactionID)
+	}
+
+	c.StorageKey = storageKey
+	c.FileSHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	eAttachment := attachmentToEntAttachment(c)
+
+	if err = dStores.AttachmentStore().Store(&eAttachment); err != nil {
+		return errors.Join(fmt.Errorf("ds.AttachmentStore().Store:%w [transaction:%d]", err, c.TransactionID),
+			dStores.BlobStore().Delete(ctx, storageKey))
+	}
+
+	*c = *entAttachmentToAttachment(&eAttachment)
+
+	return nil
+}
+
+// Open opens the file of the Attachment, the caller closes it
+func (c *Attachment) Open(ctx context.Context, dStores *datastore.Datastores) (io.ReadCloser, error) {
+	blob, err := dStores.BlobStore().Get(ctx, c.StorageKey)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			return nil, fmt.Errorf("%w: file is missing [attachment:%d]", ErrAttachmentNotFound, c.AttachmentID)
+		}
+
+		return nil, fmt.Errorf("ds.BlobStore().Get:%w [attachment:%d]", err, c.AttachmentID)
+	}
+
+	return blob, nil
+}
+
+// Delete deletes the metadata of the Attachment, then its file
+func (c *Attachment) Delete(ctx context.Context, dStores *datastore.Datastores) error {
+	eAttachment := attachmentToEntAttachment(c)
+
+	if err := dStores.AttachmentStore().Delete(&eAttachment); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAttachmentNotFound
+		}
+
+		return fmt.Errorf("ds.AttachmentStore().Delete:%w [attachment:%d]", err, c.AttachmentID)
+	}
+
+	if err := dStores.BlobStore().Delete(ctx, c.StorageKey); err != nil {
+		return fmt.Errorf("ds.BlobStore().Delete:%w [attachment:%d]", err, c.AttachmentID)
+	}
+
+	return nil
+}
+
+func (c *Attachment) validate(dStores *datastore.Datastores) error {
+	// only the name of the file is kept, not the path it was uploaded from
+	c.FileName = strings.TrimSpace(path.Base(strings.ReplaceAll(c.FileName, `\`, "/")))
+	if c.FileName == "" || c.FileName == "." || c.FileName == "/" {
+		return fmt.Errorf("%w: file name cannot be empty", ErrAttachmentInvalid)
+	}
+
+	if c.FileSize < 0 {
+		return fmt.Errorf("%w: file size %d is negative", ErrAttachmentInvalid, c.FileSize)
+	}
+
+	c.ContentType = strings.TrimSpace(c.ContentType)
+	if c.ContentType == "" {
+		c.ContentType = attachmentDefaultContentType
+	}
+
+	if _, err := RetrieveTransactionByID(dStores, c.TransactionID); err != nil {
+		return fmt.Errorf("RetrieveTransactionByID:%w", err)
+	}
+
+	return nil
+}
+
+// newAttachmentKey is a new storage key for a file attached to a transaction
+func newAttachmentKey(transactionID uint64) (string, error) {
+	random := make([]byte, attachmentKeyBytes)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("rand.Read:%w", err)
+	}
+
+	return fmt.Sprintf("transactions/%d/%s", transactionID, hex.EncodeToString(random)), nil
+}
+
+// RetrieveAttachmentByID retrieves an Attachment of a transaction, ErrAttachmentNotFound when it is attached to
+// another transaction
+func RetrieveAttachmentByID(dStores *datastore.Datastores, transactionID, attachmentID uint64) (*Attachment, error) {
+	eAttachment, err := dStores.AttachmentStore().RetrieveByID(attachmentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAttachmentNotFound
+		}
+
+		return nil, fmt.Errorf("ds.AttachmentStore().RetrieveByID:%w", err)
+	}
+
+	if eAttachment.TransactionID != transactionID {
+		return nil, ErrAttachmentNotFound
+	}
+
+	return entAttachmentToAttachment(eAttachment), nil
+}
+
+// RetrieveAttachmentsForTransaction retrieves the Attachments of a transaction in the order they were attached
+func RetrieveAttachmentsForTransaction(dStores *datastore.Datastores, transactionID uint64) ([]*Attachment, error) {
+	if _, err := RetrieveTransactionByID(dStores, transactionID); err != nil {
+		return nil, fmt.Errorf("RetrieveTransactionByID:%w", err)
+	}
+
+	eAttachments, err := dStores.AttachmentStore().RetrieveByTransactionID(transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("ds.AttachmentStore().RetrieveByTransactionID:%w", err)
+	}
+
+	attachments := make([]*Attachment, len(eAttachments))
+
+	for idx := range eAttachments {
+		attachments[idx] = entAttachmentToAttachment(eAttachments[idx])
+	}
+
+	return attachments, nil
+}
+
+// deleteAttachmentFiles deletes the files of attachments whose metadata is already gone, such as those of a deleted
+// transaction
+func deleteAttachmentFiles(ctx context.Context, dStores *datastore.Datastores,
+	eAttachments []*datastore.Attachment) error {
+	var errs []error
+
+	for _, eAttachment := range eAttachments {
+		if err := dStores.BlobStore().Delete(ctx, eAttachment.StorageKey); err != nil {
+			errs = append(errs, fmt.Errorf("ds.BlobStore().Delete:%w [attachment:%d]", err, eAttachment.AttachmentID))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func attachmentToEntAttachment(attachment *Attachment) datastore.Attachment {
+	return datastore.Attachment{
+		AttachmentID:  attachment.AttachmentID,
+		TransactionID: attachment.TransactionID,
+		FileName:      attachment.FileName,
+		ContentType:   attachment.ContentType,
+		FileSize:      attachment.FileSize,
+		FileSHA256:    attachment.FileSHA256,
+		StorageKey:    attachment.StorageKey,
+		CreatedDate:   attachment.CreatedDate,
+	}
+}
+
+func entAttachmentToAttachment(eAttachment *datastore.Attachment) *Attachment {
+	return &Attachment{
+		AttachmentID:  eAttachment.AttachmentID,
+		TransactionID: eAttachment.TransactionID,
+		FileName:      eAttachment.FileName,
+		ContentType:   eAttachment.ContentType,
+		FileSize:      eAttachment.FileSize,
+		FileSHA256:    eAttachment.FileSHA256,
+		StorageKey:    eAttachment.StorageKey,
+		CreatedDate:   eAttachment.CreatedDate,
+	}
+}
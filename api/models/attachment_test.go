@@ -0,0 +1,115 @@
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/blobstore"
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestAttachment_StoreOpenDelete(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	ctx := context.Background()
+
+	checking := Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	expense := Account{AccountName: "Office", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeExpense}
+	err = expense.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	txn := Transaction{TransactionCore: TransactionCore{TransactionComment: "Printer paper",
+		TransactionDate: time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)},
+		DebitCreditSet: []*TransactionDebitCredit{
+			&TransactionDebitCredit{AccountID: checking.AccountID,
+				DebitOrCredit:       datastore.AccountSignCredit,
+				TransactionDCAmount: 2499},
+			&TransactionDebitCredit{AccountID: expense.AccountID,
+				DebitOrCredit:       datastore.AccountSignDebit,
+				TransactionDCAmount: 2499},
+		},
+	}
+	err = txn.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	receipt := "%PDF-1.4 printer paper receipt"
+	sum := sha256.Sum256([]byte(receipt))
+
+	// only the base name of the uploaded file is kept
+	attachment := Attachment{TransactionID: txn.TransactionID, FileName: `C:\receipts\paper.pdf`,
+		ContentType: "application/pdf", FileSize: int64(len(receipt))}
+	err = attachment.Store(ctx, testDS, strings.NewReader(receipt))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(attachment.AttachmentID).NotTo(gomega.BeZero())
+	g.Expect(attachment.FileName).To(gomega.Equal("paper.pdf"))
+	g.Expect(attachment.FileSHA256).To(gomega.Equal(hex.EncodeToString(sum[:])))
+
+	unnamed := Attachment{TransactionID: txn.TransactionID, FileName: " ", FileSize: 1}
+	err = unnamed.Store(ctx, testDS, strings.NewReader("x"))
+	g.Expect(errors.Is(err, ErrAttachmentInvalid)).To(gomega.BeTrue())
+
+	orphan := Attachment{TransactionID: txn.TransactionID + 1000, FileName: "orphan.txt", FileSize: 1}
+	err = orphan.Store(ctx, testDS, strings.NewReader("x"))
+	g.Expect(errors.Is(err, ErrTransactionNotFound)).To(gomega.BeTrue())
+
+	note := Attachment{TransactionID: txn.TransactionID, FileName: "note.txt", FileSize: 5}
+	err = note.Store(ctx, testDS, strings.NewReader("paper"))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(note.ContentType).To(gomega.Equal("application/octet-stream"))
+
+	attachments, err := RetrieveAttachmentsForTransaction(testDS, txn.TransactionID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(attachments).To(gomega.HaveLen(2))
+	g.Expect(attachments[0].AttachmentID).To(gomega.Equal(attachment.AttachmentID))
+
+	retrieved, err := RetrieveAttachmentByID(testDS, txn.TransactionID, attachment.AttachmentID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	blob, err := retrieved.Open(ctx, testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	body, err := io.ReadAll(blob)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(blob.Close()).To(gomega.Succeed())
+	g.Expect(string(body)).To(gomega.Equal(receipt))
+
+	// an attachment is only found through its own transaction
+	_, err = RetrieveAttachmentByID(testDS, txn.TransactionID+1000, attachment.AttachmentID)
+	g.Expect(errors.Is(err, ErrAttachmentNotFound)).To(gomega.BeTrue())
+
+	ledger, err := RetrieveAccountLedger(testDS, &checking, &TransactionLedgerFilter{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ledger.Transactions).To(gomega.HaveLen(1))
+	g.Expect(ledger.Transactions[0].AttachmentCount).To(gomega.Equal(uint64(2)))
+
+	err = retrieved.Delete(ctx, testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	err = retrieved.Delete(ctx, testDS)
+	g.Expect(errors.Is(err, ErrAttachmentNotFound)).To(gomega.BeTrue())
+
+	_, err = testDS.BlobStore().Get(ctx, retrieved.StorageKey)
+	g.Expect(errors.Is(err, blobstore.ErrNotFound)).To(gomega.BeTrue())
+
+	// deleting the transaction deletes the files of its attachments
+	err = txn.Delete(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	_, err = testDS.BlobStore().Get(ctx, note.StorageKey)
+	g.Expect(errors.Is(err, blobstore.ErrNotFound)).To(gomega.BeTrue())
+}
@@ -0,0 +1,557 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+)
+
+// CategorizationRule is a rule of the rules engine.  Rules are applied in RuleOrder, then in the order they were
+// created.  A rule matches a transaction on its description, amount, source account and reference, and then sets the
+// account it is offset against, splits it between accounts, or rewrites its comment.
+type CategorizationRule struct {
+	RuleID    uint64
+	RuleName  string
+	RuleOrder int
+	IsEnabled bool
+	Match     datastore.RuleMatch
+	Action    datastore.RuleAction
+}
+
+// rulePercentTotal is the most the percentages of a split can add up to
+const rulePercentTotal = 100
+
+// rulePercentTolerance allows for percentages such as 33.33 that cannot add up to exactly 100 as floats
+const rulePercentTolerance = 1e-6
+
+var ErrCategorizationRuleNotFound = errors.New("categorization rule not found")
+var ErrCategorizationRuleInvalid = errors.New("categorization rule is invalid")
+
+// Store inserts a CategorizationRule, the rule must be valid before it is stored
+func (c *CategorizationRule) Store(dStores *datastore.Datastores) error {
+	if err := c.validate(dStores); err != nil {
+		return fmt.Errorf("c.validate:%w", err)
+	}
+
+	eRule := categorizationRuleToEntCategorizationRule(c)
+
+	if err := dStores.CategorizationRuleStore().Store(&eRule); err != nil {
+		return fmt.Errorf("ds.CategorizationRuleStore().Store:%w [CategorizationRule:%s]", err, eRule.RuleName)
+	}
+
+	*c = *entCategorizationRuleToCategorizationRule(&eRule)
+
+	return nil
+}
+
+// Update updates a CategorizationRule, the rule must be valid before it is stored
+func (c *CategorizationRule) Update(dStores *datastore.Datastores) error {
+	if err := c.validate(dStores); err != nil {
+		return fmt.Errorf("c.validate:%w", err)
+	}
+
+	eRule := categorizationRuleToEntCategorizationRule(c)
+
+	if err := dStores.CategorizationRuleStore().Update(&eRule); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCategorizationRuleNotFound
+		}
+
+		return fmt.Errorf("ds.CategorizationRuleStore().Update:%w [CategorizationRule:%s]", err, eRule.RuleName)
+	}
+
+	*c = *entCategorizationRuleToCategorizationRule(&eRule)
+
+	return nil
+}
+
+func (c *CategorizationRule) Delete(dStores *datastore.Datastores) error {
+	eRule := categorizationRuleToEntCategorizationRule(c)
+
+	if err := dStores.CategorizationRuleStore().Delete(&eRule); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCategorizationRuleNotFound
+		}
+
+		return fmt.Errorf("ds.CategorizationRuleStore().Delete:%w [CategorizationRule:%+v]", err, c)
+	}
+
+	return nil
+}
+
+func (c *CategorizationRule) validate(dStores *datastore.Datastores) error { //nolint:cyclop
+	if strings.TrimSpace(c.RuleName) == "" {
+		return fmt.Errorf("%w: name cannot be empty", ErrCategorizationRuleInvalid)
+	}
+
+	if _, err := compileDescriptionRegex(c.Match.DescriptionRegex); err != nil {
+		return err
+	}
+
+	if c.Match.MinAmount != nil && c.Match.MaxAmount != nil && *c.Match.MinAmount > *c.Match.MaxAmount {
+		return fmt.Errorf("%w: minAmount is more than maxAmount", ErrCategorizationRuleInvalid)
+	}
+
+	accountIDs := []uint64{c.Match.SourceAccountID}
+
+	switch c.Action.ActionType {
+	case datastore.RuleActionSetAccount:
+		if c.Action.OffsetAccountID == 0 {
+			return fmt.Errorf("%w: %s needs an offsetAccountID", ErrCategorizationRuleInvalid, c.Action.ActionType)
+		}
+
+		accountIDs = append(accountIDs, c.Action.OffsetAccountID)
+	case datastore.RuleActionSplit:
+		if err := validateRuleSplits(c.Action.Splits); err != nil {
+			return err
+		}
+
+		for _, split := range c.Action.Splits {
+			accountIDs = append(accountIDs, split.AccountID)
+		}
+	case datastore.RuleActionSetComment:
+		if strings.TrimSpace(c.Action.Comment) == "" {
+			return fmt.Errorf("%w: %s needs a comment", ErrCategorizationRuleInvalid, c.Action.ActionType)
+		}
+	default:
+		return fmt.Errorf("%w: unknown action %q", ErrCategorizationRuleInvalid, c.Action.ActionType)
+	}
+
+	for _, accountID := range accountIDs {
+		if accountID == 0 {
+			continue
+		}
+
+		if _, err := RetrieveAccountByID(dStores, accountID); err != nil {
+			return fmt.Errorf("RetrieveAccountByID:%w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateRuleSplits checks each split is either a fixed amount or a percentage, and the percentages are no more
+// than the whole
+func validateRuleSplits(splits []*datastore.RuleSplit) error {
+	if len(splits) == 0 {
+		return fmt.Errorf("%w: %s needs splits", ErrCategorizationRuleInvalid, datastore.RuleActionSplit)
+	}
+
+	var percentTotal float64
+
+	for _, split := range splits {
+		switch {
+		case split.AccountID == 0:
+			return fmt.Errorf("%w: a split needs an accountID", ErrCategorizationRuleInvalid)
+		case split.Percent < 0, split.Percent > rulePercentTotal:
+			return fmt.Errorf("%w: split percent %v is not between 0 and 100", ErrCategorizationRuleInvalid,
+				split.Percent)
+		case (split.Percent > 0) == (split.Amount > 0):
+			return fmt.Errorf("%w: a split needs either a percent or an amount", ErrCategorizationRuleInvalid)
+		}
+
+		percentTotal += split.Percent
+	}
+
+	if percentTotal > rulePercentTotal+rulePercentTolerance {
+		return fmt.Errorf("%w: split percents add up to %v", ErrCategorizationRuleInvalid, percentTotal)
+	}
+
+	return nil
+}
+
+// compileDescriptionRegex compiles the description regex of a rule, descriptions are matched without regard to case
+func compileDescriptionRegex(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	re, err := regexp.Compile("(?i)" + expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: descriptionRegex %w", ErrCategorizationRuleInvalid, err)
+	}
+
+	return re, nil
+}
+
+// RetrieveCategorizationRuleByID retrieves a CategorizationRule
+func RetrieveCategorizationRuleByID(dStores *datastore.Datastores, ruleID uint64) (*CategorizationRule, error) {
+	eRule, err := dStores.CategorizationRuleStore().RetrieveByID(ruleID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCategorizationRuleNotFound
+		}
+
+		return nil, fmt.Errorf("ds.CategorizationRuleStore().RetrieveByID:%w", err)
+	}
+
+	return entCategorizationRuleToCategorizationRule(eRule), nil
+}
+
+// RetrieveCategorizationRules retrieves all CategorizationRules in the order they are applied
+func RetrieveCategorizationRules(dStores *datastore.Datastores) ([]*CategorizationRule, error) {
+	eRules, err := dStores.CategorizationRuleStore().Retrieve()
+	if err != nil {
+		return nil, fmt.Errorf("ds.CategorizationRuleStore().Retrieve:%w", err)
+	}
+
+	rules := make([]*CategorizationRule, len(eRules))
+
+	for idx := range eRules {
+		rules[idx] = entCategorizationRuleToCategorizationRule(eRules[idx])
+	}
+
+	return rules, nil
+}
+
+// ruleSubject is what a rule is matched against, amount is in the sign of the source account, positive for money
+// into it
+type ruleSubject struct {
+	comment         string
+	reference       string
+	amount          int64
+	sourceAccountID uint64
+}
+
+// ruleOffset is an account the subject is offset against and its share of the amount, in the sign of the subject
+type ruleOffset struct {
+	accountID uint64
+	amount    int64
+}
+
+// ruleOutcome is what the rules do to a subject, offsets is nil when no rule sets the account
+type ruleOutcome struct {
+	comment string
+	offsets []*ruleOffset
+	ruleIDs []uint64
+}
+
+// ruleEngine applies the enabled rules in order.  The first matching rule that sets the account or splits decides
+// the offset, and the first matching rule that rewrites the comment decides the comment.  Every rule is matched
+// against the original description, so a rewritten comment does not change which rules match.
+type ruleEngine struct {
+	rules []*compiledRule
+}
+
+type compiledRule struct {
+	*CategorizationRule
+	description *regexp.Regexp
+}
+
+// newRuleEngine loads the enabled rules
+func newRuleEngine(dStores *datastore.Datastores) (*ruleEngine, error) {
+	rules, err := RetrieveCategorizationRules(dStores)
+	if err != nil {
+		return nil, fmt.Errorf("RetrieveCategorizationRules:%w", err)
+	}
+
+	engine := ruleEngine{rules: nil}
+
+	for _, rule := range rules {
+		if !rule.IsEnabled {
+			continue
+		}
+
+		description, err := compileDescriptionRegex(rule.Match.DescriptionRegex)
+		if err != nil {
+			return nil, fmt.Errorf("%w [CategorizationRule:%d]", err, rule.RuleID)
+		}
+
+		engine.rules = append(engine.rules, &compiledRule{CategorizationRule: rule, description: description})
+	}
+
+	return &engine, nil
+}
+
+// apply is the outcome of the rules for a subject, what a split does not allocate stays on offsetAccountID
+func (c *ruleEngine) apply(subject *ruleSubject, offsetAccountID uint64) *ruleOutcome {
+	outcome := ruleOutcome{comment: subject.comment, offsets: nil, ruleIDs: nil}
+	commentSet := false
+
+	for _, rule := range c.rules {
+		if commentSet && outcome.offsets != nil {
+			break
+		}
+
+		match, ok := rule.match(subject)
+		if !ok {
+			continue
+		}
+
+		switch rule.Action.ActionType {
+		case datastore.RuleActionSetComment:
+			if commentSet {
+				continue
+			}
+
+			outcome.comment = rule.comment(subject.comment, match)
+			commentSet = true
+		case datastore.RuleActionSetAccount:
+			if outcome.offsets != nil {
+				continue
+			}
+
+			outcome.offsets = []*ruleOffset{{accountID: rule.Action.OffsetAccountID, amount: subject.amount}}
+		case datastore.RuleActionSplit:
+			if outcome.offsets != nil {
+				continue
+			}
+
+			outcome.offsets = splitRuleOffsets(rule.Action.Splits, subject.amount, offsetAccountID)
+		default:
+			continue
+		}
+
+		outcome.ruleIDs = append(outcome.ruleIDs, rule.RuleID)
+	}
+
+	return &outcome
+}
+
+// match is true when every condition of the rule that is set matches, it returns the submatches of the description
+func (c *compiledRule) match(subject *ruleSubject) ([]int, bool) {
+	switch {
+	case c.Match.SourceAccountID != 0 && c.Match.SourceAccountID != subject.sourceAccountID,
+		c.Match.MinAmount != nil && subject.amount < *c.Match.MinAmount,
+		c.Match.MaxAmount != nil && subject.amount > *c.Match.MaxAmount,
+		c.Match.Reference != "" && !strings.EqualFold(strings.TrimSpace(c.Match.Reference),
+			strings.TrimSpace(subject.reference)):
+		return nil, false
+	case c.description == nil:
+		return nil, true
+	}
+
+	match := c.description.FindStringSubmatchIndex(subject.comment)
+
+	return match, match != nil
+}
+
+// comment is the rewritten comment, $1 and ${name} in it are the groups of the description regex
+func (c *compiledRule) comment(original string, match []int) string {
+	comment := c.Action.Comment
+	if c.description != nil {
+		comment = string(c.description.ExpandString(nil, comment, original, match))
+	}
+
+	if comment = strings.TrimSpace(comment); comment == "" {
+		return original
+	}
+
+	return comment
+}
+
+// splitRuleOffsets divides amount between the splits.  The fixed amounts are taken first, and the percentages are of
+// what is left after them.  When the percentages add up to 100 the last one takes the rounding, otherwise what is
+// not allocated is offset against offsetAccountID.
+func splitRuleOffsets(splits []*datastore.RuleSplit, amount int64, offsetAccountID uint64) []*ruleOffset {
+	sign, remaining := int64(1), amount
+	if amount < 0 {
+		sign, remaining = -1, -amount
+	}
+
+	var offsets []*ruleOffset
+
+	for _, split := range splits {
+		if split.Amount == 0 || remaining == 0 {
+			continue
+		}
+
+		share := min(int64(split.Amount), remaining) //nolint:gosec
+		offsets = append(offsets, &ruleOffset{accountID: split.AccountID, amount: sign * share})
+		remaining -= share
+	}
+
+	base := remaining
+
+	var (
+		percentTotal float64
+		lastPercent  *ruleOffset
+	)
+
+	for _, split := range splits {
+		if split.Percent == 0 {
+			continue
+		}
+
+		percentTotal += split.Percent
+
+		share := min(int64(math.Round(float64(base)*split.Percent/rulePercentTotal)), remaining)
+		if share == 0 {
+			continue
+		}
+
+		lastPercent = &ruleOffset{accountID: split.AccountID, amount: sign * share}
+		offsets = append(offsets, lastPercent)
+		remaining -= share
+	}
+
+	if remaining > 0 && lastPercent != nil && percentTotal >= rulePercentTotal-rulePercentTolerance {
+		lastPercent.amount += sign * remaining
+		remaining = 0
+	}
+
+	if remaining > 0 {
+		offsets = append(offsets, &ruleOffset{accountID: offsetAccountID, amount: sign * remaining})
+	}
+
+	return offsets
+}
+
+// CategorizeFilter selects the transactions CategorizeTransactions applies the rules to
+type CategorizeFilter struct {
+	// AccountID is the account the uncategorized transactions are offset against, the suspense account when it is 0
+	AccountID uint64
+	StartDate sql.NullTime
+	EndDate   sql.NullTime
+	// DryRun reports what would change without changing anything
+	DryRun bool
+}
+
+// CategorizationChange is a transaction the rules change, as it is and as it would be after the rules
+type CategorizationChange struct {
+	TransactionID   uint64
+	TransactionDate time.Time
+	RuleIDs         []uint64
+	Before          *Transaction
+	After           *Transaction
+}
+
+// CategorizationResult is what CategorizeTransactions changed, or would change on a dry run
+type CategorizationResult struct {
+	AccountID           uint64
+	DryRun              bool
+	TransactionsChecked int
+	Changes             []*CategorizationChange
+}
+
+// CategorizeTransactions applies the rules to the transactions offset against the uncategorized account.  A
+// transaction is only recategorized when it is a debit and a credit between a source account and the uncategorized
+// account, the source side is kept and the uncategorized side is replaced by what the rules set.
+func CategorizeTransactions(dStores *datastore.Datastores, filter *CategorizeFilter) (*CategorizationResult, error) {
+	accountID := filter.AccountID
+	if accountID == 0 {
+		suspense, err := RetrieveSuspenseAccount(dStores)
+		if err != nil {
+			return nil, fmt.Errorf("RetrieveSuspenseAccount:%w", err)
+		}
+
+		accountID = suspense.AccountID
+	} else if _, err := RetrieveAccountByID(dStores, accountID); err != nil {
+		return nil, fmt.Errorf("RetrieveAccountByID:%w", err)
+	}
+
+	engine, err := newRuleEngine(dStores)
+	if err != nil {
+		return nil, err
+	}
+
+	eTxns, err := dStores.TransactionStore().GetByAccountForDates(accountID, filter.StartDate, filter.EndDate)
+	if err != nil {
+		return nil, fmt.Errorf("TransactionStore().GetByAccountForDates:%w", err)
+	}
+
+	result := CategorizationResult{AccountID: accountID, DryRun: filter.DryRun, TransactionsChecked: len(eTxns),
+		Changes: []*CategorizationChange{}}
+
+	for _, eTxn := range eTxns {
+		txn, err := RetrieveTransactionByID(dStores, eTxn.TransactionID)
+		if err != nil {
+			return nil, fmt.Errorf("RetrieveTransactionByID:%w", err)
+		}
+
+		change := categorizeTransaction(engine, txn, accountID)
+		if change == nil {
+			continue
+		}
+
+		if !filter.DryRun {
+			if err = change.After.Update(dStores); err != nil {
+				return nil, fmt.Errorf("change.After.Update:%w [transaction:%d]", err, txn.TransactionID)
+			}
+		}
+
+		result.Changes = append(result.Changes, change)
+	}
+
+	return &result, nil
+}
+
+// categorizeTransaction is the change the rules make to a transaction, nil when they change nothing
+func categorizeTransaction(engine *ruleEngine, txn *Transaction, accountID uint64) *CategorizationChange {
+	if len(txn.DebitCreditSet) != 2 { //nolint:mnd
+		return nil
+	}
+
+	source, uncategorized := txn.DebitCreditSet[0], txn.DebitCreditSet[1]
+	if source.AccountID == accountID {
+		source, uncategorized = uncategorized, source
+	}
+
+	if uncategorized.AccountID != accountID || source.AccountID == accountID {
+		return nil
+	}
+
+	amount := int64(source.TransactionDCAmount) //nolint:gosec
+	if source.DebitOrCredit == datastore.AccountSignCredit {
+		amount = -amount
+	}
+
+	outcome := engine.apply(&ruleSubject{comment: txn.TransactionComment, reference: txn.TransactionReference,
+		amount: amount, sourceAccountID: source.AccountID}, accountID)
+
+	unchangedOffset := outcome.offsets == nil ||
+		(len(outcome.offsets) == 1 && outcome.offsets[0].accountID == accountID)
+	if unchangedOffset && outcome.comment == txn.TransactionComment {
+		return nil
+	}
+
+	after := Transaction{TransactionCore: txn.TransactionCore, DebitCreditSet: []*TransactionDebitCredit{
+		{TransactionDCID: 0, TransactionID: txn.TransactionID, AccountID: source.AccountID,
+			TransactionDCAmount: source.TransactionDCAmount, DebitOrCredit: source.DebitOrCredit},
+	}}
+	after.TransactionComment = outcome.comment
+
+	if unchangedOffset {
+		after.DebitCreditSet = append(after.DebitCreditSet, importDebitCredit(accountID, -amount))
+	} else {
+		for _, offset := range outcome.offsets {
+			after.DebitCreditSet = append(after.DebitCreditSet, importDebitCredit(offset.accountID, -offset.amount))
+		}
+	}
+
+	for _, myDC := range after.DebitCreditSet {
+		myDC.TransactionID = txn.TransactionID
+	}
+
+	after.IsSplit = len(after.DebitCreditSet) > 2 //nolint:mnd
+
+	return &CategorizationChange{TransactionID: txn.TransactionID, TransactionDate: txn.TransactionDate,
+		RuleIDs: outcome.ruleIDs, Before: txn, After: &after}
+}
+
+func categorizationRuleToEntCategorizationRule(myRule *CategorizationRule) datastore.CategorizationRule {
+	return datastore.CategorizationRule{
+		RuleID:    myRule.RuleID,
+		RuleName:  strings.TrimSpace(myRule.RuleName),
+		RuleOrder: myRule.RuleOrder,
+		IsEnabled: myRule.IsEnabled,
+		RuleBody:  datastore.CategorizationRuleBody{Match: myRule.Match, Action: myRule.Action},
+	}
+}
+
+func entCategorizationRuleToCategorizationRule(eRule *datastore.CategorizationRule) *CategorizationRule {
+	return &CategorizationRule{
+		RuleID:    eRule.RuleID,
+		RuleName:  eRule.RuleName,
+		RuleOrder: eRule.RuleOrder,
+		IsEnabled: eRule.IsEnabled,
+		Match:     eRule.RuleBody.Match,
+		Action:    eRule.RuleBody.Action,
+	}
+}
@@ -0,0 +1,238 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/importer"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestCategorizationRule_SplitOffsets(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	// fixed amounts first, the percentages are of the rest and what is left stays on the offset account
+	offsets := splitRuleOffsets([]*datastore.RuleSplit{
+		{AccountID: 2, Percent: 50},
+		{AccountID: 1, Amount: 2500},
+		{AccountID: 3, Percent: 25},
+	}, -10000, 9)
+	g.Expect(offsets).To(gomega.Equal([]*ruleOffset{
+		{accountID: 1, amount: -2500},
+		{accountID: 2, amount: -3750},
+		{accountID: 3, amount: -1875},
+		{accountID: 9, amount: -1875},
+	}))
+
+	// percentages of the whole leave the rounding with the last of them
+	offsets = splitRuleOffsets([]*datastore.RuleSplit{
+		{AccountID: 1, Percent: 33.33},
+		{AccountID: 2, Percent: 33.33},
+		{AccountID: 3, Percent: 33.34},
+	}, 100, 9)
+	g.Expect(offsets).To(gomega.Equal([]*ruleOffset{
+		{accountID: 1, amount: 33},
+		{accountID: 2, amount: 33},
+		{accountID: 3, amount: 34},
+	}))
+
+	// a fixed amount is no more than the amount
+	offsets = splitRuleOffsets([]*datastore.RuleSplit{{AccountID: 1, Amount: 5000}}, 1000, 9)
+	g.Expect(offsets).To(gomega.Equal([]*ruleOffset{{accountID: 1, amount: 1000}}))
+}
+
+func TestCategorizationRule_Apply(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	newRule := func(ruleID uint64, match datastore.RuleMatch, action datastore.RuleAction) *compiledRule {
+		description, err := compileDescriptionRegex(match.DescriptionRegex)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		return &compiledRule{CategorizationRule: &CategorizationRule{RuleID: ruleID, RuleName: "rule", IsEnabled: true,
+			Match: match, Action: action}, description: description}
+	}
+
+	maxAmount := int64(-1)
+	engine := ruleEngine{rules: []*compiledRule{
+		newRule(1, datastore.RuleMatch{DescriptionRegex: `(\w+) payroll`},
+			datastore.RuleAction{ActionType: datastore.RuleActionSetComment, Comment: "Salary from $1"}),
+		newRule(2, datastore.RuleMatch{DescriptionRegex: "home depot", MaxAmount: &maxAmount},
+			datastore.RuleAction{ActionType: datastore.RuleActionSetAccount, OffsetAccountID: 20}),
+		newRule(3, datastore.RuleMatch{Reference: "1042", SourceAccountID: 10},
+			datastore.RuleAction{ActionType: datastore.RuleActionSplit, Splits: []*datastore.RuleSplit{
+				{AccountID: 30, Amount: 20000}, {AccountID: 31, Percent: 100}}}),
+		// a later rule does not change the account a rule before it set
+		newRule(4, datastore.RuleMatch{DescriptionRegex: "depot"},
+			datastore.RuleAction{ActionType: datastore.RuleActionSetAccount, OffsetAccountID: 21}),
+	}}
+
+	outcome := engine.apply(&ruleSubject{comment: "ACME PAYROLL", amount: 250000, sourceAccountID: 10}, 9)
+	g.Expect(outcome.comment).To(gomega.Equal("Salary from ACME"))
+	g.Expect(outcome.offsets).To(gomega.BeNil())
+	g.Expect(outcome.ruleIDs).To(gomega.Equal([]uint64{1}))
+
+	outcome = engine.apply(&ruleSubject{comment: "HOME DEPOT #1234", amount: -12750, sourceAccountID: 10}, 9)
+	g.Expect(outcome.comment).To(gomega.Equal("HOME DEPOT #1234"))
+	g.Expect(outcome.offsets).To(gomega.Equal([]*ruleOffset{{accountID: 20, amount: -12750}}))
+	g.Expect(outcome.ruleIDs).To(gomega.Equal([]uint64{2}))
+
+	// a refund is outside the amount range of rule 2
+	outcome = engine.apply(&ruleSubject{comment: "HOME DEPOT REFUND", amount: 500, sourceAccountID: 10}, 9)
+	g.Expect(outcome.offsets).To(gomega.Equal([]*ruleOffset{{accountID: 21, amount: 500}}))
+
+	outcome = engine.apply(&ruleSubject{comment: "Rent", reference: "1042", amount: -120000, sourceAccountID: 10}, 9)
+	g.Expect(outcome.offsets).To(gomega.Equal([]*ruleOffset{
+		{accountID: 30, amount: -20000},
+		{accountID: 31, amount: -100000},
+	}))
+
+	// the reference rule is only for its source account
+	outcome = engine.apply(&ruleSubject{comment: "Rent", reference: "1042", amount: -120000, sourceAccountID: 11}, 9)
+	g.Expect(outcome.offsets).To(gomega.BeNil())
+	g.Expect(outcome.ruleIDs).To(gomega.BeEmpty())
+}
+
+func TestCategorizationRule_ImportAndCategorize(t *testing.T) { //nolint:funlen
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	storeAccount := func(name string, accountType datastore.AccountType) *Account {
+		account := Account{AccountName: name, AccountSign: datastore.AccountSignDebit, AccountType: accountType}
+		err := account.Store(testDS)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		return &account
+	}
+
+	checking := storeAccount("Checking", datastore.AccountTypeAsset)
+	home := storeAccount("Home", datastore.AccountTypeExpense)
+	rent := storeAccount("Rent", datastore.AccountTypeExpense)
+	utilities := storeAccount("Utilities", datastore.AccountTypeExpense)
+
+	// rules are validated before they are stored
+	invalid := CategorizationRule{RuleName: "Broken", IsEnabled: true,
+		Match:  datastore.RuleMatch{DescriptionRegex: "home ("},
+		Action: datastore.RuleAction{ActionType: datastore.RuleActionSetAccount, OffsetAccountID: home.AccountID}}
+	err := invalid.Store(testDS)
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(ErrCategorizationRuleInvalid.Error())))
+
+	invalid.Match.DescriptionRegex = "home"
+	invalid.Action = datastore.RuleAction{ActionType: datastore.RuleActionSplit, Splits: []*datastore.RuleSplit{
+		{AccountID: home.AccountID, Percent: 60}, {AccountID: rent.AccountID, Percent: 50}}}
+	err = invalid.Store(testDS)
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(ErrCategorizationRuleInvalid.Error())))
+
+	invalid.Action = datastore.RuleAction{ActionType: datastore.RuleActionSetAccount, OffsetAccountID: 999999}
+	err = invalid.Store(testDS)
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(ErrAccountNotFound.Error())))
+
+	maxAmount := int64(-1)
+	rules := []*CategorizationRule{
+		{RuleName: "Home Depot", RuleOrder: 10, IsEnabled: true,
+			Match:  datastore.RuleMatch{DescriptionRegex: "home depot", MaxAmount: &maxAmount},
+			Action: datastore.RuleAction{ActionType: datastore.RuleActionSetAccount, OffsetAccountID: home.AccountID}},
+		{RuleName: "Rent check", RuleOrder: 20, IsEnabled: true,
+			Match: datastore.RuleMatch{Reference: "1042", SourceAccountID: checking.AccountID},
+			Action: datastore.RuleAction{ActionType: datastore.RuleActionSplit, Splits: []*datastore.RuleSplit{
+				{AccountID: utilities.AccountID, Amount: 20000}, {AccountID: rent.AccountID, Percent: 100}}}},
+		{RuleName: "Payroll", RuleOrder: 5, IsEnabled: true,
+			Match:  datastore.RuleMatch{DescriptionRegex: `(\w+) payroll`},
+			Action: datastore.RuleAction{ActionType: datastore.RuleActionSetComment, Comment: "Salary from $1"}},
+		{RuleName: "Disabled", RuleOrder: 1, IsEnabled: false,
+			Match:  datastore.RuleMatch{DescriptionRegex: "payroll"},
+			Action: datastore.RuleAction{ActionType: datastore.RuleActionSetAccount, OffsetAccountID: home.AccountID}},
+	}
+
+	for _, rule := range rules {
+		err = rule.Store(testDS)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+
+	stored, err := RetrieveCategorizationRules(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(stored).To(gomega.HaveLen(4))
+	g.Expect(stored[0].RuleName).To(gomega.Equal("Disabled"))
+	g.Expect(stored[1].RuleName).To(gomega.Equal("Payroll"))
+	g.Expect(stored[3].Action.Splits).To(gomega.HaveLen(2))
+
+	myImport, err := NewImport(testDS, parseTestOFX(g), importer.FormatOFX, "bank_v1.ofx",
+		&ImportAccounts{AccountID: checking.AccountID})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	err = myImport.Post(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	suspense, err := RetrieveSuspenseAccount(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	offsets := func(transactionID uint64) map[uint64]uint64 {
+		txn, err := RetrieveTransactionByID(testDS, transactionID)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		amounts := make(map[uint64]uint64)
+
+		for _, dc := range txn.DebitCreditSet {
+			if dc.AccountID != checking.AccountID {
+				amounts[dc.AccountID] = dc.TransactionDCAmount
+			}
+		}
+
+		return amounts
+	}
+
+	g.Expect(offsets(uint64(myImport.Lines[0].TransactionID.Int64))).To(gomega.Equal(
+		map[uint64]uint64{home.AccountID: 12750}))
+	g.Expect(offsets(uint64(myImport.Lines[1].TransactionID.Int64))).To(gomega.Equal(
+		map[uint64]uint64{utilities.AccountID: 20000, rent.AccountID: 100000}))
+
+	// the payroll comment is rewritten, and without an account rule it stays uncategorized
+	payroll, err := RetrieveTransactionByID(testDS, uint64(myImport.Lines[2].TransactionID.Int64))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(payroll.TransactionComment).To(gomega.Equal("Salary from ACME"))
+	g.Expect(offsets(payroll.TransactionID)).To(gomega.Equal(map[uint64]uint64{suspense.AccountID: 250000}))
+
+	// a transaction entered against the suspense account before the rules existed
+	manual := Transaction{TransactionCore: TransactionCore{TransactionComment: "Home Depot garden centre",
+		TransactionDate: time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)},
+		DebitCreditSet: []*TransactionDebitCredit{
+			{AccountID: checking.AccountID, DebitOrCredit: datastore.AccountSignCredit, TransactionDCAmount: 5000},
+			{AccountID: suspense.AccountID, DebitOrCredit: datastore.AccountSignDebit, TransactionDCAmount: 5000},
+		},
+	}
+	err = manual.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	filter := CategorizeFilter{DryRun: true}
+
+	result, err := CategorizeTransactions(testDS, &filter)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(result.AccountID).To(gomega.Equal(suspense.AccountID))
+	g.Expect(result.TransactionsChecked).To(gomega.Equal(2))
+	g.Expect(result.Changes).To(gomega.HaveLen(1))
+	g.Expect(result.Changes[0].TransactionID).To(gomega.Equal(manual.TransactionID))
+	g.Expect(result.Changes[0].After.DebitCreditSet[1].AccountID).To(gomega.Equal(home.AccountID))
+
+	// a dry run changes nothing
+	g.Expect(offsets(manual.TransactionID)).To(gomega.Equal(map[uint64]uint64{suspense.AccountID: 5000}))
+
+	// the date range leaves out the manual transaction
+	filter.EndDate = sql.NullTime{Time: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), Valid: true}
+	result, err = CategorizeTransactions(testDS, &filter)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(result.Changes).To(gomega.BeEmpty())
+
+	result, err = CategorizeTransactions(testDS, &CategorizeFilter{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(result.Changes).To(gomega.HaveLen(1))
+	g.Expect(offsets(manual.TransactionID)).To(gomega.Equal(map[uint64]uint64{home.AccountID: 5000}))
+
+	homeAccount, err := RetrieveAccountByID(testDS, home.AccountID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(homeAccount.AccountBalance).To(gomega.Equal(int64(12750 + 5000)))
+}
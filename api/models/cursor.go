@@ -0,0 +1,53 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errCursorMalformed = errors.New("malformed cursor")
+
+// encodeCursor makes an opaque page cursor from a position in an ordering by date then ids
+func encodeCursor(date time.Time, ids ...uint64) string {
+	parts := make([]string, 0, len(ids)+1)
+	parts = append(parts, strconv.FormatInt(date.UnixNano(), 10))
+
+	for _, id := range ids {
+		parts = append(parts, strconv.FormatUint(id, 10))
+	}
+
+	return base64.RawURLEncoding.EncodeToString([]byte(strings.Join(parts, ".")))
+}
+
+// decodeCursor reverses encodeCursor, idCount is the number of ids the cursor must hold
+func decodeCursor(cursor string, idCount int) (time.Time, []uint64, error) {
+	position, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("base64.DecodeString:%w", err)
+	}
+
+	parts := strings.Split(string(position), ".")
+	if len(parts) != idCount+1 {
+		return time.Time{}, nil, fmt.Errorf("%w: [cursor:%s]", errCursorMalformed, cursor)
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("strconv.ParseInt:%w", err)
+	}
+
+	ids := make([]uint64, idCount)
+
+	for idx := range ids {
+		ids[idx], err = strconv.ParseUint(parts[idx+1], 10, 64)
+		if err != nil {
+			return time.Time{}, nil, fmt.Errorf("strconv.ParseUint:%w", err)
+		}
+	}
+
+	return time.Unix(0, nanos).UTC(), ids, nil
+}
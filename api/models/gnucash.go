@@ -0,0 +1,330 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/importer"
+)
+
+// GnuCashImportSummary is the outcome of importing a GnuCash book, with the balance of each account in GnuCash
+// and in the ledger after the import
+type GnuCashImportSummary struct {
+	AccountsCreated      int
+	AccountsReused       int
+	TransactionsImported int
+	// TransactionsSkipped are voided transactions whose splits are all zero
+	TransactionsSkipped int
+	Accounts            []*GnuCashAccountSummary
+	// Balanced is true when every account balance matches GnuCash
+	Balanced bool
+}
+
+// GnuCashAccountSummary compares the balance of an account, its own lines without subaccounts, in the sign of the
+// account
+type GnuCashAccountSummary struct {
+	GnuCashID       string
+	GnuCashType     string
+	AccountID       uint64
+	AccountFullName string
+	GnuCashBalance  int64
+	LedgerBalance   int64
+	Difference      int64
+}
+
+// gnuCashAccountTypes maps the GnuCash account types onto AccountType
+var gnuCashAccountTypes = map[string]datastore.AccountType{ //nolint:gochecknoglobals
+	"ASSET":      datastore.AccountTypeAsset,
+	"BANK":       datastore.AccountTypeAsset,
+	"CASH":       datastore.AccountTypeAsset,
+	"STOCK":      datastore.AccountTypeAsset,
+	"MUTUAL":     datastore.AccountTypeAsset,
+	"RECEIVABLE": datastore.AccountTypeAsset,
+	"TRADING":    datastore.AccountTypeAsset,
+	"CREDIT":     datastore.AccountTypeLiability,
+	"LIABILITY":  datastore.AccountTypeLiability,
+	"PAYABLE":    datastore.AccountTypeLiability,
+	"EQUITY":     datastore.AccountTypeEquity,
+	"INCOME":     datastore.AccountTypeIncome,
+	"EXPENSE":    datastore.AccountTypeExpense,
+}
+
+var ErrGnuCashAccountTypeInvalid = errors.New("gnucash account type is not supported")
+var ErrGnuCashTransactionInvalid = errors.New("gnucash transaction is invalid")
+
+// gnuCashImport is the state of an import, the GnuCash account ids mapped to accounts
+type gnuCashImport struct {
+	dStores  *datastore.Datastores
+	book     *importer.GnuCashBook
+	accounts map[string]*Account
+	children map[string][]*importer.GnuCashAccount
+	summary  GnuCashImportSummary
+}
+
+// ImportGnuCashBook recreates the account hierarchy of a GnuCash book and imports its transactions.  The children
+// of the GnuCash root are top level accounts, with their type mapped onto AccountType, and their subaccounts are
+// created through Account.Store so they take the type of their parent.  An account that already exists with the
+// same name under the same parent is reused.  Each split is reconciled on its own line, and a transaction is
+// reconciled when all of its splits were.  The book is imported in one database transaction, so when an account or
+// transaction fails to import nothing of the book is kept.
+func ImportGnuCashBook(dStores *datastore.Datastores, book *importer.GnuCashBook) (*GnuCashImportSummary, error) {
+	var summary *GnuCashImportSummary
+
+	err := dStores.InTransaction(func(txStores *datastore.Datastores) error {
+		var err error
+
+		summary, err = importGnuCashBook(txStores, book)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// importGnuCashBook imports a book with the Datastores of its database transaction, it stops at the first failure
+func importGnuCashBook(dStores *datastore.Datastores, book *importer.GnuCashBook) (*GnuCashImportSummary, error) {
+	myImport := gnuCashImport{
+		dStores:  dStores,
+		book:     book,
+		accounts: make(map[string]*Account),
+		children: make(map[string][]*importer.GnuCashAccount),
+		summary:  GnuCashImportSummary{}, //nolint:exhaustruct
+	}
+
+	known := make(map[string]bool, len(book.Accounts))
+	for _, gncAccount := range book.Accounts {
+		known[gncAccount.ID] = true
+	}
+
+	var topLevel []*importer.GnuCashAccount
+
+	for _, gncAccount := range book.Accounts {
+		switch {
+		case gncAccount.Type == importer.GnuCashAccountTypeRoot:
+		case known[gncAccount.ParentID] && !myImport.isRoot(gncAccount.ParentID):
+			myImport.children[gncAccount.ParentID] = append(myImport.children[gncAccount.ParentID], gncAccount)
+		default:
+			topLevel = append(topLevel, gncAccount)
+		}
+	}
+
+	for _, gncAccount := range topLevel {
+		if err := myImport.storeAccount(gncAccount, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, gncTxn := range book.Transactions {
+		if err := myImport.storeTransaction(gncTxn); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := myImport.summarize(); err != nil {
+		return nil, err
+	}
+
+	return &myImport.summary, nil
+}
+
+func (c *gnuCashImport) isRoot(gncAccountID string) bool {
+	for _, gncAccount := range c.book.Accounts {
+		if gncAccount.ID == gncAccountID {
+			return gncAccount.Type == importer.GnuCashAccountTypeRoot
+		}
+	}
+
+	return false
+}
+
+// storeAccount stores an account and its subaccounts, parentID is 0 for a top level account
+func (c *gnuCashImport) storeAccount(gncAccount *importer.GnuCashAccount, parentID uint64) error {
+	account, err := findAccountByName(c.dStores, parentID, gncAccount.Name)
+	if err != nil {
+		return fmt.Errorf("findAccountByName:%w", err)
+	}
+
+	if account != nil {
+		c.summary.AccountsReused++
+	} else {
+		account = &Account{ //nolint:exhaustruct
+			AccountParent: parentID,
+			AccountName:   gncAccount.Name,
+			AccountMemo:   gncAccount.Description,
+		}
+
+		if parentID == 0 {
+			accountType, ok := gnuCashAccountTypes[gncAccount.Type]
+			if !ok {
+				return fmt.Errorf("%w: %s [account:%s]", ErrGnuCashAccountTypeInvalid, gncAccount.Type,
+					gncAccount.Name)
+			}
+
+			account.AccountType = accountType
+		}
+
+		if err = account.Store(c.dStores); err != nil {
+			return fmt.Errorf("account.Store:%w [account:%s]", err, gncAccount.Name)
+		}
+
+		c.summary.AccountsCreated++
+	}
+
+	c.accounts[gncAccount.ID] = account
+
+	for _, child := range c.children[gncAccount.ID] {
+		if err = c.storeAccount(child, account.AccountID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// storeTransaction stores a GnuCash transaction, a transaction whose splits are all zero is skipped
+func (c *gnuCashImport) storeTransaction(gncTxn *importer.GnuCashTransaction) error {
+	txn, err := c.transaction(gncTxn)
+	if err != nil {
+		return fmt.Errorf("%w: %w [transaction:%s %s]", ErrGnuCashTransactionInvalid, err, gncTxn.ID,
+			gncTxn.Description)
+	}
+
+	if txn == nil {
+		c.summary.TransactionsSkipped++
+
+		return nil
+	}
+
+	if err = txn.validate(); err != nil {
+		return fmt.Errorf("%w: %w [transaction:%s %s]", ErrGnuCashTransactionInvalid, err, gncTxn.ID,
+			gncTxn.Description)
+	}
+
+	if err = txn.Store(c.dStores); err != nil {
+		return fmt.Errorf("txn.Store:%w [transaction:%s]", err, gncTxn.ID)
+	}
+
+	c.summary.TransactionsImported++
+
+	return nil
+}
+
+var errGnuCashSplitAccountUnknown = errors.New("split account is not in the book")
+
+// transaction is the Transaction a GnuCash transaction imports as, nil when all of its splits are zero
+func (c *gnuCashImport) transaction(gncTxn *importer.GnuCashTransaction) (*Transaction, error) {
+	txn := Transaction{
+		TransactionCore: TransactionCore{
+			TransactionID:            0,
+			TransactionDate:          gncTxn.DatePosted,
+			TransactionReconcileDate: sql.NullTime{},
+			TransactionComment:       gncTxn.Comment(),
+			TransactionAmount:        0,
+			TransactionReference:     gncTxn.Reference(),
+			IsReconciled:             false,
+			IsSplit:                  false,
+		},
+		DebitCreditSet: nil,
+	}
+
+	for _, split := range gncTxn.Splits {
+		account, ok := c.accounts[split.AccountID]
+		if !ok {
+			return nil, fmt.Errorf("%w [split:%s]", errGnuCashSplitAccountUnknown, split.ID)
+		}
+
+		amount := split.Amount(account.AccountDecimals)
+		if amount == 0 {
+			continue
+		}
+
+		debitCredit := importDebitCredit(account.AccountID, amount)
+		txn.DebitCreditSet = append(txn.DebitCreditSet, debitCredit)
+
+		if split.IsReconciled() {
+			reconcileDate := split.ReconcileDate
+			if reconcileDate.IsZero() {
+				reconcileDate = gncTxn.DatePosted
+			}
+
+			debitCredit.IsReconciled = true
+			debitCredit.TransactionReconcileDate = sql.NullTime{Time: reconcileDate, Valid: true}
+
+			if !txn.TransactionReconcileDate.Valid || reconcileDate.After(txn.TransactionReconcileDate.Time) {
+				txn.TransactionReconcileDate = sql.NullTime{Time: reconcileDate, Valid: true}
+			}
+		}
+	}
+
+	if len(txn.DebitCreditSet) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	txn.IsSplit = len(txn.DebitCreditSet) > 2 //nolint:mnd
+
+	return &txn, nil
+}
+
+// summarize compares the balance of each account with the total of its GnuCash splits
+func (c *gnuCashImport) summarize() error {
+	totals := make(map[string]*big.Rat)
+
+	for _, gncTxn := range c.book.Transactions {
+		for _, split := range gncTxn.Splits {
+			if totals[split.AccountID] == nil {
+				totals[split.AccountID] = new(big.Rat)
+			}
+
+			totals[split.AccountID].Add(totals[split.AccountID], split.Value)
+		}
+	}
+
+	c.summary.Balanced = true
+
+	for _, gncAccount := range c.book.Accounts {
+		imported, ok := c.accounts[gncAccount.ID]
+		if !ok {
+			continue
+		}
+
+		account, err := RetrieveAccountByID(c.dStores, imported.AccountID)
+		if err != nil {
+			return fmt.Errorf("RetrieveAccountByID:%w", err)
+		}
+
+		var gncBalance int64
+
+		if total, ok := totals[gncAccount.ID]; ok {
+			gncBalance = importer.RoundRat(total, account.AccountDecimals)
+		}
+
+		// GnuCash values debit positive
+		if account.AccountSign == datastore.AccountSignCredit {
+			gncBalance = -gncBalance
+		}
+
+		accountSummary := GnuCashAccountSummary{
+			GnuCashID:       gncAccount.ID,
+			GnuCashType:     gncAccount.Type,
+			AccountID:       account.AccountID,
+			AccountFullName: account.AccountFullName,
+			GnuCashBalance:  gncBalance,
+			LedgerBalance:   account.AccountSubtotal,
+			Difference:      account.AccountSubtotal - gncBalance,
+		}
+
+		if accountSummary.Difference != 0 {
+			c.summary.Balanced = false
+		}
+
+		c.summary.Accounts = append(c.summary.Accounts, &accountSummary)
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/importer"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func parseTestGnuCash(g *gomega.WithT) *importer.GnuCashBook {
+	file, err := os.Open("../importer/testdata/book.gnucash")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	defer file.Close()
+
+	book, err := importer.ParseGnuCash(file)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	return book
+}
+
+func TestImportGnuCashBook(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	summary, err := ImportGnuCashBook(testDS, parseTestGnuCash(g))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(summary.AccountsCreated).To(gomega.Equal(5))
+	g.Expect(summary.AccountsReused).To(gomega.Equal(0))
+	g.Expect(summary.TransactionsImported).To(gomega.Equal(3))
+	g.Expect(summary.TransactionsSkipped).To(gomega.Equal(1))
+	g.Expect(summary.Balanced).To(gomega.BeTrue())
+	g.Expect(summary.Accounts).To(gomega.HaveLen(5))
+
+	balances := make(map[string]*GnuCashAccountSummary)
+	for _, account := range summary.Accounts {
+		balances[account.AccountFullName] = account
+	}
+
+	g.Expect(balances).To(gomega.HaveKey("Assets:Checking Account"))
+	g.Expect(balances["Assets:Checking Account"].LedgerBalance).To(gomega.Equal(int64(295475)))
+	g.Expect(balances["Salary"].GnuCashBalance).To(gomega.Equal(int64(300000)))
+	g.Expect(balances["Salary"].LedgerBalance).To(gomega.Equal(int64(300000)))
+	g.Expect(balances["Credit Card"].LedgerBalance).To(gomega.Equal(int64(0)))
+	g.Expect(balances["Groceries"].Difference).To(gomega.Equal(int64(0)))
+
+	// a BANK subaccount takes the type of its ASSET parent, CREDIT maps to LIABILITY
+	checking, err := RetrieveAccountByID(testDS, balances["Assets:Checking Account"].AccountID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(checking.AccountType).To(gomega.Equal(datastore.AccountTypeAsset))
+	g.Expect(checking.AccountMemo).To(gomega.Equal("Everyday checking"))
+
+	card, err := RetrieveAccountByID(testDS, balances["Credit Card"].AccountID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(card.AccountType).To(gomega.Equal(datastore.AccountTypeLiability))
+	g.Expect(card.AccountSign).To(gomega.Equal(datastore.AccountSignCredit))
+
+	// importing the book again reuses the accounts
+	summary, err = ImportGnuCashBook(testDS, parseTestGnuCash(g))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(summary.AccountsCreated).To(gomega.Equal(0))
+	g.Expect(summary.AccountsReused).To(gomega.Equal(5))
+}
+
+func TestImportGnuCashBook_RolledBack(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	// the last transaction posts to an account that is not in the book
+	book := parseTestGnuCash(g)
+	book.Transactions = append(book.Transactions, &importer.GnuCashTransaction{ID: "bad", Num: "",
+		DatePosted: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Description: "Unknown account",
+		Splits: []*importer.GnuCashSplit{{ID: "split", AccountID: "missing", Memo: "", ReconciledState: "n",
+			ReconcileDate: time.Time{}, Value: big.NewRat(100, 1)}}})
+
+	_, err := ImportGnuCashBook(testDS, book)
+	g.Expect(err).To(gomega.MatchError(ErrGnuCashTransactionInvalid))
+
+	// nothing of the book is kept
+	accounts, err := RetrieveAccounts(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(accounts).To(gomega.BeEmpty())
+
+	journal, err := RetrieveJournal(testDS, &TransactionJournalFilter{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(journal.Transactions).To(gomega.BeEmpty())
+}
+
+func TestImportGnuCashBook_Reconciled(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	_, err := ImportGnuCashBook(testDS, parseTestGnuCash(g))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	journal, err := RetrieveJournal(testDS, &TransactionJournalFilter{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(journal.Transactions).To(gomega.HaveLen(3))
+
+	// each transaction has a split that is not reconciled, so only the reconciled splits are
+	reconciledLines := make(map[string]int)
+	for _, txn := range journal.Transactions {
+		g.Expect(txn.IsReconciled).To(gomega.BeFalse())
+
+		for _, debitCredit := range txn.DebitCreditSet {
+			if debitCredit.IsReconciled {
+				reconciledLines[txn.TransactionComment]++
+			}
+		}
+	}
+
+	g.Expect(reconciledLines).To(gomega.HaveKeyWithValue("January salary", 1))
+	g.Expect(reconciledLines).NotTo(gomega.HaveKey("Supermarket"))
+	g.Expect(reconciledLines).To(gomega.HaveKeyWithValue("Card payment", 1))
+}
@@ -0,0 +1,631 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/importer"
+)
+
+// Import is an uploaded statement.  It is created as a preview, and nothing reaches the ledger until it is posted,
+// when each line becomes a transaction between AccountID and OffsetAccountID, or the accounts its categories are
+// mapped to under CategoryParentID.
+type Import struct {
+	ImportID         uint64
+	AccountID        uint64
+	OffsetAccountID  uint64
+	CategoryParentID uint64
+	// Categories are defined by the file, they are created under CategoryParentID when the import is posted
+	Categories     datastore.ImportCategories
+	ImportFormat   string
+	ImportFilename string
+	ImportStatus   datastore.ImportStatus
+	ImportDate     time.Time
+	Lines          []*ImportLine
+	// Errors are the rows of the file that could not be read, they are not posted
+	Errors []*ImportError
+	// OpeningBalance and ClosingBalance are the balances the statement reports, nil when it does not
+	OpeningBalance *ImportBalance
+	ClosingBalance *ImportBalance
+	// Suggestions are the accounts suggested for the lines of a preview that have no category, by ImportLineID
+	Suggestions map[uint64][]*AccountSuggestion
+}
+
+// ImportBalance is a balance reported by a statement, positive when the account is in credit with the bank
+type ImportBalance struct {
+	BalanceDate time.Time
+	Balance     int64
+}
+
+// ImportLine is a line of an Import.  A positive LineAmount debits the account, ie a deposit to a bank account.
+// IsDuplicate lines were already posted by an earlier import and are skipped.
+type ImportLine struct {
+	ImportLineID  uint64
+	ImportID      uint64
+	ExternalID    string
+	LineDate      time.Time
+	LineAmount    int64
+	LineComment   string
+	LineReference string
+	IsDuplicate   bool
+	TransactionID sql.NullInt64
+	LineCategory  string
+	// LineSplits divide the line between categories, a line with splits posts as a split transaction
+	LineSplits datastore.ImportLineSplits
+	// LineValueDate is the value date the statement gives for the line, the transaction is dated LineDate
+	LineValueDate sql.NullTime
+}
+
+// ImportAccounts are the accounts a statement is imported into.  Without an OffsetAccountID the lines are offset
+// against the suspense account.  Without a CategoryParentID the categories of lines are not used.
+type ImportAccounts struct {
+	AccountID        uint64
+	OffsetAccountID  uint64
+	CategoryParentID uint64
+}
+
+// ImportError is a row of the statement file that could not be read
+type ImportError struct {
+	ImportErrorID uint64
+	ImportID      uint64
+	RowNumber     int
+	ErrorMessage  string
+}
+
+var ErrImportNotFound = errors.New("import not found")
+var ErrImportNotPreview = errors.New("import has already been posted or cancelled")
+var ErrImportOffsetAccountInvalid = errors.New("offset account must differ from the import account")
+
+// NewImport stores a statement as a preview, the import with its lines and row errors in one database transaction.
+// Lines whose ExternalID was already posted to the account are marked as duplicates.
+func NewImport(dStores *datastore.Datastores, statement *importer.Statement, format importer.Format,
+	filename string, accounts *ImportAccounts) (*Import, error) {
+	accountID, offsetAccountID := accounts.AccountID, accounts.OffsetAccountID
+
+	if _, err := RetrieveAccountByID(dStores, accountID); err != nil {
+		return nil, fmt.Errorf("RetrieveAccountByID:%w", err)
+	}
+
+	if offsetAccountID == 0 {
+		suspense, err := RetrieveSuspenseAccount(dStores)
+		if err != nil {
+			return nil, fmt.Errorf("RetrieveSuspenseAccount:%w", err)
+		}
+
+		offsetAccountID = suspense.AccountID
+	} else if _, err := RetrieveAccountByID(dStores, offsetAccountID); err != nil {
+		return nil, fmt.Errorf("RetrieveAccountByID:%w", err)
+	}
+
+	if offsetAccountID == accountID {
+		return nil, ErrImportOffsetAccountInvalid
+	}
+
+	if accounts.CategoryParentID != 0 {
+		if _, err := RetrieveAccountByID(dStores, accounts.CategoryParentID); err != nil {
+			return nil, fmt.Errorf("RetrieveAccountByID:%w", err)
+		}
+	}
+
+	posted, err := postedExternalIDs(dStores, accountID, statement.Lines)
+	if err != nil {
+		return nil, err
+	}
+
+	eImport := datastore.Import{
+		ImportID:        0,
+		AccountID:       accountID,
+		OffsetAccountID: offsetAccountID,
+		ImportFormat:    string(format),
+		ImportFilename:  filename,
+		ImportStatus:    datastore.ImportStatusPreview,
+		ImportDate:      time.Time{},
+		CategoryParentID: sql.NullInt64{Int64: int64(accounts.CategoryParentID), //nolint:gosec
+			Valid: accounts.CategoryParentID != 0},
+		ImportCategories: statementCategoriesToImportCategories(statement.Categories),
+	}
+
+	eImport.OpeningBalance, eImport.OpeningBalanceDate = statementBalanceToNull(statement.OpeningBalance)
+	eImport.ClosingBalance, eImport.ClosingBalanceDate = statementBalanceToNull(statement.ClosingBalance)
+
+	var myImport *Import
+
+	err = dStores.InTransaction(func(txStores *datastore.Datastores) error {
+		var err error
+
+		myImport, err = storeImport(txStores, &eImport, statement, posted)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = myImport.SuggestAccounts(dStores); err != nil {
+		return nil, err
+	}
+
+	return myImport, nil
+}
+
+// storeImport inserts an import with its lines and row errors, posted are the external ids already posted to the
+// account
+func storeImport(dStores *datastore.Datastores, eImport *datastore.Import, statement *importer.Statement,
+	posted map[string]bool) (*Import, error) {
+	if err := dStores.ImportStore().Store(eImport); err != nil {
+		return nil, fmt.Errorf("ImportStore().Store:%w", err)
+	}
+
+	myImport := entImportToImport(eImport)
+
+	for _, stmtLine := range statement.Lines {
+		eLine := datastore.ImportLine{
+			ImportLineID:  0,
+			ImportID:      myImport.ImportID,
+			ExternalID:    stmtLine.ExternalID,
+			LineDate:      stmtLine.Date,
+			LineAmount:    stmtLine.Amount,
+			LineComment:   stmtLine.Comment(),
+			LineReference: stmtLine.TransactionReference(),
+			IsDuplicate:   stmtLine.ExternalID != "" && posted[stmtLine.ExternalID],
+			TransactionID: sql.NullInt64{},
+			LineCategory:  stmtLine.Category,
+			LineSplits:    statementSplitsToImportLineSplits(stmtLine.Splits),
+			LineValueDate: sql.NullTime{Time: stmtLine.ValueDate, Valid: !stmtLine.ValueDate.IsZero()},
+		}
+
+		if err := dStores.ImportStore().StoreLine(&eLine); err != nil {
+			return nil, fmt.Errorf("ImportStore().StoreLine:%w", err)
+		}
+
+		// the same id twice in one file is a duplicate too
+		if stmtLine.ExternalID != "" {
+			posted[stmtLine.ExternalID] = true
+		}
+
+		myLine := ImportLine(eLine)
+		myImport.Lines = append(myImport.Lines, &myLine)
+	}
+
+	for _, rowError := range statement.RowErrors {
+		eError := datastore.ImportError{
+			ImportErrorID: 0,
+			ImportID:      myImport.ImportID,
+			RowNumber:     rowError.Row,
+			ErrorMessage:  rowError.Message,
+		}
+
+		if err := dStores.ImportStore().StoreError(&eError); err != nil {
+			return nil, fmt.Errorf("ImportStore().StoreError:%w", err)
+		}
+
+		myError := ImportError(eError)
+		myImport.Errors = append(myImport.Errors, &myError)
+	}
+
+	return myImport, nil
+}
+
+// Post stores a transaction for each line that is not a duplicate, in one database transaction with setting the
+// import posted.  Duplicates are checked again, in case another import of the same lines was posted since the
+// preview.  The import is claimed first, so a second post of it waits for the first and then fails.
+func (c *Import) Post(dStores *datastore.Datastores) error {
+	if c.ImportStatus != datastore.ImportStatusPreview {
+		return ErrImportNotPreview
+	}
+
+	var postedLines []*ImportLine
+
+	err := dStores.InTransaction(func(txStores *datastore.Datastores) error {
+		if err := c.leavePreview(txStores, datastore.ImportStatusPosted); err != nil {
+			return err
+		}
+
+		var err error
+
+		postedLines, err = c.postLines(txStores)
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	c.Lines = postedLines
+	c.ImportStatus = datastore.ImportStatusPosted
+
+	return nil
+}
+
+// postLines stores a transaction for each line that is not a duplicate, and returns the lines as posted
+func (c *Import) postLines(dStores *datastore.Datastores) ([]*ImportLine, error) {
+	posted, err := postedExternalIDsForImport(dStores, c)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := newImportCategoryAccounts(dStores, c)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := newRuleEngine(dStores)
+	if err != nil {
+		return nil, err
+	}
+
+	postedLines := make([]*ImportLine, len(c.Lines))
+
+	for idx, line := range c.Lines {
+		postedLine := *line
+		postedLines[idx] = &postedLine
+
+		if line.IsDuplicate || line.TransactionID.Valid {
+			continue
+		}
+
+		eLine := datastore.ImportLine(*line)
+
+		if line.ExternalID != "" && posted[line.ExternalID] {
+			eLine.IsDuplicate = true
+		} else {
+			txn, err := line.transaction(c.AccountID, categories, rules)
+			if err != nil {
+				return nil, fmt.Errorf("line.transaction:%w [ImportLine:%d]", err, line.ImportLineID)
+			}
+
+			if err = txn.Store(dStores); err != nil {
+				return nil, fmt.Errorf("txn.Store:%w [ImportLine:%d]", err, line.ImportLineID)
+			}
+
+			// a line without an id, or with a different one, may still be a transaction entered by hand
+			if _, err = DetectDuplicates(dStores, txn); err != nil {
+				return nil, fmt.Errorf("DetectDuplicates:%w [ImportLine:%d]", err, line.ImportLineID)
+			}
+
+			eLine.TransactionID = sql.NullInt64{Int64: int64(txn.TransactionID), Valid: true} //nolint:gosec
+		}
+
+		if err = dStores.ImportStore().SetLineTransaction(&eLine); err != nil {
+			return nil, fmt.Errorf("ImportStore().SetLineTransaction:%w", err)
+		}
+
+		postedLine = ImportLine(eLine)
+	}
+
+	return postedLines, nil
+}
+
+// SuggestAccounts sets the Suggestions of a preview
+func (c *Import) SuggestAccounts(dStores *datastore.Datastores) error {
+	if c.ImportStatus != datastore.ImportStatusPreview {
+		return nil
+	}
+
+	suggestions, err := SharedCategorizer(dStores).SuggestImportAccounts(dStores, c)
+	if err != nil {
+		return fmt.Errorf("SuggestImportAccounts:%w", err)
+	}
+
+	c.Suggestions = suggestions
+
+	return nil
+}
+
+// Cancel discards a preview
+func (c *Import) Cancel(dStores *datastore.Datastores) error {
+	if c.ImportStatus != datastore.ImportStatusPreview {
+		return ErrImportNotPreview
+	}
+
+	if err := c.leavePreview(dStores, datastore.ImportStatusCancelled); err != nil {
+		return err
+	}
+
+	c.ImportStatus = datastore.ImportStatusCancelled
+
+	return nil
+}
+
+// leavePreview moves the import on to status, ErrImportNotPreview when another request already has
+func (c *Import) leavePreview(dStores *datastore.Datastores, status datastore.ImportStatus) error {
+	eImport := importToEntImport(c)
+	eImport.ImportStatus = status
+
+	if err := dStores.ImportStore().SetStatusFrom(&eImport, datastore.ImportStatusPreview); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrImportNotPreview
+		}
+
+		return fmt.Errorf("ImportStore().SetStatusFrom:%w", err)
+	}
+
+	return nil
+}
+
+// transaction is the transaction a line posts as, the account against the category of the line or against each
+// of its splits.  The rules rewrite the comment of every line, and set the offset of a line without a category.
+func (c *ImportLine) transaction(accountID uint64, categories *importCategoryAccounts,
+	rules *ruleEngine) (*Transaction, error) {
+	txn := Transaction{
+		TransactionCore: TransactionCore{
+			TransactionID:            0,
+			TransactionDate:          c.LineDate,
+			TransactionReconcileDate: sql.NullTime{},
+			TransactionComment:       c.LineComment,
+			TransactionAmount:        0,
+			TransactionReference:     c.LineReference,
+			IsReconciled:             false,
+			IsSplit:                  len(c.LineSplits) > 0,
+		},
+		DebitCreditSet: []*TransactionDebitCredit{importDebitCredit(accountID, c.LineAmount)},
+	}
+
+	outcome := rules.apply(&ruleSubject{comment: c.LineComment, reference: c.LineReference, amount: c.LineAmount,
+		sourceAccountID: accountID}, categories.offsetAccountID)
+	txn.TransactionComment = outcome.comment
+
+	if outcome.offsets != nil && !c.isCategorized(categories) {
+		for _, offset := range outcome.offsets {
+			txn.DebitCreditSet = append(txn.DebitCreditSet, importDebitCredit(offset.accountID, -offset.amount))
+		}
+
+		txn.IsSplit = len(txn.DebitCreditSet) > 2 //nolint:mnd
+
+		return &txn, nil
+	}
+
+	splits := c.LineSplits
+	if len(splits) == 0 {
+		splits = datastore.ImportLineSplits{{Category: c.LineCategory, Memo: "", Amount: c.LineAmount}}
+	}
+
+	for _, split := range splits {
+		if split.Amount == 0 {
+			continue
+		}
+
+		offsetAccountID, err := categories.lookup(split.Category)
+		if err != nil {
+			return nil, err
+		}
+
+		// the offset takes the other side of the amount
+		txn.DebitCreditSet = append(txn.DebitCreditSet, importDebitCredit(offsetAccountID, -split.Amount))
+	}
+
+	return &txn, nil
+}
+
+// isCategorized is true when the line, or one of its splits, has a category that is mapped to an account
+func (c *ImportLine) isCategorized(categories *importCategoryAccounts) bool {
+	if categories.parentID == 0 {
+		return false
+	}
+
+	if strings.TrimSpace(c.LineCategory) != "" {
+		return true
+	}
+
+	for _, split := range c.LineSplits {
+		if strings.TrimSpace(split.Category) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// importDebitCredit debits accountID with a positive amount and credits it with a negative amount
+func importDebitCredit(accountID uint64, amount int64) *TransactionDebitCredit {
+	if amount < 0 {
+		return &TransactionDebitCredit{AccountID: accountID, DebitOrCredit: datastore.AccountSignCredit,
+			TransactionDCAmount: uint64(-amount)}
+	}
+
+	return &TransactionDebitCredit{AccountID: accountID, DebitOrCredit: datastore.AccountSignDebit,
+		TransactionDCAmount: uint64(amount)}
+}
+
+// postedExternalIDs is the set of external ids of lines already posted to accountID
+func postedExternalIDs(dStores *datastore.Datastores, accountID uint64,
+	lines []*importer.StatementLine) (map[string]bool, error) {
+	externalIDs := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		if line.ExternalID != "" {
+			externalIDs = append(externalIDs, line.ExternalID)
+		}
+	}
+
+	return lookupPostedExternalIDs(dStores, accountID, externalIDs)
+}
+
+func postedExternalIDsForImport(dStores *datastore.Datastores, myImport *Import) (map[string]bool, error) {
+	externalIDs := make([]string, 0, len(myImport.Lines))
+
+	for _, line := range myImport.Lines {
+		if line.ExternalID != "" && !line.TransactionID.Valid {
+			externalIDs = append(externalIDs, line.ExternalID)
+		}
+	}
+
+	return lookupPostedExternalIDs(dStores, myImport.AccountID, externalIDs)
+}
+
+func lookupPostedExternalIDs(dStores *datastore.Datastores, accountID uint64,
+	externalIDs []string) (map[string]bool, error) {
+	posted := make(map[string]bool)
+
+	if len(externalIDs) == 0 {
+		return posted, nil
+	}
+
+	postedIDs, err := dStores.ImportStore().GetPostedExternalIDs(accountID, externalIDs)
+	if err != nil {
+		return nil, fmt.Errorf("ImportStore().GetPostedExternalIDs:%w", err)
+	}
+
+	for _, externalID := range postedIDs {
+		posted[externalID] = true
+	}
+
+	return posted, nil
+}
+
+// DefaultSuspenseAccountName is the name of the suspense account created for imports
+const DefaultSuspenseAccountName = "Suspense"
+
+// RetrieveSuspenseAccount retrieves the account imported lines are offset against by default.  It is the top level
+// asset account named Suspense, which is created the first time.
+func RetrieveSuspenseAccount(dStores *datastore.Datastores) (*Account, error) {
+	accounts, err := RetrieveAccounts(dStores)
+	if err != nil {
+		return nil, fmt.Errorf("RetrieveAccounts:%w", err)
+	}
+
+	for _, account := range accounts {
+		if account.AccountParent == 0 && account.AccountType == datastore.AccountTypeAsset &&
+			account.AccountName == DefaultSuspenseAccountName {
+			return account, nil
+		}
+	}
+
+	suspense := Account{ //nolint:exhaustruct
+		AccountName: DefaultSuspenseAccountName,
+		AccountMemo: "imported lines waiting to be categorized",
+		AccountType: datastore.AccountTypeAsset,
+	}
+	if err = suspense.Store(dStores); err != nil {
+		return nil, fmt.Errorf("suspense.Store:%w", err)
+	}
+
+	return &suspense, nil
+}
+
+// RetrieveImportByID retrieves an import with its lines
+func RetrieveImportByID(dStores *datastore.Datastores, importID uint64) (*Import, error) {
+	eImport, err := dStores.ImportStore().RetrieveByID(importID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrImportNotFound
+		}
+
+		return nil, fmt.Errorf("ImportStore().RetrieveByID:%w", err)
+	}
+
+	myImport := entImportToImport(eImport)
+
+	eLines, err := dStores.ImportStore().GetLines(importID)
+	if err != nil {
+		return nil, fmt.Errorf("ImportStore().GetLines:%w", err)
+	}
+
+	myImport.Lines = make([]*ImportLine, len(eLines))
+
+	for idx := range eLines {
+		myLine := ImportLine(*eLines[idx])
+		myImport.Lines[idx] = &myLine
+	}
+
+	eErrors, err := dStores.ImportStore().GetErrors(importID)
+	if err != nil {
+		return nil, fmt.Errorf("ImportStore().GetErrors:%w", err)
+	}
+
+	myImport.Errors = make([]*ImportError, len(eErrors))
+
+	for idx := range eErrors {
+		myError := ImportError(*eErrors[idx])
+		myImport.Errors[idx] = &myError
+	}
+
+	return myImport, nil
+}
+
+// RetrieveImports retrieves all imports without their lines, the newest first
+func RetrieveImports(dStores *datastore.Datastores) ([]*Import, error) {
+	eImports, err := dStores.ImportStore().Retrieve()
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("ImportStore().Retrieve:%w", err)
+	}
+
+	imports := make([]*Import, len(eImports))
+
+	for idx := range eImports {
+		imports[idx] = entImportToImport(eImports[idx])
+	}
+
+	return imports, nil
+}
+
+func entImportToImport(eImport *datastore.Import) *Import {
+	return &Import{
+		ImportID:         eImport.ImportID,
+		AccountID:        eImport.AccountID,
+		OffsetAccountID:  eImport.OffsetAccountID,
+		ImportFormat:     eImport.ImportFormat,
+		ImportFilename:   eImport.ImportFilename,
+		ImportStatus:     eImport.ImportStatus,
+		ImportDate:       eImport.ImportDate,
+		Lines:            nil,
+		Errors:           nil,
+		CategoryParentID: uint64(eImport.CategoryParentID.Int64), //nolint:gosec
+		Categories:       eImport.ImportCategories,
+		OpeningBalance:   nullToImportBalance(eImport.OpeningBalance, eImport.OpeningBalanceDate),
+		ClosingBalance:   nullToImportBalance(eImport.ClosingBalance, eImport.ClosingBalanceDate),
+		Suggestions:      nil,
+	}
+}
+
+func importToEntImport(myImport *Import) datastore.Import {
+	eImport := datastore.Import{
+		ImportID:        myImport.ImportID,
+		AccountID:       myImport.AccountID,
+		OffsetAccountID: myImport.OffsetAccountID,
+		ImportFormat:    myImport.ImportFormat,
+		ImportFilename:  myImport.ImportFilename,
+		ImportStatus:    myImport.ImportStatus,
+		ImportDate:      myImport.ImportDate,
+		CategoryParentID: sql.NullInt64{Int64: int64(myImport.CategoryParentID), //nolint:gosec
+			Valid: myImport.CategoryParentID != 0},
+		ImportCategories: myImport.Categories,
+	}
+
+	if myImport.OpeningBalance != nil {
+		eImport.OpeningBalance = sql.NullInt64{Int64: myImport.OpeningBalance.Balance, Valid: true}
+		eImport.OpeningBalanceDate = sql.NullTime{Time: myImport.OpeningBalance.BalanceDate, Valid: true}
+	}
+
+	if myImport.ClosingBalance != nil {
+		eImport.ClosingBalance = sql.NullInt64{Int64: myImport.ClosingBalance.Balance, Valid: true}
+		eImport.ClosingBalanceDate = sql.NullTime{Time: myImport.ClosingBalance.BalanceDate, Valid: true}
+	}
+
+	return eImport
+}
+
+// statementBalanceToNull is the columns of a balance, null when the statement has none
+func statementBalanceToNull(balance *importer.StatementBalance) (sql.NullInt64, sql.NullTime) {
+	if balance == nil {
+		return sql.NullInt64{}, sql.NullTime{}
+	}
+
+	return sql.NullInt64{Int64: balance.Amount, Valid: true}, sql.NullTime{Time: balance.Date, Valid: true}
+}
+
+func nullToImportBalance(balance sql.NullInt64, balanceDate sql.NullTime) *ImportBalance {
+	if !balance.Valid {
+		return nil
+	}
+
+	return &ImportBalance{BalanceDate: balanceDate.Time, Balance: balance.Int64}
+}
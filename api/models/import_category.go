@@ -0,0 +1,170 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/importer"
+)
+
+// importCategoryAccounts maps the categories of an import to accounts while it is posted.  A category path such as
+// Auto:Fuel is an account Fuel under an account Auto under the category parent, and the accounts that are missing
+// are created through Account.Store, so they take the type of the parent.  A transfer category such as [Savings]
+// is the account with that name.  Lines that are not categorized, transfers to an account that does not exist,
+// and every line of an import without a category parent are offset against the offset account.
+type importCategoryAccounts struct {
+	dStores         *datastore.Datastores
+	accountID       uint64
+	offsetAccountID uint64
+	parentID        uint64
+	descriptions    map[string]string
+	byPath          map[string]uint64
+	byName          map[string]uint64
+}
+
+func newImportCategoryAccounts(dStores *datastore.Datastores, myImport *Import) (*importCategoryAccounts, error) {
+	categories := importCategoryAccounts{
+		dStores:         dStores,
+		accountID:       myImport.AccountID,
+		offsetAccountID: myImport.OffsetAccountID,
+		parentID:        myImport.CategoryParentID,
+		descriptions:    make(map[string]string),
+		byPath:          make(map[string]uint64),
+		byName:          nil,
+	}
+
+	if categories.parentID == 0 {
+		return &categories, nil
+	}
+
+	for _, category := range myImport.Categories {
+		categories.descriptions[category.Name] = category.Description
+	}
+
+	// every category the file defines is created, even when no line uses it
+	for _, category := range myImport.Categories {
+		if _, err := categories.lookup(category.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	return &categories, nil
+}
+
+// lookup is the account a category is mapped to, it is created when it is missing
+func (c *importCategoryAccounts) lookup(category string) (uint64, error) {
+	category = strings.TrimSpace(category)
+
+	if category == "" || c.parentID == 0 {
+		return c.offsetAccountID, nil
+	}
+
+	if importer.IsTransferCategory(category) {
+		return c.transferAccountID(strings.TrimSpace(category[1 : len(category)-1]))
+	}
+
+	parentID := c.parentID
+	path := ""
+
+	for _, name := range strings.Split(category, importer.CategorySeparator) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if path != "" {
+			path += importer.CategorySeparator
+		}
+
+		path += name
+
+		if accountID, ok := c.byPath[path]; ok {
+			parentID = accountID
+
+			continue
+		}
+
+		accountID, err := c.childAccountID(parentID, name, c.descriptions[path])
+		if err != nil {
+			return 0, err
+		}
+
+		c.byPath[path] = accountID
+		parentID = accountID
+	}
+
+	return parentID, nil
+}
+
+// childAccountID is the child of parentID named name, it is created when there is none
+func (c *importCategoryAccounts) childAccountID(parentID uint64, name, description string) (uint64, error) {
+	children, err := findDirectChildren(c.dStores, parentID)
+	if err != nil {
+		return 0, fmt.Errorf("findDirectChildren:%w", err)
+	}
+
+	for _, child := range children {
+		if strings.EqualFold(child.AccountName, name) {
+			return child.AccountID, nil
+		}
+	}
+
+	account := Account{ //nolint:exhaustruct
+		AccountParent: parentID,
+		AccountName:   name,
+		AccountMemo:   description,
+	}
+	if err = account.Store(c.dStores); err != nil {
+		return 0, fmt.Errorf("account.Store:%w [category:%s]", err, name)
+	}
+
+	return account.AccountID, nil
+}
+
+// transferAccountID is the account named name, or the offset account when there is none
+func (c *importCategoryAccounts) transferAccountID(name string) (uint64, error) {
+	if c.byName == nil {
+		accounts, err := RetrieveAccounts(c.dStores)
+		if err != nil {
+			return 0, fmt.Errorf("RetrieveAccounts:%w", err)
+		}
+
+		c.byName = make(map[string]uint64, len(accounts))
+
+		for _, account := range accounts {
+			if _, ok := c.byName[strings.ToLower(account.AccountName)]; !ok {
+				c.byName[strings.ToLower(account.AccountName)] = account.AccountID
+			}
+		}
+	}
+
+	accountID, ok := c.byName[strings.ToLower(name)]
+	if !ok || accountID == c.accountID {
+		return c.offsetAccountID, nil
+	}
+
+	return accountID, nil
+}
+
+func statementCategoriesToImportCategories(categories []*importer.StatementCategory) datastore.ImportCategories {
+	importCategories := make(datastore.ImportCategories, len(categories))
+
+	for idx, category := range categories {
+		importCategories[idx] = &datastore.ImportCategory{Name: category.Name, Description: category.Description,
+			IsIncome: category.IsIncome}
+	}
+
+	return importCategories
+}
+
+func statementSplitsToImportLineSplits(splits []*importer.StatementSplit) datastore.ImportLineSplits {
+	lineSplits := make(datastore.ImportLineSplits, len(splits))
+
+	for idx, split := range splits {
+		lineSplits[idx] = &datastore.ImportLineSplit{Category: split.Category, Memo: split.Memo,
+			Amount: split.Amount}
+	}
+
+	return lineSplits
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"os"
+	"testing"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/importer"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestImport_PostQIFCategoriesAndSplits(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	checking := Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	savings := Account{AccountName: "Savings", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err = savings.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	categoriesParent := Account{AccountName: "Quicken", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeExpense}
+	err = categoriesParent.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// an existing category is reused
+	groceries := Account{AccountName: "Groceries", AccountParent: categoriesParent.AccountID}
+	err = groceries.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	file, err := os.Open("../importer/testdata/quicken.qif")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	defer file.Close()
+
+	myStatement, err := importer.ParseQIF(file, checking.AccountDecimals, false)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	myImport, err := NewImport(testDS, myStatement, importer.FormatQIF, "quicken.qif",
+		&ImportAccounts{AccountID: checking.AccountID, CategoryParentID: categoriesParent.AccountID})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myImport.Categories).To(gomega.HaveLen(3))
+	g.Expect(myImport.Lines).To(gomega.HaveLen(4))
+	g.Expect(myImport.Lines[1].LineSplits).To(gomega.HaveLen(2))
+	g.Expect(myImport.Errors).To(gomega.HaveLen(2))
+
+	// nothing is created before posting
+	children, err := findDirectChildren(testDS, categoriesParent.AccountID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(children).To(gomega.HaveLen(1))
+
+	retrieved, err := RetrieveImportByID(testDS, myImport.ImportID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(retrieved.CategoryParentID).To(gomega.Equal(categoriesParent.AccountID))
+
+	err = retrieved.Post(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// Auto, Salary and Housing are created under the parent, and Fuel and Rent under them
+	children, err = findDirectChildren(testDS, categoriesParent.AccountID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	childIDs := make(map[string]uint64)
+	for _, child := range children {
+		childIDs[child.AccountName] = child.AccountID
+	}
+
+	g.Expect(childIDs).To(gomega.HaveLen(4))
+	g.Expect(childIDs).To(gomega.HaveKey("Auto"))
+	g.Expect(childIDs).To(gomega.HaveKey("Salary"))
+	g.Expect(childIDs).To(gomega.HaveKeyWithValue("Groceries", groceries.AccountID))
+
+	auto, err := RetrieveAccountByID(testDS, childIDs["Auto"])
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(auto.AccountMemo).To(gomega.Equal("Vehicle costs"))
+	g.Expect(auto.AccountType).To(gomega.Equal(datastore.AccountTypeExpense))
+
+	fuel, err := findDirectChildren(testDS, auto.AccountID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(fuel).To(gomega.HaveLen(1))
+	g.Expect(fuel[0].AccountName).To(gomega.Equal("Fuel"))
+
+	// the split line is one transaction with a line for each split
+	split, err := RetrieveTransactionByID(testDS, uint64(retrieved.Lines[1].TransactionID.Int64))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(split.IsSplit).To(gomega.BeTrue())
+	g.Expect(split.TransactionAmount).To(gomega.Equal(uint64(8437)))
+	g.Expect(split.DebitCreditSet).To(gomega.HaveLen(3))
+
+	for _, dc := range split.DebitCreditSet {
+		switch dc.AccountID {
+		case checking.AccountID:
+			g.Expect(dc.DebitOrCredit).To(gomega.Equal(datastore.AccountSignCredit))
+			g.Expect(dc.TransactionDCAmount).To(gomega.Equal(uint64(8437)))
+		case groceries.AccountID:
+			g.Expect(dc.DebitOrCredit).To(gomega.Equal(datastore.AccountSignDebit))
+			g.Expect(dc.TransactionDCAmount).To(gomega.Equal(uint64(6012)))
+		default:
+			g.Expect(dc.AccountID).To(gomega.Equal(fuel[0].AccountID))
+			g.Expect(dc.TransactionDCAmount).To(gomega.Equal(uint64(2425)))
+		}
+	}
+
+	// the transfer is offset against the account of that name
+	transfer, err := RetrieveTransactionByID(testDS, uint64(retrieved.Lines[3].TransactionID.Int64))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(transfer.IsSplit).To(gomega.BeFalse())
+
+	accountIDs := []uint64{transfer.DebitCreditSet[0].AccountID, transfer.DebitCreditSet[1].AccountID}
+	g.Expect(accountIDs).To(gomega.ConsistOf(checking.AccountID, savings.AccountID))
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/importer"
+)
+
+// ImportProfile is a saved mapping of a bank's CSV export.  Its lines are offset against OffsetAccountID unless
+// an import names another account, and against the suspense account when neither does.
+type ImportProfile struct {
+	ProfileID       uint64
+	ProfileName     string
+	OffsetAccountID uint64
+	CSV             importer.CSVProfile
+}
+
+var ErrImportProfileNotFound = errors.New("import profile not found")
+var errImportProfileNameEmptyString = errors.New("import profile name cannot be empty")
+
+// Store inserts an ImportProfile, the mapping must be valid before it is stored
+func (c *ImportProfile) Store(dStores *datastore.Datastores) error {
+	if err := c.validate(dStores); err != nil {
+		return fmt.Errorf("c.validate:%w", err)
+	}
+
+	eProfile := importProfileToEntImportProfile(c)
+
+	err := dStores.ImportProfileStore().Store(&eProfile)
+	if err != nil {
+		return fmt.Errorf("ds.ImportProfileStore().Store:%w [ImportProfile:%s]", err, eProfile.ProfileName)
+	}
+
+	*c = *entImportProfileToImportProfile(&eProfile)
+
+	return nil
+}
+
+// Update updates an ImportProfile, the mapping must be valid before it is stored
+func (c *ImportProfile) Update(dStores *datastore.Datastores) error {
+	if err := c.validate(dStores); err != nil {
+		return fmt.Errorf("c.validate:%w", err)
+	}
+
+	eProfile := importProfileToEntImportProfile(c)
+
+	err := dStores.ImportProfileStore().Update(&eProfile)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrImportProfileNotFound
+		}
+
+		return fmt.Errorf("ds.ImportProfileStore().Update:%w [ImportProfile:%s]", err, eProfile.ProfileName)
+	}
+
+	*c = *entImportProfileToImportProfile(&eProfile)
+
+	return nil
+}
+
+func (c *ImportProfile) Delete(dStores *datastore.Datastores) error {
+	eProfile := importProfileToEntImportProfile(c)
+
+	err := dStores.ImportProfileStore().Delete(&eProfile)
+	if err != nil {
+		return fmt.Errorf("ds.ImportProfileStore().Delete:%w [ImportProfile:%+v]", err, c)
+	}
+
+	return nil
+}
+
+func (c *ImportProfile) validate(dStores *datastore.Datastores) error {
+	if c.ProfileName == "" {
+		return errImportProfileNameEmptyString
+	}
+
+	if err := c.CSV.Validate(); err != nil {
+		return fmt.Errorf("c.CSV.Validate:%w", err)
+	}
+
+	if c.OffsetAccountID != 0 {
+		if _, err := RetrieveAccountByID(dStores, c.OffsetAccountID); err != nil {
+			return fmt.Errorf("RetrieveAccountByID:%w", err)
+		}
+	}
+
+	return nil
+}
+
+// RetrieveImportProfileByID retrieves an ImportProfile
+func RetrieveImportProfileByID(dStores *datastore.Datastores, profileID uint64) (*ImportProfile, error) {
+	eProfile, err := dStores.ImportProfileStore().RetrieveByID(profileID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrImportProfileNotFound
+		}
+
+		return nil, fmt.Errorf("ds.ImportProfileStore().RetrieveByID:%w", err)
+	}
+
+	return entImportProfileToImportProfile(eProfile), nil
+}
+
+// RetrieveImportProfiles retrieves all ImportProfiles by name
+func RetrieveImportProfiles(dStores *datastore.Datastores) ([]*ImportProfile, error) {
+	eProfiles, err := dStores.ImportProfileStore().Retrieve()
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return []*ImportProfile{}, nil
+		}
+
+		return nil, fmt.Errorf("ds.ImportProfileStore().Retrieve:%w", err)
+	}
+
+	profiles := make([]*ImportProfile, len(eProfiles))
+
+	for idx := range eProfiles {
+		profiles[idx] = entImportProfileToImportProfile(eProfiles[idx])
+	}
+
+	return profiles, nil
+}
+
+func importProfileToEntImportProfile(myProfile *ImportProfile) datastore.ImportProfile {
+	offsetAccountID := sql.NullInt64{Int64: int64(myProfile.OffsetAccountID), //nolint:gosec
+		Valid: myProfile.OffsetAccountID != 0}
+
+	return datastore.ImportProfile{
+		ProfileID:       myProfile.ProfileID,
+		ProfileName:     myProfile.ProfileName,
+		OffsetAccountID: offsetAccountID,
+		ProfileBody:     datastore.ImportProfileBody(myProfile.CSV),
+	}
+}
+
+func entImportProfileToImportProfile(eProfile *datastore.ImportProfile) *ImportProfile {
+	return &ImportProfile{
+		ProfileID:       eProfile.ProfileID,
+		ProfileName:     eProfile.ProfileName,
+		OffsetAccountID: uint64(eProfile.OffsetAccountID.Int64), //nolint:gosec
+		CSV:             importer.CSVProfile(eProfile.ProfileBody),
+	}
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/importer"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestImportProfile_StoreUpdateDelete(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	expense := Account{AccountName: "Expense", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeExpense}
+	err := expense.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	invalid := ImportProfile{ProfileName: "No amount", CSV: importer.CSVProfile{DateColumn: 1}}
+	err = invalid.Store(testDS)
+	g.Expect(errors.Is(err, importer.ErrCSVProfileInvalid)).To(gomega.BeTrue())
+
+	myProfile := ImportProfile{ProfileName: "My Bank", OffsetAccountID: expense.AccountID,
+		CSV: importer.CSVProfile{SkipRows: 1, DateColumn: 1, AmountColumn: 3, DescriptionColumn: 2,
+			DateFormat: "DD/MM/YYYY"}}
+	err = myProfile.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myProfile.ProfileID).NotTo(gomega.BeZero())
+
+	myProfile.CSV.SignConvention = importer.SignInverted
+	myProfile.OffsetAccountID = 0
+	err = myProfile.Update(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	retrieved, err := RetrieveImportProfileByID(testDS, myProfile.ProfileID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(retrieved.OffsetAccountID).To(gomega.BeZero())
+	g.Expect(retrieved.CSV).To(gomega.Equal(myProfile.CSV))
+
+	profiles, err := RetrieveImportProfiles(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(profiles).To(gomega.HaveLen(1))
+
+	err = retrieved.Delete(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	_, err = RetrieveImportProfileByID(testDS, myProfile.ProfileID)
+	g.Expect(err).To(gomega.MatchError(ErrImportProfileNotFound))
+}
+
+func TestImport_NewImportCSVRowErrors(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	checking := Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	file, err := os.Open("../importer/testdata/bank_debit_credit.csv")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	defer file.Close()
+
+	profile := importer.CSVProfile{Delimiter: ";", SkipRows: 2, DateColumn: 1, DescriptionColumn: 2,
+		ReferenceColumn: 3, DebitColumn: 4, CreditColumn: 5, DateFormat: "DD/MM/YYYY", DecimalSeparator: ","}
+
+	myStatement, err := importer.ParseCSV(file, &profile, checking.AccountDecimals)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	myImport, err := NewImport(testDS, myStatement, importer.FormatCSV, "bank.csv",
+		&ImportAccounts{AccountID: checking.AccountID})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myImport.Lines).To(gomega.HaveLen(3))
+	g.Expect(myImport.Errors).To(gomega.HaveLen(2))
+
+	retrieved, err := RetrieveImportByID(testDS, myImport.ImportID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(retrieved.Errors).To(gomega.HaveLen(2))
+	g.Expect(retrieved.Errors[0].RowNumber).To(gomega.Equal(5))
+
+	// the rows that were read still post
+	err = retrieved.Post(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	myAccount, err := RetrieveAccountByID(testDS, checking.AccountID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myAccount.AccountBalance).To(gomega.Equal(int64(250000 + 1250 - 4599)))
+}
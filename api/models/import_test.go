@@ -0,0 +1,178 @@
+package models
+
+import (
+	"os"
+	"testing"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/importer"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func parseTestOFX(g *gomega.WithT) *importer.Statement {
+	file, err := os.Open("../importer/testdata/bank_v1.ofx")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	defer file.Close()
+
+	myStatement, err := importer.ParseOFX(file, 2)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	return myStatement
+}
+
+func TestImport_NewImportAndPost(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	checking := Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	myImport, err := NewImport(testDS, parseTestOFX(g), importer.FormatOFX, "bank_v1.ofx",
+		&ImportAccounts{AccountID: checking.AccountID})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myImport.ImportStatus).To(gomega.Equal(datastore.ImportStatusPreview))
+	g.Expect(myImport.Lines).To(gomega.HaveLen(3))
+	g.Expect(myImport.Lines[0].IsDuplicate).To(gomega.BeFalse())
+
+	// without an offset account the suspense account is created and used
+	suspense, err := RetrieveSuspenseAccount(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(suspense.AccountName).To(gomega.Equal(DefaultSuspenseAccountName))
+	g.Expect(myImport.OffsetAccountID).To(gomega.Equal(suspense.AccountID))
+
+	// nothing reaches the ledger before posting
+	ledger, err := RetrieveAccountLedger(testDS, &checking, &TransactionLedgerFilter{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ledger.Transactions).To(gomega.BeEmpty())
+
+	// a second request holding the same preview
+	stale, err := RetrieveImportByID(testDS, myImport.ImportID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	err = myImport.Post(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myImport.ImportStatus).To(gomega.Equal(datastore.ImportStatusPosted))
+
+	// it finds the import claimed and posts nothing
+	err = stale.Post(testDS)
+	g.Expect(err).To(gomega.MatchError(ErrImportNotPreview))
+
+	ledger, err = RetrieveAccountLedger(testDS, &checking, &TransactionLedgerFilter{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ledger.Transactions).To(gomega.HaveLen(3))
+
+	for _, line := range myImport.Lines {
+		g.Expect(line.TransactionID.Valid).To(gomega.BeTrue())
+	}
+
+	// a negative line credits the account, a positive line debits it
+	txn, err := RetrieveTransactionByID(testDS, uint64(myImport.Lines[0].TransactionID.Int64))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(txn.TransactionComment).To(gomega.Equal("HOME DEPOT #1234 - POS PURCHASE"))
+	g.Expect(txn.TransactionAmount).To(gomega.Equal(uint64(12750)))
+
+	for _, dc := range txn.DebitCreditSet {
+		if dc.AccountID == checking.AccountID {
+			g.Expect(dc.DebitOrCredit).To(gomega.Equal(datastore.AccountSignCredit))
+		} else {
+			g.Expect(dc.AccountID).To(gomega.Equal(suspense.AccountID))
+			g.Expect(dc.DebitOrCredit).To(gomega.Equal(datastore.AccountSignDebit))
+		}
+	}
+
+	myAccount, err := RetrieveAccountByID(testDS, checking.AccountID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myAccount.AccountBalance).To(gomega.Equal(int64(250000 - 120000 - 12750)))
+
+	// posting twice is refused
+	err = myImport.Post(testDS)
+	g.Expect(err).To(gomega.MatchError(ErrImportNotPreview))
+
+	// importing the same file again marks every line a duplicate, and posts nothing
+	again, err := NewImport(testDS, parseTestOFX(g), importer.FormatOFX, "bank_v1.ofx",
+		&ImportAccounts{AccountID: checking.AccountID})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	for _, line := range again.Lines {
+		g.Expect(line.IsDuplicate).To(gomega.BeTrue())
+	}
+
+	err = again.Post(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	retrieved, err := RetrieveImportByID(testDS, again.ImportID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	for _, line := range retrieved.Lines {
+		g.Expect(line.TransactionID.Valid).To(gomega.BeFalse())
+	}
+}
+
+func TestImport_NewImportRolledBack(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	checking := Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// the database rejects the zero line after the import row and the first lines are inserted
+	myStatement := parseTestOFX(g)
+	myStatement.Lines[len(myStatement.Lines)-1].Amount = 0
+
+	_, err = NewImport(testDS, myStatement, importer.FormatOFX, "bank_v1.ofx",
+		&ImportAccounts{AccountID: checking.AccountID})
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	imports, err := RetrieveImports(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(imports).To(gomega.BeEmpty())
+}
+
+func TestImport_Cancel(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	checking := Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	income := Account{AccountName: "Income", AccountSign: datastore.AccountSignCredit,
+		AccountType: datastore.AccountTypeIncome}
+	err = income.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	_, err = NewImport(testDS, parseTestOFX(g), importer.FormatOFX, "bank_v1.ofx",
+		&ImportAccounts{AccountID: checking.AccountID, OffsetAccountID: checking.AccountID})
+	g.Expect(err).To(gomega.MatchError(ErrImportOffsetAccountInvalid))
+
+	myImport, err := NewImport(testDS, parseTestOFX(g), importer.FormatOFX, "bank_v1.ofx",
+		&ImportAccounts{AccountID: checking.AccountID, OffsetAccountID: income.AccountID})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myImport.OffsetAccountID).To(gomega.Equal(income.AccountID))
+
+	err = myImport.Cancel(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	retrieved, err := RetrieveImportByID(testDS, myImport.ImportID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(retrieved.ImportStatus).To(gomega.Equal(datastore.ImportStatusCancelled))
+
+	err = retrieved.Post(testDS)
+	g.Expect(err).To(gomega.MatchError(ErrImportNotPreview))
+
+	// a cancelled import does not make the lines duplicates
+	again, err := NewImport(testDS, parseTestOFX(g), importer.FormatOFX, "bank_v1.ofx",
+		&ImportAccounts{AccountID: checking.AccountID, OffsetAccountID: income.AccountID})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(again.Lines[0].IsDuplicate).To(gomega.BeFalse())
+}
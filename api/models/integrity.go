@@ -0,0 +1,291 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+)
+
+// IntegrityCheck names a check of CheckIntegrity
+type IntegrityCheck string
+
+const (
+	// IntegrityCheckAccountTree is the nested set of account_left and account_right matching account_parent
+	IntegrityCheckAccountTree = IntegrityCheck("ACCOUNT_TREE")
+	// IntegrityCheckAccountFullName is account_full_name matching the names of the account and its parents
+	IntegrityCheckAccountFullName = IntegrityCheck("ACCOUNT_FULL_NAME")
+	// IntegrityCheckAccountType is a subaccount having the type and sign of its parent, and a sign matching its type
+	IntegrityCheckAccountType = IntegrityCheck("ACCOUNT_TYPE")
+	// IntegrityCheckAccountTotals is account_subtotal and account_balance matching the debits and credits
+	IntegrityCheckAccountTotals = IntegrityCheck("ACCOUNT_TOTALS")
+	// IntegrityCheckTransactionBalanced is the debits and the credits of a transaction equal to its amount
+	IntegrityCheckTransactionBalanced = IntegrityCheck("TRANSACTION_BALANCED")
+	// IntegrityCheckDebitCreditAccount is a debit or credit posted to an account that exists
+	IntegrityCheckDebitCreditAccount = IntegrityCheck("DEBIT_CREDIT_ACCOUNT")
+)
+
+// IntegrityProblem is a failed check, AccountID or TransactionID is the record that failed it
+type IntegrityProblem struct {
+	Check         IntegrityCheck
+	AccountID     uint64
+	TransactionID uint64
+	Message       string
+}
+
+// IntegrityReport is the outcome of CheckIntegrity, the ledger is sound when there are no problems
+type IntegrityReport struct {
+	AccountsChecked     int
+	TransactionsChecked int
+	Problems            []*IntegrityProblem
+}
+
+// integrityTransaction is the debits and credits of a transaction
+type integrityTransaction struct {
+	amount  uint64
+	debits  uint64
+	credits uint64
+}
+
+// integrityCheck is the state of CheckIntegrity
+type integrityCheck struct {
+	accounts     []*Account
+	accountsByID map[uint64]*Account
+	transactions map[uint64]*integrityTransaction
+	// net is the debits less the credits posted directly to each account
+	net    map[uint64]int64
+	report IntegrityReport
+}
+
+// CheckIntegrity checks the account tree, the account totals and that every transaction balances.  It only reads
+// the ledger, problems are reported rather than repaired.
+func CheckIntegrity(dStores *datastore.Datastores) (*IntegrityReport, error) {
+	accounts, err := RetrieveAccounts(dStores)
+	if err != nil {
+		return nil, fmt.Errorf("RetrieveAccounts:%w", err)
+	}
+
+	check := integrityCheck{
+		accounts:     accounts,
+		accountsByID: make(map[uint64]*Account, len(accounts)),
+		transactions: make(map[uint64]*integrityTransaction),
+		net:          make(map[uint64]int64, len(accounts)),
+		report:       IntegrityReport{AccountsChecked: len(accounts), TransactionsChecked: 0, Problems: nil},
+	}
+
+	for _, account := range accounts {
+		check.accountsByID[account.AccountID] = account
+	}
+
+	err = dStores.ArchiveStore().EachTransaction(func(eTxn *datastore.Transaction) error {
+		check.transactions[eTxn.TransactionID] = &integrityTransaction{amount: eTxn.TransactionAmount, debits: 0,
+			credits: 0}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ArchiveStore().EachTransaction:%w", err)
+	}
+
+	err = dStores.ArchiveStore().EachDebitCredit(func(eDC *datastore.TransactionDebitCredit) error {
+		check.debitCredit(eDC)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ArchiveStore().EachDebitCredit:%w", err)
+	}
+
+	check.report.TransactionsChecked = len(check.transactions)
+
+	check.checkTree()
+	check.checkAccounts()
+	check.checkTransactions()
+
+	return &check.report, nil
+}
+
+func (c *integrityCheck) problem(check IntegrityCheck, accountID, transactionID uint64, format string, args ...any) {
+	c.report.Problems = append(c.report.Problems, &IntegrityProblem{Check: check, AccountID: accountID,
+		TransactionID: transactionID, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *integrityCheck) debitCredit(eDC *datastore.TransactionDebitCredit) {
+	if _, ok := c.accountsByID[eDC.AccountID]; !ok {
+		c.problem(IntegrityCheckDebitCreditAccount, eDC.AccountID, eDC.TransactionID,
+			"debit/credit %d is posted to account %d, which does not exist", eDC.TransactionDCID, eDC.AccountID)
+	}
+
+	txn, ok := c.transactions[eDC.TransactionID]
+	if !ok {
+		// transaction_debit_credit has a foreign key on transaction_main, so this is only a concurrent delete
+		return
+	}
+
+	amount := int64(eDC.TransactionDCAmount) //nolint:gosec
+
+	switch eDC.DebitOrCredit {
+	case datastore.AccountSignDebit:
+		txn.debits += eDC.TransactionDCAmount
+		c.net[eDC.AccountID] += amount
+	case datastore.AccountSignCredit:
+		txn.credits += eDC.TransactionDCAmount
+		c.net[eDC.AccountID] -= amount
+	}
+}
+
+// checkTree checks the nested set, every account lies strictly inside its parent and accounts only overlap their
+// parents
+func (c *integrityCheck) checkTree() {
+	seen := make(map[uint64]uint64, 2*len(c.accounts)) //nolint:mnd
+
+	for _, account := range c.accounts {
+		if account.AccountLeft >= account.AccountRight {
+			c.problem(IntegrityCheckAccountTree, account.AccountID, 0, "account_left %d is not before account_right %d",
+				account.AccountLeft, account.AccountRight)
+		}
+
+		for _, value := range []uint64{account.AccountLeft, account.AccountRight} {
+			if other, ok := seen[value]; ok {
+				c.problem(IntegrityCheckAccountTree, account.AccountID, 0, "tree value %d is also used by account %d",
+					value, other)
+			}
+
+			seen[value] = account.AccountID
+		}
+
+		if account.AccountParent == 0 {
+			continue
+		}
+
+		parent, ok := c.accountsByID[account.AccountParent]
+		if !ok {
+			c.problem(IntegrityCheckAccountTree, account.AccountID, 0, "parent account %d does not exist",
+				account.AccountParent)
+
+			continue
+		}
+
+		if account.AccountLeft <= parent.AccountLeft || account.AccountRight >= parent.AccountRight {
+			c.problem(IntegrityCheckAccountTree, account.AccountID, 0, "account is not inside parent account %d",
+				parent.AccountID)
+		}
+	}
+
+	// accounts ordered by account_left nest, each either starts inside the account before it or after its end
+	ordered := make([]*Account, len(c.accounts))
+	copy(ordered, c.accounts)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].AccountLeft < ordered[j].AccountLeft })
+
+	open := make([]*Account, 0)
+
+	for _, account := range ordered {
+		for len(open) > 0 && open[len(open)-1].AccountRight < account.AccountLeft {
+			open = open[:len(open)-1]
+		}
+
+		if len(open) > 0 && open[len(open)-1].AccountRight < account.AccountRight {
+			c.problem(IntegrityCheckAccountTree, account.AccountID, 0, "account overlaps account %d",
+				open[len(open)-1].AccountID)
+		}
+
+		open = append(open, account)
+	}
+}
+
+// checkAccounts checks the names, types and totals of every account
+func (c *integrityCheck) checkAccounts() {
+	for _, account := range c.accounts {
+		subtotal := c.net[account.AccountID]
+		if account.AccountSign == datastore.AccountSignCredit {
+			subtotal = -subtotal
+		}
+
+		if account.AccountSubtotal != subtotal {
+			c.problem(IntegrityCheckAccountTotals, account.AccountID, 0, "account_subtotal is %d, the debits and "+
+				"credits total %d", account.AccountSubtotal, subtotal)
+		}
+
+		// the balance of an account is the sum of the stored subtotals of it and its subaccounts
+		var balance int64
+
+		for _, other := range c.accounts {
+			if other.AccountLeft >= account.AccountLeft && other.AccountLeft <= account.AccountRight {
+				balance += other.AccountSubtotal
+			}
+		}
+
+		if account.AccountBalance != balance {
+			c.problem(IntegrityCheckAccountTotals, account.AccountID, 0, "account_balance is %d, the subtotals "+
+				"total %d", account.AccountBalance, balance)
+		}
+
+		if fullName := c.fullName(account); account.AccountFullName != fullName {
+			c.problem(IntegrityCheckAccountFullName, account.AccountID, 0, "account_full_name is %q, not %q",
+				account.AccountFullName, fullName)
+		}
+
+		c.checkAccountType(account)
+	}
+}
+
+func (c *integrityCheck) checkAccountType(account *Account) {
+	sign, ok := datastore.AccountTypeToSign[account.AccountType]
+	if !ok {
+		c.problem(IntegrityCheckAccountType, account.AccountID, 0, "account type %s is not valid", account.AccountType)
+
+		return
+	}
+
+	if account.AccountSign != sign {
+		c.problem(IntegrityCheckAccountType, account.AccountID, 0, "account sign %s does not match type %s",
+			account.AccountSign, account.AccountType)
+	}
+
+	parent, ok := c.accountsByID[account.AccountParent]
+	if ok && parent.AccountType != account.AccountType {
+		c.problem(IntegrityCheckAccountType, account.AccountID, 0, "account type %s does not match parent type %s",
+			account.AccountType, parent.AccountType)
+	}
+}
+
+// fullName is the names of the account and its parents, a missing parent or a loop in the parents ends the name
+func (c *integrityCheck) fullName(account *Account) string {
+	names := []string{account.AccountName}
+	visited := map[uint64]bool{account.AccountID: true}
+
+	for parent, ok := c.accountsByID[account.AccountParent]; ok && !visited[parent.AccountID]; parent, ok =
+		c.accountsByID[parent.AccountParent] {
+		visited[parent.AccountID] = true
+		names = append([]string{parent.AccountName}, names...)
+	}
+
+	return strings.Join(names, ":")
+}
+
+// checkTransactions checks every transaction has debits and credits, each totalling the transaction amount
+func (c *integrityCheck) checkTransactions() {
+	transactionIDs := make([]uint64, 0, len(c.transactions))
+	for transactionID := range c.transactions {
+		transactionIDs = append(transactionIDs, transactionID)
+	}
+
+	sort.Slice(transactionIDs, func(i, j int) bool { return transactionIDs[i] < transactionIDs[j] })
+
+	for _, transactionID := range transactionIDs {
+		txn := c.transactions[transactionID]
+
+		switch {
+		case txn.debits == 0 || txn.credits == 0:
+			c.problem(IntegrityCheckTransactionBalanced, 0, transactionID, "transaction has debits of %d and "+
+				"credits of %d", txn.debits, txn.credits)
+		case txn.debits != txn.credits:
+			c.problem(IntegrityCheckTransactionBalanced, 0, transactionID, "debits of %d do not equal credits of %d",
+				txn.debits, txn.credits)
+		case txn.debits != txn.amount:
+			c.problem(IntegrityCheckTransactionBalanced, 0, transactionID, "debits and credits of %d do not equal "+
+				"transaction_amount %d", txn.debits, txn.amount)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/money"
+	"github.com/mimirsoft/mimirledger/api/plaintext"
+)
+
+// LedgerImportSummary is the outcome of importing a ledger-cli or hledger journal
+type LedgerImportSummary struct {
+	AccountsCreated      int
+	AccountsReused       int
+	TransactionsImported int
+	// TransactionsSkipped are transactions whose postings are all zero
+	TransactionsSkipped int
+}
+
+var ErrLedgerAccountTypeUnknown = errors.New("cannot determine the type of a top level account")
+var ErrLedgerPostingInvalid = errors.New("journal posting is invalid")
+
+// ledgerAccountTypePrefixes infers the type of an undeclared top level account from its name, as hledger does
+var ledgerAccountTypePrefixes = []struct { //nolint:gochecknoglobals
+	prefix      string
+	accountType datastore.AccountType
+}{
+	{"asset", datastore.AccountTypeAsset},
+	{"liabilit", datastore.AccountTypeLiability},
+	{"debt", datastore.AccountTypeLiability},
+	{"equity", datastore.AccountTypeEquity},
+	{"income", datastore.AccountTypeIncome},
+	{"revenue", datastore.AccountTypeIncome},
+	{"expense", datastore.AccountTypeExpense},
+	{"gain", datastore.AccountTypeGain},
+	{"loss", datastore.AccountTypeLoss},
+}
+
+const (
+	// DefaultBeancountTitle is the title of a beancount export when none is requested
+	DefaultBeancountTitle = "MimirLedger"
+	// DefaultBeancountCurrency is the commodity of a beancount export when none is requested
+	DefaultBeancountCurrency = "USD"
+)
+
+// ExportLedger writes every account and transaction as a journal that ledger-cli and hledger read
+func ExportLedger(dStores *datastore.Datastores, writer io.Writer) error {
+	journal, err := plainTextJournal(dStores)
+	if err != nil {
+		return err
+	}
+
+	if err = plaintext.WriteLedger(writer, journal); err != nil {
+		return fmt.Errorf("plaintext.WriteLedger:%w", err)
+	}
+
+	return nil
+}
+
+// ExportBeancount writes every account and transaction as a beancount file with the title and currency, an empty
+// title or currency is DefaultBeancountTitle or DefaultBeancountCurrency
+func ExportBeancount(dStores *datastore.Datastores, writer io.Writer, title, currency string) error {
+	journal, err := plainTextJournal(dStores)
+	if err != nil {
+		return err
+	}
+
+	if title == "" {
+		title = DefaultBeancountTitle
+	}
+
+	if currency == "" {
+		currency = DefaultBeancountCurrency
+	}
+
+	err = plaintext.WriteBeancount(writer, journal, plaintext.BeancountOptions{Title: title, Currency: currency})
+	if err != nil {
+		return fmt.Errorf("plaintext.WriteBeancount:%w", err)
+	}
+
+	return nil
+}
+
+// plainTextJournal is the whole ledger as a plaintext.Journal, accounts are named by their full names and only top
+// level accounts have a type, as subaccounts take the type of their parent.  Debits are positive amounts, and each
+// posting is cleared when its line is reconciled.
+func plainTextJournal(dStores *datastore.Datastores) (*plaintext.Journal, error) {
+	accounts, err := RetrieveAccounts(dStores)
+	if err != nil {
+		return nil, fmt.Errorf("RetrieveAccounts:%w", err)
+	}
+
+	journal := plaintext.Journal{
+		Accounts:     make([]*plaintext.Account, 0, len(accounts)),
+		Transactions: nil,
+	}
+
+	decimals := make(map[uint64]uint64, len(accounts))
+
+	for _, account := range accounts {
+		decimals[account.AccountID] = account.AccountDecimals
+
+		journalAccount := plaintext.Account{Name: account.AccountFullName, Type: "", Memo: account.AccountMemo,
+			OpenDate: account.AccountOpenDate, CloseDate: account.AccountCloseDate.Time}
+		if account.AccountParent == 0 {
+			journalAccount.Type = string(account.AccountType)
+		}
+
+		journal.Accounts = append(journal.Accounts, &journalAccount)
+	}
+
+	myJournal, err := RetrieveJournal(dStores, &TransactionJournalFilter{}) //nolint:exhaustruct
+	if err != nil {
+		return nil, fmt.Errorf("RetrieveJournal:%w", err)
+	}
+
+	journal.Transactions = make([]*plaintext.Transaction, 0, len(myJournal.Transactions))
+
+	for _, txn := range myJournal.Transactions {
+		journalTxn := plaintext.Transaction{
+			Line:          0,
+			Date:          txn.TransactionDate,
+			Cleared:       txn.IsReconciled,
+			ReconcileDate: txn.TransactionReconcileDate.Time,
+			Code:          txn.TransactionReference,
+			Description:   txn.TransactionComment,
+			Note:          "",
+			Postings:      make([]*plaintext.Posting, 0, len(txn.DebitCreditSet)),
+		}
+
+		for _, debitCredit := range txn.DebitCreditSet {
+			amount := int64(debitCredit.TransactionDCAmount) //nolint:gosec
+			if debitCredit.DebitOrCredit == datastore.AccountSignCredit {
+				amount = -amount
+			}
+
+			journalTxn.Postings = append(journalTxn.Postings, &plaintext.Posting{
+				Account:       debitCredit.AccountFullName,
+				Amount:        money.FormatPlain(amount, decimals[debitCredit.AccountID]),
+				Cleared:       debitCredit.IsReconciled,
+				ReconcileDate: debitCredit.TransactionReconcileDate.Time,
+			})
+		}
+
+		journal.Transactions = append(journal.Transactions, &journalTxn)
+	}
+
+	return &journal, nil
+}
+
+// ledgerImport is the state of a journal import, accounts by their full name
+type ledgerImport struct {
+	dStores  *datastore.Datastores
+	declared map[string]*plaintext.Account
+	accounts map[string]*Account
+	summary  LedgerImportSummary
+}
+
+// ImportLedgerJournal imports a ledger-cli or hledger journal.  Accounts are created along the path of their full
+// names, or reused when an account with the same name is already under the same parent.  A top level account
+// takes its declared type, or else the type its name suggests, ie Assets or Expenses.  A cleared posting is
+// reconciled on its reconciled tag date, or else that of its transaction, or else the transaction date, and a
+// transaction is reconciled when all of its postings are.  Every transaction is checked before any is stored, so a
+// journal with an invalid posting imports no transactions.
+func ImportLedgerJournal(dStores *datastore.Datastores, journal *plaintext.Journal) (*LedgerImportSummary,
+	error) {
+	myImport := ledgerImport{
+		dStores:  dStores,
+		declared: make(map[string]*plaintext.Account, len(journal.Accounts)),
+		accounts: make(map[string]*Account),
+		summary: LedgerImportSummary{AccountsCreated: 0, AccountsReused: 0, TransactionsImported: 0,
+			TransactionsSkipped: 0},
+	}
+
+	for _, account := range journal.Accounts {
+		myImport.declared[account.Name] = account
+	}
+
+	for _, account := range journal.Accounts {
+		if _, err := myImport.account(account.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	txns := make([]*Transaction, 0, len(journal.Transactions))
+
+	for _, journalTxn := range journal.Transactions {
+		txn, err := myImport.transaction(journalTxn)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(txn.DebitCreditSet) == 0 {
+			myImport.summary.TransactionsSkipped++
+
+			continue
+		}
+
+		if err = txn.validate(); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrLedgerPostingInvalid, journalTxn.Line, err)
+		}
+
+		txns = append(txns, txn)
+	}
+
+	for _, txn := range txns {
+		if err := txn.Store(dStores); err != nil {
+			return nil, fmt.Errorf("txn.Store:%w", err)
+		}
+
+		myImport.summary.TransactionsImported++
+	}
+
+	return &myImport.summary, nil
+}
+
+// account is the account with a full name, it and its parents are created when they are missing
+func (c *ledgerImport) account(fullName string) (*Account, error) {
+	if account, ok := c.accounts[fullName]; ok {
+		return account, nil
+	}
+
+	var parent *Account
+
+	path, name := "", fullName
+	if idx := strings.LastIndex(fullName, plaintext.AccountSeparator); idx >= 0 {
+		path, name = fullName[:idx], fullName[idx+1:]
+
+		var err error
+		if parent, err = c.account(path); err != nil {
+			return nil, err
+		}
+	}
+
+	var parentID uint64
+	if parent != nil {
+		parentID = parent.AccountID
+	}
+
+	account, err := findAccountByName(c.dStores, parentID, name)
+	if err != nil {
+		return nil, fmt.Errorf("findAccountByName:%w", err)
+	}
+
+	if account != nil {
+		c.summary.AccountsReused++
+		c.accounts[fullName] = account
+
+		return account, nil
+	}
+
+	account = &Account{AccountParent: parentID, AccountName: name} //nolint:exhaustruct
+
+	if declared, ok := c.declared[fullName]; ok {
+		account.AccountMemo = declared.Memo
+	}
+
+	if parentID == 0 {
+		if account.AccountType, err = c.accountType(fullName); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = account.Store(c.dStores); err != nil {
+		return nil, fmt.Errorf("account.Store:%w [account:%s]", err, fullName)
+	}
+
+	c.summary.AccountsCreated++
+	c.accounts[fullName] = account
+
+	return account, nil
+}
+
+// accountType is the declared type of a top level account, or else the type its name suggests
+func (c *ledgerImport) accountType(name string) (datastore.AccountType, error) {
+	if declared, ok := c.declared[name]; ok && declared.Type != "" {
+		accountType := datastore.AccountType(declared.Type)
+		if _, ok = datastore.AccountTypeToSign[accountType]; !ok {
+			return "", fmt.Errorf("%w: %s is not a type [account:%s]", ErrLedgerAccountTypeUnknown, declared.Type,
+				name)
+		}
+
+		return accountType, nil
+	}
+
+	for _, inferred := range ledgerAccountTypePrefixes {
+		if strings.HasPrefix(strings.ToLower(name), inferred.prefix) {
+			return inferred.accountType, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w [account:%s]", ErrLedgerAccountTypeUnknown, name)
+}
+
+// transaction is the Transaction of a journal transaction, amounts are parsed with the decimals of their account
+func (c *ledgerImport) transaction(journalTxn *plaintext.Transaction) (*Transaction, error) {
+	txn := Transaction{
+		TransactionCore: TransactionCore{
+			TransactionID:            0,
+			TransactionDate:          journalTxn.Date,
+			TransactionReconcileDate: sql.NullTime{},
+			TransactionComment:       journalTxn.Comment(),
+			TransactionAmount:        0,
+			TransactionReference:     journalTxn.Reference(),
+			IsReconciled:             journalTxn.Cleared,
+			IsSplit:                  false,
+		},
+		DebitCreditSet: nil,
+	}
+
+	if journalTxn.Cleared {
+		reconcileDate := journalTxn.ReconcileDate
+		if reconcileDate.IsZero() {
+			reconcileDate = journalTxn.Date
+		}
+
+		txn.TransactionReconcileDate = sql.NullTime{Time: reconcileDate, Valid: true}
+	}
+
+	for _, posting := range journalTxn.Postings {
+		account, err := c.account(posting.Account)
+		if err != nil {
+			return nil, err
+		}
+
+		amount, err := money.Parse(posting.Amount, account.AccountDecimals, ".")
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w [account:%s]", ErrLedgerPostingInvalid, journalTxn.Line, err,
+				posting.Account)
+		}
+
+		if amount == 0 {
+			continue
+		}
+
+		debitCredit := importDebitCredit(account.AccountID, amount)
+		if posting.Cleared {
+			reconcileDate := posting.ReconcileDate
+			if reconcileDate.IsZero() {
+				reconcileDate = journalTxn.ReconcileDate
+			}
+
+			if reconcileDate.IsZero() {
+				reconcileDate = journalTxn.Date
+			}
+
+			debitCredit.IsReconciled = true
+			debitCredit.TransactionReconcileDate = sql.NullTime{Time: reconcileDate, Valid: true}
+		}
+
+		txn.DebitCreditSet = append(txn.DebitCreditSet, debitCredit)
+	}
+
+	txn.IsSplit = len(txn.DebitCreditSet) > 2 //nolint:mnd
+
+	return &txn, nil
+}
@@ -0,0 +1,166 @@
+package models
+
+import (
+	"bytes"
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/plaintext"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+// ledgerTestBalance is what a round trip must reproduce for an account
+type ledgerTestBalance struct {
+	accountType datastore.AccountType
+	subtotal    int64
+	balance     int64
+}
+
+func ledgerTestBalances(g *gomega.WithT) map[string]ledgerTestBalance {
+	accounts, err := RetrieveAccounts(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	balances := make(map[string]ledgerTestBalance, len(accounts))
+	for _, account := range accounts {
+		balances[account.AccountFullName] = ledgerTestBalance{accountType: account.AccountType,
+			subtotal: account.AccountSubtotal, balance: account.AccountBalance}
+	}
+
+	return balances
+}
+
+func TestLedgerJournal_RoundTrip(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	banks := Account{AccountName: "Banks", AccountType: datastore.AccountTypeAsset}
+	g.Expect(banks.Store(testDS)).To(gomega.Succeed())
+
+	checking := Account{AccountName: "Checking", AccountParent: banks.AccountID, AccountMemo: "joint account"}
+	g.Expect(checking.Store(testDS)).To(gomega.Succeed())
+
+	visa := Account{AccountName: "Visa", AccountType: datastore.AccountTypeLiability}
+	g.Expect(visa.Store(testDS)).To(gomega.Succeed())
+
+	salary := Account{AccountName: "Salary", AccountType: datastore.AccountTypeIncome}
+	g.Expect(salary.Store(testDS)).To(gomega.Succeed())
+
+	interest := Account{AccountName: "Interest", AccountType: datastore.AccountTypeGain}
+	g.Expect(interest.Store(testDS)).To(gomega.Succeed())
+
+	food := Account{AccountName: "Food", AccountType: datastore.AccountTypeExpense}
+	g.Expect(food.Store(testDS)).To(gomega.Succeed())
+
+	// an account without transactions is still exported
+	unused := Account{AccountName: "Unused", AccountParent: food.AccountID}
+	g.Expect(unused.Store(testDS)).To(gomega.Succeed())
+
+	paycheck := Transaction{TransactionCore: TransactionCore{TransactionComment: "paycheck; january",
+		TransactionDate: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), TransactionReference: "PAY1",
+		IsReconciled:             true,
+		TransactionReconcileDate: sql.NullTime{Time: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), Valid: true}},
+		DebitCreditSet: []*TransactionDebitCredit{
+			{AccountID: salary.AccountID, DebitOrCredit: datastore.AccountSignCredit, TransactionDCAmount: 250000},
+			{AccountID: interest.AccountID, DebitOrCredit: datastore.AccountSignCredit, TransactionDCAmount: 125},
+			{AccountID: checking.AccountID, DebitOrCredit: datastore.AccountSignDebit, TransactionDCAmount: 250125},
+		},
+	}
+	g.Expect(paycheck.Store(testDS)).To(gomega.Succeed())
+
+	groceries := Transaction{TransactionCore: TransactionCore{TransactionComment: "groceries",
+		TransactionDate: time.Date(2024, 1, 12, 0, 0, 0, 0, time.UTC)},
+		DebitCreditSet: []*TransactionDebitCredit{
+			{AccountID: visa.AccountID, DebitOrCredit: datastore.AccountSignCredit, TransactionDCAmount: 4525},
+			{AccountID: food.AccountID, DebitOrCredit: datastore.AccountSignDebit, TransactionDCAmount: 4525},
+		},
+	}
+	g.Expect(groceries.Store(testDS)).To(gomega.Succeed())
+
+	// only the bank side of the payment is reconciled
+	payment := Transaction{TransactionCore: TransactionCore{TransactionComment: "card payment",
+		TransactionDate: time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC)},
+		DebitCreditSet: []*TransactionDebitCredit{
+			{AccountID: checking.AccountID, DebitOrCredit: datastore.AccountSignCredit, TransactionDCAmount: 4525,
+				IsReconciled: true,
+				TransactionReconcileDate: sql.NullTime{Time: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+					Valid: true}},
+			{AccountID: visa.AccountID, DebitOrCredit: datastore.AccountSignDebit, TransactionDCAmount: 4525},
+		},
+	}
+	g.Expect(payment.Store(testDS)).To(gomega.Succeed())
+
+	before := ledgerTestBalances(g)
+
+	var exported bytes.Buffer
+	g.Expect(ExportLedger(testDS, &exported)).To(gomega.Succeed())
+	g.Expect(exported.String()).To(gomega.ContainSubstring("2024-01-10 * (PAY1) paycheck, january\n" +
+		"    ; reconciled: 2024-01-31\n"))
+	g.Expect(exported.String()).To(gomega.ContainSubstring("2024-01-20 card payment\n" +
+		"    * Banks:Checking  -45.25  ; reconciled: 2024-01-31\n"))
+
+	setupDB(g)
+
+	journal, err := plaintext.ParseLedger(bytes.NewReader(exported.Bytes()))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	summary, err := ImportLedgerJournal(testDS, journal)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(summary.AccountsCreated).To(gomega.Equal(7))
+	g.Expect(summary.TransactionsImported).To(gomega.Equal(3))
+
+	g.Expect(ledgerTestBalances(g)).To(gomega.Equal(before))
+
+	checkingAgain, err := findAccountByName(testDS, 0, "Banks")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	checkingAgain, err = findAccountByName(testDS, checkingAgain.AccountID, "Checking")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(checkingAgain.AccountMemo).To(gomega.Equal("joint account"))
+
+	// exporting the imported ledger gives the same journal
+	var reexported bytes.Buffer
+	g.Expect(ExportLedger(testDS, &reexported)).To(gomega.Succeed())
+	g.Expect(reexported.String()).To(gomega.Equal(exported.String()))
+}
+
+func TestImportLedgerJournal(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	file, err := os.Open("../plaintext/testdata/household.journal")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	defer file.Close()
+
+	journal, err := plaintext.ParseLedger(file)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	summary, err := ImportLedgerJournal(testDS, journal)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(summary.TransactionsImported).To(gomega.Equal(4))
+
+	balances := ledgerTestBalances(g)
+	g.Expect(balances["Assets:Bank:Checking"].subtotal).To(gomega.Equal(int64(430050)))
+	g.Expect(balances["Assets"].balance).To(gomega.Equal(int64(430050)))
+	g.Expect(balances["Equity:Opening Balances"].accountType).To(gomega.Equal(datastore.AccountTypeEquity))
+	g.Expect(balances["Liabilities:Visa"].subtotal).To(gomega.Equal(int64(5000)))
+	g.Expect(balances["Income:Salary"].subtotal).To(gomega.Equal(int64(300050)))
+	g.Expect(balances["Expenses:Food"].balance).To(gomega.Equal(int64(5000)))
+
+	// importing again reuses every account
+	summary, err = ImportLedgerJournal(testDS, journal)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(summary.AccountsCreated).To(gomega.Equal(0))
+
+	unknown, err := plaintext.ParseLedger(strings.NewReader("2024-01-01 Test\n    Cash  10\n    Expenses:Food\n"))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	_, err = ImportLedgerJournal(testDS, unknown)
+	g.Expect(err).To(gomega.MatchError(ErrLedgerAccountTypeUnknown))
+}
@@ -0,0 +1,324 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+)
+
+// MemorizedTransaction is a named template of a multi-line transaction such as a payroll split.  The amounts of its
+// lines are fixed, filled in when it is used, a percent of an amount filled in, or what balances the other lines.
+type MemorizedTransaction struct {
+	MemorizedID          uint64
+	MemorizedName        string
+	TransactionComment   string
+	TransactionReference string
+	Lines                []*datastore.MemorizedLine
+}
+
+// MemorizedParameters is what is filled in when a MemorizedTransaction is used.  Amounts are by the ParameterName of
+// the lines, the comment and reference of the template are used when they are not set.
+type MemorizedParameters struct {
+	TransactionDate      time.Time
+	TransactionComment   string
+	TransactionReference string
+	Amounts              map[string]uint64
+}
+
+// memorizedDefaultParameter is the parameter of an AMOUNT or PERCENT line that does not name one
+const memorizedDefaultParameter = "amount"
+
+var ErrMemorizedTransactionNotFound = errors.New("memorized transaction not found")
+var ErrMemorizedTransactionInvalid = errors.New("memorized transaction is invalid")
+var ErrMemorizedParametersInvalid = errors.New("memorized transaction parameters are invalid")
+
+// Store inserts a MemorizedTransaction, the template must be valid before it is stored
+func (c *MemorizedTransaction) Store(dStores *datastore.Datastores) error {
+	if err := c.validate(dStores); err != nil {
+		return fmt.Errorf("c.validate:%w", err)
+	}
+
+	eMemorized := memorizedTransactionToEntMemorizedTransaction(c)
+
+	if err := dStores.MemorizedTransactionStore().Store(&eMemorized); err != nil {
+		return fmt.Errorf("ds.MemorizedTransactionStore().Store:%w [MemorizedTransaction:%s]", err,
+			eMemorized.MemorizedName)
+	}
+
+	*c = *entMemorizedTransactionToMemorizedTransaction(&eMemorized)
+
+	return nil
+}
+
+// Update updates a MemorizedTransaction, the transactions already made from it are not changed
+func (c *MemorizedTransaction) Update(dStores *datastore.Datastores) error {
+	if err := c.validate(dStores); err != nil {
+		return fmt.Errorf("c.validate:%w", err)
+	}
+
+	eMemorized := memorizedTransactionToEntMemorizedTransaction(c)
+
+	if err := dStores.MemorizedTransactionStore().Update(&eMemorized); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrMemorizedTransactionNotFound
+		}
+
+		return fmt.Errorf("ds.MemorizedTransactionStore().Update:%w [MemorizedTransaction:%s]", err,
+			eMemorized.MemorizedName)
+	}
+
+	*c = *entMemorizedTransactionToMemorizedTransaction(&eMemorized)
+
+	return nil
+}
+
+// Delete deletes a MemorizedTransaction, the transactions made from it are kept
+func (c *MemorizedTransaction) Delete(dStores *datastore.Datastores) error {
+	eMemorized := memorizedTransactionToEntMemorizedTransaction(c)
+
+	if err := dStores.MemorizedTransactionStore().Delete(&eMemorized); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrMemorizedTransactionNotFound
+		}
+
+		return fmt.Errorf("ds.MemorizedTransactionStore().Delete:%w [MemorizedTransaction:%+v]", err, c)
+	}
+
+	return nil
+}
+
+func (c *MemorizedTransaction) validate(dStores *datastore.Datastores) error { //nolint:cyclop
+	if strings.TrimSpace(c.MemorizedName) == "" {
+		return fmt.Errorf("%w: name cannot be empty", ErrMemorizedTransactionInvalid)
+	}
+
+	if len(c.Lines) < 2 { //nolint:mnd
+		return fmt.Errorf("%w: needs at least two lines", ErrMemorizedTransactionInvalid)
+	}
+
+	balanceLines := 0
+
+	for _, line := range c.Lines {
+		if line.AccountID == 0 {
+			return fmt.Errorf("%w: a line needs an accountID", ErrMemorizedTransactionInvalid)
+		}
+
+		if line.DebitOrCredit != datastore.AccountSignDebit && line.DebitOrCredit != datastore.AccountSignCredit {
+			return fmt.Errorf("%w: line debitOrCredit %q is neither", ErrMemorizedTransactionInvalid,
+				line.DebitOrCredit)
+		}
+
+		line.ParameterName = strings.TrimSpace(line.ParameterName)
+
+		switch line.LineType {
+		case datastore.MemorizedLineFixed:
+			if line.Amount == 0 {
+				return fmt.Errorf("%w: a %s line needs an amount", ErrMemorizedTransactionInvalid, line.LineType)
+			}
+		case datastore.MemorizedLineAmount:
+			if line.ParameterName == "" {
+				line.ParameterName = memorizedDefaultParameter
+			}
+		case datastore.MemorizedLinePercent:
+			if line.Percent <= 0 || line.Percent > rulePercentTotal {
+				return fmt.Errorf("%w: line percent %v is not between 0 and 100", ErrMemorizedTransactionInvalid,
+					line.Percent)
+			}
+
+			if line.ParameterName == "" {
+				line.ParameterName = memorizedDefaultParameter
+			}
+		case datastore.MemorizedLineBalance:
+			balanceLines++
+		default:
+			return fmt.Errorf("%w: unknown line type %q", ErrMemorizedTransactionInvalid, line.LineType)
+		}
+	}
+
+	if balanceLines > 1 {
+		return fmt.Errorf("%w: only one line can be %s", ErrMemorizedTransactionInvalid,
+			datastore.MemorizedLineBalance)
+	}
+
+	for _, line := range c.Lines {
+		if _, err := RetrieveAccountByID(dStores, line.AccountID); err != nil {
+			return fmt.Errorf("RetrieveAccountByID:%w", err)
+		}
+	}
+
+	return nil
+}
+
+// Transaction builds the transaction the template makes with params, it is validated but not stored.  Lines that
+// work out to zero are left out, so deductions that do not apply can be filled in as 0.  PERCENT lines are rounded to
+// the cent, a BALANCE line takes up the rounding.
+func (c *MemorizedTransaction) Transaction(params *MemorizedParameters) (*Transaction, error) { //nolint:cyclop
+	comment, reference := c.TransactionComment, c.TransactionReference
+	if params.TransactionComment != "" {
+		comment = params.TransactionComment
+	}
+
+	if params.TransactionReference != "" {
+		reference = params.TransactionReference
+	}
+
+	amounts := make([]uint64, len(c.Lines))
+	balanceIdx := -1
+
+	var debitTotal, creditTotal uint64
+
+	for idx, line := range c.Lines {
+		if line.LineType == datastore.MemorizedLineBalance {
+			balanceIdx = idx
+
+			continue
+		}
+
+		amount, err := memorizedLineAmount(line, params.Amounts)
+		if err != nil {
+			return nil, err
+		}
+
+		amounts[idx] = amount
+
+		if line.DebitOrCredit == datastore.AccountSignDebit {
+			debitTotal += amount
+		} else {
+			creditTotal += amount
+		}
+	}
+
+	if balanceIdx >= 0 {
+		over, under := creditTotal, debitTotal
+		if c.Lines[balanceIdx].DebitOrCredit == datastore.AccountSignCredit {
+			over, under = debitTotal, creditTotal
+		}
+
+		if over < under {
+			return nil, fmt.Errorf("%w: the %s line would be negative", ErrMemorizedParametersInvalid,
+				datastore.MemorizedLineBalance)
+		}
+
+		amounts[balanceIdx] = over - under
+	}
+
+	myTxn := Transaction{
+		TransactionCore: TransactionCore{
+			TransactionID:            0,
+			TransactionDate:          params.TransactionDate,
+			TransactionReconcileDate: sql.NullTime{Time: time.Time{}, Valid: false},
+			TransactionComment:       comment,
+			TransactionAmount:        0,
+			TransactionReference:     reference,
+			IsReconciled:             false,
+			IsSplit:                  false,
+		},
+		DebitCreditSet: nil,
+	}
+
+	for idx, line := range c.Lines {
+		if amounts[idx] == 0 {
+			continue
+		}
+
+		myTxn.DebitCreditSet = append(myTxn.DebitCreditSet, &TransactionDebitCredit{
+			TransactionDCID:          0,
+			TransactionID:            0,
+			AccountID:                line.AccountID,
+			TransactionDCAmount:      amounts[idx],
+			DebitOrCredit:            line.DebitOrCredit,
+			IsReconciled:             false,
+			TransactionReconcileDate: sql.NullTime{Time: time.Time{}, Valid: false},
+		})
+	}
+
+	if err := myTxn.validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMemorizedParametersInvalid, err)
+	}
+
+	total, err := myTxn.transactionTotal()
+	if err != nil {
+		return nil, fmt.Errorf("myTxn.transactionTotal:%w", err)
+	}
+
+	myTxn.TransactionAmount = total
+
+	return &myTxn, nil
+}
+
+// memorizedLineAmount is the amount of a line that is not a BALANCE line
+func memorizedLineAmount(line *datastore.MemorizedLine, amounts map[string]uint64) (uint64, error) {
+	if line.LineType == datastore.MemorizedLineFixed {
+		return line.Amount, nil
+	}
+
+	amount, ok := amounts[line.ParameterName]
+	if !ok {
+		return 0, fmt.Errorf("%w: amount %q is not filled in", ErrMemorizedParametersInvalid, line.ParameterName)
+	}
+
+	if line.LineType == datastore.MemorizedLinePercent {
+		return uint64(math.Round(float64(amount) * line.Percent / rulePercentTotal)), nil
+	}
+
+	return amount, nil
+}
+
+// RetrieveMemorizedTransactionByID retrieves a MemorizedTransaction
+func RetrieveMemorizedTransactionByID(dStores *datastore.Datastores,
+	memorizedID uint64) (*MemorizedTransaction, error) {
+	eMemorized, err := dStores.MemorizedTransactionStore().RetrieveByID(memorizedID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMemorizedTransactionNotFound
+		}
+
+		return nil, fmt.Errorf("ds.MemorizedTransactionStore().RetrieveByID:%w", err)
+	}
+
+	return entMemorizedTransactionToMemorizedTransaction(eMemorized), nil
+}
+
+// RetrieveMemorizedTransactions retrieves all MemorizedTransactions in name order
+func RetrieveMemorizedTransactions(dStores *datastore.Datastores) ([]*MemorizedTransaction, error) {
+	eMemorizedSet, err := dStores.MemorizedTransactionStore().Retrieve()
+	if err != nil {
+		return nil, fmt.Errorf("ds.MemorizedTransactionStore().Retrieve:%w", err)
+	}
+
+	memorizedSet := make([]*MemorizedTransaction, len(eMemorizedSet))
+
+	for idx := range eMemorizedSet {
+		memorizedSet[idx] = entMemorizedTransactionToMemorizedTransaction(eMemorizedSet[idx])
+	}
+
+	return memorizedSet, nil
+}
+
+func memorizedTransactionToEntMemorizedTransaction(memorized *MemorizedTransaction) datastore.MemorizedTransaction {
+	return datastore.MemorizedTransaction{
+		MemorizedID:   memorized.MemorizedID,
+		MemorizedName: strings.TrimSpace(memorized.MemorizedName),
+		MemorizedBody: datastore.MemorizedBody{
+			TransactionComment:   memorized.TransactionComment,
+			TransactionReference: memorized.TransactionReference,
+			Lines:                memorized.Lines,
+		},
+	}
+}
+
+func entMemorizedTransactionToMemorizedTransaction(
+	eMemorized *datastore.MemorizedTransaction) *MemorizedTransaction {
+	return &MemorizedTransaction{
+		MemorizedID:          eMemorized.MemorizedID,
+		MemorizedName:        eMemorized.MemorizedName,
+		TransactionComment:   eMemorized.MemorizedBody.TransactionComment,
+		TransactionReference: eMemorized.MemorizedBody.TransactionReference,
+		Lines:                eMemorized.MemorizedBody.Lines,
+	}
+}
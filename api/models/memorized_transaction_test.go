@@ -0,0 +1,154 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestMemorizedTransaction_Transaction(t *testing.T) { //nolint:funlen
+	payrollSplit := MemorizedTransaction{
+		MemorizedID:          1,
+		MemorizedName:        "payroll split",
+		TransactionComment:   "payroll",
+		TransactionReference: "",
+		Lines: []*datastore.MemorizedLine{
+			{AccountID: 1, DebitOrCredit: datastore.AccountSignCredit, LineType: datastore.MemorizedLineAmount,
+				ParameterName: "gross"},
+			{AccountID: 2, DebitOrCredit: datastore.AccountSignDebit, LineType: datastore.MemorizedLinePercent,
+				Percent: 22, ParameterName: "gross"},
+			{AccountID: 3, DebitOrCredit: datastore.AccountSignDebit, LineType: datastore.MemorizedLinePercent,
+				Percent: 7.65, ParameterName: "gross"},
+			{AccountID: 4, DebitOrCredit: datastore.AccountSignDebit, LineType: datastore.MemorizedLineFixed,
+				Amount: 12000},
+			{AccountID: 5, DebitOrCredit: datastore.AccountSignDebit, LineType: datastore.MemorizedLineAmount,
+				ParameterName: "retirement"},
+			{AccountID: 6, DebitOrCredit: datastore.AccountSignDebit, LineType: datastore.MemorizedLineBalance},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		params      MemorizedParameters
+		wantAmounts map[uint64]uint64
+		wantTotal   uint64
+		wantComment string
+		wantErr     error
+	}{
+		{
+			name: "payroll",
+			params: MemorizedParameters{TransactionDate: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+				Amounts: map[string]uint64{"gross": 512345, "retirement": 25000}},
+			// 22% is 112715.9 and 7.65% is 39194.39, net pay takes up the rounding
+			wantAmounts: map[uint64]uint64{1: 512345, 2: 112716, 3: 39194, 4: 12000, 5: 25000, 6: 323435},
+			wantTotal:   512345,
+			wantComment: "payroll",
+		},
+		{
+			name: "zero amount is left out",
+			params: MemorizedParameters{TransactionComment: "bonus",
+				Amounts: map[string]uint64{"gross": 100000, "retirement": 0}},
+			wantAmounts: map[uint64]uint64{1: 100000, 2: 22000, 3: 7650, 4: 12000, 6: 58350},
+			wantTotal:   100000,
+			wantComment: "bonus",
+		},
+		{
+			name:    "missing amount",
+			params:  MemorizedParameters{Amounts: map[string]uint64{"gross": 100000}},
+			wantErr: ErrMemorizedParametersInvalid,
+		},
+		{
+			name:    "negative balance",
+			params:  MemorizedParameters{Amounts: map[string]uint64{"gross": 10000, "retirement": 0}},
+			wantErr: ErrMemorizedParametersInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := gomega.NewWithT(t)
+			gomega.RegisterFailHandler(ginkgo.Fail)
+
+			myTxn, err := payrollSplit.Transaction(&tt.params)
+			if tt.wantErr != nil {
+				g.Expect(err).To(gomega.MatchError(tt.wantErr))
+
+				return
+			}
+
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(myTxn.TransactionComment).To(gomega.Equal(tt.wantComment))
+			g.Expect(myTxn.TransactionDate).To(gomega.Equal(tt.params.TransactionDate))
+			g.Expect(myTxn.TransactionAmount).To(gomega.Equal(tt.wantTotal))
+
+			amounts := map[uint64]uint64{}
+			for _, debitCredit := range myTxn.DebitCreditSet {
+				amounts[debitCredit.AccountID] = debitCredit.TransactionDCAmount
+			}
+
+			g.Expect(amounts).To(gomega.Equal(tt.wantAmounts))
+		})
+	}
+}
+
+func TestMemorizedTransaction_Store(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	checking := Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	payroll := Account{AccountName: "Payroll", AccountSign: datastore.AccountSignCredit,
+		AccountType: datastore.AccountTypeIncome}
+	err = payroll.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	memorized := MemorizedTransaction{
+		MemorizedName:        "pay",
+		TransactionComment:   "pay",
+		TransactionReference: "",
+		Lines: []*datastore.MemorizedLine{
+			{AccountID: payroll.AccountID, DebitOrCredit: datastore.AccountSignCredit,
+				LineType: datastore.MemorizedLineAmount},
+			{AccountID: checking.AccountID, DebitOrCredit: datastore.AccountSignDebit,
+				LineType: datastore.MemorizedLineBalance},
+		},
+	}
+
+	twoBalances := memorized
+	twoBalances.Lines = []*datastore.MemorizedLine{
+		{AccountID: payroll.AccountID, DebitOrCredit: datastore.AccountSignCredit,
+			LineType: datastore.MemorizedLineBalance},
+		{AccountID: checking.AccountID, DebitOrCredit: datastore.AccountSignDebit,
+			LineType: datastore.MemorizedLineBalance},
+	}
+	err = twoBalances.Store(testDS)
+	g.Expect(err).To(gomega.MatchError(ErrMemorizedTransactionInvalid))
+
+	err = memorized.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(memorized.Lines[0].ParameterName).To(gomega.Equal("amount"))
+
+	retrieved, err := RetrieveMemorizedTransactionByID(testDS, memorized.MemorizedID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(retrieved).To(gomega.Equal(&memorized))
+
+	myTxn, err := retrieved.Transaction(&MemorizedParameters{TransactionDate: time.Now(),
+		Amounts: map[string]uint64{"amount": 250000}})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	err = myTxn.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	err = retrieved.Delete(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	_, err = RetrieveMemorizedTransactionByID(testDS, memorized.MemorizedID)
+	g.Expect(err).To(gomega.MatchError(ErrMemorizedTransactionNotFound))
+}
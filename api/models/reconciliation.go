@@ -0,0 +1,364 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+)
+
+// ReconciliationSession is a statement being reconciled against an account.  Transactions are ticked in and out of
+// the session as they are found on the statement, and the session can be finished once the opening reconciled balance
+// plus the ticked transactions equals the statement balance.
+type ReconciliationSession struct {
+	SessionID        uint64
+	AccountID        uint64
+	StatementDate    time.Time
+	StatementBalance int64
+	OpeningBalance   int64
+	SessionStatus    datastore.ReconciliationStatus
+	CreatedDate      time.Time
+	FinishedDate     sql.NullTime
+	// TransactionIDs are the ticked transactions
+	TransactionIDs []uint64
+	// ClearedBalance is the opening balance plus the ticked transactions, in the sign of the account.  Once the
+	// session is finished it is the balance that was cleared, whatever happens to the transactions later.
+	ClearedBalance int64
+	// Difference is what is left to clear, the session can be finished when it is zero
+	Difference int64
+	// ClearedCount is how many transactions were cleared when the session was finished
+	ClearedCount int
+	// FinishedBy is who finished the session
+	FinishedBy string
+}
+
+var ErrReconciliationSessionNotFound = errors.New("reconciliation session not found")
+var ErrReconciliationSessionInvalid = errors.New("reconciliation session is invalid")
+var ErrReconciliationSessionNotOpen = errors.New("reconciliation session is not open")
+var ErrReconciliationSessionNotFinished = errors.New("reconciliation session is not finished")
+var ErrReconciliationSessionAlreadyOpen = errors.New("account already has an open reconciliation session")
+var ErrReconciliationSessionNotBalanced = errors.New("reconciliation session difference is not zero")
+var ErrReconciliationTransactionInvalid = errors.New("transaction cannot be reconciled in this session")
+var ErrReconciliationSessionChanged = errors.New("reconciliation session transactions changed since it was loaded")
+
+// NewReconciliationSession starts reconciling a statement against an account.  The opening balance is the reconciled
+// subtotal of the account as of its reconcile date, and the statement cannot be dated before that.
+func NewReconciliationSession(dStores *datastore.Datastores, accountID uint64, statementDate time.Time,
+	statementBalance int64) (*ReconciliationSession, error) {
+	account, err := RetrieveAccountByID(dStores, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("RetrieveAccountByID:%w", err)
+	}
+
+	if statementDate.IsZero() {
+		return nil, fmt.Errorf("%w: statementDate is required", ErrReconciliationSessionInvalid)
+	}
+
+	if account.AccountReconcileDate.Valid && statementDate.Before(account.AccountReconcileDate.Time) {
+		return nil, fmt.Errorf("%w: statementDate is before the account reconcile date %s",
+			ErrReconciliationSessionInvalid, account.AccountReconcileDate.Time.Format(time.DateOnly))
+	}
+
+	sessions, err := dStores.ReconciliationStore().RetrieveByAccount(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("ds.ReconciliationStore().RetrieveByAccount:%w", err)
+	}
+
+	for _, session := range sessions {
+		if session.SessionStatus == datastore.ReconciliationStatusOpen {
+			return nil, fmt.Errorf("%w [session:%d]", ErrReconciliationSessionAlreadyOpen, session.SessionID)
+		}
+	}
+
+	openingBalance, err := GetReconciledSubtotal(dStores, account.AccountLeft, account.AccountRight,
+		account.AccountReconcileDate.Time, account.AccountSign)
+	if err != nil {
+		return nil, fmt.Errorf("GetReconciledSubtotal:%w", err)
+	}
+
+	eSession := datastore.ReconciliationSession{
+		SessionID:        0,
+		AccountID:        accountID,
+		StatementDate:    statementDate,
+		StatementBalance: statementBalance,
+		OpeningBalance:   openingBalance,
+		SessionStatus:    datastore.ReconciliationStatusOpen,
+		CreatedDate:      time.Time{},
+		FinishedDate:     sql.NullTime{Time: time.Time{}, Valid: false},
+		ClearedCount:     0,
+		ClearedBalance:   0,
+		FinishedBy:       "",
+	}
+
+	if err = dStores.ReconciliationStore().Store(&eSession); err != nil {
+		return nil, fmt.Errorf("ds.ReconciliationStore().Store:%w", err)
+	}
+
+	session := entReconciliationSessionToReconciliationSession(&eSession)
+
+	if err = session.loadCleared(dStores, account); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// RetrieveReconciliationSessionByID retrieves a session with its ticked transactions and difference
+func RetrieveReconciliationSessionByID(dStores *datastore.Datastores,
+	sessionID uint64) (*ReconciliationSession, error) {
+	eSession, err := dStores.ReconciliationStore().RetrieveByID(sessionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReconciliationSessionNotFound
+		}
+
+		return nil, fmt.Errorf("ds.ReconciliationStore().RetrieveByID:%w", err)
+	}
+
+	session := entReconciliationSessionToReconciliationSession(eSession)
+
+	account, err := RetrieveAccountByID(dStores, session.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("RetrieveAccountByID:%w", err)
+	}
+
+	if err = session.loadCleared(dStores, account); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// RetrieveReconciliationSessions retrieves the sessions of an account, the latest statement first
+func RetrieveReconciliationSessions(dStores *datastore.Datastores,
+	accountID uint64) ([]*ReconciliationSession, error) {
+	account, err := RetrieveAccountByID(dStores, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("RetrieveAccountByID:%w", err)
+	}
+
+	eSessions, err := dStores.ReconciliationStore().RetrieveByAccount(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("ds.ReconciliationStore().RetrieveByAccount:%w", err)
+	}
+
+	sessions := make([]*ReconciliationSession, len(eSessions))
+
+	for idx := range eSessions {
+		sessions[idx] = entReconciliationSessionToReconciliationSession(eSessions[idx])
+
+		if err = sessions[idx].loadCleared(dStores, account); err != nil {
+			return nil, err
+		}
+	}
+
+	return sessions, nil
+}
+
+// TickTransaction adds a transaction to the session.  The transaction must post to the account or one of its
+// children, be unreconciled there, and be dated on or before the statement date.
+func (c *ReconciliationSession) TickTransaction(dStores *datastore.Datastores, transactionID uint64) error {
+	if c.SessionStatus != datastore.ReconciliationStatusOpen {
+		return ErrReconciliationSessionNotOpen
+	}
+
+	account, err := RetrieveAccountByID(dStores, c.AccountID)
+	if err != nil {
+		return fmt.Errorf("RetrieveAccountByID:%w", err)
+	}
+
+	myTxn, err := RetrieveTransactionByID(dStores, transactionID)
+	if err != nil {
+		return fmt.Errorf("RetrieveTransactionByID:%w", err)
+	}
+
+	if err = c.validateTransaction(dStores, account, myTxn); err != nil {
+		return err
+	}
+
+	if err = dStores.ReconciliationStore().StoreTransaction(c.SessionID, transactionID); err != nil {
+		return fmt.Errorf("ds.ReconciliationStore().StoreTransaction:%w", err)
+	}
+
+	return c.loadCleared(dStores, account)
+}
+
+// UntickTransaction removes a transaction from the session
+func (c *ReconciliationSession) UntickTransaction(dStores *datastore.Datastores, transactionID uint64) error {
+	if c.SessionStatus != datastore.ReconciliationStatusOpen {
+		return ErrReconciliationSessionNotOpen
+	}
+
+	account, err := RetrieveAccountByID(dStores, c.AccountID)
+	if err != nil {
+		return fmt.Errorf("RetrieveAccountByID:%w", err)
+	}
+
+	if err = dStores.ReconciliationStore().DeleteTransaction(c.SessionID, transactionID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: transaction %d is not in the session", ErrReconciliationTransactionInvalid,
+				transactionID)
+		}
+
+		return fmt.Errorf("ds.ReconciliationStore().DeleteTransaction:%w", err)
+	}
+
+	return c.loadCleared(dStores, account)
+}
+
+// Finish marks the lines of the ticked transactions on the account reconciled on the statement date and advances
+// the reconcile date of the account, all at once.  The difference must be zero, and stay zero with the ticked
+// transactions as they are when the session is finished.  The cleared transactions are kept as they are now, for
+// the reconciliation report.
+func (c *ReconciliationSession) Finish(dStores *datastore.Datastores, finishedBy string) error {
+	if c.SessionStatus != datastore.ReconciliationStatusOpen {
+		return ErrReconciliationSessionNotOpen
+	}
+
+	if c.Difference != 0 {
+		return fmt.Errorf("%w [difference:%d]", ErrReconciliationSessionNotBalanced, c.Difference)
+	}
+
+	account, err := RetrieveAccountByID(dStores, c.AccountID)
+	if err != nil {
+		return fmt.Errorf("RetrieveAccountByID:%w", err)
+	}
+
+	c.ClearedCount = len(c.TransactionIDs)
+	c.FinishedBy = finishedBy
+	eSession := reconciliationSessionToEntReconciliationSession(c)
+
+	err = dStores.ReconciliationStore().Finish(&eSession, account.AccountLeft, account.AccountRight,
+		account.AccountSign)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrReconciliationSessionNotOpen
+		case errors.Is(err, datastore.ErrReconciliationChanged):
+			return fmt.Errorf("%w: %w", ErrReconciliationSessionChanged, err)
+		}
+
+		return fmt.Errorf("ds.ReconciliationStore().Finish:%w", err)
+	}
+
+	c.SessionStatus = eSession.SessionStatus
+	c.FinishedDate = eSession.FinishedDate
+	c.ClearedCount = eSession.ClearedCount
+	c.FinishedBy = eSession.FinishedBy
+
+	return nil
+}
+
+// Delete abandons an open session, nothing it ticked is reconciled
+func (c *ReconciliationSession) Delete(dStores *datastore.Datastores) error {
+	eSession := reconciliationSessionToEntReconciliationSession(c)
+
+	if err := dStores.ReconciliationStore().Delete(&eSession); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrReconciliationSessionNotOpen
+		}
+
+		return fmt.Errorf("ds.ReconciliationStore().Delete:%w", err)
+	}
+
+	return nil
+}
+
+func (c *ReconciliationSession) validateTransaction(dStores *datastore.Datastores, account *Account,
+	myTxn *Transaction) error {
+	if myTxn.TransactionDate.After(c.StatementDate) {
+		return fmt.Errorf("%w: transaction %d is dated after the statement", ErrReconciliationTransactionInvalid,
+			myTxn.TransactionID)
+	}
+
+	onAccount, isReconciled := false, true
+
+	for _, debitCredit := range myTxn.DebitCreditSet {
+		dcAccount, err := RetrieveAccountByID(dStores, debitCredit.AccountID)
+		if err != nil {
+			return fmt.Errorf("RetrieveAccountByID:%w", err)
+		}
+
+		if dcAccount.AccountLeft >= account.AccountLeft && dcAccount.AccountLeft <= account.AccountRight {
+			onAccount = true
+			isReconciled = isReconciled && debitCredit.IsReconciled
+		}
+	}
+
+	if !onAccount {
+		return fmt.Errorf("%w: transaction %d does not post to account %d", ErrReconciliationTransactionInvalid,
+			myTxn.TransactionID, account.AccountID)
+	}
+
+	// the other side of a transfer may be reconciled already, only the lines on this account matter
+	if isReconciled {
+		return fmt.Errorf("%w: transaction %d is already reconciled", ErrReconciliationTransactionInvalid,
+			myTxn.TransactionID)
+	}
+
+	return nil
+}
+
+// loadCleared loads the ticked transactions and works out the cleared balance and the difference.  A finished
+// session keeps the balance it cleared.
+func (c *ReconciliationSession) loadCleared(dStores *datastore.Datastores, account *Account) error {
+	transactionIDs, err := dStores.ReconciliationStore().GetTransactionIDs(c.SessionID)
+	if err != nil {
+		return fmt.Errorf("ds.ReconciliationStore().GetTransactionIDs:%w", err)
+	}
+
+	if c.SessionStatus == datastore.ReconciliationStatusFinished {
+		c.TransactionIDs = transactionIDs
+		c.Difference = c.StatementBalance - c.ClearedBalance
+
+		return nil
+	}
+
+	subtotals, err := dStores.ReconciliationStore().GetClearedSubtotals(c.SessionID, account.AccountLeft,
+		account.AccountRight)
+	if err != nil {
+		return fmt.Errorf("ds.ReconciliationStore().GetClearedSubtotals:%w", err)
+	}
+
+	c.TransactionIDs = transactionIDs
+	c.ClearedBalance = c.OpeningBalance + netSubtotals(subtotals, account.AccountSign)
+	c.Difference = c.StatementBalance - c.ClearedBalance
+
+	return nil
+}
+
+func reconciliationSessionToEntReconciliationSession(session *ReconciliationSession) datastore.ReconciliationSession {
+	return datastore.ReconciliationSession{
+		SessionID:        session.SessionID,
+		AccountID:        session.AccountID,
+		StatementDate:    session.StatementDate,
+		StatementBalance: session.StatementBalance,
+		OpeningBalance:   session.OpeningBalance,
+		SessionStatus:    session.SessionStatus,
+		CreatedDate:      session.CreatedDate,
+		FinishedDate:     session.FinishedDate,
+		ClearedCount:     session.ClearedCount,
+		ClearedBalance:   session.ClearedBalance,
+		FinishedBy:       session.FinishedBy,
+	}
+}
+
+func entReconciliationSessionToReconciliationSession(eSession *datastore.ReconciliationSession) *ReconciliationSession {
+	return &ReconciliationSession{
+		SessionID:        eSession.SessionID,
+		AccountID:        eSession.AccountID,
+		StatementDate:    eSession.StatementDate,
+		StatementBalance: eSession.StatementBalance,
+		OpeningBalance:   eSession.OpeningBalance,
+		SessionStatus:    eSession.SessionStatus,
+		CreatedDate:      eSession.CreatedDate,
+		FinishedDate:     eSession.FinishedDate,
+		TransactionIDs:   nil,
+		ClearedBalance:   eSession.ClearedBalance,
+		Difference:       0,
+		ClearedCount:     eSession.ClearedCount,
+		FinishedBy:       eSession.FinishedBy,
+	}
+}
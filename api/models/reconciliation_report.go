@@ -0,0 +1,145 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+)
+
+// ReconciliationReport is what a finished session cleared against its statement, what was still outstanding on the
+// account as of the statement date, and what has happened since to the transactions it cleared.
+type ReconciliationReport struct {
+	Session *ReconciliationSession
+	// ClearedBalance is the balance cleared by the session, in the sign of the account
+	ClearedBalance int64
+	// OutstandingDeposits are lines dated on or before the statement that increase the account and were not
+	// reconciled as of the statement date
+	OutstandingDeposits      []*TransactionReconciliation
+	OutstandingDepositsTotal int64
+	// OutstandingPayments are lines dated on or before the statement that decrease the account and were not
+	// reconciled as of the statement date
+	OutstandingPayments      []*TransactionReconciliation
+	OutstandingPaymentsTotal int64
+	// BookBalance is the cleared balance plus the outstanding deposits less the outstanding payments
+	BookBalance int64
+	// LaterChanges are the cleared transactions that were deleted, edited or unreconciled after the session
+	LaterChanges []*ReconciliationChange
+}
+
+// ReconciliationChange is a transaction cleared by a session that has changed since
+type ReconciliationChange struct {
+	TransactionID           uint64
+	TransactionDate         time.Time
+	TransactionComment      string
+	TransactionReference    string
+	ClearedAmount           int64
+	TransactionModifiedDate time.Time
+	IsDeleted               bool
+	// IsChanged is set when the transaction was edited after it was cleared
+	IsChanged bool
+	// IsUnreconciled is set when the transaction is no longer reconciled on the statement date
+	IsUnreconciled                  bool
+	CurrentTransactionDate          time.Time
+	CurrentTransactionComment       string
+	CurrentTransactionReference     string
+	CurrentAmount                   int64
+	CurrentTransactionModifiedDate  time.Time
+	CurrentTransactionReconcileDate time.Time
+}
+
+// RetrieveReconciliationReport builds the report of a finished session
+func RetrieveReconciliationReport(dStores *datastore.Datastores, sessionID uint64) (*ReconciliationReport, error) {
+	session, err := RetrieveReconciliationSessionByID(dStores, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.SessionStatus != datastore.ReconciliationStatusFinished {
+		return nil, ErrReconciliationSessionNotFinished
+	}
+
+	account, err := RetrieveAccountByID(dStores, session.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("RetrieveAccountByID:%w", err)
+	}
+
+	eOutstanding, err := dStores.TransactionStore().GetOutstandingOnAccountForDate(account.AccountLeft,
+		account.AccountRight, session.StatementDate)
+	if err != nil {
+		return nil, fmt.Errorf("ds.TransactionStore().GetOutstandingOnAccountForDate:%w", err)
+	}
+
+	eCleared, err := dStores.ReconciliationStore().GetClearedTransactions(session.SessionID, account.AccountLeft,
+		account.AccountRight, account.AccountSign)
+	if err != nil {
+		return nil, fmt.Errorf("ds.ReconciliationStore().GetClearedTransactions:%w", err)
+	}
+
+	report := ReconciliationReport{
+		Session:                  session,
+		ClearedBalance:           session.ClearedBalance,
+		OutstandingDeposits:      []*TransactionReconciliation{},
+		OutstandingDepositsTotal: 0,
+		OutstandingPayments:      []*TransactionReconciliation{},
+		OutstandingPaymentsTotal: 0,
+		BookBalance:              0,
+		LaterChanges:             reconciliationChanges(eCleared, session.StatementDate),
+	}
+
+	for _, line := range entTransactionsRecToTransactionsRec(eOutstanding) {
+		if line.DebitOrCredit == account.AccountSign {
+			report.OutstandingDeposits = append(report.OutstandingDeposits, line)
+			report.OutstandingDepositsTotal += int64(line.TransactionDCAmount) //nolint:gosec
+		} else {
+			report.OutstandingPayments = append(report.OutstandingPayments, line)
+			report.OutstandingPaymentsTotal += int64(line.TransactionDCAmount) //nolint:gosec
+		}
+	}
+
+	report.BookBalance = report.ClearedBalance + report.OutstandingDepositsTotal - report.OutstandingPaymentsTotal
+
+	return &report, nil
+}
+
+// reconciliationChanges keeps the cleared transactions that were deleted, edited since they were cleared, or are no
+// longer reconciled on the statement date
+func reconciliationChanges(eCleared []*datastore.ClearedTransaction,
+	statementDate time.Time) []*ReconciliationChange {
+	changes := []*ReconciliationChange{}
+
+	for _, cleared := range eCleared {
+		isChanged := !cleared.IsDeleted && (cleared.CurrentAmount != cleared.ClearedAmount ||
+			!cleared.CurrentTransactionDate.Time.Equal(cleared.TransactionDate) ||
+			cleared.CurrentTransactionComment != cleared.TransactionComment ||
+			cleared.CurrentTransactionReference != cleared.TransactionReference ||
+			cleared.CurrentTransactionModifiedDate.Time.After(cleared.TransactionModifiedDate))
+		isUnreconciled := !cleared.IsDeleted && (!cleared.CurrentIsReconciled ||
+			!cleared.CurrentTransactionReconcileDate.Valid ||
+			cleared.CurrentTransactionReconcileDate.Time.After(statementDate))
+
+		if !cleared.IsDeleted && !isChanged && !isUnreconciled {
+			continue
+		}
+
+		changes = append(changes, &ReconciliationChange{
+			TransactionID:                   cleared.TransactionID,
+			TransactionDate:                 cleared.TransactionDate,
+			TransactionComment:              cleared.TransactionComment,
+			TransactionReference:            cleared.TransactionReference,
+			ClearedAmount:                   cleared.ClearedAmount,
+			TransactionModifiedDate:         cleared.TransactionModifiedDate,
+			IsDeleted:                       cleared.IsDeleted,
+			IsChanged:                       isChanged,
+			IsUnreconciled:                  isUnreconciled,
+			CurrentTransactionDate:          cleared.CurrentTransactionDate.Time,
+			CurrentTransactionComment:       cleared.CurrentTransactionComment,
+			CurrentTransactionReference:     cleared.CurrentTransactionReference,
+			CurrentAmount:                   cleared.CurrentAmount,
+			CurrentTransactionModifiedDate:  cleared.CurrentTransactionModifiedDate.Time,
+			CurrentTransactionReconcileDate: cleared.CurrentTransactionReconcileDate.Time,
+		})
+	}
+
+	return changes
+}
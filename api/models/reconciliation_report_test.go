@@ -0,0 +1,174 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestReconciliationReport_ReconciliationChanges(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	statementDate := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	clearedDate := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	modifiedDate := time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC)
+
+	cleared := func(transactionID uint64) *datastore.ClearedTransaction {
+		return &datastore.ClearedTransaction{
+			SessionID:                       1,
+			TransactionID:                   transactionID,
+			TransactionDate:                 clearedDate,
+			TransactionComment:              "deposit",
+			TransactionReference:            "101",
+			ClearedAmount:                   10000,
+			TransactionModifiedDate:         modifiedDate,
+			IsDeleted:                       false,
+			CurrentTransactionDate:          sql.NullTime{Time: clearedDate, Valid: true},
+			CurrentTransactionComment:       "deposit",
+			CurrentTransactionReference:     "101",
+			CurrentAmount:                   10000,
+			CurrentTransactionModifiedDate:  sql.NullTime{Time: modifiedDate, Valid: true},
+			CurrentIsReconciled:             true,
+			CurrentTransactionReconcileDate: sql.NullTime{Time: statementDate, Valid: true},
+		}
+	}
+
+	unchanged := cleared(1)
+
+	edited := cleared(2)
+	edited.CurrentAmount = 9000
+	edited.CurrentTransactionModifiedDate.Time = modifiedDate.AddDate(0, 0, 3)
+
+	unreconciled := cleared(3)
+	unreconciled.CurrentIsReconciled = false
+	unreconciled.CurrentTransactionReconcileDate.Valid = false
+
+	deleted := cleared(4)
+	deleted.IsDeleted = true
+	deleted.CurrentTransactionDate.Valid = false
+	deleted.CurrentTransactionComment = ""
+	deleted.CurrentTransactionReference = ""
+	deleted.CurrentAmount = 0
+	deleted.CurrentTransactionModifiedDate.Valid = false
+	deleted.CurrentIsReconciled = false
+	deleted.CurrentTransactionReconcileDate.Valid = false
+
+	changes := reconciliationChanges([]*datastore.ClearedTransaction{unchanged, edited, unreconciled, deleted},
+		statementDate)
+	g.Expect(changes).To(gomega.HaveLen(3))
+
+	g.Expect(changes[0].TransactionID).To(gomega.Equal(uint64(2)))
+	g.Expect(changes[0].IsChanged).To(gomega.BeTrue())
+	g.Expect(changes[0].IsUnreconciled).To(gomega.BeFalse())
+	g.Expect(changes[0].CurrentAmount).To(gomega.Equal(int64(9000)))
+
+	g.Expect(changes[1].TransactionID).To(gomega.Equal(uint64(3)))
+	g.Expect(changes[1].IsChanged).To(gomega.BeFalse())
+	g.Expect(changes[1].IsUnreconciled).To(gomega.BeTrue())
+
+	g.Expect(changes[2].TransactionID).To(gomega.Equal(uint64(4)))
+	g.Expect(changes[2].IsDeleted).To(gomega.BeTrue())
+	g.Expect(changes[2].IsChanged).To(gomega.BeFalse())
+	g.Expect(changes[2].IsUnreconciled).To(gomega.BeFalse())
+}
+
+func TestReconciliationReport_RetrieveReconciliationReport(t *testing.T) { //nolint:funlen
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	checking := Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	income := Account{AccountName: "Income", AccountSign: datastore.AccountSignCredit,
+		AccountType: datastore.AccountTypeIncome}
+	err = income.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	expense := Account{AccountName: "Expense", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeExpense}
+	err = expense.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	storeTxn := func(month time.Month, day int, debitID, creditID, amount uint64) *Transaction {
+		txn := Transaction{TransactionCore: TransactionCore{TransactionComment: "statement line",
+			TransactionDate: time.Date(2024, month, day, 0, 0, 0, 0, time.UTC)},
+			DebitCreditSet: []*TransactionDebitCredit{
+				{AccountID: debitID, DebitOrCredit: datastore.AccountSignDebit, TransactionDCAmount: amount},
+				{AccountID: creditID, DebitOrCredit: datastore.AccountSignCredit, TransactionDCAmount: amount},
+			},
+		}
+		err := txn.Store(testDS)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		return &txn
+	}
+
+	deposit := storeTxn(3, 2, checking.AccountID, income.AccountID, 10000)
+	payment := storeTxn(3, 10, expense.AccountID, checking.AccountID, 2500)
+	outstandingDeposit := storeTxn(3, 20, checking.AccountID, income.AccountID, 300)
+	outstandingPayment := storeTxn(3, 25, expense.AccountID, checking.AccountID, 700)
+	storeTxn(4, 2, expense.AccountID, checking.AccountID, 100)
+
+	statementDate := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	session, err := NewReconciliationSession(testDS, checking.AccountID, statementDate, 7500)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	_, err = RetrieveReconciliationReport(testDS, session.SessionID)
+	g.Expect(err).To(gomega.MatchError(ErrReconciliationSessionNotFinished))
+
+	for _, transactionID := range []uint64{deposit.TransactionID, payment.TransactionID} {
+		err = session.TickTransaction(testDS, transactionID)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+
+	err = session.Finish(testDS, "pat")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	report, err := RetrieveReconciliationReport(testDS, session.SessionID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(report.Session.FinishedBy).To(gomega.Equal("pat"))
+	g.Expect(report.Session.ClearedCount).To(gomega.Equal(2))
+	g.Expect(report.ClearedBalance).To(gomega.Equal(int64(7500)))
+	g.Expect(report.OutstandingDeposits).To(gomega.HaveLen(1))
+	g.Expect(report.OutstandingDeposits[0].TransactionID).To(gomega.Equal(outstandingDeposit.TransactionID))
+	g.Expect(report.OutstandingDepositsTotal).To(gomega.Equal(int64(300)))
+	g.Expect(report.OutstandingPayments).To(gomega.HaveLen(1))
+	g.Expect(report.OutstandingPayments[0].TransactionID).To(gomega.Equal(outstandingPayment.TransactionID))
+	g.Expect(report.OutstandingPaymentsTotal).To(gomega.Equal(int64(700)))
+	g.Expect(report.BookBalance).To(gomega.Equal(int64(7100)))
+	g.Expect(report.LaterChanges).To(gomega.BeEmpty())
+
+	// reconciling an outstanding line later does not change what was outstanding on the statement date
+	outstandingDeposit.IsReconciled = true
+	outstandingDeposit.TransactionReconcileDate = sql.NullTime{Time: statementDate.AddDate(0, 1, 0), Valid: true}
+	err = outstandingDeposit.UpdateReconciled(testDS, checking.AccountID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	payment.TransactionComment = "corrected payment"
+	err = payment.Update(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	err = deposit.Delete(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	report, err = RetrieveReconciliationReport(testDS, session.SessionID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(report.ClearedBalance).To(gomega.Equal(int64(7500)))
+	g.Expect(report.OutstandingDepositsTotal).To(gomega.Equal(int64(300)))
+	g.Expect(report.LaterChanges).To(gomega.HaveLen(2))
+	g.Expect(report.LaterChanges[0].TransactionID).To(gomega.Equal(deposit.TransactionID))
+	g.Expect(report.LaterChanges[0].IsDeleted).To(gomega.BeTrue())
+	g.Expect(report.LaterChanges[1].TransactionID).To(gomega.Equal(payment.TransactionID))
+	g.Expect(report.LaterChanges[1].IsChanged).To(gomega.BeTrue())
+	g.Expect(report.LaterChanges[1].TransactionComment).To(gomega.Equal("statement line"))
+	g.Expect(report.LaterChanges[1].CurrentTransactionComment).To(gomega.Equal("corrected payment"))
+}
@@ -0,0 +1,166 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestReconciliationSession_TickAndFinish(t *testing.T) { //nolint:funlen
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	checking := Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	income := Account{AccountName: "Income", AccountSign: datastore.AccountSignCredit,
+		AccountType: datastore.AccountTypeIncome}
+	err = income.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	expense := Account{AccountName: "Expense", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeExpense}
+	err = expense.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	storeTxn := func(day int, debitID, creditID, amount uint64) *Transaction {
+		txn := Transaction{TransactionCore: TransactionCore{TransactionComment: "statement line",
+			TransactionDate: time.Date(2024, 3, day, 0, 0, 0, 0, time.UTC)},
+			DebitCreditSet: []*TransactionDebitCredit{
+				{AccountID: debitID, DebitOrCredit: datastore.AccountSignDebit, TransactionDCAmount: amount},
+				{AccountID: creditID, DebitOrCredit: datastore.AccountSignCredit, TransactionDCAmount: amount},
+			},
+		}
+		err := txn.Store(testDS)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		return &txn
+	}
+
+	deposit := storeTxn(2, checking.AccountID, income.AccountID, 10000)
+	payment := storeTxn(10, expense.AccountID, checking.AccountID, 2500)
+	afterStatement := storeTxn(5, expense.AccountID, checking.AccountID, 100)
+	afterStatement.TransactionDate = time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC)
+	err = afterStatement.Update(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	otherAccount := storeTxn(3, expense.AccountID, income.AccountID, 400)
+
+	statementDate := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	session, err := NewReconciliationSession(testDS, checking.AccountID, statementDate, 7500)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(session.SessionStatus).To(gomega.Equal(datastore.ReconciliationStatusOpen))
+	g.Expect(session.OpeningBalance).To(gomega.Equal(int64(0)))
+	g.Expect(session.Difference).To(gomega.Equal(int64(7500)))
+
+	// an account has one open session at a time
+	_, err = NewReconciliationSession(testDS, checking.AccountID, statementDate, 7500)
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(ErrReconciliationSessionAlreadyOpen.Error())))
+
+	err = session.TickTransaction(testDS, deposit.TransactionID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(session.TransactionIDs).To(gomega.Equal([]uint64{deposit.TransactionID}))
+	g.Expect(session.ClearedBalance).To(gomega.Equal(int64(10000)))
+	g.Expect(session.Difference).To(gomega.Equal(int64(-2500)))
+
+	err = session.Finish(testDS, "")
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(ErrReconciliationSessionNotBalanced.Error())))
+
+	err = session.TickTransaction(testDS, afterStatement.TransactionID)
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(ErrReconciliationTransactionInvalid.Error())))
+	err = session.TickTransaction(testDS, otherAccount.TransactionID)
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(ErrReconciliationTransactionInvalid.Error())))
+
+	// ticked in and out again
+	err = session.TickTransaction(testDS, payment.TransactionID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(session.Difference).To(gomega.Equal(int64(0)))
+	err = session.UntickTransaction(testDS, payment.TransactionID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(session.Difference).To(gomega.Equal(int64(-2500)))
+	err = session.TickTransaction(testDS, payment.TransactionID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// a ticked transaction edited after the session was loaded stops it finishing, and nothing is reconciled
+	edited, err := RetrieveTransactionByID(testDS, payment.TransactionID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	setPaymentAmount := func(amount uint64) {
+		for _, debitCredit := range edited.DebitCreditSet {
+			debitCredit.TransactionDCAmount = amount
+		}
+
+		err = edited.Update(testDS)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+
+	setPaymentAmount(2600)
+
+	err = session.Finish(testDS, "pat")
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(ErrReconciliationSessionChanged.Error())))
+
+	stillOpen, err := RetrieveReconciliationSessionByID(testDS, session.SessionID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(stillOpen.SessionStatus).To(gomega.Equal(datastore.ReconciliationStatusOpen))
+
+	unreconciled, err := RetrieveTransactionByID(testDS, deposit.TransactionID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(unreconciled.IsReconciled).To(gomega.BeFalse())
+
+	setPaymentAmount(2500)
+
+	err = session.Finish(testDS, "pat")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(session.SessionStatus).To(gomega.Equal(datastore.ReconciliationStatusFinished))
+	g.Expect(session.FinishedDate.Valid).To(gomega.BeTrue())
+	g.Expect(session.ClearedCount).To(gomega.Equal(2))
+	g.Expect(session.FinishedBy).To(gomega.Equal("pat"))
+
+	for _, transactionID := range []uint64{deposit.TransactionID, payment.TransactionID} {
+		myTxn, err := RetrieveTransactionByID(testDS, transactionID)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(myTxn.IsReconciledOnAccount(testDS, &checking)).To(gomega.BeTrue())
+		g.Expect(myTxn.TransactionReconcileDate.Time.Equal(statementDate)).To(gomega.BeTrue())
+	}
+
+	myTxn, err := RetrieveTransactionByID(testDS, afterStatement.TransactionID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myTxn.IsReconciled).To(gomega.BeFalse())
+
+	account, err := RetrieveAccountByID(testDS, checking.AccountID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(account.AccountReconcileDate.Time.Equal(statementDate)).To(gomega.BeTrue())
+
+	// a finished session cannot be changed
+	err = session.UntickTransaction(testDS, payment.TransactionID)
+	g.Expect(err).To(gomega.MatchError(ErrReconciliationSessionNotOpen))
+	err = session.Delete(testDS)
+	g.Expect(err).To(gomega.MatchError(ErrReconciliationSessionNotOpen))
+
+	// the next statement opens on what was reconciled, and cannot be dated before the last one
+	_, err = NewReconciliationSession(testDS, checking.AccountID, statementDate.AddDate(0, 0, -1), 0)
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(ErrReconciliationSessionInvalid.Error())))
+
+	next, err := NewReconciliationSession(testDS, checking.AccountID, statementDate.AddDate(0, 1, 0), 7400)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(next.OpeningBalance).To(gomega.Equal(int64(7500)))
+	g.Expect(next.Difference).To(gomega.Equal(int64(-100)))
+
+	sessions, err := RetrieveReconciliationSessions(testDS, checking.AccountID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(sessions).To(gomega.HaveLen(2))
+	g.Expect(sessions[0].SessionID).To(gomega.Equal(next.SessionID))
+	g.Expect(sessions[1].TransactionIDs).To(gomega.ConsistOf(deposit.TransactionID, payment.TransactionID))
+
+	err = next.Delete(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	_, err = RetrieveReconciliationSessionByID(testDS, next.SessionID)
+	g.Expect(err).To(gomega.MatchError(ErrReconciliationSessionNotFound))
+}
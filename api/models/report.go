@@ -127,6 +127,33 @@ func (c *Report) Run(dStores *datastore.Datastores, startDate time.Time,
 	return &myReportOutput, nil
 }
 
+// RunWithAccountTree executes a report like Run, and adds the AccountTree of the source accounts that printed
+// layouts are built from.  Ledger reports have no tree.
+func (c *Report) RunWithAccountTree(dStores *datastore.Datastores, startDate time.Time,
+	endDate time.Time, runTimeTargetAccounts []uint64) (*ReportOutput, error) {
+	myReportOutput, err := c.Run(dStores, startDate, endDate, runTimeTargetAccounts)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.ReportBody.DataSetType == datastore.ReportDataSetTypeLedger {
+		return myReportOutput, nil
+	}
+
+	treeStartDate := startDate
+	// a balance is everything up to the end date
+	if c.ReportBody.DataSetType == datastore.ReportDataSetTypeBalance {
+		treeStartDate = time.Time{}
+	}
+
+	myReportOutput.AccountTree, err = c.buildAccountTree(dStores, runTimeTargetAccounts, treeStartDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("buildAccountTree:%w", err)
+	}
+
+	return myReportOutput, nil
+}
+
 // check type of account set
 // build the set of accountIDs to process
 func (c *Report) buildAccountSet(dStores *datastore.Datastores, runTimeTargetAccounts []uint64) ([]uint64, error) { //nolint:gocognit
@@ -148,6 +175,10 @@ func (c *Report) buildAccountSet(dStores *datastore.Datastores, runTimeTargetAcc
 			for _, account := range runTimeTargetAccounts {
 				accountAndChildren, err := dStores.AccountStore().GetAccountWithChildrenByLevel(account)
 				if err != nil {
+					if errors.Is(err, sql.ErrNoRows) {
+						return nil, ErrAccountNotFound
+					}
+
 					return nil, fmt.Errorf("dStores.AccountStore().GetAccountWithChildrenByLevel:%w", err)
 				}
 				// how many levels to recurse
@@ -179,6 +210,156 @@ func (c *Report) buildAccountSet(dStores *datastore.Datastores, runTimeTargetAcc
 	return sourceAccountSet, nil
 }
 
+// sourceRootAccountIDs returns the accounts the report starts from, before any recursion into sub-accounts
+func (c *Report) sourceRootAccountIDs(dStores *datastore.Datastores, runTimeTargetAccounts []uint64) ([]uint64, error) {
+	switch c.ReportBody.SourceAccountSetType {
+	case datastore.ReportAccountSetNone:
+		return nil, nil
+	case datastore.ReportAccountSetUserSupplied:
+		return runTimeTargetAccounts, nil
+	case datastore.ReportAccountSetPredefined:
+		return c.ReportBody.SourcePredefinedAccounts, nil
+	case datastore.ReportAccountSetGroup:
+		accounts, err := dStores.AccountStore().GetAccounts()
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, nil
+			}
+
+			return nil, fmt.Errorf("dStores.AccountStore().GetAccounts:%w", err)
+		}
+
+		// the top most accounts of the group, accounts are in tree order so anything inside the last root is skipped
+		var (
+			rootIDs   []uint64
+			lastRight uint64
+		)
+
+		for idx := range accounts {
+			if accounts[idx].AccountType != c.ReportBody.SourceAccountGroup || accounts[idx].AccountLeft < lastRight {
+				continue
+			}
+
+			rootIDs = append(rootIDs, accounts[idx].AccountID)
+			lastRight = accounts[idx].AccountRight
+		}
+
+		return rootIDs, nil
+	}
+
+	return nil, nil
+}
+
+// buildAccountTree builds the hierarchy of source accounts with the net amount of each for the dates,
+// subtotals always include every sub-account even when the depth limits which accounts are listed
+func (c *Report) buildAccountTree(dStores *datastore.Datastores, runTimeTargetAccounts []uint64,
+	startDate time.Time, endDate time.Time) ([]*ReportOutputAccount, error) {
+	rootIDs, err := c.sourceRootAccountIDs(dStores, runTimeTargetAccounts)
+	if err != nil {
+		return nil, fmt.Errorf("sourceRootAccountIDs:%w", err)
+	}
+
+	var tree []*ReportOutputAccount
+
+	seen := make(map[uint64]bool)
+
+	for _, rootID := range rootIDs {
+		if seen[rootID] {
+			continue
+		}
+
+		accounts, err := dStores.AccountStore().GetAccountWithChildrenByLevel(rootID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, ErrAccountNotFound
+			}
+
+			return nil, fmt.Errorf("dStores.AccountStore().GetAccountWithChildrenByLevel:%w", err)
+		}
+
+		subtotals, err := dStores.TransactionDebitCreditStore().GetSubtotalsForDates(accounts[0].AccountLeft,
+			accounts[0].AccountRight, startDate, endDate)
+		if err != nil {
+			return nil, fmt.Errorf("dStores.TransactionDebitCreditStore().GetSubtotalsForDates:%w", err)
+		}
+
+		subTree := accountsToReportOutputAccounts(accounts, subtotals)
+
+		for idx := range subTree {
+			seen[subTree[idx].AccountID] = true
+
+			if subTree[idx].Level > 0 && !c.ReportBody.SourceRecurseSubAccounts {
+				continue
+			}
+
+			if c.ReportBody.SourceRecurseSubAccountsDepth > 0 && subTree[idx].Level > c.ReportBody.SourceRecurseSubAccountsDepth {
+				continue
+			}
+
+			tree = append(tree, subTree[idx])
+		}
+	}
+
+	return tree, nil
+}
+
+// accountsToReportOutputAccounts nets the debits and credits of each account, and rolls them up into subtotals.
+// accounts must be in tree order starting with the root of the subtree
+func accountsToReportOutputAccounts(accounts []datastore.AccountWithLevel,
+	subtotals []*datastore.AccountSubtotal) []*ReportOutputAccount {
+	outputAccounts := make([]*ReportOutputAccount, len(accounts))
+	byID := make(map[uint64]*ReportOutputAccount, len(accounts))
+
+	for idx := range accounts {
+		myAccount := ReportOutputAccount{
+			AccountID:       accounts[idx].AccountID,
+			AccountParent:   accounts[idx].AccountParent,
+			AccountName:     accounts[idx].AccountName,
+			AccountFullName: accounts[idx].AccountFullName,
+			AccountSign:     accounts[idx].AccountSign,
+			AccountType:     accounts[idx].AccountType,
+			AccountDecimals: accounts[idx].AccountDecimals,
+			Level:           accounts[idx].Level - accounts[0].Level,
+			Amount:          0,
+			Subtotal:        0,
+		}
+		outputAccounts[idx] = &myAccount
+		byID[myAccount.AccountID] = &myAccount
+	}
+
+	for _, subtotal := range subtotals {
+		myAccount, ok := byID[subtotal.AccountID]
+		if !ok {
+			continue
+		}
+
+		if subtotal.DebitOrCredit == myAccount.AccountSign {
+			myAccount.Amount += int64(subtotal.Subtotal) //nolint:gosec
+		} else {
+			myAccount.Amount -= int64(subtotal.Subtotal) //nolint:gosec
+		}
+	}
+
+	// children come after their parents, so walking backwards completes every subtree before its parent
+	for idx := len(outputAccounts) - 1; idx >= 0; idx-- {
+		myAccount := outputAccounts[idx]
+		myAccount.Subtotal += myAccount.Amount
+
+		parent, ok := byID[myAccount.AccountParent]
+		if idx == 0 || !ok {
+			continue
+		}
+
+		if parent.AccountSign == myAccount.AccountSign {
+			parent.Subtotal += myAccount.Subtotal
+		} else {
+			parent.Subtotal -= myAccount.Subtotal
+		}
+	}
+
+	return outputAccounts
+}
+
 func buildDataSetLedger(dStores *datastore.Datastores, sourceAccountSet []uint64,
 	startDate time.Time, endDate time.Time) ([]*ReportOutputData, error) {
 	var dataSet []*ReportOutputData
@@ -14,6 +14,9 @@ type ReportOutput struct {
 	EndDate     time.Time
 	DataSetType datastore.ReportDataSetType
 	ReportData  []*ReportOutputData
+	// AccountTree is the source accounts in tree order, each with its own amount and the subtotal of its subtree.
+	// It is only built by RunWithAccountTree.
+	AccountTree []*ReportOutputAccount
 }
 
 type ReportOutputData struct {
@@ -21,3 +24,19 @@ type ReportOutputData struct {
 	Income          int64
 	NetTransactions []*TransactionLedger
 }
+
+// ReportOutputAccount is one account in the account tree of a report output
+type ReportOutputAccount struct {
+	AccountID       uint64
+	AccountParent   uint64
+	AccountName     string
+	AccountFullName string
+	AccountSign     datastore.AccountSign
+	AccountType     datastore.AccountType
+	AccountDecimals uint64
+	// Level is relative to the source account the tree was built from, which is level 0
+	Level int
+	// Amount is the net of the account itself, Subtotal includes all of its sub-accounts
+	Amount   int64
+	Subtotal int64
+}
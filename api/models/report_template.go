@@ -0,0 +1,429 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/money"
+)
+
+// ReportTemplate is a user defined text/template or html/template layout.  It is rendered against either a
+// ReportOutput or the ledger of a single account.
+type ReportTemplate struct {
+	TemplateID   uint64
+	TemplateName string
+	TemplateType datastore.ReportTemplateType
+	TemplateBody string
+}
+
+// ReportTemplateOutputData is the data a template is executed with when rendering a report output, Title is the
+// title the render was requested with or the report name
+type ReportTemplateOutputData struct {
+	Title  string
+	Report *ReportOutput
+}
+
+// ReportTemplateStatementData is the data a template is executed with when rendering an account statement, Title is
+// the title the render was requested with or the account full name
+type ReportTemplateStatementData struct {
+	Title          string
+	Account        *Account
+	OpeningBalance int64
+	Transactions   []*TransactionLedger
+}
+
+var ErrReportTemplateNotFound = errors.New("report template not found")
+var ErrReportTemplateTypeInvalid = errors.New("report template type must be TEXT or HTML")
+var ErrReportTemplateInvalid = errors.New("report template does not parse")
+var ErrReportTemplateRender = errors.New("report template failed to render")
+var errReportTemplateNameEmptyString = errors.New("report template name cannot be empty")
+var errTemplateAmountType = errors.New("amount must be an integer")
+var errTemplateTimeout = errors.New("template took too long to render")
+var errTemplateOutputTooLarge = errors.New("template output is too large")
+
+// reportTemplateTimeout limits how long a template renders, it is checked on every range iteration and template call
+const reportTemplateTimeout = 10 * time.Second
+
+// reportTemplateMaxOutput is the most a template may write
+const reportTemplateMaxOutput = 10 << 20
+
+// templateGuardFunc is the helper that is called at the start of every range iteration and template, it fails the
+// render once the deadline has passed
+const templateGuardFunc = "renderGuard"
+
+// Store inserts a ReportTemplate, the body must parse before it is stored
+func (c *ReportTemplate) Store(dStores *datastore.Datastores) error {
+	if err := c.validate(); err != nil {
+		return fmt.Errorf("c.validate:%w", err)
+	}
+
+	eTemplate := datastore.ReportTemplate(*c)
+
+	err := dStores.ReportTemplateStore().Store(&eTemplate)
+	if err != nil {
+		return fmt.Errorf("ds.ReportTemplateStore().Store:%w [ReportTemplate:%s]", err, eTemplate.TemplateName)
+	}
+
+	*c = ReportTemplate(eTemplate)
+
+	return nil
+}
+
+// Update updates a ReportTemplate, the body must parse before it is stored
+func (c *ReportTemplate) Update(dStores *datastore.Datastores) error {
+	if err := c.validate(); err != nil {
+		return fmt.Errorf("c.validate:%w", err)
+	}
+
+	eTemplate := datastore.ReportTemplate(*c)
+
+	err := dStores.ReportTemplateStore().Update(&eTemplate)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrReportTemplateNotFound
+		}
+
+		return fmt.Errorf("ds.ReportTemplateStore().Update:%w [ReportTemplate:%s]", err, eTemplate.TemplateName)
+	}
+
+	*c = ReportTemplate(eTemplate)
+
+	return nil
+}
+
+func (c *ReportTemplate) Delete(dStores *datastore.Datastores) error {
+	eTemplate := datastore.ReportTemplate(*c)
+
+	err := dStores.ReportTemplateStore().Delete(&eTemplate)
+	if err != nil {
+		return fmt.Errorf("ds.ReportTemplateStore().Delete:%w [ReportTemplate:%+v]", err, c)
+	}
+
+	return nil
+}
+
+// ContentType is the Content-Type of the rendered template
+func (c *ReportTemplate) ContentType() string {
+	if c.TemplateType == datastore.ReportTemplateTypeText {
+		return "text/plain; charset=utf-8"
+	}
+
+	return "text/html; charset=utf-8"
+}
+
+func (c *ReportTemplate) validate() error {
+	if c.TemplateName == "" {
+		return errReportTemplateNameEmptyString
+	}
+
+	_, err := c.parse(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// executor is what text/template and html/template have in common
+type executor interface {
+	Execute(wr io.Writer, data any) error
+}
+
+// parse parses the body with the helper functions bound to accounts, and guards every range and template with
+// the deadline of ctx
+func (c *ReportTemplate) parse(ctx context.Context, accounts []*Account) (executor, error) {
+	funcs := templateFuncs(accounts)
+	funcs[templateGuardFunc] = func() (string, error) {
+		if ctx.Err() != nil {
+			return "", errTemplateTimeout
+		}
+
+		return "", nil
+	}
+
+	guard, err := texttemplate.New(templateGuardFunc).Funcs(funcs).Parse("{{$guard := " + templateGuardFunc + "}}")
+	if err != nil {
+		return nil, fmt.Errorf("texttemplate.Parse:%w", err)
+	}
+
+	switch c.TemplateType {
+	case datastore.ReportTemplateTypeText:
+		tmpl, err := texttemplate.New(c.TemplateName).Funcs(funcs).Parse(c.TemplateBody)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrReportTemplateInvalid, err)
+		}
+
+		for _, defined := range tmpl.Templates() {
+			guardTree(defined.Tree, guard.Tree.Root.Nodes[0])
+		}
+
+		return tmpl, nil
+	case datastore.ReportTemplateTypeHTML:
+		tmpl, err := htmltemplate.New(c.TemplateName).Funcs(funcs).Parse(c.TemplateBody)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrReportTemplateInvalid, err)
+		}
+
+		for _, defined := range tmpl.Templates() {
+			guardTree(defined.Tree, guard.Tree.Root.Nodes[0])
+		}
+
+		return tmpl, nil
+	}
+
+	return nil, fmt.Errorf("%w: [TemplateType:%s]", ErrReportTemplateTypeInvalid, c.TemplateType)
+}
+
+// guardTree puts a copy of guard first in the template and in every list inside it, so that neither a long range nor
+// recursive templates can run past the deadline.  The guard is a variable declaration, which writes nothing.
+func guardTree(tree *parse.Tree, guard parse.Node) {
+	if tree == nil || tree.Root == nil {
+		return
+	}
+
+	guardList(tree.Root, guard)
+}
+
+func guardList(list *parse.ListNode, guard parse.Node) {
+	if list == nil {
+		return
+	}
+
+	for _, node := range list.Nodes {
+		switch myNode := node.(type) {
+		case *parse.RangeNode:
+			guardList(myNode.List, guard)
+			guardList(myNode.ElseList, guard)
+		case *parse.IfNode:
+			guardList(myNode.List, guard)
+			guardList(myNode.ElseList, guard)
+		case *parse.WithNode:
+			guardList(myNode.List, guard)
+			guardList(myNode.ElseList, guard)
+		case *parse.ListNode:
+			guardList(myNode, guard)
+		}
+	}
+
+	list.Nodes = append([]parse.Node{guard.Copy()}, list.Nodes...)
+}
+
+// limitedBuffer is a bytes.Buffer that fails writes past limit, which stops the template writing to it
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.limit {
+		return 0, errTemplateOutputTooLarge
+	}
+
+	return b.Buffer.Write(p) //nolint:wrapcheck
+}
+
+// Execute renders the template against data, account lookups in the template are answered from accounts.  The render
+// stops after reportTemplateTimeout or reportTemplateMaxOutput bytes, and the output is buffered so that a failed
+// render never returns partial output.
+func (c *ReportTemplate) Execute(ctx context.Context, data any, accounts []*Account) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, reportTemplateTimeout)
+	defer cancel()
+
+	tmpl, err := c.parse(ctx, accounts)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := limitedBuffer{Buffer: bytes.Buffer{}, limit: reportTemplateMaxOutput}
+
+	if err = tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrReportTemplateRender, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// RenderReportOutput renders the template against the output of a report run, an empty title is the report name
+func (c *ReportTemplate) RenderReportOutput(ctx context.Context, dStores *datastore.Datastores, output *ReportOutput,
+	title string) ([]byte, error) {
+	accounts, err := RetrieveAccounts(dStores)
+	if err != nil {
+		return nil, fmt.Errorf("RetrieveAccounts:%w", err)
+	}
+
+	if title == "" {
+		title = output.ReportName
+	}
+
+	return c.Execute(ctx, &ReportTemplateOutputData{Title: title, Report: output}, accounts)
+}
+
+// RenderAccountStatement renders the template against the ledger of an account, an empty title is the account full
+// name
+func (c *ReportTemplate) RenderAccountStatement(ctx context.Context, dStores *datastore.Datastores,
+	account *Account, ledger *AccountLedger, title string) ([]byte, error) {
+	accounts, err := RetrieveAccounts(dStores)
+	if err != nil {
+		return nil, fmt.Errorf("RetrieveAccounts:%w", err)
+	}
+
+	if title == "" {
+		title = account.AccountFullName
+	}
+
+	data := ReportTemplateStatementData{Title: title, Account: account,
+		OpeningBalance: ledger.OpeningBalance, Transactions: ledger.Transactions}
+
+	return c.Execute(ctx, &data, accounts)
+}
+
+// templateFuncs are the helpers available to templates.  They only format values and look up account names,
+// templates have no way to reach the datastores.
+func templateFuncs(accounts []*Account) map[string]any {
+	byID := make(map[uint64]*Account, len(accounts))
+	for idx := range accounts {
+		byID[accounts[idx].AccountID] = accounts[idx]
+	}
+
+	accountName := func(accountID uint64) string {
+		if acct, ok := byID[accountID]; ok {
+			return acct.AccountName
+		}
+
+		return ""
+	}
+
+	accountFullName := func(accountID uint64) string {
+		if acct, ok := byID[accountID]; ok {
+			return acct.AccountFullName
+		}
+
+		return ""
+	}
+
+	return map[string]any{
+		// money formats minor units with thousands separators, {{money .Amount .AccountDecimals}}
+		"money": func(amount any, decimals uint64) (string, error) {
+			myAmount, err := templateAmount(amount)
+			if err != nil {
+				return "", err
+			}
+
+			return money.Format(myAmount, decimals), nil
+		},
+		// accounting is money with negative amounts in parentheses
+		"accounting": func(amount any, decimals uint64) (string, error) {
+			myAmount, err := templateAmount(amount)
+			if err != nil {
+				return "", err
+			}
+
+			return money.FormatAccounting(myAmount, decimals), nil
+		},
+		"accountName":     accountName,
+		"accountFullName": accountFullName,
+		// splitNames turns the comma separated account IDs of a ledger line into account names
+		"splitNames": func(split string) []string {
+			var names []string
+
+			for _, idStr := range strings.Split(split, ",") {
+				accountID, err := strconv.ParseUint(strings.TrimSpace(idStr), 10, 64)
+				if err != nil {
+					continue
+				}
+
+				names = append(names, accountFullName(accountID))
+			}
+
+			return names
+		},
+	}
+}
+
+// templateAmount accepts any of the integer types amounts are stored in
+func templateAmount(amount any) (int64, error) {
+	switch myAmount := amount.(type) {
+	case int:
+		return int64(myAmount), nil
+	case int64:
+		return myAmount, nil
+	case uint64:
+		return int64(myAmount), nil //nolint:gosec
+	}
+
+	return 0, fmt.Errorf("%w: [amount:%v]", errTemplateAmountType, amount)
+}
+
+// RetrieveReportTemplateByID retrieves a specific report template
+func RetrieveReportTemplateByID(dStores *datastore.Datastores, templateID uint64) (*ReportTemplate, error) {
+	eTemplate, err := dStores.ReportTemplateStore().RetrieveByID(templateID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReportTemplateNotFound
+		}
+
+		return nil, fmt.Errorf("ReportTemplateStore().RetrieveByID:%w", err)
+	}
+
+	myTemplate := ReportTemplate(*eTemplate)
+
+	return &myTemplate, nil
+}
+
+// RetrieveReportTemplateByName retrieves a specific report template
+func RetrieveReportTemplateByName(dStores *datastore.Datastores, name string) (*ReportTemplate, error) {
+	eTemplate, err := dStores.ReportTemplateStore().RetrieveByName(name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReportTemplateNotFound
+		}
+
+		return nil, fmt.Errorf("ReportTemplateStore().RetrieveByName:%w", err)
+	}
+
+	myTemplate := ReportTemplate(*eTemplate)
+
+	return &myTemplate, nil
+}
+
+// RetrieveReportTemplateByRef retrieves a report template by ID when ref is numeric, otherwise by name
+func RetrieveReportTemplateByRef(dStores *datastore.Datastores, ref string) (*ReportTemplate, error) {
+	templateID, err := strconv.ParseUint(ref, 10, 64)
+	if err != nil {
+		return RetrieveReportTemplateByName(dStores, ref)
+	}
+
+	return RetrieveReportTemplateByID(dStores, templateID)
+}
+
+// RetrieveReportTemplates retrieves all report templates
+func RetrieveReportTemplates(dStores *datastore.Datastores) ([]*ReportTemplate, error) {
+	eTemplates, err := dStores.ReportTemplateStore().Retrieve()
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("ReportTemplateStore().Retrieve:%w", err)
+	}
+
+	templates := make([]*ReportTemplate, len(eTemplates))
+
+	for idx := range eTemplates {
+		myTemplate := ReportTemplate(*eTemplates[idx])
+		templates[idx] = &myTemplate
+	}
+
+	return templates, nil
+}
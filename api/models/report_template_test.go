@@ -0,0 +1,135 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestReportTemplate_StoreInvalid(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	myTemplate := ReportTemplate{TemplateName: "broken", TemplateType: datastore.ReportTemplateTypeHTML,
+		TemplateBody: "{{range .Report.AccountTree}}"}
+	err := myTemplate.Store(testDS)
+	g.Expect(errors.Is(err, ErrReportTemplateInvalid)).To(gomega.BeTrue())
+
+	myTemplate = ReportTemplate{TemplateName: "unknown", TemplateType: "PDF", TemplateBody: "hi"}
+	err = myTemplate.Store(testDS)
+	g.Expect(errors.Is(err, ErrReportTemplateTypeInvalid)).To(gomega.BeTrue())
+}
+
+func TestReportTemplate_StoreAndDelete(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	_, err := dbClient.Exec(`delete from report_templates`)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	myTemplate := ReportTemplate{TemplateName: "Board Summary", TemplateType: datastore.ReportTemplateTypeText,
+		TemplateBody: "{{.Title}}"}
+	err = myTemplate.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myTemplate.TemplateID).NotTo(gomega.BeZero())
+
+	retTemplate, err := RetrieveReportTemplateByName(testDS, "Board Summary")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(retTemplate.TemplateID).To(gomega.Equal(myTemplate.TemplateID))
+
+	err = myTemplate.Delete(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	retTemplate, err = RetrieveReportTemplateByID(testDS, myTemplate.TemplateID)
+	g.Expect(errors.Is(err, ErrReportTemplateNotFound)).To(gomega.BeTrue())
+	g.Expect(retTemplate).To(gomega.BeNil())
+}
+
+func TestReportTemplate_Execute(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	accounts := []*Account{
+		{AccountID: 7, AccountName: "Checking", AccountFullName: "Assets:Checking"},
+		{AccountID: 9, AccountName: "Rent", AccountFullName: "Expenses:Rent"},
+	}
+	data := ReportTemplateStatementData{
+		Title:   "Mimir <Holdings>",
+		Account: accounts[0],
+		Transactions: []*TransactionLedger{
+			{TransactionComment: "rent", TransactionDCAmount: 123456, DebitOrCredit: datastore.AccountSignCredit,
+				Split: "9"},
+		},
+	}
+
+	htmlTemplate := ReportTemplate{TemplateName: "statement", TemplateType: datastore.ReportTemplateTypeHTML,
+		TemplateBody: `<h1>{{.Title}}</h1>{{range .Transactions}}` +
+			`{{accountName $.Account.AccountID}} {{money .TransactionDCAmount 2}} {{accounting -5 2}}` +
+			` {{range splitNames .Split}}{{.}}{{end}}{{end}}`}
+	output, err := htmlTemplate.Execute(context.Background(), &data, accounts)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(output)).To(gomega.Equal(
+		"<h1>Mimir &lt;Holdings&gt;</h1>Checking 1,234.56 (0.05) Expenses:Rent"))
+
+	textTemplate := htmlTemplate
+	textTemplate.TemplateType = datastore.ReportTemplateTypeText
+	output, err = textTemplate.Execute(context.Background(), &data, accounts)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(output)).To(gomega.HavePrefix("<h1>Mimir <Holdings></h1>"))
+
+	badAmount := ReportTemplate{TemplateName: "bad", TemplateType: datastore.ReportTemplateTypeText,
+		TemplateBody: `{{money .Title 2}}`}
+	_, err = badAmount.Execute(context.Background(), &data, accounts)
+	g.Expect(errors.Is(err, ErrReportTemplateRender)).To(gomega.BeTrue())
+}
+
+func TestReportTemplate_ExecuteLimits(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	data := ReportTemplateStatementData{Title: "Mimir", Account: &Account{AccountName: "Checking"}}
+
+	// the guard writes nothing, in html as in text
+	guarded := ReportTemplate{TemplateName: "guarded", TemplateType: datastore.ReportTemplateTypeHTML,
+		TemplateBody: `{{define "row"}}<td>{{.}}</td>{{end}}<script>var t = {{range 2}}{{.}}{{end}};</script>` +
+			`<a {{range 1}}href="/"{{end}}>{{template "row" .Title}}</a>`}
+	output, err := guarded.Execute(context.Background(), &data, nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(output)).To(gomega.Equal(`<script>var t =  0  1 ;</script><a href="/"><td>Mimir</td></a>`))
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "range over a large integer", body: `{{range 1000000000000}}{{end}}`},
+		{name: "recursive template", body: `{{define "a"}}{{template "a" .}}{{template "a" .}}{{end}}{{template "a" .}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := gomega.NewWithT(t)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			looping := ReportTemplate{TemplateName: "looping", TemplateType: datastore.ReportTemplateTypeText,
+				TemplateBody: tt.body}
+			_, err := looping.Execute(ctx, &data, nil)
+			g.Expect(errors.Is(err, ErrReportTemplateRender)).To(gomega.BeTrue())
+			g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("too long")))
+		})
+	}
+
+	large := ReportTemplate{TemplateName: "large", TemplateType: datastore.ReportTemplateTypeText,
+		TemplateBody: `{{range 100000000}}` + strings.Repeat("x", 100) + `{{end}}`}
+	_, err = large.Execute(context.Background(), &data, nil)
+	g.Expect(errors.Is(err, ErrReportTemplateRender)).To(gomega.BeTrue())
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("too large")))
+}
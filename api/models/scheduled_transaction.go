@@ -0,0 +1,500 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/recurrence"
+)
+
+// ScheduledTransaction is a transaction such as rent or payroll that is posted on each day of its RecurrenceRule,
+// from StartDate through EndDate.  The rule is an RRULE such as "FREQ=MONTHLY;BYMONTHDAY=1", see package recurrence.
+// NextDue is the day the scheduler posts it from, which moves past the occurrences as they are posted.  A schedule is
+// posted from the day it is created, or from StartDate when it is stored or updated with CatchUp, which is not stored.
+type ScheduledTransaction struct {
+	ScheduledID    uint64
+	ScheduledName  string
+	RecurrenceRule string
+	StartDate      time.Time
+	EndDate        sql.NullTime
+	IsEnabled      bool
+	Template       datastore.TransactionTemplate
+	NextDue        time.Time
+	CatchUp        bool
+}
+
+// ScheduledOccurrence is a day a ScheduledTransaction falls on.  TransactionID is the transaction it posted, 0 until
+// it is posted.
+type ScheduledOccurrence struct {
+	ScheduledID      uint64
+	ScheduledName    string
+	OccurrenceDate   time.Time
+	OccurrenceStatus datastore.OccurrenceStatus
+	TransactionID    uint64
+}
+
+// OccurrenceStatusPending is an occurrence that has not been posted or skipped yet, it is never stored
+const OccurrenceStatusPending = datastore.OccurrenceStatus("PENDING")
+
+var ErrScheduledTransactionNotFound = errors.New("scheduled transaction not found")
+var ErrScheduledTransactionInvalid = errors.New("scheduled transaction is invalid")
+var ErrScheduledOccurrenceInvalid = errors.New("scheduled transaction does not occur on that date")
+var ErrScheduledOccurrenceHandled = errors.New("scheduled occurrence was already posted or skipped")
+
+// Store inserts a ScheduledTransaction, the rule and the template must be valid before it is stored
+func (c *ScheduledTransaction) Store(dStores *datastore.Datastores) error {
+	if err := c.validate(dStores); err != nil {
+		return fmt.Errorf("c.validate:%w", err)
+	}
+
+	c.NextDue = c.StartDate
+	if today := recurrence.Day(time.Now()); !c.CatchUp && today.After(c.StartDate) {
+		c.NextDue = today
+	}
+
+	eScheduled := scheduledTransactionToEntScheduledTransaction(c)
+
+	if err := dStores.ScheduledTransactionStore().Store(&eScheduled); err != nil {
+		return fmt.Errorf("ds.ScheduledTransactionStore().Store:%w [ScheduledTransaction:%s]", err,
+			eScheduled.ScheduledName)
+	}
+
+	*c = *entScheduledTransactionToScheduledTransaction(&eScheduled)
+
+	return nil
+}
+
+// Update updates a ScheduledTransaction, the occurrences already posted or skipped are kept.  NextDue is kept too,
+// unless CatchUp moves it back to StartDate so the past occurrences not posted or skipped yet are posted.
+func (c *ScheduledTransaction) Update(dStores *datastore.Datastores) error {
+	if err := c.validate(dStores); err != nil {
+		return fmt.Errorf("c.validate:%w", err)
+	}
+
+	eScheduled := scheduledTransactionToEntScheduledTransaction(c)
+
+	err := dStores.InTransaction(func(txStores *datastore.Datastores) error {
+		if err := txStores.ScheduledTransactionStore().Update(&eScheduled); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrScheduledTransactionNotFound
+			}
+
+			return fmt.Errorf("ds.ScheduledTransactionStore().Update:%w [ScheduledTransaction:%s]", err,
+				eScheduled.ScheduledName)
+		}
+
+		if !c.CatchUp {
+			return nil
+		}
+
+		eScheduled.NextDue = eScheduled.StartDate
+
+		if err := txStores.ScheduledTransactionStore().SetNextDue(&eScheduled); err != nil {
+			return fmt.Errorf("ds.ScheduledTransactionStore().SetNextDue:%w [ScheduledTransaction:%s]", err,
+				eScheduled.ScheduledName)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	*c = *entScheduledTransactionToScheduledTransaction(&eScheduled)
+
+	return nil
+}
+
+// Delete deletes a ScheduledTransaction, the transactions it posted are kept
+func (c *ScheduledTransaction) Delete(dStores *datastore.Datastores) error {
+	eScheduled := scheduledTransactionToEntScheduledTransaction(c)
+
+	if err := dStores.ScheduledTransactionStore().Delete(&eScheduled); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrScheduledTransactionNotFound
+		}
+
+		return fmt.Errorf("ds.ScheduledTransactionStore().Delete:%w [ScheduledTransaction:%+v]", err, c)
+	}
+
+	return nil
+}
+
+func (c *ScheduledTransaction) validate(dStores *datastore.Datastores) error {
+	if strings.TrimSpace(c.ScheduledName) == "" {
+		return fmt.Errorf("%w: name cannot be empty", ErrScheduledTransactionInvalid)
+	}
+
+	myRule, err := recurrence.Parse(c.RecurrenceRule)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrScheduledTransactionInvalid, err)
+	}
+
+	c.RecurrenceRule = myRule.String()
+	c.StartDate = recurrence.Day(c.StartDate)
+
+	if c.EndDate.Valid {
+		c.EndDate.Time = recurrence.Day(c.EndDate.Time)
+
+		if c.EndDate.Time.Before(c.StartDate) {
+			return fmt.Errorf("%w: endDate is before startDate", ErrScheduledTransactionInvalid)
+		}
+	}
+
+	myTxn := c.transaction(c.StartDate)
+	if err = myTxn.validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrScheduledTransactionInvalid, err)
+	}
+
+	for _, debitCredit := range c.Template.DebitCreditSet {
+		if _, err = RetrieveAccountByID(dStores, debitCredit.AccountID); err != nil {
+			return fmt.Errorf("RetrieveAccountByID:%w", err)
+		}
+	}
+
+	return nil
+}
+
+// transaction is the transaction the template posts on day
+func (c *ScheduledTransaction) transaction(day time.Time) *Transaction {
+	myTxn := Transaction{
+		TransactionCore: TransactionCore{
+			TransactionID:            0,
+			TransactionDate:          day,
+			TransactionReconcileDate: sql.NullTime{Time: time.Time{}, Valid: false},
+			TransactionComment:       c.Template.TransactionComment,
+			TransactionAmount:        0,
+			TransactionReference:     c.Template.TransactionReference,
+			IsReconciled:             false,
+			IsSplit:                  false,
+		},
+		DebitCreditSet: make([]*TransactionDebitCredit, len(c.Template.DebitCreditSet)),
+	}
+
+	for idx, debitCredit := range c.Template.DebitCreditSet {
+		myTxn.DebitCreditSet[idx] = &TransactionDebitCredit{
+			TransactionDCID:          0,
+			TransactionID:            0,
+			AccountID:                debitCredit.AccountID,
+			TransactionDCAmount:      debitCredit.TransactionDCAmount,
+			DebitOrCredit:            debitCredit.DebitOrCredit,
+			IsReconciled:             false,
+			TransactionReconcileDate: sql.NullTime{Time: time.Time{}, Valid: false},
+		}
+	}
+
+	return &myTxn
+}
+
+// days is the days the schedule falls on from from through to
+func (c *ScheduledTransaction) days(from, to time.Time) ([]time.Time, error) {
+	myRule, err := recurrence.Parse(c.RecurrenceRule)
+	if err != nil {
+		return nil, fmt.Errorf("recurrence.Parse:%w [ScheduledTransaction:%d]", err, c.ScheduledID)
+	}
+
+	if c.EndDate.Valid && c.EndDate.Time.Before(to) {
+		to = c.EndDate.Time
+	}
+
+	return myRule.Between(c.StartDate, from, to), nil
+}
+
+// Occurrences is the days the schedule falls on from startDate through endDate, with the posted and skipped ones
+// that are no longer on the schedule after it was changed
+func (c *ScheduledTransaction) Occurrences(dStores *datastore.Datastores, startDate,
+	endDate time.Time) ([]*ScheduledOccurrence, error) {
+	startDate, endDate = recurrence.Day(startDate), recurrence.Day(endDate)
+
+	days, err := c.days(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	eOccurrences, err := dStores.ScheduledTransactionStore().GetOccurrences(c.ScheduledID, startDate)
+	if err != nil {
+		return nil, fmt.Errorf("ds.ScheduledTransactionStore().GetOccurrences:%w", err)
+	}
+
+	occurrences := []*ScheduledOccurrence{}
+	handled := map[time.Time]bool{}
+
+	for _, eOccurrence := range eOccurrences {
+		occurrenceDate := recurrence.Day(eOccurrence.OccurrenceDate.UTC())
+		if occurrenceDate.After(endDate) {
+			continue
+		}
+
+		handled[occurrenceDate] = true
+
+		occurrences = append(occurrences, c.occurrence(occurrenceDate, eOccurrence.OccurrenceStatus,
+			uint64(eOccurrence.TransactionID.Int64))) //nolint:gosec
+	}
+
+	for _, day := range days {
+		if !handled[day] {
+			occurrences = append(occurrences, c.occurrence(day, OccurrenceStatusPending, 0))
+		}
+	}
+
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		return occurrences[i].OccurrenceDate.Before(occurrences[j].OccurrenceDate)
+	})
+
+	return occurrences, nil
+}
+
+func (c *ScheduledTransaction) occurrence(day time.Time, status datastore.OccurrenceStatus,
+	transactionID uint64) *ScheduledOccurrence {
+	return &ScheduledOccurrence{
+		ScheduledID:      c.ScheduledID,
+		ScheduledName:    c.ScheduledName,
+		OccurrenceDate:   day,
+		OccurrenceStatus: status,
+		TransactionID:    transactionID,
+	}
+}
+
+// checkOccurrence checks the schedule falls on day
+func (c *ScheduledTransaction) checkOccurrence(day time.Time) error {
+	days, err := c.days(day, day)
+	if err != nil {
+		return err
+	}
+
+	if len(days) == 0 {
+		return ErrScheduledOccurrenceInvalid
+	}
+
+	return nil
+}
+
+// claimOccurrence inserts the row of an occurrence, ErrScheduledOccurrenceHandled when it already has one
+func (c *ScheduledTransaction) claimOccurrence(dStores *datastore.Datastores, day time.Time,
+	status datastore.OccurrenceStatus) (*datastore.ScheduledOccurrence, error) {
+	eOccurrence := datastore.ScheduledOccurrence{
+		ScheduledID:      c.ScheduledID,
+		OccurrenceDate:   day,
+		OccurrenceStatus: status,
+		TransactionID:    sql.NullInt64{Int64: 0, Valid: false},
+		ClaimedDate:      time.Time{},
+	}
+
+	if err := dStores.ScheduledTransactionStore().ClaimOccurrence(&eOccurrence); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrScheduledOccurrenceHandled
+		}
+
+		return nil, fmt.Errorf("ds.ScheduledTransactionStore().ClaimOccurrence:%w", err)
+	}
+
+	return &eOccurrence, nil
+}
+
+// SkipOccurrence marks an occurrence so it is never posted
+func (c *ScheduledTransaction) SkipOccurrence(dStores *datastore.Datastores, day time.Time) (*ScheduledOccurrence,
+	error) {
+	day = recurrence.Day(day)
+
+	if err := c.checkOccurrence(day); err != nil {
+		return nil, err
+	}
+
+	if _, err := c.claimOccurrence(dStores, day, datastore.OccurrenceStatusSkipped); err != nil {
+		return nil, err
+	}
+
+	return c.occurrence(day, datastore.OccurrenceStatusSkipped, 0), nil
+}
+
+// PostOccurrence posts the transaction of an occurrence, which may be before it is due.  The claim of the occurrence,
+// the transaction and the occurrence as posted are stored in one database transaction, so it is posted once however
+// many schedulers try to post it, and a failure part way through leaves the occurrence to be posted again.
+func (c *ScheduledTransaction) PostOccurrence(dStores *datastore.Datastores, day time.Time) (*Transaction, error) {
+	day = recurrence.Day(day)
+
+	if err := c.checkOccurrence(day); err != nil {
+		return nil, err
+	}
+
+	myTxn := c.transaction(day)
+
+	err := dStores.InTransaction(func(txStores *datastore.Datastores) error {
+		eOccurrence, err := c.claimOccurrence(txStores, day, datastore.OccurrenceStatusPosting)
+		if err != nil {
+			return err
+		}
+
+		if err = myTxn.Store(txStores); err != nil {
+			return fmt.Errorf("myTxn.Store:%w", err)
+		}
+
+		eOccurrence.TransactionID = sql.NullInt64{Int64: int64(myTxn.TransactionID), Valid: true} //nolint:gosec
+
+		if err = txStores.ScheduledTransactionStore().SetOccurrencePosted(eOccurrence); err != nil {
+			return fmt.Errorf("ds.ScheduledTransactionStore().SetOccurrencePosted:%w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return myTxn, nil
+}
+
+// RetrieveScheduledTransactionByID retrieves a specific ScheduledTransaction
+func RetrieveScheduledTransactionByID(dStores *datastore.Datastores, scheduledID uint64) (*ScheduledTransaction,
+	error) {
+	eScheduled, err := dStores.ScheduledTransactionStore().RetrieveByID(scheduledID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrScheduledTransactionNotFound
+		}
+
+		return nil, fmt.Errorf("ds.ScheduledTransactionStore().RetrieveByID:%w", err)
+	}
+
+	return entScheduledTransactionToScheduledTransaction(eScheduled), nil
+}
+
+// RetrieveScheduledTransactions retrieves the ScheduledTransactions by name, only the enabled ones when onlyEnabled
+// is set
+func RetrieveScheduledTransactions(dStores *datastore.Datastores, onlyEnabled bool) ([]*ScheduledTransaction,
+	error) {
+	eScheduledSet, err := dStores.ScheduledTransactionStore().Retrieve(onlyEnabled)
+	if err != nil {
+		return nil, fmt.Errorf("ds.ScheduledTransactionStore().Retrieve:%w", err)
+	}
+
+	scheduledSet := make([]*ScheduledTransaction, len(eScheduledSet))
+
+	for idx := range eScheduledSet {
+		scheduledSet[idx] = entScheduledTransactionToScheduledTransaction(eScheduledSet[idx])
+	}
+
+	return scheduledSet, nil
+}
+
+// RetrieveUpcomingOccurrences is the occurrences of the enabled ScheduledTransactions from startDate through
+// endDate, by date then by name
+func RetrieveUpcomingOccurrences(dStores *datastore.Datastores, startDate,
+	endDate time.Time) ([]*ScheduledOccurrence, error) {
+	scheduledSet, err := RetrieveScheduledTransactions(dStores, true)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences := []*ScheduledOccurrence{}
+
+	for _, scheduled := range scheduledSet {
+		scheduledOccurrences, err := scheduled.Occurrences(dStores, startDate, endDate)
+		if err != nil {
+			return nil, fmt.Errorf("scheduled.Occurrences:%w", err)
+		}
+
+		occurrences = append(occurrences, scheduledOccurrences...)
+	}
+
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		return occurrences[i].OccurrenceDate.Before(occurrences[j].OccurrenceDate)
+	})
+
+	return occurrences, nil
+}
+
+// PostDueScheduledOccurrences posts the occurrences of the enabled ScheduledTransactions from their NextDue through
+// asOf that were not posted or skipped yet, and then moves NextDue past asOf.  The occurrences posted by another
+// scheduler in the meantime are left alone, and an occurrence that fails is reported and NextDue is only moved up to
+// it, so it is tried again on the next run.
+func PostDueScheduledOccurrences(dStores *datastore.Datastores, asOf time.Time) ([]*ScheduledOccurrence, error) {
+	scheduledSet, err := RetrieveScheduledTransactions(dStores, true)
+	if err != nil {
+		return nil, err
+	}
+
+	posted := []*ScheduledOccurrence{}
+
+	var postErrs []error
+
+	for _, scheduled := range scheduledSet {
+		occurrences, err := scheduled.Occurrences(dStores, scheduled.NextDue, asOf)
+		if err != nil {
+			postErrs = append(postErrs, fmt.Errorf("scheduled.Occurrences:%w", err))
+
+			continue
+		}
+
+		nextDue := recurrence.Day(asOf).AddDate(0, 0, 1)
+
+		for _, occurrence := range occurrences {
+			if occurrence.OccurrenceStatus != OccurrenceStatusPending {
+				continue
+			}
+
+			myTxn, err := scheduled.PostOccurrence(dStores, occurrence.OccurrenceDate)
+			if err != nil {
+				if !errors.Is(err, ErrScheduledOccurrenceHandled) {
+					postErrs = append(postErrs, fmt.Errorf("scheduled.PostOccurrence:%w [ScheduledTransaction:%d]",
+						err, scheduled.ScheduledID))
+
+					if occurrence.OccurrenceDate.Before(nextDue) {
+						nextDue = occurrence.OccurrenceDate
+					}
+				}
+
+				continue
+			}
+
+			posted = append(posted, scheduled.occurrence(occurrence.OccurrenceDate, datastore.OccurrenceStatusPosted,
+				myTxn.TransactionID))
+		}
+
+		err = dStores.ScheduledTransactionStore().AdvanceNextDue(scheduled.ScheduledID, nextDue)
+		if err != nil {
+			postErrs = append(postErrs, fmt.Errorf("ds.ScheduledTransactionStore().AdvanceNextDue:%w "+
+				"[ScheduledTransaction:%d]", err, scheduled.ScheduledID))
+		}
+	}
+
+	return posted, errors.Join(postErrs...)
+}
+
+func scheduledTransactionToEntScheduledTransaction(scheduled *ScheduledTransaction) datastore.ScheduledTransaction {
+	return datastore.ScheduledTransaction{
+		ScheduledID:         scheduled.ScheduledID,
+		ScheduledName:       strings.TrimSpace(scheduled.ScheduledName),
+		RecurrenceRule:      scheduled.RecurrenceRule,
+		StartDate:           scheduled.StartDate,
+		EndDate:             scheduled.EndDate,
+		IsEnabled:           scheduled.IsEnabled,
+		TransactionTemplate: scheduled.Template,
+		NextDue:             scheduled.NextDue,
+	}
+}
+
+// entScheduledTransactionToScheduledTransaction converts a datastore.ScheduledTransaction, its dates are read back in
+// the time zone of the database and are days at midnight UTC again
+func entScheduledTransactionToScheduledTransaction(
+	eScheduled *datastore.ScheduledTransaction) *ScheduledTransaction {
+	endDate := eScheduled.EndDate
+	endDate.Time = endDate.Time.UTC()
+
+	return &ScheduledTransaction{
+		ScheduledID:    eScheduled.ScheduledID,
+		ScheduledName:  eScheduled.ScheduledName,
+		RecurrenceRule: eScheduled.RecurrenceRule,
+		StartDate:      eScheduled.StartDate.UTC(),
+		EndDate:        endDate,
+		IsEnabled:      eScheduled.IsEnabled,
+		Template:       eScheduled.TransactionTemplate,
+		NextDue:        eScheduled.NextDue.UTC(),
+		CatchUp:        false,
+	}
+}
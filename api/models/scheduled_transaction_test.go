@@ -0,0 +1,199 @@
+package models
+
+import (
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/recurrence"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestScheduledTransaction_PostDueScheduledOccurrences(t *testing.T) { //nolint:funlen
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	checking := Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	rent := Account{AccountName: "Rent", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeExpense}
+	err = rent.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	scheduled := ScheduledTransaction{
+		ScheduledName:  "rent",
+		RecurrenceRule: "freq=monthly;bymonthday=1",
+		StartDate:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:        sql.NullTime{Time: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), Valid: true},
+		IsEnabled:      true,
+		CatchUp:        true,
+		Template: datastore.TransactionTemplate{
+			TransactionComment:   "rent",
+			TransactionReference: "",
+			DebitCreditSet: []*datastore.TemplateDebitCredit{
+				{AccountID: rent.AccountID, DebitOrCredit: datastore.AccountSignDebit, TransactionDCAmount: 150000},
+				{AccountID: checking.AccountID, DebitOrCredit: datastore.AccountSignCredit, TransactionDCAmount: 150000},
+			},
+		},
+	}
+
+	// the template must balance
+	unbalanced := scheduled
+	unbalanced.Template.DebitCreditSet = []*datastore.TemplateDebitCredit{
+		{AccountID: rent.AccountID, DebitOrCredit: datastore.AccountSignDebit, TransactionDCAmount: 150000},
+		{AccountID: checking.AccountID, DebitOrCredit: datastore.AccountSignCredit, TransactionDCAmount: 100},
+	}
+	err = unbalanced.Store(testDS)
+	g.Expect(err).To(gomega.MatchError(ErrScheduledTransactionInvalid))
+
+	invalidRule := scheduled
+	invalidRule.RecurrenceRule = "FREQ=HOURLY"
+	err = invalidRule.Store(testDS)
+	g.Expect(err).To(gomega.MatchError(ErrScheduledTransactionInvalid))
+
+	err = scheduled.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(scheduled.RecurrenceRule).To(gomega.Equal("FREQ=MONTHLY;BYMONTHDAY=1"))
+	g.Expect(scheduled.NextDue).To(gomega.Equal(scheduled.StartDate))
+
+	// February is skipped, March is posted early
+	_, err = scheduled.SkipOccurrence(testDS, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	early, err := scheduled.PostOccurrence(testDS, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(early.TransactionAmount).To(gomega.Equal(uint64(150000)))
+
+	_, err = scheduled.PostOccurrence(testDS, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	g.Expect(err).To(gomega.MatchError(ErrScheduledOccurrenceHandled))
+
+	_, err = scheduled.SkipOccurrence(testDS, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC))
+	g.Expect(err).To(gomega.MatchError(ErrScheduledOccurrenceInvalid))
+
+	// a post that fails part way through is undone, so May is still to be posted
+	failing := scheduled
+	failing.Template.DebitCreditSet = []*datastore.TemplateDebitCredit{
+		{AccountID: rent.AccountID, DebitOrCredit: datastore.AccountSignDebit, TransactionDCAmount: 150000},
+		{AccountID: checking.AccountID + 1000, DebitOrCredit: datastore.AccountSignCredit, TransactionDCAmount: 150000},
+	}
+	_, err = failing.PostOccurrence(testDS, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	myJournal, err := RetrieveJournal(testDS, &TransactionJournalFilter{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myJournal.TotalCount).To(gomega.Equal(uint64(1)))
+
+	// schedulers running side by side post each due occurrence once
+	asOf := time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC)
+
+	var waitGroup sync.WaitGroup
+
+	postedCounts := make(chan int, 4)
+
+	for range 4 {
+		waitGroup.Add(1)
+
+		go func() {
+			defer waitGroup.Done()
+
+			posted, err := PostDueScheduledOccurrences(testDS, asOf)
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			postedCounts <- len(posted)
+		}()
+	}
+
+	waitGroup.Wait()
+	close(postedCounts)
+
+	total := 0
+	for count := range postedCounts {
+		total += count
+	}
+
+	// January and April, February was skipped and March posted early
+	g.Expect(total).To(gomega.Equal(2))
+
+	posted, err := PostDueScheduledOccurrences(testDS, asOf)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(posted).To(gomega.BeEmpty())
+
+	occurrences, err := scheduled.Occurrences(testDS, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(occurrences).To(gomega.HaveLen(5))
+
+	statuses := make([]datastore.OccurrenceStatus, len(occurrences))
+	for idx, occurrence := range occurrences {
+		statuses[idx] = occurrence.OccurrenceStatus
+	}
+
+	g.Expect(statuses).To(gomega.Equal([]datastore.OccurrenceStatus{datastore.OccurrenceStatusPosted,
+		datastore.OccurrenceStatusSkipped, datastore.OccurrenceStatusPosted, datastore.OccurrenceStatusPosted,
+		OccurrenceStatusPending}))
+	g.Expect(occurrences[2].TransactionID).To(gomega.Equal(early.TransactionID))
+
+	for _, occurrence := range []*ScheduledOccurrence{occurrences[0], occurrences[3]} {
+		myTxn, err := RetrieveTransactionByID(testDS, occurrence.TransactionID)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(myTxn.TransactionDate).To(gomega.BeTemporally("~", occurrence.OccurrenceDate, time.Second))
+		g.Expect(myTxn.TransactionComment).To(gomega.Equal("rent"))
+	}
+
+	// a disabled schedule is not posted
+	scheduled.IsEnabled = false
+	err = scheduled.Update(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	posted, err = PostDueScheduledOccurrences(testDS, time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(posted).To(gomega.BeEmpty())
+
+	upcoming, err := RetrieveUpcomingOccurrences(testDS, asOf, asOf.AddDate(0, 1, 0))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(upcoming).To(gomega.BeEmpty())
+
+	// a new schedule is posted from the day it is created, not from its start date
+	today := recurrence.Day(time.Now())
+	daily := ScheduledTransaction{
+		ScheduledName:  "coffee",
+		RecurrenceRule: "FREQ=DAILY",
+		StartDate:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		IsEnabled:      true,
+		Template:       scheduled.Template,
+	}
+	err = daily.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(daily.NextDue).To(gomega.Equal(today))
+
+	posted, err = PostDueScheduledOccurrences(testDS, today)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(posted).To(gomega.HaveLen(1))
+	g.Expect(posted[0].OccurrenceDate).To(gomega.Equal(today))
+
+	myDaily, err := RetrieveScheduledTransactionByID(testDS, daily.ScheduledID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myDaily.NextDue).To(gomega.Equal(today.AddDate(0, 0, 1)))
+
+	// catching up posts the past occurrences that were not posted
+	daily.EndDate = sql.NullTime{Time: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), Valid: true}
+	daily.CatchUp = true
+	err = daily.Update(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(daily.NextDue).To(gomega.Equal(daily.StartDate))
+
+	posted, err = PostDueScheduledOccurrences(testDS, today)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(posted).To(gomega.HaveLen(3))
+
+	myDaily, err = RetrieveScheduledTransactionByID(testDS, daily.ScheduledID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myDaily.NextDue).To(gomega.Equal(today.AddDate(0, 0, 1)))
+}
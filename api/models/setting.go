@@ -0,0 +1,73 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+)
+
+// Setting is a named ledger wide setting
+type Setting struct {
+	SettingName  string
+	SettingValue string
+}
+
+var ErrSettingNotFound = errors.New("setting not found")
+var errSettingNameEmptyString = errors.New("setting name cannot be empty")
+
+// StoreOrUpdate stores a setting, or updates the value if it already exists
+func (c *Setting) StoreOrUpdate(dStores *datastore.Datastores) error {
+	if c.SettingName == "" {
+		return errSettingNameEmptyString
+	}
+
+	eSetting := datastore.Setting(*c)
+
+	err := dStores.SettingStore().StoreOrUpdate(&eSetting)
+	if err != nil {
+		return fmt.Errorf("ds.SettingStore().StoreOrUpdate:%w [Setting:%+v]", err, eSetting)
+	}
+
+	*c = Setting(eSetting)
+
+	return nil
+}
+
+// RetrieveSettingByName retrieves a specific setting
+func RetrieveSettingByName(dStores *datastore.Datastores, name string) (*Setting, error) {
+	eSetting, err := dStores.SettingStore().RetrieveByName(name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSettingNotFound
+		}
+
+		return nil, fmt.Errorf("SettingStore().RetrieveByName:%w", err)
+	}
+
+	mySetting := Setting(*eSetting)
+
+	return &mySetting, nil
+}
+
+// RetrieveSettings retrieves all settings
+func RetrieveSettings(dStores *datastore.Datastores) ([]*Setting, error) {
+	eSettings, err := dStores.SettingStore().Retrieve()
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("SettingStore().Retrieve:%w", err)
+	}
+
+	settings := make([]*Setting, len(eSettings))
+
+	for idx := range eSettings {
+		mySetting := Setting(*eSettings[idx])
+		settings[idx] = &mySetting
+	}
+
+	return settings, nil
+}
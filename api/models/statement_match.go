@@ -0,0 +1,419 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+)
+
+// DefaultMatchDateTolerance is how many days a statement line and a transaction can be apart and still match
+const DefaultMatchDateTolerance = 4
+
+const (
+	// matchDateWeight, matchReferenceWeight and matchDescriptionWeight add up to a score of 1 for a line and a
+	// transaction on the same day, with the same reference and a matching description
+	matchDateWeight        = 0.5
+	matchReferenceWeight   = 0.4
+	matchDescriptionWeight = 0.1
+	// matchAmbiguityMargin is how close the next best candidate must score for a match to need confirming by hand
+	matchAmbiguityMargin = 0.15
+)
+
+// StatementMatch pairs a line of an imported statement with a transaction that was already entered.  TransactionID
+// is 0 when nothing matches and the line is offered as a new transaction.  An ambiguous match has another candidate,
+// or another line, that scores nearly as well, and is not confirmed unless it is chosen by hand.
+type StatementMatch struct {
+	ImportLineID  uint64
+	TransactionID uint64
+	Score         float64
+	IsAmbiguous   bool
+	// Candidates are the transactions the line could match, the best first
+	Candidates []*MatchCandidate
+}
+
+// MatchCandidate is an unreconciled transaction a statement line could match, Score is between 0 and 1
+type MatchCandidate struct {
+	TransactionID        uint64
+	TransactionDate      time.Time
+	TransactionComment   string
+	TransactionReference string
+	Score                float64
+}
+
+// MatchConfirmation reconciles a transaction as the statement line ImportLineID
+type MatchConfirmation struct {
+	ImportLineID  uint64
+	TransactionID uint64
+}
+
+var ErrStatementMatchInvalid = errors.New("statement line does not match the transaction")
+
+// MatchLines matches the lines of a preview that are not duplicates with the unreconciled transactions of the
+// import account, on amount and direction, dates up to dateTolerance days apart, and reference or check number
+func (c *Import) MatchLines(dStores *datastore.Datastores, dateTolerance int) ([]*StatementMatch, error) {
+	if c.ImportStatus != datastore.ImportStatusPreview {
+		return nil, ErrImportNotPreview
+	}
+
+	lines := c.unmatchedLines()
+	if len(lines) == 0 {
+		return []*StatementMatch{}, nil
+	}
+
+	candidates, err := c.matchCandidates(dStores, lines, dateTolerance)
+	if err != nil {
+		return nil, err
+	}
+
+	return matchStatementLines(lines, candidates, dateTolerance), nil
+}
+
+// ConfirmMatches reconciles each transaction as its statement line on reconcileDate, and links the line to the
+// transaction so the line is not posted again.  Without confirmations every match that is not ambiguous is
+// confirmed.  Lines left unmatched are posted as new transactions when the import is confirmed.
+func (c *Import) ConfirmMatches(dStores *datastore.Datastores, confirmations []*MatchConfirmation,
+	reconcileDate time.Time, dateTolerance int) ([]*StatementMatch, error) {
+	matches, err := c.MatchLines(dStores, dateTolerance)
+	if err != nil {
+		return nil, err
+	}
+
+	if confirmations == nil {
+		for _, match := range matches {
+			if match.TransactionID != 0 && !match.IsAmbiguous {
+				confirmations = append(confirmations,
+					&MatchConfirmation{ImportLineID: match.ImportLineID, TransactionID: match.TransactionID})
+			}
+		}
+	}
+
+	if reconcileDate.IsZero() {
+		reconcileDate = c.matchReconcileDate()
+	}
+
+	if err = c.validateConfirmations(dStores, confirmations); err != nil {
+		return nil, err
+	}
+
+	confirmed := make(map[uint64]bool, len(confirmations))
+
+	for _, confirmation := range confirmations {
+		if err = c.confirmMatch(dStores, confirmation, reconcileDate); err != nil {
+			return nil, err
+		}
+
+		confirmed[confirmation.ImportLineID] = true
+	}
+
+	remaining := make([]*StatementMatch, 0, len(matches))
+
+	for _, match := range matches {
+		if !confirmed[match.ImportLineID] {
+			remaining = append(remaining, match)
+		}
+	}
+
+	return remaining, nil
+}
+
+// unmatchedLines are the lines that would be posted as new transactions
+func (c *Import) unmatchedLines() []*ImportLine {
+	lines := make([]*ImportLine, 0, len(c.Lines))
+
+	for _, line := range c.Lines {
+		if !line.IsDuplicate && !line.TransactionID.Valid {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// matchCandidates are the unreconciled transactions of the import account dated within dateTolerance of the lines
+func (c *Import) matchCandidates(dStores *datastore.Datastores, lines []*ImportLine,
+	dateTolerance int) ([]*TransactionReconciliation, error) {
+	account, err := RetrieveAccountByID(dStores, c.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("RetrieveAccountByID:%w", err)
+	}
+
+	firstDate, lastDate := lines[0].LineDate, lines[0].LineDate
+
+	for _, line := range lines {
+		if line.LineDate.Before(firstDate) {
+			firstDate = line.LineDate
+		}
+
+		if line.LineDate.After(lastDate) {
+			lastDate = line.LineDate
+		}
+	}
+
+	tolerance := time.Duration(dateTolerance) * 24 * time.Hour
+
+	unreconciled, err := RetrieveUnreconciledTransactionsForDate(dStores, account.AccountLeft, account.AccountRight,
+		lastDate.Add(tolerance), account.AccountReconcileDate.Time)
+	if err != nil {
+		return nil, fmt.Errorf("RetrieveUnreconciledTransactionsForDate:%w", err)
+	}
+
+	candidates := make([]*TransactionReconciliation, 0, len(unreconciled))
+
+	for _, txn := range unreconciled {
+		if !txn.IsReconciled && !txn.TransactionDate.Before(firstDate.Add(-tolerance)) {
+			candidates = append(candidates, txn)
+		}
+	}
+
+	return candidates, nil
+}
+
+// matchReconcileDate is the date of the closing balance of the statement, or else of its last line
+func (c *Import) matchReconcileDate() time.Time {
+	if c.ClosingBalance != nil {
+		return c.ClosingBalance.BalanceDate
+	}
+
+	var lastDate time.Time
+
+	for _, line := range c.Lines {
+		if line.LineDate.After(lastDate) {
+			lastDate = line.LineDate
+		}
+	}
+
+	return lastDate
+}
+
+// validateConfirmations checks each line is an unmatched line of the import and each transaction posts its amount
+// on the same side of the import account, and that no line or transaction is confirmed twice
+func (c *Import) validateConfirmations(dStores *datastore.Datastores, confirmations []*MatchConfirmation) error {
+	lines := make(map[uint64]*ImportLine)
+
+	for _, line := range c.unmatchedLines() {
+		lines[line.ImportLineID] = line
+	}
+
+	account, err := RetrieveAccountByID(dStores, c.AccountID)
+	if err != nil {
+		return fmt.Errorf("RetrieveAccountByID:%w", err)
+	}
+
+	seenLines, seenTransactions := make(map[uint64]bool), make(map[uint64]bool)
+
+	for _, confirmation := range confirmations {
+		line, ok := lines[confirmation.ImportLineID]
+		if !ok || seenLines[confirmation.ImportLineID] || seenTransactions[confirmation.TransactionID] {
+			return fmt.Errorf("%w [ImportLine:%d]", ErrStatementMatchInvalid, confirmation.ImportLineID)
+		}
+
+		seenLines[confirmation.ImportLineID] = true
+		seenTransactions[confirmation.TransactionID] = true
+
+		myTxn, err := RetrieveTransactionByID(dStores, confirmation.TransactionID)
+		if err != nil {
+			return fmt.Errorf("RetrieveTransactionByID:%w", err)
+		}
+
+		isReconciled, err := myTxn.IsReconciledOnAccount(dStores, account)
+		if err != nil {
+			return fmt.Errorf("myTxn.IsReconciledOnAccount:%w", err)
+		}
+
+		if isReconciled || !transactionPostsLine(dStores, account, myTxn, line) {
+			return fmt.Errorf("%w [ImportLine:%d Transaction:%d]", ErrStatementMatchInvalid,
+				confirmation.ImportLineID, confirmation.TransactionID)
+		}
+	}
+
+	return nil
+}
+
+// transactionPostsLine is true when the transaction posts the amount of the line on the same side of the account
+func transactionPostsLine(dStores *datastore.Datastores, account *Account, myTxn *Transaction,
+	line *ImportLine) bool {
+	debitOrCredit, amount := lineDebitOrCredit(line)
+
+	for _, debitCredit := range myTxn.DebitCreditSet {
+		if debitCredit.DebitOrCredit != debitOrCredit || debitCredit.TransactionDCAmount != amount {
+			continue
+		}
+
+		dcAccount, err := RetrieveAccountByID(dStores, debitCredit.AccountID)
+		if err == nil && dcAccount.AccountLeft >= account.AccountLeft && dcAccount.AccountLeft <= account.AccountRight {
+			return true
+		}
+	}
+
+	return false
+}
+
+// confirmMatch links the line to the transaction, then reconciles the lines of the transaction on the import
+// account.  A failure part way is safe to retry, the line is no longer unmatched.
+func (c *Import) confirmMatch(dStores *datastore.Datastores, confirmation *MatchConfirmation,
+	reconcileDate time.Time) error {
+	for _, line := range c.Lines {
+		if line.ImportLineID != confirmation.ImportLineID {
+			continue
+		}
+
+		eLine := datastore.ImportLine(*line)
+		eLine.TransactionID = sql.NullInt64{Int64: int64(confirmation.TransactionID), Valid: true} //nolint:gosec
+
+		if err := dStores.ImportStore().SetLineTransaction(&eLine); err != nil {
+			return fmt.Errorf("ImportStore().SetLineTransaction:%w", err)
+		}
+
+		*line = ImportLine(eLine)
+	}
+
+	myTxn, err := RetrieveTransactionByID(dStores, confirmation.TransactionID)
+	if err != nil {
+		return fmt.Errorf("RetrieveTransactionByID:%w", err)
+	}
+
+	myTxn.IsReconciled = true
+	myTxn.TransactionReconcileDate = sql.NullTime{Time: reconcileDate, Valid: true}
+
+	if err = myTxn.UpdateReconciled(dStores, c.AccountID); err != nil {
+		return fmt.Errorf("myTxn.UpdateReconciled:%w", err)
+	}
+
+	return nil
+}
+
+// lineDebitOrCredit is the side and amount a line posts to the import account
+func lineDebitOrCredit(line *ImportLine) (datastore.AccountSign, uint64) {
+	if line.LineAmount < 0 {
+		return datastore.AccountSignCredit, uint64(-line.LineAmount)
+	}
+
+	return datastore.AccountSignDebit, uint64(line.LineAmount)
+}
+
+// matchStatementLines scores each line against each candidate, then pairs them best score first so a transaction
+// is matched to one line at most
+func matchStatementLines(lines []*ImportLine, candidates []*TransactionReconciliation,
+	dateTolerance int) []*StatementMatch {
+	type pairing struct {
+		match     *StatementMatch
+		candidate *MatchCandidate
+	}
+
+	matches := make([]*StatementMatch, len(lines))
+	pairings := make([]pairing, 0, len(lines))
+
+	for idx, line := range lines {
+		matches[idx] = &StatementMatch{ImportLineID: line.ImportLineID, TransactionID: 0, Score: 0,
+			IsAmbiguous: false, Candidates: []*MatchCandidate{}}
+
+		for _, txn := range candidates {
+			score, ok := matchScore(line, txn, dateTolerance)
+			if !ok {
+				continue
+			}
+
+			candidate := &MatchCandidate{
+				TransactionID:        txn.TransactionID,
+				TransactionDate:      txn.TransactionDate,
+				TransactionComment:   txn.TransactionComment,
+				TransactionReference: txn.TransactionReference,
+				Score:                score,
+			}
+			matches[idx].Candidates = append(matches[idx].Candidates, candidate)
+			pairings = append(pairings, pairing{match: matches[idx], candidate: candidate})
+		}
+
+		sort.SliceStable(matches[idx].Candidates, func(i, j int) bool {
+			return matches[idx].Candidates[i].Score > matches[idx].Candidates[j].Score
+		})
+	}
+
+	sort.SliceStable(pairings, func(i, j int) bool {
+		return pairings[i].candidate.Score > pairings[j].candidate.Score
+	})
+
+	taken := make(map[uint64]bool)
+
+	for _, pair := range pairings {
+		if pair.match.TransactionID != 0 || taken[pair.candidate.TransactionID] {
+			continue
+		}
+
+		pair.match.TransactionID = pair.candidate.TransactionID
+		pair.match.Score = pair.candidate.Score
+		taken[pair.candidate.TransactionID] = true
+	}
+
+	// a match is ambiguous when the line has another candidate, or the transaction another line, nearly as good
+	bestForTransaction := make(map[uint64][]float64)
+
+	for _, pair := range pairings {
+		bestForTransaction[pair.candidate.TransactionID] = append(bestForTransaction[pair.candidate.TransactionID],
+			pair.candidate.Score)
+	}
+
+	for _, match := range matches {
+		if match.TransactionID == 0 {
+			continue
+		}
+
+		for _, candidate := range match.Candidates {
+			if candidate.TransactionID != match.TransactionID && match.Score-candidate.Score < matchAmbiguityMargin {
+				match.IsAmbiguous = true
+			}
+		}
+
+		if scores := bestForTransaction[match.TransactionID]; len(scores) > 1 &&
+			scores[0]-scores[1] < matchAmbiguityMargin {
+			match.IsAmbiguous = true
+		}
+	}
+
+	return matches
+}
+
+// matchScore scores a line against a transaction posting to the account, false when they cannot match: the amount
+// or direction differ, they are more than dateTolerance days apart, or they have different references
+func matchScore(line *ImportLine, txn *TransactionReconciliation, dateTolerance int) (float64, bool) {
+	debitOrCredit, amount := lineDebitOrCredit(line)
+	if txn.DebitOrCredit != debitOrCredit || txn.TransactionDCAmount != amount {
+		return 0, false
+	}
+
+	days := line.LineDate.Sub(txn.TransactionDate).Hours() / 24 //nolint:mnd
+	if days < 0 {
+		days = -days
+	}
+
+	if days > float64(dateTolerance) {
+		return 0, false
+	}
+
+	score := matchDateWeight * (1 - days/float64(dateTolerance+1))
+
+	lineReference, txnReference := normalizeReference(line.LineReference), normalizeReference(txn.TransactionReference)
+
+	switch {
+	case lineReference != "" && txnReference != "" && lineReference != txnReference:
+		return 0, false
+	case lineReference != "" && lineReference == txnReference:
+		score += matchReferenceWeight
+	}
+
+	if descriptionsMatch(descriptionWords(line.LineComment), descriptionWords(txn.TransactionComment)) {
+		score += matchDescriptionWeight
+	}
+
+	return score, true
+}
+
+// normalizeReference compares references such as check numbers without case or leading zeros, "000123" is "123"
+func normalizeReference(reference string) string {
+	return strings.TrimLeft(strings.ToLower(strings.TrimSpace(reference)), "0")
+}
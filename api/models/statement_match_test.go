@@ -0,0 +1,145 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/importer"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestStatementMatch_MatchLines(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	day := func(dayOfMonth int) time.Time {
+		return time.Date(2024, 5, dayOfMonth, 0, 0, 0, 0, time.UTC)
+	}
+
+	lines := []*ImportLine{
+		{ImportLineID: 1, LineDate: day(12), LineAmount: -12750, LineComment: "HOME DEPOT #1234 - POS PURCHASE"},
+		{ImportLineID: 2, LineDate: day(15), LineAmount: -120000, LineComment: "Rent", LineReference: "1042"},
+		{ImportLineID: 3, LineDate: day(31), LineAmount: 250000, LineComment: "ACME PAYROLL"},
+		{ImportLineID: 4, LineDate: day(31), LineAmount: 999, LineComment: "INTEREST"},
+	}
+
+	candidate := func(transactionID uint64, date time.Time, debitOrCredit datastore.AccountSign, amount uint64,
+		comment, reference string) *TransactionReconciliation {
+		return &TransactionReconciliation{TransactionID: transactionID, TransactionDate: date,
+			DebitOrCredit: debitOrCredit, TransactionDCAmount: amount, TransactionComment: comment,
+			TransactionReference: reference}
+	}
+
+	candidates := []*TransactionReconciliation{
+		candidate(10, day(13), datastore.AccountSignCredit, 12750, "Home Depot", ""),
+		// too late, and the wrong direction
+		candidate(11, day(20), datastore.AccountSignCredit, 12750, "Home Depot", ""),
+		candidate(12, day(12), datastore.AccountSignDebit, 12750, "Home Depot refund", ""),
+		// the check number matches without its leading zeros, a different check number never matches
+		candidate(20, day(13), datastore.AccountSignCredit, 120000, "May rent", "001042"),
+		candidate(21, day(15), datastore.AccountSignCredit, 120000, "May rent", "1043"),
+		// two deposits of the same amount on the same day cannot be told apart
+		candidate(30, day(30), datastore.AccountSignDebit, 250000, "Pay", ""),
+		candidate(31, day(30), datastore.AccountSignDebit, 250000, "Pay", ""),
+	}
+
+	matches := matchStatementLines(lines, candidates, DefaultMatchDateTolerance)
+	g.Expect(matches).To(gomega.HaveLen(4))
+
+	g.Expect(matches[0].TransactionID).To(gomega.Equal(uint64(10)))
+	g.Expect(matches[0].Candidates).To(gomega.HaveLen(1))
+	g.Expect(matches[0].IsAmbiguous).To(gomega.BeFalse())
+
+	g.Expect(matches[1].TransactionID).To(gomega.Equal(uint64(20)))
+	g.Expect(matches[1].Score).To(gomega.BeNumerically(">", matches[0].Score))
+	g.Expect(matches[1].IsAmbiguous).To(gomega.BeFalse())
+
+	g.Expect(matches[2].TransactionID).To(gomega.BeElementOf(uint64(30), uint64(31)))
+	g.Expect(matches[2].Candidates).To(gomega.HaveLen(2))
+	g.Expect(matches[2].IsAmbiguous).To(gomega.BeTrue())
+
+	g.Expect(matches[3].TransactionID).To(gomega.BeZero())
+	g.Expect(matches[3].Candidates).To(gomega.BeEmpty())
+
+	// one transaction is matched to one line at most
+	lines = append(lines, &ImportLine{ImportLineID: 5, LineDate: day(12), LineAmount: -12750,
+		LineComment: "HOME DEPOT #1234 - POS PURCHASE"})
+
+	matches = matchStatementLines(lines, candidates, DefaultMatchDateTolerance)
+	g.Expect(matches[0].TransactionID).To(gomega.Equal(uint64(10)))
+	g.Expect(matches[0].IsAmbiguous).To(gomega.BeTrue())
+	g.Expect(matches[4].TransactionID).To(gomega.BeZero())
+}
+
+func TestStatementMatch_ConfirmMatches(t *testing.T) { //nolint:funlen
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	checking := Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	expense := Account{AccountName: "Expense", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeExpense}
+	err = expense.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	storePayment := func(date time.Time, comment, reference string, amount uint64) *Transaction {
+		txn := Transaction{TransactionCore: TransactionCore{TransactionComment: comment,
+			TransactionReference: reference, TransactionDate: date},
+			DebitCreditSet: []*TransactionDebitCredit{
+				{AccountID: expense.AccountID, DebitOrCredit: datastore.AccountSignDebit, TransactionDCAmount: amount},
+				{AccountID: checking.AccountID, DebitOrCredit: datastore.AccountSignCredit, TransactionDCAmount: amount},
+			},
+		}
+		err := txn.Store(testDS)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		return &txn
+	}
+
+	homeDepot := storePayment(time.Date(2024, 5, 11, 0, 0, 0, 0, time.UTC), "Home Depot", "", 12750)
+	rent := storePayment(time.Date(2024, 5, 14, 0, 0, 0, 0, time.UTC), "Rent", "1042", 120000)
+
+	myImport, err := NewImport(testDS, parseTestOFX(g), importer.FormatOFX, "bank_v1.ofx",
+		&ImportAccounts{AccountID: checking.AccountID, OffsetAccountID: expense.AccountID})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	matches, err := myImport.MatchLines(testDS, DefaultMatchDateTolerance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(matches).To(gomega.HaveLen(3))
+	g.Expect(matches[0].TransactionID).To(gomega.Equal(homeDepot.TransactionID))
+	g.Expect(matches[1].TransactionID).To(gomega.Equal(rent.TransactionID))
+	g.Expect(matches[2].TransactionID).To(gomega.BeZero())
+
+	// a line can only be confirmed as a transaction with its amount
+	_, err = myImport.ConfirmMatches(testDS, []*MatchConfirmation{
+		{ImportLineID: myImport.Lines[0].ImportLineID, TransactionID: rent.TransactionID},
+	}, time.Time{}, DefaultMatchDateTolerance)
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(ErrStatementMatchInvalid.Error())))
+
+	remaining, err := myImport.ConfirmMatches(testDS, nil, time.Time{}, DefaultMatchDateTolerance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(remaining).To(gomega.HaveLen(1))
+	g.Expect(remaining[0].ImportLineID).To(gomega.Equal(myImport.Lines[2].ImportLineID))
+
+	for _, transactionID := range []uint64{homeDepot.TransactionID, rent.TransactionID} {
+		myTxn, err := RetrieveTransactionByID(testDS, transactionID)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(myTxn.IsReconciledOnAccount(testDS, &checking)).To(gomega.BeTrue())
+	}
+
+	// posting only adds the unmatched line
+	err = myImport.Post(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myImport.Lines[0].TransactionID.Int64).To(gomega.Equal(int64(homeDepot.TransactionID)))
+	g.Expect(myImport.Lines[1].TransactionID.Int64).To(gomega.Equal(int64(rent.TransactionID)))
+
+	myAccount, err := RetrieveAccountByID(testDS, checking.AccountID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myAccount.AccountBalance).To(gomega.Equal(int64(250000 - 120000 - 12750)))
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -24,12 +25,16 @@ type TransactionCore struct {
 	IsReconciled             bool
 	IsSplit                  bool
 }
+
+// TransactionDebitCredit is a line of a transaction, reconciled on its own
 type TransactionDebitCredit struct {
-	TransactionDCID     uint64
-	TransactionID       uint64
-	AccountID           uint64
-	TransactionDCAmount uint64
-	DebitOrCredit       datastore.AccountSign
+	TransactionDCID          uint64
+	TransactionID            uint64
+	AccountID                uint64
+	TransactionDCAmount      uint64
+	DebitOrCredit            datastore.AccountSign
+	IsReconciled             bool
+	TransactionReconcileDate sql.NullTime
 }
 
 var ErrTransactionNotFound = errors.New("transaction not found")
@@ -53,6 +58,7 @@ func (c *Transaction) Store(dStores *datastore.Datastores) error {
 	}
 
 	c.TransactionAmount = total
+	c.reconcileLines()
 	eTxn := transactionToEntTransaction(c)
 
 	err = dStores.TransactionStore().Store(&eTxn)
@@ -141,6 +147,13 @@ func (c *Transaction) Update(dStores *datastore.Datastores) error {
 	}
 
 	c.TransactionAmount = total
+
+	err = c.keepLineReconciliation(dStores)
+	if err != nil {
+		return fmt.Errorf("c.keepLineReconciliation:%w", err)
+	}
+
+	c.reconcileLines()
 	eTxn := transactionToEntTransaction(c)
 
 	err = dStores.TransactionStore().Update(&eTxn)
@@ -206,6 +219,11 @@ func (c *Transaction) Delete(dStores *datastore.Datastores) error {
 	if err != nil {
 		return fmt.Errorf("c.handleDCSetStore:%w", err)
 	}
+	// the attachments are deleted with the transaction, their files after it
+	eAttachments, err := dStores.AttachmentStore().RetrieveByTransactionID(c.TransactionID)
+	if err != nil {
+		return fmt.Errorf("ds.AttachmentStore().RetrieveByTransactionID:%w", err)
+	}
 	// delete the existing Transaction
 	err = dStores.TransactionStore().Delete(&eTxn)
 	if err != nil {
@@ -217,6 +235,10 @@ func (c *Transaction) Delete(dStores *datastore.Datastores) error {
 		return fmt.Errorf("updateSubtotalsAndBalances:%w", err)
 	}
 
+	if err = deleteAttachmentFiles(context.Background(), dStores, eAttachments); err != nil {
+		return fmt.Errorf("deleteAttachmentFiles:%w", err)
+	}
+
 	return nil
 }
 
@@ -286,41 +308,169 @@ func (c *Transaction) transactionTotal() (uint64, error) {
 
 var ErrReconciledDateInvalid = errors.New("ReconciledDateInvalid")
 
-// This whole function should be a transaction for safety
-func (c *Transaction) UpdateReconciled(dStores *datastore.Datastores) error {
+// keepLineReconciliation gives the lines of an update that reconciles none of them the reconciled state of the
+// stored lines, so an edit does not reset lines reconciled on their own.  A line is matched to the stored line with
+// its TransactionDCID, or else to an unmatched stored line on the same account and sign.
+func (c *Transaction) keepLineReconciliation(dStores *datastore.Datastores) error {
+	for _, debitCredit := range c.DebitCreditSet {
+		if debitCredit.IsReconciled {
+			return nil
+		}
+	}
+
+	storedDCs, err := dStores.TransactionDebitCreditStore().GetDCForTransactionID(c.TransactionID)
+	if err != nil {
+		return fmt.Errorf("ds.TransactionDebitCreditStore().GetDCForTransactionID:%w [transaction:%d]", err,
+			c.TransactionID)
+	}
+
+	matched := make(map[uint64]bool)
+
+	for _, debitCredit := range c.DebitCreditSet {
+		storedDC := matchStoredLine(debitCredit, storedDCs, matched)
+		if storedDC == nil {
+			continue
+		}
+
+		matched[storedDC.TransactionDCID] = true
+		debitCredit.IsReconciled = storedDC.IsReconciled
+		debitCredit.TransactionReconcileDate = storedDC.TransactionReconcileDate
+	}
+
+	return nil
+}
+
+func matchStoredLine(debitCredit *TransactionDebitCredit, storedDCs []*datastore.TransactionDebitCredit,
+	matched map[uint64]bool) *datastore.TransactionDebitCredit {
+	if debitCredit.TransactionDCID != 0 {
+		for _, storedDC := range storedDCs {
+			if storedDC.TransactionDCID == debitCredit.TransactionDCID && !matched[storedDC.TransactionDCID] {
+				return storedDC
+			}
+		}
+	}
+
+	for _, storedDC := range storedDCs {
+		if storedDC.AccountID == debitCredit.AccountID && storedDC.DebitOrCredit == debitCredit.DebitOrCredit &&
+			!matched[storedDC.TransactionDCID] {
+			return storedDC
+		}
+	}
+
+	return nil
+}
+
+// reconcileLines makes the reconciled state of the transaction agree with its lines, which are reconciled on their
+// own.  A transaction given as reconciled without any reconciled line has all of its lines reconciled, otherwise
+// the transaction is reconciled when all of its lines are.
+func (c *Transaction) reconcileLines() {
+	isLineReconciled := false
+	isAllReconciled := true
+
+	for _, debitCredit := range c.DebitCreditSet {
+		isLineReconciled = isLineReconciled || debitCredit.IsReconciled
+		isAllReconciled = isAllReconciled && debitCredit.IsReconciled
+	}
+
+	if !isLineReconciled {
+		for _, debitCredit := range c.DebitCreditSet {
+			debitCredit.IsReconciled = c.IsReconciled
+			debitCredit.TransactionReconcileDate = c.TransactionReconcileDate
+		}
+
+		return
+	}
+
+	c.IsReconciled = isAllReconciled
+
+	for _, debitCredit := range c.DebitCreditSet {
+		if debitCredit.IsReconciled && debitCredit.TransactionReconcileDate.Valid &&
+			(!c.TransactionReconcileDate.Valid ||
+				debitCredit.TransactionReconcileDate.Time.After(c.TransactionReconcileDate.Time)) {
+			c.TransactionReconcileDate = debitCredit.TransactionReconcileDate
+		}
+	}
+}
+
+// UpdateReconciled reconciles the lines of the transaction on TransactionReconcileDate, the lines that post to
+// accountID or its children, or every line when accountID is 0
+func (c *Transaction) UpdateReconciled(dStores *datastore.Datastores, accountID uint64) error {
 	if !c.TransactionReconcileDate.Valid {
 		return ErrReconciledDateInvalid
 	}
 
-	eTxn := transactionToEntTransaction(c)
+	return c.setReconciled(dStores, accountID, true, c.TransactionReconcileDate)
+}
+
+// UpdateUnreconciled unreconciles the lines of the transaction that post to accountID or its children, or every line
+// when accountID is 0.  The lines keep the dates they were reconciled on.
+func (c *Transaction) UpdateUnreconciled(dStores *datastore.Datastores, accountID uint64) error {
+	return c.setReconciled(dStores, accountID, false, sql.NullTime{Time: time.Time{}, Valid: false})
+}
+
+func (c *Transaction) setReconciled(dStores *datastore.Datastores, accountID uint64, isReconciled bool,
+	reconcileDate sql.NullTime) error {
+	var err error
+
+	if accountID == 0 {
+		err = dStores.TransactionStore().SetReconciled(c.TransactionID, isReconciled, reconcileDate)
+	} else {
+		_, err = setReconciledBulk(dStores, accountID, []uint64{c.TransactionID}, isReconciled, reconcileDate)
+	}
 
-	err := dStores.TransactionStore().SetIsReconciled(&eTxn)
 	if err != nil {
-		return fmt.Errorf("ds.TransactionStore().SetIsReconciled:%w [transaction:%+v]", err, eTxn)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTransactionNotFound
+		}
+
+		return fmt.Errorf("setReconciled:%w [transaction:%d]", err, c.TransactionID)
 	}
 
-	err = dStores.TransactionStore().SetTransactionReconcileDate(&eTxn)
+	myTxn, err := RetrieveTransactionByID(dStores, c.TransactionID)
 	if err != nil {
-		return fmt.Errorf("ds.TransactionStore().SetTransactionReconcileDate:%w [transaction:%+v]", err, eTxn)
+		return fmt.Errorf("RetrieveTransactionByID:%w", err)
 	}
-	// set c.TransactionCore
-	c.TransactionCore = TransactionCore(eTxn)
+
+	*c = *myTxn
 
 	return nil
 }
 
-// This whole function should be a transaction for safety
-func (c *Transaction) UpdateUnreconciled(dStores *datastore.Datastores) error {
-	eTxn := transactionToEntTransaction(c)
+// IsReconciledOnAccount is whether the lines of the transaction that post to account or its children are all
+// reconciled, false when none of its lines do
+func (c *Transaction) IsReconciledOnAccount(dStores *datastore.Datastores, account *Account) (bool, error) {
+	onAccount := false
 
-	err := dStores.TransactionStore().SetIsReconciled(&eTxn)
-	if err != nil {
-		return fmt.Errorf("ds.TransactionStore().SetIsReconciled:%w [transaction:%+v]", err, eTxn)
+	for _, debitCredit := range c.DebitCreditSet {
+		dcAccount, err := RetrieveAccountByID(dStores, debitCredit.AccountID)
+		if err != nil {
+			return false, fmt.Errorf("RetrieveAccountByID:%w", err)
+		}
+
+		if dcAccount.AccountLeft < account.AccountLeft || dcAccount.AccountLeft > account.AccountRight {
+			continue
+		}
+
+		if !debitCredit.IsReconciled {
+			return false, nil
+		}
+
+		onAccount = true
 	}
-	// set c.TransactionCore
-	c.TransactionCore = TransactionCore(eTxn)
 
-	return nil
+	return onAccount, nil
+}
+
+// hasReconciledLine is whether any line of the transaction is reconciled, the transaction itself is only reconciled
+// once all of them are
+func (c *Transaction) hasReconciledLine() bool {
+	for _, debitCredit := range c.DebitCreditSet {
+		if debitCredit.IsReconciled {
+			return true
+		}
+	}
+
+	return false
 }
 
 // RetrieveTransactionByID retrieves a specific transactions
@@ -381,6 +531,8 @@ func transactionDCToEntTransactionDC(txn *TransactionDebitCredit) datastore.Tran
 
 type TransactionLedger struct {
 	TransactionID            uint64
+	TransactionDCID          uint64
+	AccountID                uint64
 	TransactionDate          time.Time
 	TransactionReconcileDate sql.NullTime
 	TransactionComment       string
@@ -391,25 +543,120 @@ type TransactionLedger struct {
 	DebitOrCredit            datastore.AccountSign
 	// split is a generated field, a comma separated list of the other d/c
 	Split string
+	// RunningBalance is the balance of the account after this line, it is only set on account ledgers
+	RunningBalance int64
+	// AttachmentCount is the number of files attached to the transaction, it is only set on account ledgers
+	AttachmentCount uint64
 }
 
-// RetrieveTransactionLedgerForAccountID retrieves all transactions ledger records in an account
-func RetrieveTransactionLedgerForAccountID(dStores *datastore.Datastores,
-	transactionID uint64) ([]*TransactionLedger, error) {
-	ts := dStores.TransactionStore()
+// TransactionLedgerFilter narrows an account ledger, dates and amounts are inclusive and unset fields do not filter.
+// Reference and Comment match case-insensitive substrings.
+type TransactionLedgerFilter struct {
+	StartDate          sql.NullTime
+	EndDate            sql.NullTime
+	MinAmount          sql.NullInt64
+	MaxAmount          sql.NullInt64
+	IsReconciled       sql.NullBool
+	Reference          string
+	Comment            string
+	IncludeSubAccounts bool
+	// Cursor is the NextCursor of the previous page, empty for the first page
+	Cursor string
+	// Limit is the page size, 0 for the whole ledger
+	Limit uint64
+	// WithTotalCount counts the lines matching the filter across all pages, which is a scan of the whole ledger
+	WithTotalCount bool
+}
+
+// AccountLedger is a page of the ledger of an account, starting from the balance as of the start date
+type AccountLedger struct {
+	OpeningBalance int64
+	// TotalCount is the number of lines matching the filter across all pages, it is only set WithTotalCount
+	TotalCount sql.NullInt64
+	// NextCursor fetches the following page, it is empty on the last page
+	NextCursor   string
+	Transactions []*TransactionLedger
+}
+
+var ErrLedgerCursorInvalid = errors.New("invalid ledger cursor")
+
+// RetrieveAccountLedger retrieves a page of the ledger records of an account, with the running balance of each line
+// computed according to the AccountSign.  With IncludeSubAccounts the ledger covers the whole nested set range of
+// the account.
+func RetrieveAccountLedger(dStores *datastore.Datastores, account *Account,
+	filter *TransactionLedgerFilter) (*AccountLedger, error) {
+	eFilter := datastore.TransactionLedgerFilter{
+		AccountLeft:  account.AccountLeft,
+		AccountRight: account.AccountLeft,
+		AccountSign:  account.AccountSign,
+		StartDate:    filter.StartDate,
+		EndDate:      filter.EndDate,
+		MinAmount:    filter.MinAmount,
+		MaxAmount:    filter.MaxAmount,
+		IsReconciled: filter.IsReconciled,
+		Reference:    filter.Reference,
+		Comment:      filter.Comment,
+		After:        nil,
+		Limit:        0,
+	}
+	if filter.IncludeSubAccounts {
+		eFilter.AccountRight = account.AccountRight
+	}
+
+	if filter.Cursor != "" {
+		afterDate, afterIDs, err := decodeCursor(filter.Cursor, 2) //nolint:mnd
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrLedgerCursorInvalid, err)
+		}
+
+		eFilter.After = &datastore.TransactionLedgerCursor{TransactionDate: afterDate,
+			TransactionID: afterIDs[0], TransactionDCID: afterIDs[1]}
+	}
+
+	// fetch one extra line to learn whether there is a next page
+	if filter.Limit > 0 {
+		eFilter.Limit = filter.Limit + 1
+	}
+
+	myLedger := AccountLedger{OpeningBalance: 0, TotalCount: sql.NullInt64{}, NextCursor: "", Transactions: nil}
+
+	if filter.StartDate.Valid {
+		subtotals, err := dStores.TransactionDebitCreditStore().GetSubtotalsBeforeDate(eFilter.AccountLeft,
+			eFilter.AccountRight, filter.StartDate.Time)
+		if err != nil {
+			return nil, fmt.Errorf("TransactionDebitCreditStore().GetSubtotalsBeforeDate:%w", err)
+		}
 
-	eTransSet, err := ts.GetTransactionsForAccount(transactionID)
+		myLedger.OpeningBalance = netSubtotals(subtotals, account.AccountSign)
+	}
+
+	if filter.WithTotalCount {
+		totalCount, err := dStores.TransactionStore().CountTransactionsForAccount(&eFilter)
+		if err != nil {
+			return nil, fmt.Errorf("TransactionStore().CountTransactionsForAccount:%w", err)
+		}
+
+		myLedger.TotalCount = sql.NullInt64{Int64: int64(totalCount), Valid: true} //nolint:gosec
+	}
+
+	eTransSet, err := dStores.TransactionStore().GetTransactionsForAccount(&eFilter)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
+			return &myLedger, nil
 		}
 
 		return nil, fmt.Errorf("TransactionStore().GetTransactionsForAccount:%w", err)
 	}
 
-	transSet := entTransactionsLedgerToTransactionsLedger(eTransSet)
+	myLedger.Transactions = entTransactionsLedgerToTransactionsLedger(eTransSet)
 
-	return transSet, nil
+	if filter.Limit > 0 && uint64(len(myLedger.Transactions)) > filter.Limit {
+		myLedger.Transactions = myLedger.Transactions[:filter.Limit]
+		lastTxn := myLedger.Transactions[filter.Limit-1]
+		myLedger.NextCursor = encodeCursor(lastTxn.TransactionDate, lastTxn.TransactionID, lastTxn.TransactionDCID)
+	}
+
+	return &myLedger, nil
 }
 
 type TransactionReconciliation struct {
@@ -462,8 +709,22 @@ func entTransactionsLedgerToTransactionsLedger(eTxn []*datastore.TransactionLedg
 	tdcSet := make([]*TransactionLedger, len(eTxn))
 
 	for idx := range eTxn {
-		myTDC := TransactionLedger(*eTxn[idx])
-		tdcSet[idx] = &myTDC
+		tdcSet[idx] = &TransactionLedger{
+			TransactionID:            eTxn[idx].TransactionID,
+			TransactionDCID:          eTxn[idx].TransactionDCID,
+			AccountID:                eTxn[idx].AccountID,
+			TransactionDate:          eTxn[idx].TransactionDate,
+			TransactionReconcileDate: eTxn[idx].TransactionReconcileDate,
+			TransactionComment:       eTxn[idx].TransactionComment,
+			TransactionReference:     eTxn[idx].TransactionReference,
+			IsReconciled:             eTxn[idx].IsReconciled,
+			IsSplit:                  eTxn[idx].IsSplit,
+			TransactionDCAmount:      eTxn[idx].TransactionDCAmount,
+			DebitOrCredit:            eTxn[idx].DebitOrCredit,
+			Split:                    eTxn[idx].Split,
+			RunningBalance:           eTxn[idx].RunningBalance,
+			AttachmentCount:          eTxn[idx].AttachmentCount,
+		}
 	}
 
 	return tdcSet
@@ -0,0 +1,282 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+)
+
+// DuplicateDateWindow is how far apart the dates of two transactions can be for them to be the same transaction,
+// a bank often books a payment a day or two after it was entered
+const DuplicateDateWindow = 3 * 24 * time.Hour
+
+// duplicateMinSimilarity is the share of words two descriptions need in common to be the same transaction
+const duplicateMinSimilarity = 0.5
+
+// TransactionDuplicate is a pair of transactions that are probably the same, found by their fingerprint: the same
+// amount on the same side of the same account, dates within DuplicateDateWindow and similar descriptions.
+// TransactionID is the older of the two.  A pair is merged, or dismissed so it is not flagged again.
+type TransactionDuplicate struct {
+	DuplicateID            uint64
+	TransactionID          uint64
+	DuplicateTransactionID uint64
+	AccountID              uint64
+	DuplicateStatus        datastore.DuplicateStatus
+	DetectedDate           time.Time
+	// Transaction and Duplicate are loaded by RetrieveTransactionDuplicates
+	Transaction *Transaction
+	Duplicate   *Transaction
+}
+
+var ErrTransactionDuplicateNotFound = errors.New("transaction duplicate not found")
+var ErrTransactionDuplicateNotSuspected = errors.New("transaction duplicate has already been dismissed")
+var ErrTransactionDuplicateKeepInvalid = errors.New("transaction to keep is not one of the duplicates")
+var ErrTransactionDuplicateReconciled = errors.New("a transaction with reconciled lines cannot be merged away")
+
+// DetectDuplicates flags the transactions that txn probably duplicates.  Pairs that were already flagged, or were
+// dismissed, are not flagged again.  It returns the newly flagged pairs.
+func DetectDuplicates(dStores *datastore.Datastores, txn *Transaction) ([]*TransactionDuplicate, error) {
+	words := descriptionWords(txn.TransactionComment)
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	var duplicates []*TransactionDuplicate
+
+	seen := map[uint64]bool{txn.TransactionID: true}
+
+	for _, myDC := range txn.DebitCreditSet {
+		filter := datastore.DuplicateCandidateFilter{
+			AccountID:            myDC.AccountID,
+			DebitOrCredit:        myDC.DebitOrCredit,
+			Amount:               myDC.TransactionDCAmount,
+			StartDate:            txn.TransactionDate.Add(-DuplicateDateWindow),
+			EndDate:              txn.TransactionDate.Add(DuplicateDateWindow),
+			ExcludeTransactionID: txn.TransactionID,
+		}
+
+		candidates, err := dStores.TransactionDuplicateStore().GetCandidates(&filter)
+		if err != nil {
+			return nil, fmt.Errorf("TransactionDuplicateStore().GetCandidates:%w", err)
+		}
+
+		for _, candidate := range candidates {
+			if seen[candidate.TransactionID] {
+				continue
+			}
+
+			seen[candidate.TransactionID] = true
+
+			if !descriptionsMatch(words, descriptionWords(candidate.TransactionComment)) {
+				continue
+			}
+
+			eDuplicate := datastore.TransactionDuplicate{
+				DuplicateID:            0,
+				TransactionID:          min(txn.TransactionID, candidate.TransactionID),
+				DuplicateTransactionID: max(txn.TransactionID, candidate.TransactionID),
+				AccountID:              myDC.AccountID,
+				DuplicateStatus:        datastore.DuplicateStatusSuspected,
+				DetectedDate:           time.Time{},
+			}
+
+			if err = dStores.TransactionDuplicateStore().Store(&eDuplicate); err != nil {
+				// the pair is already flagged or dismissed
+				if errors.Is(err, sql.ErrNoRows) {
+					continue
+				}
+
+				return nil, fmt.Errorf("TransactionDuplicateStore().Store:%w", err)
+			}
+
+			duplicates = append(duplicates, entTransactionDuplicateToTransactionDuplicate(&eDuplicate))
+		}
+	}
+
+	return duplicates, nil
+}
+
+// descriptionWords is the normalized description, its words in lower case without digits or punctuation, which
+// vary between a bank's description and one entered by hand, ie card numbers and dates
+func descriptionWords(comment string) map[string]bool {
+	words := make(map[string]bool)
+
+	fields := strings.FieldsFunc(strings.ToLower(comment), func(char rune) bool { return !unicode.IsLetter(char) })
+	for _, field := range fields {
+		if len([]rune(field)) > 1 {
+			words[field] = true
+		}
+	}
+
+	return words
+}
+
+// descriptionsMatch is true when the words of one description are all in the other, ie "Home Depot" and
+// "HOME DEPOT #1234 POS PURCHASE", or when they have most of their words in common
+func descriptionsMatch(words, otherWords map[string]bool) bool {
+	if len(words) == 0 || len(otherWords) == 0 {
+		return false
+	}
+
+	var common int
+
+	for word := range words {
+		if otherWords[word] {
+			common++
+		}
+	}
+
+	if common == min(len(words), len(otherWords)) {
+		return true
+	}
+
+	similarity := float64(common) / float64(len(words)+len(otherWords)-common)
+
+	return similarity >= duplicateMinSimilarity
+}
+
+// Merge keeps one transaction of the pair and deletes the other, the import lines posted as the deleted one are
+// moved to the one kept so the statement lines still count as posted.  keepTransactionID is 0 to keep the
+// transaction with reconciled lines, or else the older one.  A transaction with any reconciled line is never the
+// one removed, even when the rest of its lines are outstanding.
+func (c *TransactionDuplicate) Merge(dStores *datastore.Datastores, keepTransactionID uint64) (*Transaction, error) {
+	if c.DuplicateStatus != datastore.DuplicateStatusSuspected {
+		return nil, ErrTransactionDuplicateNotSuspected
+	}
+
+	if keepTransactionID != 0 && keepTransactionID != c.TransactionID && keepTransactionID != c.DuplicateTransactionID {
+		return nil, ErrTransactionDuplicateKeepInvalid
+	}
+
+	txn, err := RetrieveTransactionByID(dStores, c.TransactionID)
+	if err != nil {
+		return nil, fmt.Errorf("RetrieveTransactionByID:%w", err)
+	}
+
+	duplicate, err := RetrieveTransactionByID(dStores, c.DuplicateTransactionID)
+	if err != nil {
+		return nil, fmt.Errorf("RetrieveTransactionByID:%w", err)
+	}
+
+	keep, remove := txn, duplicate
+	if keepTransactionID == duplicate.TransactionID || (keepTransactionID == 0 && duplicate.hasReconciledLine() &&
+		!txn.hasReconciledLine()) {
+		keep, remove = duplicate, txn
+	}
+
+	if remove.hasReconciledLine() {
+		return nil, ErrTransactionDuplicateReconciled
+	}
+
+	err = dStores.ImportStore().ReplaceLineTransaction(remove.TransactionID, keep.TransactionID)
+	if err != nil {
+		return nil, fmt.Errorf("ImportStore().ReplaceLineTransaction:%w", err)
+	}
+
+	err = dStores.AttachmentStore().ReplaceTransaction(remove.TransactionID, keep.TransactionID)
+	if err != nil {
+		return nil, fmt.Errorf("AttachmentStore().ReplaceTransaction:%w", err)
+	}
+
+	// deleting the transaction deletes the pair, and any other pair it was in
+	if err = remove.Delete(dStores); err != nil {
+		return nil, fmt.Errorf("remove.Delete:%w", err)
+	}
+
+	return keep, nil
+}
+
+// Dismiss records that the pair are different transactions
+func (c *TransactionDuplicate) Dismiss(dStores *datastore.Datastores) error {
+	if c.DuplicateStatus != datastore.DuplicateStatusSuspected {
+		return ErrTransactionDuplicateNotSuspected
+	}
+
+	eDuplicate := transactionDuplicateToEntTransactionDuplicate(c)
+	eDuplicate.DuplicateStatus = datastore.DuplicateStatusDismissed
+
+	if err := dStores.TransactionDuplicateStore().SetStatus(&eDuplicate); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTransactionDuplicateNotFound
+		}
+
+		return fmt.Errorf("TransactionDuplicateStore().SetStatus:%w", err)
+	}
+
+	c.DuplicateStatus = datastore.DuplicateStatusDismissed
+
+	return nil
+}
+
+// RetrieveTransactionDuplicateByID retrieves a TransactionDuplicate, without its transactions
+func RetrieveTransactionDuplicateByID(dStores *datastore.Datastores,
+	duplicateID uint64) (*TransactionDuplicate, error) {
+	eDuplicate, err := dStores.TransactionDuplicateStore().RetrieveByID(duplicateID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTransactionDuplicateNotFound
+		}
+
+		return nil, fmt.Errorf("TransactionDuplicateStore().RetrieveByID:%w", err)
+	}
+
+	return entTransactionDuplicateToTransactionDuplicate(eDuplicate), nil
+}
+
+// RetrieveTransactionDuplicates retrieves the pairs with a status and both of their transactions, the most
+// recently detected first
+func RetrieveTransactionDuplicates(dStores *datastore.Datastores,
+	status datastore.DuplicateStatus) ([]*TransactionDuplicate, error) {
+	eDuplicates, err := dStores.TransactionDuplicateStore().RetrieveByStatus(status)
+	if err != nil {
+		return nil, fmt.Errorf("TransactionDuplicateStore().RetrieveByStatus:%w", err)
+	}
+
+	duplicates := make([]*TransactionDuplicate, len(eDuplicates))
+
+	for idx, eDuplicate := range eDuplicates {
+		myDuplicate := entTransactionDuplicateToTransactionDuplicate(eDuplicate)
+
+		if myDuplicate.Transaction, err = RetrieveTransactionByID(dStores, eDuplicate.TransactionID); err != nil {
+			return nil, fmt.Errorf("RetrieveTransactionByID:%w", err)
+		}
+
+		if myDuplicate.Duplicate, err = RetrieveTransactionByID(dStores,
+			eDuplicate.DuplicateTransactionID); err != nil {
+			return nil, fmt.Errorf("RetrieveTransactionByID:%w", err)
+		}
+
+		duplicates[idx] = myDuplicate
+	}
+
+	return duplicates, nil
+}
+
+func entTransactionDuplicateToTransactionDuplicate(eDuplicate *datastore.TransactionDuplicate) *TransactionDuplicate {
+	return &TransactionDuplicate{
+		DuplicateID:            eDuplicate.DuplicateID,
+		TransactionID:          eDuplicate.TransactionID,
+		DuplicateTransactionID: eDuplicate.DuplicateTransactionID,
+		AccountID:              eDuplicate.AccountID,
+		DuplicateStatus:        eDuplicate.DuplicateStatus,
+		DetectedDate:           eDuplicate.DetectedDate,
+		Transaction:            nil,
+		Duplicate:              nil,
+	}
+}
+
+func transactionDuplicateToEntTransactionDuplicate(myDuplicate *TransactionDuplicate) datastore.TransactionDuplicate {
+	return datastore.TransactionDuplicate{
+		DuplicateID:            myDuplicate.DuplicateID,
+		TransactionID:          myDuplicate.TransactionID,
+		DuplicateTransactionID: myDuplicate.DuplicateTransactionID,
+		AccountID:              myDuplicate.AccountID,
+		DuplicateStatus:        myDuplicate.DuplicateStatus,
+		DetectedDate:           myDuplicate.DetectedDate,
+	}
+}
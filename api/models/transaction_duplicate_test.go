@@ -0,0 +1,175 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestTransactionDuplicate_DescriptionsMatch(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	g.Expect(descriptionsMatch(descriptionWords("Home Depot"),
+		descriptionWords("HOME DEPOT #1234 - POS PURCHASE"))).To(gomega.BeTrue())
+	g.Expect(descriptionsMatch(descriptionWords("Coffee at Joe's 05/12"),
+		descriptionWords("coffee at joe s"))).To(gomega.BeTrue())
+	g.Expect(descriptionsMatch(descriptionWords("Rent payment May"),
+		descriptionWords("Rent payment June"))).To(gomega.BeTrue())
+	g.Expect(descriptionsMatch(descriptionWords("Groceries"), descriptionWords("Hardware"))).To(gomega.BeFalse())
+	// digits alone are no description
+	g.Expect(descriptionsMatch(descriptionWords("1042"), descriptionWords("1042"))).To(gomega.BeFalse())
+}
+
+func TestTransactionDuplicate_DetectMergeDismiss(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	checking := Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	expense := Account{AccountName: "Home", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeExpense}
+	err = expense.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	storeTxn := func(comment string, date time.Time, amount uint64) *Transaction {
+		txn := Transaction{TransactionCore: TransactionCore{TransactionComment: comment, TransactionDate: date},
+			DebitCreditSet: []*TransactionDebitCredit{
+				&TransactionDebitCredit{AccountID: checking.AccountID,
+					DebitOrCredit:       datastore.AccountSignCredit,
+					TransactionDCAmount: amount},
+				&TransactionDebitCredit{AccountID: expense.AccountID,
+					DebitOrCredit:       datastore.AccountSignDebit,
+					TransactionDCAmount: amount},
+			},
+		}
+		err := txn.Store(testDS)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		return &txn
+	}
+
+	manual := storeTxn("Home Depot", time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC), 12750)
+	storeTxn("Home Depot", time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC), 12750)
+	storeTxn("Groceries", time.Date(2024, 5, 11, 0, 0, 0, 0, time.UTC), 12750)
+	storeTxn("Home Depot", time.Date(2024, 5, 11, 0, 0, 0, 0, time.UTC), 9900)
+
+	imported := storeTxn("HOME DEPOT #1234 POS PURCHASE", time.Date(2024, 5, 12, 0, 0, 0, 0, time.UTC), 12750)
+
+	// only the manual entry is within the window with the same amount and a similar description
+	duplicates, err := DetectDuplicates(testDS, imported)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(duplicates).To(gomega.HaveLen(1))
+	g.Expect(duplicates[0].TransactionID).To(gomega.Equal(manual.TransactionID))
+	g.Expect(duplicates[0].DuplicateTransactionID).To(gomega.Equal(imported.TransactionID))
+	g.Expect(duplicates[0].AccountID).To(gomega.Equal(checking.AccountID))
+
+	// detecting again flags nothing new
+	duplicates, err = DetectDuplicates(testDS, imported)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(duplicates).To(gomega.BeEmpty())
+
+	suspected, err := RetrieveTransactionDuplicates(testDS, datastore.DuplicateStatusSuspected)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(suspected).To(gomega.HaveLen(1))
+	g.Expect(suspected[0].Transaction.TransactionComment).To(gomega.Equal("Home Depot"))
+	g.Expect(suspected[0].Duplicate.TransactionComment).To(gomega.Equal("HOME DEPOT #1234 POS PURCHASE"))
+
+	// a dismissed pair is remembered
+	err = suspected[0].Dismiss(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	err = suspected[0].Dismiss(testDS)
+	g.Expect(errors.Is(err, ErrTransactionDuplicateNotSuspected)).To(gomega.BeTrue())
+
+	duplicates, err = DetectDuplicates(testDS, imported)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(duplicates).To(gomega.BeEmpty())
+
+	dismissed, err := RetrieveTransactionDuplicates(testDS, datastore.DuplicateStatusDismissed)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(dismissed).To(gomega.HaveLen(1))
+
+	// a second entry of the same payment is merged into the first
+	again := storeTxn("home depot", time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC), 12750)
+	duplicates, err = DetectDuplicates(testDS, again)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(duplicates).To(gomega.HaveLen(2))
+
+	_, err = duplicates[0].Merge(testDS, again.TransactionID+1000)
+	g.Expect(errors.Is(err, ErrTransactionDuplicateKeepInvalid)).To(gomega.BeTrue())
+
+	kept, err := duplicates[0].Merge(testDS, 0)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(kept.TransactionID).To(gomega.Equal(duplicates[0].TransactionID))
+
+	_, err = RetrieveTransactionByID(testDS, again.TransactionID)
+	g.Expect(errors.Is(err, ErrTransactionNotFound)).To(gomega.BeTrue())
+
+	// the other pair of the merged transaction is gone with it
+	suspected, err = RetrieveTransactionDuplicates(testDS, datastore.DuplicateStatusSuspected)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(suspected).To(gomega.BeEmpty())
+
+	account, err := RetrieveAccountByID(testDS, checking.AccountID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(account.AccountBalance).To(gomega.Equal(int64(-(12750*4 + 9900))))
+}
+
+func TestTransactionDuplicate_MergeKeepsReconciledLines(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	checking := Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	expense := Account{AccountName: "Home", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeExpense}
+	err = expense.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	storeTxn := func(date time.Time) *Transaction {
+		txn := Transaction{TransactionCore: TransactionCore{TransactionComment: "Home Depot", TransactionDate: date},
+			DebitCreditSet: []*TransactionDebitCredit{
+				{AccountID: checking.AccountID, DebitOrCredit: datastore.AccountSignCredit, TransactionDCAmount: 4200},
+				{AccountID: expense.AccountID, DebitOrCredit: datastore.AccountSignDebit, TransactionDCAmount: 4200},
+			},
+		}
+		err := txn.Store(testDS)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		return &txn
+	}
+
+	manual := storeTxn(time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC))
+	imported := storeTxn(time.Date(2024, 5, 11, 0, 0, 0, 0, time.UTC))
+
+	// only the bank side of the imported entry is reconciled, so the transaction as a whole is not
+	imported.TransactionReconcileDate = sql.NullTime{Time: time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC), Valid: true}
+	err = imported.UpdateReconciled(testDS, checking.AccountID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	duplicates, err := DetectDuplicates(testDS, imported)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(duplicates).To(gomega.HaveLen(1))
+
+	_, err = duplicates[0].Merge(testDS, manual.TransactionID)
+	g.Expect(errors.Is(err, ErrTransactionDuplicateReconciled)).To(gomega.BeTrue())
+
+	kept, err := duplicates[0].Merge(testDS, 0)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(kept.TransactionID).To(gomega.Equal(imported.TransactionID))
+	g.Expect(kept.IsReconciledOnAccount(testDS, &checking)).To(gomega.BeTrue())
+}
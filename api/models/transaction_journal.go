@@ -0,0 +1,164 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+)
+
+// TransactionJournalFilter narrows the general journal, dates and amounts are inclusive and unset fields do not
+// filter.  AccountID limits the journal to transactions touching the subtree of that account.  IsReconciled true
+// selects the transactions with all of their lines reconciled and false those with any line unreconciled.
+type TransactionJournalFilter struct {
+	AccountID    uint64
+	StartDate    sql.NullTime
+	EndDate      sql.NullTime
+	MinAmount    sql.NullInt64
+	MaxAmount    sql.NullInt64
+	IsReconciled sql.NullBool
+	Reference    string
+	Comment      string
+	// Cursor is the NextCursor of the previous page, empty for the first page
+	Cursor string
+	// Limit is the page size, 0 for the whole journal
+	Limit uint64
+}
+
+// JournalDebitCredit is a line of a journal transaction, with the names of its account resolved.  Each line is
+// reconciled on its own.
+type JournalDebitCredit struct {
+	TransactionDCID          uint64
+	TransactionID            uint64
+	AccountID                uint64
+	TransactionDCAmount      uint64
+	DebitOrCredit            datastore.AccountSign
+	AccountName              string
+	AccountFullName          string
+	IsReconciled             bool
+	TransactionReconcileDate sql.NullTime
+}
+
+// JournalTransaction is a transaction of the general journal
+type JournalTransaction struct {
+	TransactionCore
+	DebitCreditSet []*JournalDebitCredit
+}
+
+// Journal is a page of the general journal
+type Journal struct {
+	// TotalCount is the number of transactions matching the filter across all pages
+	TotalCount uint64
+	// NextCursor fetches the following page, it is empty on the last page
+	NextCursor   string
+	Transactions []*JournalTransaction
+}
+
+var ErrJournalCursorInvalid = errors.New("invalid journal cursor")
+
+// RetrieveJournal retrieves a page of all transactions in chronological order, each with its debits and credits
+func RetrieveJournal(dStores *datastore.Datastores, filter *TransactionJournalFilter) (*Journal, error) {
+	eFilter := datastore.TransactionJournalFilter{
+		AccountLeft:  sql.NullInt64{},
+		AccountRight: sql.NullInt64{},
+		StartDate:    filter.StartDate,
+		EndDate:      filter.EndDate,
+		MinAmount:    filter.MinAmount,
+		MaxAmount:    filter.MaxAmount,
+		IsReconciled: filter.IsReconciled,
+		Reference:    filter.Reference,
+		Comment:      filter.Comment,
+		After:        nil,
+		Limit:        0,
+	}
+
+	if filter.AccountID != 0 {
+		account, err := RetrieveAccountByID(dStores, filter.AccountID)
+		if err != nil {
+			return nil, fmt.Errorf("RetrieveAccountByID:%w", err)
+		}
+
+		eFilter.AccountLeft, eFilter.AccountRight = accountSubtreeRange(account)
+	}
+
+	if filter.Cursor != "" {
+		afterDate, afterIDs, err := decodeCursor(filter.Cursor, 1)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrJournalCursorInvalid, err)
+		}
+
+		eFilter.After = &datastore.TransactionJournalCursor{TransactionDate: afterDate, TransactionID: afterIDs[0]}
+	}
+
+	// fetch one extra transaction to learn whether there is a next page
+	if filter.Limit > 0 {
+		eFilter.Limit = filter.Limit + 1
+	}
+
+	totalCount, err := dStores.TransactionStore().CountJournal(&eFilter)
+	if err != nil {
+		return nil, fmt.Errorf("TransactionStore().CountJournal:%w", err)
+	}
+
+	myJournal := Journal{TotalCount: totalCount, NextCursor: "", Transactions: nil}
+
+	eTxnSet, err := dStores.TransactionStore().GetJournal(&eFilter)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &myJournal, nil
+		}
+
+		return nil, fmt.Errorf("TransactionStore().GetJournal:%w", err)
+	}
+
+	if filter.Limit > 0 && uint64(len(eTxnSet)) > filter.Limit {
+		eTxnSet = eTxnSet[:filter.Limit]
+		lastTxn := eTxnSet[filter.Limit-1]
+		myJournal.NextCursor = encodeCursor(lastTxn.TransactionDate, lastTxn.TransactionID)
+	}
+
+	myJournal.Transactions = make([]*JournalTransaction, len(eTxnSet))
+
+	for idx := range eTxnSet {
+		myJournal.Transactions[idx] = &JournalTransaction{TransactionCore: TransactionCore(*eTxnSet[idx]),
+			DebitCreditSet: nil}
+	}
+
+	if err = attachJournalDebitCredits(dStores, myJournal.Transactions); err != nil {
+		return nil, err
+	}
+
+	return &myJournal, nil
+}
+
+// attachJournalDebitCredits loads the lines of a set of transactions in one query
+func attachJournalDebitCredits(dStores *datastore.Datastores, txns []*JournalTransaction) error {
+	transactionIDs := make([]uint64, len(txns))
+	byID := make(map[uint64]*JournalTransaction, len(txns))
+
+	for idx := range txns {
+		transactionIDs[idx] = txns[idx].TransactionID
+		byID[txns[idx].TransactionID] = txns[idx]
+	}
+
+	eDCSet, err := dStores.TransactionDebitCreditStore().GetJournalDCForTransactionIDs(transactionIDs)
+	if err != nil {
+		return fmt.Errorf("TransactionDebitCreditStore().GetJournalDCForTransactionIDs:%w", err)
+	}
+
+	for idx := range eDCSet {
+		myDC := JournalDebitCredit(*eDCSet[idx])
+		if myTxn, ok := byID[myDC.TransactionID]; ok {
+			myTxn.DebitCreditSet = append(myTxn.DebitCreditSet, &myDC)
+		}
+	}
+
+	return nil
+}
+
+// accountSubtreeRange is the nested set range of an account and its sub-accounts, as query parameters
+func accountSubtreeRange(account *Account) (sql.NullInt64, sql.NullInt64) {
+	return sql.NullInt64{Int64: int64(account.AccountLeft), Valid: true}, //nolint:gosec
+		sql.NullInt64{Int64: int64(account.AccountRight), Valid: true} //nolint:gosec
+}
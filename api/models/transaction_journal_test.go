@@ -0,0 +1,169 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestTransaction_RetrieveJournal(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	banks := Account{AccountName: "Banks", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := banks.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	checking := Account{AccountName: "Checking", AccountParent: banks.AccountID, AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err = checking.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	income := Account{AccountName: "Income", AccountSign: datastore.AccountSignCredit,
+		AccountType: datastore.AccountTypeIncome}
+	err = income.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	expense := Account{AccountName: "Expense", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeExpense}
+	err = expense.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	paycheck := Transaction{TransactionCore: TransactionCore{TransactionComment: "paycheck",
+		TransactionDate: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)},
+		DebitCreditSet: []*TransactionDebitCredit{
+			&TransactionDebitCredit{AccountID: income.AccountID,
+				DebitOrCredit:       datastore.AccountSignCredit,
+				TransactionDCAmount: 50000},
+			&TransactionDebitCredit{AccountID: checking.AccountID,
+				DebitOrCredit:       datastore.AccountSignDebit,
+				TransactionDCAmount: 50000},
+		},
+	}
+	err = paycheck.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// does not touch the banks subtree
+	accrual := Transaction{TransactionCore: TransactionCore{TransactionComment: "accrual",
+		TransactionDate: time.Date(2024, 1, 20, 9, 30, 0, 0, time.UTC), TransactionReference: "ADJ1"},
+		DebitCreditSet: []*TransactionDebitCredit{
+			&TransactionDebitCredit{AccountID: income.AccountID,
+				DebitOrCredit:       datastore.AccountSignCredit,
+				TransactionDCAmount: 1000},
+			&TransactionDebitCredit{AccountID: expense.AccountID,
+				DebitOrCredit:       datastore.AccountSignDebit,
+				TransactionDCAmount: 1000},
+		},
+	}
+	err = accrual.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	rent := Transaction{TransactionCore: TransactionCore{TransactionComment: "rent",
+		TransactionDate: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		DebitCreditSet: []*TransactionDebitCredit{
+			&TransactionDebitCredit{AccountID: checking.AccountID,
+				DebitOrCredit:       datastore.AccountSignCredit,
+				TransactionDCAmount: 20000},
+			&TransactionDebitCredit{AccountID: expense.AccountID,
+				DebitOrCredit:       datastore.AccountSignDebit,
+				TransactionDCAmount: 20000},
+		},
+	}
+	err = rent.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	myJournal, err := RetrieveJournal(testDS, &TransactionJournalFilter{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myJournal.TotalCount).To(gomega.Equal(uint64(3)))
+	g.Expect(myJournal.NextCursor).To(gomega.BeEmpty())
+	g.Expect(myJournal.Transactions).To(gomega.HaveLen(3))
+	g.Expect(myJournal.Transactions[0].TransactionComment).To(gomega.Equal("paycheck"))
+	g.Expect(myJournal.Transactions[0].DebitCreditSet).To(gomega.HaveLen(2))
+	g.Expect(myJournal.Transactions[0].DebitCreditSet[0].AccountName).To(gomega.Equal("Income"))
+	g.Expect(myJournal.Transactions[0].DebitCreditSet[1].AccountFullName).To(gomega.Equal("Banks:Checking"))
+	g.Expect(myJournal.Transactions[2].TransactionComment).To(gomega.Equal("rent"))
+
+	// the subtree of banks only
+	mySubtree, err := RetrieveJournal(testDS, &TransactionJournalFilter{AccountID: banks.AccountID})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(mySubtree.TotalCount).To(gomega.Equal(uint64(2)))
+	g.Expect(mySubtree.Transactions[0].TransactionComment).To(gomega.Equal("paycheck"))
+	g.Expect(mySubtree.Transactions[1].TransactionComment).To(gomega.Equal("rent"))
+
+	page1, err := RetrieveJournal(testDS, &TransactionJournalFilter{Limit: 2})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(page1.Transactions).To(gomega.HaveLen(2))
+	g.Expect(page1.NextCursor).NotTo(gomega.BeEmpty())
+
+	page2, err := RetrieveJournal(testDS, &TransactionJournalFilter{Limit: 2, Cursor: page1.NextCursor})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(page2.TotalCount).To(gomega.Equal(uint64(3)))
+	g.Expect(page2.Transactions).To(gomega.HaveLen(1))
+	g.Expect(page2.Transactions[0].TransactionComment).To(gomega.Equal("rent"))
+	g.Expect(page2.NextCursor).To(gomega.BeEmpty())
+
+	// the end date includes the whole of its day
+	byDate, err := RetrieveJournal(testDS, &TransactionJournalFilter{
+		StartDate: sql.NullTime{Time: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), Valid: true},
+		EndDate:   sql.NullTime{Time: time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC), Valid: true}})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(byDate.Transactions).To(gomega.HaveLen(1))
+	g.Expect(byDate.Transactions[0].TransactionComment).To(gomega.Equal("accrual"))
+
+	byAmount, err := RetrieveJournal(testDS, &TransactionJournalFilter{
+		MinAmount: sql.NullInt64{Int64: 10000, Valid: true}, MaxAmount: sql.NullInt64{Int64: 30000, Valid: true}})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(byAmount.Transactions).To(gomega.HaveLen(1))
+	g.Expect(byAmount.Transactions[0].TransactionComment).To(gomega.Equal("rent"))
+
+	byReference, err := RetrieveJournal(testDS, &TransactionJournalFilter{Reference: "adj"})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(byReference.Transactions).To(gomega.HaveLen(1))
+	g.Expect(byReference.Transactions[0].TransactionComment).To(gomega.Equal("accrual"))
+
+	reconciled, err := RetrieveJournal(testDS, &TransactionJournalFilter{
+		IsReconciled: sql.NullBool{Bool: true, Valid: true}})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(reconciled.TotalCount).To(gomega.Equal(uint64(0)))
+	g.Expect(reconciled.Transactions).To(gomega.BeEmpty())
+
+	// reconciled on checking only, so it is unreconciled until its expense line is reconciled too
+	rent.TransactionReconcileDate = sql.NullTime{Time: time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC), Valid: true}
+	err = rent.UpdateReconciled(testDS, checking.AccountID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	reconciled, err = RetrieveJournal(testDS, &TransactionJournalFilter{
+		IsReconciled: sql.NullBool{Bool: true, Valid: true}})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(reconciled.Transactions).To(gomega.BeEmpty())
+
+	unreconciled, err := RetrieveJournal(testDS, &TransactionJournalFilter{
+		IsReconciled: sql.NullBool{Bool: false, Valid: true}})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(unreconciled.TotalCount).To(gomega.Equal(uint64(3)))
+
+	rent.TransactionReconcileDate = sql.NullTime{Time: time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC), Valid: true}
+	err = rent.UpdateReconciled(testDS, expense.AccountID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	reconciled, err = RetrieveJournal(testDS, &TransactionJournalFilter{
+		IsReconciled: sql.NullBool{Bool: true, Valid: true}})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(reconciled.Transactions).To(gomega.HaveLen(1))
+	g.Expect(reconciled.Transactions[0].TransactionComment).To(gomega.Equal("rent"))
+
+	unreconciled, err = RetrieveJournal(testDS, &TransactionJournalFilter{
+		IsReconciled: sql.NullBool{Bool: false, Valid: true}})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(unreconciled.TotalCount).To(gomega.Equal(uint64(2)))
+
+	_, err = RetrieveJournal(testDS, &TransactionJournalFilter{Cursor: "bogus"})
+	g.Expect(errors.Is(err, ErrJournalCursorInvalid)).To(gomega.BeTrue())
+}
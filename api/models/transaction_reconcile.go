@@ -0,0 +1,112 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+)
+
+// ReconcileResult is the transactions reconciled or unreconciled together, and the reconciled subtotal of the
+// account afterwards
+type ReconcileResult struct {
+	AccountID          uint64
+	TransactionIDs     []uint64
+	ReconcileDate      time.Time
+	ReconciledSubtotal int64
+}
+
+var ErrReconcileNoTransactions = errors.New("no transactions to reconcile")
+var ErrReconcileTransactionNotOnAccount = errors.New("transaction does not post to the account")
+
+// ReconcileTransactions marks the lines of all of transactionIDs that post to the account or its children reconciled
+// on reconcileDate at once, or none of them when one is missing or does not post to the account.  Their lines on other
+// accounts are left as they are.  The subtotal is the account's reconciled subtotal as of reconcileDate.
+func ReconcileTransactions(dStores *datastore.Datastores, accountID uint64, transactionIDs []uint64,
+	reconcileDate time.Time) (*ReconcileResult, error) {
+	if reconcileDate.IsZero() {
+		return nil, ErrReconciledDateInvalid
+	}
+
+	return setReconciledBulk(dStores, accountID, transactionIDs, true,
+		sql.NullTime{Time: reconcileDate, Valid: true})
+}
+
+// UnreconcileTransactions marks the lines of all of transactionIDs on the account unreconciled at once, or none of
+// them.  The subtotal is the account's reconciled subtotal as of cutoffDate, or its reconcile date when cutoffDate
+// is zero.
+func UnreconcileTransactions(dStores *datastore.Datastores, accountID uint64, transactionIDs []uint64,
+	cutoffDate time.Time) (*ReconcileResult, error) {
+	return setReconciledBulk(dStores, accountID, transactionIDs, false,
+		sql.NullTime{Time: cutoffDate, Valid: !cutoffDate.IsZero()})
+}
+
+func setReconciledBulk(dStores *datastore.Datastores, accountID uint64, transactionIDs []uint64,
+	isReconciled bool, reconcileDate sql.NullTime) (*ReconcileResult, error) {
+	account, err := RetrieveAccountByID(dStores, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("RetrieveAccountByID:%w", err)
+	}
+
+	transactionIDs = slices.Clone(transactionIDs)
+	slices.Sort(transactionIDs)
+	transactionIDs = slices.Compact(transactionIDs)
+
+	if len(transactionIDs) == 0 || transactionIDs[0] == 0 {
+		return nil, ErrReconcileNoTransactions
+	}
+
+	onAccount, err := dStores.TransactionStore().GetIDsOnAccounts(transactionIDs, account.AccountLeft,
+		account.AccountRight)
+	if err != nil {
+		return nil, fmt.Errorf("ds.TransactionStore().GetIDsOnAccounts:%w", err)
+	}
+
+	for _, transactionID := range transactionIDs {
+		if _, found := slices.BinarySearch(onAccount, transactionID); !found {
+			if _, err = RetrieveTransactionByID(dStores, transactionID); err != nil {
+				return nil, fmt.Errorf("RetrieveTransactionByID:%w", err)
+			}
+
+			return nil, fmt.Errorf("%w [transaction:%d account:%d]", ErrReconcileTransactionNotOnAccount,
+				transactionID, accountID)
+		}
+	}
+
+	dateToSet := reconcileDate
+	if !isReconciled {
+		// unreconciling keeps the dates the transactions were reconciled on
+		dateToSet = sql.NullTime{Time: time.Time{}, Valid: false}
+	}
+
+	err = dStores.TransactionStore().SetReconciledBulk(transactionIDs, account.AccountLeft, account.AccountRight,
+		isReconciled, dateToSet)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTransactionNotFound
+		}
+
+		return nil, fmt.Errorf("ds.TransactionStore().SetReconciledBulk:%w", err)
+	}
+
+	cutoffDate := account.AccountReconcileDate.Time
+	if reconcileDate.Valid {
+		cutoffDate = reconcileDate.Time
+	}
+
+	subtotal, err := GetReconciledSubtotal(dStores, account.AccountLeft, account.AccountRight, cutoffDate,
+		account.AccountSign)
+	if err != nil {
+		return nil, fmt.Errorf("GetReconciledSubtotal:%w", err)
+	}
+
+	return &ReconcileResult{
+		AccountID:          accountID,
+		TransactionIDs:     transactionIDs,
+		ReconcileDate:      cutoffDate,
+		ReconciledSubtotal: subtotal,
+	}, nil
+}
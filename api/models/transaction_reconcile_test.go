@@ -0,0 +1,89 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestTransaction_ReconcileTransactions(t *testing.T) { //nolint:funlen
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	card := Account{AccountName: "Credit Card", AccountSign: datastore.AccountSignCredit,
+		AccountType: datastore.AccountTypeLiability}
+	err := card.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	expense := Account{AccountName: "Expense", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeExpense}
+	err = expense.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	income := Account{AccountName: "Income", AccountSign: datastore.AccountSignCredit,
+		AccountType: datastore.AccountTypeIncome}
+	err = income.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	storeTxn := func(debitID, creditID, amount uint64) *Transaction {
+		txn := Transaction{TransactionCore: TransactionCore{TransactionComment: "card purchase",
+			TransactionDate: time.Date(2024, 7, 3, 0, 0, 0, 0, time.UTC)},
+			DebitCreditSet: []*TransactionDebitCredit{
+				{AccountID: debitID, DebitOrCredit: datastore.AccountSignDebit, TransactionDCAmount: amount},
+				{AccountID: creditID, DebitOrCredit: datastore.AccountSignCredit, TransactionDCAmount: amount},
+			},
+		}
+		err := txn.Store(testDS)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		return &txn
+	}
+
+	first := storeTxn(expense.AccountID, card.AccountID, 4000)
+	second := storeTxn(expense.AccountID, card.AccountID, 1500)
+	offCard := storeTxn(expense.AccountID, income.AccountID, 700)
+
+	reconcileDate := time.Date(2024, 7, 31, 0, 0, 0, 0, time.UTC)
+
+	_, err = ReconcileTransactions(testDS, card.AccountID, nil, reconcileDate)
+	g.Expect(err).To(gomega.MatchError(ErrReconcileNoTransactions))
+	_, err = ReconcileTransactions(testDS, card.AccountID, []uint64{first.TransactionID}, time.Time{})
+	g.Expect(err).To(gomega.MatchError(ErrReconciledDateInvalid))
+
+	// one transaction that is not on the account, or does not exist, and nothing is reconciled
+	_, err = ReconcileTransactions(testDS, card.AccountID,
+		[]uint64{first.TransactionID, offCard.TransactionID}, reconcileDate)
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(ErrReconcileTransactionNotOnAccount.Error())))
+	_, err = ReconcileTransactions(testDS, card.AccountID,
+		[]uint64{first.TransactionID, offCard.TransactionID + 1000}, reconcileDate)
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring(ErrTransactionNotFound.Error())))
+
+	myTxn, err := RetrieveTransactionByID(testDS, first.TransactionID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myTxn.IsReconciled).To(gomega.BeFalse())
+
+	result, err := ReconcileTransactions(testDS, card.AccountID,
+		[]uint64{second.TransactionID, first.TransactionID, second.TransactionID}, reconcileDate)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(result.TransactionIDs).To(gomega.Equal([]uint64{first.TransactionID, second.TransactionID}))
+	g.Expect(result.ReconciledSubtotal).To(gomega.Equal(int64(5500)))
+
+	// only the card side of the purchase is reconciled, so the transaction as a whole is not
+	myTxn, err = RetrieveTransactionByID(testDS, second.TransactionID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myTxn.IsReconciledOnAccount(testDS, &card)).To(gomega.BeTrue())
+	g.Expect(myTxn.IsReconciled).To(gomega.BeFalse())
+	g.Expect(myTxn.TransactionReconcileDate.Time.Equal(reconcileDate)).To(gomega.BeTrue())
+
+	result, err = UnreconcileTransactions(testDS, card.AccountID, []uint64{second.TransactionID}, reconcileDate)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(result.ReconciledSubtotal).To(gomega.Equal(int64(4000)))
+
+	myTxn, err = RetrieveTransactionByID(testDS, second.TransactionID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myTxn.IsReconciledOnAccount(testDS, &card)).To(gomega.BeFalse())
+}
@@ -0,0 +1,173 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/money"
+)
+
+// TransactionSearchFilter is a full-text search of the comment and reference of transactions.  Query is in web
+// search syntax: words, "quoted phrases", OR, and -excluded words.  Amount matches the transaction amount exactly,
+// MinAmount and MaxAmount are an inclusive range.  Amounts are decimals such as "127.50", in the decimals of the
+// account when there is one, otherwise in defaultSearchDecimals.  Unset fields do not filter.
+type TransactionSearchFilter struct {
+	Query     string
+	AccountID uint64
+	StartDate sql.NullTime
+	EndDate   sql.NullTime
+	Amount    string
+	MinAmount string
+	MaxAmount string
+	Offset    uint64
+	// Limit is the page size, 0 for every match
+	Limit uint64
+}
+
+// TransactionSearchResult is a transaction matching a search.  The highlights are the comment and reference, HTML
+// escaped, with each match wrapped in <mark></mark>.
+type TransactionSearchResult struct {
+	JournalTransaction
+	Rank               float64
+	CommentHighlight   string
+	ReferenceHighlight string
+}
+
+// TransactionSearch is a page of search results, the best matches first
+type TransactionSearch struct {
+	// TotalCount is the number of matches across all pages
+	TotalCount uint64
+	Results    []*TransactionSearchResult
+}
+
+// defaultSearchDecimals are the decimals of a search amount when no account is given
+const defaultSearchDecimals = 2
+
+var ErrSearchQueryEmpty = errors.New("search query cannot be empty")
+var ErrSearchAmountInvalid = errors.New("invalid search amount")
+
+// SearchTransactions runs a full-text search of the transactions
+func SearchTransactions(dStores *datastore.Datastores, filter *TransactionSearchFilter) (*TransactionSearch, error) {
+	if strings.TrimSpace(filter.Query) == "" {
+		return nil, ErrSearchQueryEmpty
+	}
+
+	eFilter := datastore.TransactionSearchFilter{
+		Query:        filter.Query,
+		AccountLeft:  sql.NullInt64{},
+		AccountRight: sql.NullInt64{},
+		StartDate:    filter.StartDate,
+		EndDate:      filter.EndDate,
+		MinAmount:    sql.NullInt64{},
+		MaxAmount:    sql.NullInt64{},
+		Offset:       filter.Offset,
+		Limit:        filter.Limit,
+	}
+
+	var decimals uint64 = defaultSearchDecimals
+
+	if filter.AccountID != 0 {
+		account, err := RetrieveAccountByID(dStores, filter.AccountID)
+		if err != nil {
+			return nil, fmt.Errorf("RetrieveAccountByID:%w", err)
+		}
+
+		eFilter.AccountLeft, eFilter.AccountRight = accountSubtreeRange(account)
+		decimals = account.AccountDecimals
+	}
+
+	if err := filter.parseAmounts(&eFilter, decimals); err != nil {
+		return nil, err
+	}
+
+	totalCount, err := dStores.TransactionStore().CountSearch(&eFilter)
+	if err != nil {
+		return nil, fmt.Errorf("TransactionStore().CountSearch:%w", err)
+	}
+
+	mySearch := TransactionSearch{TotalCount: totalCount, Results: nil}
+
+	eResults, err := dStores.TransactionStore().Search(&eFilter)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &mySearch, nil
+		}
+
+		return nil, fmt.Errorf("TransactionStore().Search:%w", err)
+	}
+
+	mySearch.Results = make([]*TransactionSearchResult, len(eResults))
+	txns := make([]*JournalTransaction, len(eResults))
+
+	for idx, eResult := range eResults {
+		mySearch.Results[idx] = &TransactionSearchResult{
+			JournalTransaction: JournalTransaction{
+				TransactionCore: TransactionCore{
+					TransactionID:            eResult.TransactionID,
+					TransactionDate:          eResult.TransactionDate,
+					TransactionReconcileDate: eResult.TransactionReconcileDate,
+					TransactionComment:       eResult.TransactionComment,
+					TransactionAmount:        eResult.TransactionAmount,
+					TransactionReference:     eResult.TransactionReference,
+					IsReconciled:             eResult.IsReconciled,
+					IsSplit:                  eResult.IsSplit,
+				},
+				DebitCreditSet: nil,
+			},
+			Rank:               eResult.Rank,
+			CommentHighlight:   eResult.CommentHighlight,
+			ReferenceHighlight: eResult.ReferenceHighlight,
+		}
+		txns[idx] = &mySearch.Results[idx].JournalTransaction
+	}
+
+	if err = attachJournalDebitCredits(dStores, txns); err != nil {
+		return nil, err
+	}
+
+	return &mySearch, nil
+}
+
+// parseAmounts converts the decimal amounts of the filter into minor units
+func (c *TransactionSearchFilter) parseAmounts(eFilter *datastore.TransactionSearchFilter, decimals uint64) error {
+	parse := func(amountStr string) (sql.NullInt64, error) {
+		if amountStr == "" {
+			return sql.NullInt64{}, nil
+		}
+
+		amount, err := money.Parse(amountStr, decimals, ".")
+		if err != nil || amount < 0 {
+			return sql.NullInt64{}, fmt.Errorf("%w: [amount:%s]", ErrSearchAmountInvalid, amountStr)
+		}
+
+		return sql.NullInt64{Int64: amount, Valid: true}, nil
+	}
+
+	if c.Amount != "" {
+		amount, err := parse(c.Amount)
+		if err != nil {
+			return err
+		}
+
+		eFilter.MinAmount, eFilter.MaxAmount = amount, amount
+
+		return nil
+	}
+
+	minAmount, err := parse(c.MinAmount)
+	if err != nil {
+		return err
+	}
+
+	maxAmount, err := parse(c.MaxAmount)
+	if err != nil {
+		return err
+	}
+
+	eFilter.MinAmount, eFilter.MaxAmount = minAmount, maxAmount
+
+	return nil
+}
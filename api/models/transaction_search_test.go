@@ -0,0 +1,124 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestTransaction_SearchTransactions(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	checking := Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	card := Account{AccountName: "Card", AccountSign: datastore.AccountSignCredit,
+		AccountType: datastore.AccountTypeLiability}
+	err = card.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	expense := Account{AccountName: "Home", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeExpense}
+	err = expense.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	storeTxn := func(comment, reference string, date time.Time, amount uint64, sourceID uint64,
+		sourceSign datastore.AccountSign) {
+		txn := Transaction{TransactionCore: TransactionCore{TransactionComment: comment,
+			TransactionReference: reference, TransactionDate: date},
+			DebitCreditSet: []*TransactionDebitCredit{
+				&TransactionDebitCredit{AccountID: sourceID,
+					DebitOrCredit:       sourceSign,
+					TransactionDCAmount: amount},
+				&TransactionDebitCredit{AccountID: expense.AccountID,
+					DebitOrCredit:       datastore.AccountSignDebit,
+					TransactionDCAmount: amount},
+			},
+		}
+		err := txn.Store(testDS)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+
+	storeTxn("Home Depot lumber", "", time.Date(2024, 4, 12, 0, 0, 0, 0, time.UTC), 12750,
+		card.AccountID, datastore.AccountSignCredit)
+	storeTxn("Home Depot paint", "", time.Date(2024, 9, 2, 0, 0, 0, 0, time.UTC), 4599,
+		checking.AccountID, datastore.AccountSignCredit)
+	storeTxn("Groceries", "depot", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), 8000,
+		checking.AccountID, datastore.AccountSignCredit)
+	storeTxn(`Nails <img src=x onerror="alert(1)"> & screws`, "<b>", time.Date(2024, 5, 31, 18, 0, 0, 0, time.UTC),
+		1999, checking.AccountID, datastore.AccountSignCredit)
+
+	mySearch, err := SearchTransactions(testDS, &TransactionSearchFilter{Query: "home depot"})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(mySearch.TotalCount).To(gomega.Equal(uint64(2)))
+	g.Expect(mySearch.Results).To(gomega.HaveLen(2))
+	g.Expect(mySearch.Results[0].Rank).To(gomega.BeNumerically(">", 0))
+	g.Expect(mySearch.Results[0].CommentHighlight).To(gomega.ContainSubstring("<mark>Depot</mark>"))
+	g.Expect(mySearch.Results[0].DebitCreditSet).To(gomega.HaveLen(2))
+
+	// a comment match outranks a reference match
+	myDepot, err := SearchTransactions(testDS, &TransactionSearchFilter{Query: "depot"})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myDepot.Results).To(gomega.HaveLen(3))
+	g.Expect(myDepot.Results[2].TransactionComment).To(gomega.Equal("Groceries"))
+	g.Expect(myDepot.Results[2].ReferenceHighlight).To(gomega.Equal("<mark>depot</mark>"))
+
+	myExact, err := SearchTransactions(testDS, &TransactionSearchFilter{Query: "depot", Amount: "127.50"})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myExact.Results).To(gomega.HaveLen(1))
+	g.Expect(myExact.Results[0].TransactionComment).To(gomega.Equal("Home Depot lumber"))
+
+	myRange, err := SearchTransactions(testDS, &TransactionSearchFilter{Query: "depot", MinAmount: "50",
+		MaxAmount: "100"})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myRange.Results).To(gomega.HaveLen(1))
+	g.Expect(myRange.Results[0].TransactionComment).To(gomega.Equal("Groceries"))
+
+	mySpring, err := SearchTransactions(testDS, &TransactionSearchFilter{Query: "home depot",
+		StartDate: sql.NullTime{Time: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+		EndDate:   sql.NullTime{Time: time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC), Valid: true}})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(mySpring.Results).To(gomega.HaveLen(1))
+	g.Expect(mySpring.Results[0].TransactionComment).To(gomega.Equal("Home Depot lumber"))
+
+	// the text of the highlights is escaped, only the marks are markup
+	myNails, err := SearchTransactions(testDS, &TransactionSearchFilter{Query: "nails",
+		EndDate: sql.NullTime{Time: time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC), Valid: true}})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myNails.Results).To(gomega.HaveLen(1))
+	g.Expect(myNails.Results[0].CommentHighlight).To(gomega.ContainSubstring("<mark>Nails</mark>"))
+	g.Expect(myNails.Results[0].CommentHighlight).To(gomega.ContainSubstring("&lt;img"))
+	g.Expect(myNails.Results[0].CommentHighlight).To(gomega.ContainSubstring("&amp;"))
+	g.Expect(myNails.Results[0].CommentHighlight).NotTo(gomega.ContainSubstring("<img"))
+	g.Expect(myNails.Results[0].ReferenceHighlight).To(gomega.Equal("&lt;b&gt;"))
+
+	myChecking, err := SearchTransactions(testDS, &TransactionSearchFilter{Query: "depot",
+		AccountID: checking.AccountID})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myChecking.TotalCount).To(gomega.Equal(uint64(2)))
+
+	myPage, err := SearchTransactions(testDS, &TransactionSearchFilter{Query: "depot", Offset: 2, Limit: 2})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myPage.TotalCount).To(gomega.Equal(uint64(3)))
+	g.Expect(myPage.Results).To(gomega.HaveLen(1))
+
+	myNone, err := SearchTransactions(testDS, &TransactionSearchFilter{Query: "hardware"})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myNone.TotalCount).To(gomega.Equal(uint64(0)))
+	g.Expect(myNone.Results).To(gomega.BeEmpty())
+
+	_, err = SearchTransactions(testDS, &TransactionSearchFilter{Query: " "})
+	g.Expect(errors.Is(err, ErrSearchQueryEmpty)).To(gomega.BeTrue())
+
+	_, err = SearchTransactions(testDS, &TransactionSearchFilter{Query: "depot", Amount: "12.345"})
+	g.Expect(errors.Is(err, ErrSearchAmountInvalid)).To(gomega.BeTrue())
+}
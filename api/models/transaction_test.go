@@ -1,11 +1,14 @@
 package models
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/mimirsoft/mimirledger/api/datastore"
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
 	"testing"
+	"time"
 )
 
 func TestTransaction_StoreInvalid(t *testing.T) {
@@ -217,7 +220,68 @@ func TestTransaction_StoreAndUpdate(t *testing.T) {
 	g.Expect(updatedA3.AccountBalance).To(gomega.Equal(int64(33000)))
 }
 
-func TestTransaction_RetrieveTransactionLedgerForAccountID(t *testing.T) {
+func TestTransaction_UpdateKeepsLineReconciled(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	checking := Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	savings := Account{AccountName: "Savings", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err = savings.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	transfer := Transaction{TransactionCore: TransactionCore{TransactionComment: "transfer",
+		TransactionDate: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		DebitCreditSet: []*TransactionDebitCredit{
+			&TransactionDebitCredit{AccountID: checking.AccountID,
+				DebitOrCredit:       datastore.AccountSignCredit,
+				TransactionDCAmount: 5000},
+			&TransactionDebitCredit{AccountID: savings.AccountID,
+				DebitOrCredit:       datastore.AccountSignDebit,
+				TransactionDCAmount: 5000},
+		},
+	}
+	err = transfer.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	reconcileDate := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	transfer.TransactionReconcileDate = sql.NullTime{Time: reconcileDate, Valid: true}
+	err = transfer.UpdateReconciled(testDS, checking.AccountID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// an edit that gives no line states, as a client unaware of the lines would send
+	edited := Transaction{TransactionCore: TransactionCore{TransactionID: transfer.TransactionID,
+		TransactionComment: "edited transfer", TransactionDate: transfer.TransactionDate},
+		DebitCreditSet: []*TransactionDebitCredit{
+			&TransactionDebitCredit{AccountID: checking.AccountID,
+				DebitOrCredit:       datastore.AccountSignCredit,
+				TransactionDCAmount: 6000},
+			&TransactionDebitCredit{AccountID: savings.AccountID,
+				DebitOrCredit:       datastore.AccountSignDebit,
+				TransactionDCAmount: 6000},
+		},
+	}
+	err = edited.Update(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	myTxn, err := RetrieveTransactionByID(testDS, transfer.TransactionID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myTxn.TransactionComment).To(gomega.Equal("edited transfer"))
+	g.Expect(myTxn.IsReconciled).To(gomega.BeFalse())
+	g.Expect(myTxn.DebitCreditSet).To(gomega.HaveLen(2))
+	g.Expect(myTxn.DebitCreditSet[0].AccountID).To(gomega.Equal(checking.AccountID))
+	g.Expect(myTxn.DebitCreditSet[0].IsReconciled).To(gomega.BeTrue())
+	g.Expect(myTxn.DebitCreditSet[0].TransactionReconcileDate.Time.Equal(reconcileDate)).To(gomega.BeTrue())
+	g.Expect(myTxn.DebitCreditSet[1].AccountID).To(gomega.Equal(savings.AccountID))
+	g.Expect(myTxn.DebitCreditSet[1].IsReconciled).To(gomega.BeFalse())
+}
+
+func TestTransaction_RetrieveAccountLedger(t *testing.T) {
 	g := gomega.NewWithT(t)
 	gomega.RegisterFailHandler(ginkgo.Fail)
 	setupDB(g)
@@ -231,7 +295,8 @@ func TestTransaction_RetrieveTransactionLedgerForAccountID(t *testing.T) {
 	err = a2.Store(testDS)
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 
-	txn := Transaction{TransactionCore: TransactionCore{TransactionComment: "woot"},
+	txn := Transaction{TransactionCore: TransactionCore{TransactionComment: "woot",
+		TransactionDate: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)},
 		DebitCreditSet: []*TransactionDebitCredit{
 			&TransactionDebitCredit{AccountID: a2.AccountID,
 				DebitOrCredit:       datastore.AccountSignCredit,
@@ -244,18 +309,192 @@ func TestTransaction_RetrieveTransactionLedgerForAccountID(t *testing.T) {
 	err = txn.Store(testDS)
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 
-	myTxn, err := RetrieveTransactionLedgerForAccountID(testDS, a1.AccountID)
+	txn2 := Transaction{TransactionCore: TransactionCore{TransactionComment: "refund",
+		TransactionDate: time.Date(2024, 2, 10, 15, 30, 0, 0, time.UTC)},
+		DebitCreditSet: []*TransactionDebitCredit{
+			&TransactionDebitCredit{AccountID: a2.AccountID,
+				DebitOrCredit:       datastore.AccountSignDebit,
+				TransactionDCAmount: 2500},
+			&TransactionDebitCredit{AccountID: a1.AccountID,
+				DebitOrCredit:       datastore.AccountSignCredit,
+				TransactionDCAmount: 2500},
+		},
+	}
+	err = txn2.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	myLedger, err := RetrieveAccountLedger(testDS, &a1, &TransactionLedgerFilter{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myLedger.OpeningBalance).To(gomega.Equal(int64(0)))
+	g.Expect(myLedger.Transactions).To(gomega.HaveLen(2))
+	g.Expect(myLedger.Transactions[0].TransactionComment).To(gomega.Equal("woot"))
+	g.Expect(myLedger.Transactions[0].TransactionDCAmount).To(gomega.Equal(uint64(10000)))
+	g.Expect(myLedger.Transactions[0].RunningBalance).To(gomega.Equal(int64(10000)))
+	g.Expect(myLedger.Transactions[1].TransactionComment).To(gomega.Equal("refund"))
+	g.Expect(myLedger.Transactions[1].RunningBalance).To(gomega.Equal(int64(7500)))
+
+	myLedger2, err := RetrieveAccountLedger(testDS, &a2, &TransactionLedgerFilter{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myLedger2.Transactions).To(gomega.HaveLen(2))
+	g.Expect(myLedger2.Transactions[0].TransactionID).To(gomega.Equal(myLedger.Transactions[0].TransactionID))
+	g.Expect(myLedger2.Transactions[0].RunningBalance).To(gomega.Equal(int64(10000)))
+	g.Expect(myLedger2.Transactions[1].RunningBalance).To(gomega.Equal(int64(7500)))
+
+	// the opening balance carries everything before the start date
+	startDate := sql.NullTime{Time: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Valid: true}
+	myLedger3, err := RetrieveAccountLedger(testDS, &a1, &TransactionLedgerFilter{StartDate: startDate})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myLedger3.OpeningBalance).To(gomega.Equal(int64(10000)))
+	g.Expect(myLedger3.Transactions).To(gomega.HaveLen(1))
+	g.Expect(myLedger3.Transactions[0].TransactionComment).To(gomega.Equal("refund"))
+	g.Expect(myLedger3.Transactions[0].RunningBalance).To(gomega.Equal(int64(7500)))
+
+	endDate := sql.NullTime{Time: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), Valid: true}
+	myLedger4, err := RetrieveAccountLedger(testDS, &a1, &TransactionLedgerFilter{EndDate: endDate})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myLedger4.Transactions).To(gomega.HaveLen(1))
+	g.Expect(myLedger4.Transactions[0].TransactionComment).To(gomega.Equal("woot"))
+
+	// the end date includes the whole of its day
+	endDate = sql.NullTime{Time: time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC), Valid: true}
+	myLedger5, err := RetrieveAccountLedger(testDS, &a1, &TransactionLedgerFilter{EndDate: endDate})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myLedger5.Transactions).To(gomega.HaveLen(2))
+}
+
+func TestTransaction_RetrieveAccountLedgerPaged(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	a1 := Account{AccountName: "MyBank", AccountSign: datastore.AccountSignDebit, AccountType: datastore.AccountTypeAsset}
+	err := a1.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	a2 := Account{AccountName: "Income", AccountSign: datastore.AccountSignCredit, AccountType: datastore.AccountTypeIncome}
+	err = a2.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// five transactions on the same date, so the pages are ordered by transaction_id
+	sameDate := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for idx := 1; idx <= 5; idx++ {
+		txn := Transaction{TransactionCore: TransactionCore{TransactionComment: fmt.Sprintf("paycheck %d", idx),
+			TransactionDate: sameDate, TransactionReference: fmt.Sprintf("REF%d", idx)},
+			DebitCreditSet: []*TransactionDebitCredit{
+				&TransactionDebitCredit{AccountID: a2.AccountID,
+					DebitOrCredit:       datastore.AccountSignCredit,
+					TransactionDCAmount: uint64(idx * 1000)},
+				&TransactionDebitCredit{AccountID: a1.AccountID,
+					DebitOrCredit:       datastore.AccountSignDebit,
+					TransactionDCAmount: uint64(idx * 1000)},
+			},
+		}
+		err = txn.Store(testDS)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+
+	page1, err := RetrieveAccountLedger(testDS, &a1, &TransactionLedgerFilter{Limit: 2, WithTotalCount: true})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(page1.TotalCount).To(gomega.Equal(sql.NullInt64{Int64: 5, Valid: true}))
+	g.Expect(page1.Transactions).To(gomega.HaveLen(2))
+	g.Expect(page1.NextCursor).NotTo(gomega.BeEmpty())
+	g.Expect(page1.Transactions[0].TransactionComment).To(gomega.Equal("paycheck 1"))
+	g.Expect(page1.Transactions[1].RunningBalance).To(gomega.Equal(int64(3000)))
+
+	page2, err := RetrieveAccountLedger(testDS, &a1, &TransactionLedgerFilter{Limit: 2, Cursor: page1.NextCursor})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	// the total is only counted when asked for
+	g.Expect(page2.TotalCount.Valid).To(gomega.BeFalse())
+	g.Expect(page2.Transactions).To(gomega.HaveLen(2))
+	g.Expect(page2.Transactions[0].TransactionComment).To(gomega.Equal("paycheck 3"))
+	// the running balance carries across pages
+	g.Expect(page2.Transactions[0].RunningBalance).To(gomega.Equal(int64(6000)))
+
+	page3, err := RetrieveAccountLedger(testDS, &a1, &TransactionLedgerFilter{Limit: 2, Cursor: page2.NextCursor})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(page3.Transactions).To(gomega.HaveLen(1))
+	g.Expect(page3.Transactions[0].TransactionComment).To(gomega.Equal("paycheck 5"))
+	g.Expect(page3.NextCursor).To(gomega.BeEmpty())
+
+	// filters narrow the count but not the running balance
+	filtered, err := RetrieveAccountLedger(testDS, &a1, &TransactionLedgerFilter{
+		MinAmount: sql.NullInt64{Int64: 2000, Valid: true},
+		MaxAmount: sql.NullInt64{Int64: 4000, Valid: true},
+		Comment:   "PAYCHECK", WithTotalCount: true})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(filtered.TotalCount).To(gomega.Equal(sql.NullInt64{Int64: 3, Valid: true}))
+	g.Expect(filtered.Transactions).To(gomega.HaveLen(3))
+	g.Expect(filtered.Transactions[0].RunningBalance).To(gomega.Equal(int64(3000)))
+	g.Expect(filtered.Transactions[2].RunningBalance).To(gomega.Equal(int64(10000)))
+
+	filteredPage, err := RetrieveAccountLedger(testDS, &a1, &TransactionLedgerFilter{
+		MinAmount: sql.NullInt64{Int64: 2000, Valid: true}, Limit: 1, Cursor: page1.NextCursor})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(filteredPage.Transactions).To(gomega.HaveLen(1))
+	g.Expect(filteredPage.Transactions[0].TransactionComment).To(gomega.Equal("paycheck 3"))
+	g.Expect(filteredPage.Transactions[0].RunningBalance).To(gomega.Equal(int64(6000)))
+
+	byReference, err := RetrieveAccountLedger(testDS, &a1, &TransactionLedgerFilter{Reference: "ref4"})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(byReference.Transactions).To(gomega.HaveLen(1))
+	g.Expect(byReference.Transactions[0].TransactionComment).To(gomega.Equal("paycheck 4"))
+
+	unreconciled, err := RetrieveAccountLedger(testDS, &a1, &TransactionLedgerFilter{
+		IsReconciled: sql.NullBool{Bool: true, Valid: true}, WithTotalCount: true})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(unreconciled.TotalCount).To(gomega.Equal(sql.NullInt64{Int64: 0, Valid: true}))
+	g.Expect(unreconciled.Transactions).To(gomega.BeEmpty())
+
+	_, err = RetrieveAccountLedger(testDS, &a1, &TransactionLedgerFilter{Limit: 2, Cursor: "not-a-cursor"})
+	g.Expect(errors.Is(err, ErrLedgerCursorInvalid)).To(gomega.BeTrue())
+}
+
+func TestTransaction_RetrieveAccountLedgerIncludeSubAccounts(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDB(g)
+
+	parent := Account{AccountName: "Banks", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := parent.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	child := Account{AccountName: "MyBank", AccountParent: parent.AccountID, AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err = child.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	income := Account{AccountName: "Income", AccountSign: datastore.AccountSignCredit,
+		AccountType: datastore.AccountTypeIncome}
+	err = income.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	txn := Transaction{TransactionCore: TransactionCore{TransactionComment: "woot"},
+		DebitCreditSet: []*TransactionDebitCredit{
+			&TransactionDebitCredit{AccountID: income.AccountID,
+				DebitOrCredit:       datastore.AccountSignCredit,
+				TransactionDCAmount: 10000},
+			&TransactionDebitCredit{AccountID: child.AccountID,
+				DebitOrCredit:       datastore.AccountSignDebit,
+				TransactionDCAmount: 10000},
+		},
+	}
+	err = txn.Store(testDS)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// the nested set bounds changed when the child was added
+	myParent, err := RetrieveAccountByID(testDS, parent.AccountID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	myLedger, err := RetrieveAccountLedger(testDS, myParent, &TransactionLedgerFilter{})
 	g.Expect(err).NotTo(gomega.HaveOccurred())
-	g.Expect(myTxn).To(gomega.HaveLen(1))
-	g.Expect(myTxn[0].TransactionComment).To(gomega.Equal("woot"))
-	g.Expect(myTxn[0].TransactionDCAmount).To(gomega.Equal(uint64(10000)))
+	g.Expect(myLedger.Transactions).To(gomega.BeEmpty())
 
-	myTxn2, err := RetrieveTransactionLedgerForAccountID(testDS, a2.AccountID)
+	myLedger, err = RetrieveAccountLedger(testDS, myParent, &TransactionLedgerFilter{IncludeSubAccounts: true})
 	g.Expect(err).NotTo(gomega.HaveOccurred())
-	g.Expect(myTxn2).To(gomega.HaveLen(1))
-	g.Expect(myTxn2[0].TransactionComment).To(gomega.Equal("woot"))
-	g.Expect(myTxn2[0].TransactionDCAmount).To(gomega.Equal(uint64(10000)))
-	g.Expect(myTxn2[0].TransactionID).To(gomega.Equal(myTxn[0].TransactionID))
+	g.Expect(myLedger.Transactions).To(gomega.HaveLen(1))
+	g.Expect(myLedger.Transactions[0].AccountID).To(gomega.Equal(child.AccountID))
+	g.Expect(myLedger.Transactions[0].RunningBalance).To(gomega.Equal(int64(10000)))
 }
 
 func TestTransaction_StoreAndDelete(t *testing.T) {
@@ -0,0 +1,164 @@
+// Package money formats and parses the integer minor-unit amounts stored in the ledger.
+// An amount of 123456 with 2 decimals is 1234.56.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	base10         = 10
+	groupingDigits = 3
+)
+
+var ErrInvalidAmount = errors.New("invalid amount")
+
+// Format formats an amount with thousands separators, ie -1234567 with 2 decimals is "-12,345.67"
+func Format(amount int64, decimals uint64) string {
+	return format(amount, decimals, ",")
+}
+
+// FormatPlain formats an amount without thousands separators, ie -1234567 with 2 decimals is "-12345.67"
+func FormatPlain(amount int64, decimals uint64) string {
+	return format(amount, decimals, "")
+}
+
+// FormatAccounting formats negative amounts in parentheses, ie -1234567 with 2 decimals is "(12,345.67)"
+func FormatAccounting(amount int64, decimals uint64) string {
+	if amount < 0 {
+		return "(" + Format(-amount, decimals) + ")"
+	}
+
+	return Format(amount, decimals)
+}
+
+func format(amount int64, decimals uint64, separator string) string {
+	negative := amount < 0
+
+	// work on the uint64 magnitude so math.MinInt64 does not overflow
+	magnitude := uint64(amount)
+	if negative {
+		magnitude = -magnitude
+	}
+
+	digits := strconv.FormatUint(magnitude, base10)
+	if pad := int(decimals) + 1 - len(digits); pad > 0 { //nolint:gosec
+		digits = strings.Repeat("0", pad) + digits
+	}
+
+	whole := digits[:len(digits)-int(decimals)]    //nolint:gosec
+	fraction := digits[len(digits)-int(decimals):] //nolint:gosec
+
+	if separator != "" {
+		whole = groupThousands(whole, separator)
+	}
+
+	var builder strings.Builder
+
+	if negative {
+		builder.WriteString("-")
+	}
+
+	builder.WriteString(whole)
+
+	if decimals > 0 {
+		builder.WriteString(".")
+		builder.WriteString(fraction)
+	}
+
+	return builder.String()
+}
+
+func groupThousands(whole string, separator string) string {
+	if len(whole) <= groupingDigits {
+		return whole
+	}
+
+	var builder strings.Builder
+
+	lead := len(whole) % groupingDigits
+	if lead > 0 {
+		builder.WriteString(whole[:lead])
+	}
+
+	for idx := lead; idx < len(whole); idx += groupingDigits {
+		if builder.Len() > 0 {
+			builder.WriteString(separator)
+		}
+
+		builder.WriteString(whole[idx : idx+groupingDigits])
+	}
+
+	return builder.String()
+}
+
+// Parse parses a decimal string such as "-1,234.5" into minor units, ie -123450 with 2 decimals.
+// decimalSeparator is usually "." or ","; the other of the two is treated as a grouping separator and ignored.
+// Spaces, apostrophes and a leading "+" are also accepted.
+func Parse(str string, decimals uint64, decimalSeparator string) (int64, error) {
+	clean := strings.TrimSpace(str)
+	if clean == "" {
+		return 0, fmt.Errorf("%w: empty string", ErrInvalidAmount)
+	}
+
+	negative := false
+
+	switch {
+	case strings.HasPrefix(clean, "(") && strings.HasSuffix(clean, ")"):
+		negative = true
+		clean = clean[1 : len(clean)-1]
+	case strings.HasPrefix(clean, "-"):
+		negative = true
+		clean = clean[1:]
+	case strings.HasPrefix(clean, "+"):
+		clean = clean[1:]
+	case strings.HasSuffix(clean, "-"):
+		negative = true
+		clean = clean[:len(clean)-1]
+	}
+
+	groupSeparator := ","
+	if decimalSeparator == "," {
+		groupSeparator = "."
+	}
+
+	clean = strings.NewReplacer(groupSeparator, "", " ", "", "'", "", " ", "").Replace(clean)
+
+	whole, fraction, _ := strings.Cut(clean, decimalSeparator)
+	if whole == "" && fraction == "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, str)
+	}
+
+	if uint64(len(fraction)) > decimals {
+		// allow extra trailing zeros, ie "12.500" with 2 decimals
+		extra := fraction[decimals:]
+		if strings.Trim(extra, "0") != "" {
+			return 0, fmt.Errorf("%w: %q has more than %d decimals", ErrInvalidAmount, str, decimals)
+		}
+
+		fraction = fraction[:decimals]
+	}
+
+	fraction += strings.Repeat("0", int(decimals)-len(fraction)) //nolint:gosec
+
+	digits := whole + fraction
+	for _, char := range digits {
+		if char < '0' || char > '9' {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, str)
+		}
+	}
+
+	amount, err := strconv.ParseInt(digits, base10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("strconv.ParseInt:%w", err)
+	}
+
+	if negative {
+		amount = -amount
+	}
+
+	return amount, nil
+}
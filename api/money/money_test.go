@@ -0,0 +1,70 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestFormat(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	g.Expect(Format(0, 2)).To(gomega.Equal("0.00"))
+	g.Expect(Format(5, 2)).To(gomega.Equal("0.05"))
+	g.Expect(Format(-5, 2)).To(gomega.Equal("-0.05"))
+	g.Expect(Format(12345, 2)).To(gomega.Equal("123.45"))
+	g.Expect(Format(123456, 2)).To(gomega.Equal("1,234.56"))
+	g.Expect(Format(-123456789, 2)).To(gomega.Equal("-1,234,567.89"))
+	g.Expect(Format(1234, 0)).To(gomega.Equal("1,234"))
+	g.Expect(Format(1234, 3)).To(gomega.Equal("1.234"))
+	g.Expect(Format(math.MinInt64, 2)).To(gomega.Equal("-92,233,720,368,547,758.08"))
+	g.Expect(FormatPlain(-123456789, 2)).To(gomega.Equal("-1234567.89"))
+	g.Expect(FormatAccounting(-123456, 2)).To(gomega.Equal("(1,234.56)"))
+	g.Expect(FormatAccounting(123456, 2)).To(gomega.Equal("1,234.56"))
+}
+
+func TestParse(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	amount, err := Parse("127.50", 2, ".")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(amount).To(gomega.Equal(int64(12750)))
+
+	amount, err = Parse("-1,234.5", 2, ".")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(amount).To(gomega.Equal(int64(-123450)))
+
+	amount, err = Parse("1.234,56", 2, ",")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(amount).To(gomega.Equal(int64(123456)))
+
+	amount, err = Parse("(12)", 2, ".")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(amount).To(gomega.Equal(int64(-1200)))
+
+	amount, err = Parse("+.5", 2, ".")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(amount).To(gomega.Equal(int64(50)))
+
+	amount, err = Parse("12.500", 2, ".")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(amount).To(gomega.Equal(int64(1250)))
+
+	amount, err = Parse("99-", 2, ".")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(amount).To(gomega.Equal(int64(-9900)))
+
+	_, err = Parse("12.505", 2, ".")
+	g.Expect(errors.Is(err, ErrInvalidAmount)).To(gomega.BeTrue())
+
+	_, err = Parse("", 2, ".")
+	g.Expect(errors.Is(err, ErrInvalidAmount)).To(gomega.BeTrue())
+
+	_, err = Parse("12a", 2, ".")
+	g.Expect(errors.Is(err, ErrInvalidAmount)).To(gomega.BeTrue())
+}
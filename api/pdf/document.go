@@ -0,0 +1,189 @@
+// Package pdf is a small PDF 1.4 writer for text based financial statements.
+// It only uses the standard Helvetica fonts so no font files need to be embedded.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// PageSize is the width and height of a page in points (1/72 inch).
+type PageSize struct {
+	Width, Height float64
+}
+
+var (
+	PageSizeLetter = PageSize{Width: 612, Height: 792} //nolint:gochecknoglobals,mnd
+	PageSizeA4     = PageSize{Width: 595, Height: 842} //nolint:gochecknoglobals,mnd
+)
+
+// Document is an in-memory PDF document, pages are written out by WriteTo.
+type Document struct {
+	size  PageSize
+	pages []*Page
+}
+
+// Page holds the content stream for one page.
+type Page struct {
+	content bytes.Buffer
+}
+
+// NewDocument creates an empty document whose pages are of size.
+func NewDocument(size PageSize) *Document {
+	return &Document{size: size, pages: nil}
+}
+
+// Size returns the page size of the document.
+func (d *Document) Size() PageSize {
+	return d.size
+}
+
+// AddPage appends a new blank page.
+func (d *Document) AddPage() *Page {
+	page := &Page{} //nolint:exhaustruct
+	d.pages = append(d.pages, page)
+
+	return page
+}
+
+// Pages returns the pages added so far.
+func (d *Document) Pages() []*Page {
+	return d.pages
+}
+
+// Text draws text with its baseline starting at x,y.  The origin is the bottom left corner of the page.
+func (p *Page) Text(xPos, yPos float64, font Font, size float64, text string) {
+	fmt.Fprintf(&p.content, "BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+		fontResources[font].name, formatNumber(size), formatNumber(xPos), formatNumber(yPos),
+		escapeText(encodeText(text)))
+}
+
+// TextRight draws text so that it ends at xRight.
+func (p *Page) TextRight(xRight, yPos float64, font Font, size float64, text string) {
+	p.Text(xRight-TextWidth(font, size, text), yPos, font, size, text)
+}
+
+// TextCenter draws text centered on xCenter.
+func (p *Page) TextCenter(xCenter, yPos float64, font Font, size float64, text string) {
+	p.Text(xCenter-TextWidth(font, size, text)/2, yPos, font, size, text) //nolint:mnd
+}
+
+// Line draws a straight line of the given width.
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n", formatNumber(width),
+		formatNumber(x1), formatNumber(y1), formatNumber(x2), formatNumber(y2))
+}
+
+// WriteTo writes the document as a PDF file.
+func (d *Document) WriteTo(writer io.Writer) (int64, error) {
+	var buf bytes.Buffer
+
+	// object numbers: 1 catalog, 2 page tree, fonts, then a page and a content stream for each page
+	const firstFontObject = 3
+
+	firstPageObject := firstFontObject + len(fontResources)
+	offsets := make([]int, 0, firstPageObject+2*len(d.pages)) //nolint:mnd
+
+	startObject := func() {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n", len(offsets))
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	startObject()
+	buf.WriteString("<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+
+	kids := make([]string, len(d.pages))
+	for idx := range d.pages {
+		kids[idx] = strconv.Itoa(firstPageObject+2*idx) + " 0 R" //nolint:mnd
+	}
+
+	startObject()
+	fmt.Fprintf(&buf, "<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %s %s] >>\nendobj\n",
+		strings.Join(kids, " "), len(d.pages), formatNumber(d.size.Width), formatNumber(d.size.Height))
+
+	fontRefs := make([]string, len(fontResources))
+
+	for idx, font := range fontResources {
+		startObject()
+		fmt.Fprintf(&buf, "<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>\nendobj\n",
+			font.baseFont)
+
+		fontRefs[idx] = fmt.Sprintf("/%s %d 0 R", font.name, firstFontObject+idx)
+	}
+
+	for idx, page := range d.pages {
+		startObject()
+		fmt.Fprintf(&buf, "<< /Type /Page /Parent 2 0 R /Resources << /Font << %s >> >> /Contents %d 0 R >>\nendobj\n",
+			strings.Join(fontRefs, " "), firstPageObject+2*idx+1) //nolint:mnd
+
+		startObject()
+		fmt.Fprintf(&buf, "<< /Length %d >>\nstream\n", page.content.Len())
+		buf.Write(page.content.Bytes())
+		buf.WriteString("endstream\nendobj\n")
+	}
+
+	xrefOffset := buf.Len()
+
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xrefOffset)
+
+	written, err := writer.Write(buf.Bytes())
+	if err != nil {
+		return int64(written), fmt.Errorf("writer.Write:%w", err)
+	}
+
+	return int64(written), nil
+}
+
+// encodeText converts text to WinAnsi (Latin-1 for our purposes), unsupported characters become "?"
+func encodeText(text string) []byte {
+	const maxLatin1 = 0xff
+
+	encoded := make([]byte, 0, len(text))
+
+	for _, char := range text {
+		switch {
+		case char == '\t':
+			encoded = append(encoded, ' ')
+		case char < ' ' || char > maxLatin1:
+			encoded = append(encoded, '?')
+		default:
+			encoded = append(encoded, byte(char))
+		}
+	}
+
+	return encoded
+}
+
+// escapeText escapes the characters which are special inside a PDF string literal
+func escapeText(text []byte) string {
+	var builder strings.Builder
+
+	for _, char := range text {
+		if char == '(' || char == ')' || char == '\\' {
+			builder.WriteByte('\\')
+		}
+
+		builder.WriteByte(char)
+	}
+
+	return builder.String()
+}
+
+// formatNumber writes a coordinate with at most two decimals
+func formatNumber(num float64) string {
+	const hundredths = 100
+
+	return strconv.FormatFloat(math.Round(num*hundredths)/hundredths, 'f', -1, 64)
+}
@@ -0,0 +1,83 @@
+package pdf
+
+// Font is one of the standard PDF Type1 fonts, these need no embedding.
+type Font int
+
+const (
+	FontRegular Font = iota
+	FontBold
+)
+
+const (
+	firstMetricChar = 32
+	defaultWidth    = 556
+	unitsPerEm      = 1000.0
+)
+
+// resource names and base fonts, in Font order
+var fontResources = [...]struct{ name, baseFont string }{ //nolint:gochecknoglobals
+	{name: "F1", baseFont: "Helvetica"},
+	{name: "F2", baseFont: "Helvetica-Bold"},
+}
+
+// helveticaWidths are the AFM advance widths for characters 32-126
+var helveticaWidths = [...]int{ //nolint:gochecknoglobals
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// helveticaBoldWidths are the AFM advance widths for characters 32-126
+var helveticaBoldWidths = [...]int{ //nolint:gochecknoglobals
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// TextWidth returns the width in points of text set in font at size.
+func TextWidth(font Font, size float64, text string) float64 {
+	widths := helveticaWidths[:]
+	if font == FontBold {
+		widths = helveticaBoldWidths[:]
+	}
+
+	total := 0
+
+	for _, char := range encodeText(text) {
+		idx := int(char) - firstMetricChar
+		if idx >= 0 && idx < len(widths) {
+			total += widths[idx]
+		} else {
+			total += defaultWidth
+		}
+	}
+
+	return float64(total) * size / unitsPerEm
+}
+
+// TruncateToWidth shortens text with a trailing "..." so that it fits in width.
+func TruncateToWidth(font Font, size float64, text string, width float64) string {
+	if TextWidth(font, size, text) <= width {
+		return text
+	}
+
+	const ellipsis = "..."
+
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+
+		candidate := string(runes) + ellipsis
+		if TextWidth(font, size, candidate) <= width {
+			return candidate
+		}
+	}
+
+	return ""
+}
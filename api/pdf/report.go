@@ -0,0 +1,246 @@
+package pdf
+
+import (
+	"strconv"
+)
+
+// Report is a paginated financial statement: a page header, one or more tables and page numbers.
+type Report struct {
+	Title    string // printed first on every page
+	Subtitle string // ie the report name
+	Period   string // ie "January 1, 2024 - December 31, 2024"
+	Tables   []*Table
+}
+
+// Table is a titled set of rows, columns without a width share whatever width is left over by the others.
+type Table struct {
+	Title   string
+	Columns []Column
+	Rows    []Row
+}
+
+// Column describes one table column.
+type Column struct {
+	Header     string
+	Width      float64 // zero to share the left over width
+	AlignRight bool
+}
+
+// Row is one printed line of a table.
+type Row struct {
+	Cells     []string
+	Indent    int  // indentation level of the first cell, for account hierarchies
+	Bold      bool // subtotals and totals
+	RuleAbove bool // draw a rule above the number columns, before subtotals
+}
+
+const (
+	margin          = 54.0
+	titleSize       = 14.0
+	subtitleSize    = 11.0
+	bodySize        = 9.0
+	lineHeight      = 13.0
+	indentWidth     = 12.0
+	columnGap       = 8.0
+	headerRuleWidth = 0.75
+	thinRuleWidth   = 0.5
+	footerOffset    = 30.0
+	tableGap        = 10.0
+)
+
+// Render lays out the report onto as many pages as it needs.
+func (r *Report) Render(size PageSize) *Document {
+	doc := NewDocument(size)
+	layout := reportLayout{report: r, doc: doc, page: nil, yPos: 0}
+
+	layout.newPage()
+
+	for idx, table := range r.Tables {
+		if idx > 0 {
+			layout.yPos -= tableGap
+		}
+
+		layout.renderTable(table)
+	}
+
+	pages := doc.Pages()
+	for idx, page := range pages {
+		footer := "Page " + strconv.Itoa(idx+1) + " of " + strconv.Itoa(len(pages))
+		page.TextCenter(size.Width/2, footerOffset, FontRegular, bodySize, footer) //nolint:mnd
+	}
+
+	return doc
+}
+
+type reportLayout struct {
+	report *Report
+	doc    *Document
+	page   *Page
+	yPos   float64
+}
+
+func (l *reportLayout) newPage() {
+	size := l.doc.Size()
+	l.page = l.doc.AddPage()
+	l.yPos = size.Height - margin
+
+	l.page.Text(margin, l.yPos, FontBold, titleSize, l.report.Title)
+	l.yPos -= titleSize + 4 //nolint:mnd
+
+	if l.report.Subtitle != "" {
+		l.page.Text(margin, l.yPos, FontBold, subtitleSize, l.report.Subtitle)
+		l.yPos -= subtitleSize + 3 //nolint:mnd
+	}
+
+	if l.report.Period != "" {
+		l.page.Text(margin, l.yPos, FontRegular, bodySize+1, l.report.Period)
+		l.yPos -= bodySize + 3 //nolint:mnd
+	}
+
+	l.page.Line(margin, l.yPos, size.Width-margin, l.yPos, headerRuleWidth)
+	l.yPos -= lineHeight + 4 //nolint:mnd
+}
+
+// spaceLeft reports whether count more lines fit above the footer
+func (l *reportLayout) spaceLeft(count int) bool {
+	return l.yPos-float64(count-1)*lineHeight > margin+lineHeight
+}
+
+func (l *reportLayout) renderTable(table *Table) {
+	// keep the title, column headers and at least one row together
+	if !l.spaceLeft(3) { //nolint:mnd
+		l.newPage()
+	}
+
+	if table.Title != "" {
+		l.page.Text(margin, l.yPos, FontBold, subtitleSize, table.Title)
+		l.yPos -= lineHeight + 2 //nolint:mnd
+	}
+
+	columns := l.columnEdges(table)
+
+	l.renderColumnHeaders(table, columns)
+
+	for _, row := range table.Rows {
+		if !l.spaceLeft(1) {
+			l.newPage()
+
+			if table.Title != "" {
+				l.page.Text(margin, l.yPos, FontBold, subtitleSize, table.Title+" (continued)")
+				l.yPos -= lineHeight + 2 //nolint:mnd
+			}
+
+			l.renderColumnHeaders(table, columns)
+		}
+
+		l.renderRow(table, row, columns)
+	}
+}
+
+type columnEdge struct {
+	left, right float64
+}
+
+// columnEdges lays the columns out left to right, columns without a width share the left over width
+func (l *reportLayout) columnEdges(table *Table) []columnEdge {
+	available := l.doc.Size().Width - 2*margin //nolint:mnd
+	flexible := 0
+
+	for idx, column := range table.Columns {
+		if idx > 0 {
+			available -= columnGap
+		}
+
+		if column.Width > 0 {
+			available -= column.Width
+		} else {
+			flexible++
+		}
+	}
+
+	flexWidth := 0.0
+	if flexible > 0 && available > 0 {
+		flexWidth = available / float64(flexible)
+	}
+
+	edges := make([]columnEdge, len(table.Columns))
+	left := margin
+
+	for idx, column := range table.Columns {
+		width := column.Width
+		if width <= 0 {
+			width = flexWidth
+		}
+
+		edges[idx] = columnEdge{left: left, right: left + width}
+		left += width + columnGap
+	}
+
+	return edges
+}
+
+func (l *reportLayout) renderColumnHeaders(table *Table, columns []columnEdge) {
+	hasHeaders := false
+
+	for idx, column := range table.Columns {
+		if column.Header == "" {
+			continue
+		}
+
+		hasHeaders = true
+
+		l.drawCell(table, columns, idx, FontBold, column.Header, 0)
+	}
+
+	if hasHeaders {
+		l.yPos -= 3 //nolint:mnd
+		l.page.Line(margin, l.yPos, l.doc.Size().Width-margin, l.yPos, thinRuleWidth)
+		l.yPos -= lineHeight - 3 //nolint:mnd
+	}
+}
+
+func (l *reportLayout) renderRow(table *Table, row Row, columns []columnEdge) {
+	font := FontRegular
+	if row.Bold {
+		font = FontBold
+	}
+
+	if row.RuleAbove {
+		ruleY := l.yPos + lineHeight - 3 //nolint:mnd
+
+		for idx := 1; idx < len(table.Columns); idx++ {
+			if idx < len(row.Cells) && row.Cells[idx] != "" {
+				l.page.Line(columns[idx].left, ruleY, columns[idx].right, ruleY, thinRuleWidth)
+			}
+		}
+	}
+
+	for idx := range table.Columns {
+		if idx >= len(row.Cells) || row.Cells[idx] == "" {
+			continue
+		}
+
+		indent := 0.0
+		if idx == 0 {
+			indent = float64(row.Indent) * indentWidth
+		}
+
+		l.drawCell(table, columns, idx, font, row.Cells[idx], indent)
+	}
+
+	l.yPos -= lineHeight
+}
+
+func (l *reportLayout) drawCell(table *Table, columns []columnEdge, idx int, font Font, text string,
+	indent float64) {
+	left := columns[idx].left + indent
+	text = TruncateToWidth(font, bodySize, text, columns[idx].right-left)
+
+	if table.Columns[idx].AlignRight {
+		l.page.TextRight(columns[idx].right, l.yPos, font, bodySize, text)
+
+		return
+	}
+
+	l.page.Text(left, l.yPos, font, bodySize, text)
+}
@@ -0,0 +1,100 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestDocument_WriteToXrefOffsets(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	doc := NewDocument(PageSizeLetter)
+	page := doc.AddPage()
+	page.Text(72, 700, FontRegular, 12, "Cash (checking) \\ savings")
+	page.Line(72, 690, 540, 690, 1)
+	doc.AddPage().TextRight(540, 700, FontBold, 12, "1,234.56")
+
+	var buf bytes.Buffer
+	written, err := doc.WriteTo(&buf)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(written).To(gomega.Equal(int64(buf.Len())))
+
+	output := buf.String()
+	g.Expect(output).To(gomega.HavePrefix("%PDF-1.4"))
+	g.Expect(output).To(gomega.HaveSuffix("%%EOF\n"))
+	g.Expect(output).To(gomega.ContainSubstring(`(Cash \(checking\) \\ savings) Tj`))
+	g.Expect(output).To(gomega.ContainSubstring("/Count 2"))
+	g.Expect(output).To(gomega.ContainSubstring("/BaseFont /Helvetica-Bold"))
+
+	// every xref entry must point at the start of its object
+	xrefStart := strings.LastIndex(output, "startxref\n")
+	xrefOffset, err := strconv.Atoi(strings.TrimSpace(strings.Split(output[xrefStart+len("startxref\n"):], "\n")[0]))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(output[xrefOffset:]).To(gomega.HavePrefix("xref\n"))
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(output[xrefOffset:], -1)
+	g.Expect(entries).To(gomega.HaveLen(8))
+
+	for idx, entry := range entries {
+		offset, err := strconv.Atoi(entry[1])
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(output[offset:]).To(gomega.HavePrefix(fmt.Sprintf("%d 0 obj", idx+1)))
+	}
+}
+
+func TestTextWidthAndTruncate(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	g.Expect(TextWidth(FontRegular, 10, "0000")).To(gomega.BeNumerically("~", 22.24, 0.001))
+	g.Expect(TextWidth(FontBold, 10, "i")).To(gomega.BeNumerically(">", TextWidth(FontRegular, 10, "i")))
+	g.Expect(TruncateToWidth(FontRegular, 10, "short", 100)).To(gomega.Equal("short"))
+
+	truncated := TruncateToWidth(FontRegular, 10, "a very long account name that will not fit", 60)
+	g.Expect(truncated).To(gomega.HaveSuffix("..."))
+	g.Expect(TextWidth(FontRegular, 10, truncated)).To(gomega.BeNumerically("<=", 60))
+}
+
+func TestReport_RenderPaginatesWithHeadersAndPageNumbers(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	table := &Table{
+		Title: "Assets",
+		Columns: []Column{
+			{Header: "Account"},
+			{Header: "Amount", Width: 80, AlignRight: true},
+		},
+	}
+	for idx := 0; idx < 120; idx++ {
+		table.Rows = append(table.Rows, Row{Cells: []string{fmt.Sprintf("Account %d", idx), "1.00"}, Indent: idx % 3})
+	}
+
+	table.Rows = append(table.Rows, Row{Cells: []string{"Total Assets", "120.00"}, Bold: true, RuleAbove: true})
+
+	report := Report{Title: "Mimir Holdings LLC", Subtitle: "Balance Sheet", Period: "As of December 31, 2024",
+		Tables: []*Table{table}}
+	doc := report.Render(PageSizeLetter)
+	g.Expect(len(doc.Pages())).To(gomega.Equal(3))
+
+	var buf bytes.Buffer
+	_, err := doc.WriteTo(&buf)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	output := buf.String()
+	g.Expect(strings.Count(output, "(Mimir Holdings LLC) Tj")).To(gomega.Equal(3))
+	g.Expect(strings.Count(output, "(As of December 31, 2024) Tj")).To(gomega.Equal(3))
+	g.Expect(output).To(gomega.ContainSubstring("(Page 1 of 3) Tj"))
+	g.Expect(output).To(gomega.ContainSubstring("(Page 3 of 3) Tj"))
+	g.Expect(strings.Count(output, "(Assets \\(continued\\)) Tj")).To(gomega.Equal(2))
+	g.Expect(output).To(gomega.ContainSubstring("(Account 119) Tj"))
+	g.Expect(output).To(gomega.ContainSubstring("(Total Assets) Tj"))
+}
@@ -0,0 +1,314 @@
+package plaintext
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// BeancountOptions are the file wide options of a beancount file
+type BeancountOptions struct {
+	Title string
+	// Currency is the commodity of every amount, ie USD
+	Currency string
+}
+
+var ErrBeancountCurrencyInvalid = errors.New("currency is not a valid beancount commodity")
+
+// beancountCurrency is the syntax of a beancount commodity
+var beancountCurrency = regexp.MustCompile(`^[A-Z][A-Z0-9'._-]{0,22}[A-Z0-9]$`)
+
+// beancountRoots maps AccountType onto the five root accounts of beancount
+var beancountRoots = map[string]string{ //nolint:gochecknoglobals
+	"ASSET":     "Assets",
+	"LIABILITY": "Liabilities",
+	"EQUITY":    "Equity",
+	"INCOME":    "Income",
+	"GAIN":      "Income",
+	"EXPENSE":   "Expenses",
+	"LOSS":      "Expenses",
+}
+
+// beancountDefaultOpenDate opens accounts that have neither an open date nor postings
+var beancountDefaultOpenDate = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC) //nolint:gochecknoglobals
+
+// beancountAccount is an account as it is written to the beancount file
+type beancountAccount struct {
+	name      string
+	memo      string
+	openDate  time.Time
+	closeDate time.Time
+}
+
+// WriteBeancount writes a journal that bean-check accepts.  Every account is placed under the beancount root of
+// the type of its top level account, ie Checking under Assets, and its name is sanitized to letters, digits and
+// dashes.  Accounts are opened on their open date and closed on their close date, widened to cover their postings,
+// since beancount rejects postings to accounts that are not open.  Reconciled transactions have the * flag and
+// the others the ! flag, references and reconcile dates are metadata.  In a transaction that is not reconciled the
+// reconciled postings have the * flag, with their reconcile date as posting metadata.
+func WriteBeancount(writer io.Writer, journal *Journal, options BeancountOptions) error {
+	if !beancountCurrency.MatchString(options.Currency) {
+		return fmt.Errorf("%w: %q", ErrBeancountCurrencyInvalid, options.Currency)
+	}
+
+	accounts, err := beancountAccounts(journal)
+	if err != nil {
+		return err
+	}
+
+	transactions := make([]*Transaction, len(journal.Transactions))
+	copy(transactions, journal.Transactions)
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].Date.Before(transactions[j].Date)
+	})
+
+	buffered := bufio.NewWriter(writer)
+
+	fmt.Fprintln(buffered, ";; beancount file exported from mimirledger")
+	fmt.Fprintf(buffered, "option \"title\" %s\n", beancountString(options.Title))
+	fmt.Fprintf(buffered, "option \"operating_currency\" %s\n", beancountString(options.Currency))
+
+	opened := make([]*beancountAccount, 0, len(accounts))
+	for _, account := range accounts {
+		opened = append(opened, account)
+	}
+
+	sort.Slice(opened, func(i, j int) bool {
+		if !opened[i].openDate.Equal(opened[j].openDate) {
+			return opened[i].openDate.Before(opened[j].openDate)
+		}
+
+		return opened[i].name < opened[j].name
+	})
+
+	fmt.Fprintln(buffered)
+
+	for _, account := range opened {
+		fmt.Fprintf(buffered, "%s open %s %s\n", account.openDate.Format(ledgerDateFormat), account.name,
+			options.Currency)
+
+		if account.memo != "" {
+			fmt.Fprintf(buffered, "  description: %s\n", beancountString(account.memo))
+		}
+	}
+
+	for _, txn := range transactions {
+		writeBeancountTransaction(buffered, txn, accounts, options.Currency)
+	}
+
+	closed := make([]*beancountAccount, 0)
+
+	for _, account := range opened {
+		if !account.closeDate.IsZero() {
+			closed = append(closed, account)
+		}
+	}
+
+	sort.SliceStable(closed, func(i, j int) bool {
+		return closed[i].closeDate.Before(closed[j].closeDate)
+	})
+
+	if len(closed) > 0 {
+		fmt.Fprintln(buffered)
+	}
+
+	for _, account := range closed {
+		fmt.Fprintf(buffered, "%s close %s\n", account.closeDate.Format(ledgerDateFormat), account.name)
+	}
+
+	if err = buffered.Flush(); err != nil {
+		return fmt.Errorf("buffered.Flush:%w", err)
+	}
+
+	return nil
+}
+
+func writeBeancountTransaction(writer io.Writer, txn *Transaction, accounts map[string]*beancountAccount,
+	currency string) {
+	flag := "!"
+	if txn.Cleared {
+		flag = "*"
+	}
+
+	fmt.Fprintf(writer, "\n%s %s %s\n", txn.Date.Format(ledgerDateFormat), flag, beancountString(txn.Comment()))
+
+	if txn.Code != "" {
+		fmt.Fprintf(writer, "  reference: %s\n", beancountString(txn.Code))
+	}
+
+	if txn.Cleared && !txn.ReconcileDate.IsZero() {
+		fmt.Fprintf(writer, "  reconciled: %s\n", txn.ReconcileDate.Format(ledgerDateFormat))
+	}
+
+	names := make([]string, len(txn.Postings))
+	accountWidth, amountWidth := 0, 0
+
+	for idx, posting := range txn.Postings {
+		names[idx] = accounts[posting.Account].name
+		if posting.Cleared && !txn.Cleared {
+			names[idx] = "* " + names[idx]
+		}
+
+		accountWidth = max(accountWidth, len(names[idx]))
+		amountWidth = max(amountWidth, len(posting.Amount))
+	}
+
+	for idx, posting := range txn.Postings {
+		fmt.Fprintf(writer, "  %-*s  %*s %s\n", accountWidth, names[idx], amountWidth, posting.Amount, currency)
+
+		if reconcileDate := txn.postingReconcileDate(posting); !reconcileDate.IsZero() {
+			fmt.Fprintf(writer, "    reconciled: %s\n", reconcileDate.Format(ledgerDateFormat))
+		}
+	}
+}
+
+// beancountAccounts names the accounts of a journal and its postings, by their journal name
+func beancountAccounts(journal *Journal) (map[string]*beancountAccount, error) {
+	types := make(map[string]string)
+	for _, account := range journal.Accounts {
+		if account.Type != "" {
+			types[account.Name] = account.Type
+		}
+	}
+
+	accounts := make(map[string]*beancountAccount)
+	taken := make(map[string]bool)
+
+	add := func(name string) (*beancountAccount, error) {
+		if account, ok := accounts[name]; ok {
+			return account, nil
+		}
+
+		top, _, _ := strings.Cut(name, AccountSeparator)
+
+		root, ok := beancountRoots[types[top]]
+		if !ok {
+			return nil, fmt.Errorf("%w: account %s has no type", ErrJournalInvalid, name)
+		}
+
+		beancountName := root
+		for _, component := range strings.Split(name, AccountSeparator) {
+			beancountName += AccountSeparator + beancountComponent(component)
+		}
+
+		// accounts whose names only differ in the characters sanitized away are numbered
+		unique := beancountName
+		for suffix := 2; taken[unique]; suffix++ {
+			unique = beancountName + "-" + strconv.Itoa(suffix)
+		}
+
+		taken[unique] = true
+		account := &beancountAccount{name: unique, memo: "", openDate: time.Time{}, closeDate: time.Time{}}
+		accounts[name] = account
+
+		return account, nil
+	}
+
+	for _, account := range journal.Accounts {
+		beancount, err := add(account.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		beancount.memo = account.Memo
+		beancount.openDate = account.OpenDate
+		beancount.closeDate = account.CloseDate
+	}
+
+	for _, txn := range journal.Transactions {
+		for _, posting := range txn.Postings {
+			beancount, err := add(posting.Account)
+			if err != nil {
+				return nil, err
+			}
+
+			if beancount.openDate.IsZero() || txn.Date.Before(beancount.openDate) {
+				beancount.openDate = txn.Date
+			}
+
+			if !beancount.closeDate.IsZero() && txn.Date.After(beancount.closeDate) {
+				beancount.closeDate = txn.Date
+			}
+		}
+	}
+
+	for _, account := range accounts {
+		if account.openDate.IsZero() {
+			account.openDate = beancountDefaultOpenDate
+		}
+
+		if !account.closeDate.IsZero() && account.closeDate.Before(account.openDate) {
+			account.closeDate = account.openDate
+		}
+	}
+
+	return accounts, nil
+}
+
+// beancountComponent is a part of an account name that beancount accepts, words of letters and digits joined by
+// dashes and starting with a capital, ie "credit card (visa)" is Credit-Card-Visa.  Accented letters lose their
+// accents where they can and other characters separate words.
+func beancountComponent(name string) string {
+	words := strings.FieldsFunc(beancountFold(name), func(char rune) bool {
+		return !(char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || char >= '0' && char <= '9')
+	})
+
+	for idx, word := range words {
+		words[idx] = strings.ToUpper(word[:1]) + word[1:]
+	}
+
+	if len(words) == 0 {
+		return "Account"
+	}
+
+	return strings.Join(words, "-")
+}
+
+// beancountFold replaces the accented latin letters with their base letter
+func beancountFold(name string) string {
+	var builder strings.Builder
+
+	for _, char := range name {
+		if char < unicode.MaxASCII {
+			builder.WriteRune(char)
+
+			continue
+		}
+
+		if folded, ok := beancountFolds[char]; ok {
+			builder.WriteString(folded)
+
+			continue
+		}
+
+		builder.WriteRune(' ')
+	}
+
+	return builder.String()
+}
+
+// beancountFolds are the latin letters with accents and their base letters
+var beancountFolds = map[rune]string{ //nolint:gochecknoglobals
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'æ': "ae", 'ç': "c",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i",
+	'ñ': "n", 'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ù': "u",
+	'ú': "u", 'û': "u", 'ü': "u", 'ý': "y", 'ÿ': "y", 'ß': "ss",
+	'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Ä': "A", 'Å': "A", 'Æ': "AE", 'Ç': "C",
+	'È': "E", 'É': "E", 'Ê': "E", 'Ë': "E", 'Ì': "I", 'Í': "I", 'Î': "I", 'Ï': "I",
+	'Ñ': "N", 'Ò': "O", 'Ó': "O", 'Ô': "O", 'Õ': "O", 'Ö': "O", 'Ø': "O", 'Ù': "U",
+	'Ú': "U", 'Û': "U", 'Ü': "U", 'Ý': "Y",
+}
+
+// beancountString is a quoted beancount string on a single line
+func beancountString(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text) + `"`
+}
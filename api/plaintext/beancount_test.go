@@ -0,0 +1,199 @@
+package plaintext
+
+import (
+	"bytes"
+	"flag"
+	"math/big"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files") //nolint:gochecknoglobals
+
+func beancountTestJournal() *Journal {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	return &Journal{
+		Accounts: []*Account{
+			{Name: "Banks", Type: "ASSET", Memo: `Bank "main" accounts`, OpenDate: date(1, 1)},
+			// opened after its first posting
+			{Name: "Banks:Checking Account", OpenDate: date(2, 1)},
+			// closed before its last posting, and only differs from Checking Account in punctuation
+			{Name: "Banks:Checking-Account", OpenDate: date(1, 1), CloseDate: date(1, 15)},
+			{Name: "401k", Type: "ASSET", OpenDate: date(1, 1), CloseDate: date(6, 30)},
+			{Name: "Visa (card)", Type: "LIABILITY", OpenDate: date(1, 1)},
+			{Name: "Salary", Type: "INCOME", OpenDate: date(1, 1)},
+			{Name: "Interest", Type: "GAIN", OpenDate: date(1, 1)},
+			{Name: "Épicerie", Type: "EXPENSE", OpenDate: date(1, 1)},
+			{Name: "Épicerie:café & thé", OpenDate: date(1, 1)},
+			{Name: "Opening", Type: "EQUITY"},
+		},
+		Transactions: []*Transaction{
+			{Date: date(1, 10), Cleared: true, ReconcileDate: date(1, 31), Code: "PAY1",
+				Description: `paycheck "january"`, Postings: []*Posting{
+					{Account: "Salary", Amount: "-2500.00"},
+					{Account: "Interest", Amount: "-1.25"},
+					{Account: "Banks:Checking Account", Amount: "2501.25"},
+				}},
+			// only the card side is reconciled
+			{Date: date(1, 20), Description: `coffee \ tea`, Postings: []*Posting{
+				{Account: "Visa (card)", Amount: "-4.50", Cleared: true, ReconcileDate: date(2, 5)},
+				{Account: "Épicerie:café & thé", Amount: "4.50"},
+			}},
+			{Date: date(1, 5), Cleared: true, Description: "old account", Postings: []*Posting{
+				{Account: "Banks:Checking-Account", Amount: "-100.00"},
+				{Account: "401k", Amount: "100.00"},
+			}},
+			{Date: date(2, 1), Cleared: true, Description: "close old account", Postings: []*Posting{
+				{Account: "Banks:Checking-Account", Amount: "100.00"},
+				{Account: "Opening", Amount: "-100.00"},
+			}},
+		},
+	}
+}
+
+func TestWriteBeancount_Golden(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	var written bytes.Buffer
+	g.Expect(WriteBeancount(&written, beancountTestJournal(),
+		BeancountOptions{Title: "Household", Currency: "USD"})).To(gomega.Succeed())
+
+	const golden = "testdata/export.beancount"
+
+	if *updateGolden {
+		g.Expect(os.WriteFile(golden, written.Bytes(), 0o600)).To(gomega.Succeed())
+	}
+
+	expected, err := os.ReadFile(golden)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(written.String()).To(gomega.Equal(string(expected)))
+
+	checkBeancountSyntax(g, written.String())
+}
+
+func TestWriteBeancount_Invalid(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	var written bytes.Buffer
+	err := WriteBeancount(&written, beancountTestJournal(), BeancountOptions{Title: "", Currency: "usd"})
+	g.Expect(err).To(gomega.MatchError(ErrBeancountCurrencyInvalid))
+
+	untyped := &Journal{Accounts: []*Account{{Name: "Cash"}}}
+	err = WriteBeancount(&written, untyped, BeancountOptions{Title: "", Currency: "USD"})
+	g.Expect(err).To(gomega.MatchError(ErrJournalInvalid))
+}
+
+func TestBeancountComponent(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	tests := map[string]string{
+		"Checking":           "Checking",
+		"credit card (visa)": "Credit-Card-Visa",
+		"401k plan":          "401k-Plan",
+		"Épargne logement":   "Epargne-Logement",
+		"Straße":             "Strasse",
+		"日本":                 "Account",
+		"  -- ":              "Account",
+		"AT&T_bill.2024":     "AT-T-Bill-2024",
+	}
+
+	for name, expected := range tests {
+		g.Expect(beancountComponent(name)).To(gomega.Equal(expected), name)
+	}
+}
+
+// beancountSyntax are the lines WriteBeancount writes, with the account, commodity, date and string syntax of
+// bean-check
+var beancountSyntax = func() map[string]*regexp.Regexp { //nolint:gochecknoglobals
+	const (
+		account   = `((?:Assets|Liabilities|Equity|Income|Expenses)(?::[A-Z0-9][A-Za-z0-9-]*)+)`
+		commodity = `([A-Z][A-Z0-9'._-]{0,22}[A-Z0-9])`
+		date      = `(\d{4}-\d{2}-\d{2})`
+		str       = `"(?:[^"\\]|\\.)*"`
+	)
+
+	return map[string]*regexp.Regexp{
+		"comment":     regexp.MustCompile(`^;.*$`),
+		"option":      regexp.MustCompile(`^option "[a-z_]+" ` + str + `$`),
+		"open":        regexp.MustCompile(`^` + date + ` open ` + account + ` ` + commodity + `$`),
+		"close":       regexp.MustCompile(`^` + date + ` close ` + account + `$`),
+		"transaction": regexp.MustCompile(`^` + date + ` [*!] ` + str + `$`),
+		"metadata":    regexp.MustCompile(`^ {2,4}[a-z][a-zA-Z0-9_-]*: (?:` + str + `|` + date + `)$`),
+		"posting":     regexp.MustCompile(`^  (?:[*!] )?` + account + ` +(-?\d+(?:\.\d+)?) ` + commodity + `$`),
+	}
+}()
+
+// checkBeancountSyntax checks every line is valid, transactions balance, and accounts are open when posted to
+func checkBeancountSyntax(g *gomega.WithT, file string) {
+	opened := make(map[string]string)
+	closed := make(map[string]string)
+
+	var (
+		txnDate string
+		sum     *big.Rat
+	)
+
+	endTransaction := func() {
+		if sum != nil {
+			g.Expect(sum.Sign()).To(gomega.BeZero(), "transaction on %s does not balance", txnDate)
+		}
+
+		sum = nil
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(file, "\n"), "\n") {
+		matched := ""
+
+		for kind, syntax := range beancountSyntax {
+			if syntax.MatchString(line) {
+				matched = kind
+			}
+		}
+
+		switch match := beancountSyntax[matched]; matched {
+		case "":
+			g.Expect(line).To(gomega.BeEmpty(), "invalid line")
+			endTransaction()
+		case "open":
+			parts := match.FindStringSubmatch(line)
+			g.Expect(opened).NotTo(gomega.HaveKey(parts[2]), "opened twice")
+			opened[parts[2]] = parts[1]
+		case "close":
+			parts := match.FindStringSubmatch(line)
+			g.Expect(opened).To(gomega.HaveKey(parts[2]), "closed before it is opened")
+			closed[parts[2]] = parts[1]
+		case "transaction":
+			endTransaction()
+
+			txnDate = match.FindStringSubmatch(line)[1]
+			sum = new(big.Rat)
+		case "posting":
+			parts := match.FindStringSubmatch(line)
+			g.Expect(sum).NotTo(gomega.BeNil(), "posting outside a transaction")
+			g.Expect(opened).To(gomega.HaveKey(parts[1]), "posting to an account that is not open")
+			g.Expect(opened[parts[1]] <= txnDate).To(gomega.BeTrue(), "posting before %s is open", parts[1])
+
+			amount, ok := new(big.Rat).SetString(parts[2])
+			g.Expect(ok).To(gomega.BeTrue())
+			sum.Add(sum, amount)
+		}
+	}
+
+	endTransaction()
+
+	for account, date := range closed {
+		g.Expect(date >= opened[account]).To(gomega.BeTrue(), "%s closed before it is opened", account)
+	}
+}
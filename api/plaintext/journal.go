@@ -0,0 +1,118 @@
+// Package plaintext reads and writes the ledger as a plain-text accounting journal, the file formats of ledger-cli,
+// hledger and beancount.  Amounts are decimal strings, ie "-1234.56", so they keep the precision of the account
+// they are posted to.
+package plaintext
+
+import (
+	"errors"
+	"time"
+)
+
+// Journal is the accounts and transactions of a plain-text journal
+type Journal struct {
+	// Accounts are the declared accounts, accounts that are only posted to are not listed
+	Accounts     []*Account
+	Transactions []*Transaction
+}
+
+// Account is a declared account, Name is the full name with subaccounts separated by AccountSeparator
+type Account struct {
+	Name string
+	// Type is an AccountType such as ASSET, empty when the journal does not declare it
+	Type string
+	Memo string
+	// OpenDate and CloseDate are when the account was opened and closed, zero when they are not known
+	OpenDate  time.Time
+	CloseDate time.Time
+}
+
+// AccountSeparator separates an account from its subaccounts, ie Assets:Checking
+const AccountSeparator = ":"
+
+// Transaction is a dated transaction, its postings add up to zero
+type Transaction struct {
+	// Line is the line number of the transaction in the journal, starting at 1
+	Line int
+	Date time.Time
+	// Cleared is whether every posting is cleared, ReconcileDate is the date the transaction was reconciled on
+	Cleared       bool
+	ReconcileDate time.Time
+	// Code is the check or reference number
+	Code        string
+	Description string
+	Note        string
+	Postings    []*Posting
+}
+
+// Posting is a line of a transaction, a positive Amount debits the account.  Each posting is cleared on its own,
+// ReconcileDate is zero when the date it was reconciled on is not known.
+type Posting struct {
+	Account       string
+	Amount        string
+	Cleared       bool
+	ReconcileDate time.Time
+}
+
+var ErrJournalInvalid = errors.New("journal file is invalid")
+
+// maxCommentLength and maxReferenceLength are the column sizes of transaction_main
+const (
+	maxCommentLength   = 250
+	maxReferenceLength = 32
+)
+
+// Comment is the transaction comment, the description or else the note
+func (c *Transaction) Comment() string {
+	comment := c.Description
+	if comment == "" {
+		comment = c.Note
+	}
+
+	if comment == "" {
+		comment = "Imported transaction"
+	}
+
+	return truncate(comment, maxCommentLength)
+}
+
+// postingReconcileDate is the reconcile date of a cleared posting that is written with the posting, zero when it is
+// the reconcile date of its cleared transaction
+func (c *Transaction) postingReconcileDate(posting *Posting) time.Time {
+	if !posting.Cleared || (c.Cleared && posting.ReconcileDate.Equal(c.ReconcileDate)) {
+		return time.Time{}
+	}
+
+	return posting.ReconcileDate
+}
+
+// clearPostings marks the postings of a cleared transaction cleared, a cleared posting without a date of its own
+// takes the reconcile date of the transaction, and the transaction is cleared when all of its postings are
+func (c *Transaction) clearPostings() {
+	cleared := len(c.Postings) > 0
+
+	for _, posting := range c.Postings {
+		posting.Cleared = posting.Cleared || c.Cleared
+
+		if posting.Cleared && posting.ReconcileDate.IsZero() {
+			posting.ReconcileDate = c.ReconcileDate
+		}
+
+		cleared = cleared && posting.Cleared
+	}
+
+	c.Cleared = cleared
+}
+
+// Reference is the code of the transaction, truncated to fit a transaction reference
+func (c *Transaction) Reference() string {
+	return truncate(c.Code, maxReferenceLength)
+}
+
+func truncate(str string, length int) string {
+	runes := []rune(str)
+	if len(runes) <= length {
+		return str
+	}
+
+	return string(runes[:length])
+}
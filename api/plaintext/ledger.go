@@ -0,0 +1,663 @@
+package plaintext
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ledgerDateLayouts are the dates of a transaction, months and days may have one or two digits
+var ledgerDateLayouts = []string{"2006-1-2", "2006/1/2", "2006.1.2"} //nolint:gochecknoglobals
+
+// ledgerDateFormat is the date the journal is written with
+const ledgerDateFormat = "2006-01-02"
+
+// ledgerReconciledTag is the tag of the date a cleared transaction was reconciled on
+const ledgerReconciledTag = "reconciled"
+
+// ledgerAccountTypeTag is the tag of the AccountType of an account, hledger's type tag cannot tell income and gains
+// apart
+const ledgerAccountTypeTag = "accounttype"
+
+// hledgerAccountTypes maps the values of the hledger type tag onto AccountType
+var hledgerAccountTypes = map[string]string{ //nolint:gochecknoglobals
+	"A":          "ASSET",
+	"ASSET":      "ASSET",
+	"C":          "ASSET",
+	"CASH":       "ASSET",
+	"L":          "LIABILITY",
+	"LIABILITY":  "LIABILITY",
+	"E":          "EQUITY",
+	"EQUITY":     "EQUITY",
+	"V":          "EQUITY",
+	"CONVERSION": "EQUITY",
+	"R":          "INCOME",
+	"REVENUE":    "INCOME",
+	"X":          "EXPENSE",
+	"EXPENSE":    "EXPENSE",
+}
+
+// accountTypesToHledger maps AccountType onto the values of the hledger type tag
+var accountTypesToHledger = map[string]string{ //nolint:gochecknoglobals
+	"ASSET":     "Asset",
+	"LIABILITY": "Liability",
+	"EQUITY":    "Equity",
+	"INCOME":    "Revenue",
+	"EXPENSE":   "Expense",
+	"GAIN":      "Revenue",
+	"LOSS":      "Expense",
+}
+
+// ledgerBlock is the kind of top level entry the indented lines that follow belong to
+type ledgerBlock int
+
+const (
+	ledgerBlockNone ledgerBlock = iota
+	ledgerBlockTransaction
+	ledgerBlockAccount
+	ledgerBlockSkipped
+	ledgerBlockComment
+)
+
+// ledgerParser is the state of ParseLedger
+type ledgerParser struct {
+	journal     Journal
+	block       ledgerBlock
+	transaction *Transaction
+	account     *Account
+	accounts    map[string]*Account
+	// commodity is the commodity of the transaction, nil until an amount is read
+	commodity *string
+}
+
+// ParseLedger parses a ledger-cli or hledger journal.  Account directives declare accounts, their type is read from
+// the accounttype tag or else the hledger type tag.  A posting is cleared when its transaction is or when it is
+// marked with * itself, and is reconciled on its own reconciled tag date or else that of its transaction.  A
+// posting without an amount is inferred to balance the transaction.  Virtual postings in parentheses do not have to
+// balance and are ignored, virtual postings in brackets are read as real postings.  Periodic and automated transactions and other directives are skipped.  Only
+// a single commodity per transaction is supported, prices and include directives are not.
+func ParseLedger(reader io.Reader) (*Journal, error) {
+	parser := ledgerParser{
+		journal:     Journal{Accounts: nil, Transactions: nil},
+		block:       ledgerBlockNone,
+		transaction: nil,
+		account:     nil,
+		accounts:    make(map[string]*Account),
+		commodity:   nil,
+	}
+
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+
+		if err := parser.parseLine(strings.TrimRight(scanner.Text(), "\r"), lineNumber); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanner.Err:%w", err)
+	}
+
+	if err := parser.endBlock(); err != nil {
+		return nil, err
+	}
+
+	if len(parser.journal.Transactions) == 0 && len(parser.journal.Accounts) == 0 {
+		return nil, fmt.Errorf("%w: no transactions", ErrJournalInvalid)
+	}
+
+	return &parser.journal, nil
+}
+
+func (c *ledgerParser) parseLine(line string, lineNumber int) error {
+	if c.block == ledgerBlockComment {
+		if strings.HasPrefix(line, "end comment") || strings.HasPrefix(line, "end test") {
+			c.block = ledgerBlockNone
+		}
+
+		return nil
+	}
+
+	if strings.TrimSpace(line) == "" {
+		return c.endBlock()
+	}
+
+	if line[0] == ' ' || line[0] == '\t' {
+		return c.parseIndented(strings.TrimSpace(line), lineNumber)
+	}
+
+	if err := c.endBlock(); err != nil {
+		return err
+	}
+
+	keyword, rest, _ := strings.Cut(strings.ReplaceAll(line, "\t", " "), " ")
+
+	switch {
+	case strings.ContainsRune(";#%|*", rune(line[0])):
+		// a comment line
+	case keyword == "comment", keyword == "test":
+		c.block = ledgerBlockComment
+	case line[0] >= '0' && line[0] <= '9':
+		return c.parseTransactionHeader(line, lineNumber)
+	case keyword == "account":
+		c.parseAccountDirective(rest)
+	case keyword == "include", keyword == "!include":
+		return fmt.Errorf("%w: line %d: include is not supported", ErrJournalInvalid, lineNumber)
+	default:
+		c.block = ledgerBlockSkipped
+	}
+
+	return nil
+}
+
+func (c *ledgerParser) parseIndented(line string, lineNumber int) error {
+	switch c.block {
+	case ledgerBlockTransaction:
+		if strings.HasPrefix(line, ";") {
+			return c.parseTransactionNote(strings.TrimSpace(line[1:]), lineNumber)
+		}
+
+		return c.parsePosting(line, lineNumber)
+	case ledgerBlockAccount:
+		c.parseAccountSubdirective(line)
+	case ledgerBlockNone:
+		return fmt.Errorf("%w: line %d: unexpected indented line", ErrJournalInvalid, lineNumber)
+	case ledgerBlockSkipped, ledgerBlockComment:
+	}
+
+	return nil
+}
+
+// endBlock ends the current entry, a transaction is balanced
+func (c *ledgerParser) endBlock() error {
+	if c.block == ledgerBlockTransaction {
+		if err := balanceTransaction(c.transaction); err != nil {
+			return err
+		}
+
+		c.transaction.clearPostings()
+
+		c.journal.Transactions = append(c.journal.Transactions, c.transaction)
+	}
+
+	if c.block != ledgerBlockComment {
+		c.block = ledgerBlockNone
+	}
+
+	c.transaction = nil
+	c.account = nil
+	c.commodity = nil
+
+	return nil
+}
+
+// parseTransactionHeader parses DATE[=DATE2] [*|!] [(CODE)] DESCRIPTION [; NOTE]
+func (c *ledgerParser) parseTransactionHeader(line string, lineNumber int) error {
+	dateStr, rest, _ := strings.Cut(strings.ReplaceAll(line, "\t", " "), " ")
+	dateStr, _, _ = strings.Cut(dateStr, "=")
+
+	date, err := parseLedgerDate(dateStr)
+	if err != nil {
+		return fmt.Errorf("%w: line %d: date %q", ErrJournalInvalid, lineNumber, dateStr)
+	}
+
+	txn := Transaction{
+		Line:          lineNumber,
+		Date:          date,
+		Cleared:       false,
+		ReconcileDate: time.Time{},
+		Code:          "",
+		Description:   "",
+		Note:          "",
+		Postings:      nil,
+	}
+
+	rest = strings.TrimSpace(rest)
+
+	switch {
+	case strings.HasPrefix(rest, "*"):
+		txn.Cleared = true
+		rest = strings.TrimSpace(rest[1:])
+	case strings.HasPrefix(rest, "!"):
+		rest = strings.TrimSpace(rest[1:])
+	}
+
+	if strings.HasPrefix(rest, "(") {
+		if end := strings.IndexByte(rest, ')'); end > 0 {
+			txn.Code = strings.TrimSpace(rest[1:end])
+			rest = strings.TrimSpace(rest[end+1:])
+		}
+	}
+
+	description, note, hasNote := strings.Cut(rest, ";")
+	txn.Description = strings.TrimSpace(description)
+
+	c.block = ledgerBlockTransaction
+	c.transaction = &txn
+
+	if hasNote {
+		return c.parseTransactionNote(strings.TrimSpace(note), lineNumber)
+	}
+
+	return nil
+}
+
+// parseTransactionNote adds a comment to the note of the transaction and reads its reconciled tag
+func (c *ledgerParser) parseTransactionNote(note string, lineNumber int) error {
+	tags := parseLedgerTags(note)
+
+	if dateStr, ok := tags[ledgerReconciledTag]; ok {
+		date, err := parseLedgerDate(dateStr)
+		if err != nil {
+			return fmt.Errorf("%w: line %d: reconciled date %q", ErrJournalInvalid, lineNumber, dateStr)
+		}
+
+		c.transaction.ReconcileDate = date
+
+		return nil
+	}
+
+	if c.transaction.Note != "" {
+		c.transaction.Note += " "
+	}
+
+	c.transaction.Note += note
+
+	return nil
+}
+
+// parsePosting parses [*|!] ACCOUNT[  AMOUNT] [= ASSERTION] [; COMMENT], the comment may have a reconciled tag
+func (c *ledgerParser) parsePosting(line string, lineNumber int) error {
+	line, comment, _ := strings.Cut(line, ";")
+	cleared := strings.HasPrefix(line, "*")
+	line = strings.TrimSpace(strings.TrimLeft(line, "*! "))
+
+	account, amountStr := splitLedgerPosting(line)
+
+	switch {
+	case strings.HasPrefix(account, "(") && strings.HasSuffix(account, ")"):
+		return nil
+	case strings.HasPrefix(account, "[") && strings.HasSuffix(account, "]"):
+		account = strings.TrimSpace(account[1 : len(account)-1])
+	}
+
+	if account == "" {
+		return fmt.Errorf("%w: line %d: posting has no account", ErrJournalInvalid, lineNumber)
+	}
+
+	// a balance assertion only checks the running balance
+	amountStr, _, _ = strings.Cut(amountStr, "=")
+
+	if strings.Contains(amountStr, "@") {
+		return fmt.Errorf("%w: line %d: prices are not supported", ErrJournalInvalid, lineNumber)
+	}
+
+	posting := Posting{Account: account, Amount: "", Cleared: cleared, ReconcileDate: time.Time{}}
+
+	if dateStr, ok := parseLedgerTags(comment)[ledgerReconciledTag]; ok {
+		date, err := parseLedgerDate(dateStr)
+		if err != nil {
+			return fmt.Errorf("%w: line %d: reconciled date %q", ErrJournalInvalid, lineNumber, dateStr)
+		}
+
+		posting.ReconcileDate = date
+	}
+
+	if amountStr = strings.TrimSpace(amountStr); amountStr != "" {
+		amount, commodity, err := parseLedgerAmount(amountStr)
+		if err != nil {
+			return fmt.Errorf("%w: line %d: amount %q", ErrJournalInvalid, lineNumber, amountStr)
+		}
+
+		if err = c.checkCommodity(commodity, lineNumber); err != nil {
+			return err
+		}
+
+		posting.Amount = amount
+	}
+
+	c.transaction.Postings = append(c.transaction.Postings, &posting)
+
+	return nil
+}
+
+// checkCommodity checks a transaction only uses one commodity
+func (c *ledgerParser) checkCommodity(commodity string, lineNumber int) error {
+	if c.commodity == nil {
+		c.commodity = &commodity
+
+		return nil
+	}
+
+	if *c.commodity != commodity {
+		return fmt.Errorf("%w: line %d: more than one commodity in a transaction", ErrJournalInvalid, lineNumber)
+	}
+
+	return nil
+}
+
+// parseAccountDirective declares an account, a later declaration of the same account adds to it
+func (c *ledgerParser) parseAccountDirective(rest string) {
+	name, comment, _ := strings.Cut(rest, ";")
+	name = strings.TrimSpace(name)
+
+	account, ok := c.accounts[name]
+	if !ok {
+		account = &Account{Name: name, Type: "", Memo: "", OpenDate: time.Time{}, CloseDate: time.Time{}}
+		c.accounts[name] = account
+		c.journal.Accounts = append(c.journal.Accounts, account)
+	}
+
+	c.block = ledgerBlockAccount
+	c.account = account
+
+	if comment = strings.TrimSpace(comment); comment != "" {
+		c.parseAccountComment(comment)
+	}
+}
+
+// parseAccountSubdirective reads the comments and note of an account directive
+func (c *ledgerParser) parseAccountSubdirective(line string) {
+	switch {
+	case strings.HasPrefix(line, ";"):
+		c.parseAccountComment(strings.TrimSpace(line[1:]))
+	case strings.HasPrefix(line, "note "):
+		c.account.Memo = strings.TrimSpace(strings.TrimPrefix(line, "note "))
+	}
+}
+
+// parseAccountComment reads the type of an account from its tags, a comment without tags is the memo
+func (c *ledgerParser) parseAccountComment(comment string) {
+	tags := parseLedgerTags(comment)
+
+	if len(tags) == 0 {
+		c.account.Memo = comment
+
+		return
+	}
+
+	if hledgerType, ok := tags["type"]; ok {
+		c.account.Type = hledgerAccountTypes[strings.ToUpper(hledgerType)]
+	}
+
+	if accountType, ok := tags[ledgerAccountTypeTag]; ok {
+		c.account.Type = strings.ToUpper(accountType)
+	}
+}
+
+// parseLedgerTags reads the tags of a comment, ie "type: Asset, accounttype: ASSET", tag names are lower cased
+func parseLedgerTags(comment string) map[string]string {
+	tags := make(map[string]string)
+
+	for _, part := range strings.Split(comment, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			continue
+		}
+
+		tags[strings.ToLower(name)] = strings.TrimSpace(value)
+	}
+
+	return tags
+}
+
+func parseLedgerDate(str string) (time.Time, error) {
+	var err error
+
+	for _, layout := range ledgerDateLayouts {
+		var date time.Time
+
+		if date, err = time.Parse(layout, str); err == nil {
+			return date, nil
+		}
+	}
+
+	return time.Time{}, err //nolint:wrapcheck
+}
+
+// splitLedgerPosting splits a posting at the first run of two spaces or a tab, account names may contain single
+// spaces
+func splitLedgerPosting(line string) (string, string) {
+	for idx := 0; idx < len(line); idx++ {
+		if line[idx] == '\t' || (line[idx] == ' ' && idx+1 < len(line) && line[idx+1] == ' ') {
+			return strings.TrimSpace(line[:idx]), strings.TrimSpace(line[idx:])
+		}
+	}
+
+	return strings.TrimSpace(line), ""
+}
+
+// parseLedgerAmount reads an amount with an optional commodity, ie "$-1,234.56", "-1.234,56 EUR" or "10 AAPL", into
+// a decimal string such as "-1234.56".  When an amount has a single comma or dot the other is the decimal mark,
+// otherwise the last of them is.  A lone comma followed by three digits is a thousands separator.
+func parseLedgerAmount(str string) (string, string, error) {
+	var number, commodity strings.Builder
+
+	quoted := false
+
+	for _, char := range str {
+		switch {
+		case char == '"':
+			quoted = !quoted
+		case !quoted && (char >= '0' && char <= '9' || strings.ContainsRune(".,-+", char)):
+			number.WriteRune(char)
+		case quoted || char != ' ':
+			commodity.WriteRune(char)
+		}
+	}
+
+	digits := number.String()
+	if strings.Trim(digits, "0123456789") == digits {
+		return "", "", fmt.Errorf("%w: amount %q", ErrJournalInvalid, str)
+	}
+
+	negative := strings.Contains(digits, "-")
+	digits = strings.NewReplacer("-", "", "+", "").Replace(digits)
+
+	decimalMark := ledgerDecimalMark(digits)
+
+	whole, fraction := digits, ""
+	if decimalMark != "" {
+		idx := strings.LastIndex(digits, decimalMark)
+		whole, fraction = digits[:idx], digits[idx+1:]
+	}
+
+	whole = strings.NewReplacer(",", "", ".", "").Replace(whole)
+	if whole == "" {
+		whole = "0"
+	}
+
+	if strings.Trim(whole+fraction, "0123456789") != "" {
+		return "", "", fmt.Errorf("%w: amount %q", ErrJournalInvalid, str)
+	}
+
+	amount := whole
+	if fraction != "" {
+		amount += "." + fraction
+	}
+
+	if negative {
+		amount = "-" + amount
+	}
+
+	return amount, commodity.String(), nil
+}
+
+// ledgerDecimalMark is the decimal mark of the digits of an amount, empty when it has no fraction
+func ledgerDecimalMark(digits string) string {
+	lastDot := strings.LastIndex(digits, ".")
+	lastComma := strings.LastIndex(digits, ",")
+
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		if lastDot > lastComma {
+			return "."
+		}
+
+		return ","
+	case lastDot >= 0:
+		if strings.Count(digits, ".") > 1 {
+			return ""
+		}
+
+		return "."
+	case lastComma >= 0:
+		if strings.Count(digits, ",") > 1 || len(digits)-lastComma-1 == 3 { //nolint:mnd
+			return ""
+		}
+
+		return ","
+	}
+
+	return ""
+}
+
+// balanceTransaction infers the amount of a posting without one and checks the postings add up to zero
+func balanceTransaction(txn *Transaction) error {
+	sum := new(big.Rat)
+
+	var (
+		inferred *Posting
+		decimals int
+	)
+
+	for _, posting := range txn.Postings {
+		if posting.Amount == "" {
+			if inferred != nil {
+				return fmt.Errorf("%w: line %d: more than one posting without an amount", ErrJournalInvalid,
+					txn.Line)
+			}
+
+			inferred = posting
+
+			continue
+		}
+
+		amount, ok := new(big.Rat).SetString(posting.Amount)
+		if !ok {
+			return fmt.Errorf("%w: line %d: amount %q", ErrJournalInvalid, txn.Line, posting.Amount)
+		}
+
+		sum.Add(sum, amount)
+
+		if _, fraction, ok := strings.Cut(posting.Amount, "."); ok && len(fraction) > decimals {
+			decimals = len(fraction)
+		}
+	}
+
+	if inferred != nil {
+		inferred.Amount = new(big.Rat).Neg(sum).FloatString(decimals)
+
+		return nil
+	}
+
+	if sum.Sign() != 0 {
+		return fmt.Errorf("%w: line %d: transaction does not balance by %s", ErrJournalInvalid, txn.Line,
+			sum.FloatString(decimals))
+	}
+
+	return nil
+}
+
+// WriteLedger writes a journal that both ledger-cli and hledger read.  Every account is declared with an account
+// directive, top level accounts with their type, and a cleared transaction is marked with * and tagged with its
+// reconcile date.  In a transaction that is not cleared the cleared postings are marked with * instead, and a
+// cleared posting reconciled on a date of its own is tagged with it.  Semicolons in descriptions would start a
+// comment, so they are replaced with commas.
+func WriteLedger(writer io.Writer, journal *Journal) error {
+	buffered := bufio.NewWriter(writer)
+
+	fmt.Fprintln(buffered, "; journal exported from mimirledger")
+
+	for _, account := range journal.Accounts {
+		fmt.Fprintf(buffered, "\naccount %s\n", ledgerAccountName(account.Name))
+
+		if account.Type != "" {
+			fmt.Fprintf(buffered, "    ; type: %s, %s: %s\n", accountTypesToHledger[account.Type],
+				ledgerAccountTypeTag, account.Type)
+		}
+
+		if memo := ledgerText(account.Memo); memo != "" {
+			fmt.Fprintf(buffered, "    ; %s\n", memo)
+		}
+	}
+
+	transactions := make([]*Transaction, len(journal.Transactions))
+	copy(transactions, journal.Transactions)
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].Date.Before(transactions[j].Date)
+	})
+
+	for _, txn := range transactions {
+		writeLedgerTransaction(buffered, txn)
+	}
+
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("buffered.Flush:%w", err)
+	}
+
+	return nil
+}
+
+func writeLedgerTransaction(writer io.Writer, txn *Transaction) {
+	header := txn.Date.Format(ledgerDateFormat)
+
+	if txn.Cleared {
+		header += " *"
+	}
+
+	if code := ledgerText(txn.Code); code != "" {
+		header += " (" + strings.NewReplacer("(", "", ")", "").Replace(code) + ")"
+	}
+
+	fmt.Fprintf(writer, "\n%s %s\n", header, ledgerText(txn.Description))
+
+	if txn.Cleared && !txn.ReconcileDate.IsZero() {
+		fmt.Fprintf(writer, "    ; %s: %s\n", ledgerReconciledTag, txn.ReconcileDate.Format(ledgerDateFormat))
+	}
+
+	if note := ledgerText(txn.Note); note != "" {
+		fmt.Fprintf(writer, "    ; %s\n", note)
+	}
+
+	// accounts are left aligned and amounts right aligned
+	accounts := make([]string, len(txn.Postings))
+	accountWidth, amountWidth := 0, 0
+
+	for idx, posting := range txn.Postings {
+		accounts[idx] = ledgerAccountName(posting.Account)
+		if posting.Cleared && !txn.Cleared {
+			accounts[idx] = "* " + accounts[idx]
+		}
+
+		accountWidth = max(accountWidth, len(accounts[idx]))
+		amountWidth = max(amountWidth, len(posting.Amount))
+	}
+
+	for idx, posting := range txn.Postings {
+		fmt.Fprintf(writer, "    %-*s  %*s", accountWidth, accounts[idx], amountWidth, posting.Amount)
+
+		if reconcileDate := txn.postingReconcileDate(posting); !reconcileDate.IsZero() {
+			fmt.Fprintf(writer, "  ; %s: %s", ledgerReconciledTag, reconcileDate.Format(ledgerDateFormat))
+		}
+
+		fmt.Fprintln(writer)
+	}
+}
+
+// ledgerText is text on a single line without semicolons
+func ledgerText(text string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(text, ";", ",")), " ")
+}
+
+// ledgerAccountName is an account name without the runs of spaces that end an account name in a posting
+func ledgerAccountName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
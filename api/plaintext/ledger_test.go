@@ -0,0 +1,186 @@
+package plaintext
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func parseTestJournal(g *gomega.WithT) *Journal {
+	file, err := os.Open("testdata/household.journal")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	defer file.Close()
+
+	journal, err := ParseLedger(file)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	return journal
+}
+
+func TestParseLedger(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	journal := parseTestJournal(g)
+
+	g.Expect(journal.Accounts).To(gomega.HaveLen(5))
+	g.Expect(*journal.Accounts[0]).To(gomega.Equal(Account{Name: "Assets", Type: "ASSET", Memo: ""}))
+	g.Expect(*journal.Accounts[1]).To(gomega.Equal(Account{Name: "Assets:Bank:Checking", Type: "",
+		Memo: "Everyday checking"}))
+	g.Expect(journal.Accounts[3].Type).To(gomega.Equal("INCOME"))
+
+	// the periodic transaction and the comment block are skipped
+	g.Expect(journal.Transactions).To(gomega.HaveLen(4))
+
+	opening := journal.Transactions[0]
+	g.Expect(opening.Cleared).To(gomega.BeTrue())
+	g.Expect(opening.Description).To(gomega.Equal("Opening balance"))
+	g.Expect(opening.Postings).To(gomega.Equal([]*Posting{
+		{Account: "Assets:Bank:Checking", Amount: "2500.00", Cleared: true},
+		{Account: "Equity:Opening Balances", Amount: "-2500.00", Cleared: true},
+	}))
+
+	rent := journal.Transactions[1]
+	g.Expect(rent.Date).To(gomega.Equal(time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)))
+	g.Expect(rent.Code).To(gomega.Equal("1001"))
+	g.Expect(rent.Description).To(gomega.Equal("Landlord"))
+	g.Expect(rent.Note).To(gomega.Equal("january rent"))
+	g.Expect(rent.ReconcileDate).To(gomega.Equal(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)))
+	g.Expect(rent.Postings[1]).To(gomega.Equal(&Posting{Account: "Assets:Bank:Checking", Amount: "-1200.00",
+		Cleared: true, ReconcileDate: rent.ReconcileDate}))
+
+	// pending is not cleared, the unbalanced virtual posting is ignored
+	shop := journal.Transactions[2]
+	g.Expect(shop.Cleared).To(gomega.BeFalse())
+	g.Expect(shop.Description).To(gomega.Equal("Supermarket | weekly shop"))
+	g.Expect(shop.Postings).To(gomega.HaveLen(3))
+	g.Expect(shop.Postings[2]).To(gomega.Equal(&Posting{Account: "Liabilities:Visa", Amount: "-50.00"}))
+
+	salary := journal.Transactions[3]
+	g.Expect(salary.Date).To(gomega.Equal(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)))
+	g.Expect(salary.Postings).To(gomega.Equal([]*Posting{
+		{Account: "Assets:Bank:Checking", Amount: "3000.50"},
+		{Account: "Income:Salary", Amount: "-3000.50"},
+	}))
+}
+
+func TestParseLedger_ClearedPostings(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	journal, err := ParseLedger(strings.NewReader("2024-01-10 Supermarket\n" +
+		"    * Liabilities:Visa  -50.00  ; reconciled: 2024-02-05\n" +
+		"    Expenses:Food\n" +
+		"2024-01-12 Pharmacy\n" +
+		"    * Liabilities:Visa  -5.00\n" +
+		"    * Assets:Cash  5.00  ; reconciled: 2024-01-20\n"))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// a transaction is only cleared when all of its postings are
+	shop := journal.Transactions[0]
+	g.Expect(shop.Cleared).To(gomega.BeFalse())
+	g.Expect(shop.Postings).To(gomega.Equal([]*Posting{
+		{Account: "Liabilities:Visa", Amount: "-50.00", Cleared: true,
+			ReconcileDate: time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC)},
+		{Account: "Expenses:Food", Amount: "50.00"},
+	}))
+	g.Expect(journal.Transactions[1].Cleared).To(gomega.BeTrue())
+
+	var written bytes.Buffer
+	g.Expect(WriteLedger(&written, journal)).To(gomega.Succeed())
+	g.Expect(written.String()).To(gomega.ContainSubstring("2024-01-10 Supermarket\n" +
+		"    * Liabilities:Visa  -50.00  ; reconciled: 2024-02-05\n" +
+		"    Expenses:Food        50.00\n"))
+
+	reread, err := ParseLedger(&written)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	for idx, txn := range reread.Transactions {
+		txn.Line = journal.Transactions[idx].Line
+	}
+
+	g.Expect(reread.Transactions).To(gomega.Equal(journal.Transactions))
+}
+
+func TestParseLedger_Invalid(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	tests := map[string]string{
+		"unbalanced":        "2024-01-01 Test\n    Assets:Cash  10\n    Expenses:Food  -9\n",
+		"two inferred":      "2024-01-01 Test\n    Assets:Cash  10\n    Expenses:Food\n    Expenses:Rent\n",
+		"bad date":          "2024-13-01 Test\n    Assets:Cash  10\n    Expenses:Food\n",
+		"two commodities":   "2024-01-01 Test\n    Assets:Cash  $10\n    Expenses:Food  -10 EUR\n",
+		"price":             "2024-01-01 Test\n    Assets:Cash  10 AAPL @ $5\n    Expenses:Food\n",
+		"include":           "include other.journal\n",
+		"no transactions":   "; nothing here\n",
+		"stray indentation": "    Assets:Cash  10\n",
+	}
+
+	for name, journal := range tests {
+		_, err := ParseLedger(strings.NewReader(journal))
+		g.Expect(err).To(gomega.MatchError(ErrJournalInvalid), name)
+	}
+}
+
+func TestParseLedgerAmount(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	tests := map[string][2]string{
+		"$1,234.56":     {"1234.56", "$"},
+		"-$1,234.56":    {"-1234.56", "$"},
+		"1.234,56 EUR":  {"1234.56", "EUR"},
+		"1,234":         {"1234", ""},
+		"12,5":          {"12.5", ""},
+		"10 \"AAPL 2\"": {"10", "AAPL 2"},
+		"1.000.000":     {"1000000", ""},
+		"+0.10":         {"0.10", ""},
+		"\"ABC\" -3":    {"-3", "ABC"},
+	}
+
+	for str, expected := range tests {
+		amount, commodity, err := parseLedgerAmount(str)
+		g.Expect(err).NotTo(gomega.HaveOccurred(), str)
+		g.Expect(amount).To(gomega.Equal(expected[0]), str)
+		g.Expect(commodity).To(gomega.Equal(expected[1]), str)
+	}
+
+	_, _, err := parseLedgerAmount("USD")
+	g.Expect(err).To(gomega.MatchError(ErrJournalInvalid))
+}
+
+func TestWriteLedger_RoundTrip(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	journal := parseTestJournal(g)
+	journal.Accounts[1].Memo = "semicolons; are replaced"
+
+	var written bytes.Buffer
+	g.Expect(WriteLedger(&written, journal)).To(gomega.Succeed())
+	g.Expect(written.String()).To(gomega.ContainSubstring("2024-01-05 * (1001) Landlord\n" +
+		"    ; reconciled: 2024-01-31\n" +
+		"    ; january rent\n" +
+		"    Expenses:Housing:Rent   1200.00\n" +
+		"    Assets:Bank:Checking   -1200.00\n"))
+	g.Expect(written.String()).To(gomega.ContainSubstring("account Income\n" +
+		"    ; type: Revenue, accounttype: INCOME\n"))
+
+	reread, err := ParseLedger(&written)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// only the line numbers differ
+	for idx, txn := range reread.Transactions {
+		txn.Line = journal.Transactions[idx].Line
+	}
+
+	journal.Accounts[1].Memo = "semicolons, are replaced"
+	g.Expect(reread).To(gomega.Equal(journal))
+}
@@ -0,0 +1,543 @@
+// Package recurrence expands the recurrence rules of scheduled transactions into the days they fall on.
+// A rule is the RRULE of RFC 5545 without the time of day parts, ie "FREQ=MONTHLY;BYMONTHDAY=1" is monthly on the 1st,
+// "FREQ=WEEKLY;INTERVAL=2" is every two weeks on the weekday of the start date and
+// "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1" is the last business day of the month.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is how often a rule repeats, each repeat is a period the rule picks its days from
+type Frequency string
+
+const (
+	Daily   = Frequency("DAILY")
+	Weekly  = Frequency("WEEKLY")
+	Monthly = Frequency("MONTHLY")
+	Yearly  = Frequency("YEARLY")
+)
+
+const (
+	untilLayout   = "20060102"
+	untilLayoutTZ = "20060102T150405Z"
+	daysInWeek    = 7
+	maxMonthDay   = 31
+	maxOrdinal    = 5
+	maxSetPos     = 366
+	monthsInYear  = 12
+)
+
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+// WeekdayNum is a BYDAY value, the Ordinal picks the nth Weekday of the month counting from the end when it is
+// negative, and every Weekday when it is 0
+type WeekdayNum struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+// Rule is a parsed recurrence rule, Until is the zero time when the rule has no end date
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	ByMonth    []time.Month
+	ByMonthDay []int
+	ByDay      []WeekdayNum
+	BySetPos   []int
+	Count      int
+	Until      time.Time
+}
+
+var weekdayCodes = map[string]time.Weekday{ //nolint:gochecknoglobals
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Parse parses a rule such as "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=-1", the "RRULE:" prefix is optional
+func Parse(rule string) (*Rule, error) { //nolint:cyclop,funlen
+	myRule := Rule{
+		Freq:       "",
+		Interval:   1,
+		ByMonth:    nil,
+		ByMonthDay: nil,
+		ByDay:      nil,
+		BySetPos:   nil,
+		Count:      0,
+		Until:      time.Time{},
+	}
+
+	rule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:")
+	seen := map[string]bool{}
+
+	for _, part := range strings.Split(rule, ";") {
+		if part == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(part, "=")
+		if !found || value == "" {
+			return nil, fmt.Errorf("%w: %q is not NAME=VALUE", ErrInvalidRule, part)
+		}
+
+		if seen[name] {
+			return nil, fmt.Errorf("%w: %s is repeated", ErrInvalidRule, name)
+		}
+
+		seen[name] = true
+
+		var err error
+
+		switch name {
+		case "FREQ":
+			myRule.Freq = Frequency(value)
+		case "INTERVAL":
+			myRule.Interval, err = parseInt(name, value, 1, 0)
+		case "COUNT":
+			myRule.Count, err = parseInt(name, value, 1, 0)
+		case "UNTIL":
+			myRule.Until, err = parseUntil(value)
+		case "BYMONTH":
+			myRule.ByMonth, err = parseByMonth(value)
+		case "BYMONTHDAY":
+			myRule.ByMonthDay, err = parseIntList(name, value, maxMonthDay)
+		case "BYDAY":
+			myRule.ByDay, err = parseByDay(value)
+		case "BYSETPOS":
+			myRule.BySetPos, err = parseIntList(name, value, maxSetPos)
+		default:
+			return nil, fmt.Errorf("%w: %s is not supported", ErrInvalidRule, name)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := myRule.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &myRule, nil
+}
+
+// Validate checks the parts of the rule make sense together
+func (r *Rule) Validate() error { //nolint:cyclop
+	switch r.Freq {
+	case Daily, Weekly, Monthly, Yearly:
+	case "":
+		return fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	default:
+		return fmt.Errorf("%w: FREQ %s is not supported", ErrInvalidRule, r.Freq)
+	}
+
+	if r.Interval < 1 {
+		return fmt.Errorf("%w: INTERVAL must be at least 1", ErrInvalidRule)
+	}
+
+	if r.Count > 0 && !r.Until.IsZero() {
+		return fmt.Errorf("%w: COUNT and UNTIL cannot both be set", ErrInvalidRule)
+	}
+
+	if r.Freq == Weekly && len(r.ByMonthDay) > 0 {
+		return fmt.Errorf("%w: BYMONTHDAY cannot be used with FREQ=WEEKLY", ErrInvalidRule)
+	}
+
+	if r.Freq == Yearly && len(r.ByDay) > 0 && len(r.ByMonth) == 0 {
+		return fmt.Errorf("%w: BYDAY with FREQ=YEARLY needs BYMONTH", ErrInvalidRule)
+	}
+
+	for _, day := range r.ByDay {
+		if day.Ordinal != 0 && (r.Freq == Daily || r.Freq == Weekly) {
+			return fmt.Errorf("%w: BYDAY ordinals need FREQ=MONTHLY or FREQ=YEARLY", ErrInvalidRule)
+		}
+	}
+
+	if len(r.BySetPos) > 0 && len(r.ByMonth)+len(r.ByMonthDay)+len(r.ByDay) == 0 {
+		return fmt.Errorf("%w: BYSETPOS needs BYMONTH, BYMONTHDAY or BYDAY", ErrInvalidRule)
+	}
+
+	return nil
+}
+
+// String formats the rule in the order Parse documents, it parses back to the same rule
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+
+	if len(r.ByMonth) > 0 {
+		months := make([]string, len(r.ByMonth))
+		for idx, month := range r.ByMonth {
+			months[idx] = strconv.Itoa(int(month))
+		}
+
+		parts = append(parts, "BYMONTH="+strings.Join(months, ","))
+	}
+
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for idx, day := range r.ByDay {
+			days[idx] = day.String()
+		}
+
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+
+	if len(r.BySetPos) > 0 {
+		parts = append(parts, "BYSETPOS="+joinInts(r.BySetPos))
+	}
+
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.Format(untilLayout))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// String formats a BYDAY value, ie "-1FR" for the last Friday
+func (w WeekdayNum) String() string {
+	code := strings.ToUpper(w.Weekday.String()[:2])
+	if w.Ordinal == 0 {
+		return code
+	}
+
+	return strconv.Itoa(w.Ordinal) + code
+}
+
+// Between is the days of the rule starting on start that fall from from through to
+func (r *Rule) Between(start, from, to time.Time) []time.Time {
+	from, to = Day(from), Day(to)
+	days := []time.Time{}
+
+	r.each(Day(start), to, func(day time.Time) {
+		if !day.Before(from) {
+			days = append(days, day)
+		}
+	})
+
+	return days
+}
+
+// IsOccurrence is whether the rule starting on start falls on day
+func (r *Rule) IsOccurrence(start, day time.Time) bool {
+	return len(r.Between(start, day, day)) == 1
+}
+
+// Day is the calendar day of t, as midnight UTC
+func Day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// each calls myFunc with the days of the rule in order, up to to, the COUNT or the UNTIL of the rule
+func (r *Rule) each(start, to time.Time, myFunc func(time.Time)) {
+	if !r.Until.IsZero() && Day(r.Until).Before(to) {
+		to = Day(r.Until)
+	}
+
+	count := 0
+
+	for period := 0; !r.periodStart(start, period).After(to); period++ {
+		for _, day := range r.periodDays(start, period) {
+			if day.Before(start) {
+				continue
+			}
+
+			if day.After(to) {
+				return
+			}
+
+			myFunc(day)
+
+			count++
+			if r.Count > 0 && count >= r.Count {
+				return
+			}
+		}
+	}
+}
+
+// periodStart is the first day of the nth period of the rule
+func (r *Rule) periodStart(start time.Time, period int) time.Time {
+	step := period * r.Interval
+
+	switch r.Freq {
+	case Daily:
+		return start.AddDate(0, 0, step)
+	case Weekly:
+		return weekStart(start).AddDate(0, 0, step*daysInWeek)
+	case Monthly:
+		return time.Date(start.Year(), start.Month()+time.Month(step), 1, 0, 0, 0, 0, time.UTC) //nolint:gosec
+	case Yearly:
+		return time.Date(start.Year()+step, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	return start
+}
+
+// periodDays is the days the rule picks from its nth period, in order
+func (r *Rule) periodDays(start time.Time, period int) []time.Time {
+	periodStart := r.periodStart(start, period)
+	days := []time.Time{}
+
+	switch r.Freq {
+	case Daily:
+		if r.matchesMonth(periodStart.Month()) && r.matchesMonthDay(periodStart) && r.matchesWeekday(periodStart) {
+			days = append(days, periodStart)
+		}
+	case Weekly:
+		for offset := range daysInWeek {
+			day := periodStart.AddDate(0, 0, offset)
+			if r.matchesMonth(day.Month()) && r.isWeeklyDay(start, day) {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		if r.matchesMonth(periodStart.Month()) {
+			days = r.monthDays(start, periodStart.Year(), periodStart.Month())
+		}
+	case Yearly:
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []time.Month{start.Month()}
+		}
+
+		for month := time.January; month <= monthsInYear; month++ {
+			if slices.Contains(months, month) {
+				days = append(days, r.monthDays(start, periodStart.Year(), month)...)
+			}
+		}
+	}
+
+	return r.setPos(days)
+}
+
+// monthDays is the days of a month the rule picks, the day of the month of start when BYMONTHDAY and BYDAY are
+// not set.  Like RFC 5545, a month without that day, such as the 31st, is skipped.
+func (r *Rule) monthDays(start time.Time, year int, month time.Month) []time.Time {
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	days := []time.Time{}
+
+	for dayOfMonth := 1; dayOfMonth <= lastDay; dayOfMonth++ {
+		day := time.Date(year, month, dayOfMonth, 0, 0, 0, 0, time.UTC)
+
+		if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+			if dayOfMonth == start.Day() {
+				days = append(days, day)
+			}
+
+			continue
+		}
+
+		if r.matchesMonthDay(day) && r.matchesMonthWeekday(day) {
+			days = append(days, day)
+		}
+	}
+
+	return days
+}
+
+func (r *Rule) matchesMonth(month time.Month) bool {
+	return len(r.ByMonth) == 0 || slices.Contains(r.ByMonth, month)
+}
+
+// matchesMonthDay is whether the day is one of BYMONTHDAY, negative days count back from the end of the month
+func (r *Rule) matchesMonthDay(day time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+
+	lastDay := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	for _, monthDay := range r.ByMonthDay {
+		if monthDay == day.Day() || lastDay+monthDay+1 == day.Day() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesWeekday is whether the weekday of the day is one of BYDAY, ignoring ordinals
+func (r *Rule) matchesWeekday(day time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+
+	for _, weekday := range r.ByDay {
+		if weekday.Weekday == day.Weekday() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesMonthWeekday is whether the day is one of BYDAY, where an ordinal is the nth weekday of its month
+func (r *Rule) matchesMonthWeekday(day time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+
+	lastDay := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	fromStart := (day.Day()-1)/daysInWeek + 1
+	fromEnd := -((lastDay-day.Day())/daysInWeek + 1)
+
+	for _, weekday := range r.ByDay {
+		if weekday.Weekday != day.Weekday() {
+			continue
+		}
+
+		if weekday.Ordinal == 0 || weekday.Ordinal == fromStart || weekday.Ordinal == fromEnd {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isWeeklyDay is whether a day of a weekly period is one of BYDAY, or the weekday of start when BYDAY is not set
+func (r *Rule) isWeeklyDay(start, day time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return day.Weekday() == start.Weekday()
+	}
+
+	return r.matchesWeekday(day)
+}
+
+// setPos keeps the days at the BYSETPOS positions of a period, negative positions count back from the end
+func (r *Rule) setPos(days []time.Time) []time.Time {
+	if len(r.BySetPos) == 0 {
+		return days
+	}
+
+	kept := []time.Time{}
+
+	for idx, day := range days {
+		for _, pos := range r.BySetPos {
+			if pos == idx+1 || pos == idx-len(days) {
+				kept = append(kept, day)
+
+				break
+			}
+		}
+	}
+
+	return kept
+}
+
+// weekStart is the Monday of the week of day
+func weekStart(day time.Time) time.Time {
+	return day.AddDate(0, 0, -((int(day.Weekday()) + daysInWeek - 1) % daysInWeek))
+}
+
+func parseInt(name, value string, minValue, maxValue int) (int, error) {
+	number, err := strconv.Atoi(value)
+	if err != nil || number < minValue || (maxValue > 0 && number > maxValue) {
+		return 0, fmt.Errorf("%w: %s=%s is out of range", ErrInvalidRule, name, value)
+	}
+
+	return number, nil
+}
+
+// parseIntList parses a list of non zero numbers from -maxValue to maxValue
+func parseIntList(name, value string, maxValue int) ([]int, error) {
+	numbers := []int{}
+
+	for _, item := range strings.Split(value, ",") {
+		number, err := strconv.Atoi(item)
+		if err != nil || number == 0 || number > maxValue || number < -maxValue {
+			return nil, fmt.Errorf("%w: %s=%s is out of range", ErrInvalidRule, name, value)
+		}
+
+		numbers = append(numbers, number)
+	}
+
+	return numbers, nil
+}
+
+func parseByMonth(value string) ([]time.Month, error) {
+	months := []time.Month{}
+
+	for _, item := range strings.Split(value, ",") {
+		month, err := parseInt("BYMONTH", item, 1, monthsInYear)
+		if err != nil {
+			return nil, err
+		}
+
+		months = append(months, time.Month(month))
+	}
+
+	return months, nil
+}
+
+// parseByDay parses BYDAY values such as MO, 1MO or -1FR
+func parseByDay(value string) ([]WeekdayNum, error) {
+	days := []WeekdayNum{}
+
+	for _, item := range strings.Split(value, ",") {
+		if len(item) < 2 { //nolint:mnd
+			return nil, fmt.Errorf("%w: BYDAY=%s is not a weekday", ErrInvalidRule, value)
+		}
+
+		weekday, found := weekdayCodes[item[len(item)-2:]]
+		if !found {
+			return nil, fmt.Errorf("%w: BYDAY=%s is not a weekday", ErrInvalidRule, value)
+		}
+
+		ordinal := 0
+
+		if ordinalStr := item[:len(item)-2]; ordinalStr != "" {
+			var err error
+
+			ordinal, err = strconv.Atoi(ordinalStr)
+			if err != nil || ordinal == 0 || ordinal > maxOrdinal || ordinal < -maxOrdinal {
+				return nil, fmt.Errorf("%w: BYDAY=%s ordinal is out of range", ErrInvalidRule, value)
+			}
+		}
+
+		days = append(days, WeekdayNum{Ordinal: ordinal, Weekday: weekday})
+	}
+
+	return days, nil
+}
+
+// parseUntil parses an UNTIL date, a time of day is dropped
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{untilLayout, untilLayoutTZ} {
+		if until, err := time.Parse(layout, value); err == nil {
+			return Day(until), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: UNTIL=%s is not a date", ErrInvalidRule, value)
+}
+
+func joinInts(numbers []int) string {
+	items := make([]string, len(numbers))
+	for idx, number := range numbers {
+		items[idx] = strconv.Itoa(number)
+	}
+
+	return strings.Join(items, ",")
+}
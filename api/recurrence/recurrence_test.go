@@ -0,0 +1,128 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	myRule, err := Parse("RRULE:FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myRule.Freq).To(gomega.Equal(Monthly))
+	g.Expect(myRule.Interval).To(gomega.Equal(1))
+	g.Expect(myRule.ByDay).To(gomega.HaveLen(5))
+	g.Expect(myRule.BySetPos).To(gomega.Equal([]int{-1}))
+	g.Expect(myRule.String()).To(gomega.Equal("FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1"))
+
+	myRule, err = Parse("freq=weekly;interval=2;until=20241231")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myRule.Until).To(gomega.Equal(date(2024, 12, 31)))
+	g.Expect(myRule.String()).To(gomega.Equal("FREQ=WEEKLY;INTERVAL=2;UNTIL=20241231"))
+
+	myRule, err = Parse("FREQ=YEARLY;BYMONTH=3;BYDAY=-1FR;COUNT=4")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myRule.ByDay).To(gomega.Equal([]WeekdayNum{{Ordinal: -1, Weekday: time.Friday}}))
+	g.Expect(myRule.String()).To(gomega.Equal("FREQ=YEARLY;BYMONTH=3;BYDAY=-1FR;COUNT=4"))
+
+	for _, invalid := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=MONTHLY;INTERVAL=0",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=YEARLY;BYDAY=MO",
+		"FREQ=MONTHLY;BYSETPOS=1",
+		"FREQ=MONTHLY;COUNT=2;UNTIL=20241231",
+		"FREQ=MONTHLY;FREQ=DAILY",
+		"FREQ=MONTHLY;BYHOUR=9",
+		"FREQ=MONTHLY;UNTIL=tomorrow",
+	} {
+		_, err = Parse(invalid)
+		g.Expect(err).To(gomega.MatchError(ErrInvalidRule), invalid)
+	}
+}
+
+func TestRule_Between(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+
+	tests := []struct {
+		rule     string
+		start    time.Time
+		from     time.Time
+		to       time.Time
+		expected []time.Time
+	}{
+		{ // monthly on the 1st
+			rule: "FREQ=MONTHLY;BYMONTHDAY=1", start: date(2024, 1, 15),
+			from: date(2024, 1, 1), to: date(2024, 4, 1),
+			expected: []time.Time{date(2024, 2, 1), date(2024, 3, 1), date(2024, 4, 1)},
+		},
+		{ // every 2 weeks on the weekday of the start
+			rule: "FREQ=WEEKLY;INTERVAL=2", start: date(2024, 1, 5),
+			from: date(2024, 1, 10), to: date(2024, 2, 20),
+			expected: []time.Time{date(2024, 1, 19), date(2024, 2, 2), date(2024, 2, 16)},
+		},
+		{ // last business day
+			rule: "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", start: date(2024, 1, 1),
+			from: date(2024, 1, 1), to: date(2024, 4, 30),
+			expected: []time.Time{date(2024, 1, 31), date(2024, 2, 29), date(2024, 3, 29), date(2024, 4, 30)},
+		},
+		{ // last day of the month
+			rule: "FREQ=MONTHLY;BYMONTHDAY=-1", start: date(2023, 12, 1),
+			from: date(2023, 12, 1), to: date(2024, 2, 29),
+			expected: []time.Time{date(2023, 12, 31), date(2024, 1, 31), date(2024, 2, 29)},
+		},
+		{ // the 31st skips the shorter months
+			rule: "FREQ=MONTHLY", start: date(2024, 1, 31),
+			from: date(2024, 1, 1), to: date(2024, 5, 31),
+			expected: []time.Time{date(2024, 1, 31), date(2024, 3, 31), date(2024, 5, 31)},
+		},
+		{ // second Tuesday, 3 times
+			rule: "FREQ=MONTHLY;BYDAY=2TU;COUNT=3", start: date(2024, 1, 1),
+			from: date(2024, 1, 1), to: date(2024, 12, 31),
+			expected: []time.Time{date(2024, 1, 9), date(2024, 2, 13), date(2024, 3, 12)},
+		},
+		{ // yearly on the start date until the end of 2026
+			rule: "FREQ=YEARLY;UNTIL=20261231", start: date(2024, 4, 15),
+			from: date(2024, 1, 1), to: date(2030, 12, 31),
+			expected: []time.Time{date(2024, 4, 15), date(2025, 4, 15), date(2026, 4, 15)},
+		},
+		{ // weekdays
+			rule: "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", start: date(2024, 1, 5),
+			from: date(2024, 1, 5), to: date(2024, 1, 9),
+			expected: []time.Time{date(2024, 1, 5), date(2024, 1, 8), date(2024, 1, 9)},
+		},
+	}
+
+	for _, test := range tests {
+		myRule, err := Parse(test.rule)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(myRule.Between(test.start, test.from, test.to)).To(gomega.Equal(test.expected), test.rule)
+	}
+
+	// COUNT is counted from the start, not from the window
+	myRule, err := Parse("FREQ=MONTHLY;COUNT=2")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myRule.Between(date(2024, 1, 10), date(2024, 2, 1), date(2024, 12, 31))).To(
+		gomega.Equal([]time.Time{date(2024, 2, 10)}))
+
+	g.Expect(myRule.IsOccurrence(date(2024, 1, 10), date(2024, 2, 10))).To(gomega.BeTrue())
+	g.Expect(myRule.IsOccurrence(date(2024, 1, 10), date(2024, 2, 11))).To(gomega.BeFalse())
+	g.Expect(myRule.IsOccurrence(date(2024, 1, 10), date(2024, 3, 10))).To(gomega.BeFalse())
+}
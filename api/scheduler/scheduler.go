@@ -0,0 +1,77 @@
+// Package scheduler posts the due occurrences of scheduled transactions in the background of the API server.
+// Each occurrence is claimed in the database before it is posted, so any number of replicas can run a Scheduler.
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// Scheduler posts the occurrences due as of today every Interval
+type Scheduler struct {
+	DataStores *datastore.Datastores
+	Logger     *zerolog.Logger
+	Interval   time.Duration
+}
+
+// NewScheduler instantiates a new Scheduler struct
+func NewScheduler(ds *datastore.Datastores, logger *zerolog.Logger, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		DataStores: ds,
+		Logger:     logger,
+		Interval:   interval,
+	}
+}
+
+// Start runs the Scheduler in the background.  The channel is closed once ctx is done and the run in progress, if
+// any, has finished, so a server can wait for it before it exits.
+func (s *Scheduler) Start(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		s.Run(ctx)
+	}()
+
+	return done
+}
+
+// Run posts the due occurrences straight away and then every Interval, until ctx is done.  A run in progress when
+// ctx is done is finished before Run returns.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.PostDue(time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PostDue posts the occurrences due on or before the day of asOf, and logs what was posted and what failed
+func (s *Scheduler) PostDue(asOf time.Time) {
+	posted, err := models.PostDueScheduledOccurrences(s.DataStores, asOf)
+	if err != nil {
+		s.Logger.Error().Err(err).Msg("models.PostDueScheduledOccurrences")
+	}
+
+	for _, occurrence := range posted {
+		s.Logger.Info().
+			Uint64("scheduled-id", occurrence.ScheduledID).
+			Str("scheduled-name", occurrence.ScheduledName).
+			Time("occurrence-date", occurrence.OccurrenceDate).
+			Uint64("transaction-id", occurrence.TransactionID).
+			Msg("posted scheduled transaction")
+	}
+}
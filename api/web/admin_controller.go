@@ -0,0 +1,51 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// AdminController is the controller struct for backing up, restoring and checking the whole ledger
+type AdminController struct {
+	DataStores *datastore.Datastores
+}
+
+// NewAdminController instantiates a new AdminController struct
+func NewAdminController(ds *datastore.Datastores) *AdminController {
+	return &AdminController{
+		DataStores: ds,
+	}
+}
+
+// GET /admin/export, the archive is written as it is read
+func (ac *AdminController) ExportArchive(_ context.Context, writer io.Writer) error {
+	if err := models.ExportArchive(ac.DataStores, writer); err != nil {
+		return fmt.Errorf("models.ExportArchive:%w", err)
+	}
+
+	return nil
+}
+
+// POST /admin/import
+func (ac *AdminController) RestoreArchive(_ context.Context, file io.Reader) (*models.ArchiveRestoreSummary, error) {
+	summary, err := models.RestoreArchive(ac.DataStores, file)
+	if err != nil {
+		return nil, fmt.Errorf("models.RestoreArchive:%w", err)
+	}
+
+	return summary, nil
+}
+
+// GET /admin/integrity
+func (ac *AdminController) CheckIntegrity(_ context.Context) (*models.IntegrityReport, error) {
+	report, err := models.CheckIntegrity(ac.DataStores)
+	if err != nil {
+		return nil, fmt.Errorf("models.CheckIntegrity:%w", err)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,71 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/mimirsoft/mimirledger/api/archive"
+	"github.com/mimirsoft/mimirledger/api/models"
+	"github.com/mimirsoft/mimirledger/api/web/response"
+)
+
+// archiveExportFilename is the name the archive is downloaded as
+const archiveExportFilename = "mimirledger-archive.ndjson"
+
+// GET /admin/export, the whole ledger as an NDJSON archive.  The archive is streamed, so an error part way through
+// leaves it without its end record, which a restore rejects as truncated.
+func GetAdminExport(adminCtl *AdminController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		res.Header().Set("Content-Type", "application/x-ndjson")
+		res.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", archiveExportFilename))
+
+		if err := adminCtl.ExportArchive(req.Context(), res); err != nil {
+			return NewRequestError(http.StatusServiceUnavailable, err)
+		}
+
+		return nil
+	}
+}
+
+// POST /admin/import, the archive is either the file of a multipart form or the request body
+func PostAdminImport(adminCtl *AdminController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		var file io.Reader = req.Body
+
+		if mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+			var err error
+			if file, _, err = parseImportFile(res, req); err != nil {
+				return err
+			}
+		}
+
+		summary, err := adminCtl.RestoreArchive(req.Context(), file)
+		if err != nil {
+			switch {
+			case errors.Is(err, models.ErrArchiveDatabaseNotEmpty):
+				return NewRequestError(http.StatusConflict, err)
+			case errors.Is(err, archive.ErrArchiveInvalid), errors.Is(err, archive.ErrArchiveVersionUnsupported):
+				return NewRequestError(http.StatusBadRequest, err)
+			}
+
+			return NewRequestError(http.StatusServiceUnavailable, err)
+		}
+
+		return RespondOK(res, response.ArchiveRestoreSummaryToResp(summary))
+	}
+}
+
+// GET /admin/integrity
+func GetAdminIntegrity(adminCtl *AdminController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		report, err := adminCtl.CheckIntegrity(req.Context())
+		if err != nil {
+			return NewRequestError(http.StatusServiceUnavailable, err)
+		}
+
+		return RespondOK(res, response.IntegrityReportToResp(report))
+	}
+}
@@ -0,0 +1,90 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+
+	"github.com/mimirsoft/mimirledger/api/web/response"
+)
+
+func postAdminImport(body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/admin/import", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/x-ndjson")
+
+	recorder := httptest.NewRecorder()
+	TestRouter.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func TestAdmin_ExportAndImport(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	recorder := postImportFile(g, "/imports/ledger", "../plaintext/testdata/household.journal", map[string]string{})
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusOK))
+
+	accounts := RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: "/accounts",
+	}, GomegaWithT: g, Code: http.StatusOK}
+	before := accounts.Request.Invoke()
+	g.Expect(before.Code).To(gomega.Equal(http.StatusOK))
+
+	test := RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: "/admin/export",
+	}, GomegaWithT: g, Code: http.StatusOK}
+	exported := test.Request.Invoke()
+	g.Expect(exported.Code).To(gomega.Equal(http.StatusOK))
+	g.Expect(exported.Header().Get("Content-Type")).To(gomega.Equal("application/x-ndjson"))
+	g.Expect(exported.Header().Get("Content-Disposition")).To(gomega.ContainSubstring("mimirledger-archive.ndjson"))
+	g.Expect(exported.Body.String()).To(gomega.HavePrefix(`{"format":"mimirledger-archive","version":1,`))
+
+	// the ledger is not empty
+	recorder = postAdminImport(exported.Body.String())
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusConflict))
+
+	setupDatastores(TestDataStore)
+
+	recorder = postAdminImport("Assets:Checking  10.00\n")
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusBadRequest))
+
+	filename := filepath.Join(t.TempDir(), "archive.ndjson")
+	g.Expect(os.WriteFile(filename, exported.Body.Bytes(), 0o600)).To(gomega.Succeed())
+
+	recorder = postImportFile(g, "/admin/import", filename, map[string]string{})
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusOK))
+
+	var summary response.ArchiveRestoreSummary
+	g.Expect(json.Unmarshal(recorder.Body.Bytes(), &summary)).To(gomega.Succeed())
+	g.Expect(summary.Transactions).To(gomega.Equal(4))
+	g.Expect(summary.Integrity.OK).To(gomega.BeTrue())
+	g.Expect(summary.Integrity.Problems).To(gomega.BeEmpty())
+
+	// accounts keep their IDs and balances
+	after := accounts.Request.Invoke()
+	g.Expect(after.Body.String()).To(gomega.Equal(before.Body.String()))
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: "/admin/integrity",
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var report response.IntegrityReport
+	test.ExecWithUnmarshal(&report)
+	g.Expect(report.OK).To(gomega.BeTrue())
+	g.Expect(report.TransactionsChecked).To(gomega.Equal(4))
+}
@@ -0,0 +1,94 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// AttachmentsController is the controller struct for the files attached to transactions
+type AttachmentsController struct {
+	DataStores *datastore.Datastores
+}
+
+// NewAttachmentsController instantiates a new AttachmentsController struct
+func NewAttachmentsController(ds *datastore.Datastores) *AttachmentsController {
+	return &AttachmentsController{
+		DataStores: ds,
+	}
+}
+
+// AttachmentUpload is a file uploaded to be attached to a transaction
+type AttachmentUpload struct {
+	File        io.Reader
+	FileName    string
+	ContentType string
+	FileSize    int64
+}
+
+// GET /transactions/{transactionID}/attachments
+func (ac *AttachmentsController) AttachmentList(_ context.Context,
+	transactionID uint64) ([]*models.Attachment, error) {
+	attachments, err := models.RetrieveAttachmentsForTransaction(ac.DataStores, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveAttachmentsForTransaction:%w", err)
+	}
+
+	return attachments, nil
+}
+
+// POST /transactions/{transactionID}/attachments
+func (ac *AttachmentsController) CreateAttachment(ctx context.Context, transactionID uint64,
+	upload *AttachmentUpload) (*models.Attachment, error) {
+	attachment := models.Attachment{
+		AttachmentID:  0,
+		TransactionID: transactionID,
+		FileName:      upload.FileName,
+		ContentType:   upload.ContentType,
+		FileSize:      upload.FileSize,
+		FileSHA256:    "",
+		StorageKey:    "",
+		CreatedDate:   time.Time{},
+	}
+
+	if err := attachment.Store(ctx, ac.DataStores, upload.File); err != nil {
+		return nil, fmt.Errorf("attachment.Store:%w", err)
+	}
+
+	return &attachment, nil
+}
+
+// GET /transactions/{transactionID}/attachments/{attachmentID}, the caller closes the file
+func (ac *AttachmentsController) OpenAttachment(ctx context.Context, transactionID,
+	attachmentID uint64) (*models.Attachment, io.ReadCloser, error) {
+	attachment, err := models.RetrieveAttachmentByID(ac.DataStores, transactionID, attachmentID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("models.RetrieveAttachmentByID:%w", err)
+	}
+
+	file, err := attachment.Open(ctx, ac.DataStores)
+	if err != nil {
+		return nil, nil, fmt.Errorf("attachment.Open:%w", err)
+	}
+
+	return attachment, file, nil
+}
+
+// DELETE /transactions/{transactionID}/attachments/{attachmentID}
+func (ac *AttachmentsController) DeleteAttachment(ctx context.Context, transactionID,
+	attachmentID uint64) (*models.Attachment, error) {
+	attachment, err := models.RetrieveAttachmentByID(ac.DataStores, transactionID, attachmentID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveAttachmentByID:%w", err)
+	}
+
+	if err = attachment.Delete(ctx, ac.DataStores); err != nil {
+		return nil, fmt.Errorf("attachment.Delete:%w", err)
+	}
+
+	return attachment, nil
+}
@@ -0,0 +1,152 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mimirsoft/mimirledger/api/models"
+	"github.com/mimirsoft/mimirledger/api/web/response"
+)
+
+var ErrInvalidAttachmentID = errors.New("invalid attachmentID request parameter")
+var ErrNoAttachmentFile = errors.New("missing file in multipart form")
+
+// maxAttachmentFileSize is the largest receipt or document accepted
+const maxAttachmentFileSize = 25 << 20
+
+func parseAttachmentTransactionID(req *http.Request) (uint64, error) {
+	transactionID, err := strconv.ParseUint(chi.URLParam(req, "transactionID"), 10, 64)
+	if err != nil || transactionID == 0 {
+		return 0, NewRequestError(http.StatusBadRequest, ErrInvalidTransactionID)
+	}
+
+	return transactionID, nil
+}
+
+// parseAttachmentIDs reads the transaction and the attachment of the path
+func parseAttachmentIDs(req *http.Request) (uint64, uint64, error) {
+	transactionID, err := parseAttachmentTransactionID(req)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	attachmentID, err := strconv.ParseUint(chi.URLParam(req, "attachmentID"), 10, 64)
+	if err != nil || attachmentID == 0 {
+		return 0, 0, NewRequestError(http.StatusBadRequest, ErrInvalidAttachmentID)
+	}
+
+	return transactionID, attachmentID, nil
+}
+
+// parseAttachmentUpload reads a multipart form with the file to attach in "file", the content type is that of the
+// part
+func parseAttachmentUpload(res http.ResponseWriter, req *http.Request) (*AttachmentUpload, error) {
+	req.Body = http.MaxBytesReader(res, req.Body, maxAttachmentFileSize)
+
+	if err := req.ParseMultipartForm(maxAttachmentFileSize); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, NewRequestError(http.StatusRequestEntityTooLarge, err)
+		}
+
+		return nil, NewRequestError(http.StatusBadRequest, err)
+	}
+
+	file, header, err := req.FormFile("file")
+	if err != nil {
+		return nil, NewRequestError(http.StatusBadRequest, ErrNoAttachmentFile)
+	}
+
+	return &AttachmentUpload{File: file, FileName: header.Filename, ContentType: header.Header.Get("Content-Type"),
+		FileSize: header.Size}, nil
+}
+
+// respondWithAttachmentError maps the errors of attachments to a status
+func respondWithAttachmentError(err error) error {
+	switch {
+	case errors.Is(err, models.ErrTransactionNotFound), errors.Is(err, models.ErrAttachmentNotFound):
+		return NewRequestError(http.StatusNotFound, err)
+	case errors.Is(err, models.ErrAttachmentInvalid):
+		return NewRequestError(http.StatusBadRequest, err)
+	}
+
+	return fmt.Errorf("attachments:%w", err)
+}
+
+// GET /transactions/{transactionID}/attachments
+func GetAttachments(attachmentsCtl *AttachmentsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		transactionID, err := parseAttachmentTransactionID(req)
+		if err != nil {
+			return err
+		}
+
+		attachments, err := attachmentsCtl.AttachmentList(req.Context(), transactionID)
+		if err != nil {
+			return respondWithAttachmentError(err)
+		}
+
+		return RespondOK(res, response.ConvertAttachmentsToRespAttachmentSet(attachments))
+	}
+}
+
+// POST /transactions/{transactionID}/attachments, multipart form with the receipt or document in file
+func PostAttachments(attachmentsCtl *AttachmentsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		transactionID, err := parseAttachmentTransactionID(req)
+		if err != nil {
+			return err
+		}
+
+		upload, err := parseAttachmentUpload(res, req)
+		if err != nil {
+			return err
+		}
+
+		attachment, err := attachmentsCtl.CreateAttachment(req.Context(), transactionID, upload)
+		if err != nil {
+			return respondWithAttachmentError(err)
+		}
+
+		return RespondOK(res, response.AttachmentToRespAttachment(attachment))
+	}
+}
+
+// GET /transactions/{transactionID}/attachments/{attachmentID}, downloads the file
+func GetAttachment(attachmentsCtl *AttachmentsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		transactionID, attachmentID, err := parseAttachmentIDs(req)
+		if err != nil {
+			return err
+		}
+
+		attachment, file, err := attachmentsCtl.OpenAttachment(req.Context(), transactionID, attachmentID)
+		if err != nil {
+			return respondWithAttachmentError(err)
+		}
+		defer file.Close()
+
+		return RespondAttachmentStream(res, attachment.ContentType, attachment.FileName, attachment.FileSize, file)
+	}
+}
+
+// DELETE /transactions/{transactionID}/attachments/{attachmentID}
+func DeleteAttachment(attachmentsCtl *AttachmentsController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		transactionID, attachmentID, err := parseAttachmentIDs(req)
+		if err != nil {
+			return err
+		}
+
+		attachment, err := attachmentsCtl.DeleteAttachment(req.Context(), transactionID, attachmentID)
+		if err != nil {
+			return respondWithAttachmentError(err)
+		}
+
+		return RespondOK(res, response.AttachmentToRespAttachment(attachment))
+	}
+}
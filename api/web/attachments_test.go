@@ -0,0 +1,137 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+	"github.com/mimirsoft/mimirledger/api/web/response"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+// postAttachment posts body as the file of a multipart form
+func postAttachment(g *gomega.WithT, requestURL, filename string, body []byte) *httptest.ResponseRecorder {
+	var form bytes.Buffer
+
+	writer := multipart.NewWriter(&form)
+
+	part, err := writer.CreateFormFile("file", filename)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	_, err = part.Write(body)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	g.Expect(writer.Close()).To(gomega.Succeed())
+
+	request := httptest.NewRequest(http.MethodPost, requestURL, &form)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+
+	recorder := httptest.NewRecorder()
+	TestRouter.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func TestAttachments_UploadDownloadDelete(t *testing.T) { //nolint:funlen
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	checking := models.Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	expense := models.Account{AccountName: "Office", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeExpense}
+	err = expense.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	txn := models.Transaction{TransactionCore: models.TransactionCore{TransactionComment: "Printer paper",
+		TransactionDate: time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)},
+		DebitCreditSet: []*models.TransactionDebitCredit{
+			&models.TransactionDebitCredit{AccountID: checking.AccountID,
+				DebitOrCredit:       datastore.AccountSignCredit,
+				TransactionDCAmount: 2499},
+			&models.TransactionDebitCredit{AccountID: expense.AccountID,
+				DebitOrCredit:       datastore.AccountSignDebit,
+				TransactionDCAmount: 2499},
+		},
+	}
+	err = txn.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	attachmentsURL := fmt.Sprintf("/transactions/%d/attachments", txn.TransactionID)
+	receipt := []byte("%PDF-1.4 printer paper receipt")
+
+	recorder := postAttachment(g, fmt.Sprintf("/transactions/%d/attachments", txn.TransactionID+1000),
+		"receipt.pdf", receipt)
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusNotFound))
+
+	recorder = postAttachment(g, "/transactions/abc/attachments", "receipt.pdf", receipt)
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusBadRequest))
+
+	recorder = postAttachment(g, attachmentsURL, "receipt.pdf", receipt)
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusOK))
+
+	var attachment response.Attachment
+	g.Expect(json.Unmarshal(recorder.Body.Bytes(), &attachment)).To(gomega.Succeed())
+	g.Expect(attachment.TransactionID).To(gomega.Equal(txn.TransactionID))
+	g.Expect(attachment.FileName).To(gomega.Equal("receipt.pdf"))
+	g.Expect(attachment.FileSize).To(gomega.Equal(int64(len(receipt))))
+	g.Expect(attachment.FileSHA256).To(gomega.HaveLen(64))
+
+	var attachmentSet response.AttachmentSet
+	test := RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: attachmentsURL,
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&attachmentSet)
+	g.Expect(attachmentSet.Attachments).To(gomega.HaveLen(1))
+	g.Expect(attachmentSet.Attachments[0].AttachmentID).To(gomega.Equal(attachment.AttachmentID))
+
+	var ledger response.TransactionLedgerSet
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/transactions/account/%d", checking.AccountID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&ledger)
+	g.Expect(ledger.Transactions).To(gomega.HaveLen(1))
+	g.Expect(ledger.Transactions[0].AttachmentCount).To(gomega.Equal(uint64(1)))
+
+	// the file is downloaded as it was uploaded
+	attachmentURL := fmt.Sprintf("%s/%d", attachmentsURL, attachment.AttachmentID)
+	recorder = Request{Method: http.MethodGet, Router: TestRouter, RequestURL: attachmentURL}.Invoke()
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusOK))
+	g.Expect(recorder.Body.Bytes()).To(gomega.Equal(receipt))
+	g.Expect(recorder.Header().Get("Content-Disposition")).To(gomega.ContainSubstring("receipt.pdf"))
+	g.Expect(recorder.Header().Get("Content-Length")).To(gomega.Equal(fmt.Sprint(len(receipt))))
+
+	// an attachment is only found through its own transaction
+	recorder = Request{Method: http.MethodGet, Router: TestRouter,
+		RequestURL: fmt.Sprintf("/transactions/%d/attachments/%d", txn.TransactionID+1000,
+			attachment.AttachmentID)}.Invoke()
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusNotFound))
+
+	recorder = Request{Method: http.MethodGet, Router: TestRouter, RequestURL: attachmentsURL + "/abc"}.Invoke()
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusBadRequest))
+
+	recorder = Request{Method: http.MethodDelete, Router: TestRouter, RequestURL: attachmentURL}.Invoke()
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusOK))
+
+	recorder = Request{Method: http.MethodDelete, Router: TestRouter, RequestURL: attachmentURL}.Invoke()
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusNotFound))
+
+	recorder = Request{Method: http.MethodGet, Router: TestRouter, RequestURL: attachmentURL}.Invoke()
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusNotFound))
+}
@@ -0,0 +1,44 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// ExportsController is the controller struct for exporting the ledger to other tools
+type ExportsController struct {
+	DataStores *datastore.Datastores
+}
+
+// NewExportsController instantiates a new ExportsController struct
+func NewExportsController(ds *datastore.Datastores) *ExportsController {
+	return &ExportsController{
+		DataStores: ds,
+	}
+}
+
+// GET /export/ledger
+func (ec *ExportsController) ExportLedger(_ context.Context) ([]byte, error) {
+	var journal bytes.Buffer
+
+	if err := models.ExportLedger(ec.DataStores, &journal); err != nil {
+		return nil, fmt.Errorf("models.ExportLedger:%w", err)
+	}
+
+	return journal.Bytes(), nil
+}
+
+// GET /export/beancount
+func (ec *ExportsController) ExportBeancount(_ context.Context, title, currency string) ([]byte, error) {
+	var beancount bytes.Buffer
+
+	if err := models.ExportBeancount(ec.DataStores, &beancount, title, currency); err != nil {
+		return nil, fmt.Errorf("models.ExportBeancount:%w", err)
+	}
+
+	return beancount.Bytes(), nil
+}
@@ -0,0 +1,44 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mimirsoft/mimirledger/api/plaintext"
+)
+
+// ledgerExportFilename and beancountExportFilename are the names the exports are downloaded as
+const (
+	ledgerExportFilename    = "mimirledger.journal"
+	beancountExportFilename = "mimirledger.beancount"
+)
+
+// GET /export/ledger, the whole ledger as a ledger-cli and hledger journal
+func GetExportLedger(exportsCtl *ExportsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		journal, err := exportsCtl.ExportLedger(req.Context())
+		if err != nil {
+			return NewRequestError(http.StatusServiceUnavailable, err)
+		}
+
+		return RespondAttachment(res, "text/plain; charset=utf-8", ledgerExportFilename, journal)
+	}
+}
+
+// GET /export/beancount?title=&currency=, the whole ledger as a beancount file
+func GetExportBeancount(exportsCtl *ExportsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		beancount, err := exportsCtl.ExportBeancount(req.Context(), req.URL.Query().Get("title"),
+			req.URL.Query().Get("currency"))
+		if err != nil {
+			// the currency cannot be written as a beancount commodity
+			if errors.Is(err, plaintext.ErrBeancountCurrencyInvalid) {
+				return NewRequestError(http.StatusBadRequest, err)
+			}
+
+			return NewRequestError(http.StatusServiceUnavailable, err)
+		}
+
+		return RespondAttachment(res, "text/plain; charset=utf-8", beancountExportFilename, beancount)
+	}
+}
@@ -0,0 +1,89 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+
+	"github.com/mimirsoft/mimirledger/api/web/response"
+)
+
+func TestExports_GetExportLedger(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	recorder := postImportFile(g, "/imports/ledger", "../plaintext/testdata/household.journal", map[string]string{})
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusOK))
+
+	var summary response.LedgerImportSummary
+	g.Expect(json.Unmarshal(recorder.Body.Bytes(), &summary)).To(gomega.Succeed())
+	g.Expect(summary.TransactionsImported).To(gomega.Equal(4))
+
+	test := RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: "/export/ledger",
+	}, GomegaWithT: g, Code: http.StatusOK}
+	exported := test.Request.Invoke()
+	g.Expect(exported.Code).To(gomega.Equal(http.StatusOK))
+	g.Expect(exported.Header().Get("Content-Disposition")).To(gomega.ContainSubstring("mimirledger.journal"))
+	g.Expect(exported.Body.String()).To(gomega.ContainSubstring("2024-01-05 * (1001) Landlord\n"))
+
+	// the export imports into an empty ledger
+	setupDatastores(TestDataStore)
+
+	filename := filepath.Join(t.TempDir(), "export.journal")
+	g.Expect(os.WriteFile(filename, exported.Body.Bytes(), 0o600)).To(gomega.Succeed())
+
+	recorder = postImportFile(g, "/imports/ledger", filename, map[string]string{})
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusOK))
+	g.Expect(json.Unmarshal(recorder.Body.Bytes(), &summary)).To(gomega.Succeed())
+	g.Expect(summary.AccountsReused).To(gomega.Equal(0))
+	g.Expect(summary.TransactionsImported).To(gomega.Equal(4))
+
+	reexported := test.Request.Invoke()
+	g.Expect(reexported.Body.String()).To(gomega.Equal(exported.Body.String()))
+
+	recorder = postImportFile(g, "/imports/ledger", "../importer/testdata/quicken.qif", map[string]string{})
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusBadRequest))
+}
+
+func TestExports_GetExportBeancount(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	recorder := postImportFile(g, "/imports/ledger", "../plaintext/testdata/household.journal", map[string]string{})
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusOK))
+
+	test := RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: "/export/beancount",
+	}, GomegaWithT: g, Code: http.StatusOK}
+	exported := test.Request.Invoke()
+	g.Expect(exported.Code).To(gomega.Equal(http.StatusOK))
+	g.Expect(exported.Header().Get("Content-Disposition")).To(gomega.ContainSubstring("mimirledger.beancount"))
+	g.Expect(exported.Body.String()).To(gomega.ContainSubstring("option \"operating_currency\" \"USD\"\n"))
+	g.Expect(exported.Body.String()).To(gomega.ContainSubstring(" open Equity:Opening-Balances USD\n"))
+	g.Expect(exported.Body.String()).To(gomega.ContainSubstring("2024-01-05 * \"Landlord\"\n" +
+		"  reference: \"1001\"\n" +
+		"  reconciled: 2024-01-31\n"))
+	g.Expect(exported.Body.String()).To(gomega.ContainSubstring("2024-01-10 ! \"Supermarket | weekly shop\"\n"))
+
+	test.RequestURL = "/export/beancount?title=Household&currency=EUR"
+	exported = test.Request.Invoke()
+	g.Expect(exported.Code).To(gomega.Equal(http.StatusOK))
+	g.Expect(exported.Body.String()).To(gomega.ContainSubstring("option \"title\" \"Household\"\n"))
+	g.Expect(exported.Body.String()).To(gomega.ContainSubstring("option \"operating_currency\" \"EUR\"\n"))
+
+	test.RequestURL = "/export/beancount?currency=us+dollars"
+	test.Code = http.StatusBadRequest
+	test.Exec()
+}
@@ -0,0 +1,324 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/importer"
+	"github.com/mimirsoft/mimirledger/api/models"
+	"github.com/mimirsoft/mimirledger/api/plaintext"
+)
+
+// ImportsController is the controller struct for statement imports
+type ImportsController struct {
+	DataStores *datastore.Datastores
+}
+
+// NewImportsController instantiates a new ImportsController struct
+func NewImportsController(ds *datastore.Datastores) *ImportsController {
+	return &ImportsController{
+		DataStores: ds,
+	}
+}
+
+// ImportUpload is an uploaded statement file and the accounts it is imported into
+type ImportUpload struct {
+	File             io.Reader
+	Filename         string
+	AccountID        uint64
+	OffsetAccountID  uint64
+	CategoryParentID uint64
+}
+
+func (c *ImportUpload) accounts() *models.ImportAccounts {
+	return &models.ImportAccounts{AccountID: c.AccountID, OffsetAccountID: c.OffsetAccountID,
+		CategoryParentID: c.CategoryParentID}
+}
+
+// POST /imports/ofx
+func (ic *ImportsController) ImportOFX(_ context.Context, upload *ImportUpload) (*models.Import, error) {
+	account, err := models.RetrieveAccountByID(ic.DataStores, upload.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveAccountByID:%w", err)
+	}
+
+	myStatement, err := importer.ParseOFX(upload.File, account.AccountDecimals)
+	if err != nil {
+		return nil, fmt.Errorf("importer.ParseOFX:%w", err)
+	}
+
+	myImport, err := models.NewImport(ic.DataStores, myStatement, importer.FormatOFX, upload.Filename,
+		upload.accounts())
+	if err != nil {
+		return nil, fmt.Errorf("models.NewImport:%w", err)
+	}
+
+	return myImport, nil
+}
+
+// POST /imports/csv, the lines are offset against the upload's offset account, then the profile's
+func (ic *ImportsController) ImportCSV(_ context.Context, upload *ImportUpload,
+	profileID uint64) (*models.Import, error) {
+	account, err := models.RetrieveAccountByID(ic.DataStores, upload.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveAccountByID:%w", err)
+	}
+
+	profile, err := models.RetrieveImportProfileByID(ic.DataStores, profileID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveImportProfileByID:%w", err)
+	}
+
+	myStatement, err := importer.ParseCSV(upload.File, &profile.CSV, account.AccountDecimals)
+	if err != nil {
+		return nil, fmt.Errorf("importer.ParseCSV:%w", err)
+	}
+
+	accounts := upload.accounts()
+	if accounts.OffsetAccountID == 0 {
+		accounts.OffsetAccountID = profile.OffsetAccountID
+	}
+
+	myImport, err := models.NewImport(ic.DataStores, myStatement, importer.FormatCSV, upload.Filename, accounts)
+	if err != nil {
+		return nil, fmt.Errorf("models.NewImport:%w", err)
+	}
+
+	return myImport, nil
+}
+
+// POST /imports/qif, categories are mapped to accounts under the upload's category parent
+func (ic *ImportsController) ImportQIF(_ context.Context, upload *ImportUpload,
+	dayFirst bool) (*models.Import, error) {
+	account, err := models.RetrieveAccountByID(ic.DataStores, upload.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveAccountByID:%w", err)
+	}
+
+	myStatement, err := importer.ParseQIF(upload.File, account.AccountDecimals, dayFirst)
+	if err != nil {
+		return nil, fmt.Errorf("importer.ParseQIF:%w", err)
+	}
+
+	myImport, err := models.NewImport(ic.DataStores, myStatement, importer.FormatQIF, upload.Filename,
+		upload.accounts())
+	if err != nil {
+		return nil, fmt.Errorf("models.NewImport:%w", err)
+	}
+
+	return myImport, nil
+}
+
+// POST /imports/camt053
+func (ic *ImportsController) ImportCAMT053(_ context.Context, upload *ImportUpload) (*models.Import, error) {
+	account, err := models.RetrieveAccountByID(ic.DataStores, upload.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveAccountByID:%w", err)
+	}
+
+	myStatement, err := importer.ParseCAMT053(upload.File, account.AccountDecimals)
+	if err != nil {
+		return nil, fmt.Errorf("importer.ParseCAMT053:%w", err)
+	}
+
+	myImport, err := models.NewImport(ic.DataStores, myStatement, importer.FormatCAMT053, upload.Filename,
+		upload.accounts())
+	if err != nil {
+		return nil, fmt.Errorf("models.NewImport:%w", err)
+	}
+
+	return myImport, nil
+}
+
+// POST /imports/mt940
+func (ic *ImportsController) ImportMT940(_ context.Context, upload *ImportUpload) (*models.Import, error) {
+	account, err := models.RetrieveAccountByID(ic.DataStores, upload.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveAccountByID:%w", err)
+	}
+
+	myStatement, err := importer.ParseMT940(upload.File, account.AccountDecimals)
+	if err != nil {
+		return nil, fmt.Errorf("importer.ParseMT940:%w", err)
+	}
+
+	myImport, err := models.NewImport(ic.DataStores, myStatement, importer.FormatMT940, upload.Filename,
+		upload.accounts())
+	if err != nil {
+		return nil, fmt.Errorf("models.NewImport:%w", err)
+	}
+
+	return myImport, nil
+}
+
+// POST /imports/gnucash, the book is imported directly, without a preview
+func (ic *ImportsController) ImportGnuCash(_ context.Context, file io.Reader) (*models.GnuCashImportSummary, error) {
+	book, err := importer.ParseGnuCash(file)
+	if err != nil {
+		return nil, fmt.Errorf("importer.ParseGnuCash:%w", err)
+	}
+
+	summary, err := models.ImportGnuCashBook(ic.DataStores, book)
+	if err != nil {
+		return nil, fmt.Errorf("models.ImportGnuCashBook:%w", err)
+	}
+
+	return summary, nil
+}
+
+// POST /imports/ledger, the journal is imported directly, without a preview
+func (ic *ImportsController) ImportLedger(_ context.Context, file io.Reader) (*models.LedgerImportSummary, error) {
+	journal, err := plaintext.ParseLedger(file)
+	if err != nil {
+		return nil, fmt.Errorf("plaintext.ParseLedger:%w", err)
+	}
+
+	summary, err := models.ImportLedgerJournal(ic.DataStores, journal)
+	if err != nil {
+		return nil, fmt.Errorf("models.ImportLedgerJournal:%w", err)
+	}
+
+	return summary, nil
+}
+
+// GET /imports
+func (ic *ImportsController) ImportList(_ context.Context) ([]*models.Import, error) {
+	imports, err := models.RetrieveImports(ic.DataStores)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveImports:%w", err)
+	}
+
+	return imports, nil
+}
+
+// GET /imports/{importID}
+func (ic *ImportsController) GetImportByID(_ context.Context, importID uint64) (*models.Import, error) {
+	myImport, err := models.RetrieveImportByID(ic.DataStores, importID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveImportByID:%w", err)
+	}
+
+	if err = myImport.SuggestAccounts(ic.DataStores); err != nil {
+		return nil, fmt.Errorf("myImport.SuggestAccounts:%w", err)
+	}
+
+	return myImport, nil
+}
+
+// POST /imports/{importID}/confirm
+func (ic *ImportsController) ConfirmImport(_ context.Context, importID uint64) (*models.Import, error) {
+	myImport, err := models.RetrieveImportByID(ic.DataStores, importID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveImportByID:%w", err)
+	}
+
+	if err = myImport.Post(ic.DataStores); err != nil {
+		return nil, fmt.Errorf("myImport.Post:%w", err)
+	}
+
+	return myImport, nil
+}
+
+// GET /imports/{importID}/matches
+func (ic *ImportsController) GetImportMatches(_ context.Context, importID uint64,
+	dateTolerance int) ([]*models.StatementMatch, error) {
+	myImport, err := models.RetrieveImportByID(ic.DataStores, importID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveImportByID:%w", err)
+	}
+
+	matches, err := myImport.MatchLines(ic.DataStores, dateTolerance)
+	if err != nil {
+		return nil, fmt.Errorf("myImport.MatchLines:%w", err)
+	}
+
+	return matches, nil
+}
+
+// POST /imports/{importID}/matches
+func (ic *ImportsController) ConfirmImportMatches(_ context.Context, importID uint64,
+	confirmations []*models.MatchConfirmation, reconcileDate time.Time,
+	dateTolerance int) ([]*models.StatementMatch, error) {
+	myImport, err := models.RetrieveImportByID(ic.DataStores, importID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveImportByID:%w", err)
+	}
+
+	matches, err := myImport.ConfirmMatches(ic.DataStores, confirmations, reconcileDate, dateTolerance)
+	if err != nil {
+		return nil, fmt.Errorf("myImport.ConfirmMatches:%w", err)
+	}
+
+	return matches, nil
+}
+
+// DELETE /imports/{importID}
+func (ic *ImportsController) CancelImport(_ context.Context, importID uint64) (*models.Import, error) {
+	myImport, err := models.RetrieveImportByID(ic.DataStores, importID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveImportByID:%w", err)
+	}
+
+	if err = myImport.Cancel(ic.DataStores); err != nil {
+		return nil, fmt.Errorf("myImport.Cancel:%w", err)
+	}
+
+	return myImport, nil
+}
+
+// GET /imports/profiles
+func (ic *ImportsController) ProfileList(_ context.Context) ([]*models.ImportProfile, error) {
+	profiles, err := models.RetrieveImportProfiles(ic.DataStores)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveImportProfiles:%w", err)
+	}
+
+	return profiles, nil
+}
+
+// GET /imports/profiles/{profileID}
+func (ic *ImportsController) GetProfileByID(_ context.Context, profileID uint64) (*models.ImportProfile, error) {
+	myProfile, err := models.RetrieveImportProfileByID(ic.DataStores, profileID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveImportProfileByID:%w", err)
+	}
+
+	return myProfile, nil
+}
+
+// POST /imports/profiles
+func (ic *ImportsController) CreateProfile(_ context.Context,
+	myProfile *models.ImportProfile) (*models.ImportProfile, error) {
+	if err := myProfile.Store(ic.DataStores); err != nil {
+		return nil, fmt.Errorf("myProfile.Store:%w", err)
+	}
+
+	return myProfile, nil
+}
+
+// PUT /imports/profiles/{profileID}
+func (ic *ImportsController) UpdateProfile(_ context.Context,
+	myProfile *models.ImportProfile) (*models.ImportProfile, error) {
+	if err := myProfile.Update(ic.DataStores); err != nil {
+		return nil, fmt.Errorf("myProfile.Update:%w", err)
+	}
+
+	return myProfile, nil
+}
+
+// DELETE /imports/profiles/{profileID}
+func (ic *ImportsController) DeleteProfile(_ context.Context, profileID uint64) (*models.ImportProfile, error) {
+	myProfile, err := models.RetrieveImportProfileByID(ic.DataStores, profileID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveImportProfileByID:%w", err)
+	}
+
+	if err = myProfile.Delete(ic.DataStores); err != nil {
+		return nil, fmt.Errorf("myProfile.Delete:%w", err)
+	}
+
+	return myProfile, nil
+}
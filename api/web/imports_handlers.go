@@ -0,0 +1,493 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mimirsoft/mimirledger/api/importer"
+	"github.com/mimirsoft/mimirledger/api/models"
+	"github.com/mimirsoft/mimirledger/api/plaintext"
+	"github.com/mimirsoft/mimirledger/api/web/request"
+	"github.com/mimirsoft/mimirledger/api/web/response"
+)
+
+var ErrInvalidImportID = errors.New("invalid importID request parameter")
+var ErrNoImportFile = errors.New("missing file in multipart form")
+var ErrInvalidOffsetAccountID = errors.New("invalid offsetAccountID request parameter")
+var ErrInvalidProfileID = errors.New("invalid profileID request parameter")
+var ErrInvalidCategoryParentID = errors.New("invalid categoryParentID request parameter")
+var ErrInvalidDayFirst = errors.New("invalid dayFirst request parameter")
+var ErrInvalidDateTolerance = errors.New("invalid dateTolerance request parameter")
+
+// maxImportFileSize is the largest statement file accepted
+const maxImportFileSize = 10 << 20
+
+// parseImportUpload reads a multipart form with the statement in "file", the account to import into in
+// "accountID", and optionally the account to offset the lines against in "offsetAccountID" and the account to
+// create categories under in "categoryParentID"
+func parseImportUpload(res http.ResponseWriter, req *http.Request) (*ImportUpload, error) {
+	file, filename, err := parseImportFile(res, req)
+	if err != nil {
+		return nil, err
+	}
+
+	accountID, err := strconv.ParseUint(req.FormValue("accountID"), 10, 64)
+	if err != nil || accountID == 0 {
+		return nil, NewRequestError(http.StatusBadRequest, ErrInvalidAccountID)
+	}
+
+	var offsetAccountID uint64
+
+	if offsetStr := req.FormValue("offsetAccountID"); offsetStr != "" {
+		offsetAccountID, err = strconv.ParseUint(offsetStr, 10, 64)
+		if err != nil {
+			return nil, NewRequestError(http.StatusBadRequest, ErrInvalidOffsetAccountID)
+		}
+	}
+
+	var categoryParentID uint64
+
+	if parentStr := req.FormValue("categoryParentID"); parentStr != "" {
+		categoryParentID, err = strconv.ParseUint(parentStr, 10, 64)
+		if err != nil {
+			return nil, NewRequestError(http.StatusBadRequest, ErrInvalidCategoryParentID)
+		}
+	}
+
+	return &ImportUpload{File: file, Filename: filename, AccountID: accountID,
+		OffsetAccountID: offsetAccountID, CategoryParentID: categoryParentID}, nil
+}
+
+// parseImportFile reads a multipart form with the file to import in "file"
+func parseImportFile(res http.ResponseWriter, req *http.Request) (io.Reader, string, error) {
+	req.Body = http.MaxBytesReader(res, req.Body, maxImportFileSize)
+
+	if err := req.ParseMultipartForm(maxImportFileSize); err != nil {
+		return nil, "", NewRequestError(http.StatusBadRequest, err)
+	}
+
+	file, header, err := req.FormFile("file")
+	if err != nil {
+		return nil, "", NewRequestError(http.StatusBadRequest, ErrNoImportFile)
+	}
+
+	return file, header.Filename, nil
+}
+
+func parseImportID(req *http.Request) (uint64, error) {
+	importID, err := strconv.ParseUint(chi.URLParam(req, "importID"), 10, 64)
+	if err != nil || importID == 0 {
+		return 0, NewRequestError(http.StatusBadRequest, ErrInvalidImportID)
+	}
+
+	return importID, nil
+}
+
+func parseProfileID(idStr string) (uint64, error) {
+	profileID, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil || profileID == 0 {
+		return 0, NewRequestError(http.StatusBadRequest, ErrInvalidProfileID)
+	}
+
+	return profileID, nil
+}
+
+// respondWithImportError maps the errors of creating and posting imports to a status
+func respondWithImportError(err error) error {
+	switch {
+	case errors.Is(err, models.ErrAccountNotFound), errors.Is(err, models.ErrImportNotFound),
+		errors.Is(err, models.ErrImportProfileNotFound), errors.Is(err, models.ErrTransactionNotFound):
+		return NewRequestError(http.StatusNotFound, err)
+	case errors.Is(err, importer.ErrStatementInvalid), errors.Is(err, importer.ErrStatementEmpty),
+		errors.Is(err, importer.ErrCSVProfileInvalid), errors.Is(err, models.ErrImportOffsetAccountInvalid),
+		errors.Is(err, models.ErrGnuCashAccountTypeInvalid), errors.Is(err, models.ErrGnuCashTransactionInvalid),
+		errors.Is(err, plaintext.ErrJournalInvalid),
+		errors.Is(err, models.ErrLedgerAccountTypeUnknown), errors.Is(err, models.ErrLedgerPostingInvalid),
+		errors.Is(err, models.ErrStatementMatchInvalid):
+		return NewRequestError(http.StatusBadRequest, err)
+	case errors.Is(err, models.ErrImportNotPreview):
+		return NewRequestError(http.StatusConflict, err)
+	}
+
+	return fmt.Errorf("import:%w", err)
+}
+
+// POST /imports/ofx, multipart form with file, accountID and offsetAccountID
+func PostImportOFX(importsCtl *ImportsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		upload, err := parseImportUpload(res, req)
+		if err != nil {
+			return err
+		}
+
+		myImport, err := importsCtl.ImportOFX(req.Context(), upload)
+		if err != nil {
+			return respondWithImportError(err)
+		}
+
+		return RespondOK(res, response.ImportToRespImport(myImport))
+	}
+}
+
+// POST /imports/csv, multipart form with file, accountID, profileID and offsetAccountID
+func PostImportCSV(importsCtl *ImportsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		upload, err := parseImportUpload(res, req)
+		if err != nil {
+			return err
+		}
+
+		profileID, err := parseProfileID(req.FormValue("profileID"))
+		if err != nil {
+			return err
+		}
+
+		myImport, err := importsCtl.ImportCSV(req.Context(), upload, profileID)
+		if err != nil {
+			return respondWithImportError(err)
+		}
+
+		return RespondOK(res, response.ImportToRespImport(myImport))
+	}
+}
+
+// POST /imports/qif, multipart form with file, accountID, offsetAccountID, categoryParentID and dayFirst for
+// files with day first dates
+func PostImportQIF(importsCtl *ImportsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		upload, err := parseImportUpload(res, req)
+		if err != nil {
+			return err
+		}
+
+		var dayFirst bool
+
+		if dayFirstStr := req.FormValue("dayFirst"); dayFirstStr != "" {
+			if dayFirst, err = strconv.ParseBool(dayFirstStr); err != nil {
+				return NewRequestError(http.StatusBadRequest, ErrInvalidDayFirst)
+			}
+		}
+
+		myImport, err := importsCtl.ImportQIF(req.Context(), upload, dayFirst)
+		if err != nil {
+			return respondWithImportError(err)
+		}
+
+		return RespondOK(res, response.ImportToRespImport(myImport))
+	}
+}
+
+// POST /imports/camt053, multipart form with file, accountID and offsetAccountID
+func PostImportCAMT053(importsCtl *ImportsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		upload, err := parseImportUpload(res, req)
+		if err != nil {
+			return err
+		}
+
+		myImport, err := importsCtl.ImportCAMT053(req.Context(), upload)
+		if err != nil {
+			return respondWithImportError(err)
+		}
+
+		return RespondOK(res, response.ImportToRespImport(myImport))
+	}
+}
+
+// POST /imports/mt940, multipart form with file, accountID and offsetAccountID
+func PostImportMT940(importsCtl *ImportsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		upload, err := parseImportUpload(res, req)
+		if err != nil {
+			return err
+		}
+
+		myImport, err := importsCtl.ImportMT940(req.Context(), upload)
+		if err != nil {
+			return respondWithImportError(err)
+		}
+
+		return RespondOK(res, response.ImportToRespImport(myImport))
+	}
+}
+
+// POST /imports/gnucash, multipart form with the GnuCash book in file
+func PostImportGnuCash(importsCtl *ImportsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		file, _, err := parseImportFile(res, req)
+		if err != nil {
+			return err
+		}
+
+		summary, err := importsCtl.ImportGnuCash(req.Context(), file)
+		if err != nil {
+			return respondWithImportError(err)
+		}
+
+		return RespondOK(res, response.GnuCashImportSummaryToResp(summary))
+	}
+}
+
+// POST /imports/ledger, multipart form with the ledger-cli or hledger journal in file
+func PostImportLedger(importsCtl *ImportsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		file, _, err := parseImportFile(res, req)
+		if err != nil {
+			return err
+		}
+
+		summary, err := importsCtl.ImportLedger(req.Context(), file)
+		if err != nil {
+			return respondWithImportError(err)
+		}
+
+		return RespondOK(res, response.LedgerImportSummaryToResp(summary))
+	}
+}
+
+// GET /imports
+func GetImports(importsCtl *ImportsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		imports, err := importsCtl.ImportList(req.Context())
+		if err != nil {
+			return NewRequestError(http.StatusServiceUnavailable, err)
+		}
+
+		return RespondOK(res, response.ConvertImportsToRespImportSet(imports))
+	}
+}
+
+// GET /imports/{importID}
+func GetImport(importsCtl *ImportsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		importID, err := parseImportID(req)
+		if err != nil {
+			return err
+		}
+
+		myImport, err := importsCtl.GetImportByID(req.Context(), importID)
+		if err != nil {
+			return respondWithImportError(err)
+		}
+
+		return RespondOK(res, response.ImportToRespImport(myImport))
+	}
+}
+
+// POST /imports/{importID}/confirm
+func PostImportConfirm(importsCtl *ImportsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		importID, err := parseImportID(req)
+		if err != nil {
+			return err
+		}
+
+		myImport, err := importsCtl.ConfirmImport(req.Context(), importID)
+		if err != nil {
+			return respondWithImportError(err)
+		}
+
+		return RespondOK(res, response.ImportToRespImport(myImport))
+	}
+}
+
+// parseDateTolerance reads ?dateTolerance=<days>, DefaultMatchDateTolerance when it is not set
+func parseDateTolerance(req *http.Request) (int, error) {
+	toleranceStr := req.URL.Query().Get("dateTolerance")
+	if toleranceStr == "" {
+		return models.DefaultMatchDateTolerance, nil
+	}
+
+	dateTolerance, err := strconv.Atoi(toleranceStr)
+	if err != nil || dateTolerance < 0 {
+		return 0, NewRequestError(http.StatusBadRequest, ErrInvalidDateTolerance)
+	}
+
+	return dateTolerance, nil
+}
+
+// GET /imports/{importID}/matches?dateTolerance=<days>, the unmatched lines of a preview paired with unreconciled
+// transactions already entered on the import account
+func GetImportMatches(importsCtl *ImportsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		importID, err := parseImportID(req)
+		if err != nil {
+			return err
+		}
+
+		dateTolerance, err := parseDateTolerance(req)
+		if err != nil {
+			return err
+		}
+
+		matches, err := importsCtl.GetImportMatches(req.Context(), importID, dateTolerance)
+		if err != nil {
+			return respondWithImportError(err)
+		}
+
+		return RespondOK(res, response.ConvertStatementMatchesToRespStatementMatchSet(importID, matches))
+	}
+}
+
+// POST /imports/{importID}/matches?dateTolerance=<days>, reconciles the confirmed matches and responds with the
+// lines left to post as new transactions
+func PostImportMatches(importsCtl *ImportsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		importID, err := parseImportID(req)
+		if err != nil {
+			return err
+		}
+
+		dateTolerance, err := parseDateTolerance(req)
+		if err != nil {
+			return err
+		}
+
+		var reqConfirm request.ConfirmMatches
+
+		if req.Body != nil {
+			if err = json.NewDecoder(req.Body).Decode(&reqConfirm); err != nil && !errors.Is(err, io.EOF) {
+				return NewRequestError(http.StatusBadRequest, err)
+			}
+		}
+
+		confirmations, reconcileDate := request.ReqConfirmMatchesToMatchConfirmations(&reqConfirm)
+
+		matches, err := importsCtl.ConfirmImportMatches(req.Context(), importID, confirmations, reconcileDate,
+			dateTolerance)
+		if err != nil {
+			return respondWithImportError(err)
+		}
+
+		return RespondOK(res, response.ConvertStatementMatchesToRespStatementMatchSet(importID, matches))
+	}
+}
+
+// DELETE /imports/{importID}
+func DeleteImport(importsCtl *ImportsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		importID, err := parseImportID(req)
+		if err != nil {
+			return err
+		}
+
+		myImport, err := importsCtl.CancelImport(req.Context(), importID)
+		if err != nil {
+			return respondWithImportError(err)
+		}
+
+		return RespondOK(res, response.ImportToRespImport(myImport))
+	}
+}
+
+// GET /imports/profiles
+func GetImportProfiles(importsCtl *ImportsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		profiles, err := importsCtl.ProfileList(req.Context())
+		if err != nil {
+			return NewRequestError(http.StatusServiceUnavailable, err)
+		}
+
+		return RespondOK(res, response.ConvertImportProfilesToRespImportProfileSet(profiles))
+	}
+}
+
+// GET /imports/profiles/{profileID}
+func GetImportProfile(importsCtl *ImportsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		profileID, err := parseProfileID(chi.URLParam(req, "profileID"))
+		if err != nil {
+			return err
+		}
+
+		myProfile, err := importsCtl.GetProfileByID(req.Context(), profileID)
+		if err != nil {
+			return respondWithImportError(err)
+		}
+
+		return RespondOK(res, response.ImportProfileToRespImportProfile(myProfile))
+	}
+}
+
+// POST /imports/profiles
+func PostImportProfiles(importsCtl *ImportsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		if req.Body == nil {
+			return NewRequestError(http.StatusBadRequest, ErrNoRequestBody)
+		}
+
+		var reqProfile request.ImportProfile
+
+		if err := json.NewDecoder(req.Body).Decode(&reqProfile); err != nil {
+			return fmt.Errorf("json.NewDecoder(r.Body).Decode:%w", err)
+		}
+
+		myProfile, err := importsCtl.CreateProfile(req.Context(), request.ReqImportProfileToImportProfile(&reqProfile))
+		if err != nil {
+			return respondWithProfileError(err)
+		}
+
+		return RespondOK(res, response.ImportProfileToRespImportProfile(myProfile))
+	}
+}
+
+// PUT /imports/profiles/{profileID}
+func PutImportProfileUpdate(importsCtl *ImportsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		profileID, err := parseProfileID(chi.URLParam(req, "profileID"))
+		if err != nil {
+			return err
+		}
+
+		if req.Body == nil {
+			return NewRequestError(http.StatusBadRequest, ErrNoRequestBody)
+		}
+
+		var reqProfile request.ImportProfile
+
+		if err = json.NewDecoder(req.Body).Decode(&reqProfile); err != nil {
+			return fmt.Errorf("json.NewDecoder(r.Body).Decode:%w", err)
+		}
+
+		mdlProfile := request.ReqImportProfileToImportProfile(&reqProfile)
+		mdlProfile.ProfileID = profileID
+
+		myProfile, err := importsCtl.UpdateProfile(req.Context(), mdlProfile)
+		if err != nil {
+			return respondWithProfileError(err)
+		}
+
+		return RespondOK(res, response.ImportProfileToRespImportProfile(myProfile))
+	}
+}
+
+// DELETE /imports/profiles/{profileID}
+func DeleteImportProfile(importsCtl *ImportsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		profileID, err := parseProfileID(chi.URLParam(req, "profileID"))
+		if err != nil {
+			return err
+		}
+
+		myProfile, err := importsCtl.DeleteProfile(req.Context(), profileID)
+		if err != nil {
+			return respondWithImportError(err)
+		}
+
+		return RespondOK(res, response.ImportProfileToRespImportProfile(myProfile))
+	}
+}
+
+// respondWithProfileError maps the errors of storing a profile, any other error is an invalid profile
+func respondWithProfileError(err error) error {
+	if errors.Is(err, models.ErrImportProfileNotFound) {
+		return NewRequestError(http.StatusNotFound, err)
+	}
+
+	return NewRequestError(http.StatusBadRequest, err)
+}
@@ -0,0 +1,460 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+	"github.com/mimirsoft/mimirledger/api/web/request"
+	"github.com/mimirsoft/mimirledger/api/web/response"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+// postImportFile posts a statement file as a multipart form, with fields as the other form values
+func postImportFile(g *gomega.WithT, requestURL, filename string, fields map[string]string) *httptest.ResponseRecorder {
+	body, err := os.ReadFile(filename)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	var form bytes.Buffer
+
+	writer := multipart.NewWriter(&form)
+
+	part, err := writer.CreateFormFile("file", filename)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	_, err = part.Write(body)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	for key, value := range fields {
+		g.Expect(writer.WriteField(key, value)).To(gomega.Succeed())
+	}
+
+	g.Expect(writer.Close()).To(gomega.Succeed())
+
+	request := httptest.NewRequest(http.MethodPost, requestURL, &form)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+
+	recorder := httptest.NewRecorder()
+	TestRouter.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func TestImports_PostImportOFX(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	checking := models.Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	expense := models.Account{AccountName: "Expense", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeExpense}
+	err = expense.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// missing account
+	recorder := postImportFile(g, "/imports/ofx", "../importer/testdata/bank_v1.ofx", map[string]string{})
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusBadRequest))
+
+	// not an OFX file
+	recorder = postImportFile(g, "/imports/ofx", "imports_test.go",
+		map[string]string{"accountID": fmt.Sprint(checking.AccountID)})
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusBadRequest))
+
+	recorder = postImportFile(g, "/imports/ofx", "../importer/testdata/bank_v1.ofx",
+		map[string]string{"accountID": fmt.Sprint(checking.AccountID),
+			"offsetAccountID": fmt.Sprint(expense.AccountID)})
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusOK))
+
+	var preview response.Import
+	g.Expect(json.Unmarshal(recorder.Body.Bytes(), &preview)).To(gomega.Succeed())
+	g.Expect(preview.ImportStatus).To(gomega.Equal(datastore.ImportStatusPreview))
+	g.Expect(preview.ImportFormat).To(gomega.Equal("OFX"))
+	g.Expect(preview.OffsetAccountID).To(gomega.Equal(expense.AccountID))
+	g.Expect(preview.Lines).To(gomega.HaveLen(3))
+	g.Expect(preview.Lines[1].LineAmount).To(gomega.Equal(int64(-120000)))
+	g.Expect(preview.Lines[1].LineReference).To(gomega.Equal("1042"))
+
+	var importSet response.ImportSet
+	test := RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: "/imports",
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&importSet)
+	g.Expect(importSet.Imports).To(gomega.HaveLen(1))
+
+	var posted response.Import
+	test = RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/imports/%d/confirm", preview.ImportID),
+		Payload:    http.NoBody,
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&posted)
+	g.Expect(posted.ImportStatus).To(gomega.Equal(datastore.ImportStatusPosted))
+
+	for _, line := range posted.Lines {
+		g.Expect(line.TransactionID).NotTo(gomega.BeZero())
+	}
+
+	// confirming twice is a conflict, as is cancelling a posted import
+	test = RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/imports/%d/confirm", preview.ImportID),
+		Payload:    http.NoBody,
+	}, GomegaWithT: g, Code: http.StatusConflict}
+	test.Exec()
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodDelete,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/imports/%d", preview.ImportID),
+	}, GomegaWithT: g, Code: http.StatusConflict}
+	test.Exec()
+
+	// the same file again is all duplicates
+	recorder = postImportFile(g, "/imports/ofx", "../importer/testdata/bank_v1.ofx",
+		map[string]string{"accountID": fmt.Sprint(checking.AccountID),
+			"offsetAccountID": fmt.Sprint(expense.AccountID)})
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusOK))
+
+	var duplicate response.Import
+	g.Expect(json.Unmarshal(recorder.Body.Bytes(), &duplicate)).To(gomega.Succeed())
+
+	for _, line := range duplicate.Lines {
+		g.Expect(line.IsDuplicate).To(gomega.BeTrue())
+	}
+
+	var cancelled response.Import
+	test = RouterTest{Request: Request{
+		Method:     http.MethodDelete,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/imports/%d", duplicate.ImportID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&cancelled)
+	g.Expect(cancelled.ImportStatus).To(gomega.Equal(datastore.ImportStatusCancelled))
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: "/imports/99999999",
+	}, GomegaWithT: g, Code: http.StatusNotFound}
+	test.Exec()
+}
+
+func TestImports_PostImportCSV(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	checking := models.Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	expense := models.Account{AccountName: "Expense", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeExpense}
+	err = expense.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// a profile needs a date and an amount
+	test := RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: "/imports/profiles",
+		Payload:    request.ImportProfile{ProfileName: "Broken", DateColumn: 1},
+	}, GomegaWithT: g, Code: http.StatusBadRequest}
+	test.Exec()
+
+	var profile response.ImportProfile
+	test = RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: "/imports/profiles",
+		Payload: request.ImportProfile{ProfileName: "My Bank", OffsetAccountID: expense.AccountID,
+			Delimiter: ";", SkipRows: 2, DateColumn: 1, DescriptionColumn: 2, ReferenceColumn: 3, DebitColumn: 4,
+			CreditColumn: 5, DateFormat: "DD/MM/YYYY", DecimalSeparator: ","},
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&profile)
+	g.Expect(profile.ProfileID).NotTo(gomega.BeZero())
+	g.Expect(profile.DebitColumn).To(gomega.Equal(4))
+
+	var profileSet response.ImportProfileSet
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: "/imports/profiles",
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&profileSet)
+	g.Expect(profileSet.Profiles).To(gomega.HaveLen(1))
+
+	recorder := postImportFile(g, "/imports/csv", "../importer/testdata/bank_debit_credit.csv",
+		map[string]string{"accountID": fmt.Sprint(checking.AccountID), "profileID": "99999999"})
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusNotFound))
+
+	// the offset account comes from the profile, and the bad rows are reported
+	recorder = postImportFile(g, "/imports/csv", "../importer/testdata/bank_debit_credit.csv",
+		map[string]string{"accountID": fmt.Sprint(checking.AccountID),
+			"profileID": fmt.Sprint(profile.ProfileID)})
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusOK))
+
+	var preview response.Import
+	g.Expect(json.Unmarshal(recorder.Body.Bytes(), &preview)).To(gomega.Succeed())
+	g.Expect(preview.ImportFormat).To(gomega.Equal("CSV"))
+	g.Expect(preview.OffsetAccountID).To(gomega.Equal(expense.AccountID))
+	g.Expect(preview.Lines).To(gomega.HaveLen(3))
+	g.Expect(preview.Errors).To(gomega.HaveLen(2))
+	g.Expect(preview.Errors[0].RowNumber).To(gomega.Equal(5))
+	g.Expect(preview.Errors[1].RowNumber).To(gomega.Equal(6))
+
+	var posted response.Import
+	test = RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/imports/%d/confirm", preview.ImportID),
+		Payload:    http.NoBody,
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&posted)
+	g.Expect(posted.ImportStatus).To(gomega.Equal(datastore.ImportStatusPosted))
+
+	myAccount, err := models.RetrieveAccountByID(TestDataStore, expense.AccountID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myAccount.AccountBalance).To(gomega.Equal(int64(4599 - 250000 - 1250)))
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodDelete,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/imports/profiles/%d", profile.ProfileID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.Exec()
+}
+
+func TestImports_PostImportQIF(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	checking := models.Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	categoriesParent := models.Account{AccountName: "Quicken", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeExpense}
+	err = categoriesParent.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	recorder := postImportFile(g, "/imports/qif", "../importer/testdata/quicken.qif",
+		map[string]string{"accountID": fmt.Sprint(checking.AccountID), "dayFirst": "maybe"})
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusBadRequest))
+
+	recorder = postImportFile(g, "/imports/qif", "../importer/testdata/quicken.qif",
+		map[string]string{"accountID": fmt.Sprint(checking.AccountID),
+			"categoryParentID": fmt.Sprint(categoriesParent.AccountID)})
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusOK))
+
+	var preview response.Import
+	g.Expect(json.Unmarshal(recorder.Body.Bytes(), &preview)).To(gomega.Succeed())
+	g.Expect(preview.ImportFormat).To(gomega.Equal("QIF"))
+	g.Expect(preview.CategoryParentID).To(gomega.Equal(categoriesParent.AccountID))
+	g.Expect(preview.Categories).To(gomega.HaveLen(3))
+	g.Expect(preview.Lines).To(gomega.HaveLen(4))
+	g.Expect(preview.Lines[0].LineCategory).To(gomega.Equal("Housing:Rent"))
+	g.Expect(preview.Lines[1].LineSplits).To(gomega.HaveLen(2))
+	g.Expect(preview.Errors).To(gomega.HaveLen(2))
+
+	var posted response.Import
+	test := RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/imports/%d/confirm", preview.ImportID),
+		Payload:    http.NoBody,
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&posted)
+	g.Expect(posted.ImportStatus).To(gomega.Equal(datastore.ImportStatusPosted))
+
+	var accountSet response.AccountSet
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: "/accounts",
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&accountSet)
+
+	names := make([]string, 0, len(accountSet.Accounts))
+	for _, account := range accountSet.Accounts {
+		names = append(names, account.AccountFullName)
+	}
+
+	g.Expect(names).To(gomega.ContainElements("Quicken:Auto:Fuel", "Quicken:Housing:Rent", "Quicken:Groceries"))
+}
+
+func TestImports_PostImportCAMT053AndMT940(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	checking := models.Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// not a camt.053 file
+	recorder := postImportFile(g, "/imports/camt053", "../importer/testdata/statement.mt940",
+		map[string]string{"accountID": fmt.Sprint(checking.AccountID)})
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusBadRequest))
+
+	recorder = postImportFile(g, "/imports/camt053", "../importer/testdata/statement.camt053.xml",
+		map[string]string{"accountID": fmt.Sprint(checking.AccountID)})
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusOK))
+
+	var preview response.Import
+	g.Expect(json.Unmarshal(recorder.Body.Bytes(), &preview)).To(gomega.Succeed())
+	g.Expect(preview.ImportFormat).To(gomega.Equal("CAMT053"))
+	g.Expect(preview.OpeningBalance.Balance).To(gomega.Equal(int64(100000)))
+	g.Expect(preview.ClosingBalance.Balance).To(gomega.Equal(int64(311250)))
+	g.Expect(preview.Lines).To(gomega.HaveLen(4))
+	g.Expect(preview.Lines[0].LineValueDate).NotTo(gomega.BeNil())
+	g.Expect(preview.Lines[0].LineValueDate.Day()).To(gomega.Equal(3))
+
+	var posted response.Import
+	test := RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/imports/%d/confirm", preview.ImportID),
+		Payload:    http.NoBody,
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&posted)
+	g.Expect(posted.ImportStatus).To(gomega.Equal(datastore.ImportStatusPosted))
+	g.Expect(posted.ClosingBalance.Balance).To(gomega.Equal(int64(311250)))
+
+	recorder = postImportFile(g, "/imports/mt940", "../importer/testdata/statement.mt940",
+		map[string]string{"accountID": fmt.Sprint(checking.AccountID)})
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusOK))
+
+	var mt940Preview response.Import
+	g.Expect(json.Unmarshal(recorder.Body.Bytes(), &mt940Preview)).To(gomega.Succeed())
+	g.Expect(mt940Preview.ImportFormat).To(gomega.Equal("MT940"))
+	g.Expect(mt940Preview.ClosingBalance.Balance).To(gomega.Equal(int64(361250)))
+	g.Expect(mt940Preview.Lines).To(gomega.HaveLen(3))
+	// the salary has the same bank reference as the one posted by the camt.053 import
+	g.Expect(mt940Preview.Lines[0].IsDuplicate).To(gomega.BeTrue())
+	g.Expect(mt940Preview.Lines[2].IsDuplicate).To(gomega.BeFalse())
+	g.Expect(mt940Preview.Errors).To(gomega.HaveLen(1))
+}
+
+func TestImports_PostImportGnuCash(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	recorder := postImportFile(g, "/imports/gnucash", "../importer/testdata/bank_v1.ofx", map[string]string{})
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusBadRequest))
+
+	recorder = postImportFile(g, "/imports/gnucash", "../importer/testdata/book.gnucash", map[string]string{})
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusOK))
+
+	var summary response.GnuCashImportSummary
+	g.Expect(json.Unmarshal(recorder.Body.Bytes(), &summary)).To(gomega.Succeed())
+	g.Expect(summary.AccountsCreated).To(gomega.Equal(5))
+	g.Expect(summary.TransactionsImported).To(gomega.Equal(3))
+	g.Expect(summary.TransactionsSkipped).To(gomega.Equal(1))
+	g.Expect(summary.Accounts).To(gomega.HaveLen(5))
+	g.Expect(summary.Balanced).To(gomega.BeTrue())
+}
+
+func TestImports_ImportMatches(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	checking := models.Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	expense := models.Account{AccountName: "Expense", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeExpense}
+	err = expense.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// the rent check was entered by hand when it was written
+	rent := models.Transaction{TransactionCore: models.TransactionCore{TransactionComment: "Rent",
+		TransactionReference: "1042", TransactionDate: time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC)},
+		DebitCreditSet: []*models.TransactionDebitCredit{
+			{AccountID: expense.AccountID, DebitOrCredit: datastore.AccountSignDebit, TransactionDCAmount: 120000},
+			{AccountID: checking.AccountID, DebitOrCredit: datastore.AccountSignCredit, TransactionDCAmount: 120000},
+		},
+	}
+	err = rent.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	recorder := postImportFile(g, "/imports/ofx", "../importer/testdata/bank_v1.ofx",
+		map[string]string{"accountID": fmt.Sprint(checking.AccountID),
+			"offsetAccountID": fmt.Sprint(expense.AccountID)})
+	g.Expect(recorder.Code).To(gomega.Equal(http.StatusOK))
+
+	var preview response.Import
+	g.Expect(json.Unmarshal(recorder.Body.Bytes(), &preview)).To(gomega.Succeed())
+
+	NewRouterTableTest([]RouterTest{
+		{Request: Request{Method: http.MethodGet, Router: TestRouter,
+			RequestURL: fmt.Sprintf("/imports/%d/matches?dateTolerance=soon", preview.ImportID)},
+			GomegaWithT: g, Code: http.StatusBadRequest, RespBody: ErrInvalidDateTolerance.Error()},
+		{Request: Request{Method: http.MethodPost, Router: TestRouter,
+			RequestURL: fmt.Sprintf("/imports/%d/matches", preview.ImportID),
+			Payload: M{"matches": []M{{"importLineID": preview.Lines[0].ImportLineID,
+				"transactionID": rent.TransactionID}}}},
+			GomegaWithT: g, Code: http.StatusBadRequest, RespBody: models.ErrStatementMatchInvalid.Error()},
+	}).Exec()
+
+	var matchSet response.StatementMatchSet
+	test := RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/imports/%d/matches", preview.ImportID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&matchSet)
+	g.Expect(matchSet.Matches).To(gomega.HaveLen(3))
+	g.Expect(matchSet.Matches[1].TransactionID).To(gomega.Equal(rent.TransactionID))
+	g.Expect(matchSet.Matches[1].Candidates[0].TransactionReference).To(gomega.Equal("1042"))
+
+	// a tolerance of a day leaves the check two days early unmatched
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/imports/%d/matches?dateTolerance=1", preview.ImportID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&matchSet)
+	g.Expect(matchSet.Matches[1].TransactionID).To(gomega.BeZero())
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/imports/%d/matches", preview.ImportID),
+		Payload: M{"matches": []M{{"importLineID": preview.Lines[1].ImportLineID,
+			"transactionID": rent.TransactionID}}, "reconcileDate": "2024-05-31T00:00:00Z"},
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&matchSet)
+	g.Expect(matchSet.Matches).To(gomega.HaveLen(2))
+
+	reconciled, err := models.RetrieveTransactionByID(TestDataStore, rent.TransactionID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(reconciled.IsReconciledOnAccount(TestDataStore, &checking)).To(gomega.BeTrue())
+	g.Expect(reconciled.TransactionReconcileDate.Time.Equal(time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC))).
+		To(gomega.BeTrue())
+}
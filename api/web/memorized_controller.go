@@ -0,0 +1,105 @@
+package web
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// MemorizedController is the controller struct for memorized transactions
+type MemorizedController struct {
+	DataStores *datastore.Datastores
+}
+
+// NewMemorizedController instantiates a new MemorizedController struct
+func NewMemorizedController(ds *datastore.Datastores) *MemorizedController {
+	return &MemorizedController{
+		DataStores: ds,
+	}
+}
+
+// GET /transactions/templates
+func (mc *MemorizedController) MemorizedList(_ context.Context) ([]*models.MemorizedTransaction, error) {
+	memorizedSet, err := models.RetrieveMemorizedTransactions(mc.DataStores)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveMemorizedTransactions:%w", err)
+	}
+
+	return memorizedSet, nil
+}
+
+// GET /transactions/templates/{memorizedID}
+func (mc *MemorizedController) GetMemorizedByID(_ context.Context,
+	memorizedID uint64) (*models.MemorizedTransaction, error) {
+	memorized, err := models.RetrieveMemorizedTransactionByID(mc.DataStores, memorizedID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveMemorizedTransactionByID:%w", err)
+	}
+
+	return memorized, nil
+}
+
+// POST /transactions/templates
+func (mc *MemorizedController) CreateMemorized(_ context.Context,
+	memorized *models.MemorizedTransaction) (*models.MemorizedTransaction, error) {
+	if err := memorized.Store(mc.DataStores); err != nil {
+		return nil, fmt.Errorf("memorized.Store:%w", err)
+	}
+
+	return memorized, nil
+}
+
+// PUT /transactions/templates/{memorizedID}
+func (mc *MemorizedController) UpdateMemorized(_ context.Context,
+	memorized *models.MemorizedTransaction) (*models.MemorizedTransaction, error) {
+	if err := memorized.Update(mc.DataStores); err != nil {
+		return nil, fmt.Errorf("memorized.Update:%w", err)
+	}
+
+	return memorized, nil
+}
+
+// DELETE /transactions/templates/{memorizedID}
+func (mc *MemorizedController) DeleteMemorized(_ context.Context,
+	memorizedID uint64) (*models.MemorizedTransaction, error) {
+	memorized, err := models.RetrieveMemorizedTransactionByID(mc.DataStores, memorizedID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveMemorizedTransactionByID:%w", err)
+	}
+
+	if err = memorized.Delete(mc.DataStores); err != nil {
+		return nil, fmt.Errorf("memorized.Delete:%w", err)
+	}
+
+	return memorized, nil
+}
+
+// POST /transactions/from-template/{memorizedID}, the transaction is only built and validated with dryRun
+func (mc *MemorizedController) CreateFromMemorized(_ context.Context, memorizedID uint64,
+	params *models.MemorizedParameters, dryRun bool) (*models.Transaction, error) {
+	memorized, err := models.RetrieveMemorizedTransactionByID(mc.DataStores, memorizedID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveMemorizedTransactionByID:%w", err)
+	}
+
+	myTxn, err := memorized.Transaction(params)
+	if err != nil {
+		return nil, fmt.Errorf("memorized.Transaction:%w", err)
+	}
+
+	if dryRun {
+		return myTxn, nil
+	}
+
+	if err = myTxn.Store(mc.DataStores); err != nil {
+		return nil, fmt.Errorf("myTxn.Store:%w", err)
+	}
+
+	if _, err = models.DetectDuplicates(mc.DataStores, myTxn); err != nil {
+		return nil, fmt.Errorf("models.DetectDuplicates:%w", err)
+	}
+
+	return myTxn, nil
+}
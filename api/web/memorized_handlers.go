@@ -0,0 +1,184 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mimirsoft/mimirledger/api/models"
+	"github.com/mimirsoft/mimirledger/api/web/request"
+	"github.com/mimirsoft/mimirledger/api/web/response"
+)
+
+var ErrInvalidMemorizedID = errors.New("invalid memorizedID request parameter")
+
+func parseMemorizedID(req *http.Request) (uint64, error) {
+	memorizedID, err := strconv.ParseUint(chi.URLParam(req, "memorizedID"), 10, 64)
+	if err != nil || memorizedID == 0 {
+		return 0, NewRequestError(http.StatusBadRequest, ErrInvalidMemorizedID)
+	}
+
+	return memorizedID, nil
+}
+
+// respondWithMemorizedError maps the errors of memorized transactions to a status
+func respondWithMemorizedError(err error) error {
+	switch {
+	case errors.Is(err, models.ErrMemorizedTransactionNotFound):
+		return NewRequestError(http.StatusNotFound, err)
+	case errors.Is(err, models.ErrMemorizedTransactionInvalid), errors.Is(err, models.ErrMemorizedParametersInvalid),
+		errors.Is(err, models.ErrAccountNotFound):
+		return NewRequestError(http.StatusBadRequest, err)
+	}
+
+	return fmt.Errorf("memorized:%w", err)
+}
+
+// parseMemorizedTransaction reads the body of a create or update
+func parseMemorizedTransaction(req *http.Request) (*models.MemorizedTransaction, error) {
+	if req.Body == nil {
+		return nil, NewRequestError(http.StatusBadRequest, ErrNoRequestBody)
+	}
+
+	var reqMemorized request.MemorizedTransaction
+
+	if err := json.NewDecoder(req.Body).Decode(&reqMemorized); err != nil {
+		return nil, fmt.Errorf("json.NewDecoder(r.Body).Decode:%w", err)
+	}
+
+	return request.ReqMemorizedTransactionToMemorizedTransaction(&reqMemorized), nil
+}
+
+// GET /transactions/templates
+func GetMemorizedTransactions(memorizedCtl *MemorizedController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		memorizedSet, err := memorizedCtl.MemorizedList(req.Context())
+		if err != nil {
+			return NewRequestError(http.StatusServiceUnavailable, err)
+		}
+
+		return RespondOK(res, response.ConvertMemorizedTransactionsToRespMemorizedTransactionSet(memorizedSet))
+	}
+}
+
+// GET /transactions/templates/{memorizedID}
+func GetMemorizedTransaction(memorizedCtl *MemorizedController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		memorizedID, err := parseMemorizedID(req)
+		if err != nil {
+			return err
+		}
+
+		memorized, err := memorizedCtl.GetMemorizedByID(req.Context(), memorizedID)
+		if err != nil {
+			return respondWithMemorizedError(err)
+		}
+
+		return RespondOK(res, response.MemorizedTransactionToRespMemorizedTransaction(memorized))
+	}
+}
+
+// POST /transactions/templates
+func PostMemorizedTransactions(memorizedCtl *MemorizedController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		mdlMemorized, err := parseMemorizedTransaction(req)
+		if err != nil {
+			return err
+		}
+
+		memorized, err := memorizedCtl.CreateMemorized(req.Context(), mdlMemorized)
+		if err != nil {
+			return respondWithMemorizedError(err)
+		}
+
+		return RespondOK(res, response.MemorizedTransactionToRespMemorizedTransaction(memorized))
+	}
+}
+
+// PUT /transactions/templates/{memorizedID}
+func PutMemorizedTransactionUpdate(memorizedCtl *MemorizedController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		memorizedID, err := parseMemorizedID(req)
+		if err != nil {
+			return err
+		}
+
+		mdlMemorized, err := parseMemorizedTransaction(req)
+		if err != nil {
+			return err
+		}
+
+		mdlMemorized.MemorizedID = memorizedID
+
+		memorized, err := memorizedCtl.UpdateMemorized(req.Context(), mdlMemorized)
+		if err != nil {
+			return respondWithMemorizedError(err)
+		}
+
+		return RespondOK(res, response.MemorizedTransactionToRespMemorizedTransaction(memorized))
+	}
+}
+
+// DELETE /transactions/templates/{memorizedID}, the transactions made from it are kept
+func DeleteMemorizedTransaction(memorizedCtl *MemorizedController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		memorizedID, err := parseMemorizedID(req)
+		if err != nil {
+			return err
+		}
+
+		memorized, err := memorizedCtl.DeleteMemorized(req.Context(), memorizedID)
+		if err != nil {
+			return respondWithMemorizedError(err)
+		}
+
+		return RespondOK(res, response.MemorizedTransactionToRespMemorizedTransaction(memorized))
+	}
+}
+
+// POST /transactions/from-template/{memorizedID}?dryRun=true, the body is the date, the amounts by the parameterName
+// of the lines, and optionally a comment and reference.  The transaction is built and stored, with dryRun it is only
+// built and validated.
+func PostTransactionFromMemorized(memorizedCtl *MemorizedController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		memorizedID, err := parseMemorizedID(req)
+		if err != nil {
+			return err
+		}
+
+		var dryRun bool
+
+		if dryRunStr := req.URL.Query().Get("dryRun"); dryRunStr != "" {
+			if dryRun, err = strconv.ParseBool(dryRunStr); err != nil {
+				return NewRequestError(http.StatusBadRequest, ErrInvalidDryRun)
+			}
+		}
+
+		if req.Body == nil {
+			return NewRequestError(http.StatusBadRequest, ErrNoRequestBody)
+		}
+
+		var reqParams request.MemorizedParameters
+
+		if err = json.NewDecoder(req.Body).Decode(&reqParams); err != nil {
+			return fmt.Errorf("json.NewDecoder(r.Body).Decode:%w", err)
+		}
+
+		myTxn, err := memorizedCtl.CreateFromMemorized(req.Context(), memorizedID,
+			request.ReqMemorizedParametersToMemorizedParameters(&reqParams), dryRun)
+		if err != nil {
+			return respondWithMemorizedError(err)
+		}
+
+		return RespondOK(res, response.TransactionToRespTransaction(myTxn))
+	}
+}
@@ -0,0 +1,137 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+	"github.com/mimirsoft/mimirledger/api/web/response"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestMemorized_FromTemplate(t *testing.T) { //nolint:funlen
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	checking := models.Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	payroll := models.Account{AccountName: "Payroll", AccountSign: datastore.AccountSignCredit,
+		AccountType: datastore.AccountTypeIncome}
+	err = payroll.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	taxes := models.Account{AccountName: "Taxes", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeExpense}
+	err = taxes.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	lines := []M{
+		{"accountID": payroll.AccountID, "debitOrCredit": "CREDIT", "lineType": "AMOUNT", "parameterName": "gross"},
+		{"accountID": taxes.AccountID, "debitOrCredit": "DEBIT", "lineType": "PERCENT", "percent": 20,
+			"parameterName": "gross"},
+		{"accountID": checking.AccountID, "debitOrCredit": "DEBIT", "lineType": "BALANCE"},
+	}
+
+	NewRouterTableTest([]RouterTest{
+		{Request: Request{
+			Method:     http.MethodPost,
+			Router:     TestRouter,
+			RequestURL: "/transactions/templates",
+			Payload:    M{"memorizedName": "payroll split", "transactionComment": "pay", "lines": lines[:1]},
+		}, GomegaWithT: g, Code: http.StatusBadRequest, RespBody: models.ErrMemorizedTransactionInvalid.Error()},
+		{Request: Request{
+			Method:     http.MethodGet,
+			Router:     TestRouter,
+			RequestURL: "/transactions/templates/abc",
+		}, GomegaWithT: g, Code: http.StatusBadRequest, RespBody: ErrInvalidMemorizedID.Error()},
+		{Request: Request{
+			Method:     http.MethodGet,
+			Router:     TestRouter,
+			RequestURL: "/transactions/templates/999999",
+		}, GomegaWithT: g, Code: http.StatusNotFound},
+	}).Exec()
+
+	test := RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: "/transactions/templates",
+		Payload:    M{"memorizedName": "payroll split", "transactionComment": "pay", "lines": lines},
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var created response.MemorizedTransaction
+	test.ExecWithUnmarshal(&created)
+	g.Expect(created.MemorizedID).NotTo(gomega.BeZero())
+	g.Expect(created.Lines).To(gomega.HaveLen(3))
+
+	fromTemplateURL := fmt.Sprintf("/transactions/from-template/%d", created.MemorizedID)
+	params := M{"transactionDate": "2024-03-15T00:00:00Z", "amounts": M{"gross": 300000}}
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: fromTemplateURL + "?dryRun=true",
+		Payload:    params,
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var built response.Transaction
+	test.ExecWithUnmarshal(&built)
+	g.Expect(built.TransactionID).To(gomega.BeZero())
+	g.Expect(built.TransactionAmount).To(gomega.Equal(uint64(300000)))
+
+	amounts := map[uint64]uint64{}
+	for _, debitCredit := range built.DebitCreditSet {
+		amounts[debitCredit.AccountID] = debitCredit.TransactionDCAmount
+	}
+
+	g.Expect(amounts).To(gomega.Equal(map[uint64]uint64{payroll.AccountID: 300000, taxes.AccountID: 60000,
+		checking.AccountID: 240000}))
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: fromTemplateURL,
+		Payload:    params,
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var stored response.Transaction
+	test.ExecWithUnmarshal(&stored)
+	g.Expect(stored.TransactionID).NotTo(gomega.BeZero())
+	g.Expect(stored.TransactionComment).To(gomega.Equal("pay"))
+
+	myTxn, err := models.RetrieveTransactionByID(TestDataStore, stored.TransactionID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(myTxn.DebitCreditSet).To(gomega.HaveLen(3))
+
+	NewRouterTableTest([]RouterTest{
+		{Request: Request{
+			Method:     http.MethodPost,
+			Router:     TestRouter,
+			RequestURL: fromTemplateURL,
+			Payload:    M{"transactionDate": "2024-03-15T00:00:00Z", "amounts": M{"net": 300000}},
+		}, GomegaWithT: g, Code: http.StatusBadRequest, RespBody: models.ErrMemorizedParametersInvalid.Error()},
+		{Request: Request{
+			Method:     http.MethodPost,
+			Router:     TestRouter,
+			RequestURL: fromTemplateURL + "?dryRun=maybe",
+			Payload:    params,
+		}, GomegaWithT: g, Code: http.StatusBadRequest, RespBody: ErrInvalidDryRun.Error()},
+		{Request: Request{
+			Method:     http.MethodDelete,
+			Router:     TestRouter,
+			RequestURL: fmt.Sprintf("/transactions/templates/%d", created.MemorizedID),
+		}, GomegaWithT: g, Code: http.StatusOK},
+		{Request: Request{
+			Method:     http.MethodPost,
+			Router:     TestRouter,
+			RequestURL: fromTemplateURL,
+			Payload:    params,
+		}, GomegaWithT: g, Code: http.StatusNotFound},
+	}).Exec()
+}
@@ -0,0 +1,138 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// ReconciliationsController is the controller struct for statement reconciliation sessions
+type ReconciliationsController struct {
+	DataStores *datastore.Datastores
+}
+
+// NewReconciliationsController instantiates a new ReconciliationsController struct
+func NewReconciliationsController(ds *datastore.Datastores) *ReconciliationsController {
+	return &ReconciliationsController{
+		DataStores: ds,
+	}
+}
+
+// GET /reconciliations?accountID=<id>&status=<status>
+func (rc *ReconciliationsController) SessionList(_ context.Context, accountID uint64,
+	status datastore.ReconciliationStatus) ([]*models.ReconciliationSession, error) {
+	sessions, err := models.RetrieveReconciliationSessions(rc.DataStores, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveReconciliationSessions:%w", err)
+	}
+
+	if status == "" {
+		return sessions, nil
+	}
+
+	filtered := make([]*models.ReconciliationSession, 0, len(sessions))
+
+	for _, session := range sessions {
+		if session.SessionStatus == status {
+			filtered = append(filtered, session)
+		}
+	}
+
+	return filtered, nil
+}
+
+// GET /reconciliations/{sessionID}
+func (rc *ReconciliationsController) GetSessionByID(_ context.Context,
+	sessionID uint64) (*models.ReconciliationSession, error) {
+	session, err := models.RetrieveReconciliationSessionByID(rc.DataStores, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveReconciliationSessionByID:%w", err)
+	}
+
+	return session, nil
+}
+
+// POST /reconciliations
+func (rc *ReconciliationsController) CreateSession(_ context.Context, accountID uint64, statementDate time.Time,
+	statementBalance int64) (*models.ReconciliationSession, error) {
+	session, err := models.NewReconciliationSession(rc.DataStores, accountID, statementDate, statementBalance)
+	if err != nil {
+		return nil, fmt.Errorf("models.NewReconciliationSession:%w", err)
+	}
+
+	return session, nil
+}
+
+// DELETE /reconciliations/{sessionID}
+func (rc *ReconciliationsController) DeleteSession(_ context.Context,
+	sessionID uint64) (*models.ReconciliationSession, error) {
+	session, err := models.RetrieveReconciliationSessionByID(rc.DataStores, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveReconciliationSessionByID:%w", err)
+	}
+
+	if err = session.Delete(rc.DataStores); err != nil {
+		return nil, fmt.Errorf("session.Delete:%w", err)
+	}
+
+	return session, nil
+}
+
+// PUT /reconciliations/{sessionID}/transactions/{transactionID}
+func (rc *ReconciliationsController) TickTransaction(_ context.Context, sessionID,
+	transactionID uint64) (*models.ReconciliationSession, error) {
+	session, err := models.RetrieveReconciliationSessionByID(rc.DataStores, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveReconciliationSessionByID:%w", err)
+	}
+
+	if err = session.TickTransaction(rc.DataStores, transactionID); err != nil {
+		return nil, fmt.Errorf("session.TickTransaction:%w", err)
+	}
+
+	return session, nil
+}
+
+// DELETE /reconciliations/{sessionID}/transactions/{transactionID}
+func (rc *ReconciliationsController) UntickTransaction(_ context.Context, sessionID,
+	transactionID uint64) (*models.ReconciliationSession, error) {
+	session, err := models.RetrieveReconciliationSessionByID(rc.DataStores, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveReconciliationSessionByID:%w", err)
+	}
+
+	if err = session.UntickTransaction(rc.DataStores, transactionID); err != nil {
+		return nil, fmt.Errorf("session.UntickTransaction:%w", err)
+	}
+
+	return session, nil
+}
+
+// POST /reconciliations/{sessionID}/finish
+func (rc *ReconciliationsController) FinishSession(_ context.Context, sessionID uint64,
+	finishedBy string) (*models.ReconciliationSession, error) {
+	session, err := models.RetrieveReconciliationSessionByID(rc.DataStores, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveReconciliationSessionByID:%w", err)
+	}
+
+	if err = session.Finish(rc.DataStores, finishedBy); err != nil {
+		return nil, fmt.Errorf("session.Finish:%w", err)
+	}
+
+	return session, nil
+}
+
+// GET /reconciliations/{sessionID}/report
+func (rc *ReconciliationsController) GetSessionReport(_ context.Context,
+	sessionID uint64) (*models.ReconciliationReport, error) {
+	report, err := models.RetrieveReconciliationReport(rc.DataStores, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveReconciliationReport:%w", err)
+	}
+
+	return report, nil
+}
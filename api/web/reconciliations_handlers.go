@@ -0,0 +1,226 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+	"github.com/mimirsoft/mimirledger/api/web/request"
+	"github.com/mimirsoft/mimirledger/api/web/response"
+)
+
+var ErrInvalidSessionID = errors.New("invalid sessionID request parameter")
+var ErrInvalidSessionStatus = errors.New("invalid status request parameter")
+
+func parseSessionID(req *http.Request) (uint64, error) {
+	sessionID, err := strconv.ParseUint(chi.URLParam(req, "sessionID"), 10, 64)
+	if err != nil || sessionID == 0 {
+		return 0, NewRequestError(http.StatusBadRequest, ErrInvalidSessionID)
+	}
+
+	return sessionID, nil
+}
+
+// respondWithReconciliationError maps the errors of reconciling a statement to a status
+func respondWithReconciliationError(err error) error {
+	switch {
+	case errors.Is(err, models.ErrReconciliationSessionNotFound), errors.Is(err, models.ErrAccountNotFound),
+		errors.Is(err, models.ErrTransactionNotFound):
+		return NewRequestError(http.StatusNotFound, err)
+	case errors.Is(err, models.ErrReconciliationSessionInvalid),
+		errors.Is(err, models.ErrReconciliationTransactionInvalid):
+		return NewRequestError(http.StatusBadRequest, err)
+	case errors.Is(err, models.ErrReconciliationSessionNotOpen),
+		errors.Is(err, models.ErrReconciliationSessionNotFinished),
+		errors.Is(err, models.ErrReconciliationSessionAlreadyOpen),
+		errors.Is(err, models.ErrReconciliationSessionNotBalanced),
+		errors.Is(err, models.ErrReconciliationSessionChanged):
+		return NewRequestError(http.StatusConflict, err)
+	}
+
+	return fmt.Errorf("reconciliations:%w", err)
+}
+
+// GET /reconciliations?accountID=<id>&status=<status>, status FINISHED is the reconciliation history of the account
+func GetReconciliations(reconCtl *ReconciliationsController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		accountID, err := strconv.ParseUint(req.URL.Query().Get("accountID"), 10, 64)
+		if err != nil || accountID == 0 {
+			return NewRequestError(http.StatusBadRequest, ErrInvalidAccountID)
+		}
+
+		status := datastore.ReconciliationStatus(req.URL.Query().Get("status"))
+		if status != "" && status != datastore.ReconciliationStatusOpen &&
+			status != datastore.ReconciliationStatusFinished {
+			return NewRequestError(http.StatusBadRequest, ErrInvalidSessionStatus)
+		}
+
+		sessions, err := reconCtl.SessionList(req.Context(), accountID, status)
+		if err != nil {
+			return respondWithReconciliationError(err)
+		}
+
+		return RespondOK(res, response.ConvertReconciliationSessionsToRespReconciliationSessionSet(sessions))
+	}
+}
+
+// GET /reconciliations/{sessionID}
+func GetReconciliation(reconCtl *ReconciliationsController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		sessionID, err := parseSessionID(req)
+		if err != nil {
+			return err
+		}
+
+		session, err := reconCtl.GetSessionByID(req.Context(), sessionID)
+		if err != nil {
+			return respondWithReconciliationError(err)
+		}
+
+		return RespondOK(res, response.ReconciliationSessionToRespReconciliationSession(session))
+	}
+}
+
+// POST /reconciliations
+func PostReconciliations(reconCtl *ReconciliationsController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		if req.Body == nil {
+			return NewRequestError(http.StatusBadRequest, ErrNoRequestBody)
+		}
+
+		var reqSession request.ReconciliationSession
+
+		if err := json.NewDecoder(req.Body).Decode(&reqSession); err != nil {
+			return fmt.Errorf("json.NewDecoder(r.Body).Decode:%w", err)
+		}
+
+		if reqSession.AccountID == 0 {
+			return NewRequestError(http.StatusBadRequest, ErrInvalidAccountID)
+		}
+
+		session, err := reconCtl.CreateSession(req.Context(), reqSession.AccountID, reqSession.StatementDate,
+			reqSession.StatementBalance)
+		if err != nil {
+			return respondWithReconciliationError(err)
+		}
+
+		return RespondOK(res, response.ReconciliationSessionToRespReconciliationSession(session))
+	}
+}
+
+// DELETE /reconciliations/{sessionID}, only an open session can be deleted
+func DeleteReconciliation(reconCtl *ReconciliationsController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		sessionID, err := parseSessionID(req)
+		if err != nil {
+			return err
+		}
+
+		session, err := reconCtl.DeleteSession(req.Context(), sessionID)
+		if err != nil {
+			return respondWithReconciliationError(err)
+		}
+
+		return RespondOK(res, response.ReconciliationSessionToRespReconciliationSession(session))
+	}
+}
+
+// PUT /reconciliations/{sessionID}/transactions/{transactionID}
+func PutReconciliationTransaction(reconCtl *ReconciliationsController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		sessionID, err := parseSessionID(req)
+		if err != nil {
+			return err
+		}
+
+		transactionID, err := strconv.ParseUint(chi.URLParam(req, "transactionID"), 10, 64)
+		if err != nil || transactionID == 0 {
+			return NewRequestError(http.StatusBadRequest, ErrInvalidTransactionID)
+		}
+
+		session, err := reconCtl.TickTransaction(req.Context(), sessionID, transactionID)
+		if err != nil {
+			return respondWithReconciliationError(err)
+		}
+
+		return RespondOK(res, response.ReconciliationSessionToRespReconciliationSession(session))
+	}
+}
+
+// DELETE /reconciliations/{sessionID}/transactions/{transactionID}
+func DeleteReconciliationTransaction(reconCtl *ReconciliationsController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		sessionID, err := parseSessionID(req)
+		if err != nil {
+			return err
+		}
+
+		transactionID, err := strconv.ParseUint(chi.URLParam(req, "transactionID"), 10, 64)
+		if err != nil || transactionID == 0 {
+			return NewRequestError(http.StatusBadRequest, ErrInvalidTransactionID)
+		}
+
+		session, err := reconCtl.UntickTransaction(req.Context(), sessionID, transactionID)
+		if err != nil {
+			return respondWithReconciliationError(err)
+		}
+
+		return RespondOK(res, response.ReconciliationSessionToRespReconciliationSession(session))
+	}
+}
+
+// POST /reconciliations/{sessionID}/finish, the difference must be zero.  The body is optional.
+func PostReconciliationFinish(reconCtl *ReconciliationsController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		sessionID, err := parseSessionID(req)
+		if err != nil {
+			return err
+		}
+
+		var reqFinish request.ReconciliationFinish
+
+		if req.Body != nil {
+			if err = json.NewDecoder(req.Body).Decode(&reqFinish); err != nil && !errors.Is(err, io.EOF) {
+				return fmt.Errorf("json.NewDecoder(r.Body).Decode:%w", err)
+			}
+		}
+
+		session, err := reconCtl.FinishSession(req.Context(), sessionID, reqFinish.FinishedBy)
+		if err != nil {
+			return respondWithReconciliationError(err)
+		}
+
+		return RespondOK(res, response.ReconciliationSessionToRespReconciliationSession(session))
+	}
+}
+
+// GET /reconciliations/{sessionID}/report, only a finished session has a report
+func GetReconciliationReport(reconCtl *ReconciliationsController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		sessionID, err := parseSessionID(req)
+		if err != nil {
+			return err
+		}
+
+		report, err := reconCtl.GetSessionReport(req.Context(), sessionID)
+		if err != nil {
+			return respondWithReconciliationError(err)
+		}
+
+		return RespondOK(res, response.ReconciliationReportToRespReconciliationReport(report))
+	}
+}
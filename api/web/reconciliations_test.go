@@ -0,0 +1,154 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+	"github.com/mimirsoft/mimirledger/api/web/response"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestReconciliations_Session(t *testing.T) { //nolint:funlen
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	checking := models.Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	income := models.Account{AccountName: "Income", AccountSign: datastore.AccountSignCredit,
+		AccountType: datastore.AccountTypeIncome}
+	err = income.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	txn := models.Transaction{TransactionCore: models.TransactionCore{TransactionComment: "pay day",
+		TransactionDate: time.Date(2024, 6, 14, 0, 0, 0, 0, time.UTC)},
+		DebitCreditSet: []*models.TransactionDebitCredit{
+			{AccountID: checking.AccountID, DebitOrCredit: datastore.AccountSignDebit, TransactionDCAmount: 52000},
+			{AccountID: income.AccountID, DebitOrCredit: datastore.AccountSignCredit, TransactionDCAmount: 52000},
+		},
+	}
+	err = txn.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	var session response.ReconciliationSession
+
+	test := RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: "/reconciliations",
+		Payload: M{"accountID": checking.AccountID, "statementDate": "2024-06-30T00:00:00Z",
+			"statementBalance": 52000},
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&session)
+	g.Expect(session.SessionStatus).To(gomega.Equal(datastore.ReconciliationStatusOpen))
+	g.Expect(session.Difference).To(gomega.Equal(int64(52000)))
+	g.Expect(session.TransactionIDs).To(gomega.BeEmpty())
+
+	NewRouterTableTest([]RouterTest{
+		{Request: Request{Method: http.MethodGet, Router: TestRouter, RequestURL: "/reconciliations"},
+			GomegaWithT: g, Code: http.StatusBadRequest, RespBody: ErrInvalidAccountID.Error()},
+		{Request: Request{Method: http.MethodGet, Router: TestRouter, RequestURL: "/reconciliations/0"},
+			GomegaWithT: g, Code: http.StatusBadRequest, RespBody: ErrInvalidSessionID.Error()},
+		{Request: Request{Method: http.MethodGet, Router: TestRouter, RequestURL: "/reconciliations/999999"},
+			GomegaWithT: g, Code: http.StatusNotFound},
+		{Request: Request{Method: http.MethodPost, Router: TestRouter, RequestURL: "/reconciliations",
+			Payload: M{"accountID": checking.AccountID, "statementDate": "2024-06-30T00:00:00Z"}},
+			GomegaWithT: g, Code: http.StatusConflict},
+		{Request: Request{Method: http.MethodPost, Router: TestRouter,
+			RequestURL: fmt.Sprintf("/reconciliations/%d/finish", session.SessionID)},
+			GomegaWithT: g, Code: http.StatusConflict},
+		{Request: Request{Method: http.MethodPut, Router: TestRouter,
+			RequestURL: fmt.Sprintf("/reconciliations/%d/transactions/999999", session.SessionID)},
+			GomegaWithT: g, Code: http.StatusNotFound},
+		{Request: Request{Method: http.MethodGet, Router: TestRouter,
+			RequestURL: fmt.Sprintf("/reconciliations/%d/report", session.SessionID)},
+			GomegaWithT: g, Code: http.StatusConflict},
+	}).Exec()
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodPut,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/reconciliations/%d/transactions/%d", session.SessionID, txn.TransactionID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&session)
+	g.Expect(session.TransactionIDs).To(gomega.Equal([]uint64{txn.TransactionID}))
+	g.Expect(session.ClearedBalance).To(gomega.Equal(int64(52000)))
+	g.Expect(session.Difference).To(gomega.Equal(int64(0)))
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/reconciliations/%d/finish", session.SessionID),
+		Payload:    M{"finishedBy": "pat"},
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&session)
+	g.Expect(session.SessionStatus).To(gomega.Equal(datastore.ReconciliationStatusFinished))
+	g.Expect(session.FinishedDate).NotTo(gomega.BeNil())
+	g.Expect(session.ClearedCount).To(gomega.Equal(1))
+	g.Expect(session.FinishedBy).To(gomega.Equal("pat"))
+
+	var reconciled response.Transaction
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/transactions/%d", txn.TransactionID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&reconciled)
+	g.Expect(reconciled.IsReconciled).To(gomega.BeFalse())
+
+	for _, debitCredit := range reconciled.DebitCreditSet {
+		g.Expect(debitCredit.IsReconciled).To(gomega.Equal(debitCredit.AccountID == checking.AccountID))
+	}
+
+	var sessionSet response.ReconciliationSessionSet
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/reconciliations?accountID=%d", checking.AccountID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&sessionSet)
+	g.Expect(sessionSet.Sessions).To(gomega.HaveLen(1))
+	g.Expect(sessionSet.Sessions[0].SessionID).To(gomega.Equal(session.SessionID))
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/reconciliations?accountID=%d&status=OPEN", checking.AccountID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&sessionSet)
+	g.Expect(sessionSet.Sessions).To(gomega.BeEmpty())
+
+	var report response.ReconciliationReport
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/reconciliations/%d/report", session.SessionID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&report)
+	g.Expect(report.Session.SessionID).To(gomega.Equal(session.SessionID))
+	g.Expect(report.ClearedBalance).To(gomega.Equal(int64(52000)))
+	g.Expect(report.OutstandingDeposits).To(gomega.BeEmpty())
+	g.Expect(report.OutstandingPayments).To(gomega.BeEmpty())
+	g.Expect(report.BookBalance).To(gomega.Equal(int64(52000)))
+	g.Expect(report.LaterChanges).To(gomega.BeEmpty())
+
+	NewRouterTableTest([]RouterTest{
+		{Request: Request{Method: http.MethodGet, Router: TestRouter,
+			RequestURL: fmt.Sprintf("/reconciliations?accountID=%d&status=DONE", checking.AccountID)},
+			GomegaWithT: g, Code: http.StatusBadRequest, RespBody: ErrInvalidSessionStatus.Error()},
+		{Request: Request{Method: http.MethodDelete, Router: TestRouter,
+			RequestURL: fmt.Sprintf("/reconciliations/%d", session.SessionID)},
+			GomegaWithT: g, Code: http.StatusConflict},
+	}).Exec()
+}
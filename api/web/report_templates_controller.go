@@ -0,0 +1,80 @@
+package web
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// ReportTemplatesController is the controller struct for report templates
+type ReportTemplatesController struct {
+	DataStores *datastore.Datastores
+}
+
+// NewReportTemplatesController instantiates a new ReportTemplatesController struct
+func NewReportTemplatesController(ds *datastore.Datastores) *ReportTemplatesController {
+	return &ReportTemplatesController{
+		DataStores: ds,
+	}
+}
+
+// GET /templates
+func (tc *ReportTemplatesController) TemplateList(_ context.Context) ([]*models.ReportTemplate, error) {
+	templates, err := models.RetrieveReportTemplates(tc.DataStores)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveReportTemplates:%w", err)
+	}
+
+	return templates, nil
+}
+
+// GET /templates/{templateID}
+func (tc *ReportTemplatesController) GetTemplateByID(_ context.Context,
+	templateID uint64) (*models.ReportTemplate, error) {
+	myTemplate, err := models.RetrieveReportTemplateByID(tc.DataStores, templateID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveReportTemplateByID:%w", err)
+	}
+
+	return myTemplate, nil
+}
+
+// POST /templates
+func (tc *ReportTemplatesController) CreateTemplate(_ context.Context,
+	myTemplate *models.ReportTemplate) (*models.ReportTemplate, error) {
+	err := myTemplate.Store(tc.DataStores)
+	if err != nil {
+		return nil, fmt.Errorf("myTemplate.Store:%w", err)
+	}
+
+	return myTemplate, nil
+}
+
+// PUT /templates/{templateID}
+func (tc *ReportTemplatesController) UpdateTemplate(_ context.Context,
+	myTemplate *models.ReportTemplate) (*models.ReportTemplate, error) {
+	err := myTemplate.Update(tc.DataStores)
+	if err != nil {
+		return nil, fmt.Errorf("myTemplate.Update:%w", err)
+	}
+
+	return myTemplate, nil
+}
+
+// DELETE /templates/{templateID}
+func (tc *ReportTemplatesController) DeleteTemplate(_ context.Context,
+	templateID uint64) (*models.ReportTemplate, error) {
+	myTemplate, err := models.RetrieveReportTemplateByID(tc.DataStores, templateID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveReportTemplateByID:%w", err)
+	}
+
+	err = myTemplate.Delete(tc.DataStores)
+	if err != nil {
+		return nil, fmt.Errorf("myTemplate.Delete:%w", err)
+	}
+
+	return myTemplate, nil
+}
@@ -0,0 +1,173 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mimirsoft/mimirledger/api/models"
+	"github.com/mimirsoft/mimirledger/api/web/request"
+	"github.com/mimirsoft/mimirledger/api/web/response"
+)
+
+var ErrInvalidTemplateID = errors.New("invalid templateID request parameter")
+
+// GET /templates
+func GetReportTemplates(templatesCtl *ReportTemplatesController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		templates, err := templatesCtl.TemplateList(req.Context())
+		if err != nil {
+			return NewRequestError(http.StatusServiceUnavailable, err)
+		}
+
+		jsonResponse := response.ConvertReportTemplatesToRespReportTemplateSet(templates)
+
+		return RespondOK(res, jsonResponse)
+	}
+}
+
+// GET /templates/{templateID}
+func GetReportTemplate(templatesCtl *ReportTemplatesController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		templateID, err := parseTemplateID(req)
+		if err != nil {
+			return err
+		}
+
+		myTemplate, err := templatesCtl.GetTemplateByID(req.Context(), templateID)
+		if err != nil {
+			if errors.Is(err, models.ErrReportTemplateNotFound) {
+				return NewRequestError(http.StatusNotFound, err)
+			}
+
+			return NewRequestError(http.StatusServiceUnavailable, err)
+		}
+
+		jsonResponse := response.ReportTemplateToRespReportTemplate(myTemplate)
+
+		return RespondOK(res, jsonResponse)
+	}
+}
+
+// POST /templates
+func PostReportTemplates(templatesCtl *ReportTemplatesController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		var reqTemplate request.ReportTemplate
+
+		if req.Body == nil {
+			return NewRequestError(http.StatusBadRequest, ErrNoRequestBody)
+		}
+
+		err := json.NewDecoder(req.Body).Decode(&reqTemplate)
+		if err != nil {
+			return fmt.Errorf("json.NewDecoder(r.Body).Decode:%w", err)
+		}
+
+		mdlTemplate := request.ReqReportTemplateToReportTemplate(&reqTemplate)
+
+		myTemplate, err := templatesCtl.CreateTemplate(req.Context(), mdlTemplate)
+		if err != nil {
+			return NewRequestError(http.StatusBadRequest, err)
+		}
+
+		jsonResponse := response.ReportTemplateToRespReportTemplate(myTemplate)
+
+		return RespondOK(res, jsonResponse)
+	}
+}
+
+// PUT /templates/{templateID}
+func PutReportTemplateUpdate(templatesCtl *ReportTemplatesController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		templateID, err := parseTemplateID(req)
+		if err != nil {
+			return err
+		}
+
+		if req.Body == nil {
+			return NewRequestError(http.StatusBadRequest, ErrNoRequestBody)
+		}
+
+		var reqTemplate request.ReportTemplate
+
+		err = json.NewDecoder(req.Body).Decode(&reqTemplate)
+		if err != nil {
+			return fmt.Errorf("json.NewDecoder(r.Body).Decode:%w", err)
+		}
+
+		mdlTemplate := request.ReqReportTemplateToReportTemplate(&reqTemplate)
+		mdlTemplate.TemplateID = templateID
+
+		myTemplate, err := templatesCtl.UpdateTemplate(req.Context(), mdlTemplate)
+		if err != nil {
+			if errors.Is(err, models.ErrReportTemplateNotFound) {
+				return NewRequestError(http.StatusNotFound, err)
+			}
+
+			return NewRequestError(http.StatusBadRequest, err)
+		}
+
+		jsonResponse := response.ReportTemplateToRespReportTemplate(myTemplate)
+
+		return RespondOK(res, jsonResponse)
+	}
+}
+
+// DELETE /templates/{templateID}
+func DeleteReportTemplate(templatesCtl *ReportTemplatesController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		templateID, err := parseTemplateID(req)
+		if err != nil {
+			return err
+		}
+
+		myTemplate, err := templatesCtl.DeleteTemplate(req.Context(), templateID)
+		if err != nil {
+			if errors.Is(err, models.ErrReportTemplateNotFound) {
+				return NewRequestError(http.StatusNotFound, err)
+			}
+
+			return NewRequestError(http.StatusBadRequest, err)
+		}
+
+		jsonResponse := response.ReportTemplateToRespReportTemplate(myTemplate)
+
+		return RespondOK(res, jsonResponse)
+	}
+}
+
+func parseTemplateID(req *http.Request) (uint64, error) {
+	idStr := chi.URLParam(req, "templateID")
+
+	templateID, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return 0, NewRequestError(http.StatusBadRequest, err)
+	}
+
+	if templateID == 0 {
+		return 0, NewRequestError(http.StatusBadRequest, ErrInvalidTemplateID)
+	}
+
+	return templateID, nil
+}
+
+// respondWithTemplateError maps the errors of retrieving and rendering a template onto a RequestError
+func respondWithTemplateError(err error) error {
+	switch {
+	case errors.Is(err, models.ErrReportTemplateNotFound):
+		return NewRequestError(http.StatusNotFound, err)
+	case errors.Is(err, models.ErrReportTemplateRender), errors.Is(err, models.ErrReportTemplateInvalid),
+		errors.Is(err, models.ErrReportTemplateTypeInvalid):
+		return NewRequestError(http.StatusUnprocessableEntity, err)
+	}
+
+	return NewRequestError(http.StatusServiceUnavailable, err)
+}
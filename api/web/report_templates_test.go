@@ -0,0 +1,143 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+	"github.com/mimirsoft/mimirledger/api/web/response"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestReportTemplates_CRUD(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	test := RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: "/templates",
+		Payload:    M{"templateName": "broken", "templateType": "HTML", "templateBody": "{{if}}"},
+	}, GomegaWithT: g, Code: http.StatusBadRequest, RespBody: models.ErrReportTemplateInvalid.Error()}
+	test.Exec()
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: "/templates",
+		Payload:    M{"templateName": "Board Summary", "templateType": "TEXT", "templateBody": "{{.Title}}"},
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var created response.ReportTemplate
+	test.ExecWithUnmarshal(&created)
+	g.Expect(created.TemplateID).NotTo(gomega.BeZero())
+	g.Expect(created.TemplateType).To(gomega.Equal(datastore.ReportTemplateTypeText))
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodPut,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/templates/%d", created.TemplateID),
+		Payload:    M{"templateName": "Board Summary", "templateType": "HTML", "templateBody": "<b>{{.Title}}</b>"},
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var updated response.ReportTemplate
+	test.ExecWithUnmarshal(&updated)
+	g.Expect(updated.TemplateType).To(gomega.Equal(datastore.ReportTemplateTypeHTML))
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: "/templates",
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var templateSet response.ReportTemplateSet
+	test.ExecWithUnmarshal(&templateSet)
+	g.Expect(templateSet.Templates).To(gomega.HaveLen(1))
+	g.Expect(templateSet.Templates[0].TemplateBody).To(gomega.Equal("<b>{{.Title}}</b>"))
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodDelete,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/templates/%d", created.TemplateID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.Exec()
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/templates/%d", created.TemplateID),
+	}, GomegaWithT: g, Code: http.StatusNotFound}
+	test.Exec()
+}
+
+func TestReportTemplates_RenderReportOutputAndStatement(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	a1 := models.Account{AccountName: "MyBank", AccountSign: datastore.AccountSignDebit, AccountType: datastore.AccountTypeAsset}
+	err := a1.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	a2 := models.Account{AccountName: "Income", AccountSign: datastore.AccountSignCredit, AccountType: datastore.AccountTypeIncome}
+	err = a2.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	txn := models.Transaction{
+		TransactionCore: models.TransactionCore{TransactionComment: "getting paid"},
+		DebitCreditSet: []*models.TransactionDebitCredit{
+			{AccountID: a1.AccountID, TransactionDCAmount: 123456, DebitOrCredit: datastore.AccountSignDebit},
+			{AccountID: a2.AccountID, TransactionDCAmount: 123456, DebitOrCredit: datastore.AccountSignCredit},
+		},
+	}
+	err = txn.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	statement := models.ReportTemplate{TemplateName: "statement", TemplateType: datastore.ReportTemplateTypeText,
+		TemplateBody: `{{.Account.AccountName}}{{range .Transactions}}|{{.TransactionComment}} ` +
+			`{{money .TransactionDCAmount $.Account.AccountDecimals}}{{range splitNames .Split}} {{.}}{{end}}{{end}}`}
+	err = statement.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	test := RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/transactions/account/%d/statement?template=statement", a1.AccountID),
+	}, GomegaWithT: g, Code: http.StatusOK, RespBody: "MyBank|getting paid 1,234.56 Income"}
+	test.Exec()
+	g.Expect(test.response.Header.Get("Content-Type")).To(gomega.Equal("text/plain; charset=utf-8"))
+
+	report := models.Report{ReportName: "testName",
+		ReportBody: models.ReportBody{
+			SourceAccountSetType: datastore.ReportAccountSetGroup,
+			SourceAccountGroup:   datastore.AccountTypeExpense,
+			DataSetType:          datastore.ReportDataSetTypeExpense,
+		}}
+	err = report.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	summary := models.ReportTemplate{TemplateName: "summary", TemplateType: datastore.ReportTemplateTypeHTML,
+		TemplateBody: `<h1>{{.Title}}</h1><h2>{{.Report.ReportName}}</h2>`}
+	err = summary.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	test = RouterTest{Request: Request{
+		Method: http.MethodGet,
+		Router: TestRouter,
+		RequestURL: fmt.Sprintf("/reports/%d/output?startDate=2020-01-01&endDate=2020-01-31&template=%d",
+			report.ReportID, summary.TemplateID),
+	}, GomegaWithT: g, Code: http.StatusOK, RespBody: "<h1>testName</h1><h2>testName</h2>"}
+	test.Exec()
+	g.Expect(test.response.Header.Get("Content-Type")).To(gomega.Equal("text/html; charset=utf-8"))
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/reports/%d/output?startDate=2020-01-01&endDate=2020-01-31&template=missing", report.ReportID),
+	}, GomegaWithT: g, Code: http.StatusNotFound}
+	test.Exec()
+}
@@ -62,6 +62,38 @@ func (rc *ReportsController) RunReport(_ context.Context, reportID uint64,
 	return reportOutPut, nil
 }
 
+// RunReportWithAccountTree runs a report with the account tree that the PDF and template layouts are built from
+func (rc *ReportsController) RunReportWithAccountTree(_ context.Context, reportID uint64,
+	startDate time.Time, endDate time.Time, accounts []uint64) (*models.ReportOutput, error) {
+	report, err := models.RetrieveReportByID(rc.DataStores, reportID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveReportByID:%w", err)
+	}
+
+	reportOutPut, err := report.RunWithAccountTree(rc.DataStores, startDate, endDate, accounts)
+	if err != nil {
+		return nil, fmt.Errorf("report.RunWithAccountTree:%w", err)
+	}
+
+	return reportOutPut, nil
+}
+
+// GET /reports/:reportID/output?template=
+func (rc *ReportsController) RenderReportOutput(ctx context.Context, templateRef string,
+	reportOutput *models.ReportOutput, title string) ([]byte, string, error) {
+	myTemplate, err := models.RetrieveReportTemplateByRef(rc.DataStores, templateRef)
+	if err != nil {
+		return nil, "", fmt.Errorf("models.RetrieveReportTemplateByRef:%w", err)
+	}
+
+	rendered, err := myTemplate.RenderReportOutput(ctx, rc.DataStores, reportOutput, title)
+	if err != nil {
+		return nil, "", fmt.Errorf("myTemplate.RenderReportOutput:%w", err)
+	}
+
+	return rendered, myTemplate.ContentType(), nil
+}
+
 // POST /reports
 func (rc *ReportsController) CreateReport(_ context.Context, report *models.Report) (*models.Report, error) {
 	err := report.Store(rc.DataStores)
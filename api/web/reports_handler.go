@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/mimirsoft/mimirledger/api/models"
+	"github.com/mimirsoft/mimirledger/api/pdf"
 	"github.com/mimirsoft/mimirledger/api/web/request"
 	"github.com/mimirsoft/mimirledger/api/web/response"
 )
@@ -62,55 +64,90 @@ func GetReport(reportsCtl *ReportsController) func(res http.ResponseWriter, req
 var ErrInvalidStartDate = errors.New("invalid startDate")
 var ErrInvalidEndDate = errors.New("invalid endDate")
 
-// GET /reports/{reportID}/output?date
-func GetReportOutput(reportsCtl *ReportsController) func(res http.ResponseWriter, req *http.Request) error {
-	return func(res http.ResponseWriter, req *http.Request) error {
-		reportIDStr := chi.URLParam(req, "reportID")
+// parseReportOutputRequest reads the reportID, the startDate and endDate, and any runtime accounts of a report run
+func parseReportOutputRequest(req *http.Request) (uint64, time.Time, time.Time, []uint64, error) {
+	reportIDStr := chi.URLParam(req, "reportID")
 
-		reportID, err := strconv.ParseUint(reportIDStr, 10, 64)
-		if err != nil {
-			return NewRequestError(http.StatusBadRequest, err)
-		}
+	reportID, err := strconv.ParseUint(reportIDStr, 10, 64)
+	if err != nil {
+		return 0, time.Time{}, time.Time{}, nil, NewRequestError(http.StatusBadRequest, err)
+	}
 
-		if reportID == 0 {
-			return NewRequestError(http.StatusBadRequest, ErrInvalidAccountID)
-		}
+	if reportID == 0 {
+		return 0, time.Time{}, time.Time{}, nil, NewRequestError(http.StatusBadRequest, ErrInvalidAccountID)
+	}
 
-		startDateStr := req.URL.Query().Get("startDate")
+	startDateStr := req.URL.Query().Get("startDate")
 
-		startDate, err := time.Parse("2006-01-02", startDateStr)
-		if err != nil {
-			return NewRequestError(http.StatusBadRequest, ErrInvalidStartDate)
+	startDate, err := time.Parse("2006-01-02", startDateStr)
+	if err != nil {
+		return 0, time.Time{}, time.Time{}, nil, NewRequestError(http.StatusBadRequest, ErrInvalidStartDate)
+	}
+
+	endDateStr := req.URL.Query().Get("endDate")
+
+	endDate, err := time.Parse("2006-01-02", endDateStr)
+	if err != nil {
+		return 0, time.Time{}, time.Time{}, nil, NewRequestError(http.StatusBadRequest, ErrInvalidEndDate)
+	}
+
+	var accountSet []uint64
+
+	queryVals, ok := req.URL.Query()["account"]
+	if ok {
+		for _, str := range queryVals {
+			parsedUint, strConvErr := strconv.ParseUint(str, 10, 64)
+			if strConvErr != nil {
+				return 0, time.Time{}, time.Time{}, nil, NewRequestError(http.StatusBadRequest, strConvErr)
+			}
+
+			accountSet = append(accountSet, parsedUint)
 		}
+	}
+
+	return reportID, startDate, endDate, accountSet, nil
+}
+
+// respondWithReportRunError maps the errors of running a report to a status
+func respondWithReportRunError(err error) error {
+	if errors.Is(err, models.ErrReportNotFound) {
+		return NewRequestError(http.StatusNotFound, err)
+	}
+
+	if errors.Is(err, models.ErrAccountNotFound) {
+		return NewRequestError(http.StatusBadRequest, err)
+	}
 
-		endDateStr := req.URL.Query().Get("endDate")
+	return NewRequestError(http.StatusServiceUnavailable, err)
+}
 
-		endDate, err := time.Parse("2006-01-02", endDateStr)
+// GET /reports/{reportID}/output?date&template&title
+func GetReportOutput(reportsCtl *ReportsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		reportID, startDate, endDate, accountSet, err := parseReportOutputRequest(req)
 		if err != nil {
-			return NewRequestError(http.StatusBadRequest, ErrInvalidStartDate)
+			return err
 		}
 
-		var accountSet []uint64
-
-		queryVals, ok := req.URL.Query()["account"]
-		if ok {
-			for _, str := range queryVals {
-				parsedUint, strConvErr := strconv.ParseUint(str, 10, 64)
-				if strConvErr != nil {
-					return NewRequestError(http.StatusBadRequest, strConvErr)
-				}
+		if templateRef := req.URL.Query().Get("template"); templateRef != "" {
+			reportOutput, err := reportsCtl.RunReportWithAccountTree(req.Context(), reportID, startDate, endDate,
+				accountSet)
+			if err != nil {
+				return respondWithReportRunError(err)
+			}
 
-				accountSet = append(accountSet, parsedUint)
+			rendered, contentType, err := reportsCtl.RenderReportOutput(req.Context(), templateRef, reportOutput,
+				req.URL.Query().Get("title"))
+			if err != nil {
+				return respondWithTemplateError(err)
 			}
+
+			return RespondRendered(res, contentType, rendered)
 		}
 
 		reportOutput, err := reportsCtl.RunReport(req.Context(), reportID, startDate, endDate, accountSet)
 		if err != nil {
-			if errors.Is(err, models.ErrReportNotFound) {
-				return NewRequestError(http.StatusNotFound, err)
-			}
-
-			return NewRequestError(http.StatusServiceUnavailable, err)
+			return respondWithReportRunError(err)
 		}
 
 		jsonResponse := response.ReportOutputToRespReportOutput(reportOutput)
@@ -119,6 +156,39 @@ func GetReportOutput(reportsCtl *ReportsController) func(res http.ResponseWriter
 	}
 }
 
+var ErrInvalidPageSize = errors.New("invalid pageSize, must be letter or a4")
+
+// GET /reports/{reportID}/output.pdf?date&title, the title heads every page and is the report name by default
+func GetReportOutputPDF(reportsCtl *ReportsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		reportID, startDate, endDate, accountSet, err := parseReportOutputRequest(req)
+		if err != nil {
+			return err
+		}
+
+		pageSize := pdf.PageSizeLetter
+
+		switch strings.ToLower(req.URL.Query().Get("pageSize")) {
+		case "", "letter":
+		case "a4":
+			pageSize = pdf.PageSizeA4
+		default:
+			return NewRequestError(http.StatusBadRequest, ErrInvalidPageSize)
+		}
+
+		reportOutput, err := reportsCtl.RunReportWithAccountTree(req.Context(), reportID, startDate, endDate,
+			accountSet)
+		if err != nil {
+			return respondWithReportRunError(err)
+		}
+
+		pdfReport := response.ReportOutputToPDFReport(req.URL.Query().Get("title"), reportOutput)
+		filename := fmt.Sprintf("%s-%s.pdf", reportOutput.ReportName, endDate.Format("2006-01-02"))
+
+		return RespondPDF(res, filename, pdfReport.Render(pageSize))
+	}
+}
+
 // PUT /reports/{reportID}
 func PutReportUpdate(reportsCtl *ReportsController) func(res http.ResponseWriter, req *http.Request) error {
 	return func(res http.ResponseWriter, req *http.Request) error {
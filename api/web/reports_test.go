@@ -254,6 +254,70 @@ func TestReports_GetReportOutput(t *testing.T) {
 	g.Expect(respReportOutput.ReportID).To(gomega.Equal(a1.ReportID))
 	g.Expect(respReportOutput.ReportName).To(gomega.Equal("testName"))
 	g.Expect(respReportOutput.ReportData).To(gomega.HaveLen(1))
+
+	// an account that does not exist is a bad request from both the JSON and the PDF output
+	userSupplied := models.Report{ReportName: "userSupplied",
+		ReportBody: models.ReportBody{
+			SourceAccountSetType:     datastore.ReportAccountSetUserSupplied,
+			SourceRecurseSubAccounts: true,
+			DataSetType:              datastore.ReportDataSetTypeExpense,
+		}}
+	err = userSupplied.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	for _, output := range []string{"output", "output.pdf"} {
+		test = RouterTest{Request: Request{
+			Method: http.MethodGet,
+			Router: TestRouter,
+			RequestURL: fmt.Sprintf("/reports/%d/%s?startDate=2020-01-01&endDate=2020-01-31&account=999999",
+				userSupplied.ReportID, output),
+		}, GomegaWithT: g, Code: http.StatusBadRequest}
+		test.Exec()
+	}
+}
+
+func TestReports_GetReportOutputPDF(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	a1 := models.Report{ReportName: "testName",
+		ReportBody: models.ReportBody{
+			SourceAccountSetType:          datastore.ReportAccountSetGroup,
+			SourceAccountGroup:            datastore.AccountTypeExpense,
+			SourceRecurseSubAccounts:      true,
+			SourceRecurseSubAccountsDepth: 1,
+			DataSetType:                   datastore.ReportDataSetTypeExpense,
+		}}
+	err := a1.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	test := RouterTest{Request: Request{
+		Method: http.MethodGet,
+		Router: TestRouter,
+		RequestURL: fmt.Sprintf("/reports/%d/output.pdf?startDate=2020-01-01&endDate=2020-01-31&title=Mimir+Holdings",
+			a1.ReportID),
+	}, GomegaWithT: g, Code: http.StatusOK, RespBody: "(Mimir Holdings) Tj"}
+	test.Exec()
+	g.Expect(test.response.Header.Get("Content-Type")).To(gomega.Equal("application/pdf"))
+	g.Expect(test.actualRespBody.String()).To(gomega.HavePrefix("%PDF-1.4"))
+	g.Expect(test.actualRespBody.String()).To(gomega.ContainSubstring("(testName) Tj"))
+	g.Expect(test.actualRespBody.String()).To(gomega.ContainSubstring("(Page 1 of 1) Tj"))
+
+	// without a title the report name heads the page
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/reports/%d/output.pdf?startDate=2020-01-01&endDate=2020-01-31", a1.ReportID),
+	}, GomegaWithT: g, Code: http.StatusOK, RespBody: "(testName) Tj"}
+	test.Exec()
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/reports/%d/output.pdf?startDate=2020-01-01&endDate=2020-01-31&pageSize=legal", a1.ReportID),
+	}, GomegaWithT: g, Code: http.StatusBadRequest}
+	test.Exec()
 }
 
 func TestReports_PostRestoreDefault(t *testing.T) {
@@ -0,0 +1,62 @@
+package request
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// CategorizationRule is a rule of the rules engine, a rule is enabled unless isEnabled is false
+type CategorizationRule struct {
+	RuleName  string               `json:"ruleName"`
+	RuleOrder int                  `json:"ruleOrder"`
+	IsEnabled *bool                `json:"isEnabled"`
+	Match     datastore.RuleMatch  `json:"match"`
+	Action    datastore.RuleAction `json:"action"`
+}
+
+func ReqCategorizationRuleToCategorizationRule(rule *CategorizationRule) *models.CategorizationRule {
+	isEnabled := true
+	if rule.IsEnabled != nil {
+		isEnabled = *rule.IsEnabled
+	}
+
+	return &models.CategorizationRule{
+		RuleID:    0,
+		RuleName:  rule.RuleName,
+		RuleOrder: rule.RuleOrder,
+		IsEnabled: isEnabled,
+		Match:     rule.Match,
+		Action:    rule.Action,
+	}
+}
+
+// CategorizeTransactions selects the transactions the rules are applied to, accountID is the account of the
+// uncategorized transactions and is the suspense account when it is 0
+type CategorizeTransactions struct {
+	AccountID uint64     `json:"accountID"`
+	StartDate *time.Time `json:"startDate"`
+	EndDate   *time.Time `json:"endDate"`
+}
+
+func ReqCategorizeTransactionsToCategorizeFilter(categorize *CategorizeTransactions,
+	dryRun bool) *models.CategorizeFilter {
+	filter := models.CategorizeFilter{
+		AccountID: categorize.AccountID,
+		StartDate: sql.NullTime{Time: time.Time{}, Valid: false},
+		EndDate:   sql.NullTime{Time: time.Time{}, Valid: false},
+		DryRun:    dryRun,
+	}
+
+	if categorize.StartDate != nil {
+		filter.StartDate = sql.NullTime{Time: *categorize.StartDate, Valid: true}
+	}
+
+	if categorize.EndDate != nil {
+		filter.EndDate = sql.NullTime{Time: *categorize.EndDate, Valid: true}
+	}
+
+	return &filter
+}
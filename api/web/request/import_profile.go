@@ -0,0 +1,45 @@
+package request
+
+import (
+	"github.com/mimirsoft/mimirledger/api/importer"
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+type ImportProfile struct {
+	ProfileName       string `json:"profileName"`
+	OffsetAccountID   uint64 `json:"offsetAccountID"`
+	Delimiter         string `json:"delimiter"`
+	SkipRows          int    `json:"skipRows"`
+	DateColumn        int    `json:"dateColumn"`
+	AmountColumn      int    `json:"amountColumn"`
+	DebitColumn       int    `json:"debitColumn"`
+	CreditColumn      int    `json:"creditColumn"`
+	DescriptionColumn int    `json:"descriptionColumn"`
+	ReferenceColumn   int    `json:"referenceColumn"`
+	ExternalIDColumn  int    `json:"externalIDColumn"`
+	DateFormat        string `json:"dateFormat"`
+	DecimalSeparator  string `json:"decimalSeparator"`
+	SignConvention    string `json:"signConvention"`
+}
+
+func ReqImportProfileToImportProfile(profile *ImportProfile) *models.ImportProfile {
+	return &models.ImportProfile{
+		ProfileID:       0,
+		ProfileName:     profile.ProfileName,
+		OffsetAccountID: profile.OffsetAccountID,
+		CSV: importer.CSVProfile{
+			Delimiter:         profile.Delimiter,
+			SkipRows:          profile.SkipRows,
+			DateColumn:        profile.DateColumn,
+			AmountColumn:      profile.AmountColumn,
+			DebitColumn:       profile.DebitColumn,
+			CreditColumn:      profile.CreditColumn,
+			DescriptionColumn: profile.DescriptionColumn,
+			ReferenceColumn:   profile.ReferenceColumn,
+			ExternalIDColumn:  profile.ExternalIDColumn,
+			DateFormat:        profile.DateFormat,
+			DecimalSeparator:  profile.DecimalSeparator,
+			SignConvention:    profile.SignConvention,
+		},
+	}
+}
@@ -0,0 +1,44 @@
+package request
+
+import (
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// MemorizedTransaction is a named template of a multi-line transaction
+type MemorizedTransaction struct {
+	MemorizedName        string                     `json:"memorizedName"`
+	TransactionComment   string                     `json:"transactionComment"`
+	TransactionReference string                     `json:"transactionReference"`
+	Lines                []*datastore.MemorizedLine `json:"lines"`
+}
+
+func ReqMemorizedTransactionToMemorizedTransaction(memorized *MemorizedTransaction) *models.MemorizedTransaction {
+	return &models.MemorizedTransaction{
+		MemorizedID:          0,
+		MemorizedName:        memorized.MemorizedName,
+		TransactionComment:   memorized.TransactionComment,
+		TransactionReference: memorized.TransactionReference,
+		Lines:                memorized.Lines,
+	}
+}
+
+// MemorizedParameters is what is filled in when a template is used, amounts are by the parameterName of its lines.
+// The comment and reference of the template are used when they are empty.
+type MemorizedParameters struct {
+	TransactionDate      time.Time         `json:"transactionDate"`
+	TransactionComment   string            `json:"transactionComment"`
+	TransactionReference string            `json:"transactionReference"`
+	Amounts              map[string]uint64 `json:"amounts"`
+}
+
+func ReqMemorizedParametersToMemorizedParameters(params *MemorizedParameters) *models.MemorizedParameters {
+	return &models.MemorizedParameters{
+		TransactionDate:      params.TransactionDate,
+		TransactionComment:   params.TransactionComment,
+		TransactionReference: params.TransactionReference,
+		Amounts:              params.Amounts,
+	}
+}
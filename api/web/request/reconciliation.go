@@ -0,0 +1,18 @@
+package request
+
+import (
+	"time"
+)
+
+// ReconciliationSession starts reconciling a statement, statementBalance is the ending balance of the statement in
+// the sign of the account
+type ReconciliationSession struct {
+	AccountID        uint64    `json:"accountID"`
+	StatementDate    time.Time `json:"statementDate"`
+	StatementBalance int64     `json:"statementBalance"`
+}
+
+// ReconciliationFinish finishes a session, finishedBy is recorded in the reconciliation history
+type ReconciliationFinish struct {
+	FinishedBy string `json:"finishedBy"`
+}
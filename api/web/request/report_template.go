@@ -0,0 +1,20 @@
+package request
+
+import (
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+type ReportTemplate struct {
+	TemplateName string                       `json:"templateName"`
+	TemplateType datastore.ReportTemplateType `json:"templateType"`
+	TemplateBody string                       `json:"templateBody"`
+}
+
+func ReqReportTemplateToReportTemplate(tmpl *ReportTemplate) *models.ReportTemplate {
+	return &models.ReportTemplate{ //nolint:exhaustruct
+		TemplateName: tmpl.TemplateName,
+		TemplateType: tmpl.TemplateType,
+		TemplateBody: tmpl.TemplateBody,
+	}
+}
@@ -0,0 +1,46 @@
+package request
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// ScheduledTransaction is a transaction posted on each day of its recurrenceRule from startDate, through endDate
+// when it is set.  A schedule is enabled unless isEnabled is false.  A new schedule is posted from the day it is
+// created, catchUp posts its occurrences from startDate instead, the past ones included.
+type ScheduledTransaction struct {
+	ScheduledName  string                        `json:"scheduledName"`
+	RecurrenceRule string                        `json:"recurrenceRule"`
+	StartDate      time.Time                     `json:"startDate"`
+	EndDate        *time.Time                    `json:"endDate"`
+	IsEnabled      *bool                         `json:"isEnabled"`
+	Template       datastore.TransactionTemplate `json:"template"`
+	CatchUp        bool                          `json:"catchUp"`
+}
+
+func ReqScheduledTransactionToScheduledTransaction(scheduled *ScheduledTransaction) *models.ScheduledTransaction {
+	isEnabled := true
+	if scheduled.IsEnabled != nil {
+		isEnabled = *scheduled.IsEnabled
+	}
+
+	endDate := sql.NullTime{Time: time.Time{}, Valid: false}
+	if scheduled.EndDate != nil {
+		endDate = sql.NullTime{Time: *scheduled.EndDate, Valid: true}
+	}
+
+	return &models.ScheduledTransaction{
+		ScheduledID:    0,
+		ScheduledName:  scheduled.ScheduledName,
+		RecurrenceRule: scheduled.RecurrenceRule,
+		StartDate:      scheduled.StartDate,
+		EndDate:        endDate,
+		IsEnabled:      isEnabled,
+		Template:       scheduled.Template,
+		NextDue:        time.Time{},
+		CatchUp:        scheduled.CatchUp,
+	}
+}
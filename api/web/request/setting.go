@@ -0,0 +1,15 @@
+package request
+
+import (
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+type Setting struct {
+	SettingValue string `json:"settingValue"`
+}
+
+func ReqSettingToSetting(setting *Setting) *models.Setting {
+	return &models.Setting{ //nolint:exhaustruct
+		SettingValue: setting.SettingValue,
+	}
+}
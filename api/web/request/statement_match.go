@@ -0,0 +1,42 @@
+package request
+
+import (
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// ConfirmMatches confirms statement lines as transactions that were already entered.  Without matches every match
+// that is not ambiguous is confirmed, and without a reconcileDate the transactions are reconciled on the closing
+// balance date of the statement, or the date of its last line.
+type ConfirmMatches struct {
+	Matches       []*MatchConfirmation `json:"matches"`
+	ReconcileDate *time.Time           `json:"reconcileDate"`
+}
+
+type MatchConfirmation struct {
+	ImportLineID  uint64 `json:"importLineID"`
+	TransactionID uint64 `json:"transactionID"`
+}
+
+func ReqConfirmMatchesToMatchConfirmations(confirm *ConfirmMatches) ([]*models.MatchConfirmation, time.Time) {
+	var reconcileDate time.Time
+	if confirm.ReconcileDate != nil {
+		reconcileDate = *confirm.ReconcileDate
+	}
+
+	if confirm.Matches == nil {
+		return nil, reconcileDate
+	}
+
+	confirmations := make([]*models.MatchConfirmation, len(confirm.Matches))
+
+	for idx, match := range confirm.Matches {
+		confirmations[idx] = &models.MatchConfirmation{
+			ImportLineID:  match.ImportLineID,
+			TransactionID: match.TransactionID,
+		}
+	}
+
+	return confirmations, reconcileDate
+}
@@ -21,12 +21,17 @@ type Transaction struct {
 	DebitCreditSet       []*TransactionDebitCredit `json:"debitCreditSet"`
 }
 
+// TransactionDebitCredit is a line of a transaction.  When no line is reconciled, an update keeps the reconciled state
+// of the stored lines, and the lines of a new transaction take isReconciled and transactionReconcileDate of the
+// transaction.
 type TransactionDebitCredit struct {
-	TransactionDCID     uint64                `json:"transactionDCID,omitempty"` //nolint:tagliatelle
-	TransactionID       uint64                `json:"transactionID"`
-	AccountID           uint64                `json:"accountID"`
-	TransactionDCAmount uint64                `json:"transactionDCAmount"` //nolint:tagliatelle
-	DebitOrCredit       datastore.AccountSign `json:"debitOrCredit"`
+	TransactionDCID          uint64                `json:"transactionDCID,omitempty"` //nolint:tagliatelle
+	TransactionID            uint64                `json:"transactionID"`
+	AccountID                uint64                `json:"accountID"`
+	TransactionDCAmount      uint64                `json:"transactionDCAmount"` //nolint:tagliatelle
+	DebitOrCredit            datastore.AccountSign `json:"debitOrCredit"`
+	IsReconciled             bool                  `json:"isReconciled"`
+	TransactionReconcileDate *time.Time            `json:"transactionReconcileDate,omitempty"`
 }
 
 func ReqTransactionToTransaction(rTrans *Transaction) *models.Transaction {
@@ -56,9 +61,34 @@ func ConvertReqDebitCreditsToDebitCreditSet(dcSet []*TransactionDebitCredit) []*
 	var mset = make([]*models.TransactionDebitCredit, len(dcSet))
 
 	for idx := range dcSet {
-		myDS := models.TransactionDebitCredit(*dcSet[idx])
-		mset[idx] = &myDS
+		mset[idx] = &models.TransactionDebitCredit{
+			TransactionDCID:          dcSet[idx].TransactionDCID,
+			TransactionID:            dcSet[idx].TransactionID,
+			AccountID:                dcSet[idx].AccountID,
+			TransactionDCAmount:      dcSet[idx].TransactionDCAmount,
+			DebitOrCredit:            dcSet[idx].DebitOrCredit,
+			IsReconciled:             dcSet[idx].IsReconciled,
+			TransactionReconcileDate: sql.NullTime{Time: time.Time{}, Valid: false},
+		}
+
+		if dcSet[idx].TransactionReconcileDate != nil {
+			mset[idx].TransactionReconcileDate = sql.NullTime{Time: *dcSet[idx].TransactionReconcileDate, Valid: true}
+		}
 	}
 
 	return mset
 }
+
+// TransactionDuplicateMerge names the transaction of a suspected pair to keep, 0 keeps the reconciled one or else
+// the older one
+type TransactionDuplicateMerge struct {
+	KeepTransactionID uint64 `json:"keepTransactionID"`
+}
+
+// TransactionsReconcile is a list of transactions on an account to reconcile or unreconcile together.
+// reconcileDate is required to reconcile, and when unreconciling is the date the subtotal is reported for.
+type TransactionsReconcile struct {
+	AccountID      uint64     `json:"accountID"`
+	TransactionIDs []uint64   `json:"transactionIDs"`
+	ReconcileDate  *time.Time `json:"reconcileDate"`
+}
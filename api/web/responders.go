@@ -3,7 +3,11 @@ package web
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+
+	"github.com/mimirsoft/mimirledger/api/pdf"
 )
 
 // get an object, marshal and return it as JSON
@@ -31,3 +35,53 @@ func RespondRedirectStatusFound(w http.ResponseWriter, location string) error {
 
 	return nil
 }
+
+// return the output of a user defined template as is
+func RespondRendered(w http.ResponseWriter, contentType string, body []byte) error {
+	w.Header().Set("Content-Type", contentType)
+
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("failed writing rendered response: %w", err)
+	}
+
+	return nil
+}
+
+// return a file to download
+func RespondAttachment(w http.ResponseWriter, contentType string, filename string, body []byte) error {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("failed writing attachment response: %w", err)
+	}
+
+	return nil
+}
+
+// stream a stored file to download, the browser is not to guess another content type for it
+func RespondAttachmentStream(w http.ResponseWriter, contentType string, filename string, size int64,
+	body io.Reader) error {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if _, err := io.Copy(w, body); err != nil {
+		return fmt.Errorf("failed writing attachment response: %w", err)
+	}
+
+	return nil
+}
+
+// render a PDF document and return it inline, browsers will display it rather than download it
+func RespondPDF(w http.ResponseWriter, filename string, doc *pdf.Document) error {
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename))
+
+	if _, err := doc.WriteTo(w); err != nil {
+		return fmt.Errorf("failed writing pdf response: %w", err)
+	}
+
+	return nil
+}
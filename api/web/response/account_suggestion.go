@@ -0,0 +1,30 @@
+package response
+
+import (
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// AccountSuggestionSet is the accounts suggested for a transaction, the most likely first
+type AccountSuggestionSet struct {
+	TransactionID uint64               `json:"transactionID"`
+	Suggestions   []*AccountSuggestion `json:"suggestions"`
+}
+
+// AccountSuggestion is a suggested account, confidence is between 0 and 1
+type AccountSuggestion struct {
+	AccountID   uint64  `json:"accountID"`
+	AccountName string  `json:"accountName"`
+	Confidence  float64 `json:"confidence"`
+}
+
+// ConvertAccountSuggestionsToRespAccountSuggestions converts []*models.AccountSuggestion to []*AccountSuggestion
+func ConvertAccountSuggestionsToRespAccountSuggestions(
+	suggestions []*models.AccountSuggestion) []*AccountSuggestion {
+	respSuggestions := make([]*AccountSuggestion, len(suggestions))
+
+	for idx := range suggestions {
+		respSuggestions[idx] = (*AccountSuggestion)(suggestions[idx])
+	}
+
+	return respSuggestions
+}
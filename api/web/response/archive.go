@@ -0,0 +1,65 @@
+package response
+
+import (
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// ArchiveRestoreSummary is the outcome of restoring an archive
+type ArchiveRestoreSummary struct {
+	Settings        int              `json:"settings"`
+	Accounts        int              `json:"accounts"`
+	Transactions    int              `json:"transactions"`
+	DebitCredits    int              `json:"debitCredits"`
+	Reports         int              `json:"reports"`
+	ReportTemplates int              `json:"reportTemplates"`
+	Integrity       *IntegrityReport `json:"integrity"`
+}
+
+// IntegrityReport is the outcome of the integrity checks, OK when there are no problems
+type IntegrityReport struct {
+	OK                  bool                `json:"ok"`
+	AccountsChecked     int                 `json:"accountsChecked"`
+	TransactionsChecked int                 `json:"transactionsChecked"`
+	Problems            []*IntegrityProblem `json:"problems"`
+}
+
+type IntegrityProblem struct {
+	Check         string `json:"check"`
+	AccountID     uint64 `json:"accountID,omitempty"`
+	TransactionID uint64 `json:"transactionID,omitempty"`
+	Message       string `json:"message"`
+}
+
+// ArchiveRestoreSummaryToResp converts models.ArchiveRestoreSummary to ArchiveRestoreSummary
+func ArchiveRestoreSummaryToResp(summary *models.ArchiveRestoreSummary) *ArchiveRestoreSummary {
+	return &ArchiveRestoreSummary{
+		Settings:        summary.Settings,
+		Accounts:        summary.Accounts,
+		Transactions:    summary.Transactions,
+		DebitCredits:    summary.DebitCredits,
+		Reports:         summary.Reports,
+		ReportTemplates: summary.ReportTemplates,
+		Integrity:       IntegrityReportToResp(summary.Integrity),
+	}
+}
+
+// IntegrityReportToResp converts models.IntegrityReport to IntegrityReport
+func IntegrityReportToResp(report *models.IntegrityReport) *IntegrityReport {
+	respReport := IntegrityReport{
+		OK:                  len(report.Problems) == 0,
+		AccountsChecked:     report.AccountsChecked,
+		TransactionsChecked: report.TransactionsChecked,
+		Problems:            []*IntegrityProblem{},
+	}
+
+	for _, problem := range report.Problems {
+		respReport.Problems = append(respReport.Problems, &IntegrityProblem{
+			Check:         string(problem.Check),
+			AccountID:     problem.AccountID,
+			TransactionID: problem.TransactionID,
+			Message:       problem.Message,
+		})
+	}
+
+	return &respReport
+}
@@ -0,0 +1,47 @@
+package response
+
+import (
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// AttachmentSet is the attachments of a transaction in the order they were attached
+type AttachmentSet struct {
+	Attachments []*Attachment `json:"attachments"`
+}
+
+// Attachment is a file attached to a transaction, fileSHA256 is the hex sha256 of its contents
+type Attachment struct {
+	AttachmentID  uint64    `json:"attachmentID"`
+	TransactionID uint64    `json:"transactionID"`
+	FileName      string    `json:"fileName"`
+	ContentType   string    `json:"contentType"`
+	FileSize      int64     `json:"fileSize"`
+	FileSHA256    string    `json:"fileSHA256"` //nolint:tagliatelle
+	CreatedDate   time.Time `json:"createdDate"`
+}
+
+// AttachmentToRespAttachment converts models.Attachment to Attachment
+func AttachmentToRespAttachment(attachment *models.Attachment) *Attachment {
+	return &Attachment{
+		AttachmentID:  attachment.AttachmentID,
+		TransactionID: attachment.TransactionID,
+		FileName:      attachment.FileName,
+		ContentType:   attachment.ContentType,
+		FileSize:      attachment.FileSize,
+		FileSHA256:    attachment.FileSHA256,
+		CreatedDate:   attachment.CreatedDate,
+	}
+}
+
+// ConvertAttachmentsToRespAttachmentSet converts []*models.Attachment to AttachmentSet
+func ConvertAttachmentsToRespAttachmentSet(attachments []*models.Attachment) *AttachmentSet {
+	respAttachments := make([]*Attachment, len(attachments))
+
+	for idx := range attachments {
+		respAttachments[idx] = AttachmentToRespAttachment(attachments[idx])
+	}
+
+	return &AttachmentSet{Attachments: respAttachments}
+}
@@ -0,0 +1,87 @@
+package response
+
+import (
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// CategorizationRuleSet is for use in rules controller responses, the rules are in the order they are applied
+type CategorizationRuleSet struct {
+	Rules []*CategorizationRule `json:"rules"`
+}
+
+type CategorizationRule struct {
+	RuleID    uint64               `json:"ruleID"`
+	RuleName  string               `json:"ruleName"`
+	RuleOrder int                  `json:"ruleOrder"`
+	IsEnabled bool                 `json:"isEnabled"`
+	Match     datastore.RuleMatch  `json:"match"`
+	Action    datastore.RuleAction `json:"action"`
+}
+
+// CategorizationResult is what applying the rules changed, or would change on a dry run
+type CategorizationResult struct {
+	AccountID           uint64                  `json:"accountID"`
+	DryRun              bool                    `json:"dryRun"`
+	TransactionsChecked int                     `json:"transactionsChecked"`
+	Changes             []*CategorizationChange `json:"changes"`
+}
+
+// CategorizationChange is a transaction before and after the rules
+type CategorizationChange struct {
+	TransactionID   uint64       `json:"transactionID"`
+	TransactionDate time.Time    `json:"transactionDate"`
+	RuleIDs         []uint64     `json:"ruleIDs"`
+	Before          *Transaction `json:"before"`
+	After           *Transaction `json:"after"`
+}
+
+// CategorizationRuleToRespCategorizationRule converts models.CategorizationRule to CategorizationRule
+func CategorizationRuleToRespCategorizationRule(rule *models.CategorizationRule) *CategorizationRule {
+	return &CategorizationRule{
+		RuleID:    rule.RuleID,
+		RuleName:  rule.RuleName,
+		RuleOrder: rule.RuleOrder,
+		IsEnabled: rule.IsEnabled,
+		Match:     rule.Match,
+		Action:    rule.Action,
+	}
+}
+
+// ConvertCategorizationRulesToRespCategorizationRuleSet converts []*models.CategorizationRule to
+// CategorizationRuleSet
+func ConvertCategorizationRulesToRespCategorizationRuleSet(rules []*models.CategorizationRule) *CategorizationRuleSet {
+	respRules := make([]*CategorizationRule, len(rules))
+
+	for idx := range rules {
+		respRules[idx] = CategorizationRuleToRespCategorizationRule(rules[idx])
+	}
+
+	return &CategorizationRuleSet{Rules: respRules}
+}
+
+// ConvertCategorizationResultToRespCategorizationResult converts models.CategorizationResult to
+// CategorizationResult
+func ConvertCategorizationResultToRespCategorizationResult(
+	result *models.CategorizationResult) *CategorizationResult {
+	changes := make([]*CategorizationChange, len(result.Changes))
+
+	for idx, change := range result.Changes {
+		changes[idx] = &CategorizationChange{
+			TransactionID:   change.TransactionID,
+			TransactionDate: change.TransactionDate,
+			RuleIDs:         change.RuleIDs,
+			Before:          TransactionToRespTransaction(change.Before),
+			After:           TransactionToRespTransaction(change.After),
+		}
+	}
+
+	return &CategorizationResult{
+		AccountID:           result.AccountID,
+		DryRun:              result.DryRun,
+		TransactionsChecked: result.TransactionsChecked,
+		Changes:             changes,
+	}
+}
@@ -0,0 +1,44 @@
+package response
+
+import (
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// GnuCashImportSummary is the outcome of a GnuCash book import
+type GnuCashImportSummary struct {
+	AccountsCreated      int                      `json:"accountsCreated"`
+	AccountsReused       int                      `json:"accountsReused"`
+	TransactionsImported int                      `json:"transactionsImported"`
+	TransactionsSkipped  int                      `json:"transactionsSkipped"`
+	Accounts             []*GnuCashAccountSummary `json:"accounts"`
+	Balanced             bool                     `json:"balanced"`
+}
+
+type GnuCashAccountSummary struct {
+	GnuCashID       string `json:"gnuCashID"`
+	GnuCashType     string `json:"gnuCashType"`
+	AccountID       uint64 `json:"accountID"`
+	AccountFullName string `json:"accountFullName"`
+	GnuCashBalance  int64  `json:"gnuCashBalance"`
+	LedgerBalance   int64  `json:"ledgerBalance"`
+	Difference      int64  `json:"difference"`
+}
+
+// GnuCashImportSummaryToResp converts models.GnuCashImportSummary to GnuCashImportSummary
+func GnuCashImportSummaryToResp(summary *models.GnuCashImportSummary) *GnuCashImportSummary {
+	respSummary := GnuCashImportSummary{
+		AccountsCreated:      summary.AccountsCreated,
+		AccountsReused:       summary.AccountsReused,
+		TransactionsImported: summary.TransactionsImported,
+		TransactionsSkipped:  summary.TransactionsSkipped,
+		Accounts:             []*GnuCashAccountSummary{},
+		Balanced:             summary.Balanced,
+	}
+
+	for _, account := range summary.Accounts {
+		respAccount := GnuCashAccountSummary(*account)
+		respSummary.Accounts = append(respSummary.Accounts, &respAccount)
+	}
+
+	return &respSummary
+}
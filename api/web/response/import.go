@@ -0,0 +1,146 @@
+package response
+
+import (
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// ImportSet is for use in imports controller responses
+type ImportSet struct {
+	Imports []*Import `json:"imports"`
+}
+
+type Import struct {
+	ImportID        uint64 `json:"importID"`
+	AccountID       uint64 `json:"accountID"`
+	OffsetAccountID uint64 `json:"offsetAccountID"`
+	// CategoryParentID is 0 when categories are not mapped to accounts
+	CategoryParentID uint64                 `json:"categoryParentID"`
+	Categories       []*ImportCategory      `json:"categories,omitempty"`
+	ImportFormat     string                 `json:"importFormat"`
+	ImportFilename   string                 `json:"importFilename"`
+	ImportStatus     datastore.ImportStatus `json:"importStatus"`
+	ImportDate       time.Time              `json:"importDate"`
+	Lines            []*ImportLine          `json:"lines,omitempty"`
+	Errors           []*ImportError         `json:"errors,omitempty"`
+	// OpeningBalance and ClosingBalance are omitted when the statement does not report them
+	OpeningBalance *ImportBalance `json:"openingBalance,omitempty"`
+	ClosingBalance *ImportBalance `json:"closingBalance,omitempty"`
+}
+
+// ImportBalance is a balance reported by the statement, positive when the account is in credit with the bank
+type ImportBalance struct {
+	BalanceDate time.Time `json:"balanceDate"`
+	Balance     int64     `json:"balance"`
+}
+
+type ImportLine struct {
+	ImportLineID uint64    `json:"importLineID"`
+	ExternalID   string    `json:"externalID"`
+	LineDate     time.Time `json:"lineDate"`
+	// LineValueDate is omitted when the statement does not give one
+	LineValueDate *time.Time `json:"lineValueDate,omitempty"`
+	LineAmount    int64      `json:"lineAmount"`
+	LineComment   string     `json:"lineComment"`
+	LineReference string     `json:"lineReference"`
+	IsDuplicate   bool       `json:"isDuplicate"`
+	LineCategory  string     `json:"lineCategory"`
+	// TransactionID is set once the line is posted
+	TransactionID uint64             `json:"transactionID,omitempty"`
+	LineSplits    []*ImportLineSplit `json:"lineSplits,omitempty"`
+	// Suggestions are the accounts suggested for a line of a preview without a category
+	Suggestions []*AccountSuggestion `json:"suggestions,omitempty"`
+}
+
+type ImportCategory struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	IsIncome    bool   `json:"isIncome"`
+}
+
+type ImportLineSplit struct {
+	Category string `json:"category"`
+	Memo     string `json:"memo"`
+	Amount   int64  `json:"amount"`
+}
+
+// ImportError is a row of the uploaded file that could not be read
+type ImportError struct {
+	RowNumber    int    `json:"rowNumber"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+// ImportToRespImport converts models.Import to Import
+func ImportToRespImport(myImport *models.Import) *Import {
+	lines := make([]*ImportLine, len(myImport.Lines))
+
+	for idx, line := range myImport.Lines {
+		lines[idx] = &ImportLine{
+			ImportLineID:  line.ImportLineID,
+			ExternalID:    line.ExternalID,
+			LineDate:      line.LineDate,
+			LineValueDate: nil,
+			LineAmount:    line.LineAmount,
+			LineComment:   line.LineComment,
+			LineReference: line.LineReference,
+			IsDuplicate:   line.IsDuplicate,
+			LineCategory:  line.LineCategory,
+			TransactionID: uint64(line.TransactionID.Int64), //nolint:gosec
+			LineSplits:    make([]*ImportLineSplit, len(line.LineSplits)),
+			Suggestions:   nil,
+		}
+
+		if suggestions, ok := myImport.Suggestions[line.ImportLineID]; ok {
+			lines[idx].Suggestions = ConvertAccountSuggestionsToRespAccountSuggestions(suggestions)
+		}
+
+		if line.LineValueDate.Valid {
+			lines[idx].LineValueDate = &line.LineValueDate.Time
+		}
+
+		for splitIdx, split := range line.LineSplits {
+			lines[idx].LineSplits[splitIdx] = (*ImportLineSplit)(split)
+		}
+	}
+
+	importErrors := make([]*ImportError, len(myImport.Errors))
+
+	for idx, importError := range myImport.Errors {
+		importErrors[idx] = &ImportError{RowNumber: importError.RowNumber, ErrorMessage: importError.ErrorMessage}
+	}
+
+	categories := make([]*ImportCategory, len(myImport.Categories))
+
+	for idx, category := range myImport.Categories {
+		categories[idx] = (*ImportCategory)(category)
+	}
+
+	return &Import{
+		ImportID:         myImport.ImportID,
+		AccountID:        myImport.AccountID,
+		OffsetAccountID:  myImport.OffsetAccountID,
+		CategoryParentID: myImport.CategoryParentID,
+		Categories:       categories,
+		ImportFormat:     myImport.ImportFormat,
+		ImportFilename:   myImport.ImportFilename,
+		ImportStatus:     myImport.ImportStatus,
+		ImportDate:       myImport.ImportDate,
+		Lines:            lines,
+		Errors:           importErrors,
+		OpeningBalance:   (*ImportBalance)(myImport.OpeningBalance),
+		ClosingBalance:   (*ImportBalance)(myImport.ClosingBalance),
+	}
+}
+
+// ConvertImportsToRespImportSet converts []*models.Import to ImportSet
+func ConvertImportsToRespImportSet(imports []*models.Import) *ImportSet {
+	respImports := make([]*Import, len(imports))
+
+	for idx := range imports {
+		respImports[idx] = ImportToRespImport(imports[idx])
+	}
+
+	return &ImportSet{Imports: respImports}
+}
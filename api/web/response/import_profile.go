@@ -0,0 +1,60 @@
+package response
+
+import (
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// ImportProfileSet is for use in imports controller responses
+type ImportProfileSet struct {
+	Profiles []*ImportProfile `json:"profiles"`
+}
+
+type ImportProfile struct {
+	ProfileID         uint64 `json:"profileID"`
+	ProfileName       string `json:"profileName"`
+	OffsetAccountID   uint64 `json:"offsetAccountID"`
+	Delimiter         string `json:"delimiter"`
+	SkipRows          int    `json:"skipRows"`
+	DateColumn        int    `json:"dateColumn"`
+	AmountColumn      int    `json:"amountColumn"`
+	DebitColumn       int    `json:"debitColumn"`
+	CreditColumn      int    `json:"creditColumn"`
+	DescriptionColumn int    `json:"descriptionColumn"`
+	ReferenceColumn   int    `json:"referenceColumn"`
+	ExternalIDColumn  int    `json:"externalIDColumn"`
+	DateFormat        string `json:"dateFormat"`
+	DecimalSeparator  string `json:"decimalSeparator"`
+	SignConvention    string `json:"signConvention"`
+}
+
+// ImportProfileToRespImportProfile converts models.ImportProfile to ImportProfile
+func ImportProfileToRespImportProfile(profile *models.ImportProfile) *ImportProfile {
+	return &ImportProfile{
+		ProfileID:         profile.ProfileID,
+		ProfileName:       profile.ProfileName,
+		OffsetAccountID:   profile.OffsetAccountID,
+		Delimiter:         profile.CSV.Delimiter,
+		SkipRows:          profile.CSV.SkipRows,
+		DateColumn:        profile.CSV.DateColumn,
+		AmountColumn:      profile.CSV.AmountColumn,
+		DebitColumn:       profile.CSV.DebitColumn,
+		CreditColumn:      profile.CSV.CreditColumn,
+		DescriptionColumn: profile.CSV.DescriptionColumn,
+		ReferenceColumn:   profile.CSV.ReferenceColumn,
+		ExternalIDColumn:  profile.CSV.ExternalIDColumn,
+		DateFormat:        profile.CSV.DateFormat,
+		DecimalSeparator:  profile.CSV.DecimalSeparator,
+		SignConvention:    profile.CSV.SignConvention,
+	}
+}
+
+// ConvertImportProfilesToRespImportProfileSet converts []*models.ImportProfile to ImportProfileSet
+func ConvertImportProfilesToRespImportProfileSet(profiles []*models.ImportProfile) *ImportProfileSet {
+	respProfiles := make([]*ImportProfile, len(profiles))
+
+	for idx := range profiles {
+		respProfiles[idx] = ImportProfileToRespImportProfile(profiles[idx])
+	}
+
+	return &ImportProfileSet{Profiles: respProfiles}
+}
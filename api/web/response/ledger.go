@@ -0,0 +1,20 @@
+package response
+
+import (
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// LedgerImportSummary is the outcome of a ledger-cli or hledger journal import
+type LedgerImportSummary struct {
+	AccountsCreated      int `json:"accountsCreated"`
+	AccountsReused       int `json:"accountsReused"`
+	TransactionsImported int `json:"transactionsImported"`
+	TransactionsSkipped  int `json:"transactionsSkipped"`
+}
+
+// LedgerImportSummaryToResp converts models.LedgerImportSummary to LedgerImportSummary
+func LedgerImportSummaryToResp(summary *models.LedgerImportSummary) *LedgerImportSummary {
+	respSummary := LedgerImportSummary(*summary)
+
+	return &respSummary
+}
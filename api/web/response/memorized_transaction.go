@@ -0,0 +1,44 @@
+package response
+
+import (
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// MemorizedTransactionSet is for use in memorized transaction controller responses
+type MemorizedTransactionSet struct {
+	MemorizedTransactions []*MemorizedTransaction `json:"memorizedTransactions"`
+}
+
+// MemorizedTransaction is a named template of a multi-line transaction
+type MemorizedTransaction struct {
+	MemorizedID          uint64                     `json:"memorizedID"`
+	MemorizedName        string                     `json:"memorizedName"`
+	TransactionComment   string                     `json:"transactionComment"`
+	TransactionReference string                     `json:"transactionReference"`
+	Lines                []*datastore.MemorizedLine `json:"lines"`
+}
+
+// MemorizedTransactionToRespMemorizedTransaction converts models.MemorizedTransaction to MemorizedTransaction
+func MemorizedTransactionToRespMemorizedTransaction(memorized *models.MemorizedTransaction) *MemorizedTransaction {
+	return &MemorizedTransaction{
+		MemorizedID:          memorized.MemorizedID,
+		MemorizedName:        memorized.MemorizedName,
+		TransactionComment:   memorized.TransactionComment,
+		TransactionReference: memorized.TransactionReference,
+		Lines:                memorized.Lines,
+	}
+}
+
+// ConvertMemorizedTransactionsToRespMemorizedTransactionSet converts []*models.MemorizedTransaction to
+// MemorizedTransactionSet
+func ConvertMemorizedTransactionsToRespMemorizedTransactionSet(
+	memorizedSet []*models.MemorizedTransaction) *MemorizedTransactionSet {
+	respMemorizedSet := make([]*MemorizedTransaction, len(memorizedSet))
+
+	for idx := range memorizedSet {
+		respMemorizedSet[idx] = MemorizedTransactionToRespMemorizedTransaction(memorizedSet[idx])
+	}
+
+	return &MemorizedTransactionSet{MemorizedTransactions: respMemorizedSet}
+}
@@ -0,0 +1,161 @@
+package response
+
+import (
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// ReconciliationSessionSet is for use in reconciliations controller responses, the latest statement first
+type ReconciliationSessionSet struct {
+	Sessions []*ReconciliationSession `json:"sessions"`
+}
+
+// ReconciliationSession is a statement being reconciled, it can be finished when difference is zero
+type ReconciliationSession struct {
+	SessionID        uint64                         `json:"sessionID"`
+	AccountID        uint64                         `json:"accountID"`
+	StatementDate    time.Time                      `json:"statementDate"`
+	StatementBalance int64                          `json:"statementBalance"`
+	OpeningBalance   int64                          `json:"openingBalance"`
+	ClearedBalance   int64                          `json:"clearedBalance"`
+	Difference       int64                          `json:"difference"`
+	SessionStatus    datastore.ReconciliationStatus `json:"sessionStatus"`
+	CreatedDate      time.Time                      `json:"createdDate"`
+	FinishedDate     *time.Time                     `json:"finishedDate,omitempty"`
+	ClearedCount     int                            `json:"clearedCount"`
+	FinishedBy       string                         `json:"finishedBy"`
+	TransactionIDs   []uint64                       `json:"transactionIDs"`
+}
+
+// ReconciliationSessionToRespReconciliationSession converts models.ReconciliationSession to ReconciliationSession
+func ReconciliationSessionToRespReconciliationSession(
+	session *models.ReconciliationSession) *ReconciliationSession {
+	respSession := ReconciliationSession{
+		SessionID:        session.SessionID,
+		AccountID:        session.AccountID,
+		StatementDate:    session.StatementDate,
+		StatementBalance: session.StatementBalance,
+		OpeningBalance:   session.OpeningBalance,
+		ClearedBalance:   session.ClearedBalance,
+		Difference:       session.Difference,
+		SessionStatus:    session.SessionStatus,
+		CreatedDate:      session.CreatedDate,
+		FinishedDate:     nil,
+		ClearedCount:     session.ClearedCount,
+		FinishedBy:       session.FinishedBy,
+		TransactionIDs:   session.TransactionIDs,
+	}
+
+	if session.FinishedDate.Valid {
+		respSession.FinishedDate = &session.FinishedDate.Time
+	}
+
+	if respSession.TransactionIDs == nil {
+		respSession.TransactionIDs = []uint64{}
+	}
+
+	return &respSession
+}
+
+// ConvertReconciliationSessionsToRespReconciliationSessionSet converts []*models.ReconciliationSession to
+// ReconciliationSessionSet
+func ConvertReconciliationSessionsToRespReconciliationSessionSet(
+	sessions []*models.ReconciliationSession) *ReconciliationSessionSet {
+	respSessions := make([]*ReconciliationSession, len(sessions))
+
+	for idx := range sessions {
+		respSessions[idx] = ReconciliationSessionToRespReconciliationSession(sessions[idx])
+	}
+
+	return &ReconciliationSessionSet{Sessions: respSessions}
+}
+
+// ReconciliationReport is what a finished session cleared, what was outstanding on the account as of the statement
+// date, and the cleared transactions that changed afterwards
+type ReconciliationReport struct {
+	Session                  *ReconciliationSession  `json:"session"`
+	ClearedBalance           int64                   `json:"clearedBalance"`
+	OutstandingDeposits      []*TransactionLedger    `json:"outstandingDeposits"`
+	OutstandingDepositsTotal int64                   `json:"outstandingDepositsTotal"`
+	OutstandingPayments      []*TransactionLedger    `json:"outstandingPayments"`
+	OutstandingPaymentsTotal int64                   `json:"outstandingPaymentsTotal"`
+	BookBalance              int64                   `json:"bookBalance"`
+	LaterChanges             []*ReconciliationChange `json:"laterChanges"`
+}
+
+// ReconciliationChange is a cleared transaction as it was cleared and as it is now, the current fields are empty
+// when it was deleted
+type ReconciliationChange struct {
+	TransactionID                   uint64     `json:"transactionID"`
+	TransactionDate                 time.Time  `json:"transactionDate"`
+	TransactionComment              string     `json:"transactionComment"`
+	TransactionReference            string     `json:"transactionReference"`
+	ClearedAmount                   int64      `json:"clearedAmount"`
+	IsDeleted                       bool       `json:"isDeleted"`
+	IsChanged                       bool       `json:"isChanged"`
+	IsUnreconciled                  bool       `json:"isUnreconciled"`
+	CurrentTransactionDate          *time.Time `json:"currentTransactionDate,omitempty"`
+	CurrentTransactionComment       string     `json:"currentTransactionComment"`
+	CurrentTransactionReference     string     `json:"currentTransactionReference"`
+	CurrentAmount                   int64      `json:"currentAmount"`
+	CurrentTransactionModifiedDate  *time.Time `json:"currentTransactionModifiedDate,omitempty"`
+	CurrentTransactionReconcileDate *time.Time `json:"currentTransactionReconcileDate,omitempty"`
+}
+
+// ReconciliationReportToRespReconciliationReport converts models.ReconciliationReport to ReconciliationReport
+func ReconciliationReportToRespReconciliationReport(report *models.ReconciliationReport) *ReconciliationReport {
+	respReport := ReconciliationReport{
+		Session:                  ReconciliationSessionToRespReconciliationSession(report.Session),
+		ClearedBalance:           report.ClearedBalance,
+		OutstandingDeposits:      make([]*TransactionLedger, len(report.OutstandingDeposits)),
+		OutstandingDepositsTotal: report.OutstandingDepositsTotal,
+		OutstandingPayments:      make([]*TransactionLedger, len(report.OutstandingPayments)),
+		OutstandingPaymentsTotal: report.OutstandingPaymentsTotal,
+		BookBalance:              report.BookBalance,
+		LaterChanges:             make([]*ReconciliationChange, len(report.LaterChanges)),
+	}
+
+	for idx := range report.OutstandingDeposits {
+		respReport.OutstandingDeposits[idx] = ConvertTransactionReconcileToRespTransactionLedger(
+			report.OutstandingDeposits[idx])
+	}
+
+	for idx := range report.OutstandingPayments {
+		respReport.OutstandingPayments[idx] = ConvertTransactionReconcileToRespTransactionLedger(
+			report.OutstandingPayments[idx])
+	}
+
+	for idx, change := range report.LaterChanges {
+		respChange := ReconciliationChange{
+			TransactionID:                   change.TransactionID,
+			TransactionDate:                 change.TransactionDate,
+			TransactionComment:              change.TransactionComment,
+			TransactionReference:            change.TransactionReference,
+			ClearedAmount:                   change.ClearedAmount,
+			IsDeleted:                       change.IsDeleted,
+			IsChanged:                       change.IsChanged,
+			IsUnreconciled:                  change.IsUnreconciled,
+			CurrentTransactionDate:          nil,
+			CurrentTransactionComment:       change.CurrentTransactionComment,
+			CurrentTransactionReference:     change.CurrentTransactionReference,
+			CurrentAmount:                   change.CurrentAmount,
+			CurrentTransactionModifiedDate:  nil,
+			CurrentTransactionReconcileDate: nil,
+		}
+
+		if !change.IsDeleted {
+			respChange.CurrentTransactionDate = &change.CurrentTransactionDate
+			respChange.CurrentTransactionModifiedDate = &change.CurrentTransactionModifiedDate
+		}
+
+		if !change.CurrentTransactionReconcileDate.IsZero() {
+			respChange.CurrentTransactionReconcileDate = &change.CurrentTransactionReconcileDate
+		}
+
+		respReport.LaterChanges[idx] = &respChange
+	}
+
+	return &respReport
+}
@@ -0,0 +1,214 @@
+package response
+
+import (
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+	"github.com/mimirsoft/mimirledger/api/money"
+	"github.com/mimirsoft/mimirledger/api/pdf"
+)
+
+const (
+	pdfDateFormat       = "January 2, 2006"
+	pdfLedgerDateFormat = "2006-01-02"
+	// transactions do not carry their account's decimals, ledger tables use the account default
+	pdfLedgerDecimals    = 2
+	pdfAmountWidth       = 80
+	pdfLedgerDateWidth   = 58
+	pdfLedgerRefWidth    = 60
+	pdfLedgerAmountWidth = 70
+	pdfLedgerDebitCell   = 3
+	pdfLedgerCreditCell  = 4
+)
+
+// ReportOutputToPDFReport lays out a report output as a printable statement headed with title, the report name is
+// the title when none is given
+func ReportOutputToPDFReport(title string, rpt *models.ReportOutput) *pdf.Report {
+	subtitle := rpt.ReportName
+	if title == "" {
+		title, subtitle = rpt.ReportName, ""
+	}
+
+	myReport := pdf.Report{
+		Title:    title,
+		Subtitle: subtitle,
+		Period:   reportOutputPeriod(rpt),
+		Tables:   accountTreeToPDFTables(rpt.AccountTree),
+	}
+
+	if summary := reportDataToPDFSummary(rpt.ReportData); summary != nil {
+		myReport.Tables = append(myReport.Tables, summary)
+	}
+
+	for idx := range rpt.ReportData {
+		if len(rpt.ReportData[idx].NetTransactions) > 0 {
+			myReport.Tables = append(myReport.Tables, ledgerToPDFTable(rpt.ReportData[idx].NetTransactions))
+		}
+	}
+
+	return &myReport
+}
+
+func reportOutputPeriod(rpt *models.ReportOutput) string {
+	if rpt.DataSetType == datastore.ReportDataSetTypeBalance {
+		return "As of " + rpt.EndDate.Format(pdfDateFormat)
+	}
+
+	return rpt.StartDate.Format(pdfDateFormat) + " - " + rpt.EndDate.Format(pdfDateFormat)
+}
+
+// accountTreeToPDFTables makes one table for each top level account of the tree.  Accounts with sub-accounts
+// are listed as a heading, followed by their sub-accounts and a bold total.
+func accountTreeToPDFTables(tree []*models.ReportOutputAccount) []*pdf.Table {
+	var (
+		tables  []*pdf.Table
+		current *pdf.Table
+		open    []*models.ReportOutputAccount // accounts still waiting for their total row
+	)
+
+	closeTotals := func(level int) {
+		for len(open) > 0 && open[len(open)-1].Level >= level {
+			acct := open[len(open)-1]
+			open = open[:len(open)-1]
+
+			current.Rows = append(current.Rows, pdf.Row{
+				Cells:     []string{"Total " + acct.AccountName, money.FormatAccounting(acct.Subtotal, acct.AccountDecimals)},
+				Indent:    max(acct.Level-1, 0),
+				Bold:      true,
+				RuleAbove: true,
+			})
+		}
+	}
+
+	for idx, acct := range tree {
+		closeTotals(acct.Level)
+
+		hasChildren := idx+1 < len(tree) && tree[idx+1].Level > acct.Level
+
+		if acct.Level == 0 {
+			current = &pdf.Table{
+				Title: acct.AccountName,
+				Columns: []pdf.Column{
+					{Header: "", Width: 0, AlignRight: false},
+					{Header: "", Width: pdfAmountWidth, AlignRight: true},
+				},
+				Rows: nil,
+			}
+			tables = append(tables, current)
+
+			if !hasChildren {
+				current.Rows = append(current.Rows, pdf.Row{ //nolint:exhaustruct
+					Cells: []string{acct.AccountName, money.FormatAccounting(acct.Subtotal, acct.AccountDecimals)},
+				})
+
+				continue
+			}
+
+			// the top level account is the table title, its own amount is only shown when it has one
+			if acct.Amount != 0 {
+				current.Rows = append(current.Rows, pdf.Row{ //nolint:exhaustruct
+					Cells: []string{acct.AccountName, money.FormatAccounting(acct.Amount, acct.AccountDecimals)},
+				})
+			}
+
+			open = append(open, acct)
+
+			continue
+		}
+
+		if hasChildren {
+			amount := ""
+			if acct.Amount != 0 {
+				amount = money.FormatAccounting(acct.Amount, acct.AccountDecimals)
+			}
+
+			current.Rows = append(current.Rows, pdf.Row{ //nolint:exhaustruct
+				Cells:  []string{acct.AccountName, amount},
+				Indent: acct.Level - 1,
+			})
+			open = append(open, acct)
+
+			continue
+		}
+
+		// the subtotal includes any sub-accounts left out by the depth limit
+		current.Rows = append(current.Rows, pdf.Row{ //nolint:exhaustruct
+			Cells:  []string{acct.AccountName, money.FormatAccounting(acct.Subtotal, acct.AccountDecimals)},
+			Indent: acct.Level - 1,
+		})
+	}
+
+	closeTotals(0)
+
+	return tables
+}
+
+func reportDataToPDFSummary(dataSet []*models.ReportOutputData) *pdf.Table {
+	var rows []pdf.Row
+
+	for idx := range dataSet {
+		if dataSet[idx].Income != 0 {
+			rows = append(rows, pdf.Row{ //nolint:exhaustruct
+				Cells: []string{"Income", money.FormatAccounting(dataSet[idx].Income, pdfLedgerDecimals)},
+			})
+		}
+
+		if dataSet[idx].Expense != 0 {
+			rows = append(rows, pdf.Row{ //nolint:exhaustruct
+				Cells: []string{"Expense", money.FormatAccounting(dataSet[idx].Expense, pdfLedgerDecimals)},
+			})
+		}
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	return &pdf.Table{
+		Title: "Summary",
+		Columns: []pdf.Column{
+			{Header: "", Width: 0, AlignRight: false},
+			{Header: "", Width: pdfAmountWidth, AlignRight: true},
+		},
+		Rows: rows,
+	}
+}
+
+func ledgerToPDFTable(txns []*models.TransactionLedger) *pdf.Table {
+	table := pdf.Table{
+		Title: "Transactions",
+		Columns: []pdf.Column{
+			{Header: "Date", Width: pdfLedgerDateWidth, AlignRight: false},
+			{Header: "Reference", Width: pdfLedgerRefWidth, AlignRight: false},
+			{Header: "Comment", Width: 0, AlignRight: false},
+			{Header: "Debit", Width: pdfLedgerAmountWidth, AlignRight: true},
+			{Header: "Credit", Width: pdfLedgerAmountWidth, AlignRight: true},
+		},
+		Rows: make([]pdf.Row, 0, len(txns)+1),
+	}
+
+	var debits, credits int64
+
+	for _, txn := range txns {
+		amount := money.Format(int64(txn.TransactionDCAmount), pdfLedgerDecimals) //nolint:gosec
+		cells := []string{txn.TransactionDate.Format(pdfLedgerDateFormat), txn.TransactionReference,
+			txn.TransactionComment, amount, ""}
+
+		if txn.DebitOrCredit == datastore.AccountSignCredit {
+			cells[pdfLedgerDebitCell], cells[pdfLedgerCreditCell] = "", amount
+			credits += int64(txn.TransactionDCAmount) //nolint:gosec
+		} else {
+			debits += int64(txn.TransactionDCAmount) //nolint:gosec
+		}
+
+		table.Rows = append(table.Rows, pdf.Row{Cells: cells}) //nolint:exhaustruct
+	}
+
+	table.Rows = append(table.Rows, pdf.Row{
+		Cells:     []string{"", "", "Total", money.Format(debits, pdfLedgerDecimals), money.Format(credits, pdfLedgerDecimals)},
+		Indent:    0,
+		Bold:      true,
+		RuleAbove: true,
+	})
+
+	return &table
+}
@@ -0,0 +1,35 @@
+package response
+
+import (
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// ReportTemplateSet is for use in report template controller responses
+type ReportTemplateSet struct {
+	Templates []*ReportTemplate `json:"templates"`
+}
+
+type ReportTemplate struct {
+	TemplateID   uint64                       `json:"templateID"`
+	TemplateName string                       `json:"templateName"`
+	TemplateType datastore.ReportTemplateType `json:"templateType"`
+	TemplateBody string                       `json:"templateBody"`
+}
+
+func ReportTemplateToRespReportTemplate(tmpl *models.ReportTemplate) *ReportTemplate {
+	myTemplate := ReportTemplate(*tmpl)
+
+	return &myTemplate
+}
+
+// ConvertReportTemplatesToRespReportTemplateSet converts []*models.ReportTemplate to ReportTemplateSet
+func ConvertReportTemplatesToRespReportTemplateSet(templates []*models.ReportTemplate) *ReportTemplateSet {
+	mset := make([]*ReportTemplate, len(templates))
+
+	for idx := range templates {
+		mset[idx] = ReportTemplateToRespReportTemplate(templates[idx])
+	}
+
+	return &ReportTemplateSet{Templates: mset}
+}
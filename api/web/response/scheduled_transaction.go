@@ -0,0 +1,97 @@
+package response
+
+import (
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// ScheduledTransactionSet is for use in schedules controller responses
+type ScheduledTransactionSet struct {
+	ScheduledTransactions []*ScheduledTransaction `json:"scheduledTransactions"`
+}
+
+// ScheduledTransaction is a transaction posted on each day of its recurrenceRule, endDate is null when it has no end.
+// nextDue is the day the scheduler posts it from.
+type ScheduledTransaction struct {
+	ScheduledID    uint64                        `json:"scheduledID"`
+	ScheduledName  string                        `json:"scheduledName"`
+	RecurrenceRule string                        `json:"recurrenceRule"`
+	StartDate      time.Time                     `json:"startDate"`
+	EndDate        *time.Time                    `json:"endDate"`
+	IsEnabled      bool                          `json:"isEnabled"`
+	Template       datastore.TransactionTemplate `json:"template"`
+	NextDue        time.Time                     `json:"nextDue"`
+}
+
+// ScheduledOccurrenceSet is the occurrences of schedules in date order
+type ScheduledOccurrenceSet struct {
+	Occurrences []*ScheduledOccurrence `json:"occurrences"`
+}
+
+// ScheduledOccurrence is a day a schedule falls on, occurrenceStatus is PENDING until it is posted or skipped and
+// transactionID is 0 until it is posted
+type ScheduledOccurrence struct {
+	ScheduledID      uint64                     `json:"scheduledID"`
+	ScheduledName    string                     `json:"scheduledName"`
+	OccurrenceDate   time.Time                  `json:"occurrenceDate"`
+	OccurrenceStatus datastore.OccurrenceStatus `json:"occurrenceStatus"`
+	TransactionID    uint64                     `json:"transactionID"`
+}
+
+// ScheduledTransactionToRespScheduledTransaction converts models.ScheduledTransaction to ScheduledTransaction
+func ScheduledTransactionToRespScheduledTransaction(scheduled *models.ScheduledTransaction) *ScheduledTransaction {
+	var endDate *time.Time
+	if scheduled.EndDate.Valid {
+		endDate = &scheduled.EndDate.Time
+	}
+
+	return &ScheduledTransaction{
+		ScheduledID:    scheduled.ScheduledID,
+		ScheduledName:  scheduled.ScheduledName,
+		RecurrenceRule: scheduled.RecurrenceRule,
+		StartDate:      scheduled.StartDate,
+		EndDate:        endDate,
+		IsEnabled:      scheduled.IsEnabled,
+		Template:       scheduled.Template,
+		NextDue:        scheduled.NextDue,
+	}
+}
+
+// ConvertScheduledTransactionsToRespScheduledTransactionSet converts []*models.ScheduledTransaction to
+// ScheduledTransactionSet
+func ConvertScheduledTransactionsToRespScheduledTransactionSet(
+	scheduledSet []*models.ScheduledTransaction) *ScheduledTransactionSet {
+	respScheduledSet := make([]*ScheduledTransaction, len(scheduledSet))
+
+	for idx := range scheduledSet {
+		respScheduledSet[idx] = ScheduledTransactionToRespScheduledTransaction(scheduledSet[idx])
+	}
+
+	return &ScheduledTransactionSet{ScheduledTransactions: respScheduledSet}
+}
+
+// ScheduledOccurrenceToRespScheduledOccurrence converts models.ScheduledOccurrence to ScheduledOccurrence
+func ScheduledOccurrenceToRespScheduledOccurrence(occurrence *models.ScheduledOccurrence) *ScheduledOccurrence {
+	return &ScheduledOccurrence{
+		ScheduledID:      occurrence.ScheduledID,
+		ScheduledName:    occurrence.ScheduledName,
+		OccurrenceDate:   occurrence.OccurrenceDate,
+		OccurrenceStatus: occurrence.OccurrenceStatus,
+		TransactionID:    occurrence.TransactionID,
+	}
+}
+
+// ConvertScheduledOccurrencesToRespScheduledOccurrenceSet converts []*models.ScheduledOccurrence to
+// ScheduledOccurrenceSet
+func ConvertScheduledOccurrencesToRespScheduledOccurrenceSet(
+	occurrences []*models.ScheduledOccurrence) *ScheduledOccurrenceSet {
+	respOccurrences := make([]*ScheduledOccurrence, len(occurrences))
+
+	for idx := range occurrences {
+		respOccurrences[idx] = ScheduledOccurrenceToRespScheduledOccurrence(occurrences[idx])
+	}
+
+	return &ScheduledOccurrenceSet{Occurrences: respOccurrences}
+}
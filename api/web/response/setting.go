@@ -0,0 +1,33 @@
+package response
+
+import (
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// SettingSet is for use in settings controller responses
+type SettingSet struct {
+	Settings []*Setting `json:"settings"`
+}
+
+type Setting struct {
+	SettingName  string `json:"settingName"`
+	SettingValue string `json:"settingValue"`
+}
+
+func SettingToRespSetting(setting *models.Setting) *Setting {
+	return &Setting{
+		SettingName:  setting.SettingName,
+		SettingValue: setting.SettingValue,
+	}
+}
+
+// ConvertSettingsToRespSettingSet converts []*models.Setting to SettingSet
+func ConvertSettingsToRespSettingSet(settings []*models.Setting) *SettingSet {
+	mset := make([]*Setting, len(settings))
+
+	for idx := range settings {
+		mset[idx] = SettingToRespSetting(settings[idx])
+	}
+
+	return &SettingSet{Settings: mset}
+}
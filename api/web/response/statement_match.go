@@ -0,0 +1,54 @@
+package response
+
+import (
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// StatementMatchSet is the matches of the unmatched lines of an import, transactionID is 0 for a line that is
+// offered as a new transaction
+type StatementMatchSet struct {
+	ImportID uint64            `json:"importID"`
+	Matches  []*StatementMatch `json:"matches"`
+}
+
+type StatementMatch struct {
+	ImportLineID  uint64            `json:"importLineID"`
+	TransactionID uint64            `json:"transactionID"`
+	Score         float64           `json:"score"`
+	IsAmbiguous   bool              `json:"isAmbiguous"`
+	Candidates    []*MatchCandidate `json:"candidates"`
+}
+
+type MatchCandidate struct {
+	TransactionID        uint64    `json:"transactionID"`
+	TransactionDate      time.Time `json:"transactionDate"`
+	TransactionComment   string    `json:"transactionComment"`
+	TransactionReference string    `json:"transactionReference"`
+	Score                float64   `json:"score"`
+}
+
+// ConvertStatementMatchesToRespStatementMatchSet converts []*models.StatementMatch to StatementMatchSet
+func ConvertStatementMatchesToRespStatementMatchSet(importID uint64,
+	matches []*models.StatementMatch) *StatementMatchSet {
+	respMatches := make([]*StatementMatch, len(matches))
+
+	for idx, match := range matches {
+		candidates := make([]*MatchCandidate, len(match.Candidates))
+
+		for cIdx := range match.Candidates {
+			candidates[cIdx] = (*MatchCandidate)(match.Candidates[cIdx])
+		}
+
+		respMatches[idx] = &StatementMatch{
+			ImportLineID:  match.ImportLineID,
+			TransactionID: match.TransactionID,
+			Score:         match.Score,
+			IsAmbiguous:   match.IsAmbiguous,
+			Candidates:    candidates,
+		}
+	}
+
+	return &StatementMatchSet{ImportID: importID, Matches: respMatches}
+}
@@ -25,11 +25,13 @@ type Transaction struct {
 	DebitCreditSet       []*TransactionDebitCredit `json:"debitCreditSet"`
 }
 type TransactionDebitCredit struct {
-	TransactionDCID     uint64                `json:"transactionDCID"` //nolint:tagliatelle
-	TransactionID       uint64                `json:"transactionID"`
-	AccountID           uint64                `json:"accountID"`
-	TransactionDCAmount uint64                `json:"transactionDCAmount"` //nolint:tagliatelle
-	DebitOrCredit       datastore.AccountSign `json:"debitOrCredit"`
+	TransactionDCID          uint64                `json:"transactionDCID"` //nolint:tagliatelle
+	TransactionID            uint64                `json:"transactionID"`
+	AccountID                uint64                `json:"accountID"`
+	TransactionDCAmount      uint64                `json:"transactionDCAmount"` //nolint:tagliatelle
+	DebitOrCredit            datastore.AccountSign `json:"debitOrCredit"`
+	IsReconciled             bool                  `json:"isReconciled"`
+	TransactionReconcileDate time.Time             `json:"transactionReconcileDate"`
 }
 
 func TransactionToRespTransaction(trans *models.Transaction) *Transaction {
@@ -54,8 +56,15 @@ func ConvertDebitCreditsToReDebitCreditSet(dcSet []*models.TransactionDebitCredi
 	var mset = make([]*TransactionDebitCredit, len(dcSet))
 
 	for idx := range dcSet {
-		myDS := TransactionDebitCredit(*dcSet[idx])
-		mset[idx] = &myDS
+		mset[idx] = &TransactionDebitCredit{
+			TransactionDCID:          dcSet[idx].TransactionDCID,
+			TransactionID:            dcSet[idx].TransactionID,
+			AccountID:                dcSet[idx].AccountID,
+			TransactionDCAmount:      dcSet[idx].TransactionDCAmount,
+			DebitOrCredit:            dcSet[idx].DebitOrCredit,
+			IsReconciled:             dcSet[idx].IsReconciled,
+			TransactionReconcileDate: dcSet[idx].TransactionReconcileDate.Time,
+		}
 	}
 
 	return mset
@@ -77,11 +86,15 @@ type TransactionLedgerSet struct {
 	AccountSign     string               `json:"accountSign"`
 	AccountName     string               `json:"accountName"`
 	AccountFullName string               `json:"accountFullName"`
+	OpeningBalance  int64                `json:"openingBalance"`
+	TotalCount      *uint64              `json:"totalCount,omitempty"`
+	NextCursor      string               `json:"nextCursor"`
 	Transactions    []*TransactionLedger `json:"transactions"`
 }
 
 type TransactionLedger struct {
 	TransactionID            uint64    `json:"transactionID"`
+	AccountID                uint64    `json:"accountID"`
 	TransactionDate          time.Time `json:"transactionDate"`
 	TransactionReconcileDate time.Time `json:"transactionReconcileDate"`
 	TransactionComment       string    `json:"transactionComment"`
@@ -92,14 +105,23 @@ type TransactionLedger struct {
 	TransactionDCAmount  uint64                `json:"transactionDCAmount"` //nolint:tagliatelle
 	DebitOrCredit        datastore.AccountSign `json:"debitOrCredit"`
 	Split                string                `json:"split"` // this could be a check number, batch ,etc
+	RunningBalance       int64                 `json:"runningBalance"`
+	AttachmentCount      uint64                `json:"attachmentCount"`
 }
 
-// ConvertTransactionLedgerToRespTransactionLedger converts []models.TransactionLedger to TransactionLedger
+// ConvertTransactionLedgerToRespTransactionLedger converts models.AccountLedger to TransactionLedgerSet
 func ConvertTransactionLedgerToRespTransactionLedger(act *models.Account,
-	txns []*models.TransactionLedger) *TransactionLedgerSet {
-	var tas = make([]*TransactionLedger, len(txns))
-	for idx := range txns {
-		tas[idx] = ConvertTransactionLedgerToRespTransactionLeger(txns[idx])
+	ledger *models.AccountLedger) *TransactionLedgerSet {
+	var tas = make([]*TransactionLedger, len(ledger.Transactions))
+	for idx := range ledger.Transactions {
+		tas[idx] = ConvertTransactionLedgerToRespTransactionLeger(ledger.Transactions[idx])
+	}
+
+	var totalCount *uint64
+
+	if ledger.TotalCount.Valid {
+		count := uint64(ledger.TotalCount.Int64) //nolint:gosec
+		totalCount = &count
 	}
 
 	return &TransactionLedgerSet{
@@ -107,12 +129,16 @@ func ConvertTransactionLedgerToRespTransactionLedger(act *models.Account,
 		AccountName:     act.AccountName,
 		AccountFullName: act.AccountFullName,
 		AccountSign:     string(act.AccountSign),
+		OpeningBalance:  ledger.OpeningBalance,
+		TotalCount:      totalCount,
+		NextCursor:      ledger.NextCursor,
 		Transactions:    tas}
 }
 
 func ConvertTransactionLedgerToRespTransactionLeger(trans *models.TransactionLedger) *TransactionLedger {
 	respTransLedger := TransactionLedger{
 		TransactionID:            trans.TransactionID,
+		AccountID:                trans.AccountID,
 		TransactionDate:          trans.TransactionDate,
 		TransactionReconcileDate: trans.TransactionReconcileDate.Time,
 		TransactionComment:       trans.TransactionComment,
@@ -122,6 +148,8 @@ func ConvertTransactionLedgerToRespTransactionLeger(trans *models.TransactionLed
 		IsSplit:                  trans.IsSplit,
 		Split:                    trans.Split,
 		DebitOrCredit:            trans.DebitOrCredit,
+		RunningBalance:           trans.RunningBalance,
+		AttachmentCount:          trans.AttachmentCount,
 	}
 
 	return &respTransLedger
@@ -163,6 +191,7 @@ func ConvertTransactionReconcileToRespTransactionLedger(
 	trans *models.TransactionReconciliation) *TransactionLedger {
 	respTransLedger := TransactionLedger{
 		TransactionID:            trans.TransactionID,
+		AccountID:                trans.AccountID,
 		TransactionDate:          trans.TransactionDate,
 		TransactionReconcileDate: trans.TransactionReconcileDate.Time,
 		TransactionComment:       trans.TransactionComment,
@@ -176,3 +205,22 @@ func ConvertTransactionReconcileToRespTransactionLedger(
 
 	return &respTransLedger
 }
+
+// TransactionsReconcile is the transactions reconciled or unreconciled together, and the reconciled subtotal of the
+// account as of reconcileDate afterwards
+type TransactionsReconcile struct {
+	AccountID          uint64    `json:"accountID"`
+	TransactionIDs     []uint64  `json:"transactionIDs"`
+	ReconcileDate      time.Time `json:"reconcileDate"`
+	ReconciledSubtotal int64     `json:"reconciledSubtotal"`
+}
+
+// ReconcileResultToRespTransactionsReconcile converts models.ReconcileResult to TransactionsReconcile
+func ReconcileResultToRespTransactionsReconcile(result *models.ReconcileResult) *TransactionsReconcile {
+	return &TransactionsReconcile{
+		AccountID:          result.AccountID,
+		TransactionIDs:     result.TransactionIDs,
+		ReconcileDate:      result.ReconcileDate,
+		ReconciledSubtotal: result.ReconciledSubtotal,
+	}
+}
@@ -0,0 +1,62 @@
+package response
+
+import (
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// TransactionDuplicateSet is for use in transaction controller responses
+type TransactionDuplicateSet struct {
+	Duplicates []*TransactionDuplicate `json:"duplicates"`
+}
+
+// TransactionDuplicate is a pair of transactions that are probably the same, Transaction is the older one
+type TransactionDuplicate struct {
+	DuplicateID            uint64                    `json:"duplicateID"`
+	TransactionID          uint64                    `json:"transactionID"`
+	DuplicateTransactionID uint64                    `json:"duplicateTransactionID"`
+	AccountID              uint64                    `json:"accountID"`
+	DuplicateStatus        datastore.DuplicateStatus `json:"duplicateStatus"`
+	DetectedDate           time.Time                 `json:"detectedDate"`
+	Transaction            *Transaction              `json:"transaction,omitempty"`
+	Duplicate              *Transaction              `json:"duplicate,omitempty"`
+}
+
+// TransactionDuplicateToRespTransactionDuplicate converts models.TransactionDuplicate to TransactionDuplicate
+func TransactionDuplicateToRespTransactionDuplicate(myDuplicate *models.TransactionDuplicate) *TransactionDuplicate {
+	respDuplicate := TransactionDuplicate{
+		DuplicateID:            myDuplicate.DuplicateID,
+		TransactionID:          myDuplicate.TransactionID,
+		DuplicateTransactionID: myDuplicate.DuplicateTransactionID,
+		AccountID:              myDuplicate.AccountID,
+		DuplicateStatus:        myDuplicate.DuplicateStatus,
+		DetectedDate:           myDuplicate.DetectedDate,
+		Transaction:            nil,
+		Duplicate:              nil,
+	}
+
+	if myDuplicate.Transaction != nil {
+		respDuplicate.Transaction = TransactionToRespTransaction(myDuplicate.Transaction)
+	}
+
+	if myDuplicate.Duplicate != nil {
+		respDuplicate.Duplicate = TransactionToRespTransaction(myDuplicate.Duplicate)
+	}
+
+	return &respDuplicate
+}
+
+// ConvertTransactionDuplicatesToRespTransactionDuplicateSet converts []*models.TransactionDuplicate to
+// TransactionDuplicateSet
+func ConvertTransactionDuplicatesToRespTransactionDuplicateSet(
+	duplicates []*models.TransactionDuplicate) *TransactionDuplicateSet {
+	respDuplicates := make([]*TransactionDuplicate, len(duplicates))
+
+	for idx := range duplicates {
+		respDuplicates[idx] = TransactionDuplicateToRespTransactionDuplicate(duplicates[idx])
+	}
+
+	return &TransactionDuplicateSet{Duplicates: respDuplicates}
+}
@@ -0,0 +1,86 @@
+package response
+
+import (
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// TransactionJournal is a page of the general journal
+type TransactionJournal struct {
+	TotalCount   uint64                `json:"totalCount"`
+	NextCursor   string                `json:"nextCursor"`
+	Transactions []*JournalTransaction `json:"transactions"`
+}
+
+type JournalTransaction struct {
+	TransactionID            uint64    `json:"transactionID"`
+	TransactionDate          time.Time `json:"transactionDate"`
+	TransactionReconcileDate time.Time `json:"transactionReconcileDate"`
+	TransactionComment       string    `json:"transactionComment"`
+	TransactionAmount        uint64    `json:"transactionAmount"`
+	// TransactionReference could be a check number, batch ,etc
+	TransactionReference string                `json:"transactionReference"`
+	IsReconciled         bool                  `json:"isReconciled"`
+	IsSplit              bool                  `json:"isSplit"`
+	DebitCreditSet       []*JournalDebitCredit `json:"debitCreditSet"`
+}
+
+type JournalDebitCredit struct {
+	TransactionDCID          uint64                `json:"transactionDCID"` //nolint:tagliatelle
+	TransactionID            uint64                `json:"transactionID"`
+	AccountID                uint64                `json:"accountID"`
+	TransactionDCAmount      uint64                `json:"transactionDCAmount"` //nolint:tagliatelle
+	DebitOrCredit            datastore.AccountSign `json:"debitOrCredit"`
+	AccountName              string                `json:"accountName"`
+	AccountFullName          string                `json:"accountFullName"`
+	IsReconciled             bool                  `json:"isReconciled"`
+	TransactionReconcileDate time.Time             `json:"transactionReconcileDate"`
+}
+
+// ConvertJournalToRespJournal converts models.Journal to TransactionJournal
+func ConvertJournalToRespJournal(journal *models.Journal) *TransactionJournal {
+	var txns = make([]*JournalTransaction, len(journal.Transactions))
+
+	for idx := range journal.Transactions {
+		txns[idx] = convertJournalTransaction(journal.Transactions[idx])
+	}
+
+	return &TransactionJournal{
+		TotalCount:   journal.TotalCount,
+		NextCursor:   journal.NextCursor,
+		Transactions: txns,
+	}
+}
+
+func convertJournalTransaction(trans *models.JournalTransaction) *JournalTransaction {
+	dcSet := make([]*JournalDebitCredit, len(trans.DebitCreditSet))
+
+	for idx := range trans.DebitCreditSet {
+		myDC := trans.DebitCreditSet[idx]
+		dcSet[idx] = &JournalDebitCredit{
+			TransactionDCID:          myDC.TransactionDCID,
+			TransactionID:            myDC.TransactionID,
+			AccountID:                myDC.AccountID,
+			TransactionDCAmount:      myDC.TransactionDCAmount,
+			DebitOrCredit:            myDC.DebitOrCredit,
+			AccountName:              myDC.AccountName,
+			AccountFullName:          myDC.AccountFullName,
+			IsReconciled:             myDC.IsReconciled,
+			TransactionReconcileDate: myDC.TransactionReconcileDate.Time,
+		}
+	}
+
+	return &JournalTransaction{
+		TransactionID:            trans.TransactionID,
+		TransactionDate:          trans.TransactionDate,
+		TransactionReconcileDate: trans.TransactionReconcileDate.Time,
+		TransactionComment:       trans.TransactionComment,
+		TransactionAmount:        trans.TransactionAmount,
+		TransactionReference:     trans.TransactionReference,
+		IsReconciled:             trans.IsReconciled,
+		IsSplit:                  trans.IsSplit,
+		DebitCreditSet:           dcSet,
+	}
+}
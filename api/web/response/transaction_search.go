@@ -0,0 +1,36 @@
+package response
+
+import (
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// TransactionSearch is a page of search results, the best matches first
+type TransactionSearch struct {
+	Query      string                     `json:"query"`
+	TotalCount uint64                     `json:"totalCount"`
+	Results    []*TransactionSearchResult `json:"results"`
+}
+
+type TransactionSearchResult struct {
+	JournalTransaction
+	Rank float64 `json:"rank"`
+	// the highlights are HTML escaped and wrap each match in <mark></mark>
+	CommentHighlight   string `json:"commentHighlight"`
+	ReferenceHighlight string `json:"referenceHighlight"`
+}
+
+// ConvertSearchToRespSearch converts models.TransactionSearch to TransactionSearch
+func ConvertSearchToRespSearch(query string, search *models.TransactionSearch) *TransactionSearch {
+	var results = make([]*TransactionSearchResult, len(search.Results))
+
+	for idx, result := range search.Results {
+		results[idx] = &TransactionSearchResult{
+			JournalTransaction: *convertJournalTransaction(&result.JournalTransaction),
+			Rank:               result.Rank,
+			CommentHighlight:   result.CommentHighlight,
+			ReferenceHighlight: result.ReferenceHighlight,
+		}
+	}
+
+	return &TransactionSearch{Query: query, TotalCount: search.TotalCount, Results: results}
+}
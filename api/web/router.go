@@ -44,6 +44,16 @@ func NewRouter(dStores *datastore.Datastores, logger *zerolog.Logger) *chi.Mux {
 	accountsController := NewAccountsController(dStores)
 	reportsController := NewReportsController(dStores)
 	transController := NewTransactionsController(dStores)
+	settingsController := NewSettingsController(dStores)
+	templatesController := NewReportTemplatesController(dStores)
+	importsController := NewImportsController(dStores)
+	exportsController := NewExportsController(dStores)
+	adminController := NewAdminController(dStores)
+	rulesController := NewRulesController(dStores)
+	reconController := NewReconciliationsController(dStores)
+	schedulesController := NewSchedulesController(dStores)
+	memorizedController := NewMemorizedController(dStores)
+	attachmentsController := NewAttachmentsController(dStores)
 
 	r.Get("/", func(w http.ResponseWriter, _ *http.Request) {
 		_, err := w.Write([]byte("{ok}"))
@@ -71,18 +81,111 @@ func NewRouter(dStores *datastore.Datastores, logger *zerolog.Logger) *chi.Mux {
 	r.Put("/reports/{reportID}", NewRootHandler(PutReportUpdate(reportsController)).ServeHTTP)
 	r.Delete("/reports/{reportID}", NewRootHandler(DeleteReport(reportsController)).ServeHTTP)
 	r.Get("/reports/{reportID}/output", NewRootHandler(GetReportOutput(reportsController)).ServeHTTP)
+	r.Get("/reports/{reportID}/output.pdf", NewRootHandler(GetReportOutputPDF(reportsController)).ServeHTTP)
+	r.Get("/templates", NewRootHandler(GetReportTemplates(templatesController)).ServeHTTP)
+	r.Post("/templates", NewRootHandler(PostReportTemplates(templatesController)).ServeHTTP)
+	r.Get("/templates/{templateID}", NewRootHandler(GetReportTemplate(templatesController)).ServeHTTP)
+	r.Put("/templates/{templateID}", NewRootHandler(PutReportTemplateUpdate(templatesController)).ServeHTTP)
+	r.Delete("/templates/{templateID}", NewRootHandler(DeleteReportTemplate(templatesController)).ServeHTTP)
+	r.Get("/imports", NewRootHandler(GetImports(importsController)).ServeHTTP)
+	r.Post("/imports/ofx", NewRootHandler(PostImportOFX(importsController)).ServeHTTP)
+	r.Post("/imports/csv", NewRootHandler(PostImportCSV(importsController)).ServeHTTP)
+	r.Post("/imports/qif", NewRootHandler(PostImportQIF(importsController)).ServeHTTP)
+	r.Post("/imports/camt053", NewRootHandler(PostImportCAMT053(importsController)).ServeHTTP)
+	r.Post("/imports/mt940", NewRootHandler(PostImportMT940(importsController)).ServeHTTP)
+	r.Post("/imports/gnucash", NewRootHandler(PostImportGnuCash(importsController)).ServeHTTP)
+	r.Post("/imports/ledger", NewRootHandler(PostImportLedger(importsController)).ServeHTTP)
+	r.Get("/imports/profiles", NewRootHandler(GetImportProfiles(importsController)).ServeHTTP)
+	r.Post("/imports/profiles", NewRootHandler(PostImportProfiles(importsController)).ServeHTTP)
+	r.Get("/imports/profiles/{profileID}", NewRootHandler(GetImportProfile(importsController)).ServeHTTP)
+	r.Put("/imports/profiles/{profileID}", NewRootHandler(PutImportProfileUpdate(importsController)).ServeHTTP)
+	r.Delete("/imports/profiles/{profileID}", NewRootHandler(DeleteImportProfile(importsController)).ServeHTTP)
+	r.Get("/imports/{importID}", NewRootHandler(GetImport(importsController)).ServeHTTP)
+	r.Post("/imports/{importID}/confirm", NewRootHandler(PostImportConfirm(importsController)).ServeHTTP)
+	r.Get("/imports/{importID}/matches", NewRootHandler(GetImportMatches(importsController)).ServeHTTP)
+	r.Post("/imports/{importID}/matches", NewRootHandler(PostImportMatches(importsController)).ServeHTTP)
+	r.Delete("/imports/{importID}", NewRootHandler(DeleteImport(importsController)).ServeHTTP)
+	r.Get("/export/ledger", NewRootHandler(GetExportLedger(exportsController)).ServeHTTP)
+	r.Get("/export/beancount", NewRootHandler(GetExportBeancount(exportsController)).ServeHTTP)
+	r.Get("/admin/export", NewRootHandler(GetAdminExport(adminController)).ServeHTTP)
+	r.Post("/admin/import", NewRootHandler(PostAdminImport(adminController)).ServeHTTP)
+	r.Get("/admin/integrity", NewRootHandler(GetAdminIntegrity(adminController)).ServeHTTP)
+	r.Get("/reconciliations", NewRootHandler(GetReconciliations(reconController)).ServeHTTP)
+	r.Post("/reconciliations", NewRootHandler(PostReconciliations(reconController)).ServeHTTP)
+	r.Get("/reconciliations/{sessionID}", NewRootHandler(GetReconciliation(reconController)).ServeHTTP)
+	r.Delete("/reconciliations/{sessionID}", NewRootHandler(DeleteReconciliation(reconController)).ServeHTTP)
+	r.Get("/reconciliations/{sessionID}/report",
+		NewRootHandler(GetReconciliationReport(reconController)).ServeHTTP)
+	r.Post("/reconciliations/{sessionID}/finish",
+		NewRootHandler(PostReconciliationFinish(reconController)).ServeHTTP)
+	r.Put("/reconciliations/{sessionID}/transactions/{transactionID}",
+		NewRootHandler(PutReconciliationTransaction(reconController)).ServeHTTP)
+	r.Delete("/reconciliations/{sessionID}/transactions/{transactionID}",
+		NewRootHandler(DeleteReconciliationTransaction(reconController)).ServeHTTP)
+	r.Get("/rules", NewRootHandler(GetRules(rulesController)).ServeHTTP)
+	r.Post("/rules", NewRootHandler(PostRules(rulesController)).ServeHTTP)
+	r.Post("/rules/apply", NewRootHandler(PostRulesApply(rulesController)).ServeHTTP)
+	r.Get("/rules/{ruleID}", NewRootHandler(GetRule(rulesController)).ServeHTTP)
+	r.Put("/rules/{ruleID}", NewRootHandler(PutRuleUpdate(rulesController)).ServeHTTP)
+	r.Delete("/rules/{ruleID}", NewRootHandler(DeleteRule(rulesController)).ServeHTTP)
+	r.Get("/schedules", NewRootHandler(GetSchedules(schedulesController)).ServeHTTP)
+	r.Post("/schedules", NewRootHandler(PostSchedules(schedulesController)).ServeHTTP)
+	r.Get("/schedules/upcoming", NewRootHandler(GetSchedulesUpcoming(schedulesController)).ServeHTTP)
+	r.Get("/schedules/{scheduledID}", NewRootHandler(GetSchedule(schedulesController)).ServeHTTP)
+	r.Put("/schedules/{scheduledID}", NewRootHandler(PutScheduleUpdate(schedulesController)).ServeHTTP)
+	r.Delete("/schedules/{scheduledID}", NewRootHandler(DeleteSchedule(schedulesController)).ServeHTTP)
+	r.Get("/schedules/{scheduledID}/occurrences",
+		NewRootHandler(GetScheduleOccurrences(schedulesController)).ServeHTTP)
+	r.Post("/schedules/{scheduledID}/occurrences/{occurrenceDate}/skip",
+		NewRootHandler(PostScheduleOccurrenceSkip(schedulesController)).ServeHTTP)
+	r.Post("/schedules/{scheduledID}/occurrences/{occurrenceDate}/post",
+		NewRootHandler(PostScheduleOccurrencePost(schedulesController)).ServeHTTP)
+	r.Get("/settings", NewRootHandler(GetSettings(settingsController)).ServeHTTP)
+	r.Get("/settings/{settingName}", NewRootHandler(GetSetting(settingsController)).ServeHTTP)
+	r.Put("/settings/{settingName}", NewRootHandler(PutSettingUpdate(settingsController)).ServeHTTP)
 
+	r.Get("/transactions", NewRootHandler(GetTransactions(transController)).ServeHTTP)
 	r.Post("/transactions", NewRootHandler(PostTransactions(transController)).ServeHTTP)
+	r.Get("/transactions/search", NewRootHandler(GetTransactionsSearch(transController)).ServeHTTP)
+	r.Post("/transactions/reconcile", NewRootHandler(PostTransactionsReconcile(transController)).ServeHTTP)
+	r.Post("/transactions/unreconcile", NewRootHandler(PostTransactionsUnreconcile(transController)).ServeHTTP)
+	r.Get("/transactions/duplicates", NewRootHandler(GetTransactionDuplicates(transController)).ServeHTTP)
+	r.Post("/transactions/duplicates/{duplicateID}/merge",
+		NewRootHandler(PostTransactionDuplicateMerge(transController)).ServeHTTP)
+	r.Post("/transactions/duplicates/{duplicateID}/dismiss",
+		NewRootHandler(PostTransactionDuplicateDismiss(transController)).ServeHTTP)
+	r.Get("/transactions/templates", NewRootHandler(GetMemorizedTransactions(memorizedController)).ServeHTTP)
+	r.Post("/transactions/templates", NewRootHandler(PostMemorizedTransactions(memorizedController)).ServeHTTP)
+	r.Get("/transactions/templates/{memorizedID}",
+		NewRootHandler(GetMemorizedTransaction(memorizedController)).ServeHTTP)
+	r.Put("/transactions/templates/{memorizedID}",
+		NewRootHandler(PutMemorizedTransactionUpdate(memorizedController)).ServeHTTP)
+	r.Delete("/transactions/templates/{memorizedID}",
+		NewRootHandler(DeleteMemorizedTransaction(memorizedController)).ServeHTTP)
+	r.Post("/transactions/from-template/{memorizedID}",
+		NewRootHandler(PostTransactionFromMemorized(memorizedController)).ServeHTTP)
 	r.Get("/transactions/account/{accountID}", NewRootHandler(GetTransactionsOnAccount(transController)).ServeHTTP)
+	r.Get("/transactions/account/{accountID}/statement",
+		NewRootHandler(GetStatementOnAccount(transController)).ServeHTTP)
 	r.Get("/transactions/account/{accountID}/unreconciled",
 		NewRootHandler(GetUnreconciledTransactionsOnAccount(transController)).ServeHTTP)
 	r.Get("/transactions/{transactionID}", NewRootHandler(GetTransaction(transController)).ServeHTTP)
 	r.Put("/transactions/{transactionID}", NewRootHandler(PutTransactionUpdate(transController)).ServeHTTP)
+	r.Get("/transactions/{transactionID}/suggestions",
+		NewRootHandler(GetTransactionSuggestions(transController)).ServeHTTP)
 	r.Put("/transactions/{transactionID}/reconciled",
 		NewRootHandler(PutTransactionReconciled(transController)).ServeHTTP)
 	r.Put("/transactions/{transactionID}/unreconciled",
 		NewRootHandler(PutTransactionUnreconciled(transController)).ServeHTTP)
 	r.Delete("/transactions/{transactionID}", NewRootHandler(DeleteTransaction(transController)).ServeHTTP)
+	r.Get("/transactions/{transactionID}/attachments",
+		NewRootHandler(GetAttachments(attachmentsController)).ServeHTTP)
+	r.Post("/transactions/{transactionID}/attachments",
+		NewRootHandler(PostAttachments(attachmentsController)).ServeHTTP)
+	r.Get("/transactions/{transactionID}/attachments/{attachmentID}",
+		NewRootHandler(GetAttachment(attachmentsController)).ServeHTTP)
+	r.Delete("/transactions/{transactionID}/attachments/{attachmentID}",
+		NewRootHandler(DeleteAttachment(attachmentsController)).ServeHTTP)
 
 	return r
 }
@@ -0,0 +1,86 @@
+package web
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// RulesController is the controller struct for categorization rules
+type RulesController struct {
+	DataStores *datastore.Datastores
+}
+
+// NewRulesController instantiates a new RulesController struct
+func NewRulesController(ds *datastore.Datastores) *RulesController {
+	return &RulesController{
+		DataStores: ds,
+	}
+}
+
+// GET /rules
+func (rc *RulesController) RuleList(_ context.Context) ([]*models.CategorizationRule, error) {
+	rules, err := models.RetrieveCategorizationRules(rc.DataStores)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveCategorizationRules:%w", err)
+	}
+
+	return rules, nil
+}
+
+// GET /rules/{ruleID}
+func (rc *RulesController) GetRuleByID(_ context.Context, ruleID uint64) (*models.CategorizationRule, error) {
+	myRule, err := models.RetrieveCategorizationRuleByID(rc.DataStores, ruleID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveCategorizationRuleByID:%w", err)
+	}
+
+	return myRule, nil
+}
+
+// POST /rules
+func (rc *RulesController) CreateRule(_ context.Context,
+	myRule *models.CategorizationRule) (*models.CategorizationRule, error) {
+	if err := myRule.Store(rc.DataStores); err != nil {
+		return nil, fmt.Errorf("myRule.Store:%w", err)
+	}
+
+	return myRule, nil
+}
+
+// PUT /rules/{ruleID}
+func (rc *RulesController) UpdateRule(_ context.Context,
+	myRule *models.CategorizationRule) (*models.CategorizationRule, error) {
+	if err := myRule.Update(rc.DataStores); err != nil {
+		return nil, fmt.Errorf("myRule.Update:%w", err)
+	}
+
+	return myRule, nil
+}
+
+// DELETE /rules/{ruleID}
+func (rc *RulesController) DeleteRule(_ context.Context, ruleID uint64) (*models.CategorizationRule, error) {
+	myRule, err := models.RetrieveCategorizationRuleByID(rc.DataStores, ruleID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveCategorizationRuleByID:%w", err)
+	}
+
+	if err = myRule.Delete(rc.DataStores); err != nil {
+		return nil, fmt.Errorf("myRule.Delete:%w", err)
+	}
+
+	return myRule, nil
+}
+
+// POST /rules/apply
+func (rc *RulesController) ApplyRules(_ context.Context,
+	filter *models.CategorizeFilter) (*models.CategorizationResult, error) {
+	result, err := models.CategorizeTransactions(rc.DataStores, filter)
+	if err != nil {
+		return nil, fmt.Errorf("models.CategorizeTransactions:%w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,169 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mimirsoft/mimirledger/api/models"
+	"github.com/mimirsoft/mimirledger/api/web/request"
+	"github.com/mimirsoft/mimirledger/api/web/response"
+)
+
+var ErrInvalidRuleID = errors.New("invalid ruleID request parameter")
+var ErrInvalidDryRun = errors.New("invalid dryRun request parameter")
+
+func parseRuleID(req *http.Request) (uint64, error) {
+	ruleID, err := strconv.ParseUint(chi.URLParam(req, "ruleID"), 10, 64)
+	if err != nil || ruleID == 0 {
+		return 0, NewRequestError(http.StatusBadRequest, ErrInvalidRuleID)
+	}
+
+	return ruleID, nil
+}
+
+// respondWithRuleError maps the errors of storing and applying rules to a status
+func respondWithRuleError(err error) error {
+	switch {
+	case errors.Is(err, models.ErrCategorizationRuleNotFound):
+		return NewRequestError(http.StatusNotFound, err)
+	case errors.Is(err, models.ErrCategorizationRuleInvalid), errors.Is(err, models.ErrAccountNotFound):
+		return NewRequestError(http.StatusBadRequest, err)
+	}
+
+	return fmt.Errorf("rules:%w", err)
+}
+
+// GET /rules
+func GetRules(rulesCtl *RulesController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		rules, err := rulesCtl.RuleList(req.Context())
+		if err != nil {
+			return NewRequestError(http.StatusServiceUnavailable, err)
+		}
+
+		return RespondOK(res, response.ConvertCategorizationRulesToRespCategorizationRuleSet(rules))
+	}
+}
+
+// GET /rules/{ruleID}
+func GetRule(rulesCtl *RulesController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		ruleID, err := parseRuleID(req)
+		if err != nil {
+			return err
+		}
+
+		myRule, err := rulesCtl.GetRuleByID(req.Context(), ruleID)
+		if err != nil {
+			return respondWithRuleError(err)
+		}
+
+		return RespondOK(res, response.CategorizationRuleToRespCategorizationRule(myRule))
+	}
+}
+
+// POST /rules
+func PostRules(rulesCtl *RulesController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		if req.Body == nil {
+			return NewRequestError(http.StatusBadRequest, ErrNoRequestBody)
+		}
+
+		var reqRule request.CategorizationRule
+
+		if err := json.NewDecoder(req.Body).Decode(&reqRule); err != nil {
+			return fmt.Errorf("json.NewDecoder(r.Body).Decode:%w", err)
+		}
+
+		myRule, err := rulesCtl.CreateRule(req.Context(), request.ReqCategorizationRuleToCategorizationRule(&reqRule))
+		if err != nil {
+			return respondWithRuleError(err)
+		}
+
+		return RespondOK(res, response.CategorizationRuleToRespCategorizationRule(myRule))
+	}
+}
+
+// PUT /rules/{ruleID}
+func PutRuleUpdate(rulesCtl *RulesController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		ruleID, err := parseRuleID(req)
+		if err != nil {
+			return err
+		}
+
+		if req.Body == nil {
+			return NewRequestError(http.StatusBadRequest, ErrNoRequestBody)
+		}
+
+		var reqRule request.CategorizationRule
+
+		if err = json.NewDecoder(req.Body).Decode(&reqRule); err != nil {
+			return fmt.Errorf("json.NewDecoder(r.Body).Decode:%w", err)
+		}
+
+		mdlRule := request.ReqCategorizationRuleToCategorizationRule(&reqRule)
+		mdlRule.RuleID = ruleID
+
+		myRule, err := rulesCtl.UpdateRule(req.Context(), mdlRule)
+		if err != nil {
+			return respondWithRuleError(err)
+		}
+
+		return RespondOK(res, response.CategorizationRuleToRespCategorizationRule(myRule))
+	}
+}
+
+// DELETE /rules/{ruleID}
+func DeleteRule(rulesCtl *RulesController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		ruleID, err := parseRuleID(req)
+		if err != nil {
+			return err
+		}
+
+		myRule, err := rulesCtl.DeleteRule(req.Context(), ruleID)
+		if err != nil {
+			return respondWithRuleError(err)
+		}
+
+		return RespondOK(res, response.CategorizationRuleToRespCategorizationRule(myRule))
+	}
+}
+
+// POST /rules/apply?dryRun=true, the body optionally selects the transactions, accountID is the account of the
+// uncategorized transactions and startDate and endDate limit their dates.  With dryRun the changes are reported and
+// nothing is changed.
+func PostRulesApply(rulesCtl *RulesController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		var dryRun bool
+
+		if dryRunStr := req.URL.Query().Get("dryRun"); dryRunStr != "" {
+			var err error
+			if dryRun, err = strconv.ParseBool(dryRunStr); err != nil {
+				return NewRequestError(http.StatusBadRequest, ErrInvalidDryRun)
+			}
+		}
+
+		var reqCategorize request.CategorizeTransactions
+
+		if req.Body != nil {
+			if err := json.NewDecoder(req.Body).Decode(&reqCategorize); err != nil && !errors.Is(err, io.EOF) {
+				return NewRequestError(http.StatusBadRequest, err)
+			}
+		}
+
+		result, err := rulesCtl.ApplyRules(req.Context(),
+			request.ReqCategorizeTransactionsToCategorizeFilter(&reqCategorize, dryRun))
+		if err != nil {
+			return respondWithRuleError(err)
+		}
+
+		return RespondOK(res, response.ConvertCategorizationResultToRespCategorizationResult(result))
+	}
+}
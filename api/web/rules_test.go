@@ -0,0 +1,199 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+	"github.com/mimirsoft/mimirledger/api/web/response"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestRules_CRUD(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	home := models.Account{AccountName: "Home", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeExpense}
+	err := home.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	NewRouterTableTest([]RouterTest{
+		{Request: Request{
+			Method:     http.MethodPost,
+			Router:     TestRouter,
+			RequestURL: "/rules",
+			Payload: M{"ruleName": "broken", "match": M{"descriptionRegex": "home ("},
+				"action": M{"actionType": "SET_ACCOUNT", "offsetAccountID": home.AccountID}},
+		}, GomegaWithT: g, Code: http.StatusBadRequest, RespBody: models.ErrCategorizationRuleInvalid.Error()},
+		{Request: Request{
+			Method:     http.MethodPost,
+			Router:     TestRouter,
+			RequestURL: "/rules",
+			Payload:    M{"ruleName": "nothing", "action": M{"actionType": "DELETE"}},
+		}, GomegaWithT: g, Code: http.StatusBadRequest, RespBody: models.ErrCategorizationRuleInvalid.Error()},
+		{Request: Request{
+			Method:     http.MethodGet,
+			Router:     TestRouter,
+			RequestURL: "/rules/abc",
+		}, GomegaWithT: g, Code: http.StatusBadRequest, RespBody: ErrInvalidRuleID.Error()},
+		{Request: Request{
+			Method:     http.MethodGet,
+			Router:     TestRouter,
+			RequestURL: "/rules/999999",
+		}, GomegaWithT: g, Code: http.StatusNotFound},
+	}).Exec()
+
+	test := RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: "/rules",
+		Payload: M{"ruleName": "Home Depot", "ruleOrder": 10, "match": M{"descriptionRegex": "home depot"},
+			"action": M{"actionType": "SET_ACCOUNT", "offsetAccountID": home.AccountID}},
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var created response.CategorizationRule
+	test.ExecWithUnmarshal(&created)
+	g.Expect(created.RuleID).NotTo(gomega.BeZero())
+	g.Expect(created.IsEnabled).To(gomega.BeTrue())
+	g.Expect(created.Action.ActionType).To(gomega.Equal(datastore.RuleActionSetAccount))
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodPut,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/rules/%d", created.RuleID),
+		Payload: M{"ruleName": "Home Depot", "ruleOrder": 10, "isEnabled": false,
+			"match":  M{"descriptionRegex": "home depot", "maxAmount": -1},
+			"action": M{"actionType": "SET_ACCOUNT", "offsetAccountID": home.AccountID}},
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var updated response.CategorizationRule
+	test.ExecWithUnmarshal(&updated)
+	g.Expect(updated.IsEnabled).To(gomega.BeFalse())
+	g.Expect(*updated.Match.MaxAmount).To(gomega.Equal(int64(-1)))
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: "/rules",
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var ruleSet response.CategorizationRuleSet
+	test.ExecWithUnmarshal(&ruleSet)
+	g.Expect(ruleSet.Rules).To(gomega.HaveLen(1))
+
+	NewRouterTableTest([]RouterTest{
+		{Request: Request{
+			Method:     http.MethodDelete,
+			Router:     TestRouter,
+			RequestURL: fmt.Sprintf("/rules/%d", created.RuleID),
+		}, GomegaWithT: g, Code: http.StatusOK},
+		{Request: Request{
+			Method:     http.MethodGet,
+			Router:     TestRouter,
+			RequestURL: fmt.Sprintf("/rules/%d", created.RuleID),
+		}, GomegaWithT: g, Code: http.StatusNotFound},
+	}).Exec()
+}
+
+func TestRules_Apply(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	checking := models.Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	home := models.Account{AccountName: "Home", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeExpense}
+	err = home.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	garden := models.Account{AccountName: "Garden", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeExpense}
+	err = garden.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	suspense, err := models.RetrieveSuspenseAccount(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	txn := models.Transaction{TransactionCore: models.TransactionCore{TransactionComment: "HOME DEPOT #1234",
+		TransactionDate: time.Date(2024, 5, 12, 0, 0, 0, 0, time.UTC)},
+		DebitCreditSet: []*models.TransactionDebitCredit{
+			{AccountID: checking.AccountID, DebitOrCredit: datastore.AccountSignCredit, TransactionDCAmount: 10000},
+			{AccountID: suspense.AccountID, DebitOrCredit: datastore.AccountSignDebit, TransactionDCAmount: 10000},
+		},
+	}
+	err = txn.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	test := RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: "/rules",
+		Payload: M{"ruleName": "Home Depot", "match": M{"descriptionRegex": "home depot"},
+			"action": M{"actionType": "SPLIT", "splits": []M{
+				{"accountID": home.AccountID, "percent": 75}, {"accountID": garden.AccountID, "percent": 25}}}},
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.Exec()
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: "/rules/apply?dryRun=maybe",
+	}, GomegaWithT: g, Code: http.StatusBadRequest, RespBody: ErrInvalidDryRun.Error()}
+	test.Exec()
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: "/rules/apply?dryRun=true",
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var dryRun response.CategorizationResult
+	test.ExecWithUnmarshal(&dryRun)
+	g.Expect(dryRun.DryRun).To(gomega.BeTrue())
+	g.Expect(dryRun.AccountID).To(gomega.Equal(suspense.AccountID))
+	g.Expect(dryRun.Changes).To(gomega.HaveLen(1))
+	g.Expect(dryRun.Changes[0].Before.DebitCreditSet).To(gomega.HaveLen(2))
+	g.Expect(dryRun.Changes[0].After.DebitCreditSet).To(gomega.HaveLen(3))
+	g.Expect(dryRun.Changes[0].After.IsSplit).To(gomega.BeTrue())
+
+	unchanged, err := models.RetrieveTransactionByID(TestDataStore, txn.TransactionID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(unchanged.IsSplit).To(gomega.BeFalse())
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: "/rules/apply",
+		Payload:    M{"accountID": suspense.AccountID, "startDate": "2024-05-01T00:00:00Z"},
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var applied response.CategorizationResult
+	test.ExecWithUnmarshal(&applied)
+	g.Expect(applied.DryRun).To(gomega.BeFalse())
+	g.Expect(applied.Changes).To(gomega.HaveLen(1))
+
+	changed, err := models.RetrieveTransactionByID(TestDataStore, txn.TransactionID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(changed.IsSplit).To(gomega.BeTrue())
+
+	for _, dc := range changed.DebitCreditSet {
+		switch dc.AccountID {
+		case home.AccountID:
+			g.Expect(dc.TransactionDCAmount).To(gomega.Equal(uint64(7500)))
+		case garden.AccountID:
+			g.Expect(dc.TransactionDCAmount).To(gomega.Equal(uint64(2500)))
+		default:
+			g.Expect(dc.AccountID).To(gomega.Equal(checking.AccountID))
+		}
+	}
+}
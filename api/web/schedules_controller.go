@@ -0,0 +1,137 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// SchedulesController is the controller struct for scheduled transactions
+type SchedulesController struct {
+	DataStores *datastore.Datastores
+}
+
+// NewSchedulesController instantiates a new SchedulesController struct
+func NewSchedulesController(ds *datastore.Datastores) *SchedulesController {
+	return &SchedulesController{
+		DataStores: ds,
+	}
+}
+
+// GET /schedules
+func (sc *SchedulesController) ScheduleList(_ context.Context) ([]*models.ScheduledTransaction, error) {
+	scheduledSet, err := models.RetrieveScheduledTransactions(sc.DataStores, false)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveScheduledTransactions:%w", err)
+	}
+
+	return scheduledSet, nil
+}
+
+// GET /schedules/{scheduledID}
+func (sc *SchedulesController) GetScheduleByID(_ context.Context,
+	scheduledID uint64) (*models.ScheduledTransaction, error) {
+	scheduled, err := models.RetrieveScheduledTransactionByID(sc.DataStores, scheduledID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveScheduledTransactionByID:%w", err)
+	}
+
+	return scheduled, nil
+}
+
+// POST /schedules
+func (sc *SchedulesController) CreateSchedule(_ context.Context,
+	scheduled *models.ScheduledTransaction) (*models.ScheduledTransaction, error) {
+	if err := scheduled.Store(sc.DataStores); err != nil {
+		return nil, fmt.Errorf("scheduled.Store:%w", err)
+	}
+
+	return scheduled, nil
+}
+
+// PUT /schedules/{scheduledID}
+func (sc *SchedulesController) UpdateSchedule(_ context.Context,
+	scheduled *models.ScheduledTransaction) (*models.ScheduledTransaction, error) {
+	if err := scheduled.Update(sc.DataStores); err != nil {
+		return nil, fmt.Errorf("scheduled.Update:%w", err)
+	}
+
+	return scheduled, nil
+}
+
+// DELETE /schedules/{scheduledID}
+func (sc *SchedulesController) DeleteSchedule(_ context.Context,
+	scheduledID uint64) (*models.ScheduledTransaction, error) {
+	scheduled, err := models.RetrieveScheduledTransactionByID(sc.DataStores, scheduledID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveScheduledTransactionByID:%w", err)
+	}
+
+	if err = scheduled.Delete(sc.DataStores); err != nil {
+		return nil, fmt.Errorf("scheduled.Delete:%w", err)
+	}
+
+	return scheduled, nil
+}
+
+// GET /schedules/upcoming
+func (sc *SchedulesController) UpcomingOccurrences(_ context.Context, startDate,
+	endDate time.Time) ([]*models.ScheduledOccurrence, error) {
+	occurrences, err := models.RetrieveUpcomingOccurrences(sc.DataStores, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveUpcomingOccurrences:%w", err)
+	}
+
+	return occurrences, nil
+}
+
+// GET /schedules/{scheduledID}/occurrences
+func (sc *SchedulesController) Occurrences(_ context.Context, scheduledID uint64, startDate,
+	endDate time.Time) ([]*models.ScheduledOccurrence, error) {
+	scheduled, err := models.RetrieveScheduledTransactionByID(sc.DataStores, scheduledID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveScheduledTransactionByID:%w", err)
+	}
+
+	occurrences, err := scheduled.Occurrences(sc.DataStores, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("scheduled.Occurrences:%w", err)
+	}
+
+	return occurrences, nil
+}
+
+// POST /schedules/{scheduledID}/occurrences/{occurrenceDate}/skip
+func (sc *SchedulesController) SkipOccurrence(_ context.Context, scheduledID uint64,
+	occurrenceDate time.Time) (*models.ScheduledOccurrence, error) {
+	scheduled, err := models.RetrieveScheduledTransactionByID(sc.DataStores, scheduledID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveScheduledTransactionByID:%w", err)
+	}
+
+	occurrence, err := scheduled.SkipOccurrence(sc.DataStores, occurrenceDate)
+	if err != nil {
+		return nil, fmt.Errorf("scheduled.SkipOccurrence:%w", err)
+	}
+
+	return occurrence, nil
+}
+
+// POST /schedules/{scheduledID}/occurrences/{occurrenceDate}/post
+func (sc *SchedulesController) PostOccurrence(_ context.Context, scheduledID uint64,
+	occurrenceDate time.Time) (*models.Transaction, error) {
+	scheduled, err := models.RetrieveScheduledTransactionByID(sc.DataStores, scheduledID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveScheduledTransactionByID:%w", err)
+	}
+
+	myTxn, err := scheduled.PostOccurrence(sc.DataStores, occurrenceDate)
+	if err != nil {
+		return nil, fmt.Errorf("scheduled.PostOccurrence:%w", err)
+	}
+
+	return myTxn, nil
+}
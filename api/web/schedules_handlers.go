@@ -0,0 +1,269 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mimirsoft/mimirledger/api/models"
+	"github.com/mimirsoft/mimirledger/api/web/request"
+	"github.com/mimirsoft/mimirledger/api/web/response"
+)
+
+var ErrInvalidScheduledID = errors.New("invalid scheduledID request parameter")
+var ErrInvalidOccurrenceDate = errors.New("invalid occurrenceDate request parameter")
+
+// upcomingDays is how far ahead the occurrences are listed when there is no endDate
+const upcomingDays = 30
+
+func parseScheduledID(req *http.Request) (uint64, error) {
+	scheduledID, err := strconv.ParseUint(chi.URLParam(req, "scheduledID"), 10, 64)
+	if err != nil || scheduledID == 0 {
+		return 0, NewRequestError(http.StatusBadRequest, ErrInvalidScheduledID)
+	}
+
+	return scheduledID, nil
+}
+
+func parseOccurrenceDate(req *http.Request) (time.Time, error) {
+	occurrenceDate, err := time.Parse("2006-01-02", chi.URLParam(req, "occurrenceDate"))
+	if err != nil {
+		return time.Time{}, NewRequestError(http.StatusBadRequest, ErrInvalidOccurrenceDate)
+	}
+
+	return occurrenceDate, nil
+}
+
+// parseOccurrenceDates reads the optional startDate and endDate of a list of occurrences, from today through
+// upcomingDays from the startDate when they are not set
+func parseOccurrenceDates(req *http.Request) (time.Time, time.Time, error) {
+	now := time.Now()
+	startDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	if startDateStr := req.URL.Query().Get("startDate"); startDateStr != "" {
+		var err error
+		if startDate, err = time.Parse("2006-01-02", startDateStr); err != nil {
+			return time.Time{}, time.Time{}, NewRequestError(http.StatusBadRequest, ErrInvalidStartDate)
+		}
+	}
+
+	endDate := startDate.AddDate(0, 0, upcomingDays)
+
+	if endDateStr := req.URL.Query().Get("endDate"); endDateStr != "" {
+		var err error
+		if endDate, err = time.Parse("2006-01-02", endDateStr); err != nil {
+			return time.Time{}, time.Time{}, NewRequestError(http.StatusBadRequest, ErrInvalidEndDate)
+		}
+	}
+
+	return startDate, endDate, nil
+}
+
+// respondWithScheduleError maps the errors of scheduled transactions to a status
+func respondWithScheduleError(err error) error {
+	switch {
+	case errors.Is(err, models.ErrScheduledTransactionNotFound):
+		return NewRequestError(http.StatusNotFound, err)
+	case errors.Is(err, models.ErrScheduledTransactionInvalid), errors.Is(err, models.ErrScheduledOccurrenceInvalid),
+		errors.Is(err, models.ErrAccountNotFound):
+		return NewRequestError(http.StatusBadRequest, err)
+	case errors.Is(err, models.ErrScheduledOccurrenceHandled):
+		return NewRequestError(http.StatusConflict, err)
+	}
+
+	return fmt.Errorf("schedules:%w", err)
+}
+
+// parseScheduledTransaction reads the body of a create or update
+func parseScheduledTransaction(req *http.Request) (*models.ScheduledTransaction, error) {
+	if req.Body == nil {
+		return nil, NewRequestError(http.StatusBadRequest, ErrNoRequestBody)
+	}
+
+	var reqScheduled request.ScheduledTransaction
+
+	if err := json.NewDecoder(req.Body).Decode(&reqScheduled); err != nil {
+		return nil, fmt.Errorf("json.NewDecoder(r.Body).Decode:%w", err)
+	}
+
+	return request.ReqScheduledTransactionToScheduledTransaction(&reqScheduled), nil
+}
+
+// GET /schedules
+func GetSchedules(schedulesCtl *SchedulesController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		scheduledSet, err := schedulesCtl.ScheduleList(req.Context())
+		if err != nil {
+			return NewRequestError(http.StatusServiceUnavailable, err)
+		}
+
+		return RespondOK(res, response.ConvertScheduledTransactionsToRespScheduledTransactionSet(scheduledSet))
+	}
+}
+
+// GET /schedules/{scheduledID}
+func GetSchedule(schedulesCtl *SchedulesController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		scheduledID, err := parseScheduledID(req)
+		if err != nil {
+			return err
+		}
+
+		scheduled, err := schedulesCtl.GetScheduleByID(req.Context(), scheduledID)
+		if err != nil {
+			return respondWithScheduleError(err)
+		}
+
+		return RespondOK(res, response.ScheduledTransactionToRespScheduledTransaction(scheduled))
+	}
+}
+
+// POST /schedules
+func PostSchedules(schedulesCtl *SchedulesController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		mdlScheduled, err := parseScheduledTransaction(req)
+		if err != nil {
+			return err
+		}
+
+		scheduled, err := schedulesCtl.CreateSchedule(req.Context(), mdlScheduled)
+		if err != nil {
+			return respondWithScheduleError(err)
+		}
+
+		return RespondOK(res, response.ScheduledTransactionToRespScheduledTransaction(scheduled))
+	}
+}
+
+// PUT /schedules/{scheduledID}
+func PutScheduleUpdate(schedulesCtl *SchedulesController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		scheduledID, err := parseScheduledID(req)
+		if err != nil {
+			return err
+		}
+
+		mdlScheduled, err := parseScheduledTransaction(req)
+		if err != nil {
+			return err
+		}
+
+		mdlScheduled.ScheduledID = scheduledID
+
+		scheduled, err := schedulesCtl.UpdateSchedule(req.Context(), mdlScheduled)
+		if err != nil {
+			return respondWithScheduleError(err)
+		}
+
+		return RespondOK(res, response.ScheduledTransactionToRespScheduledTransaction(scheduled))
+	}
+}
+
+// DELETE /schedules/{scheduledID}, the transactions it posted are kept
+func DeleteSchedule(schedulesCtl *SchedulesController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		scheduledID, err := parseScheduledID(req)
+		if err != nil {
+			return err
+		}
+
+		scheduled, err := schedulesCtl.DeleteSchedule(req.Context(), scheduledID)
+		if err != nil {
+			return respondWithScheduleError(err)
+		}
+
+		return RespondOK(res, response.ScheduledTransactionToRespScheduledTransaction(scheduled))
+	}
+}
+
+// GET /schedules/upcoming?startDate=<date>&endDate=<date>, the occurrences of every enabled schedule, from today
+// through the next 30 days by default
+func GetSchedulesUpcoming(schedulesCtl *SchedulesController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		startDate, endDate, err := parseOccurrenceDates(req)
+		if err != nil {
+			return err
+		}
+
+		occurrences, err := schedulesCtl.UpcomingOccurrences(req.Context(), startDate, endDate)
+		if err != nil {
+			return respondWithScheduleError(err)
+		}
+
+		return RespondOK(res, response.ConvertScheduledOccurrencesToRespScheduledOccurrenceSet(occurrences))
+	}
+}
+
+// GET /schedules/{scheduledID}/occurrences?startDate=<date>&endDate=<date>
+func GetScheduleOccurrences(schedulesCtl *SchedulesController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		scheduledID, err := parseScheduledID(req)
+		if err != nil {
+			return err
+		}
+
+		startDate, endDate, err := parseOccurrenceDates(req)
+		if err != nil {
+			return err
+		}
+
+		occurrences, err := schedulesCtl.Occurrences(req.Context(), scheduledID, startDate, endDate)
+		if err != nil {
+			return respondWithScheduleError(err)
+		}
+
+		return RespondOK(res, response.ConvertScheduledOccurrencesToRespScheduledOccurrenceSet(occurrences))
+	}
+}
+
+// POST /schedules/{scheduledID}/occurrences/{occurrenceDate}/skip, the occurrence is never posted
+func PostScheduleOccurrenceSkip(schedulesCtl *SchedulesController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		scheduledID, err := parseScheduledID(req)
+		if err != nil {
+			return err
+		}
+
+		occurrenceDate, err := parseOccurrenceDate(req)
+		if err != nil {
+			return err
+		}
+
+		occurrence, err := schedulesCtl.SkipOccurrence(req.Context(), scheduledID, occurrenceDate)
+		if err != nil {
+			return respondWithScheduleError(err)
+		}
+
+		return RespondOK(res, response.ScheduledOccurrenceToRespScheduledOccurrence(occurrence))
+	}
+}
+
+// POST /schedules/{scheduledID}/occurrences/{occurrenceDate}/post, posts the occurrence now even if it is not due
+func PostScheduleOccurrencePost(schedulesCtl *SchedulesController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		scheduledID, err := parseScheduledID(req)
+		if err != nil {
+			return err
+		}
+
+		occurrenceDate, err := parseOccurrenceDate(req)
+		if err != nil {
+			return err
+		}
+
+		myTxn, err := schedulesCtl.PostOccurrence(req.Context(), scheduledID, occurrenceDate)
+		if err != nil {
+			return respondWithScheduleError(err)
+		}
+
+		return RespondOK(res, response.TransactionToRespTransaction(myTxn))
+	}
+}
@@ -0,0 +1,143 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+	"github.com/mimirsoft/mimirledger/api/web/response"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func TestSchedules_OccurrencesSkipAndPost(t *testing.T) { //nolint:funlen
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	checking := models.Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	payroll := models.Account{AccountName: "Payroll", AccountSign: datastore.AccountSignCredit,
+		AccountType: datastore.AccountTypeIncome}
+	err = payroll.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	template := M{"transactionComment": "pay", "debitCreditSet": []M{
+		{"accountID": checking.AccountID, "debitOrCredit": "DEBIT", "transactionDCAmount": 250000},
+		{"accountID": payroll.AccountID, "debitOrCredit": "CREDIT", "transactionDCAmount": 250000},
+	}}
+
+	NewRouterTableTest([]RouterTest{
+		{Request: Request{
+			Method:     http.MethodPost,
+			Router:     TestRouter,
+			RequestURL: "/schedules",
+			Payload: M{"scheduledName": "pay", "recurrenceRule": "FREQ=FORTNIGHTLY",
+				"startDate": "2024-01-05T00:00:00Z", "template": template},
+		}, GomegaWithT: g, Code: http.StatusBadRequest, RespBody: models.ErrScheduledTransactionInvalid.Error()},
+		{Request: Request{
+			Method:     http.MethodGet,
+			Router:     TestRouter,
+			RequestURL: "/schedules/abc",
+		}, GomegaWithT: g, Code: http.StatusBadRequest, RespBody: ErrInvalidScheduledID.Error()},
+		{Request: Request{
+			Method:     http.MethodGet,
+			Router:     TestRouter,
+			RequestURL: "/schedules/999999",
+		}, GomegaWithT: g, Code: http.StatusNotFound},
+	}).Exec()
+
+	// payday every 2 weeks
+	test := RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: "/schedules",
+		Payload: M{"scheduledName": "pay", "recurrenceRule": "FREQ=WEEKLY;INTERVAL=2",
+			"startDate": "2024-01-05T00:00:00Z", "template": template},
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var created response.ScheduledTransaction
+	test.ExecWithUnmarshal(&created)
+	g.Expect(created.ScheduledID).NotTo(gomega.BeZero())
+	g.Expect(created.IsEnabled).To(gomega.BeTrue())
+	g.Expect(created.EndDate).To(gomega.BeNil())
+	// posted from today, the past paydays are not caught up
+	g.Expect(created.NextDue).To(gomega.BeTemporally("~", time.Now(), 24*time.Hour))
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/schedules/%d/occurrences/2024-01-19/skip", created.ScheduledID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var skipped response.ScheduledOccurrence
+	test.ExecWithUnmarshal(&skipped)
+	g.Expect(skipped.OccurrenceStatus).To(gomega.Equal(datastore.OccurrenceStatusSkipped))
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/schedules/%d/occurrences/2024-02-02/post", created.ScheduledID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var postedTxn response.Transaction
+	test.ExecWithUnmarshal(&postedTxn)
+	g.Expect(postedTxn.TransactionComment).To(gomega.Equal("pay"))
+	g.Expect(postedTxn.TransactionAmount).To(gomega.Equal(uint64(250000)))
+	g.Expect(postedTxn.TransactionDate).To(gomega.BeTemporally("~",
+		time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC), time.Second))
+
+	NewRouterTableTest([]RouterTest{
+		{Request: Request{
+			Method:     http.MethodPost,
+			Router:     TestRouter,
+			RequestURL: fmt.Sprintf("/schedules/%d/occurrences/2024-02-02/post", created.ScheduledID),
+		}, GomegaWithT: g, Code: http.StatusConflict, RespBody: models.ErrScheduledOccurrenceHandled.Error()},
+		{Request: Request{
+			Method:     http.MethodPost,
+			Router:     TestRouter,
+			RequestURL: fmt.Sprintf("/schedules/%d/occurrences/2024-02-03/skip", created.ScheduledID),
+		}, GomegaWithT: g, Code: http.StatusBadRequest, RespBody: models.ErrScheduledOccurrenceInvalid.Error()},
+		{Request: Request{
+			Method:     http.MethodPost,
+			Router:     TestRouter,
+			RequestURL: fmt.Sprintf("/schedules/%d/occurrences/tomorrow/skip", created.ScheduledID),
+		}, GomegaWithT: g, Code: http.StatusBadRequest, RespBody: ErrInvalidOccurrenceDate.Error()},
+	}).Exec()
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: "/schedules/upcoming?startDate=2024-01-01&endDate=2024-02-29",
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var upcoming response.ScheduledOccurrenceSet
+	test.ExecWithUnmarshal(&upcoming)
+	g.Expect(upcoming.Occurrences).To(gomega.HaveLen(4))
+
+	statuses := make([]datastore.OccurrenceStatus, len(upcoming.Occurrences))
+	for idx, occurrence := range upcoming.Occurrences {
+		statuses[idx] = occurrence.OccurrenceStatus
+	}
+
+	g.Expect(statuses).To(gomega.Equal([]datastore.OccurrenceStatus{models.OccurrenceStatusPending,
+		datastore.OccurrenceStatusSkipped, datastore.OccurrenceStatusPosted, models.OccurrenceStatusPending}))
+	g.Expect(upcoming.Occurrences[2].TransactionID).To(gomega.Equal(postedTxn.TransactionID))
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodDelete,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/schedules/%d", created.ScheduledID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.Exec()
+
+	// the posted transaction is kept
+	_, err = models.RetrieveTransactionByID(TestDataStore, postedTxn.TransactionID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+}
@@ -0,0 +1,51 @@
+package web
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mimirsoft/mimirledger/api/datastore"
+	"github.com/mimirsoft/mimirledger/api/models"
+)
+
+// SettingsController is the controller struct for settings
+type SettingsController struct {
+	DataStores *datastore.Datastores
+}
+
+// NewSettingsController instantiates a new SettingsController struct
+func NewSettingsController(ds *datastore.Datastores) *SettingsController {
+	return &SettingsController{
+		DataStores: ds,
+	}
+}
+
+// GET /settings
+func (sc *SettingsController) SettingList(_ context.Context) ([]*models.Setting, error) {
+	settings, err := models.RetrieveSettings(sc.DataStores)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveSettings:%w", err)
+	}
+
+	return settings, nil
+}
+
+// GET /settings/{settingName}
+func (sc *SettingsController) GetSettingByName(_ context.Context, name string) (*models.Setting, error) {
+	setting, err := models.RetrieveSettingByName(sc.DataStores, name)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveSettingByName:%w", err)
+	}
+
+	return setting, nil
+}
+
+// PUT /settings/{settingName}
+func (sc *SettingsController) UpdateSetting(_ context.Context, setting *models.Setting) (*models.Setting, error) {
+	err := setting.StoreOrUpdate(sc.DataStores)
+	if err != nil {
+		return nil, fmt.Errorf("setting.StoreOrUpdate:%w", err)
+	}
+
+	return setting, nil
+}
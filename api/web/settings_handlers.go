@@ -0,0 +1,77 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mimirsoft/mimirledger/api/models"
+	"github.com/mimirsoft/mimirledger/api/web/request"
+	"github.com/mimirsoft/mimirledger/api/web/response"
+)
+
+// GET /settings
+func GetSettings(settingsCtl *SettingsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		settings, err := settingsCtl.SettingList(req.Context())
+		if err != nil {
+			return NewRequestError(http.StatusServiceUnavailable, err)
+		}
+
+		jsonResponse := response.ConvertSettingsToRespSettingSet(settings)
+
+		return RespondOK(res, jsonResponse)
+	}
+}
+
+// GET /settings/{settingName}
+func GetSetting(settingsCtl *SettingsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		settingName := chi.URLParam(req, "settingName")
+
+		setting, err := settingsCtl.GetSettingByName(req.Context(), settingName)
+		if err != nil {
+			if errors.Is(err, models.ErrSettingNotFound) {
+				return NewRequestError(http.StatusNotFound, err)
+			}
+
+			return NewRequestError(http.StatusServiceUnavailable, err)
+		}
+
+		jsonResponse := response.SettingToRespSetting(setting)
+
+		return RespondOK(res, jsonResponse)
+	}
+}
+
+// PUT /settings/{settingName}
+func PutSettingUpdate(settingsCtl *SettingsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		settingName := chi.URLParam(req, "settingName")
+
+		if req.Body == nil {
+			return NewRequestError(http.StatusBadRequest, ErrNoRequestBody)
+		}
+
+		var setting request.Setting
+
+		err := json.NewDecoder(req.Body).Decode(&setting)
+		if err != nil {
+			return fmt.Errorf("json.NewDecoder(r.Body).Decode:%w", err)
+		}
+
+		mdlSetting := request.ReqSettingToSetting(&setting)
+		mdlSetting.SettingName = settingName
+
+		mySetting, err := settingsCtl.UpdateSetting(req.Context(), mdlSetting)
+		if err != nil {
+			return NewRequestError(http.StatusBadRequest, err)
+		}
+
+		jsonResponse := response.SettingToRespSetting(mySetting)
+
+		return RespondOK(res, jsonResponse)
+	}
+}
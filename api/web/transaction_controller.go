@@ -28,25 +28,129 @@ func (tc *TransactionsController) CreateTransaction(_ context.Context, myTxn *mo
 	if err != nil {
 		return nil, fmt.Errorf("myTxn.Store:%w", err)
 	}
+
+	if _, err = models.DetectDuplicates(tc.DataStores, myTxn); err != nil {
+		return nil, fmt.Errorf("models.DetectDuplicates:%w", err)
+	}
 	// after creating transaction, update balance on all affected accounts
 	return myTxn, nil
 }
 
+// GET /transactions/duplicates?status=
+func (tc *TransactionsController) GetDuplicates(_ context.Context,
+	status datastore.DuplicateStatus) ([]*models.TransactionDuplicate, error) {
+	duplicates, err := models.RetrieveTransactionDuplicates(tc.DataStores, status)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveTransactionDuplicates:%w", err)
+	}
+
+	return duplicates, nil
+}
+
+// GET /transactions/{transactionID}/suggestions
+func (tc *TransactionsController) GetSuggestions(_ context.Context, transactionID,
+	accountID uint64) ([]*models.AccountSuggestion, error) {
+	myTxn, err := models.RetrieveTransactionByID(tc.DataStores, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveTransactionByID:%w", err)
+	}
+
+	suggestions, err := models.SharedCategorizer(tc.DataStores).SuggestAccounts(tc.DataStores, myTxn, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("SuggestAccounts:%w", err)
+	}
+
+	return suggestions, nil
+}
+
+// POST /transactions/duplicates/{duplicateID}/merge
+func (tc *TransactionsController) MergeDuplicate(_ context.Context, duplicateID,
+	keepTransactionID uint64) (*models.Transaction, error) {
+	myDuplicate, err := models.RetrieveTransactionDuplicateByID(tc.DataStores, duplicateID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveTransactionDuplicateByID:%w", err)
+	}
+
+	myTxn, err := myDuplicate.Merge(tc.DataStores, keepTransactionID)
+	if err != nil {
+		return nil, fmt.Errorf("myDuplicate.Merge:%w", err)
+	}
+
+	return myTxn, nil
+}
+
+// POST /transactions/duplicates/{duplicateID}/dismiss
+func (tc *TransactionsController) DismissDuplicate(_ context.Context,
+	duplicateID uint64) (*models.TransactionDuplicate, error) {
+	myDuplicate, err := models.RetrieveTransactionDuplicateByID(tc.DataStores, duplicateID)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveTransactionDuplicateByID:%w", err)
+	}
+
+	if err = myDuplicate.Dismiss(tc.DataStores); err != nil {
+		return nil, fmt.Errorf("myDuplicate.Dismiss:%w", err)
+	}
+
+	return myDuplicate, nil
+}
+
+// GET /transactions
+func (tc *TransactionsController) GetJournal(_ context.Context,
+	filter *models.TransactionJournalFilter) (*models.Journal, error) {
+	myJournal, err := models.RetrieveJournal(tc.DataStores, filter)
+	if err != nil {
+		return nil, fmt.Errorf("models.RetrieveJournal:%w", err)
+	}
+
+	return myJournal, nil
+}
+
+// GET /transactions/search
+func (tc *TransactionsController) SearchTransactions(_ context.Context,
+	filter *models.TransactionSearchFilter) (*models.TransactionSearch, error) {
+	mySearch, err := models.SearchTransactions(tc.DataStores, filter)
+	if err != nil {
+		return nil, fmt.Errorf("models.SearchTransactions:%w", err)
+	}
+
+	return mySearch, nil
+}
+
 // GET /transactions/account/{accountID}
-func (tc *TransactionsController) GetTransactionsForAccount(_ context.Context, accountID uint64) (*models.Account,
-	[]*models.TransactionLedger,
-	error) {
+func (tc *TransactionsController) GetTransactionsForAccount(_ context.Context, accountID uint64,
+	filter *models.TransactionLedgerFilter) (*models.Account, *models.AccountLedger, error) {
 	account, err := models.RetrieveAccountByID(tc.DataStores, accountID)
 	if err != nil {
-		return nil, nil, fmt.Errorf("models.RetrieveTransactionsForAccountID:%w", err)
+		return nil, nil, fmt.Errorf("models.RetrieveAccountByID:%w", err)
 	}
 
-	myTxn, err := models.RetrieveTransactionLedgerForAccountID(tc.DataStores, accountID)
+	myLedger, err := models.RetrieveAccountLedger(tc.DataStores, account, filter)
 	if err != nil {
-		return nil, nil, fmt.Errorf("models.RetrieveTransactionLedgerForAccountID:%w", err)
+		return nil, nil, fmt.Errorf("models.RetrieveAccountLedger:%w", err)
 	}
 
-	return account, myTxn, nil
+	return account, myLedger, nil
+}
+
+// GET /transactions/account/{accountID}/statement?template=
+func (tc *TransactionsController) RenderStatementForAccount(ctx context.Context, accountID uint64,
+	filter *models.TransactionLedgerFilter, templateRef, title string) ([]byte, string, error) {
+	myTemplate, err := models.RetrieveReportTemplateByRef(tc.DataStores, templateRef)
+	if err != nil {
+		return nil, "", fmt.Errorf("models.RetrieveReportTemplateByRef:%w", err)
+	}
+
+	account, myLedger, err := tc.GetTransactionsForAccount(ctx, accountID, filter)
+	if err != nil {
+		return nil, "", fmt.Errorf("tc.GetTransactionsForAccount:%w", err)
+	}
+
+	rendered, err := myTemplate.RenderAccountStatement(ctx, tc.DataStores, account, myLedger, title)
+	if err != nil {
+		return nil, "", fmt.Errorf("myTemplate.RenderAccountStatement:%w", err)
+	}
+
+	return rendered, myTemplate.ContentType(), nil
 }
 
 // GET /transactions/account/{accountID}/unreconciled?date=<date>
@@ -94,9 +198,9 @@ func (tc *TransactionsController) UpdateTransaction(_ context.Context, myTxn *mo
 	return myTxn, nil
 }
 
-// PUT /transactions/{transactionID}/reconciled
-func (tc *TransactionsController) UpdateReconciled(_ context.Context, myTxn *models.Transaction) (*models.Transaction,
-	error) {
+// PUT /transactions/{transactionID}/reconciled?accountID=<id>
+func (tc *TransactionsController) UpdateReconciled(_ context.Context, myTxn *models.Transaction,
+	accountID uint64) (*models.Transaction, error) {
 	readTxn, err := models.RetrieveTransactionByID(tc.DataStores, myTxn.TransactionID)
 	if err != nil {
 		return nil, fmt.Errorf("models.RetrieveTransactionByID:%w", err)
@@ -105,7 +209,7 @@ func (tc *TransactionsController) UpdateReconciled(_ context.Context, myTxn *mod
 	readTxn.IsReconciled = true
 	readTxn.TransactionReconcileDate = myTxn.TransactionReconcileDate
 
-	err = readTxn.UpdateReconciled(tc.DataStores)
+	err = readTxn.UpdateReconciled(tc.DataStores, accountID)
 	if err != nil {
 		return nil, fmt.Errorf("readTxn.UpdateReconciled:%w", err)
 	}
@@ -113,9 +217,9 @@ func (tc *TransactionsController) UpdateReconciled(_ context.Context, myTxn *mod
 	return readTxn, nil
 }
 
-// PUT /transactions/{transactionID}/unreconciled
-func (tc *TransactionsController) UpdateUnreconciled(_ context.Context, myTxn *models.Transaction) (*models.Transaction,
-	error) {
+// PUT /transactions/{transactionID}/unreconciled?accountID=<id>
+func (tc *TransactionsController) UpdateUnreconciled(_ context.Context, myTxn *models.Transaction,
+	accountID uint64) (*models.Transaction, error) {
 	readTxn, err := models.RetrieveTransactionByID(tc.DataStores, myTxn.TransactionID)
 	if err != nil {
 		return nil, fmt.Errorf("models.RetrieveTransactionByID:%w", err)
@@ -123,7 +227,7 @@ func (tc *TransactionsController) UpdateUnreconciled(_ context.Context, myTxn *m
 
 	readTxn.IsReconciled = false
 
-	err = readTxn.UpdateUnreconciled(tc.DataStores)
+	err = readTxn.UpdateUnreconciled(tc.DataStores, accountID)
 	if err != nil {
 		return nil, fmt.Errorf("myTxn.UpdateUnreconciled:%w", err)
 	}
@@ -131,6 +235,28 @@ func (tc *TransactionsController) UpdateUnreconciled(_ context.Context, myTxn *m
 	return readTxn, nil
 }
 
+// POST /transactions/reconcile
+func (tc *TransactionsController) ReconcileTransactions(_ context.Context, accountID uint64, transactionIDs []uint64,
+	reconcileDate time.Time) (*models.ReconcileResult, error) {
+	result, err := models.ReconcileTransactions(tc.DataStores, accountID, transactionIDs, reconcileDate)
+	if err != nil {
+		return nil, fmt.Errorf("models.ReconcileTransactions:%w", err)
+	}
+
+	return result, nil
+}
+
+// POST /transactions/unreconcile
+func (tc *TransactionsController) UnreconcileTransactions(_ context.Context, accountID uint64,
+	transactionIDs []uint64, cutoffDate time.Time) (*models.ReconcileResult, error) {
+	result, err := models.UnreconcileTransactions(tc.DataStores, accountID, transactionIDs, cutoffDate)
+	if err != nil {
+		return nil, fmt.Errorf("models.UnreconcileTransactions:%w", err)
+	}
+
+	return result, nil
+}
+
 // DELETE /transactions/{transactionID}
 func (tc *TransactionsController) DeleteTransaction(_ context.Context, transactionID uint64) (*models.Transaction,
 	error) {
@@ -1,14 +1,17 @@
 package web
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/mimirsoft/mimirledger/api/datastore"
 	"github.com/mimirsoft/mimirledger/api/models"
 	"github.com/mimirsoft/mimirledger/api/web/request"
 	"github.com/mimirsoft/mimirledger/api/web/response"
@@ -39,6 +42,158 @@ func GetTransaction(contoller *TransactionsController) func(res http.ResponseWri
 	}
 }
 
+// GET /transactions?startDate=<date>&endDate=<date>&accountID=<id>&minAmount=<int>&maxAmount=<int>
+// &reconciled=<bool>&reference=<str>&comment=<str>&cursor=<str>&limit=<int>
+func GetTransactions(contoller *TransactionsController) func(res http.ResponseWriter, req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		// the journal always covers the sub-accounts of accountID
+		if req.URL.Query().Has("includeSubAccounts") {
+			return NewRequestError(http.StatusBadRequest, ErrJournalIncludeSubAccounts)
+		}
+
+		// the journal shares the filters of the account ledger
+		ledgerFilter, err := parseLedgerFilter(req)
+		if err != nil {
+			return err
+		}
+
+		ledgerFilter.Cursor, ledgerFilter.Limit, err = parsePage(req, defaultJournalLimit, maxJournalLimit)
+		if err != nil {
+			return err
+		}
+
+		filter := models.TransactionJournalFilter{
+			AccountID:    0,
+			StartDate:    ledgerFilter.StartDate,
+			EndDate:      ledgerFilter.EndDate,
+			MinAmount:    ledgerFilter.MinAmount,
+			MaxAmount:    ledgerFilter.MaxAmount,
+			IsReconciled: ledgerFilter.IsReconciled,
+			Reference:    ledgerFilter.Reference,
+			Comment:      ledgerFilter.Comment,
+			Cursor:       ledgerFilter.Cursor,
+			Limit:        ledgerFilter.Limit,
+		}
+
+		if accountIDStr := req.URL.Query().Get("accountID"); accountIDStr != "" {
+			accountID, err := strconv.ParseUint(accountIDStr, 10, 64)
+			if err != nil || accountID == 0 {
+				return NewRequestError(http.StatusBadRequest, ErrInvalidAccountID)
+			}
+
+			filter.AccountID = accountID
+		}
+
+		myJournal, err := contoller.GetJournal(req.Context(), &filter)
+		if err != nil {
+			switch {
+			case errors.Is(err, models.ErrJournalCursorInvalid):
+				return NewRequestError(http.StatusBadRequest, err)
+			case errors.Is(err, models.ErrAccountNotFound):
+				return NewRequestError(http.StatusNotFound, err)
+			}
+
+			return fmt.Errorf("contoller.GetJournal:%w", err)
+		}
+
+		jsonResponse := response.ConvertJournalToRespJournal(myJournal)
+
+		return RespondOK(res, jsonResponse)
+	}
+}
+
+var ErrJournalIncludeSubAccounts = errors.New("includeSubAccounts is not a journal filter, " +
+	"accountID always includes sub-accounts")
+
+// defaultJournalLimit is the page size of the journal without a limit
+const defaultJournalLimit = 50
+
+// maxJournalLimit is the largest page of the journal, larger limits are clamped to it
+const maxJournalLimit = 500
+
+var ErrInvalidOffset = errors.New("invalid offset")
+
+// defaultSearchLimit is the page size of a search without a limit
+const defaultSearchLimit = 50
+
+// GET /transactions/search?q=<query>&accountID=<id>&startDate=<date>&endDate=<date>&amount=<decimal>
+// &minAmount=<decimal>&maxAmount=<decimal>&offset=<int>&limit=<int>
+func GetTransactionsSearch(contoller *TransactionsController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		query := req.URL.Query()
+		filter := models.TransactionSearchFilter{ //nolint:exhaustruct
+			Query:     query.Get("q"),
+			Amount:    query.Get("amount"),
+			MinAmount: query.Get("minAmount"),
+			MaxAmount: query.Get("maxAmount"),
+			Limit:     defaultSearchLimit,
+		}
+
+		if accountIDStr := query.Get("accountID"); accountIDStr != "" {
+			accountID, err := strconv.ParseUint(accountIDStr, 10, 64)
+			if err != nil || accountID == 0 {
+				return NewRequestError(http.StatusBadRequest, ErrInvalidAccountID)
+			}
+
+			filter.AccountID = accountID
+		}
+
+		if startDateStr := query.Get("startDate"); startDateStr != "" {
+			startDate, err := time.Parse("2006-01-02", startDateStr)
+			if err != nil {
+				return NewRequestError(http.StatusBadRequest, ErrInvalidStartDate)
+			}
+
+			filter.StartDate = sql.NullTime{Time: startDate, Valid: true}
+		}
+
+		if endDateStr := query.Get("endDate"); endDateStr != "" {
+			endDate, err := time.Parse("2006-01-02", endDateStr)
+			if err != nil {
+				return NewRequestError(http.StatusBadRequest, ErrInvalidEndDate)
+			}
+
+			filter.EndDate = sql.NullTime{Time: endDate, Valid: true}
+		}
+
+		if offsetStr := query.Get("offset"); offsetStr != "" {
+			offset, err := strconv.ParseUint(offsetStr, 10, 64)
+			if err != nil {
+				return NewRequestError(http.StatusBadRequest, ErrInvalidOffset)
+			}
+
+			filter.Offset = offset
+		}
+
+		if limitStr := query.Get("limit"); limitStr != "" {
+			limit, err := strconv.ParseUint(limitStr, 10, 64)
+			if err != nil || limit == 0 || limit > maxLedgerLimit {
+				return NewRequestError(http.StatusBadRequest, fmt.Errorf("%w, must be 1 to %d", ErrInvalidLimit,
+					maxLedgerLimit))
+			}
+
+			filter.Limit = limit
+		}
+
+		mySearch, err := contoller.SearchTransactions(req.Context(), &filter)
+		if err != nil {
+			switch {
+			case errors.Is(err, models.ErrSearchQueryEmpty), errors.Is(err, models.ErrSearchAmountInvalid):
+				return NewRequestError(http.StatusBadRequest, err)
+			case errors.Is(err, models.ErrAccountNotFound):
+				return NewRequestError(http.StatusNotFound, err)
+			}
+
+			return fmt.Errorf("contoller.SearchTransactions:%w", err)
+		}
+
+		jsonResponse := response.ConvertSearchToRespSearch(filter.Query, mySearch)
+
+		return RespondOK(res, jsonResponse)
+	}
+}
+
 // POST /transactions
 func PostTransactions(contoller *TransactionsController) func(res http.ResponseWriter, req *http.Request) error {
 	return func(res http.ResponseWriter, req *http.Request) error {
@@ -66,7 +221,105 @@ func PostTransactions(contoller *TransactionsController) func(res http.ResponseW
 	}
 }
 
-// GET /transactions/account/{accountID}
+var ErrInvalidIncludeSubAccounts = errors.New("invalid includeSubAccounts, must be true or false")
+var ErrInvalidMinAmount = errors.New("invalid minAmount")
+var ErrInvalidMaxAmount = errors.New("invalid maxAmount")
+var ErrInvalidReconciled = errors.New("invalid reconciled, must be true or false")
+var ErrInvalidLimit = errors.New("invalid limit")
+var ErrInvalidWithTotalCount = errors.New("invalid withTotalCount, must be true or false")
+
+// defaultLedgerLimit is the page size of an account ledger read from a cursor without a limit, an account ledger
+// without either is not paged
+const defaultLedgerLimit = 100
+
+// maxLedgerLimit is the largest page of an account ledger, larger limits are clamped to it
+const maxLedgerLimit = 1000
+
+// parseLedgerFilter reads the optional filters of an account ledger
+func parseLedgerFilter(req *http.Request) (*models.TransactionLedgerFilter, error) {
+	filter := models.TransactionLedgerFilter{} //nolint:exhaustruct
+
+	if startDateStr := req.URL.Query().Get("startDate"); startDateStr != "" {
+		startDate, err := time.Parse("2006-01-02", startDateStr)
+		if err != nil {
+			return nil, NewRequestError(http.StatusBadRequest, ErrInvalidStartDate)
+		}
+
+		filter.StartDate = sql.NullTime{Time: startDate, Valid: true}
+	}
+
+	if endDateStr := req.URL.Query().Get("endDate"); endDateStr != "" {
+		endDate, err := time.Parse("2006-01-02", endDateStr)
+		if err != nil {
+			return nil, NewRequestError(http.StatusBadRequest, ErrInvalidEndDate)
+		}
+
+		filter.EndDate = sql.NullTime{Time: endDate, Valid: true}
+	}
+
+	if includeStr := req.URL.Query().Get("includeSubAccounts"); includeStr != "" {
+		include, err := strconv.ParseBool(includeStr)
+		if err != nil {
+			return nil, NewRequestError(http.StatusBadRequest, ErrInvalidIncludeSubAccounts)
+		}
+
+		filter.IncludeSubAccounts = include
+	}
+
+	if minAmountStr := req.URL.Query().Get("minAmount"); minAmountStr != "" {
+		minAmount, err := strconv.ParseInt(minAmountStr, 10, 64)
+		if err != nil || minAmount < 0 {
+			return nil, NewRequestError(http.StatusBadRequest, ErrInvalidMinAmount)
+		}
+
+		filter.MinAmount = sql.NullInt64{Int64: minAmount, Valid: true}
+	}
+
+	if maxAmountStr := req.URL.Query().Get("maxAmount"); maxAmountStr != "" {
+		maxAmount, err := strconv.ParseInt(maxAmountStr, 10, 64)
+		if err != nil || maxAmount < 0 {
+			return nil, NewRequestError(http.StatusBadRequest, ErrInvalidMaxAmount)
+		}
+
+		filter.MaxAmount = sql.NullInt64{Int64: maxAmount, Valid: true}
+	}
+
+	if reconciledStr := req.URL.Query().Get("reconciled"); reconciledStr != "" {
+		reconciled, err := strconv.ParseBool(reconciledStr)
+		if err != nil {
+			return nil, NewRequestError(http.StatusBadRequest, ErrInvalidReconciled)
+		}
+
+		filter.IsReconciled = sql.NullBool{Bool: reconciled, Valid: true}
+	}
+
+	filter.Reference = req.URL.Query().Get("reference")
+	filter.Comment = req.URL.Query().Get("comment")
+
+	return &filter, nil
+}
+
+// parsePage reads the optional cursor and limit of a page.  Without a limit the page is defaultLimit long, and
+// limits over maxLimit are clamped to it.
+func parsePage(req *http.Request, defaultLimit, maxLimit uint64) (string, uint64, error) {
+	cursor := req.URL.Query().Get("cursor")
+
+	limitStr := req.URL.Query().Get("limit")
+	if limitStr == "" {
+		return cursor, defaultLimit, nil
+	}
+
+	limit, err := strconv.ParseUint(limitStr, 10, 64)
+	if err != nil || limit == 0 {
+		return "", 0, NewRequestError(http.StatusBadRequest, ErrInvalidLimit)
+	}
+
+	return cursor, min(limit, maxLimit), nil
+}
+
+// GET /transactions/account/{accountID}?startDate=<date>&endDate=<date>&includeSubAccounts=<bool>
+// &minAmount=<int>&maxAmount=<int>&reconciled=<bool>&reference=<str>&comment=<str>&cursor=<str>&limit=<int>
+// &withTotalCount=<bool>
 func GetTransactionsOnAccount(contoller *TransactionsController) func(res http.ResponseWriter,
 	req *http.Request) error {
 	return func(res http.ResponseWriter, req *http.Request) error {
@@ -81,17 +334,83 @@ func GetTransactionsOnAccount(contoller *TransactionsController) func(res http.R
 			return NewRequestError(http.StatusBadRequest, ErrInvalidAccountID)
 		}
 
-		account, transactions, err := contoller.GetTransactionsForAccount(req.Context(), accountID)
+		filter, err := parseLedgerFilter(req)
 		if err != nil {
+			return err
+		}
+
+		// the client reads the whole ledger, it is only paged when a page is asked for
+		if req.URL.Query().Has("limit") || req.URL.Query().Has("cursor") {
+			filter.Cursor, filter.Limit, err = parsePage(req, defaultLedgerLimit, maxLedgerLimit)
+			if err != nil {
+				return err
+			}
+		}
+
+		if withTotalCountStr := req.URL.Query().Get("withTotalCount"); withTotalCountStr != "" {
+			filter.WithTotalCount, err = strconv.ParseBool(withTotalCountStr)
+			if err != nil {
+				return NewRequestError(http.StatusBadRequest, ErrInvalidWithTotalCount)
+			}
+		}
+
+		account, myLedger, err := contoller.GetTransactionsForAccount(req.Context(), accountID, filter)
+		if err != nil {
+			if errors.Is(err, models.ErrLedgerCursorInvalid) {
+				return NewRequestError(http.StatusBadRequest, err)
+			}
+
 			return NewRequestError(http.StatusNotFound, err)
 		}
 
-		jsonResponse := response.ConvertTransactionLedgerToRespTransactionLedger(account, transactions)
+		jsonResponse := response.ConvertTransactionLedgerToRespTransactionLedger(account, myLedger)
 
 		return RespondOK(res, jsonResponse)
 	}
 }
 
+var ErrNoTemplate = errors.New("missing template request parameter")
+
+// GET /transactions/account/{accountID}/statement?template=<templateID or name>&startDate=<date>&endDate=<date>
+// &title=<title>
+func GetStatementOnAccount(contoller *TransactionsController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		accountIDStr := chi.URLParam(req, "accountID")
+
+		accountID, err := strconv.ParseUint(accountIDStr, 10, 64)
+		if err != nil {
+			return NewRequestError(http.StatusBadRequest, err)
+		}
+
+		if accountID == 0 {
+			return NewRequestError(http.StatusBadRequest, ErrInvalidAccountID)
+		}
+
+		templateRef := req.URL.Query().Get("template")
+		if templateRef == "" {
+			return NewRequestError(http.StatusBadRequest, ErrNoTemplate)
+		}
+
+		filter, err := parseLedgerFilter(req)
+		if err != nil {
+			return err
+		}
+
+		rendered, contentType, err := contoller.RenderStatementForAccount(req.Context(), accountID, filter,
+			templateRef, req.URL.Query().Get("title"))
+		if err != nil {
+			if errors.Is(err, models.ErrAccountNotFound) {
+				return NewRequestError(http.StatusNotFound, err)
+			}
+
+			return respondWithTemplateError(err)
+		}
+
+		return RespondRendered(res, contentType, rendered)
+	}
+}
+
 var ErrInvalidReconcileDate = errors.New("invalid reconcile date")
 
 // GET /transactions/account/{accountID}/unreconciled?date=<date>
@@ -196,7 +515,23 @@ func DeleteTransaction(contoller *TransactionsController) func(res http.Response
 	}
 }
 
-// PUT /transactions/{transactionID}/reconciled
+// parseReconcileAccountID reads the optional accountID of a single transaction reconcile, 0 for every line
+func parseReconcileAccountID(req *http.Request) (uint64, error) {
+	accountIDStr := req.URL.Query().Get("accountID")
+	if accountIDStr == "" {
+		return 0, nil
+	}
+
+	accountID, err := strconv.ParseUint(accountIDStr, 10, 64)
+	if err != nil || accountID == 0 {
+		return 0, NewRequestError(http.StatusBadRequest, ErrInvalidAccountID)
+	}
+
+	return accountID, nil
+}
+
+// PUT /transactions/{transactionID}/reconciled?accountID=<id>, only the lines on the account and its children are
+// reconciled when accountID is given
 func PutTransactionReconciled(contoller *TransactionsController) func(res http.ResponseWriter,
 	req *http.Request) error {
 	return func(res http.ResponseWriter, req *http.Request) error {
@@ -224,10 +559,15 @@ func PutTransactionReconciled(contoller *TransactionsController) func(res http.R
 			return fmt.Errorf("json.NewDecoder(r.Body).Decode:%w", err)
 		}
 
+		accountID, err := parseReconcileAccountID(req)
+		if err != nil {
+			return err
+		}
+
 		mdlTransaction := request.ReqTransactionToTransaction(&reqTransaction)
 		mdlTransaction.TransactionID = transactionID
 
-		transaction, err := contoller.UpdateReconciled(req.Context(), mdlTransaction)
+		transaction, err := contoller.UpdateReconciled(req.Context(), mdlTransaction, accountID)
 		if err != nil {
 			return NewRequestError(http.StatusBadRequest, err)
 		}
@@ -238,7 +578,87 @@ func PutTransactionReconciled(contoller *TransactionsController) func(res http.R
 	}
 }
 
-// PUT /transactions/{transactionID}/unreconciled
+// parseTransactionsReconcile reads the body of a bulk reconcile or unreconcile
+func parseTransactionsReconcile(req *http.Request) (*request.TransactionsReconcile, error) {
+	if req.Body == nil {
+		return nil, NewRequestError(http.StatusBadRequest, ErrNoRequestBody)
+	}
+
+	var reqReconcile request.TransactionsReconcile
+
+	if err := json.NewDecoder(req.Body).Decode(&reqReconcile); err != nil {
+		return nil, NewRequestError(http.StatusBadRequest, err)
+	}
+
+	if reqReconcile.AccountID == 0 {
+		return nil, NewRequestError(http.StatusBadRequest, ErrInvalidAccountID)
+	}
+
+	return &reqReconcile, nil
+}
+
+// respondWithReconcileError maps the errors of a bulk reconcile or unreconcile to a status
+func respondWithReconcileError(err error) error {
+	switch {
+	case errors.Is(err, models.ErrAccountNotFound), errors.Is(err, models.ErrTransactionNotFound):
+		return NewRequestError(http.StatusNotFound, err)
+	case errors.Is(err, models.ErrReconcileNoTransactions), errors.Is(err, models.ErrReconciledDateInvalid),
+		errors.Is(err, models.ErrReconcileTransactionNotOnAccount):
+		return NewRequestError(http.StatusBadRequest, err)
+	}
+
+	return fmt.Errorf("reconcile:%w", err)
+}
+
+// POST /transactions/reconcile, reconciles all the transactions on reconcileDate or none of them
+func PostTransactionsReconcile(contoller *TransactionsController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		reqReconcile, err := parseTransactionsReconcile(req)
+		if err != nil {
+			return err
+		}
+
+		if reqReconcile.ReconcileDate == nil {
+			return NewRequestError(http.StatusBadRequest, models.ErrReconciledDateInvalid)
+		}
+
+		result, err := contoller.ReconcileTransactions(req.Context(), reqReconcile.AccountID,
+			reqReconcile.TransactionIDs, *reqReconcile.ReconcileDate)
+		if err != nil {
+			return respondWithReconcileError(err)
+		}
+
+		return RespondOK(res, response.ReconcileResultToRespTransactionsReconcile(result))
+	}
+}
+
+// POST /transactions/unreconcile, unreconciles all the transactions or none of them
+func PostTransactionsUnreconcile(contoller *TransactionsController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		reqReconcile, err := parseTransactionsReconcile(req)
+		if err != nil {
+			return err
+		}
+
+		var cutoffDate time.Time
+		if reqReconcile.ReconcileDate != nil {
+			cutoffDate = *reqReconcile.ReconcileDate
+		}
+
+		result, err := contoller.UnreconcileTransactions(req.Context(), reqReconcile.AccountID,
+			reqReconcile.TransactionIDs, cutoffDate)
+		if err != nil {
+			return respondWithReconcileError(err)
+		}
+
+		return RespondOK(res, response.ReconcileResultToRespTransactionsReconcile(result))
+	}
+}
+
+// PUT /transactions/{transactionID}/unreconciled?accountID=<id>, only the lines on the account and its children are
+// unreconciled when accountID is given
 func PutTransactionUnreconciled(contoller *TransactionsController) func(res http.ResponseWriter,
 	req *http.Request) error {
 	return func(res http.ResponseWriter, req *http.Request) error {
@@ -253,10 +673,15 @@ func PutTransactionUnreconciled(contoller *TransactionsController) func(res http
 			return NewRequestError(http.StatusBadRequest, ErrInvalidTransactionID)
 		}
 
+		accountID, err := parseReconcileAccountID(req)
+		if err != nil {
+			return err
+		}
+
 		mdlTransaction := models.Transaction{} //nolint:exhaustruct
 		mdlTransaction.TransactionID = transactionID
 
-		transaction, err := contoller.UpdateUnreconciled(req.Context(), &mdlTransaction)
+		transaction, err := contoller.UpdateUnreconciled(req.Context(), &mdlTransaction, accountID)
 		if err != nil {
 			return NewRequestError(http.StatusBadRequest, err)
 		}
@@ -266,3 +691,131 @@ func PutTransactionUnreconciled(contoller *TransactionsController) func(res http
 		return RespondOK(res, jsonResponse)
 	}
 }
+
+var ErrInvalidDuplicateID = errors.New("invalid duplicateID request parameter")
+var ErrInvalidDuplicateStatus = errors.New("invalid status, must be SUSPECTED or DISMISSED")
+
+func parseDuplicateID(req *http.Request) (uint64, error) {
+	duplicateID, err := strconv.ParseUint(chi.URLParam(req, "duplicateID"), 10, 64)
+	if err != nil || duplicateID == 0 {
+		return 0, NewRequestError(http.StatusBadRequest, ErrInvalidDuplicateID)
+	}
+
+	return duplicateID, nil
+}
+
+// respondWithDuplicateError maps the errors of merging and dismissing duplicates to a status
+func respondWithDuplicateError(err error) error {
+	switch {
+	case errors.Is(err, models.ErrTransactionDuplicateNotFound):
+		return NewRequestError(http.StatusNotFound, err)
+	case errors.Is(err, models.ErrTransactionDuplicateKeepInvalid):
+		return NewRequestError(http.StatusBadRequest, err)
+	case errors.Is(err, models.ErrTransactionDuplicateNotSuspected),
+		errors.Is(err, models.ErrTransactionDuplicateReconciled):
+		return NewRequestError(http.StatusConflict, err)
+	}
+
+	return fmt.Errorf("duplicate:%w", err)
+}
+
+// GET /transactions/duplicates?status=<SUSPECTED|DISMISSED>, suspected pairs by default
+func GetTransactionDuplicates(contoller *TransactionsController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		status := datastore.DuplicateStatusSuspected
+
+		if statusStr := req.URL.Query().Get("status"); statusStr != "" {
+			status = datastore.DuplicateStatus(statusStr)
+			if status != datastore.DuplicateStatusSuspected && status != datastore.DuplicateStatusDismissed {
+				return NewRequestError(http.StatusBadRequest, ErrInvalidDuplicateStatus)
+			}
+		}
+
+		duplicates, err := contoller.GetDuplicates(req.Context(), status)
+		if err != nil {
+			return err
+		}
+
+		return RespondOK(res, response.ConvertTransactionDuplicatesToRespTransactionDuplicateSet(duplicates))
+	}
+}
+
+// POST /transactions/duplicates/{duplicateID}/merge, the body may name the keepTransactionID
+func PostTransactionDuplicateMerge(contoller *TransactionsController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		duplicateID, err := parseDuplicateID(req)
+		if err != nil {
+			return err
+		}
+
+		var reqMerge request.TransactionDuplicateMerge
+
+		if req.Body != nil {
+			if err = json.NewDecoder(req.Body).Decode(&reqMerge); err != nil && !errors.Is(err, io.EOF) {
+				return NewRequestError(http.StatusBadRequest, err)
+			}
+		}
+
+		transaction, err := contoller.MergeDuplicate(req.Context(), duplicateID, reqMerge.KeepTransactionID)
+		if err != nil {
+			return respondWithDuplicateError(err)
+		}
+
+		return RespondOK(res, response.TransactionToRespTransaction(transaction))
+	}
+}
+
+// POST /transactions/duplicates/{duplicateID}/dismiss
+func PostTransactionDuplicateDismiss(contoller *TransactionsController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		duplicateID, err := parseDuplicateID(req)
+		if err != nil {
+			return err
+		}
+
+		myDuplicate, err := contoller.DismissDuplicate(req.Context(), duplicateID)
+		if err != nil {
+			return respondWithDuplicateError(err)
+		}
+
+		return RespondOK(res, response.TransactionDuplicateToRespTransactionDuplicate(myDuplicate))
+	}
+}
+
+// GET /transactions/{transactionID}/suggestions?accountID=<id>, accountID is the side of the transaction the accounts
+// are suggested for, the side that is not on the suspense account by default
+func GetTransactionSuggestions(contoller *TransactionsController) func(res http.ResponseWriter,
+	req *http.Request) error {
+	return func(res http.ResponseWriter, req *http.Request) error {
+		transactionID, err := strconv.ParseUint(chi.URLParam(req, "transactionID"), 10, 64)
+		if err != nil || transactionID == 0 {
+			return NewRequestError(http.StatusBadRequest, ErrInvalidTransactionID)
+		}
+
+		var accountID uint64
+
+		if accountIDStr := req.URL.Query().Get("accountID"); accountIDStr != "" {
+			if accountID, err = strconv.ParseUint(accountIDStr, 10, 64); err != nil {
+				return NewRequestError(http.StatusBadRequest, ErrInvalidAccountID)
+			}
+		}
+
+		suggestions, err := contoller.GetSuggestions(req.Context(), transactionID, accountID)
+		if err != nil {
+			switch {
+			case errors.Is(err, models.ErrTransactionNotFound):
+				return NewRequestError(http.StatusNotFound, err)
+			case errors.Is(err, models.ErrSuggestionAccountInvalid):
+				return NewRequestError(http.StatusBadRequest, err)
+			}
+
+			return fmt.Errorf("suggestions:%w", err)
+		}
+
+		return RespondOK(res, &response.AccountSuggestionSet{TransactionID: transactionID,
+			Suggestions: response.ConvertAccountSuggestionsToRespAccountSuggestions(suggestions)})
+	}
+}
@@ -365,6 +365,74 @@ func TestTransaction_PutTransactionUpdateReconciled(t *testing.T) {
 
 }
 
+func TestTransaction_PutTransactionUpdateReconciledOnAccount(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	checking := models.Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	savings := models.Account{AccountName: "Savings", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err = savings.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	txn := models.Transaction{TransactionCore: models.TransactionCore{TransactionComment: "transfer"},
+		DebitCreditSet: []*models.TransactionDebitCredit{
+			{AccountID: checking.AccountID, DebitOrCredit: datastore.AccountSignCredit, TransactionDCAmount: 5000},
+			{AccountID: savings.AccountID, DebitOrCredit: datastore.AccountSignDebit, TransactionDCAmount: 5000},
+		},
+	}
+	err = txn.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	reconcileDate, err := time.Parse("2006-01-02", "2016-07-08")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	acctReq := map[string]interface{}{
+		"transactionReconcileDate": reconcileDate.Format(time.RFC3339),
+	}
+	var test = RouterTest{Request: Request{
+		Method:     http.MethodPut,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/transactions/%d/reconciled?accountID=%d", txn.TransactionID, checking.AccountID),
+		Payload:    acctReq,
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	// the transaction is only reconciled once both of its sides are
+	var res response.Transaction
+	test.ExecWithUnmarshal(&res)
+	g.Expect(res.IsReconciled).To(gomega.BeFalse())
+	g.Expect(res.DebitCreditSet).To(gomega.HaveLen(2))
+
+	for _, debitCredit := range res.DebitCreditSet {
+		if debitCredit.AccountID == checking.AccountID {
+			g.Expect(debitCredit.IsReconciled).To(gomega.BeTrue())
+			g.Expect(debitCredit.TransactionReconcileDate).To(gomega.BeTemporally("~", reconcileDate, time.Second))
+		} else {
+			g.Expect(debitCredit.IsReconciled).To(gomega.BeFalse())
+		}
+	}
+
+	// the savings side is still outstanding
+	myTxn, err := models.RetrieveTransactionByID(TestDataStore, txn.TransactionID)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	isReconciled, err := myTxn.IsReconciledOnAccount(TestDataStore, &savings)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(isReconciled).To(gomega.BeFalse())
+
+	var test2 = RouterTest{Request: Request{
+		Method:     http.MethodPut,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/transactions/%d/reconciled?accountID=abc", txn.TransactionID),
+		Payload:    acctReq,
+	}, GomegaWithT: g, Code: http.StatusBadRequest}
+	test2.Exec()
+}
+
 func TestTransactionsController_DeleteTransaction(t *testing.T) {
 	g := gomega.NewWithT(t)
 	gomega.RegisterFailHandler(ginkgo.Fail)
@@ -500,6 +568,406 @@ func TestTransaction_GetTransactionsOnAccountValid(t *testing.T) {
 
 }
 
+func TestTransaction_GetTransactionsOnAccountDateRange(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	// create accounts first
+	parent := models.Account{AccountName: "Banks", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := parent.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	a1 := models.Account{AccountName: "MyBank", AccountParent: parent.AccountID, AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err = a1.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	a2 := models.Account{AccountName: "Income", AccountSign: datastore.AccountSignCredit, AccountType: datastore.AccountTypeIncome}
+	err = a2.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	for idx, amount := range []uint64{10000, 30000, 5000} {
+		txn := models.Transaction{TransactionCore: models.TransactionCore{
+			TransactionComment: fmt.Sprintf("woot%d", idx),
+			TransactionDate:    time.Date(2024, time.Month(idx+1), 15, 0, 0, 0, 0, time.UTC)},
+			DebitCreditSet: []*models.TransactionDebitCredit{
+				&models.TransactionDebitCredit{AccountID: a2.AccountID,
+					DebitOrCredit:       datastore.AccountSignCredit,
+					TransactionDCAmount: amount},
+				&models.TransactionDebitCredit{AccountID: a1.AccountID,
+					DebitOrCredit:       datastore.AccountSignDebit,
+					TransactionDCAmount: amount},
+			},
+		}
+		err = txn.Store(TestDataStore)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+
+	var test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/transactions/account/%d?startDate=2024-02-01&endDate=2024-02-28", a1.AccountID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var res response.TransactionLedgerSet
+	test.ExecWithUnmarshal(&res)
+	g.Expect(res.OpeningBalance).To(gomega.Equal(int64(10000)))
+	g.Expect(res.Transactions).To(gomega.HaveLen(1))
+	g.Expect(res.Transactions[0].TransactionComment).To(gomega.Equal("woot1"))
+	g.Expect(res.Transactions[0].RunningBalance).To(gomega.Equal(int64(40000)))
+
+	// the parent has no lines of its own
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/transactions/account/%d", parent.AccountID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var resParent response.TransactionLedgerSet
+	test.ExecWithUnmarshal(&resParent)
+	g.Expect(resParent.Transactions).To(gomega.HaveLen(0))
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/transactions/account/%d?includeSubAccounts=true", parent.AccountID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var resSubs response.TransactionLedgerSet
+	test.ExecWithUnmarshal(&resSubs)
+	g.Expect(resSubs.Transactions).To(gomega.HaveLen(3))
+	g.Expect(resSubs.Transactions[0].AccountID).To(gomega.Equal(a1.AccountID))
+	g.Expect(resSubs.Transactions[2].RunningBalance).To(gomega.Equal(int64(45000)))
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/transactions/account/%d?startDate=2024-02-31", a1.AccountID),
+	}, GomegaWithT: g, Code: http.StatusBadRequest}
+	test.Exec()
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/transactions/account/%d?includeSubAccounts=maybe", a1.AccountID),
+	}, GomegaWithT: g, Code: http.StatusBadRequest}
+	test.Exec()
+}
+
+func TestTransaction_GetTransactionsOnAccountPaged(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	// create accounts first
+	a1 := models.Account{AccountName: "MyBank", AccountSign: datastore.AccountSignDebit, AccountType: datastore.AccountTypeAsset}
+	err := a1.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	a2 := models.Account{AccountName: "Income", AccountSign: datastore.AccountSignCredit, AccountType: datastore.AccountTypeIncome}
+	err = a2.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	for idx := 1; idx <= 3; idx++ {
+		txn := models.Transaction{TransactionCore: models.TransactionCore{
+			TransactionComment: fmt.Sprintf("woot%d", idx),
+			TransactionDate:    time.Date(2024, time.Month(idx), 15, 0, 0, 0, 0, time.UTC)},
+			DebitCreditSet: []*models.TransactionDebitCredit{
+				&models.TransactionDebitCredit{AccountID: a2.AccountID,
+					DebitOrCredit:       datastore.AccountSignCredit,
+					TransactionDCAmount: 10000},
+				&models.TransactionDebitCredit{AccountID: a1.AccountID,
+					DebitOrCredit:       datastore.AccountSignDebit,
+					TransactionDCAmount: 10000},
+			},
+		}
+		err = txn.Store(TestDataStore)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+
+	var test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/transactions/account/%d?limit=2&withTotalCount=true", a1.AccountID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var res response.TransactionLedgerSet
+	test.ExecWithUnmarshal(&res)
+	g.Expect(res.TotalCount).To(gomega.HaveValue(gomega.Equal(uint64(3))))
+	g.Expect(res.Transactions).To(gomega.HaveLen(2))
+	g.Expect(res.NextCursor).NotTo(gomega.BeEmpty())
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/transactions/account/%d?limit=2&cursor=%s", a1.AccountID, res.NextCursor),
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var res2 response.TransactionLedgerSet
+	test.ExecWithUnmarshal(&res2)
+	g.Expect(res2.TotalCount).To(gomega.BeNil())
+	g.Expect(res2.Transactions).To(gomega.HaveLen(1))
+	g.Expect(res2.Transactions[0].TransactionComment).To(gomega.Equal("woot3"))
+	g.Expect(res2.Transactions[0].RunningBalance).To(gomega.Equal(int64(30000)))
+	g.Expect(res2.NextCursor).To(gomega.BeEmpty())
+
+	test = RouterTest{Request: Request{
+		Method: http.MethodGet,
+		Router: TestRouter,
+		RequestURL: fmt.Sprintf("/transactions/account/%d?comment=WOOT2&reconciled=false&withTotalCount=true",
+			a1.AccountID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var res3 response.TransactionLedgerSet
+	test.ExecWithUnmarshal(&res3)
+	g.Expect(res3.TotalCount).To(gomega.HaveValue(gomega.Equal(uint64(1))))
+
+	// limits over the largest page are clamped to it
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/transactions/account/%d?limit=%d", a1.AccountID, maxLedgerLimit+1),
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var res4 response.TransactionLedgerSet
+	test.ExecWithUnmarshal(&res4)
+	g.Expect(res4.Transactions).To(gomega.HaveLen(3))
+	g.Expect(res3.Transactions).To(gomega.HaveLen(1))
+	g.Expect(res3.Transactions[0].TransactionComment).To(gomega.Equal("woot2"))
+
+	for _, query := range []string{"limit=0", "limit=abc", "cursor=bogus&limit=2", "minAmount=-5",
+		"maxAmount=x", "reconciled=maybe", "withTotalCount=maybe"} {
+		test = RouterTest{Request: Request{
+			Method:     http.MethodGet,
+			Router:     TestRouter,
+			RequestURL: fmt.Sprintf("/transactions/account/%d?%s", a1.AccountID, query),
+		}, GomegaWithT: g, Code: http.StatusBadRequest}
+		test.Exec()
+	}
+}
+
+func TestTransaction_GetTransactionsOnAccountUnpaged(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	a1 := models.Account{AccountName: "MyBank", AccountSign: datastore.AccountSignDebit, AccountType: datastore.AccountTypeAsset}
+	err := a1.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	a2 := models.Account{AccountName: "Income", AccountSign: datastore.AccountSignCredit, AccountType: datastore.AccountTypeIncome}
+	err = a2.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	for idx := 0; idx <= defaultLedgerLimit; idx++ {
+		txn := models.Transaction{TransactionCore: models.TransactionCore{
+			TransactionComment: fmt.Sprintf("woot%d", idx),
+			TransactionDate:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, idx)},
+			DebitCreditSet: []*models.TransactionDebitCredit{
+				&models.TransactionDebitCredit{AccountID: a2.AccountID,
+					DebitOrCredit:       datastore.AccountSignCredit,
+					TransactionDCAmount: 100},
+				&models.TransactionDebitCredit{AccountID: a1.AccountID,
+					DebitOrCredit:       datastore.AccountSignDebit,
+					TransactionDCAmount: 100},
+			},
+		}
+		err = txn.Store(TestDataStore)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+
+	// without a limit or cursor the whole ledger is returned
+	var test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/transactions/account/%d", a1.AccountID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var res response.TransactionLedgerSet
+	test.ExecWithUnmarshal(&res)
+	g.Expect(res.Transactions).To(gomega.HaveLen(defaultLedgerLimit + 1))
+	g.Expect(res.NextCursor).To(gomega.BeEmpty())
+
+	// a cursor without a limit reads a default page
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/transactions/account/%d?limit=1", a1.AccountID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var res2 response.TransactionLedgerSet
+	test.ExecWithUnmarshal(&res2)
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/transactions/account/%d?cursor=%s", a1.AccountID, res2.NextCursor),
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var res3 response.TransactionLedgerSet
+	test.ExecWithUnmarshal(&res3)
+	g.Expect(res3.Transactions).To(gomega.HaveLen(defaultLedgerLimit))
+	g.Expect(res3.NextCursor).To(gomega.BeEmpty())
+}
+
+func TestTransactions_GetTransactions(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	// create accounts first
+	a1 := models.Account{AccountName: "MyBank", AccountSign: datastore.AccountSignDebit, AccountType: datastore.AccountTypeAsset}
+	err := a1.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	a2 := models.Account{AccountName: "Income", AccountSign: datastore.AccountSignCredit, AccountType: datastore.AccountTypeIncome}
+	err = a2.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	for idx := 1; idx <= 3; idx++ {
+		txn := models.Transaction{TransactionCore: models.TransactionCore{
+			TransactionComment: fmt.Sprintf("woot%d", idx),
+			TransactionDate:    time.Date(2024, time.Month(idx), 15, 0, 0, 0, 0, time.UTC)},
+			DebitCreditSet: []*models.TransactionDebitCredit{
+				&models.TransactionDebitCredit{AccountID: a2.AccountID,
+					DebitOrCredit:       datastore.AccountSignCredit,
+					TransactionDCAmount: uint64(idx * 10000)},
+				&models.TransactionDebitCredit{AccountID: a1.AccountID,
+					DebitOrCredit:       datastore.AccountSignDebit,
+					TransactionDCAmount: uint64(idx * 10000)},
+			},
+		}
+		err = txn.Store(TestDataStore)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+
+	var test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: "/transactions?limit=2",
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var res response.TransactionJournal
+	test.ExecWithUnmarshal(&res)
+	g.Expect(res.TotalCount).To(gomega.Equal(uint64(3)))
+	g.Expect(res.Transactions).To(gomega.HaveLen(2))
+	g.Expect(res.NextCursor).NotTo(gomega.BeEmpty())
+	g.Expect(res.Transactions[0].TransactionComment).To(gomega.Equal("woot1"))
+	g.Expect(res.Transactions[0].DebitCreditSet).To(gomega.HaveLen(2))
+	g.Expect(res.Transactions[0].DebitCreditSet[0].AccountName).To(gomega.Equal("Income"))
+	g.Expect(res.Transactions[0].DebitCreditSet[1].AccountName).To(gomega.Equal("MyBank"))
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: "/transactions?limit=2&cursor=" + res.NextCursor,
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var res2 response.TransactionJournal
+	test.ExecWithUnmarshal(&res2)
+	g.Expect(res2.Transactions).To(gomega.HaveLen(1))
+	g.Expect(res2.Transactions[0].TransactionComment).To(gomega.Equal("woot3"))
+	g.Expect(res2.NextCursor).To(gomega.BeEmpty())
+
+	test = RouterTest{Request: Request{
+		Method: http.MethodGet,
+		Router: TestRouter,
+		RequestURL: fmt.Sprintf("/transactions?accountID=%d&startDate=2024-02-01&minAmount=25000",
+			a1.AccountID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var res3 response.TransactionJournal
+	test.ExecWithUnmarshal(&res3)
+	g.Expect(res3.TotalCount).To(gomega.Equal(uint64(1)))
+	g.Expect(res3.Transactions[0].TransactionComment).To(gomega.Equal("woot3"))
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: "/transactions?accountID=99999",
+	}, GomegaWithT: g, Code: http.StatusNotFound}
+	test.Exec()
+
+	// limits over the largest page are clamped to it
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/transactions?limit=%d", maxJournalLimit+1),
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var res4 response.TransactionJournal
+	test.ExecWithUnmarshal(&res4)
+	g.Expect(res4.Transactions).To(gomega.HaveLen(3))
+
+	for _, query := range []string{"accountID=abc", "limit=0", "cursor=bogus", "startDate=2024-13-01",
+		"includeSubAccounts=true"} {
+		test = RouterTest{Request: Request{
+			Method:     http.MethodGet,
+			Router:     TestRouter,
+			RequestURL: "/transactions?" + query,
+		}, GomegaWithT: g, Code: http.StatusBadRequest}
+		test.Exec()
+	}
+}
+
+func TestTransactions_GetTransactionsSearch(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	// create accounts first
+	a1 := models.Account{AccountName: "MyBank", AccountSign: datastore.AccountSignDebit, AccountType: datastore.AccountTypeAsset}
+	err := a1.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	a2 := models.Account{AccountName: "Home", AccountSign: datastore.AccountSignDebit, AccountType: datastore.AccountTypeExpense}
+	err = a2.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	for _, comment := range []string{"Home Depot lumber", "Corner store"} {
+		txn := models.Transaction{TransactionCore: models.TransactionCore{TransactionComment: comment},
+			DebitCreditSet: []*models.TransactionDebitCredit{
+				&models.TransactionDebitCredit{AccountID: a1.AccountID,
+					DebitOrCredit:       datastore.AccountSignCredit,
+					TransactionDCAmount: 12750},
+				&models.TransactionDebitCredit{AccountID: a2.AccountID,
+					DebitOrCredit:       datastore.AccountSignDebit,
+					TransactionDCAmount: 12750},
+			},
+		}
+		err = txn.Store(TestDataStore)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+
+	var test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/transactions/search?q=depot&amount=127.50&accountID=%d", a1.AccountID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var res response.TransactionSearch
+	test.ExecWithUnmarshal(&res)
+	g.Expect(res.Query).To(gomega.Equal("depot"))
+	g.Expect(res.TotalCount).To(gomega.Equal(uint64(1)))
+	g.Expect(res.Results).To(gomega.HaveLen(1))
+	g.Expect(res.Results[0].TransactionComment).To(gomega.Equal("Home Depot lumber"))
+	g.Expect(res.Results[0].CommentHighlight).To(gomega.Equal("Home <mark>Depot</mark> lumber"))
+	g.Expect(res.Results[0].DebitCreditSet).To(gomega.HaveLen(2))
+	g.Expect(res.Results[0].DebitCreditSet[0].AccountName).To(gomega.Equal("MyBank"))
+
+	for _, query := range []string{"", "q=depot&amount=abc", "q=depot&limit=0", "q=depot&offset=-1",
+		"q=depot&accountID=x", "q=depot&endDate=tomorrow"} {
+		test = RouterTest{Request: Request{
+			Method:     http.MethodGet,
+			Router:     TestRouter,
+			RequestURL: "/transactions/search?" + query,
+		}, GomegaWithT: g, Code: http.StatusBadRequest}
+		test.Exec()
+	}
+}
+
 func TestTransactions_GetUnreconciledTransactionsOnAccount(t *testing.T) {
 	g := gomega.NewWithT(t)
 	gomega.RegisterFailHandler(ginkgo.Fail)
@@ -604,7 +1072,7 @@ func TestTransactions_GetUnreconciledTransactionsOnAccountForDate(t *testing.T)
 	reconciledDate, err := time.Parse("2006-01-02", "2016-07-11")
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 	txn.TransactionReconcileDate = sql.NullTime{Time: reconciledDate, Valid: true}
-	err = txn.UpdateReconciled(TestDataStore)
+	err = txn.UpdateReconciled(TestDataStore, 0)
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 
 	// should still return nothing, as the reconciled date greater than the search limit date
@@ -652,3 +1120,245 @@ func TestTransactions_GetUnreconciledTransactionsOnAccountForDate(t *testing.T)
 	test4.ExecWithUnmarshal(&res4)
 	g.Expect(res4.Transactions).To(gomega.HaveLen(0))
 }
+
+func TestTransactions_Duplicates(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	checking := models.Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	expense := models.Account{AccountName: "Home", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeExpense}
+	err = expense.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	postTxn := func(comment, date string) response.Transaction {
+		var res response.Transaction
+		test := RouterTest{Request: Request{
+			Method:     http.MethodPost,
+			Router:     TestRouter,
+			RequestURL: "/transactions",
+			Payload: M{"transactionComment": comment, "transactionDate": date,
+				"debitCreditSet": []M{
+					{"transactionDCAmount": 4599, "accountID": checking.AccountID, "debitOrCredit": "CREDIT"},
+					{"transactionDCAmount": 4599, "accountID": expense.AccountID, "debitOrCredit": "DEBIT"},
+				}},
+		}, GomegaWithT: g, Code: http.StatusOK}
+		test.ExecWithUnmarshal(&res)
+
+		return res
+	}
+
+	first := postTxn("Hardware store", "2024-05-10T00:00:00Z")
+	second := postTxn("HARDWARE STORE 0042", "2024-05-11T00:00:00Z")
+	third := postTxn("Hardware Store", "2024-05-12T00:00:00Z")
+
+	var duplicateSet response.TransactionDuplicateSet
+	test := RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: "/transactions/duplicates",
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&duplicateSet)
+	g.Expect(duplicateSet.Duplicates).To(gomega.HaveLen(3))
+
+	pairs := make(map[[2]uint64]uint64)
+	for _, duplicate := range duplicateSet.Duplicates {
+		g.Expect(duplicate.DuplicateStatus).To(gomega.Equal(datastore.DuplicateStatusSuspected))
+		g.Expect(duplicate.Transaction.TransactionID).To(gomega.Equal(duplicate.TransactionID))
+		g.Expect(duplicate.Duplicate.TransactionID).To(gomega.Equal(duplicate.DuplicateTransactionID))
+		pairs[[2]uint64{duplicate.TransactionID, duplicate.DuplicateTransactionID}] = duplicate.DuplicateID
+	}
+
+	NewRouterTableTest([]RouterTest{
+		{Request: Request{Method: http.MethodGet, Router: TestRouter,
+			RequestURL: "/transactions/duplicates?status=MAYBE"},
+			GomegaWithT: g, Code: http.StatusBadRequest, RespBody: ErrInvalidDuplicateStatus.Error()},
+		{Request: Request{Method: http.MethodPost, Router: TestRouter,
+			RequestURL: "/transactions/duplicates/0/dismiss"},
+			GomegaWithT: g, Code: http.StatusBadRequest, RespBody: ErrInvalidDuplicateID.Error()},
+		{Request: Request{Method: http.MethodPost, Router: TestRouter,
+			RequestURL: "/transactions/duplicates/999999/merge"},
+			GomegaWithT: g, Code: http.StatusNotFound},
+		{Request: Request{Method: http.MethodPost, Router: TestRouter,
+			RequestURL: fmt.Sprintf("/transactions/duplicates/%d/merge",
+				pairs[[2]uint64{first.TransactionID, second.TransactionID}]),
+			Payload: M{"keepTransactionID": third.TransactionID}},
+			GomegaWithT: g, Code: http.StatusBadRequest,
+			RespBody: models.ErrTransactionDuplicateKeepInvalid.Error()},
+	}).Exec()
+
+	// the first and third are different payments
+	var dismissed response.TransactionDuplicate
+	test = RouterTest{Request: Request{
+		Method: http.MethodPost,
+		Router: TestRouter,
+		RequestURL: fmt.Sprintf("/transactions/duplicates/%d/dismiss",
+			pairs[[2]uint64{first.TransactionID, third.TransactionID}]),
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&dismissed)
+	g.Expect(dismissed.DuplicateStatus).To(gomega.Equal(datastore.DuplicateStatusDismissed))
+
+	// the second is the first entered again, keep the second
+	var kept response.Transaction
+	test = RouterTest{Request: Request{
+		Method: http.MethodPost,
+		Router: TestRouter,
+		RequestURL: fmt.Sprintf("/transactions/duplicates/%d/merge",
+			pairs[[2]uint64{first.TransactionID, second.TransactionID}]),
+		Payload: M{"keepTransactionID": second.TransactionID},
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&kept)
+	g.Expect(kept.TransactionID).To(gomega.Equal(second.TransactionID))
+
+	NewRouterTableTest([]RouterTest{
+		{Request: Request{Method: http.MethodGet, Router: TestRouter,
+			RequestURL: fmt.Sprintf("/transactions/%d", first.TransactionID)},
+			GomegaWithT: g, Code: http.StatusNotFound},
+	}).Exec()
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: "/transactions/duplicates",
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&duplicateSet)
+	g.Expect(duplicateSet.Duplicates).To(gomega.HaveLen(1))
+	g.Expect(duplicateSet.Duplicates[0].TransactionID).To(gomega.Equal(second.TransactionID))
+	g.Expect(duplicateSet.Duplicates[0].DuplicateTransactionID).To(gomega.Equal(third.TransactionID))
+}
+
+func TestTransaction_GetTransactionSuggestions(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	checking := models.Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	groceries := models.Account{AccountName: "Groceries", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeExpense}
+	err = groceries.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	suspense, err := models.RetrieveSuspenseAccount(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	storeTxn := func(comment string, offsetAccountID uint64) *models.Transaction {
+		txn := models.Transaction{TransactionCore: models.TransactionCore{TransactionComment: comment},
+			DebitCreditSet: []*models.TransactionDebitCredit{
+				{AccountID: checking.AccountID, DebitOrCredit: datastore.AccountSignCredit, TransactionDCAmount: 3150},
+				{AccountID: offsetAccountID, DebitOrCredit: datastore.AccountSignDebit, TransactionDCAmount: 3150},
+			},
+		}
+		err := txn.Store(TestDataStore)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		return &txn
+	}
+
+	storeTxn("FRESH MARKET 0042", groceries.AccountID)
+	storeTxn("Fresh Market", groceries.AccountID)
+	uncategorized := storeTxn("FRESH MARKET 0077", suspense.AccountID)
+
+	NewRouterTableTest([]RouterTest{
+		{Request: Request{Method: http.MethodGet, Router: TestRouter,
+			RequestURL: "/transactions/0/suggestions"},
+			GomegaWithT: g, Code: http.StatusBadRequest, RespBody: ErrInvalidTransactionID.Error()},
+		{Request: Request{Method: http.MethodGet, Router: TestRouter,
+			RequestURL: "/transactions/999999/suggestions"},
+			GomegaWithT: g, Code: http.StatusNotFound},
+		{Request: Request{Method: http.MethodGet, Router: TestRouter,
+			RequestURL: fmt.Sprintf("/transactions/%d/suggestions?accountID=%d", uncategorized.TransactionID,
+				groceries.AccountID)},
+			GomegaWithT: g, Code: http.StatusBadRequest, RespBody: models.ErrSuggestionAccountInvalid.Error()},
+	}).Exec()
+
+	var test = RouterTest{Request: Request{
+		Method:     http.MethodGet,
+		Router:     TestRouter,
+		RequestURL: fmt.Sprintf("/transactions/%d/suggestions", uncategorized.TransactionID),
+	}, GomegaWithT: g, Code: http.StatusOK}
+
+	var res response.AccountSuggestionSet
+	test.ExecWithUnmarshal(&res)
+	g.Expect(res.TransactionID).To(gomega.Equal(uncategorized.TransactionID))
+	g.Expect(res.Suggestions).To(gomega.HaveLen(1))
+	g.Expect(res.Suggestions[0].AccountID).To(gomega.Equal(groceries.AccountID))
+	g.Expect(res.Suggestions[0].AccountName).To(gomega.Equal("Groceries"))
+	g.Expect(res.Suggestions[0].Confidence).To(gomega.BeNumerically("~", 1.0, 1e-9))
+}
+
+func TestTransaction_PostTransactionsReconcile(t *testing.T) {
+	g := gomega.NewWithT(t)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	setupDatastores(TestDataStore)
+
+	checking := models.Account{AccountName: "Checking", AccountSign: datastore.AccountSignDebit,
+		AccountType: datastore.AccountTypeAsset}
+	err := checking.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	income := models.Account{AccountName: "Income", AccountSign: datastore.AccountSignCredit,
+		AccountType: datastore.AccountTypeIncome}
+	err = income.Store(TestDataStore)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	transactionIDs := make([]uint64, 3)
+
+	for idx := range transactionIDs {
+		txn := models.Transaction{TransactionCore: models.TransactionCore{TransactionComment: "deposit",
+			TransactionDate: time.Date(2024, 8, idx+1, 0, 0, 0, 0, time.UTC)},
+			DebitCreditSet: []*models.TransactionDebitCredit{
+				{AccountID: checking.AccountID, DebitOrCredit: datastore.AccountSignDebit, TransactionDCAmount: 1000},
+				{AccountID: income.AccountID, DebitOrCredit: datastore.AccountSignCredit, TransactionDCAmount: 1000},
+			},
+		}
+		err = txn.Store(TestDataStore)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		transactionIDs[idx] = txn.TransactionID
+	}
+
+	NewRouterTableTest([]RouterTest{
+		{Request: Request{Method: http.MethodPost, Router: TestRouter, RequestURL: "/transactions/reconcile",
+			Payload: M{"transactionIDs": transactionIDs, "reconcileDate": "2024-08-31T00:00:00Z"}},
+			GomegaWithT: g, Code: http.StatusBadRequest, RespBody: ErrInvalidAccountID.Error()},
+		{Request: Request{Method: http.MethodPost, Router: TestRouter, RequestURL: "/transactions/reconcile",
+			Payload: M{"accountID": checking.AccountID, "transactionIDs": transactionIDs}},
+			GomegaWithT: g, Code: http.StatusBadRequest, RespBody: models.ErrReconciledDateInvalid.Error()},
+		{Request: Request{Method: http.MethodPost, Router: TestRouter, RequestURL: "/transactions/reconcile",
+			Payload: M{"accountID": checking.AccountID, "transactionIDs": []uint64{transactionIDs[0], 999999},
+				"reconcileDate": "2024-08-31T00:00:00Z"}},
+			GomegaWithT: g, Code: http.StatusNotFound},
+	}).Exec()
+
+	var result response.TransactionsReconcile
+
+	test := RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: "/transactions/reconcile",
+		Payload: M{"accountID": checking.AccountID, "transactionIDs": transactionIDs,
+			"reconcileDate": "2024-08-31T00:00:00Z"},
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&result)
+	g.Expect(result.TransactionIDs).To(gomega.Equal(transactionIDs))
+	g.Expect(result.ReconciledSubtotal).To(gomega.Equal(int64(3000)))
+
+	test = RouterTest{Request: Request{
+		Method:     http.MethodPost,
+		Router:     TestRouter,
+		RequestURL: "/transactions/unreconcile",
+		Payload: M{"accountID": checking.AccountID, "transactionIDs": transactionIDs[1:],
+			"reconcileDate": "2024-08-31T00:00:00Z"},
+	}, GomegaWithT: g, Code: http.StatusOK}
+	test.ExecWithUnmarshal(&result)
+	g.Expect(result.ReconciledSubtotal).To(gomega.Equal(int64(1000)))
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/go-chi/chi/v5"
 	"github.com/jmoiron/sqlx"
+	"github.com/mimirsoft/mimirledger/api/blobstore"
 	"github.com/mimirsoft/mimirledger/api/cfg"
 	"github.com/mimirsoft/mimirledger/api/datastore"
 	"github.com/onsi/gomega"
@@ -33,11 +34,16 @@ func TestMain(m *testing.M) {
 	if err != nil {
 		panic(err)
 	}
-	ds := datastore.NewDatastores(myClient)
+	blobDir, err := os.MkdirTemp("", "attachments")
+	if err != nil {
+		panic(err)
+	}
+	ds := datastore.NewDatastores(myClient, blobstore.NewFileStore(blobDir))
 	TestDataStore = ds
 	TestPostgresClient = myClient
 	TestRouter = NewRouter(ds, nil)
 	result := m.Run()
+	os.RemoveAll(blobDir)
 	os.Exit(result)
 }
 
@@ -46,12 +52,30 @@ func setupDatastores(ds *datastore.Datastores) {
 	if err := TeardownTestTransactionDebitsCredits(ds.PGClient()); err != nil {
 		log.Panicln(err)
 	}
+	if err := TeardownTestTransactions(ds.PGClient()); err != nil {
+		log.Panicln(err)
+	}
 	if err := TeardownTestAccounts(ds.PGClient()); err != nil {
 		log.Panicln(err)
 	}
 	if err := TeardownTestReports(ds.PGClient()); err != nil {
 		log.Panicln(err)
 	}
+	if err := TeardownTestSettings(ds.PGClient()); err != nil {
+		log.Panicln(err)
+	}
+	if err := TeardownTestReportTemplates(ds.PGClient()); err != nil {
+		log.Panicln(err)
+	}
+	if err := TeardownTestCategorizationRules(ds.PGClient()); err != nil {
+		log.Panicln(err)
+	}
+	if err := TeardownTestScheduledTransactions(ds.PGClient()); err != nil {
+		log.Panicln(err)
+	}
+	if err := TeardownTestMemorizedTransactions(ds.PGClient()); err != nil {
+		log.Panicln(err)
+	}
 }
 
 // TeardownTestTransactionDebitsCredits truncates the transactions_accounts table
@@ -60,6 +84,12 @@ func TeardownTestTransactionDebitsCredits(client *sqlx.DB) (err error) {
 	return
 }
 
+// TeardownTestTransactions truncates the transaction_main table
+func TeardownTestTransactions(client *sqlx.DB) (err error) {
+	_, err = client.Exec("TRUNCATE TABLE transaction_main CASCADE;")
+	return
+}
+
 // TeardownTestAccounts truncates the transactions_accounts table
 func TeardownTestAccounts(client *sqlx.DB) (err error) {
 	_, err = client.Exec("TRUNCATE TABLE transaction_accounts CASCADE;")
@@ -72,6 +102,36 @@ func TeardownTestReports(client *sqlx.DB) (err error) {
 	return
 }
 
+// TeardownTestSettings truncates the settings table
+func TeardownTestSettings(client *sqlx.DB) (err error) {
+	_, err = client.Exec("TRUNCATE TABLE settings CASCADE;")
+	return
+}
+
+// TeardownTestReportTemplates truncates the report_templates table
+func TeardownTestReportTemplates(client *sqlx.DB) (err error) {
+	_, err = client.Exec("TRUNCATE TABLE report_templates CASCADE;")
+	return
+}
+
+// TeardownTestCategorizationRules truncates the categorization_rules table
+func TeardownTestCategorizationRules(client *sqlx.DB) (err error) {
+	_, err = client.Exec("TRUNCATE TABLE categorization_rules CASCADE;")
+	return
+}
+
+// TeardownTestScheduledTransactions truncates the scheduled_transactions table
+func TeardownTestScheduledTransactions(client *sqlx.DB) (err error) {
+	_, err = client.Exec("TRUNCATE TABLE scheduled_transactions CASCADE;")
+	return
+}
+
+// TeardownTestMemorizedTransactions truncates the memorized_transactions table
+func TeardownTestMemorizedTransactions(client *sqlx.DB) (err error) {
+	_, err = client.Exec("TRUNCATE TABLE memorized_transactions CASCADE;")
+	return
+}
+
 // TableTest represents the methods required to run table tests.
 type TableTest interface {
 	Exec()
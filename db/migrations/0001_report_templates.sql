@@ -0,0 +1,7 @@
+-- user defined text/template and html/template layouts for report output and account statements
+CREATE TYPE report_template_type AS ENUM ('TEXT','HTML');
+CREATE TABLE IF NOT EXISTS report_templates (
+          template_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+          template_name varchar(250) NOT NULL CHECK (template_name <> '') UNIQUE,
+          template_type report_template_type NOT NULL DEFAULT 'HTML',
+          template_body text NOT NULL DEFAULT '') ;
@@ -0,0 +1,2 @@
+-- account ledgers are ordered and paged by (transaction_date, transaction_id)
+CREATE INDEX IF NOT EXISTS transaction_main_date_id_idx ON transaction_main (transaction_date, transaction_id);
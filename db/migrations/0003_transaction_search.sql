@@ -0,0 +1,5 @@
+-- full-text search over the comment and reference of transactions
+ALTER TABLE transaction_main ADD COLUMN IF NOT EXISTS transaction_search tsvector
+    GENERATED ALWAYS AS (setweight(to_tsvector('english', COALESCE(transaction_comment, '')), 'A') ||
+                         setweight(to_tsvector('english', COALESCE(transaction_reference, '')), 'B')) STORED;
+CREATE INDEX IF NOT EXISTS transaction_main_search_idx ON transaction_main USING GIN (transaction_search);
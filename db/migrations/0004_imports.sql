@@ -0,0 +1,22 @@
+-- statement imports, lines are previewed and then posted as transactions against an offset account
+CREATE TYPE import_status_type AS ENUM ('PREVIEW','POSTED','CANCELLED');
+CREATE TABLE IF NOT EXISTS imports (
+          import_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+          account_id integer NOT NULL REFERENCES transaction_accounts(account_id),
+          offset_account_id integer NOT NULL REFERENCES transaction_accounts(account_id),
+          import_format varchar(16) NOT NULL,
+          import_filename varchar(250) NOT NULL DEFAULT '',
+          import_status import_status_type NOT NULL DEFAULT 'PREVIEW',
+          import_date TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()) ;
+CREATE TABLE IF NOT EXISTS import_lines (
+          import_line_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+          import_id integer NOT NULL REFERENCES imports(import_id) ON DELETE CASCADE,
+          external_id varchar(255) NOT NULL DEFAULT '',
+          line_date TIMESTAMP WITH TIME ZONE NOT NULL,
+          line_amount bigint NOT NULL CHECK (line_amount <> 0),
+          line_comment varchar(250) NOT NULL CHECK (line_comment <> ''),
+          line_reference varchar(32) NOT NULL DEFAULT '',
+          is_duplicate bool NOT NULL DEFAULT FALSE,
+          transaction_id integer DEFAULT NULL REFERENCES transaction_main(transaction_id) ON DELETE SET NULL) ;
+CREATE INDEX IF NOT EXISTS import_lines_import_id_idx ON import_lines (import_id);
+CREATE INDEX IF NOT EXISTS import_lines_external_id_idx ON import_lines (external_id);
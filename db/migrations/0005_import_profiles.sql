@@ -0,0 +1,12 @@
+-- saved column mappings for CSV statement imports, and the rows of an import that could not be read
+CREATE TABLE IF NOT EXISTS import_profiles (
+          profile_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+          profile_name varchar(250) NOT NULL CHECK (profile_name <> '') UNIQUE,
+          offset_account_id integer DEFAULT NULL REFERENCES transaction_accounts(account_id) ON DELETE SET NULL,
+          profile_body JSONB NOT NULL) ;
+CREATE TABLE IF NOT EXISTS import_errors (
+          import_error_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+          import_id integer NOT NULL REFERENCES imports(import_id) ON DELETE CASCADE,
+          row_number integer NOT NULL,
+          error_message text NOT NULL) ;
+CREATE INDEX IF NOT EXISTS import_errors_import_id_idx ON import_errors (import_id);
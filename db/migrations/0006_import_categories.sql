@@ -0,0 +1,8 @@
+-- categories and splits of imported lines, categories are mapped to accounts under category_parent_id
+ALTER TABLE imports
+    ADD COLUMN IF NOT EXISTS category_parent_id integer DEFAULT NULL
+        REFERENCES transaction_accounts(account_id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS import_categories JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE import_lines
+    ADD COLUMN IF NOT EXISTS line_category varchar(250) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS line_splits JSONB NOT NULL DEFAULT '[]';
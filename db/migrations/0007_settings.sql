@@ -0,0 +1,4 @@
+-- named ledger wide settings, they are carried in ledger archives along with the reports
+CREATE TABLE IF NOT EXISTS settings (
+          setting_name varchar(100) PRIMARY KEY CHECK (setting_name <> ''),
+          setting_value text NOT NULL DEFAULT '') ;
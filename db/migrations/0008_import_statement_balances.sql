@@ -0,0 +1,8 @@
+-- opening and closing balances of imported statements, and the value date of their lines, for reconciliation
+ALTER TABLE imports
+    ADD COLUMN IF NOT EXISTS opening_balance bigint DEFAULT NULL,
+    ADD COLUMN IF NOT EXISTS opening_balance_date TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+    ADD COLUMN IF NOT EXISTS closing_balance bigint DEFAULT NULL,
+    ADD COLUMN IF NOT EXISTS closing_balance_date TIMESTAMP WITH TIME ZONE DEFAULT NULL;
+ALTER TABLE import_lines
+    ADD COLUMN IF NOT EXISTS line_value_date TIMESTAMP WITH TIME ZONE DEFAULT NULL;
@@ -0,0 +1,13 @@
+-- suspected duplicate transactions, a dismissed pair is kept so it is not flagged again
+CREATE TYPE duplicate_status_type AS ENUM ('SUSPECTED','DISMISSED');
+CREATE TABLE IF NOT EXISTS transaction_duplicates (
+          duplicate_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+          transaction_id integer NOT NULL REFERENCES transaction_main(transaction_id) ON DELETE CASCADE,
+          duplicate_transaction_id integer NOT NULL REFERENCES transaction_main(transaction_id) ON DELETE CASCADE,
+          account_id integer NOT NULL REFERENCES transaction_accounts(account_id) ON DELETE CASCADE,
+          duplicate_status duplicate_status_type NOT NULL DEFAULT 'SUSPECTED',
+          detected_date TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+          CHECK (transaction_id < duplicate_transaction_id),
+          UNIQUE (transaction_id, duplicate_transaction_id)) ;
+CREATE INDEX IF NOT EXISTS transaction_duplicates_duplicate_transaction_id_idx
+          ON transaction_duplicates (duplicate_transaction_id);
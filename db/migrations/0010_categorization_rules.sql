@@ -0,0 +1,7 @@
+-- ordered rules that categorize imported and uncategorized transactions
+CREATE TABLE IF NOT EXISTS categorization_rules (
+          rule_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+          rule_name varchar(250) NOT NULL CHECK (rule_name <> '') UNIQUE,
+          rule_order integer NOT NULL DEFAULT 0,
+          is_enabled bool NOT NULL DEFAULT TRUE,
+          rule_body JSONB NOT NULL) ;
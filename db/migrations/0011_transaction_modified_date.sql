@@ -0,0 +1,2 @@
+-- when a transaction was last changed, so the account suggestions only learn again from the transactions that changed
+ALTER TABLE transaction_main ADD COLUMN IF NOT EXISTS transaction_modified_date TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
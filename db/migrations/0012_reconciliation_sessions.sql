@@ -0,0 +1,19 @@
+-- a statement being reconciled against an account, and the transactions ticked as cleared on it
+CREATE TYPE reconciliation_status_type AS ENUM ('OPEN','FINISHED');
+CREATE TABLE IF NOT EXISTS reconciliation_sessions (
+          session_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+          account_id integer NOT NULL REFERENCES transaction_accounts(account_id) ON DELETE CASCADE,
+          statement_date TIMESTAMP WITH TIME ZONE NOT NULL,
+          statement_balance bigint NOT NULL,
+          opening_balance bigint NOT NULL,
+          session_status reconciliation_status_type NOT NULL DEFAULT 'OPEN',
+          created_date TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+          finished_date TIMESTAMP WITH TIME ZONE DEFAULT NULL) ;
+CREATE UNIQUE INDEX IF NOT EXISTS reconciliation_sessions_open_account_id_idx
+          ON reconciliation_sessions (account_id) WHERE session_status = 'OPEN';
+CREATE TABLE IF NOT EXISTS reconciliation_session_transactions (
+          session_id integer NOT NULL REFERENCES reconciliation_sessions(session_id) ON DELETE CASCADE,
+          transaction_id integer NOT NULL REFERENCES transaction_main(transaction_id) ON DELETE CASCADE,
+          PRIMARY KEY (session_id, transaction_id)) ;
+CREATE INDEX IF NOT EXISTS reconciliation_session_transactions_transaction_id_idx
+          ON reconciliation_session_transactions (transaction_id);
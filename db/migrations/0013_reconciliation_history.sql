@@ -0,0 +1,14 @@
+-- what a finished reconciliation cleared and who finished it, kept as it was for the reconciliation report
+ALTER TABLE reconciliation_sessions
+          ADD COLUMN IF NOT EXISTS cleared_count integer NOT NULL DEFAULT 0,
+          ADD COLUMN IF NOT EXISTS cleared_balance bigint NOT NULL DEFAULT 0,
+          ADD COLUMN IF NOT EXISTS finished_by varchar(250) NOT NULL DEFAULT '';
+CREATE TABLE IF NOT EXISTS reconciliation_cleared_transactions (
+          session_id integer NOT NULL REFERENCES reconciliation_sessions(session_id) ON DELETE CASCADE,
+          transaction_id integer NOT NULL,
+          transaction_date TIMESTAMP WITH TIME ZONE NOT NULL,
+          transaction_comment varchar(250) NOT NULL,
+          transaction_reference varchar(32) NOT NULL DEFAULT '',
+          cleared_amount bigint NOT NULL,
+          transaction_modified_date TIMESTAMP WITH TIME ZONE NOT NULL,
+          PRIMARY KEY (session_id, transaction_id)) ;
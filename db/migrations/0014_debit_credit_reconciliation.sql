@@ -0,0 +1,12 @@
+-- reconciliation is kept on each debit/credit line, so one side of a transfer can be reconciled without the other.
+-- is_reconciled and transaction_reconcile_date on transaction_main are kept up to date from the lines.
+ALTER TABLE transaction_debit_credit
+          ADD COLUMN IF NOT EXISTS is_reconciled bool NOT NULL DEFAULT FALSE,
+          ADD COLUMN IF NOT EXISTS transaction_reconcile_date TIMESTAMP WITH TIME ZONE DEFAULT NULL;
+UPDATE transaction_debit_credit AS dc
+   SET is_reconciled = tm.is_reconciled,
+       transaction_reconcile_date = tm.transaction_reconcile_date
+  FROM transaction_main AS tm
+ WHERE tm.transaction_id = dc.transaction_id;
+CREATE INDEX IF NOT EXISTS transactions_debit_credit_account_reconciled_idx
+          ON transaction_debit_credit (account_id, is_reconciled);
@@ -0,0 +1,19 @@
+-- transactions posted on a recurrence rule, and the occurrences of each that were posted or skipped.
+-- an occurrence is claimed by inserting its row, so each one is posted at most once by however many schedulers run.
+CREATE TABLE IF NOT EXISTS scheduled_transactions (
+          scheduled_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+          scheduled_name varchar(250) NOT NULL CHECK (scheduled_name <> ''),
+          recurrence_rule varchar(250) NOT NULL,
+          start_date TIMESTAMP WITH TIME ZONE NOT NULL,
+          end_date TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+          is_enabled bool NOT NULL DEFAULT TRUE,
+          transaction_template JSONB NOT NULL) ;
+CREATE TYPE scheduled_occurrence_status_type AS ENUM ('POSTING','POSTED','SKIPPED');
+CREATE TABLE IF NOT EXISTS scheduled_occurrences (
+          scheduled_id integer NOT NULL REFERENCES scheduled_transactions(scheduled_id) ON DELETE CASCADE,
+          occurrence_date TIMESTAMP WITH TIME ZONE NOT NULL,
+          occurrence_status scheduled_occurrence_status_type NOT NULL,
+          transaction_id integer DEFAULT NULL REFERENCES transaction_main(transaction_id) ON DELETE SET NULL,
+          claimed_date TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+          PRIMARY KEY (scheduled_id, occurrence_date)) ;
+CREATE INDEX IF NOT EXISTS scheduled_occurrences_transaction_id_idx ON scheduled_occurrences (transaction_id);
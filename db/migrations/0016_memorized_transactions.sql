@@ -0,0 +1,6 @@
+-- memorized transactions are named templates of multi-line entries, the amounts of their lines are fixed, filled in
+-- when the template is used, a percent of an amount filled in, or what balances the other lines.
+CREATE TABLE IF NOT EXISTS memorized_transactions (
+          memorized_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+          memorized_name varchar(250) NOT NULL CHECK (memorized_name <> ''),
+          memorized_body JSONB NOT NULL) ;
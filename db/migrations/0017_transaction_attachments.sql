@@ -0,0 +1,12 @@
+-- receipts, invoices and other documents attached to transactions.  only the metadata is kept here, the files are
+-- kept in the blob store under storage_key and are deleted with their transaction by the api.
+CREATE TABLE IF NOT EXISTS transaction_attachments (
+          attachment_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+          transaction_id integer NOT NULL REFERENCES transaction_main(transaction_id) ON DELETE CASCADE,
+          file_name varchar(250) NOT NULL CHECK (file_name <> ''),
+          content_type varchar(250) NOT NULL,
+          file_size bigint NOT NULL CHECK (file_size >= 0),
+          file_sha256 char(64) NOT NULL,
+          storage_key varchar(250) NOT NULL UNIQUE,
+          created_date TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()) ;
+CREATE INDEX IF NOT EXISTS transaction_attachments_transaction_id_idx ON transaction_attachments (transaction_id);
@@ -0,0 +1,8 @@
+-- the day each schedule is posted from, moved past the occurrences as they are posted.  a schedule only posts the
+-- occurrences due from the day it was created unless its past ones are asked for, so the schedules already running
+-- are posted from today on.
+ALTER TABLE scheduled_transactions ADD COLUMN IF NOT EXISTS next_due TIMESTAMP WITH TIME ZONE DEFAULT NULL;
+UPDATE scheduled_transactions
+   SET next_due = GREATEST(start_date, date_trunc('day', NOW() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC')
+ WHERE next_due IS NULL;
+ALTER TABLE scheduled_transactions ALTER COLUMN next_due SET NOT NULL;
@@ -32,16 +32,25 @@ CREATE TABLE transaction_main (
     transaction_comment varchar(250) NOT NULL CHECK (transaction_comment <> ''),
     transaction_amount integer NOT NULL CHECK (transaction_amount > 0),
     transaction_reference varchar(32) DEFAULT NULL,
+    -- is_reconciled is set when any line is reconciled, transaction_reconcile_date is the latest line reconcile date
     is_reconciled bool NOT NULL default FALSE,
     transaction_reconcile_date TIMESTAMP WITH TIME ZONE DEFAULT NULL,
-    is_split bool NOT NULL default FALSE) ;
+    is_split bool NOT NULL default FALSE,
+    transaction_modified_date TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    transaction_search tsvector
+        GENERATED ALWAYS AS (setweight(to_tsvector('english', COALESCE(transaction_comment, '')), 'A') ||
+                             setweight(to_tsvector('english', COALESCE(transaction_reference, '')), 'B')) STORED) ;
+CREATE INDEX transaction_main_date_id_idx ON transaction_main (transaction_date, transaction_id);
+CREATE INDEX transaction_main_search_idx ON transaction_main USING GIN (transaction_search);
 
 CREATE TABLE transaction_debit_credit (
     transaction_dc_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
     account_id integer NOT NULL,
     transaction_id integer NOT NULL,
     transaction_dc_amount integer NOT NULL CHECK (transaction_dc_amount > 0),
-    debit_or_credit transaction_account_sign_type NOT NULL DEFAULT 'DEBIT') ;
+    debit_or_credit transaction_account_sign_type NOT NULL DEFAULT 'DEBIT',
+    is_reconciled bool NOT NULL default FALSE,
+    transaction_reconcile_date TIMESTAMP WITH TIME ZONE DEFAULT NULL) ;
 
 ALTER TABLE transaction_debit_credit
     ADD CONSTRAINT transactions_debit_credit_transaction_id_fkey FOREIGN KEY (transaction_id) REFERENCES transaction_main(transaction_id) ON DELETE CASCADE;
@@ -49,9 +58,139 @@ CREATE INDEX transactions_debit_credit_transaction_id_idx ON transaction_debit_c
 ALTER TABLE transaction_debit_credit
     ADD CONSTRAINT transactions_debit_credit_transaction_account_id_fkey FOREIGN KEY (account_id) REFERENCES transaction_accounts(account_id);
 CREATE INDEX transactions_debit_credit_transaction_account_idx ON transaction_debit_credit (account_id);
+CREATE INDEX transactions_debit_credit_account_reconciled_idx ON transaction_debit_credit (account_id, is_reconciled);
 
 
 CREATE TABLE reports (
           report_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
           report_name varchar(250) NOT NULL CHECK (report_name <> '') UNIQUE,
-          report_body JSONB NOT NULL) ;
\ No newline at end of file
+          report_body JSONB NOT NULL) ;
+CREATE TYPE report_template_type AS ENUM ('TEXT','HTML');
+CREATE TABLE report_templates (
+          template_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+          template_name varchar(250) NOT NULL CHECK (template_name <> '') UNIQUE,
+          template_type report_template_type NOT NULL DEFAULT 'HTML',
+          template_body text NOT NULL DEFAULT '') ;
+CREATE TYPE import_status_type AS ENUM ('PREVIEW','POSTED','CANCELLED');
+CREATE TABLE imports (
+          import_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+          account_id integer NOT NULL REFERENCES transaction_accounts(account_id),
+          offset_account_id integer NOT NULL REFERENCES transaction_accounts(account_id),
+          import_format varchar(16) NOT NULL,
+          import_filename varchar(250) NOT NULL DEFAULT '',
+          import_status import_status_type NOT NULL DEFAULT 'PREVIEW',
+          import_date TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+          category_parent_id integer DEFAULT NULL REFERENCES transaction_accounts(account_id) ON DELETE SET NULL,
+          import_categories JSONB NOT NULL DEFAULT '[]',
+          opening_balance bigint DEFAULT NULL,
+          opening_balance_date TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+          closing_balance bigint DEFAULT NULL,
+          closing_balance_date TIMESTAMP WITH TIME ZONE DEFAULT NULL) ;
+CREATE TABLE import_lines (
+          import_line_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+          import_id integer NOT NULL REFERENCES imports(import_id) ON DELETE CASCADE,
+          external_id varchar(255) NOT NULL DEFAULT '',
+          line_date TIMESTAMP WITH TIME ZONE NOT NULL,
+          line_amount bigint NOT NULL CHECK (line_amount <> 0),
+          line_comment varchar(250) NOT NULL CHECK (line_comment <> ''),
+          line_reference varchar(32) NOT NULL DEFAULT '',
+          is_duplicate bool NOT NULL DEFAULT FALSE,
+          transaction_id integer DEFAULT NULL REFERENCES transaction_main(transaction_id) ON DELETE SET NULL,
+          line_category varchar(250) NOT NULL DEFAULT '',
+          line_splits JSONB NOT NULL DEFAULT '[]',
+          line_value_date TIMESTAMP WITH TIME ZONE DEFAULT NULL) ;
+CREATE INDEX import_lines_import_id_idx ON import_lines (import_id);
+CREATE INDEX import_lines_external_id_idx ON import_lines (external_id);
+CREATE TABLE import_profiles (
+          profile_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+          profile_name varchar(250) NOT NULL CHECK (profile_name <> '') UNIQUE,
+          offset_account_id integer DEFAULT NULL REFERENCES transaction_accounts(account_id) ON DELETE SET NULL,
+          profile_body JSONB NOT NULL) ;
+CREATE TABLE import_errors (
+          import_error_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+          import_id integer NOT NULL REFERENCES imports(import_id) ON DELETE CASCADE,
+          row_number integer NOT NULL,
+          error_message text NOT NULL) ;
+CREATE INDEX import_errors_import_id_idx ON import_errors (import_id);
+CREATE TABLE settings (
+          setting_name varchar(100) PRIMARY KEY CHECK (setting_name <> ''),
+          setting_value text NOT NULL DEFAULT '') ;
+CREATE TYPE duplicate_status_type AS ENUM ('SUSPECTED','DISMISSED');
+CREATE TABLE transaction_duplicates (
+          duplicate_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+          transaction_id integer NOT NULL REFERENCES transaction_main(transaction_id) ON DELETE CASCADE,
+          duplicate_transaction_id integer NOT NULL REFERENCES transaction_main(transaction_id) ON DELETE CASCADE,
+          account_id integer NOT NULL REFERENCES transaction_accounts(account_id) ON DELETE CASCADE,
+          duplicate_status duplicate_status_type NOT NULL DEFAULT 'SUSPECTED',
+          detected_date TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+          CHECK (transaction_id < duplicate_transaction_id),
+          UNIQUE (transaction_id, duplicate_transaction_id)) ;
+CREATE INDEX transaction_duplicates_duplicate_transaction_id_idx ON transaction_duplicates (duplicate_transaction_id);
+CREATE TABLE categorization_rules (
+          rule_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+          rule_name varchar(250) NOT NULL CHECK (rule_name <> '') UNIQUE,
+          rule_order integer NOT NULL DEFAULT 0,
+          is_enabled bool NOT NULL DEFAULT TRUE,
+          rule_body JSONB NOT NULL) ;
+CREATE TYPE reconciliation_status_type AS ENUM ('OPEN','FINISHED');
+CREATE TABLE reconciliation_sessions (
+          session_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+          account_id integer NOT NULL REFERENCES transaction_accounts(account_id) ON DELETE CASCADE,
+          statement_date TIMESTAMP WITH TIME ZONE NOT NULL,
+          statement_balance bigint NOT NULL,
+          opening_balance bigint NOT NULL,
+          session_status reconciliation_status_type NOT NULL DEFAULT 'OPEN',
+          created_date TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+          finished_date TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+          cleared_count integer NOT NULL DEFAULT 0,
+          cleared_balance bigint NOT NULL DEFAULT 0,
+          finished_by varchar(250) NOT NULL DEFAULT '') ;
+CREATE UNIQUE INDEX reconciliation_sessions_open_account_id_idx
+          ON reconciliation_sessions (account_id) WHERE session_status = 'OPEN';
+CREATE TABLE reconciliation_session_transactions (
+          session_id integer NOT NULL REFERENCES reconciliation_sessions(session_id) ON DELETE CASCADE,
+          transaction_id integer NOT NULL REFERENCES transaction_main(transaction_id) ON DELETE CASCADE,
+          PRIMARY KEY (session_id, transaction_id)) ;
+CREATE INDEX reconciliation_session_transactions_transaction_id_idx
+          ON reconciliation_session_transactions (transaction_id);
+CREATE TABLE reconciliation_cleared_transactions (
+          session_id integer NOT NULL REFERENCES reconciliation_sessions(session_id) ON DELETE CASCADE,
+          transaction_id integer NOT NULL,
+          transaction_date TIMESTAMP WITH TIME ZONE NOT NULL,
+          transaction_comment varchar(250) NOT NULL,
+          transaction_reference varchar(32) NOT NULL DEFAULT '',
+          cleared_amount bigint NOT NULL,
+          transaction_modified_date TIMESTAMP WITH TIME ZONE NOT NULL,
+          PRIMARY KEY (session_id, transaction_id)) ;
+CREATE TABLE scheduled_transactions (
+          scheduled_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+          scheduled_name varchar(250) NOT NULL CHECK (scheduled_name <> ''),
+          recurrence_rule varchar(250) NOT NULL,
+          start_date TIMESTAMP WITH TIME ZONE NOT NULL,
+          end_date TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+          is_enabled bool NOT NULL DEFAULT TRUE,
+          transaction_template JSONB NOT NULL,
+          next_due TIMESTAMP WITH TIME ZONE NOT NULL) ;
+CREATE TYPE scheduled_occurrence_status_type AS ENUM ('POSTING','POSTED','SKIPPED');
+CREATE TABLE scheduled_occurrences (
+          scheduled_id integer NOT NULL REFERENCES scheduled_transactions(scheduled_id) ON DELETE CASCADE,
+          occurrence_date TIMESTAMP WITH TIME ZONE NOT NULL,
+          occurrence_status scheduled_occurrence_status_type NOT NULL,
+          transaction_id integer DEFAULT NULL REFERENCES transaction_main(transaction_id) ON DELETE SET NULL,
+          claimed_date TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+          PRIMARY KEY (scheduled_id, occurrence_date)) ;
+CREATE INDEX scheduled_occurrences_transaction_id_idx ON scheduled_occurrences (transaction_id);
+CREATE TABLE memorized_transactions (
+          memorized_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+          memorized_name varchar(250) NOT NULL CHECK (memorized_name <> ''),
+          memorized_body JSONB NOT NULL) ;
+CREATE TABLE transaction_attachments (
+          attachment_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+          transaction_id integer NOT NULL REFERENCES transaction_main(transaction_id) ON DELETE CASCADE,
+          file_name varchar(250) NOT NULL CHECK (file_name <> ''),
+          content_type varchar(250) NOT NULL,
+          file_size bigint NOT NULL CHECK (file_size >= 0),
+          file_sha256 char(64) NOT NULL,
+          storage_key varchar(250) NOT NULL UNIQUE,
+          created_date TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()) ;
+CREATE INDEX transaction_attachments_transaction_id_idx ON transaction_attachments (transaction_id);